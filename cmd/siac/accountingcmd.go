@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	accountingCmd = &cobra.Command{
+		Use:   "accounting",
+		Short: "Print the accounting information of the node",
+		Long: `Print the current accounting information of the node as well as the
+persisted accounting history. The history can be limited to a time range with
+the --start and --end flags, which take Unix timestamps. Use the --csv flag to
+print the history in CSV format instead.`,
+		Run: wrap(accountingcmd),
+	}
+)
+
+// accountingcsvheader is the header row of the CSV output of the accounting
+// command.
+var accountingcsvheader = []string{
+	"timestamp",
+	"wallet_confirmed_siacoin_balance",
+	"wallet_confirmed_siafund_balance",
+	"renter_unspent_unallocated",
+	"renter_withheld_funds",
+}
+
+// accountingcmd is the handler for the command `siac accounting`.
+// Prints the current accounting information and the accounting history.
+func accountingcmd() {
+	ag, err := httpClient.AccountingGet(accountingStart, accountingEnd)
+	if err != nil {
+		die("Could not get accounting information:", err)
+	}
+
+	// Print the CSV output if requested. The current accounting information
+	// is included as the last row if it's within the requested range.
+	if accountingCSV {
+		infos := accountingCSVRows(ag.History, ag.Current, accountingStart, accountingEnd)
+		err = writeAccountingCSV(os.Stdout, infos)
+		if err != nil {
+			die("Could not write CSV output:", err)
+		}
+		return
+	}
+
+	fmt.Printf(`Current Accounting Information:
+  Wallet:
+    Confirmed Siacoin Balance: %v
+    Confirmed Siafund Balance: %v SF
+  Renter:
+    Unspent Unallocated:       %v
+    Withheld Funds:            %v
+`, currencyUnits(ag.Current.Wallet.ConfirmedSiacoinBalance), ag.Current.Wallet.ConfirmedSiafundBalance,
+		currencyUnits(ag.Current.Renter.UnspentUnallocated), currencyUnits(ag.Current.Renter.WithheldFunds))
+
+	if len(ag.History) == 0 {
+		fmt.Println("\nNo accounting history in the requested range.")
+		return
+	}
+	fmt.Println("\nAccounting History:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Time\tSiacoin Balance\tSiafund Balance\tUnspent Unallocated\tWithheld Funds")
+	for _, ai := range ag.History {
+		fmt.Fprintf(w, "  %v\t%v\t%v SF\t%v\t%v\n", time.Unix(ai.Timestamp, 0).Format(time.RFC3339),
+			currencyUnits(ai.Wallet.ConfirmedSiacoinBalance), ai.Wallet.ConfirmedSiafundBalance,
+			currencyUnits(ai.Renter.UnspentUnallocated), currencyUnits(ai.Renter.WithheldFunds))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// accountingCSVRows returns the accounting information which is written to
+// the CSV output. The current accounting information is appended to the
+// history if its timestamp is within the requested range.
+func accountingCSVRows(history []modules.AccountingInfo, current modules.AccountingInfo, start, end int64) []modules.AccountingInfo {
+	if current.Timestamp < start || current.Timestamp > end {
+		return history
+	}
+	return append(history, current)
+}
+
+// writeAccountingCSV writes the provided accounting information to w in CSV
+// format. Currency values are written in hastings.
+func writeAccountingCSV(w io.Writer, infos []modules.AccountingInfo) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(accountingcsvheader)
+	if err != nil {
+		return err
+	}
+	for _, ai := range infos {
+		err = cw.Write([]string{
+			strconv.FormatInt(ai.Timestamp, 10),
+			ai.Wallet.ConfirmedSiacoinBalance.String(),
+			ai.Wallet.ConfirmedSiafundBalance.String(),
+			ai.Renter.UnspentUnallocated.String(),
+			ai.Renter.WithheldFunds.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestWriteAccountingCSV probes the writeAccountingCSV function
+func TestWriteAccountingCSV(t *testing.T) {
+	infos := []modules.AccountingInfo{
+		{
+			Renter: modules.RenterAccounting{
+				UnspentUnallocated: types.NewCurrency64(3),
+				WithheldFunds:      types.NewCurrency64(4),
+			},
+			Wallet: modules.WalletAccounting{
+				ConfirmedSiacoinBalance: types.NewCurrency64(1),
+				ConfirmedSiafundBalance: types.NewCurrency64(2),
+			},
+			Timestamp: 100,
+		},
+		{
+			Timestamp: 200,
+		},
+	}
+
+	// Write the CSV
+	var buf bytes.Buffer
+	err := writeAccountingCSV(&buf, infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read it back
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(infos)+1 {
+		t.Fatalf("expected %v records, got %v", len(infos)+1, len(records))
+	}
+	if len(records[0]) != len(accountingcsvheader) {
+		t.Fatal("header has wrong number of columns", records[0])
+	}
+	expected := []string{"100", "1", "2", "3", "4"}
+	for i, field := range records[1] {
+		if field != expected[i] {
+			t.Errorf("column %v: expected %v, got %v", i, expected[i], field)
+		}
+	}
+	expected = []string{"200", "0", "0", "0", "0"}
+	for i, field := range records[2] {
+		if field != expected[i] {
+			t.Errorf("column %v: expected %v, got %v", i, expected[i], field)
+		}
+	}
+}
+
+// TestAccountingCSVRows probes the accountingCSVRows function
+func TestAccountingCSVRows(t *testing.T) {
+	history := []modules.AccountingInfo{{Timestamp: 100}, {Timestamp: 200}}
+	current := modules.AccountingInfo{Timestamp: 300}
+
+	// The current accounting information is included if it's in the range
+	rows := accountingCSVRows(history, current, 0, math.MaxInt64)
+	if len(rows) != 3 || rows[2].Timestamp != current.Timestamp {
+		t.Fatal("current accounting information should be included", rows)
+	}
+	rows = accountingCSVRows(nil, current, 300, 300)
+	if len(rows) != 1 || rows[0].Timestamp != current.Timestamp {
+		t.Fatal("current accounting information should be included", rows)
+	}
+
+	// It's left out if it's outside of the range
+	rows = accountingCSVRows(history, current, 0, 200)
+	if len(rows) != 2 {
+		t.Fatal("current accounting information shouldn't be included", rows)
+	}
+	rows = accountingCSVRows(nil, current, 400, math.MaxInt64)
+	if len(rows) != 0 {
+		t.Fatal("current accounting information shouldn't be included", rows)
+	}
+}
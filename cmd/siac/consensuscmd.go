@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -17,8 +19,67 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Perform consensus snapshot actions",
+		Long:  "Export snapshots of the consensus state, which can be used to bootstrap new nodes.",
+		Run:   consensussnapshotcmd,
+	}
+
+	consensusSnapshotExportCmd = &cobra.Command{
+		Use:   "export [filename]",
+		Short: "Export a consensus snapshot",
+		Long: `Export a snapshot of the consensus state to a file. The snapshot is taken
+at the current height, or at the height of the --height flag. A new node can be
+bootstrapped from the snapshot by starting siad with the --bootstrap-snapshot
+and --bootstrap-snapshot-id flags. The snapshot id is the id of the block at the
+snapshot height and should be verified against a trusted source.
+
+The consensus state of a snapshot can't be verified by the importing node, only
+import snapshots from nodes you trust.`,
+		Run: wrap(consensussnapshotexportcmd),
+	}
 )
 
+// consensussnapshotcmd is the handler for the command `siac consensus
+// snapshot`.
+func consensussnapshotcmd(cmd *cobra.Command, args []string) {
+	_ = cmd.UsageFunc()(cmd)
+	os.Exit(exitCodeUsage)
+}
+
+// consensussnapshotexportcmd is the handler for the command `siac consensus
+// snapshot export [filename]`. Writes a snapshot of the consensus state to the
+// file.
+func consensussnapshotexportcmd(filename string) {
+	height := types.BlockHeight(consensusSnapshotHeight)
+	if height == 0 {
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current consensus state:", err)
+		}
+		height = cg.Height
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		die("Could not create snapshot file:", err)
+	}
+	err = httpClient.ConsensusSnapshotGet(height, f)
+	if err = errors.Compose(err, f.Close()); err != nil {
+		os.Remove(filename)
+		die("Could not export snapshot:", err)
+	}
+	cbg, err := httpClient.ConsensusBlocksHeightGet(height)
+	if err != nil {
+		die("Could not get snapshot block:", err)
+	}
+	fmt.Printf(`Exported consensus snapshot to %v
+Height:      %v
+Snapshot ID: %v
+`, abs(filename), height, cbg.ID)
+}
+
 // consensuscmd is the handler for the command `siac consensus`.
 // Prints the current state of consensus.
 func consensuscmd() {
@@ -3,12 +3,16 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
 )
 
 var (
@@ -33,6 +37,38 @@ var (
 		Run:   wrap(updatecheckcmd),
 	}
 
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Perform daemon actions",
+		Long:  "Perform daemon actions such as managing the API tokens.",
+		Run:   daemoncmd,
+	}
+
+	daemonTokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "List the API tokens",
+		Long: `List the API tokens of the daemon and the scopes they were granted.
+API tokens can be used instead of the API password to authenticate with the
+daemon, either as the HTTP basic auth password or as a bearer token.`,
+		Run: wrap(daemontokenscmd),
+	}
+
+	daemonTokensCreateCmd = &cobra.Command{
+		Use:   "create [name] [scopes]",
+		Short: "Create an API token",
+		Long: `Create an API token with the given name and a comma separated list of
+scopes. The token is only displayed once. Available scopes:
+` + strings.Join(api.APIScopes, ", "),
+		Run: wrap(daemontokenscreatecmd),
+	}
+
+	daemonTokensRevokeCmd = &cobra.Command{
+		Use:   "revoke [name]",
+		Short: "Revoke an API token",
+		Long:  "Revoke the API token with the given name.",
+		Run:   wrap(daemontokensrevokecmd),
+	}
+
 	globalRatelimitCmd = &cobra.Command{
 		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
 		Short: "set the global maxdownloadspeed and maxuploadspeed",
@@ -138,6 +174,52 @@ func alertscmd() {
 	}
 }
 
+// daemoncmd displays the usage info for the command.
+func daemoncmd(cmd *cobra.Command, args []string) {
+	_ = cmd.UsageFunc()(cmd)
+	os.Exit(exitCodeUsage)
+}
+
+// daemontokenscmd lists the API tokens of the daemon.
+func daemontokenscmd() {
+	dtg, err := httpClient.DaemonTokensGet()
+	if err != nil {
+		die("Could not get API tokens:", err)
+	}
+	if len(dtg.Tokens) == 0 {
+		fmt.Println("No API tokens.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tCreated\tScopes")
+	for _, token := range dtg.Tokens {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", token.Name, time.Unix(token.CreatedAt, 0).Format(time.RFC3339), strings.Join(token.Scopes, ","))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// daemontokenscreatecmd creates a new API token.
+func daemontokenscreatecmd(name, scopes string) {
+	dtcp, err := httpClient.DaemonTokensCreatePost(name, strings.Split(scopes, ","))
+	if err != nil {
+		die("Could not create API token:", err)
+	}
+	fmt.Printf("Created API token %q with scopes %v.\n", dtcp.Name, strings.Join(dtcp.Scopes, ","))
+	fmt.Println("Store the token now, it can't be displayed again:")
+	fmt.Println(dtcp.Token)
+}
+
+// daemontokensrevokecmd revokes an API token.
+func daemontokensrevokecmd(name string) {
+	err := httpClient.DaemonTokensRevokePost(name)
+	if err != nil {
+		die("Could not revoke API token:", err)
+	}
+	fmt.Printf("Revoked API token %q.\n", name)
+}
+
 // profilecmd displays the usage info for the command.
 func profilecmd(cmd *cobra.Command, args []string) {
 	_ = cmd.UsageFunc()(cmd)
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -109,8 +114,19 @@ Available output types:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, migrate, or tier a storage folder",
+		Long:  "Add, remove, resize, migrate, or tier a storage folder.",
+	}
+
+	hostFolderMigrateCmd = &cobra.Command{
+		Use:   "migrate [path] [newpath]",
+		Short: "Move a storage folder to a new path",
+		Long: `Move all data of a storage folder to a new path, typically on a new disk,
+while the host keeps serving the data. If there is no storage folder at the new
+path yet, one with the same size is added. The old storage folder is removed
+once all data has been moved. If any data can't be moved, the old storage
+folder is kept and the migration can be resumed by running the command again.`,
+		Run: wrap(hostfoldermigratecmd),
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -131,6 +147,100 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostFolderTierCmd = &cobra.Command{
+		Use:   "tier [path] [fast|standard]",
+		Short: "Move a storage folder to the fast tier or the standard tier",
+		Long: `Move a storage folder to the fast tier or the standard tier. Fast tier
+storage folders, typically on SSDs, hold a read cache of the most frequently
+downloaded data instead of data of their own. The data of a storage folder is
+distributed across the other storage folders before it's moved to the fast
+tier.`,
+		Run: wrap(hostfoldertiercmd),
+	}
+
+	hostPolicyAllowCmd = &cobra.Command{
+		Use:   "allow [pubkey]",
+		Short: "Add a renter to the allowlist",
+		Long: `Add a renter, identified by its identity key, to the host's allowlist. The
+renter reports its identity key as "identity" on the /renter endpoint. Once the
+allowlist contains a renter, only renters on the allowlist can form contracts
+with the host and use its resources.`,
+		Run: wrap(hostpolicyallowcmd),
+	}
+
+	hostPolicyCmd = &cobra.Command{
+		Use:   "policy",
+		Short: "Show or modify the host's renter policy",
+		Long: `Show the host's renter policy and the resources used by the renters with
+active contracts.
+
+The policy restricts which renters are served by the host through an allowlist
+and a denylist of renter identity keys, and limits the data stored, the
+bandwidth used per period and the collateral locked on behalf of a single renter
+across all of its contracts. New contracts of renters which don't prove their
+identity are rejected while the policy restricts the renters. The policy applies
+to new contracts, renewals, uploads and account fundings. Existing contracts can
+still be locked and downloaded from.`,
+		Run: wrap(hostpolicycmd),
+	}
+
+	hostPolicyDenyCmd = &cobra.Command{
+		Use:   "deny [pubkey]",
+		Short: "Add a renter to the denylist",
+		Long: `Add a renter, identified by its identity key, to the host's denylist. The
+renter reports its identity key as "identity" on the /renter endpoint. Renters
+on the denylist can't form or renew contracts, upload data or fund ephemeral
+accounts on the host. They can still download the data of their existing
+contracts.`,
+		Run: wrap(hostpolicydenycmd),
+	}
+
+	hostPolicyLimitsCmd = &cobra.Command{
+		Use:   "limits",
+		Short: "Set the per-renter limits of the host's policy",
+		Long: `Set the limits which apply to every renter. Only the provided limits are
+changed, a limit of 0 removes it.
+
+Available flags:
+     --max-storage:      filesize
+     --max-bandwidth:    filesize per period
+     --bandwidth-period: blocks
+     --max-collateral:   currency
+
+Durations must be specified in either blocks (b), hours (h), days (d), or weeks
+(w). Currency units can be specified, e.g. 10SC; run 'siac help wallet' for
+details.
+
+To limit every renter to 1 TB of data and 500 GB of bandwidth per week:
+	siac host policy limits --max-storage 1TB --max-bandwidth 500GB --bandwidth-period 1w
+`,
+		Run: wrap(hostpolicylimitscmd),
+	}
+
+	hostPolicyRemoveCmd = &cobra.Command{
+		Use:   "remove [pubkey]",
+		Short: "Remove a renter from the allowlist and the denylist",
+		Long:  "Remove a renter from both the allowlist and the denylist of the host's policy.",
+		Run:   wrap(hostpolicyremovecmd),
+	}
+
+	hostRevenueCmd = &cobra.Command{
+		Use:   "revenue",
+		Short: "Show the host's revenue ledger",
+		Long: `Show the revenue realized and lost by the host's resolved storage
+obligations. Every entry contains the block height and time at which the
+obligation was resolved. The ledger can be limited to a time range with the
+--start and --end flags, which take Unix timestamps, and to a range of block
+heights with the --start-height and --end-height flags.
+
+Available output formats:
+     table: show the entries and their totals
+     csv:   export the entries in CSV format, currency values are in hastings
+     json:  export the entries in JSON format
+`,
+		Run: wrap(hostrevenuecmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -172,6 +282,9 @@ func hostcmd() {
 	// calculate total storage available and remaining
 	var totalstorage, storageremaining uint64
 	for _, folder := range sg.Folders {
+		if folder.FastTier {
+			continue
+		}
 		totalstorage += folder.Capacity
 		storageremaining += folder.CapacityRemaining
 	}
@@ -355,15 +468,31 @@ RPC Stats:
 		return
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tPath\n")
+	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tCorrupt Sectors\tTier\tPath\n")
 	for _, folder := range sg.Folders {
 		curSize := int64(folder.Capacity - folder.CapacityRemaining)
 		pctUsed := 100 * (float64(curSize) / float64(folder.Capacity))
-		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.Path)
+		tier := "standard"
+		if folder.FastTier {
+			tier = "fast"
+		}
+		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%v\t%s\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.CorruptSectors, tier, folder.Path)
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
+
+	// display the read cache on the fast tier
+	if sg.Cache.Capacity == 0 {
+		return
+	}
+	fmt.Printf(`
+Read Cache:
+  Cached:   %v / %v
+  Hits:     %v
+  Misses:   %v
+  Hit Rate: %.2f%%
+`, modules.FilesizeUnits(sg.Cache.Size), modules.FilesizeUnits(sg.Cache.Capacity), sg.Cache.Hits, sg.Cache.Misses, 100*sg.Cache.HitRate)
 }
 
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
@@ -483,6 +612,280 @@ func hostcontractcmd() {
 	}
 }
 
+// hostpolicycmd is the handler for the command `siac host policy`.
+// Prints the host's renter policy and the usage of the renters.
+func hostpolicycmd() {
+	hpg, err := httpClient.HostPolicyGet()
+	if err != nil {
+		die("Could not fetch host policy:", err)
+	}
+	p := hpg.Policy
+
+	limit := func(set bool, value string) string {
+		if !set {
+			return "none"
+		}
+		return value
+	}
+	fmt.Printf(`Renter Policy:
+  Max Storage:      %v
+  Max Bandwidth:    %v
+  Bandwidth Period: %v
+  Max Collateral:   %v
+`, limit(p.MaxStorage > 0, modules.FilesizeUnits(p.MaxStorage)),
+		limit(p.MaxBandwidth > 0, modules.FilesizeUnits(p.MaxBandwidth)),
+		limit(p.BandwidthPeriod > 0, fmt.Sprintf("%v blocks", p.BandwidthPeriod)),
+		limit(!p.MaxCollateral.IsZero(), currencyUnits(p.MaxCollateral)))
+
+	fmt.Println()
+	if len(p.Allowlist) == 0 {
+		fmt.Println("Allowlist: empty, all renters which are not denied are allowed")
+	} else {
+		fmt.Println("Allowlist:")
+		for _, spk := range p.Allowlist {
+			fmt.Println("  " + spk.String())
+		}
+	}
+	if len(p.Denylist) == 0 {
+		fmt.Println("Denylist: empty")
+	} else {
+		fmt.Println("Denylist:")
+		for _, spk := range p.Denylist {
+			fmt.Println("  " + spk.String())
+		}
+	}
+
+	if len(hpg.Usage) == 0 {
+		fmt.Println("\nNo renters with active contracts.")
+		return
+	}
+	fmt.Println("\nRenter Usage:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "  Renter\tContracts\tStorage\tBandwidth\tCollateral")
+	for _, u := range hpg.Usage {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", u.Renter, u.Contracts, modules.FilesizeUnits(u.Storage), modules.FilesizeUnits(u.Bandwidth), currencyUnits(u.Collateral))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
+// hostpolicyallowcmd adds a renter to the allowlist of the host's policy.
+func hostpolicyallowcmd(pubkey string) {
+	p, renter := hostPolicyRenter(pubkey)
+	p.Denylist = removeSiaPublicKey(p.Denylist, renter)
+	p.Allowlist = append(removeSiaPublicKey(p.Allowlist, renter), renter)
+	if err := httpClient.HostPolicyPost(p); err != nil {
+		die("Could not update host policy:", err)
+	}
+	fmt.Println("Added renter", pubkey, "to the allowlist")
+}
+
+// hostpolicydenycmd adds a renter to the denylist of the host's policy.
+func hostpolicydenycmd(pubkey string) {
+	p, renter := hostPolicyRenter(pubkey)
+	p.Allowlist = removeSiaPublicKey(p.Allowlist, renter)
+	p.Denylist = append(removeSiaPublicKey(p.Denylist, renter), renter)
+	if err := httpClient.HostPolicyPost(p); err != nil {
+		die("Could not update host policy:", err)
+	}
+	fmt.Println("Added renter", pubkey, "to the denylist")
+}
+
+// hostpolicyremovecmd removes a renter from the allowlist and the denylist of
+// the host's policy.
+func hostpolicyremovecmd(pubkey string) {
+	p, renter := hostPolicyRenter(pubkey)
+	p.Allowlist = removeSiaPublicKey(p.Allowlist, renter)
+	p.Denylist = removeSiaPublicKey(p.Denylist, renter)
+	if err := httpClient.HostPolicyPost(p); err != nil {
+		die("Could not update host policy:", err)
+	}
+	fmt.Println("Removed renter", pubkey, "from the policy")
+}
+
+// hostpolicylimitscmd sets the per-renter limits of the host's policy.
+func hostpolicylimitscmd() {
+	hpg, err := httpClient.HostPolicyGet()
+	if err != nil {
+		die("Could not fetch host policy:", err)
+	}
+	p := hpg.Policy
+
+	parseSize := func(name, value string) uint64 {
+		size, err := parseFilesize(value)
+		if err != nil {
+			die("Could not parse "+name+":", err)
+		}
+		n, err := strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			die("Could not parse "+name+":", err)
+		}
+		return n
+	}
+	if hostPolicyMaxStorage != "" {
+		p.MaxStorage = parseSize("max storage", hostPolicyMaxStorage)
+	}
+	if hostPolicyMaxBandwidth != "" {
+		p.MaxBandwidth = parseSize("max bandwidth", hostPolicyMaxBandwidth)
+	}
+	if hostPolicyBandwidthPeriod != "" {
+		period, err := parsePeriod(hostPolicyBandwidthPeriod)
+		if err != nil {
+			die("Could not parse bandwidth period:", err)
+		}
+		_, err = fmt.Sscan(period, &p.BandwidthPeriod)
+		if err != nil {
+			die("Could not parse bandwidth period:", err)
+		}
+	}
+	if hostPolicyMaxCollateral != "" {
+		hastings, err := types.ParseCurrency(hostPolicyMaxCollateral)
+		if err != nil {
+			die("Could not parse max collateral:", err)
+		}
+		_, err = fmt.Sscan(hastings, &p.MaxCollateral)
+		if err != nil {
+			die("Could not parse max collateral:", err)
+		}
+	}
+
+	if err := httpClient.HostPolicyPost(p); err != nil {
+		die("Could not update host policy:", err)
+	}
+	fmt.Println("Updated the limits of the host policy")
+}
+
+// hostPolicyRenter fetches the host's current policy and parses the public key
+// of a renter.
+func hostPolicyRenter(pubkey string) (modules.HostPolicy, types.SiaPublicKey) {
+	var renter types.SiaPublicKey
+	if err := renter.LoadString(pubkey); err != nil {
+		die("Could not parse renter public key:", err)
+	}
+	hpg, err := httpClient.HostPolicyGet()
+	if err != nil {
+		die("Could not fetch host policy:", err)
+	}
+	return hpg.Policy, renter
+}
+
+// removeSiaPublicKey returns the keys without the provided key.
+func removeSiaPublicKey(keys []types.SiaPublicKey, key types.SiaPublicKey) []types.SiaPublicKey {
+	var filtered []types.SiaPublicKey
+	for _, spk := range keys {
+		if !spk.Equals(key) {
+			filtered = append(filtered, spk)
+		}
+	}
+	return filtered
+}
+
+// hostrevenuecsvheader is the header row of the CSV output of the host revenue
+// command.
+var hostrevenuecsvheader = []string{
+	"obligation_id",
+	"status",
+	"block_height",
+	"timestamp",
+	"account_funding",
+	"contract_compensation",
+	"download_bandwidth_revenue",
+	"storage_revenue",
+	"upload_bandwidth_revenue",
+	"lost_revenue",
+	"lost_storage_collateral",
+	"transaction_fee_expenses",
+}
+
+// hostrevenuecmd is the handler for the command `siac host revenue`.
+// Prints or exports the host's revenue ledger.
+func hostrevenuecmd() {
+	hrg, err := httpClient.HostRevenueGet(modules.HostRevenueFilter{
+		Start:       hostRevenueStart,
+		End:         hostRevenueEnd,
+		StartHeight: types.BlockHeight(hostRevenueStartHeight),
+		EndHeight:   types.BlockHeight(hostRevenueEndHeight),
+	})
+	if err != nil {
+		die("Could not fetch host revenue ledger:", err)
+	}
+
+	switch hostRevenueFormat {
+	case "csv":
+		if err := writeHostRevenueCSV(os.Stdout, hrg.Entries); err != nil {
+			die("Could not write CSV output:", err)
+		}
+		return
+	case "json":
+		if err := json.NewEncoder(os.Stdout).Encode(hrg.Entries); err != nil {
+			die("Could not write JSON output:", err)
+		}
+		return
+	case "table":
+	default:
+		die("\"" + hostRevenueFormat + "\" is not a format")
+	}
+
+	if len(hrg.Entries) == 0 {
+		fmt.Println("No revenue entries in the requested range.")
+		return
+	}
+	var realized, lost, lostCollateral, fees types.Currency
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "Obligation Id\tObligation Status\tBlock Height\tTime\tRevenue\tLost Revenue\tLost Collateral\tTransaction Fees")
+	for _, e := range hrg.Entries {
+		revenue := e.AccountFunding.Add(e.ContractCompensation).Add(e.DownloadBandwidthRevenue).Add(e.StorageRevenue).Add(e.UploadBandwidthRevenue)
+		realized = realized.Add(revenue)
+		lost = lost.Add(e.LostRevenue)
+		lostCollateral = lostCollateral.Add(e.LostStorageCollateral)
+		fees = fees.Add(e.TransactionFeeExpenses)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%v\t%s\t%s\t%s\t%s\n", e.ObligationID, strings.TrimPrefix(e.Status, "obligation"), e.BlockHeight, time.Unix(e.Timestamp, 0).Format(time.RFC3339),
+			currencyUnits(revenue), currencyUnits(e.LostRevenue), currencyUnits(e.LostStorageCollateral), currencyUnits(e.TransactionFeeExpenses))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+	fmt.Printf(`
+Totals:
+  Revenue:          %v
+  Lost Revenue:     %v
+  Lost Collateral:  %v
+  Transaction Fees: %v
+`, currencyUnits(realized), currencyUnits(lost), currencyUnits(lostCollateral), currencyUnits(fees))
+}
+
+// writeHostRevenueCSV writes the provided entries of the host's revenue ledger
+// to w in CSV format. Currency values are written in hastings.
+func writeHostRevenueCSV(w io.Writer, entries []modules.HostRevenueEntry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(hostrevenuecsvheader)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err = cw.Write([]string{
+			e.ObligationID.String(),
+			e.Status,
+			strconv.FormatUint(uint64(e.BlockHeight), 10),
+			strconv.FormatInt(e.Timestamp, 10),
+			e.AccountFunding.String(),
+			e.ContractCompensation.String(),
+			e.DownloadBandwidthRevenue.String(),
+			e.StorageRevenue.String(),
+			e.UploadBandwidthRevenue.String(),
+			e.LostRevenue.String(),
+			e.LostStorageCollateral.String(),
+			e.TransactionFeeExpenses.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
@@ -531,6 +934,15 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldermigratecmd migrates a folder of the host to a new path.
+func hostfoldermigratecmd(path, newPath string) {
+	err := httpClient.HostStorageFoldersMigratePost(abs(path), abs(newPath))
+	if err != nil {
+		die("Could not migrate folder:", err)
+	}
+	fmt.Printf("Migrated folder %v to %v\n", path, newPath)
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	// Ask for confirm for dangerous --force flag
@@ -569,6 +981,23 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostfoldertiercmd moves a folder of the host to the fast or standard tier.
+func hostfoldertiercmd(path, tier string) {
+	var fastTier bool
+	switch tier {
+	case "fast":
+		fastTier = true
+	case "standard":
+	default:
+		die("Tier must be either 'fast' or 'standard'")
+	}
+	err := httpClient.HostStorageFoldersTierPost(abs(path), fastTier)
+	if err != nil {
+		die("Could not change the tier of the folder:", err)
+	}
+	fmt.Printf("Moved folder %v to the %v tier\n", path, tier)
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	var hash crypto.Hash
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestWriteHostRevenueCSV probes the writeHostRevenueCSV function
+func TestWriteHostRevenueCSV(t *testing.T) {
+	entries := []modules.HostRevenueEntry{
+		{
+			ObligationID:             types.FileContractID{1},
+			Status:                   "obligationSucceeded",
+			BlockHeight:              10,
+			Timestamp:                100,
+			AccountFunding:           types.NewCurrency64(1),
+			ContractCompensation:     types.NewCurrency64(2),
+			DownloadBandwidthRevenue: types.NewCurrency64(3),
+			StorageRevenue:           types.NewCurrency64(4),
+			UploadBandwidthRevenue:   types.NewCurrency64(5),
+			TransactionFeeExpenses:   types.NewCurrency64(6),
+		},
+		{
+			ObligationID:          types.FileContractID{2},
+			Status:                "obligationFailed",
+			BlockHeight:           20,
+			Timestamp:             200,
+			LostRevenue:           types.NewCurrency64(7),
+			LostStorageCollateral: types.NewCurrency64(8),
+		},
+	}
+
+	// Write the CSV
+	var buf bytes.Buffer
+	err := writeHostRevenueCSV(&buf, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read it back
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(entries)+1 {
+		t.Fatalf("expected %v records, got %v", len(entries)+1, len(records))
+	}
+	if len(records[0]) != len(hostrevenuecsvheader) {
+		t.Fatal("header has wrong number of columns", records[0])
+	}
+	expected := []string{types.FileContractID{1}.String(), "obligationSucceeded", "10", "100", "1", "2", "3", "4", "5", "0", "0", "6"}
+	for i, field := range records[1] {
+		if field != expected[i] {
+			t.Errorf("column %v: expected %v, got %v", i, expected[i], field)
+		}
+	}
+	expected = []string{types.FileContractID{2}.String(), "obligationFailed", "20", "200", "0", "0", "0", "0", "0", "7", "8", "0"}
+	for i, field := range records[2] {
+		if field != expected[i] {
+			t.Errorf("column %v: expected %v, got %v", i, expected[i], field)
+		}
+	}
+}
@@ -23,6 +23,14 @@ var (
 
 	// Module Specific Flags
 	//
+	// Accounting Flags
+	accountingCSV   bool  // Print the accounting history in CSV format
+	accountingEnd   int64 // Unix timestamp of the end of the accounting history
+	accountingStart int64 // Unix timestamp of the start of the accounting history
+
+	// Consensus Flags
+	consensusSnapshotHeight uint64 // block height of the exported consensus snapshot
+
 	// Daemon Flags
 	daemonStackOutputFile  string // The file that the stack trace will be written to
 	daemonCPUProfile       bool   // Indicates that the CPU profile should be started
@@ -31,8 +39,17 @@ var (
 	daemonTraceProfile     bool   // Indicates that the Trace profile should be started
 
 	// Host Flags
-	hostContractOutputType string // output type for host contracts
-	hostFolderRemoveForce  bool   // force folder remove
+	hostContractOutputType    string // output type for host contracts
+	hostFolderRemoveForce     bool   // force folder remove
+	hostPolicyBandwidthPeriod string // period of the per-renter bandwidth limit
+	hostPolicyMaxBandwidth    string // per-renter bandwidth limit
+	hostPolicyMaxCollateral   string // per-renter collateral limit
+	hostPolicyMaxStorage      string // per-renter storage limit
+	hostRevenueEnd            int64  // Unix timestamp of the end of the revenue ledger
+	hostRevenueEndHeight      uint64 // block height of the end of the revenue ledger
+	hostRevenueFormat         string // output format of the revenue ledger
+	hostRevenueStart          int64  // Unix timestamp of the start of the revenue ledger
+	hostRevenueStartHeight    uint64 // block height of the start of the revenue ledger
 
 	// Renter Flags
 	dataPieces                string // the number of data pieces a file should be uploaded with
@@ -44,10 +61,15 @@ var (
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
+	renterFuseMountReadOnly   bool   // Mount fuse with 'ReadOnly' set to true.
 	renterListRecursive       bool   // List files of folder recursively.
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
+	renterPolicyCipherType    string // The cipher type of a folder's policy.
+	renterPolicyHosts         string // The pinned hosts of a folder's policy.
+	renterRegistryEntryType   uint8  // The type of a registry entry to update.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
 	renterShowHistory         bool   // Show download history in addition to download queue.
+	renterUploadPack          bool   // Pack the small files of a folder into shared sectors.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -78,13 +100,20 @@ var (
 	dictionaryLanguage string // dictionary for seed utils
 
 	// Wallet Flags
-	initForce            bool   // destroy and re-encrypt the wallet on init if it already exists
-	initPassword         bool   // supply a custom password when creating a wallet
-	walletRawTxn         bool   // Encode/decode transactions in base64-encoded binary.
-	walletStartHeight    uint64 // Start height for transaction search.
-	walletEndHeight      uint64 // End height for transaction search.
-	walletTxnFeeIncluded bool   // include the fee in the balance being sent
-	insecureInput        bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
+	initForce              bool   // destroy and re-encrypt the wallet on init if it already exists
+	initPassword           bool   // supply a custom password when creating a wallet
+	walletRawTxn           bool   // Encode/decode transactions in base64-encoded binary.
+	walletStartHeight      uint64 // Start height for transaction search.
+	walletEndHeight        uint64 // End height for transaction search.
+	walletTxnFeeIncluded   bool   // include the fee in the balance being sent
+	walletMultisigUnused   bool   // the multisig address has never appeared in the blockchain
+	walletBumpMethod       string // method used to bump the fee of a transaction
+	walletBumpFee          string // additional fee per KB when bumping a transaction
+	walletSendInputs       string // comma separated output ids to spend when sending siacoins
+	walletScheduleLabel    string // label of a scheduled payment
+	walletScheduleInterval uint64 // number of blocks between recurring payments
+	walletScheduleCount    uint64 // number of recurring payments
+	insecureInput          bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
 )
 
 var (
@@ -289,7 +318,15 @@ func initCmds() *cobra.Command {
 	}
 
 	// create command tree (alphabetized by root command)
+	root.AddCommand(accountingCmd)
+	accountingCmd.Flags().BoolVarP(&accountingCSV, "csv", "", false, "Print the accounting history in CSV format")
+	accountingCmd.Flags().Int64Var(&accountingStart, "start", 0, "Unix timestamp of the start of the accounting history")
+	accountingCmd.Flags().Int64Var(&accountingEnd, "end", math.MaxInt64, "Unix timestamp of the end of the accounting history")
+
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
+	consensusSnapshotCmd.AddCommand(consensusSnapshotExportCmd)
+	consensusSnapshotExportCmd.Flags().Uint64Var(&consensusSnapshotHeight, "height", 0, "Block height of the snapshot, defaults to the current height")
 	root.AddCommand(jsonCmd)
 
 	root.AddCommand(gatewayCmd)
@@ -297,11 +334,21 @@ func initCmds() *cobra.Command {
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostPolicyCmd, hostRevenueCmd, hostSectorCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderMigrateCmd, hostFolderRemoveCmd, hostFolderResizeCmd, hostFolderTierCmd)
+	hostPolicyCmd.AddCommand(hostPolicyAllowCmd, hostPolicyDenyCmd, hostPolicyLimitsCmd, hostPolicyRemoveCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
+	hostPolicyLimitsCmd.Flags().StringVar(&hostPolicyMaxStorage, "max-storage", "", "Maximum amount of data stored for a single renter")
+	hostPolicyLimitsCmd.Flags().StringVar(&hostPolicyMaxBandwidth, "max-bandwidth", "", "Maximum bandwidth used by a single renter per period")
+	hostPolicyLimitsCmd.Flags().StringVar(&hostPolicyBandwidthPeriod, "bandwidth-period", "", "Length of the bandwidth period")
+	hostPolicyLimitsCmd.Flags().StringVar(&hostPolicyMaxCollateral, "max-collateral", "", "Maximum collateral locked for a single renter")
+	hostRevenueCmd.Flags().StringVarP(&hostRevenueFormat, "format", "f", "table", "Select output format")
+	hostRevenueCmd.Flags().Int64Var(&hostRevenueStart, "start", 0, "Unix timestamp of the start of the revenue ledger")
+	hostRevenueCmd.Flags().Int64Var(&hostRevenueEnd, "end", math.MaxInt64, "Unix timestamp of the end of the revenue ledger")
+	hostRevenueCmd.Flags().Uint64Var(&hostRevenueStartHeight, "start-height", 0, "Block height of the start of the revenue ledger")
+	hostRevenueCmd.Flags().Uint64Var(&hostRevenueEndHeight, "end-height", math.MaxUint64, "Block height of the end of the revenue ledger")
 
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbFiltermodeCmd, hostdbSetFiltermodeCmd, hostdbViewCmd)
@@ -312,15 +359,22 @@ func initCmds() *cobra.Command {
 
 	root.AddCommand(renterCmd)
 	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
-		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
+		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDedupCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
 		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterSetLocalPathCmd, renterSetRedundancyCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
+		renterHealthSummaryCmd, renterPolicyCmd, renterRegistryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
+	renterPolicyCmd.AddCommand(renterPolicySetCmd)
+	renterPolicySetCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces new files in the folder should be uploaded with")
+	renterPolicySetCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces new files in the folder should be uploaded with")
+	renterPolicySetCmd.Flags().StringVar(&renterPolicyCipherType, "cipher-type", "", "the cipher type new files in the folder should be encrypted with")
+	renterPolicySetCmd.Flags().StringVar(&renterPolicyHosts, "hosts", "", "comma separated list of host public keys new files in the folder should be pinned to")
+	renterRegistryCmd.AddCommand(renterRegistryReadCmd, renterRegistrySubscribeCmd, renterRegistryUpdateCmd)
+	renterRegistryUpdateCmd.Flags().Uint8Var(&renterRegistryEntryType, "type", uint8(modules.RegistryTypeWithoutPubkey), "The type of the registry entry")
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
@@ -335,6 +389,7 @@ func initCmds() *cobra.Command {
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadPack, "pack", false, "Pack the small files of a folder into shared sectors")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
 
@@ -355,9 +410,12 @@ func initCmds() *cobra.Command {
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
+	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountReadOnly, "read-only", "", false, "Mount the fuse directory in read-only mode")
 
 	// Daemon Commands
-	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
+	root.AddCommand(alertsCmd, daemonCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
+	daemonCmd.AddCommand(daemonTokensCmd)
+	daemonTokensCmd.AddCommand(daemonTokensCreateCmd, daemonTokensRevokeCmd)
 	profileCmd.AddCommand(profileStartCmd, profileStopCmd)
 	profileStartCmd.Flags().BoolVarP(&daemonCPUProfile, "cpu", "c", false, "Start the CPU profile")
 	profileStartCmd.Flags().BoolVarP(&daemonMemoryProfile, "memory", "m", false, "Start the Memory profile")
@@ -374,14 +432,25 @@ func initCmds() *cobra.Command {
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
-		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
-		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletBumpCmd, walletChangepasswordCmd,
+		walletFreezeCmd, walletInitCmd, walletInitSeedCmd, walletLabelCmd, walletLoadCmd, walletLockCmd, walletMultisigCmd, walletScheduleCmd,
+		walletSeedsCmd, walletSendCmd, walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnfreezeCmd, walletUnlockCmd, walletUnspentCmd)
+	walletBumpCmd.Flags().StringVar(&walletBumpMethod, "method", string(modules.FeeBumpReplace), "Method used to bump the fee, either rbf or cpfp")
+	walletBumpCmd.Flags().StringVar(&walletBumpFee, "fee", "", "Additional fee per KB, defaults to the recommended fee")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
-	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletMultisigCmd.AddCommand(walletMultisigAddCmd, walletMultisigBroadcastCmd, walletMultisigCombineCmd, walletMultisigPubkeyCmd,
+		walletMultisigRemoveCmd, walletMultisigSendCmd, walletMultisigSignCmd)
+	walletMultisigAddCmd.Flags().BoolVarP(&walletMultisigUnused, "unused", "", false, "Skip the blockchain rescan because the address has never been used")
+	walletMultisigRemoveCmd.Flags().BoolVarP(&walletMultisigUnused, "unused", "", false, "Skip the blockchain rescan because the address has never been used")
+	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd, walletSendTimelockedCmd)
+	walletScheduleCmd.AddCommand(walletScheduleAddCmd, walletScheduleRemoveCmd)
+	walletScheduleAddCmd.Flags().StringVar(&walletScheduleLabel, "label", "", "Label of the payment")
+	walletScheduleAddCmd.Flags().Uint64Var(&walletScheduleInterval, "interval", 0, "Send the payment again every interval blocks")
+	walletScheduleAddCmd.Flags().Uint64Var(&walletScheduleCount, "count", 0, "Number of recurring payments, 0 sends the payment until it is removed")
+	walletSendSiacoinsCmd.Flags().StringVar(&walletSendInputs, "inputs", "", "Comma separated list of output ids to spend, the change is sent to a new address")
 	walletSendSiacoinsCmd.Flags().BoolVarP(&walletTxnFeeIncluded, "fee-included", "", false, "Take the transaction fee out of the balance being submitted instead of the fee being additional")
 	walletUnlockCmd.Flags().BoolVarP(&insecureInput, "insecure-input", "", false, "Disable shoulder-surf protection (echoing passwords and seeds)")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
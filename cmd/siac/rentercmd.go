@@ -138,6 +138,16 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Run:   wrap(renterdownloadcancelcmd),
 	}
 
+	renterDedupCmd = &cobra.Command{
+		Use:   "dedup [true|false]",
+		Short: "Enable or disable the deduplication of uploads",
+		Long: `Enable or disable the deduplication of uploads. If enabled, chunks which
+are identical to an already uploaded chunk reference the existing chunk
+instead of being uploaded again. Only chunks uploaded while dedup is enabled
+are deduplicated.`,
+		Run: wrap(renterdedupcmd),
+	}
+
 	renterFilesDeleteCmd = &cobra.Command{
 		Use:     "delete [path]",
 		Aliases: []string{"rm"},
@@ -179,10 +189,10 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Use:   "mount [path] [siapath]",
 		Short: "Mount a Sia folder to your disk",
 		Long: `Mount a Sia folder to your disk. Applications will be able to see this folder
-as though it is a normal part of your filesystem.  Currently experimental, and
-read-only. When Sia is ready to support read-write fuse mounting, siac will be
-updated to mount in read-write mode as the default. If you must guarantee that
-read-only mode is used, you must use the API.`,
+as though it is a normal part of your filesystem.  Currently experimental. The
+folder is mounted in read-write mode by default. Files written to the mount are
+uploaded as they are written and existing files can only be replaced as a
+whole. Use the --read-only flag to mount the folder in read-only mode.`,
 		Run: wrap(renterfusemountcmd),
 	}
 
@@ -201,6 +211,15 @@ local path where the Sia folder is mounted.`,
 		Run:   wrap(rentersetlocalpathcmd),
 	}
 
+	renterSetRedundancyCmd = &cobra.Command{
+		Use:   "setredundancy [siapath] [datapieces] [paritypieces]",
+		Short: "Changes the redundancy of the file",
+		Long: `Changes the erasure code settings of an uploaded file. The file is re-encoded and
+re-uploaded in the background and replaced once the new version is fully
+uploaded.`,
+		Run: wrap(rentersetredundancycmd),
+	}
+
 	renterFilesUnstuckCmd = &cobra.Command{
 		Use:   "unstuckall",
 		Short: "Set all files to unstuck",
@@ -212,7 +231,8 @@ local path where the Sia folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the Sia network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. When uploading a folder, the --pack
+flag packs all files which are smaller than a sector into shared sectors to save storage.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
@@ -1893,10 +1913,6 @@ func renterfusecmd() {
 
 // renterfusemountcmd is the handler for the command `siac renter fuse mount [path] [siapath]`.
 func renterfusemountcmd(path, siaPathStr string) {
-	// TODO: Once read-write is supported on the backend, the 'true' flag can be
-	// set to 'false' - siac will support mounting read-write by default. Need
-	// to update the help string of the command to indicate that mounting will
-	// mount in read-write mode.
 	path = abs(path)
 	var siaPath modules.SiaPath
 	var err error
@@ -1909,7 +1925,7 @@ func renterfusemountcmd(path, siaPathStr string) {
 		}
 	}
 	opts := modules.MountOptions{
-		ReadOnly:   true,
+		ReadOnly:   renterFuseMountReadOnly,
 		AllowOther: renterFuseMountAllowOther,
 	}
 	err = httpClient.RenterFuseMount(path, siaPath, opts)
@@ -1946,6 +1962,29 @@ func rentersetlocalpathcmd(siapath, newlocalpath string) {
 	fmt.Printf("Updated %s localpath to %s\n", siapath, newlocalpath)
 }
 
+// rentersetredundancycmd is the handler for the command `siac renter
+// setredundancy [siapath] [datapieces] [paritypieces]`. It starts re-encoding
+// the file with the new erasure code settings.
+func rentersetredundancycmd(siapath, datapieces, paritypieces string) {
+	siaPath, err := modules.NewSiaPath(siapath)
+	if err != nil {
+		die("Couldn't parse Siapath:", err)
+	}
+	numDataPieces, err := strconv.ParseUint(datapieces, 10, 64)
+	if err != nil {
+		die("Could not parse data pieces:", err)
+	}
+	numParityPieces, err := strconv.ParseUint(paritypieces, 10, 64)
+	if err != nil {
+		die("Could not parse parity pieces:", err)
+	}
+	err = httpClient.RenterFileChangeRedundancyPost(siaPath, numDataPieces, numParityPieces)
+	if err != nil {
+		die("Could not change the redundancy of the file:", err)
+	}
+	fmt.Printf("Changing the redundancy of %s to %v data and %v parity pieces\n", siapath, numDataPieces, numParityPieces)
+}
+
 // renterfilesunstuckcmd is the handler for the command `siac renter
 // unstuckall`. Sets all files to unstuck.
 func renterfilesunstuckcmd() {
@@ -2037,6 +2076,7 @@ func renterfilesuploadcmd(source, path string) {
 			die("Nothing to upload.")
 		}
 		failed := 0
+		var batch []modules.BatchUploadFile
 		for _, file := range files {
 			fpath, _ := filepath.Rel(source, file)
 			fpath = filepath.Join(path, fpath)
@@ -2046,12 +2086,29 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse SiaPath:", err)
 			}
+			// Files which fit into a sector are packed if requested.
+			if renterUploadPack {
+				info, err := os.Stat(file)
+				if err == nil && uint64(info.Size()) <= modules.SectorSize {
+					batch = append(batch, modules.BatchUploadFile{Source: abs(file), SiaPath: fSiaPath})
+					continue
+				}
+			}
 			err = httpClient.RenterUploadPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
 			}
 		}
+		if len(batch) > 0 {
+			rub, err := httpClient.RenterUploadBatchPost(batch, uint64(numDataPieces), uint64(numParityPieces), false)
+			if err != nil {
+				failed += len(batch)
+				fmt.Printf("Could not upload packed files: %v\n", err)
+			} else {
+				fmt.Printf("Packed %d files into '%s'.\n", len(batch), rub.Pack)
+			}
+		}
 		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", len(files)-failed, len(files), path)
 	} else {
 		// single file
@@ -2191,6 +2248,24 @@ func renterratelimitcmd(downloadSpeedStr, uploadSpeedStr string) {
 	fmt.Println("Set renter maxdownloadspeed to ", downloadSpeedInt, " and maxuploadspeed to ", uploadSpeedInt)
 }
 
+// renterdedupcmd is the handler for the command `siac renter dedup
+// [true|false]`. It enables or disables the deduplication of uploads.
+func renterdedupcmd(enabledStr string) {
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		die("Could not parse dedup flag:", err)
+	}
+	err = httpClient.RenterSetDedupPost(enabled)
+	if err != nil {
+		die("Could not set dedup:", err)
+	}
+	if enabled {
+		fmt.Println("Enabled the deduplication of uploads")
+	} else {
+		fmt.Println("Disabled the deduplication of uploads")
+	}
+}
+
 // renterworkerscmd is the handler for the command `siac renter workers`.
 // It lists the Renter's workers.
 func renterworkerscmd() {
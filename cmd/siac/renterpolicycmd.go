@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	renterPolicyCmd = &cobra.Command{
+		Use:   "policy [path]",
+		Short: "Show the upload policy of a folder",
+		Long: `Show the upload policy of a folder. The policy determines the redundancy, the
+cipher type and the pinned hosts of new files uploaded to the folder or any of
+its subfolders. Settings which are not set for a folder are inherited from its
+parent folders.`,
+		Run: wrap(renterpolicycmd),
+	}
+
+	renterPolicySetCmd = &cobra.Command{
+		Use:   "set [path]",
+		Short: "Set the upload policy of a folder",
+		Long: `Set the upload policy of a folder. Settings which are not specified are
+inherited from the parent folders. Calling the command without any flags clears
+the policy of the folder.
+
+Hosts are pinned by providing a comma separated list of host public keys in the
+format 'ed25519:<hex>'. Pieces of new files will only be uploaded to and
+repaired on these hosts.`,
+		Run: wrap(renterpolicysetcmd),
+	}
+)
+
+// parsePolicySiaPath parses the siapath of a folder passed to one of the
+// policy commands.
+func parsePolicySiaPath(path string) modules.SiaPath {
+	if path == "." || path == "" || path == "/" {
+		return modules.RootSiaPath()
+	}
+	sp, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Could not parse siapath:", err)
+	}
+	return sp
+}
+
+// renterpolicycmd is the handler for the command `siac renter policy [path]`.
+// It prints the policy of a folder.
+func renterpolicycmd(path string) {
+	sp := parsePolicySiaPath(path)
+	rd, err := httpClient.RenterDirGet(sp)
+	if err != nil {
+		die("Could not get folder:", err)
+	}
+	if len(rd.Directories) == 0 {
+		die("Could not get folder: response doesn't contain the folder")
+	}
+	policy := rd.Directories[0].Policy
+	effective := rd.EffectivePolicy
+
+	// Print the erasure coding settings.
+	redundancy := "default"
+	dataPieces, parityPieces := modules.RenterDefaultDataPieces, modules.RenterDefaultParityPieces
+	if effective.DataPieces != 0 {
+		redundancy = "inherited"
+		dataPieces, parityPieces = effective.DataPieces, effective.ParityPieces
+	}
+	if policy.DataPieces != 0 {
+		redundancy = "set"
+	}
+	fmt.Printf("Policy of %v:\n", sp)
+	fmt.Printf("  Redundancy:    %v data, %v parity pieces (%v)\n", dataPieces, parityPieces, redundancy)
+
+	// Print the cipher type.
+	cipher := "default"
+	cipherType := crypto.TypeDefaultRenter.String()
+	if effective.CipherType != "" {
+		cipher = "inherited"
+		cipherType = effective.CipherType
+	}
+	if policy.CipherType != "" {
+		cipher = "set"
+	}
+	fmt.Printf("  Cipher Type:   %v (%v)\n", cipherType, cipher)
+
+	// Print the pinned hosts.
+	if len(effective.Hosts) == 0 {
+		fmt.Println("  Pinned Hosts:  none")
+		return
+	}
+	pinned := "inherited"
+	if len(policy.Hosts) != 0 {
+		pinned = "set"
+	}
+	fmt.Printf("  Pinned Hosts:  %v (%v)\n", len(effective.Hosts), pinned)
+	for _, host := range effective.Hosts {
+		fmt.Printf("    %v\n", host)
+	}
+}
+
+// renterpolicysetcmd is the handler for the command `siac renter policy set
+// [path]`. It sets the policy of a folder.
+func renterpolicysetcmd(path string) {
+	sp := parsePolicySiaPath(path)
+	var policy modules.DirPolicy
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	policy.DataPieces, policy.ParityPieces = numDataPieces, numParityPieces
+	policy.CipherType = renterPolicyCipherType
+	if renterPolicyHosts != "" {
+		for _, hostStr := range strings.Split(renterPolicyHosts, ",") {
+			var spk types.SiaPublicKey
+			if err := spk.LoadString(strings.TrimSpace(hostStr)); err != nil {
+				die("Could not parse host public key:", err)
+			}
+			policy.Hosts = append(policy.Hosts, spk)
+		}
+	}
+	err = httpClient.RenterDirSetPolicyPost(sp, policy)
+	if err != nil {
+		die("Could not set policy:", err)
+	}
+	fmt.Printf("Policy of %v updated.\n", sp)
+}
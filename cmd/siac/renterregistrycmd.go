@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	renterRegistryCmd = &cobra.Command{
+		Use:   "registry",
+		Short: "Read, update and subscribe to registry entries",
+		Long:  "Read, update and subscribe to registry entries using the renter's workers.",
+		// Run field not provided; registry requires a subcommand.
+	}
+
+	renterRegistryReadCmd = &cobra.Command{
+		Use:   "read [publickey] [datakey]",
+		Short: "Read a registry entry",
+		Long: `Read the registry entry with the given public key and data key. The public
+key is expected in the format 'ed25519:<hex>' and the data key as a hex encoded
+hash.`,
+		Run: wrap(renterregistryreadcmd),
+	}
+
+	renterRegistrySubscribeCmd = &cobra.Command{
+		Use:   "subscribe [publickey] [datakey] [publickey] [datakey]...",
+		Short: "Subscribe to registry entries",
+		Long: `Subscribe to one or more registry entries, each specified by a public key and
+data key pair. Every update of a subscribed entry is printed until the command
+is interrupted.`,
+		Run: renterregistrysubscribecmd,
+	}
+
+	renterRegistryUpdateCmd = &cobra.Command{
+		Use:   "update [publickey] [datakey] [revision] [data] [signature]",
+		Short: "Update a registry entry",
+		Long: `Update a registry entry with an already signed value. The data and the
+signature are expected to be hex encoded. The type of the entry can be set
+with the --type flag.`,
+		Run: wrap(renterregistryupdatecmd),
+	}
+)
+
+// parseRegistryKeys parses a public key and data key pair passed to one of the
+// registry commands.
+func parseRegistryKeys(pubKeyStr, dataKeyStr string) (types.SiaPublicKey, crypto.Hash) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(pubKeyStr); err != nil {
+		die("Could not parse public key:", err)
+	}
+	var dataKey crypto.Hash
+	if err := dataKey.LoadString(dataKeyStr); err != nil {
+		die("Could not parse data key:", err)
+	}
+	return spk, dataKey
+}
+
+// renterregistryreadcmd is the handler for the command `siac renter registry
+// read`. It reads and prints a registry entry.
+func renterregistryreadcmd(pubKeyStr, dataKeyStr string) {
+	spk, dataKey := parseRegistryKeys(pubKeyStr, dataKeyStr)
+	srv, err := httpClient.RegistryRead(spk, dataKey)
+	if err != nil {
+		die("Could not read registry entry:", err)
+	}
+	fmt.Printf(`Entry ID:  %v
+Revision:  %v
+Type:      %v
+Data:      %v
+Signature: %v
+`, crypto.Hash(modules.DeriveRegistryEntryID(spk, dataKey)), srv.Revision, srv.Type,
+		hex.EncodeToString(srv.Data), hex.EncodeToString(srv.Signature[:]))
+}
+
+// renterregistrysubscribecmd is the handler for the command `siac renter
+// registry subscribe`. It prints every update of the subscribed entries.
+func renterregistrysubscribecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var entries []api.RegistrySubscriptionRequest
+	for i := 0; i < len(args); i += 2 {
+		spk, dataKey := parseRegistryKeys(args[i], args[i+1])
+		entries = append(entries, api.RegistrySubscriptionRequest{
+			PublicKey: spk,
+			DataKey:   dataKey,
+		})
+	}
+
+	sub, err := httpClient.RegistrySubscribePost(entries)
+	if err != nil {
+		die("Could not subscribe to registry entries:", err)
+	}
+	defer func() {
+		_ = sub.Close()
+	}()
+	fmt.Printf("Subscribed to %v registry entries. Waiting for updates...\n", len(entries))
+	for {
+		n, err := sub.Next()
+		if err != nil {
+			die("Subscription was interrupted:", err)
+		}
+		fmt.Printf("%v: revision %v, data %v\n", crypto.Hash(n.EntryID), n.Revision, n.Data)
+	}
+}
+
+// renterregistryupdatecmd is the handler for the command `siac renter
+// registry update`. It updates a registry entry with a signed value.
+func renterregistryupdatecmd(pubKeyStr, dataKeyStr, revisionStr, dataStr, sigStr string) {
+	spk, dataKey := parseRegistryKeys(pubKeyStr, dataKeyStr)
+	revision, err := strconv.ParseUint(revisionStr, 10, 64)
+	if err != nil {
+		die("Could not parse revision:", err)
+	}
+	data, err := hex.DecodeString(dataStr)
+	if err != nil {
+		die("Could not decode data:", err)
+	}
+	sigBytes, err := hex.DecodeString(sigStr)
+	if err != nil || len(sigBytes) != crypto.SignatureSize {
+		die("Could not decode signature:", err)
+	}
+	var sig crypto.Signature
+	copy(sig[:], sigBytes)
+
+	srv := modules.NewSignedRegistryValue(dataKey, data, revision, sig, modules.RegistryEntryType(renterRegistryEntryType))
+	err = httpClient.RegistryUpdate(spk, srv)
+	if err != nil {
+		die("Could not update registry entry:", err)
+	}
+	fmt.Println("Registry entry updated.")
+}
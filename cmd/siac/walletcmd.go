@@ -56,6 +56,19 @@ be valid. txn may be either JSON, base64, or a file containing either.`,
 		Run: wrap(walletbroadcastcmd),
 	}
 
+	walletBumpCmd = &cobra.Command{
+		Use:   "bump [txid]",
+		Short: "Increase the fee of an unconfirmed transaction",
+		Long: `Increase the fee of an unconfirmed wallet transaction that is stuck in the
+transaction pool. The rbf method replaces the transaction with a version that
+pays the additional fee from its change output; unconfirmed transactions
+spending its outputs are dropped and have to be sent again. The cpfp method
+spends the change output in a child transaction that pays the additional fee.
+The additional fee defaults to the fee recommended by the transaction pool and
+can be set per KB with --fee, e.g. --fee 10mS.`,
+		Run: wrap(walletbumpcmd),
+	}
+
 	walletChangepasswordCmd = &cobra.Command{
 		Use:   "change-password",
 		Short: "Change the wallet password",
@@ -155,7 +168,10 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+
+With --inputs exactly the given outputs are spent, even if they are frozen, and the change is sent
+to a new address of the wallet.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -269,6 +285,34 @@ func walletaddressescmd() {
 	}
 }
 
+// walletbumpcmd increases the fee of an unconfirmed transaction.
+func walletbumpcmd(txid string) {
+	var id types.TransactionID
+	if err := id.UnmarshalJSON([]byte("\"" + txid + "\"")); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	var feePerByte types.Currency
+	if walletBumpFee != "" {
+		hastings, err := types.ParseCurrency(walletBumpFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		var feePerKB types.Currency
+		if _, err := fmt.Sscan(hastings, &feePerKB); err != nil {
+			die("Could not parse fee:", err)
+		}
+		feePerByte = feePerKB.Div64(1e3)
+	}
+	wtbp, err := httpClient.WalletTransactionBumpPost(id, modules.FeeBumpMethod(walletBumpMethod), feePerByte)
+	if err != nil {
+		die("Could not bump transaction fee:", err)
+	}
+	fmt.Println("Submitted transactions:")
+	for _, txid := range wtbp.TransactionIDs {
+		fmt.Println(" ", txid)
+	}
+}
+
 // walletchangepasswordcmd changes the password of the wallet.
 func walletchangepasswordcmd() {
 	currentPassword, err := passwordPrompt(currentPasswordText)
@@ -426,7 +470,15 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	if walletSendInputs != "" {
+		if walletTxnFeeIncluded {
+			die("--inputs and --fee-included can't be combined")
+		}
+		outputs := []types.SiacoinOutput{{Value: value, UnlockHash: hash}}
+		_, err = httpClient.WalletSiacoinsInputsPost(parseInputs(walletSendInputs), outputs)
+	} else {
+		_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	}
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
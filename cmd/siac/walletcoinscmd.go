@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	walletFreezeCmd = &cobra.Command{
+		Use:   "freeze [outputid]",
+		Short: "Freeze an output",
+		Long: `Freeze an output of the wallet. Frozen outputs are never used to fund
+transactions unless they are selected with 'siac wallet send siacoins --inputs'.`,
+		Run: wrap(walletfreezecmd),
+	}
+
+	walletLabelCmd = &cobra.Command{
+		Use:   "label [outputid|address] [label]",
+		Short: "Label an output or an address",
+		Long: `Label an output or an address of the wallet. Labels are shown by 'siac wallet
+unspent'. An empty label removes the label.`,
+		Run: wrap(walletlabelcmd),
+	}
+
+	walletUnfreezeCmd = &cobra.Command{
+		Use:   "unfreeze [outputid]",
+		Short: "Unfreeze an output",
+		Long:  "Unfreeze an output so that the wallet can use it to fund transactions again.",
+		Run:   wrap(walletunfreezecmd),
+	}
+
+	walletUnspentCmd = &cobra.Command{
+		Use:   "unspent",
+		Short: "List the unspent outputs",
+		Long:  "List the unspent outputs of the wallet with their labels and whether they are frozen.",
+		Run:   wrap(walletunspentcmd),
+	}
+)
+
+// parseOutputID parses a hex encoded output id.
+func parseOutputID(s string) types.OutputID {
+	var h crypto.Hash
+	if err := h.LoadString(s); err != nil {
+		die("Could not parse output id:", err)
+	}
+	return types.OutputID(h)
+}
+
+// parseInputs parses a comma separated list of siacoin output ids.
+func parseInputs(s string) []types.SiacoinOutputID {
+	var inputs []types.SiacoinOutputID
+	for _, id := range strings.Split(s, ",") {
+		inputs = append(inputs, types.SiacoinOutputID(parseOutputID(strings.TrimSpace(id))))
+	}
+	return inputs
+}
+
+// walletfreezecmd freezes an output.
+func walletfreezecmd(id string) {
+	if err := httpClient.WalletUnspentFreezePost(parseOutputID(id), true); err != nil {
+		die("Could not freeze output:", err)
+	}
+	fmt.Println("Froze output", id)
+}
+
+// walletlabelcmd labels an output or an address.
+func walletlabelcmd(target, label string) {
+	var addr types.UnlockHash
+	if addr.LoadString(target) == nil {
+		if err := httpClient.WalletLabelsPost(addr, label); err != nil {
+			die("Could not label address:", err)
+		}
+	} else if err := httpClient.WalletUnspentLabelPost(parseOutputID(target), label); err != nil {
+		die("Could not label output:", err)
+	}
+	fmt.Println("Labeled", target)
+}
+
+// walletunfreezecmd unfreezes an output.
+func walletunfreezecmd(id string) {
+	if err := httpClient.WalletUnspentFreezePost(parseOutputID(id), false); err != nil {
+		die("Could not unfreeze output:", err)
+	}
+	fmt.Println("Unfroze output", id)
+}
+
+// walletunspentcmd lists the unspent outputs of the wallet.
+func walletunspentcmd() {
+	wug, err := httpClient.WalletUnspentGet()
+	if err != nil {
+		die("Could not get unspent outputs:", err)
+	}
+	if len(wug.Outputs) == 0 {
+		fmt.Println("No unspent outputs.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tValue\tLabel\tAddress Label\tFrozen")
+	for _, o := range wug.Outputs {
+		value := currencyUnits(o.Value)
+		if o.FundType == types.SpecifierSiafundOutput {
+			value = o.Value.String() + " SF"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", o.ID, value, o.Label, o.AddressLabel, o.Frozen)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
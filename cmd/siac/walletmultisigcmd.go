@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig",
+		Short: "List the multisig accounts",
+		Long: `List the M-of-N multisig accounts tracked by the wallet and their confirmed
+balances.`,
+		Run: wrap(walletmultisigcmd),
+	}
+
+	walletMultisigAddCmd = &cobra.Command{
+		Use:   "add [name] [required] [publickeys]",
+		Short: "Add a multisig account",
+		Long: `Add a multisig account which requires [required] signatures of the given
+ed25519 public keys. The public keys have the format ed25519:<hex> and can be
+obtained from each signer's wallet with 'siac wallet multisig pubkey'. All
+signers have to add the public keys in the same order to obtain the same
+address.`,
+		Run: walletmultisigaddcmd,
+	}
+
+	walletMultisigBroadcastCmd = &cobra.Command{
+		Use:   "broadcast [file]",
+		Short: "Broadcast a multisig transaction",
+		Long: `Broadcast a partially signed transaction that has collected enough
+signatures.`,
+		Run: wrap(walletmultisigbroadcastcmd),
+	}
+
+	walletMultisigCombineCmd = &cobra.Command{
+		Use:   "combine [output] [files]",
+		Short: "Combine partially signed transactions",
+		Long: `Merge the signatures of several partially signed versions of the same
+transaction and write the result to [output].`,
+		Run: walletmultisigcombinecmd,
+	}
+
+	walletMultisigPubkeyCmd = &cobra.Command{
+		Use:   "pubkey",
+		Short: "Get a public key for a multisig account",
+		Long: `Generate a new address from the wallet's primary seed and display its public
+key, which can be shared with the other signers of a multisig account.`,
+		Run: wrap(walletmultisigpubkeycmd),
+	}
+
+	walletMultisigRemoveCmd = &cobra.Command{
+		Use:   "remove [address]",
+		Short: "Remove a multisig account",
+		Long:  "Stop tracking the multisig account with the given address.",
+		Run:   wrap(walletmultisigremovecmd),
+	}
+
+	walletMultisigSendCmd = &cobra.Command{
+		Use:   "send [address] [amount] [dest] [file]",
+		Short: "Create a multisig transaction",
+		Long: `Create an unsigned transaction which sends [amount] from the multisig account
+with the given address to [dest] and write it to [file]. Amount should be given
+in currency units, e.g. 1SC. The file can be passed to the signers, who add
+their signatures with 'siac wallet multisig sign'.`,
+		Run: wrap(walletmultisigsendcmd),
+	}
+
+	walletMultisigSignCmd = &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Sign a multisig transaction",
+		Long: `Add the wallet's signatures to the partially signed transaction in [file]. The
+outputs and the fee of the transaction are displayed and have to be confirmed
+before signing. Only inputs of the multisig accounts tracked by the wallet are
+signed. The file is updated in place.`,
+		Run: wrap(walletmultisigsigncmd),
+	}
+)
+
+// readPartiallySignedTransaction reads a partially signed transaction from a
+// file.
+func readPartiallySignedTransaction(path string) (modules.PartiallySignedTransaction, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, errors.AddContext(err, "could not read partially signed transaction")
+	}
+	var pst modules.PartiallySignedTransaction
+	if err := json.Unmarshal(b, &pst); err != nil {
+		return modules.PartiallySignedTransaction{}, errors.AddContext(err, "could not decode partially signed transaction")
+	}
+	return pst, nil
+}
+
+// writePartiallySignedTransaction writes a partially signed transaction to a
+// file.
+func writePartiallySignedTransaction(path string, pst modules.PartiallySignedTransaction) error {
+	b, err := json.MarshalIndent(pst, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// printSignatureProgress prints how many signatures every input of a partially
+// signed transaction has.
+func printSignatureProgress(pst modules.PartiallySignedTransaction) {
+	for _, sp := range pst.Progress() {
+		fmt.Printf("  input %v: %v of %v signatures\n", sp.ParentID, sp.Signed, sp.Required)
+	}
+	if pst.Complete() {
+		fmt.Println("The transaction has enough signatures and can be broadcast.")
+	}
+}
+
+// walletmultisigcmd lists the multisig accounts of the wallet.
+func walletmultisigcmd() {
+	wmg, err := httpClient.WalletMultisigGet()
+	if err != nil {
+		die("Could not get multisig accounts:", err)
+	}
+	if len(wmg.Accounts) == 0 {
+		fmt.Println("No multisig accounts.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tAddress\tSignatures\tBalance")
+	for _, ma := range wmg.Accounts {
+		fmt.Fprintf(w, "%v\t%v\t%v of %v\t%v\n", ma.Name, ma.Address, ma.UnlockConditions.SignaturesRequired,
+			len(ma.UnlockConditions.PublicKeys), currencyUnits(ma.ConfirmedSiacoinBalance))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletmultisigaddcmd adds a multisig account to the wallet.
+func walletmultisigaddcmd(cmd *cobra.Command, args []string) {
+	if len(args) < 3 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	required, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		die("Could not parse number of required signatures:", err)
+	}
+	var pks []types.SiaPublicKey
+	for _, arg := range args[2:] {
+		var pk types.SiaPublicKey
+		if err := pk.LoadString(arg); err != nil {
+			die("Could not parse public key", arg+":", err)
+		}
+		pks = append(pks, pk)
+	}
+	wmp, err := httpClient.WalletMultisigPost(args[0], pks, required, walletMultisigUnused)
+	if err != nil {
+		die("Could not add multisig account:", err)
+	}
+	fmt.Printf("Added multisig account %v with address %v\n", wmp.Account.Name, wmp.Account.Address)
+}
+
+// walletmultisigbroadcastcmd broadcasts a fully signed multisig transaction.
+func walletmultisigbroadcastcmd(path string) {
+	pst, err := readPartiallySignedTransaction(path)
+	if err != nil {
+		die(err)
+	}
+	wmbp, err := httpClient.WalletMultisigBroadcastPost(pst)
+	if err != nil {
+		die("Could not broadcast multisig transaction:", err)
+	}
+	fmt.Println("Broadcast transaction", wmbp.TransactionID)
+}
+
+// walletmultisigcombinecmd merges the signatures of partially signed
+// transactions.
+func walletmultisigcombinecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var psts []modules.PartiallySignedTransaction
+	for _, path := range args[1:] {
+		pst, err := readPartiallySignedTransaction(path)
+		if err != nil {
+			die(err)
+		}
+		psts = append(psts, pst)
+	}
+	pst, err := modules.CombinePartiallySignedTransactions(psts...)
+	if err != nil {
+		die("Could not combine partially signed transactions:", err)
+	}
+	if err := writePartiallySignedTransaction(args[0], pst); err != nil {
+		die("Could not write partially signed transaction:", err)
+	}
+	fmt.Println("Wrote combined transaction to", args[0])
+	printSignatureProgress(pst)
+}
+
+// walletmultisigpubkeycmd displays the public key of a new wallet address.
+func walletmultisigpubkeycmd() {
+	wag, err := httpClient.WalletAddressGet()
+	if err != nil {
+		die("Could not generate new address:", err)
+	}
+	wucg, err := httpClient.WalletUnlockConditionsGet(wag.Address)
+	if err != nil {
+		die("Could not get unlock conditions of the new address:", err)
+	}
+	if len(wucg.UnlockConditions.PublicKeys) != 1 {
+		die("Unexpected number of public keys for address", wag.Address)
+	}
+	fmt.Println(wucg.UnlockConditions.PublicKeys[0])
+}
+
+// walletmultisigremovecmd removes a multisig account from the wallet.
+func walletmultisigremovecmd(addr string) {
+	var hash types.UnlockHash
+	if _, err := fmt.Sscan(addr, &hash); err != nil {
+		die("Failed to parse address", err)
+	}
+	if err := httpClient.WalletMultisigRemovePost(hash, walletMultisigUnused); err != nil {
+		die("Could not remove multisig account:", err)
+	}
+	fmt.Println("Removed multisig account", addr)
+}
+
+// walletmultisigsendcmd creates an unsigned multisig transaction.
+func walletmultisigsendcmd(addr, amount, dest, path string) {
+	var from types.UnlockHash
+	if _, err := fmt.Sscan(addr, &from); err != nil {
+		die("Failed to parse multisig address", err)
+	}
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var to types.UnlockHash
+	if _, err := fmt.Sscan(dest, &to); err != nil {
+		die("Failed to parse destination address", err)
+	}
+	wmtp, err := httpClient.WalletMultisigTransactionPost(from, []types.SiacoinOutput{{Value: value, UnlockHash: to}})
+	if err != nil {
+		die("Could not create multisig transaction:", err)
+	}
+	if err := writePartiallySignedTransaction(path, wmtp.PartiallySignedTransaction); err != nil {
+		die("Could not write partially signed transaction:", err)
+	}
+	fmt.Printf("Wrote transaction sending %v with a fee of %v to %v\n", currencyUnits(value),
+		currencyUnits(wmtp.PartiallySignedTransaction.Fee()), path)
+}
+
+// walletmultisigsigncmd adds the wallet's signatures to a multisig
+// transaction.
+func walletmultisigsigncmd(path string) {
+	pst, err := readPartiallySignedTransaction(path)
+	if err != nil {
+		die(err)
+	}
+	fmt.Println("The transaction sends")
+	for _, sco := range pst.Transaction.SiacoinOutputs {
+		fmt.Printf("  %v to %v\n", currencyUnits(sco.Value), sco.UnlockHash)
+	}
+	fmt.Printf("with a fee of %v.\n", currencyUnits(pst.Fee()))
+	if !askForConfirmation("Do you want to sign the transaction?") {
+		return
+	}
+	wmtp, err := httpClient.WalletMultisigSignPost(pst)
+	if err != nil {
+		die("Could not sign multisig transaction:", err)
+	}
+	if err := writePartiallySignedTransaction(path, wmtp.PartiallySignedTransaction); err != nil {
+		die("Could not write partially signed transaction:", err)
+	}
+	fmt.Println("Signed transaction in", path)
+	printSignatureProgress(wmtp.PartiallySignedTransaction)
+}
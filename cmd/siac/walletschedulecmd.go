@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	walletScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "List the scheduled payments",
+		Long: `List the scheduled payments of the wallet. The wallet sends a payment once the
+blockchain reaches its next height, as long as the wallet is unlocked.`,
+		Run: wrap(walletschedulecmd),
+	}
+
+	walletScheduleAddCmd = &cobra.Command{
+		Use:   "add [amount] [dest] [height]",
+		Short: "Schedule a payment",
+		Long: `Schedule a payment of [amount] to [dest] at block [height]. Amount should be
+given in currency units, e.g. 1SC. A height of the form +N is relative to the
+current block height. With --interval the payment is sent again every
+[interval] blocks, --count limits the number of payments.`,
+		Run: wrap(walletscheduleaddcmd),
+	}
+
+	walletSendTimelockedCmd = &cobra.Command{
+		Use:   "timelocked [amount] [publickey] [height]",
+		Short: "Send time-locked siacoins",
+		Long: `Send [amount] to an address which can only be spent with the secret key of
+[publickey] once the blockchain reaches block [height]. The public key has the
+format ed25519:<hex>. A height of the form +N is relative to the current block
+height. The unlock conditions of the address are displayed and are needed to
+spend the output.`,
+		Run: wrap(walletsendtimelockedcmd),
+	}
+
+	walletScheduleRemoveCmd = &cobra.Command{
+		Use:   "remove [id]",
+		Short: "Remove a scheduled payment",
+		Long:  "Remove the scheduled payment with the given id.",
+		Run:   wrap(walletscheduleremovecmd),
+	}
+)
+
+// walletschedulecmd lists the scheduled payments of the wallet.
+func walletschedulecmd() {
+	wsg, err := httpClient.WalletSchedulesGet()
+	if err != nil {
+		die("Could not get scheduled payments:", err)
+	}
+	if len(wsg.Schedules) == 0 {
+		fmt.Println("No scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLabel\tAmount\tNext Height\tInterval\tPayments\tStatus")
+	for _, ps := range wsg.Schedules {
+		var amount types.Currency
+		for _, sco := range ps.Outputs {
+			amount = amount.Add(sco.Value)
+		}
+		status := "active"
+		if ps.Completed {
+			status = "completed"
+		} else if ps.LastError != "" {
+			status = "failed: " + ps.LastError
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", ps.ID, ps.Label, currencyUnits(amount), ps.NextHeight, ps.Interval, ps.Payments, status)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// parseBlockHeight parses a block height. A height of the form +N is relative
+// to the current block height.
+func parseBlockHeight(height string) types.BlockHeight {
+	var offset types.BlockHeight
+	if strings.HasPrefix(height, "+") {
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current height:", err)
+		}
+		offset = cg.Height
+	}
+	h, err := strconv.ParseUint(strings.TrimPrefix(height, "+"), 10, 64)
+	if err != nil {
+		die("Could not parse height:", err)
+	}
+	return offset + types.BlockHeight(h)
+}
+
+// walletscheduleaddcmd schedules a payment.
+func walletscheduleaddcmd(amount, dest, height string) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var addr types.UnlockHash
+	if err := addr.LoadString(dest); err != nil {
+		die("Failed to parse destination address", err)
+	}
+	wsp, err := httpClient.WalletSchedulesPost(api.WalletSchedulePOSTParams{
+		Label:      walletScheduleLabel,
+		Outputs:    []types.SiacoinOutput{{Value: value, UnlockHash: addr}},
+		NextHeight: parseBlockHeight(height),
+		Interval:   types.BlockHeight(walletScheduleInterval),
+		Remaining:  walletScheduleCount,
+	})
+	if err != nil {
+		die("Could not schedule payment:", err)
+	}
+	fmt.Printf("Scheduled payment %v at height %v\n", wsp.Schedule.ID, wsp.Schedule.NextHeight)
+}
+
+// walletscheduleremovecmd removes a scheduled payment.
+func walletscheduleremovecmd(id string) {
+	var h crypto.Hash
+	if err := h.LoadString(id); err != nil {
+		die("Could not parse schedule id:", err)
+	}
+	if err := httpClient.WalletScheduleRemovePost(h); err != nil {
+		die("Could not remove scheduled payment:", err)
+	}
+	fmt.Println("Removed scheduled payment", id)
+}
+
+// walletsendtimelockedcmd sends siacoins to a time-locked address.
+func walletsendtimelockedcmd(amount, publicKey, height string) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(publicKey); err != nil {
+		die("Could not parse public key:", err)
+	}
+	wtp, err := httpClient.WalletTimelockPost(value, pk, parseBlockHeight(height))
+	if err != nil {
+		die("Could not send siacoins:", err)
+	}
+	uc, err := json.MarshalIndent(wtp.UnlockConditions, "", "  ")
+	if err != nil {
+		die("Could not encode unlock conditions:", err)
+	}
+	fmt.Printf("Sent %v to %v in transaction %v\n", currencyUnits(value), wtp.Address, wtp.TransactionIDs[len(wtp.TransactionIDs)-1])
+	fmt.Printf("The output can be spent from block %v with the unlock conditions\n%s\n", wtp.UnlockConditions.Timelock, uc)
+}
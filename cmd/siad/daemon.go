@@ -218,6 +218,22 @@ func startDaemon(config Config) (err error) {
 
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
+	nodeParams.EnableMetrics = config.Siad.EnableMetrics
+	nodeParams.AuthenticateAPIReads = config.Siad.AuthenticateReads
+	nodeParams.StratumAddress = config.Siad.StratumAddr
+	nodeParams.StratumDifficulty = config.Siad.StratumDifficulty
+	nodeParams.StratumSolo = config.Siad.StratumSolo
+	nodeParams.PrunedConsensus = config.Siad.PrunedConsensus
+	if config.Siad.BootstrapSnapshot != "" {
+		if config.Siad.BootstrapSnapshotID == "" {
+			return errors.New("--bootstrap-snapshot-id is required to import a snapshot")
+		}
+		err = nodeParams.BootstrapSnapshotID.LoadString(config.Siad.BootstrapSnapshotID)
+		if err != nil {
+			return errors.AddContext(err, "failed to parse bootstrap snapshot id")
+		}
+		nodeParams.BootstrapSnapshot = config.Siad.BootstrapSnapshot
+	}
 
 	// Start and run the server.
 	srv, err := server.New(config.Siad.APIaddr, config.Siad.RequiredUserAgent, config.APIPassword, nodeParams, loadStart)
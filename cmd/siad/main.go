@@ -44,6 +44,17 @@ type Config struct {
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 		TempPassword      bool
+		EnableMetrics     bool
+		AuthenticateReads bool
+
+		StratumAddr       string
+		StratumDifficulty uint64
+		StratumSolo       bool
+
+		PrunedConsensus bool
+
+		BootstrapSnapshot   string
+		BootstrapSnapshotID string
 
 		Profile    string
 		ProfileDir string
@@ -204,8 +215,16 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.SiaMuxWSAddr, "siamux-addr-ws", "", ":9984", "which port the SiaMux websocket listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "gctwrhfa", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
+	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateReads, "authenticate-api-reads", "", false, "require the API password or an API token for read-only API calls")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
+	root.Flags().BoolVarP(&globalConfig.Siad.EnableMetrics, "metrics", "", false, "enable the /metrics endpoint for Prometheus/OpenMetrics scrapers")
+	root.Flags().StringVarP(&globalConfig.Siad.StratumAddr, "stratum-addr", "", "", "which host:port the miner's stratum server listens on, the server is disabled if empty")
+	root.Flags().Uint64VarP(&globalConfig.Siad.StratumDifficulty, "stratum-difficulty", "", 0, "share difficulty of the stratum server, 0 uses the default")
+	root.Flags().BoolVarP(&globalConfig.Siad.StratumSolo, "stratum-solo", "", false, "run the stratum server as a solo pool which pays block rewards to the addresses of the workers")
+	root.Flags().BoolVarP(&globalConfig.Siad.PrunedConsensus, "pruned-consensus", "", false, "only keep the most recent blocks in the consensus database, the node stops serving older blocks to peers. Not supported with the explorer")
+	root.Flags().StringVarP(&globalConfig.Siad.BootstrapSnapshot, "bootstrap-snapshot", "", "", "import a consensus snapshot before loading consensus, if no consensus database exists yet. The snapshot state isn't verified, only use snapshots from trusted nodes. Not supported with the explorer")
+	root.Flags().StringVarP(&globalConfig.Siad.BootstrapSnapshotID, "bootstrap-snapshot-id", "", "", "trusted id of the block at the height of the bootstrap snapshot")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
 	if globalConfig.Siad.SiaDir == "" {
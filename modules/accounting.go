@@ -21,6 +21,10 @@ type (
 
 		Renter RenterAccounting `json:"renter"`
 		Wallet WalletAccounting `json:"wallet"`
+
+		// Timestamp is the Unix timestamp at which the accounting information
+		// was recorded.
+		Timestamp int64 `json:"timestamp"`
 	}
 
 	// RenterAccounting contains the accounting information related to the Renter
@@ -53,6 +57,11 @@ type Accounting interface {
 	// Accounting returns the current accounting information
 	Accounting() (AccountingInfo, error)
 
+	// AccountingHistory returns the persisted accounting information with a
+	// timestamp within the provided range. Both start and end are Unix
+	// timestamps and are inclusive.
+	AccountingHistory(start, end int64) ([]AccountingInfo, error)
+
 	// Close closes the accounting module
 	Close() error
 }
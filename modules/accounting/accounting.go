@@ -19,6 +19,10 @@ var (
 
 	// errNilWallet is the error returned when the wallet is nil
 	errNilWallet = errors.New("wallet cannot be nil")
+
+	// errInvalidRange is the error returned when the start of a requested
+	// range is after the end of the range
+	errInvalidRange = errors.New("start timestamp cannot be after the end timestamp")
 )
 
 // Accounting contains the information needed for providing accounting
@@ -101,6 +105,27 @@ func (a *Accounting) Accounting() (modules.AccountingInfo, error) {
 	return ai, nil
 }
 
+// AccountingHistory returns the persisted accounting information with a
+// timestamp within the provided range.
+func (a *Accounting) AccountingHistory(start, end int64) ([]modules.AccountingInfo, error) {
+	err := a.staticTG.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer a.staticTG.Done()
+
+	// Validate the range
+	if start > end {
+		return nil, errInvalidRange
+	}
+
+	history, err := a.readHistory(start, end)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read the accounting history")
+	}
+	return history, nil
+}
+
 // Close closes the accounting module
 //
 // NOTE: It will not call close on any of the modules it is tracking. Those
@@ -135,12 +160,13 @@ func (a *Accounting) callUpdateAccounting() (modules.AccountingInfo, error) {
 	}
 
 	// Update the Accounting state
+	ai.Timestamp = time.Now().Unix()
 	err := errors.Compose(renterErr, walletErr)
 	if err == nil {
 		a.mu.Lock()
 		a.persistence.Renter = ai.Renter
 		a.persistence.Wallet = ai.Wallet
-		a.persistence.Timestamp = time.Now().Unix()
+		a.persistence.Timestamp = ai.Timestamp
 		a.mu.Unlock()
 	}
 	return ai, err
@@ -1,9 +1,11 @@
 package accounting
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/siatest/dependencies"
 )
@@ -17,6 +19,7 @@ func TestAccounting(t *testing.T) {
 
 	// Specific Methods
 	t.Run("Accounting", testAccounting)
+	t.Run("AccountingHistory", testAccountingHistory)
 	t.Run("NewCustomAccounting", testNewCustomAccounting)
 }
 
@@ -53,6 +56,8 @@ func testAccounting(t *testing.T) {
 	expected := modules.AccountingInfo{
 		Renter: ai.Renter,
 		Wallet: ai.Wallet,
+
+		Timestamp: ai.Timestamp,
 	}
 	if !reflect.DeepEqual(ai, expected) {
 		t.Error("accounting information is incorrect")
@@ -87,6 +92,92 @@ func testAccounting(t *testing.T) {
 	}
 }
 
+// testAccountingHistory probes the AccountingHistory method
+func testAccountingHistory(t *testing.T) {
+	// Create new accounting
+	testDir := accountingTestDir(t.Name())
+	h, m, r, w, _ := testingParams()
+	a, err := NewCustomAccounting(h, m, r, w, testDir, &dependencies.AccountingDisablePersistLoop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = a.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// History should initially be empty
+	history, err := a.AccountingHistory(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("expected empty history, got %v entries", len(history))
+	}
+
+	// An invalid range should be rejected
+	_, err = a.AccountingHistory(1, 0)
+	if !errors.Contains(err, errInvalidRange) {
+		t.Fatalf("expected %v, got %v", errInvalidRange, err)
+	}
+
+	// Persist a few entries with distinct timestamps
+	numEntries := 3
+	for i := 0; i < numEntries; i++ {
+		data, err := marshalPersistence(persistence{Timestamp: int64(i + 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = a.staticAOP.Write(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The full range should return all entries
+	history, err = a.AccountingHistory(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != numEntries {
+		t.Fatalf("expected %v entries, got %v", numEntries, len(history))
+	}
+
+	// A partial range should only return the entries within the range
+	history, err = a.AccountingHistory(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected %v entries, got %v", 2, len(history))
+	}
+	if history[0].Timestamp != 2 || history[1].Timestamp != 3 {
+		t.Fatal("unexpected timestamps", history[0].Timestamp, history[1].Timestamp)
+	}
+
+	// Entries persisted by the persist loop are included as well
+	err = a.managedUpdateAndPersistAccounting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err = a.AccountingHistory(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != numEntries+1 {
+		t.Fatalf("expected %v entries, got %v", numEntries+1, len(history))
+	}
+	a.mu.Lock()
+	p := a.persistence
+	a.mu.Unlock()
+	last := history[numEntries]
+	if last.Timestamp != p.Timestamp || !last.Wallet.ConfirmedSiacoinBalance.Equals(p.Wallet.ConfirmedSiacoinBalance) {
+		t.Fatal("last entry doesn't match the persistence", last, p)
+	}
+}
+
 // testNewCustomAccounting probes the NewCustomAccounting function
 func testNewCustomAccounting(t *testing.T) {
 	// checkNew is a helper function to check NewCustomAccounting
@@ -58,6 +58,16 @@ type persistence struct {
 	Timestamp int64 `json:"timestamp"`
 }
 
+// accountingInfo returns the persisted accounting information as a
+// modules.AccountingInfo.
+func (p persistence) accountingInfo() modules.AccountingInfo {
+	return modules.AccountingInfo{
+		Renter:    p.Renter,
+		Wallet:    p.Wallet,
+		Timestamp: p.Timestamp,
+	}
+}
+
 // callThreadedPersistAccounting is a background loop that persists the
 // accounting information based on the persistInterval.
 func (a *Accounting) callThreadedPersistAccounting() {
@@ -137,7 +147,8 @@ func (a *Accounting) initPersist() error {
 		return errors.AddContext(err, "unable to unmarshal persistence")
 	}
 
-	// Keep the last persist entry in memory
+	// Keep the last persist entry in memory. The history is read from disk
+	// when it's requested.
 	if len(persistence) > 0 {
 		a.persistence = persistence[len(persistence)-1]
 	}
@@ -174,10 +185,45 @@ func (a *Accounting) managedUpdateAndPersistAccounting() error {
 		a.staticLog.Printf("WARN: %v:%v", logStr, err)
 		return err
 	}
-
 	return nil
 }
 
+// readHistory reads the persisted accounting information with a timestamp
+// within the provided range from disk.
+func (a *Accounting) readHistory(start, end int64) (_ []modules.AccountingInfo, err error) {
+	// Only read up to the current length of the persist data, so that entries
+	// which are being written concurrently are skipped.
+	length := a.staticAOP.PersistLength()
+	f, err := os.Open(a.staticAOP.FilePath())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open persist file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	r := io.NewSectionReader(f, int64(persist.MetadataPageSize), int64(length-persist.MetadataPageSize))
+
+	// Decode the persisted entries one at a time, so that only the entries in
+	// the range are kept in memory.
+	d := json.NewDecoder(r)
+	var history []modules.AccountingInfo
+	for {
+		var p persistence
+		err = d.Decode(&p)
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to read from persist file")
+		}
+		if p.Timestamp < start || p.Timestamp > end {
+			continue
+		}
+		history = append(history, p.accountingInfo())
+	}
+	return history, nil
+}
+
 // marshalPersistence marshals the persistence.
 func marshalPersistence(p persistence) ([]byte, error) {
 	// Marshal the persistence
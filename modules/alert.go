@@ -49,6 +49,10 @@ const (
 	// AlertIDHostDiskTrouble is the id of the alert that is registered when the
 	// host is encountering problems interacting with one or more of his disks
 	AlertIDHostDiskTrouble = "host-disk-trouble"
+	// AlertIDHostCorruptSectors is the id of the alert that is registered when
+	// the host's scrubber finds corrupt sectors in the storage folders and
+	// unregistered after a scrubbing pass when no corrupt sectors are left.
+	AlertIDHostCorruptSectors = "host-corrupt-sectors"
 	// AlertIDHostInsufficientCollateral is the id of the alert that is
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
@@ -48,6 +48,11 @@ var (
 	// should be handled by the module, and not reported to the user.
 	ErrInvalidConsensusChangeID = errors.New("consensus subscription has invalid id - files are inconsistent")
 
+	// ErrPrunedConsensusChange indicates that a subscriber can't catch up
+	// with a pruned consensus set because the consensus changes it is missing
+	// have been pruned already.
+	ErrPrunedConsensusChange = errors.New("consensus changes since the subscription id have been pruned")
+
 	// ErrNonExtendingBlock indicates that a block is valid but does not result
 	// in a fork that is the heaviest known fork - the consensus set has not
 	// changed as a result of seeing the block.
@@ -226,6 +231,10 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportSnapshot writes a snapshot of the consensus state at the
+		// provided height, which can be used to bootstrap new nodes.
+		ExportSnapshot(io.Writer, types.BlockHeight) error
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
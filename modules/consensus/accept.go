@@ -317,6 +317,14 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	for i := 0; i < len(changes); i++ {
 		cs.updateSubscribers(changes[i])
 	}
+	// Prune the blocks which fell out of the window once the subscribers
+	// don't need them anymore.
+	if cs.pruned {
+		err = cs.db.Update(cs.pruneBlocks)
+		if err != nil {
+			cs.log.Println("ERROR: unable to prune consensus database:", err)
+		}
+	}
 	return chainExtended, nil
 }
 
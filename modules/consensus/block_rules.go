@@ -41,6 +41,15 @@ func (rh stdBlockRuleHelper) minimumValidChildTimestamp(blockMap dbBucket, pb *p
 		// parent lies at the first 32 bytes, and the timestamp of the block
 		// lies at bytes 40-48.
 		parentBytes := blockMap.Get(parent[:])
+		if parentBytes == nil {
+			// The oldest blocks of a pruned consensus set are missing their
+			// parents. They are treated like the genesis block, which only
+			// affects the consensus changes of these blocks since new
+			// blocks always have a full window of parents.
+			windowTimes[i] = windowTimes[i-1]
+			parent = types.BlockID{}
+			continue
+		}
 		copy(parent[:], parentBytes[:32])
 		windowTimes[i] = types.Timestamp(encoding.DecUint64(parentBytes[40:48]))
 	}
@@ -81,6 +81,15 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// pruned is true if the consensus set only keeps the most recent blocks.
+	// See prune.go.
+	pruned bool
+
+	// bootstrapped is true if the changelog of the consensus set starts with
+	// a base entry instead of the genesis block, because it was bootstrapped
+	// from a snapshot or is pruned. See snapshot.go.
+	bootstrapped bool
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -96,7 +105,7 @@ type ConsensusSet struct {
 }
 
 // consensusSetBlockingStartup handles the blocking portion of NewCustomConsensusSet.
-func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, deps modules.Dependencies) (*ConsensusSet, error) {
+func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, deps modules.Dependencies, pruned bool) (*ConsensusSet, error) {
 	// Check for nil dependencies.
 	if gateway == nil {
 		return nil, errNilGateway
@@ -119,6 +128,8 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 		blockRuleHelper: stdBlockRuleHelper{},
 		blockValidator:  NewBlockValidator(),
 
+		pruned: pruned,
+
 		staticDeps: deps,
 		persistDir: persistDir,
 	}
@@ -202,9 +213,23 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
+	return newConsensusSet(gateway, bootstrap, persistDir, deps, false)
+}
+
+// NewPrunedConsensusSet returns a new ConsensusSet which only keeps the blocks
+// of the most recent PrunedBlockWindow blocks. An existing full database in
+// the persist directory is pruned, a pruned database can't be used by a full
+// consensus set anymore.
+func NewPrunedConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
+	return newConsensusSet(gateway, bootstrap, persistDir, deps, true)
+}
+
+// newConsensusSet creates a new ConsensusSet and starts its non-blocking
+// startup.
+func newConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies, pruned bool) (*ConsensusSet, <-chan error) {
 	// Handle blocking consensus startup first.
 	errChan := make(chan error, 1)
-	cs, err := consensusSetBlockingStartup(gateway, persistDir, deps)
+	cs, err := consensusSetBlockingStartup(gateway, persistDir, deps, pruned)
 	if err != nil {
 		errChan <- err
 		return nil, errChan
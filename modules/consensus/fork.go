@@ -1,9 +1,8 @@
 package consensus
 
 import (
-	"errors"
-
 	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -16,8 +15,9 @@ var (
 // backtrackToCurrentPath traces backwards from 'pb' until it reaches a block
 // in the ConsensusSet's current path (the "common parent"). It returns the
 // (inclusive) set of blocks between the common parent and 'pb', starting from
-// the former.
-func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
+// the former. On a pruned consensus set the common parent may have been
+// pruned, in which case errPrunedReorg is returned.
+func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) ([]*processedBlock, error) {
 	path := []*processedBlock{pb}
 	for {
 		// Error is not checked in production code - an error can only indicate
@@ -33,14 +33,15 @@ func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 		}
 
 		// Prepend the next block to the list of blocks leading from the
-		// current path to the input block.
+		// current path to the input block. The parent can only be missing if
+		// it was pruned.
 		pb, err = getBlockMap(tx, pb.Block.ParentID)
-		if build.DEBUG && err != nil {
-			panic(err)
+		if err != nil {
+			return nil, errors.Compose(errPrunedReorg, errOrphan)
 		}
 		path = append([]*processedBlock{pb}, path...)
 	}
-	return path
+	return path, nil
 }
 
 // revertToBlock will revert blocks from the ConsensusSet's current path until
@@ -78,7 +79,10 @@ func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (reverted
 // set's current path and 'pb'.
 func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (appliedBlocks []*processedBlock, err error) {
 	// Backtrack to the common parent of 'bn' and current path and then apply the new blocks.
-	newPath := backtrackToCurrentPath(tx, pb)
+	newPath, err := backtrackToCurrentPath(tx, pb)
+	if err != nil {
+		return nil, err
+	}
 	for _, block := range newPath[1:] {
 		// If the diffs for this block have already been generated, apply diffs
 		// directly instead of generating them. This is much faster.
@@ -110,8 +114,11 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 // found to be invalid. forkBlockchain is atomic; the ConsensusSet is only
 // updated if the function returns nil.
 func (cs *ConsensusSet) forkBlockchain(tx *bolt.Tx, newBlock *processedBlock) (revertedBlocks, appliedBlocks []*processedBlock, err error) {
-	commonParent := backtrackToCurrentPath(tx, newBlock)[0]
-	revertedBlocks = cs.revertToBlock(tx, commonParent)
+	path, err := backtrackToCurrentPath(tx, newBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	revertedBlocks = cs.revertToBlock(tx, path[0])
 	appliedBlocks, err = cs.applyUntilBlock(tx, newBlock)
 	if err != nil {
 		return nil, nil, err
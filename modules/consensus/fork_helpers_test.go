@@ -6,8 +6,9 @@ import "gitlab.com/NebulousLabs/bolt"
 // backtrackToCurrentPath without a bolt.Tx.
 func (cs *ConsensusSet) dbBacktrackToCurrentPath(pb *processedBlock) (pbs []*processedBlock) {
 	_ = cs.db.Update(func(tx *bolt.Tx) error {
-		pbs = backtrackToCurrentPath(tx, pb)
-		return nil
+		var err error
+		pbs, err = backtrackToCurrentPath(tx, pb)
+		return err
 	})
 	return pbs
 }
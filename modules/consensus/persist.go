@@ -60,6 +60,18 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Prepare pruning, or refuse to use a pruned database for a full
+		// consensus set.
+		if cs.pruned {
+			err = cs.initPruning(tx)
+			if err != nil {
+				return err
+			}
+		} else if tx.Bucket(PrunedBlocks) != nil {
+			return errPrunedDatabase
+		}
+		_, cs.bootstrapped = baseEntry(tx)
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
@@ -71,7 +83,7 @@ func (cs *ConsensusSet) loadDB() error {
 		}
 
 		// Compute initial checksum.
-		if build.DEBUG {
+		if build.DEBUG && tx.Bucket(BlockMap).Get(genesisID[:]) != nil {
 			cs.blockRoot.ConsensusChecksum = consensusChecksum(tx)
 			addBlockMap(tx, &cs.blockRoot)
 		}
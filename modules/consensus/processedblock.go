@@ -150,5 +150,8 @@ func (cs *ConsensusSet) newChild(tx *bolt.Tx, pb *processedBlock, b types.Block)
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	if cs.pruned {
+		addPrunedBlock(tx, child.Height, childID)
+	}
 	return child
 }
@@ -0,0 +1,348 @@
+package consensus
+
+// prune.go implements the pruned mode of the consensus set. A pruned consensus
+// set keeps the full consensus state (outputs, contracts and the block path),
+// but only the processed blocks, oak totals and changelog entries of the most
+// recent PrunedBlockWindow blocks. Reorgs which are deeper than the window
+// can't be followed, the blocks which would cause them are rejected like
+// orphans. Subscribers which fall further behind can't catch up and the blocks
+// below the window are no longer served to peers.
+//
+// Every processed block of a pruned consensus set is indexed by height in the
+// PrunedBlocks bucket, so that blocks which fall out of the window can be
+// found without a scan of the block map. The presence of the bucket marks the
+// database as pruned.
+//
+// Subscribers which start at the beginning can't receive the pruned changes,
+// so the changelog of a pruned consensus set starts with a base entry, like
+// the changelog of a bootstrapped consensus set (see snapshot.go). The base
+// entry applies the whole consensus state after it, which is kept in the
+// SnapshotBase bucket. When the base entry is pruned, the next entry becomes
+// the base entry and its diffs are applied to the base state before its
+// blocks are removed. Subscribers which start at the beginning miss the
+// transactions of the pruned blocks, but not the outputs and contracts they
+// created.
+
+import (
+	"encoding/binary"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// PrunedBlocks is a database bucket that indexes the processed blocks of
+	// a pruned consensus set by height.
+	PrunedBlocks = []byte("PrunedBlocks")
+
+	// ChangeLogHeadID is a key that points to the id of the oldest change
+	// entry which hasn't been pruned. Unpruned changelogs don't have the key
+	// and start at the genesis entry.
+	ChangeLogHeadID = []byte("ChangeLogHeadID")
+
+	// PrunedBlockWindow is the number of blocks below the current block for
+	// which a pruned consensus set keeps the processed blocks.
+	PrunedBlockWindow = build.Select(build.Var{
+		Standard: types.BlockHeight(1008),
+		Dev:      types.BlockHeight(144),
+		Testing:  types.BlockHeight(24),
+	}).(types.BlockHeight)
+
+	errPrunedBlocks   = errors.New("requested blocks have been pruned")
+	errPrunedReorg    = errors.New("reorg is deeper than the pruned block window")
+	errPrunedDatabase = errors.New("consensus database is pruned and can't be used by a full node")
+)
+
+// prunedBlockKey returns the key of a block in the PrunedBlocks bucket. The
+// height is big endian encoded so that the keys are sorted by height.
+func prunedBlockKey(height types.BlockHeight, id types.BlockID) []byte {
+	key := make([]byte, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(height))
+	copy(key[8:], id[:])
+	return key
+}
+
+// addPrunedBlock adds a processed block to the index of a pruned consensus
+// set.
+func addPrunedBlock(tx *bolt.Tx, height types.BlockHeight, id types.BlockID) {
+	err := tx.Bucket(PrunedBlocks).Put(prunedBlockKey(height, id), []byte{})
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// changeLogHead returns the id of the oldest change entry in the changelog.
+func (cs *ConsensusSet) changeLogHead(tx *bolt.Tx) modules.ConsensusChangeID {
+	var head modules.ConsensusChangeID
+	if b := tx.Bucket(ChangeLog).Get(ChangeLogHeadID); b != nil {
+		copy(head[:], b)
+		return head
+	}
+	ge := cs.genesisEntry()
+	return ge.ID()
+}
+
+// initPruning prepares the database of a pruned consensus set. A database
+// which is pruned for the first time gets its processed blocks indexed and is
+// pruned right away.
+func (cs *ConsensusSet) initPruning(tx *bolt.Tx) error {
+	if err := initBaseEntry(tx); err != nil {
+		return errors.AddContext(err, "unable to create base entry")
+	}
+	if tx.Bucket(PrunedBlocks) != nil {
+		return nil
+	}
+	index, err := tx.CreateBucket(PrunedBlocks)
+	if err != nil {
+		return errors.AddContext(err, "unable to create pruned blocks bucket")
+	}
+	var keys [][]byte
+	err = tx.Bucket(BlockMap).ForEach(func(k, v []byte) error {
+		var pb processedBlock
+		if err := encoding.Unmarshal(v, &pb); err != nil {
+			return err
+		}
+		var id types.BlockID
+		copy(id[:], k)
+		keys = append(keys, prunedBlockKey(pb.Height, id))
+		return nil
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to index processed blocks")
+	}
+	for _, key := range keys {
+		if err := index.Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+	return cs.pruneBlocks(tx)
+}
+
+// pruneBlocks removes the processed blocks below the pruning window together
+// with their oak totals and the change entries which refer to them.
+func (cs *ConsensusSet) pruneBlocks(tx *bolt.Tx) error {
+	height := blockHeight(tx)
+	if height < PrunedBlockWindow {
+		return nil
+	}
+	cutoff := height - PrunedBlockWindow
+
+	// Collect the pruned blocks first, bolt doesn't support modifying a
+	// bucket while iterating over it.
+	index := tx.Bucket(PrunedBlocks)
+	var keys [][]byte
+	c := index.Cursor()
+	for k, _ := c.First(); k != nil && types.BlockHeight(binary.BigEndian.Uint64(k[:8])) < cutoff; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Prune the changelog first, the base entry might need the diffs of the
+	// blocks.
+	pruned := make(map[types.BlockID]struct{}, len(keys))
+	for _, key := range keys {
+		var id types.BlockID
+		copy(id[:], key[8:])
+		pruned[id] = struct{}{}
+	}
+	if err := cs.pruneChangeLog(tx, pruned); err != nil {
+		return err
+	}
+	blockMap := tx.Bucket(BlockMap)
+	oak := tx.Bucket(BucketOak)
+	for _, key := range keys {
+		id := key[8:]
+		if err := blockMap.Delete(id); err != nil {
+			return err
+		}
+		if err := oak.Delete(id); err != nil {
+			return err
+		}
+		if err := index.Delete(key); err != nil {
+			return err
+		}
+	}
+	cs.log.Debugf("Pruned %v blocks below height %v", len(keys), cutoff)
+	return nil
+}
+
+// pruneChangeLog removes the change entries at the start of the changelog
+// which refer to blocks that are missing or about to be pruned. The base entry
+// is advanced to the next entry when it is removed.
+func (cs *ConsensusSet) pruneChangeLog(tx *bolt.Tx, pruned map[types.BlockID]struct{}) error {
+	cl := tx.Bucket(ChangeLog)
+	blockMap := tx.Bucket(BlockMap)
+	head := cs.changeLogHead(tx)
+	for {
+		entry, exists := getEntry(tx, head)
+		if !exists {
+			return errors.New("changelog head is missing")
+		}
+		prunedEntry := false
+		for _, id := range append(append([]types.BlockID(nil), entry.RevertedBlocks...), entry.AppliedBlocks...) {
+			if _, ok := pruned[id]; ok || blockMap.Get(id[:]) == nil {
+				prunedEntry = true
+				break
+			}
+		}
+		if !prunedEntry {
+			break
+		}
+		next, exists := entry.NextEntry(tx)
+		if !exists {
+			// The most recent entry always refers to the current block.
+			return errors.New("changelog tail refers to pruned blocks")
+		}
+		if id, ok := baseEntry(tx); ok && id == head {
+			if err := advanceBaseEntry(tx, next); err != nil {
+				return errors.AddContext(err, "unable to advance base entry")
+			}
+		}
+		if err := cl.Delete(head[:]); err != nil {
+			return err
+		}
+		head = next.ID()
+	}
+	return cl.Put(ChangeLogHeadID, head[:])
+}
+
+// initBaseEntry makes the most recent change entry the base entry of the
+// changelog by copying the current consensus state into the SnapshotBase
+// bucket. Bootstrapped consensus sets already have a base entry.
+func initBaseEntry(tx *bolt.Tx) error {
+	if tx.Bucket(SnapshotBase) != nil {
+		return nil
+	}
+	sb, err := tx.CreateBucket(SnapshotBase)
+	if err != nil {
+		return err
+	}
+	var names [][]byte
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if isSnapshotBaseBucket(name) {
+			names = append(names, append([]byte(nil), name...))
+		}
+		return nil
+	})
+	for _, name := range names {
+		b, err := sb.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(name).ForEach(func(k, v []byte) error {
+			return b.Put(k, v)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var tailID modules.ConsensusChangeID
+	copy(tailID[:], tx.Bucket(ChangeLog).Get(ChangeLogTailID))
+	tail, exists := getEntry(tx, tailID)
+	if !exists {
+		return errors.New("changelog tail is missing")
+	}
+	return setBaseEntry(tx, tail)
+}
+
+// advanceBaseEntry makes the entry after the base entry the new base entry,
+// applying its diffs to the base state.
+func advanceBaseEntry(tx *bolt.Tx, next changeEntry) error {
+	sb := tx.Bucket(SnapshotBase)
+	for _, id := range next.RevertedBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := applyBaseDiffs(sb, computeConsensusChangeDiffs(pb, false)); err != nil {
+			return err
+		}
+	}
+	for _, id := range next.AppliedBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := applyBaseDiffs(sb, computeConsensusChangeDiffs(pb, true)); err != nil {
+			return err
+		}
+	}
+	return setBaseEntry(tx, next)
+}
+
+// setBaseEntry stores the id and the most recent block of the base entry in
+// the SnapshotBase bucket.
+func setBaseEntry(tx *bolt.Tx, entry changeEntry) error {
+	pb, err := getBlockMap(tx, entry.AppliedBlocks[len(entry.AppliedBlocks)-1])
+	if err != nil {
+		return err
+	}
+	sb := tx.Bucket(SnapshotBase)
+	if err := sb.Put(SnapshotBase, encoding.Marshal(withoutDiffs(*pb))); err != nil {
+		return err
+	}
+	id := entry.ID()
+	return sb.Put(SnapshotEntryID, id[:])
+}
+
+// applyBaseDiffs applies consensus change diffs to the base state in the
+// SnapshotBase bucket.
+func applyBaseDiffs(sb *bolt.Bucket, cd modules.ConsensusChangeDiffs) error {
+	// putOrDelete adds the value to the bucket when a diff is applied and
+	// removes it when a diff is reverted.
+	putOrDelete := func(b *bolt.Bucket, key []byte, dir modules.DiffDirection, v interface{}) error {
+		if dir == modules.DiffApply {
+			return b.Put(key, encoding.Marshal(v))
+		}
+		return b.Delete(key)
+	}
+	for _, d := range cd.SiacoinOutputDiffs {
+		if err := putOrDelete(sb.Bucket(SiacoinOutputs), d.ID[:], d.Direction, d.SiacoinOutput); err != nil {
+			return err
+		}
+	}
+	for _, d := range cd.FileContractDiffs {
+		if err := putOrDelete(sb.Bucket(FileContracts), d.ID[:], d.Direction, d.FileContract); err != nil {
+			return err
+		}
+	}
+	for _, d := range cd.SiafundOutputDiffs {
+		if err := putOrDelete(sb.Bucket(SiafundOutputs), d.ID[:], d.Direction, d.SiafundOutput); err != nil {
+			return err
+		}
+	}
+	for _, d := range cd.SiafundPoolDiffs {
+		pool := d.Adjusted
+		if d.Direction == modules.DiffRevert {
+			pool = d.Previous
+		}
+		if err := sb.Bucket(SiafundPool).Put(SiafundPool, encoding.Marshal(pool)); err != nil {
+			return err
+		}
+	}
+	for _, d := range cd.DelayedSiacoinOutputDiffs {
+		name := append(append([]byte(nil), prefixDSCO...), encoding.EncUint64(uint64(d.MaturityHeight))...)
+		b, err := sb.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+		if err := putOrDelete(b, d.ID[:], d.Direction, d.SiacoinOutput); err != nil {
+			return err
+		}
+		// Delayed outputs are removed when they mature, drop the bucket
+		// with the last one.
+		if k, _ := b.Cursor().First(); k == nil {
+			if err := sb.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
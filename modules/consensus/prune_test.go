@@ -0,0 +1,260 @@
+package consensus
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// TestPrunedConsensusSet checks that a pruned consensus set follows the chain
+// of a full consensus set while only keeping the most recent blocks and
+// changes.
+func TestPrunedConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cst.cs.Height() < 3*PrunedBlockWindow {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// newPruned creates a pruned consensus set in the provided directory.
+	newPruned := func(dir string) (*gateway.Gateway, *ConsensusSet) {
+		g, err := gateway.New("localhost:0", false, filepath.Join(dir, modules.GatewayDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs, errChan := NewPrunedConsensusSet(g, false, filepath.Join(dir, modules.ConsensusDir), modules.ProdDependencies)
+		if err := <-errChan; err != nil {
+			t.Fatal(err)
+		}
+		return g, cs
+	}
+	// checkPruned checks that cs only has the blocks of the pruning window.
+	checkPruned := func(cs *ConsensusSet) {
+		t.Helper()
+		height := cs.Height()
+		for h := types.BlockHeight(0); h <= height; h++ {
+			_, exists := cs.BlockAtHeight(h)
+			if exists != (h >= height-PrunedBlockWindow) {
+				t.Fatalf("block at height %v of %v: exists %v", h, height, exists)
+			}
+		}
+	}
+
+	// Feed the blocks of the full consensus set to a new pruned consensus set
+	// with a subscriber.
+	dir := filepath.Join(cst.persistDir, "pruned")
+	g, cs := newPruned(dir)
+	ms := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	for h := types.BlockHeight(1); h <= cst.cs.Height(); h++ {
+		b, _ := cst.cs.BlockAtHeight(h)
+		if err := cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cs.CurrentBlock().ID() != cst.cs.CurrentBlock().ID() {
+		t.Fatal("pruned consensus set didn't follow the chain")
+	}
+	if len(ms.updates) != int(cs.Height())+1 {
+		t.Fatal("subscriber missed changes", len(ms.updates))
+	}
+	checkPruned(cs)
+
+	// New subscribers which start at the beginning receive the state of the
+	// base entry followed by the changes of the window, and see the same
+	// outputs as a subscriber of the full consensus set.
+	full := newMockSubscriber()
+	if err := cst.cs.ConsensusSetSubscribe(&full, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&full)
+	fullSCOs, fullSFOs := subscriberOutputs(full.updates)
+	checkOutputs := func(cs *ConsensusSet) {
+		t.Helper()
+		ms := newMockSubscriber()
+		if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs.Unsubscribe(&ms)
+		if len(ms.updates) > int(PrunedBlockWindow)+2 || ms.updates[0].BlockHeight < cs.Height()-PrunedBlockWindow {
+			t.Fatal("subscriber should start at the base entry", len(ms.updates), ms.updates[0].BlockHeight)
+		}
+		scos, sfos := subscriberOutputs(ms.updates)
+		if !reflect.DeepEqual(scos, fullSCOs) || !reflect.DeepEqual(sfos, fullSFOs) {
+			t.Fatal("subscriber of the pruned consensus set has different outputs", len(scos), len(fullSCOs))
+		}
+	}
+	checkOutputs(cs)
+
+	// Only the changes of the window are available to resuming subscribers.
+	if err := cs.ConsensusSetSubscribe(&mockSubscriber{}, ms.updates[1].ID, nil); !errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
+		t.Fatal("expected ErrInvalidConsensusChangeID, got", err)
+	}
+	recent := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&recent, ms.updates[len(ms.updates)-6].ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(recent.updates) != 5 || recent.updates[4].ID != ms.updates[len(ms.updates)-1].ID {
+		t.Fatal("wrong changes after subscribing within the window", len(recent.updates))
+	}
+
+	// A pruned database can't be opened by a full consensus set.
+	if err := errors.Compose(cs.Close(), g.Close()); err != nil {
+		t.Fatal(err)
+	}
+	g, err = gateway.New("localhost:0", false, filepath.Join(dir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errChan := NewCustomConsensusSet(g, false, filepath.Join(dir, modules.ConsensusDir), modules.ProdDependencies)
+	if err := <-errChan; !errors.Contains(err, errPrunedDatabase) {
+		t.Fatal("expected errPrunedDatabase, got", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Convert the database of the full consensus set.
+	height := cst.cs.Height()
+	if err := cst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, cs = newPruned(cst.persistDir)
+	defer func() {
+		if err := errors.Compose(cs.Close(), g.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cs.Height() != height {
+		t.Fatal("height changed by pruning", cs.Height(), height)
+	}
+	checkPruned(cs)
+	checkOutputs(cs)
+}
+
+// TestPrunedDeepReorg checks that a pruned consensus set rejects a heavier fork
+// whose common parent with the current chain has been pruned.
+func TestPrunedDeepReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cstA, err := blankConsensusSetTester(t.Name()+"-A", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cstA.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cstB, err := blankConsensusSetTester(t.Name()+"-B", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cstB.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a common prefix, then let the chains diverge. Chain B is heavier
+	// than chain A.
+	for cstA.cs.Height() < 5 {
+		if _, err := cstA.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fork := cstA.cs.Height()
+	for h := types.BlockHeight(1); h <= fork; h++ {
+		b, _ := cstA.cs.BlockAtHeight(h)
+		if err := cstB.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for cstA.cs.Height() < fork+PrunedBlockWindow+1 {
+		if _, err := cstA.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for cstB.cs.Height() < fork+3*PrunedBlockWindow {
+		if _, err := cstB.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(cstA.persistDir, "pruned")
+	g, err := gateway.New("localhost:0", false, filepath.Join(dir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cs, errChan := NewPrunedConsensusSet(g, false, filepath.Join(dir, modules.ConsensusDir), modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cs.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Follow chain A, but receive the first block of chain B while the common
+	// parent is still within the window. Once chain A moves on, the common
+	// parent is pruned.
+	for h := types.BlockHeight(1); h <= cstA.cs.Height(); h++ {
+		b, _ := cstA.cs.BlockAtHeight(h)
+		if err := cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		if h == fork+2 {
+			b, _ := cstB.cs.BlockAtHeight(fork + 1)
+			if err := cs.AcceptBlock(b); !errors.Contains(err, modules.ErrNonExtendingBlock) {
+				t.Fatal("expected ErrNonExtendingBlock, got", err)
+			}
+		}
+	}
+	if _, exists := cs.BlockAtHeight(fork); exists {
+		t.Fatal("common parent wasn't pruned")
+	}
+
+	// Extend chain B until it becomes heavier. The reorg is rejected.
+	h := fork + 2
+	for ; h <= cstB.cs.Height(); h++ {
+		b, _ := cstB.cs.BlockAtHeight(h)
+		err := cs.AcceptBlock(b)
+		if errors.Contains(err, modules.ErrNonExtendingBlock) {
+			continue
+		}
+		if !errors.Contains(err, errPrunedReorg) || !errors.Contains(err, errOrphan) {
+			t.Fatal("expected errPrunedReorg, got", err)
+		}
+		break
+	}
+	if h > cstB.cs.Height() {
+		t.Fatal("chain B never became heavier")
+	}
+	if cs.CurrentBlock().ID() != cstA.cs.CurrentBlock().ID() {
+		t.Fatal("pruned consensus set left chain A")
+	}
+}
@@ -0,0 +1,680 @@
+package consensus
+
+// snapshot.go implements the export and import of consensus snapshots. A
+// snapshot contains the consensus state at a height together with the blocks
+// of a pruning window below it, which is enough for a new node to continue
+// syncing from that height instead of replaying every block from genesis.
+//
+// Snapshots are streamed: a header is followed by the entries of the state
+// buckets, the processed blocks and a checksum of everything before it. The
+// exporter copies the state into a temporary database first, so that the
+// consensus set isn't locked while the snapshot is written, and the importer
+// writes the entries to the new database in batches as they are read.
+//
+// A bootstrapped consensus set is missing the blocks below the snapshot height
+// and doesn't serve them to peers. Its changelog starts with a base entry,
+// which applies the snapshot block. Subscribers that start at the beginning
+// receive the whole snapshot state as the diffs of that entry, the diffs are
+// computed from a copy of the snapshot state in the SnapshotBase bucket.
+// Pruned consensus sets keep a base entry in the same way, see prune.go.
+//
+// A snapshot is only as trustworthy as the node which exported it. The
+// importer checks that the snapshot blocks form a chain which ends with the
+// trusted block id and that the snapshot wasn't corrupted, but blocks don't
+// commit to the consensus state, so the state itself can't be verified. A
+// malicious exporter can add or remove outputs and contracts without the
+// importer noticing. Snapshots should only be imported from a trusted node, and
+// the trusted block id should be obtained from a different source.
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// snapshotMaxRecordSize is the maximum size of a decoded record of a
+	// snapshot, e.g. a processed block or the key and value of an entry.
+	snapshotMaxRecordSize = 1 << 26
+
+	// snapshotBatchSize is the number of bucket entries which are written to
+	// a database in a single transaction.
+	snapshotBatchSize = 10000
+
+	// snapshotBlockBatchSize is the number of blocks which are written or
+	// reverted in a single transaction.
+	snapshotBlockBatchSize = 100
+)
+
+var (
+	// SnapshotBase is a database bucket that only exists in consensus sets
+	// which were bootstrapped from a snapshot or which are pruned. It
+	// contains the most recent processed block of the base entry of the
+	// changelog, the id of the base entry and a copy of the consensus state
+	// after the base entry, from which the diffs of the base entry are
+	// computed.
+	SnapshotBase = []byte("SnapshotBase")
+
+	// SnapshotEntryID is the key of the base entry id in the SnapshotBase
+	// bucket.
+	SnapshotEntryID = []byte("SnapshotEntryID")
+
+	// ErrSnapshotDatabaseExists is returned when importing a snapshot into a
+	// directory that already contains a consensus database.
+	ErrSnapshotDatabaseExists = errors.New("consensus database already exists")
+
+	errSnapshotBlocks    = errors.New("snapshot blocks are invalid")
+	errSnapshotBucket    = errors.New("snapshot contains an invalid bucket")
+	errSnapshotChecksum  = errors.New("snapshot checksum mismatch")
+	errSnapshotHeight    = errors.New("snapshot height is not available")
+	errSnapshotUntrusted = errors.New("snapshot block doesn't match the trusted block id")
+
+	snapshotMetadata = persist.Metadata{
+		Header:  "Consensus Snapshot",
+		Version: "1.0",
+	}
+
+	// snapshotStateBuckets are the buckets which make up the consensus state
+	// of a snapshot, in addition to the delayed siacoin output and file
+	// contract expiration buckets.
+	snapshotStateBuckets = [][]byte{
+		BlockHeight,
+		BlockPath,
+		SiacoinOutputs,
+		FileContracts,
+		SiafundOutputs,
+		SiafundPool,
+		FoundationUnlockHashes,
+	}
+)
+
+type (
+	// snapshotHeader is the start of a snapshot. It is followed by the
+	// entries of Buckets state buckets, by Blocks processed blocks together
+	// with their oak totals, and by the checksum of the snapshot.
+	snapshotHeader struct {
+		Height   types.BlockHeight
+		BlockID  types.BlockID
+		Checksum crypto.Hash
+		Buckets  uint64
+		Blocks   uint64
+	}
+
+	// snapshotBucketHeader precedes the entries of a state bucket in a
+	// snapshot. Each entry is encoded as a key followed by a value.
+	snapshotBucketHeader struct {
+		Name    []byte
+		Entries uint64
+	}
+)
+
+// isSnapshotStateBucket returns true if the bucket with the given name is part
+// of the consensus state of a snapshot.
+func isSnapshotStateBucket(name []byte) bool {
+	for _, b := range snapshotStateBuckets {
+		if bytes.Equal(name, b) {
+			return true
+		}
+	}
+	return bytes.HasPrefix(name, prefixDSCO) || bytes.HasPrefix(name, prefixFCEX)
+}
+
+// isSnapshotBaseBucket returns true if a copy of the state bucket with the
+// given name is kept in the SnapshotBase bucket, because the diffs of the
+// base entry are computed from it.
+func isSnapshotBaseBucket(name []byte) bool {
+	return bytes.Equal(name, SiacoinOutputs) || bytes.Equal(name, FileContracts) ||
+		bytes.Equal(name, SiafundOutputs) || bytes.Equal(name, SiafundPool) ||
+		bytes.HasPrefix(name, prefixDSCO)
+}
+
+// snapshotStateBucketNames returns the names of the state buckets in tx.
+func snapshotStateBucketNames(tx *bolt.Tx) [][]byte {
+	var names [][]byte
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if isSnapshotStateBucket(name) {
+			names = append(names, append([]byte(nil), name...))
+		}
+		return nil
+	})
+	return names
+}
+
+// copyBucket copies the entries of the bucket with the given name from src to
+// db in batches. The bucket is created in db if necessary.
+func copyBucket(src *bolt.Tx, db *persist.BoltDatabase, name []byte) error {
+	c := src.Bucket(name).Cursor()
+	k, v := c.First()
+	for {
+		err := db.Update(func(dst *bolt.Tx) error {
+			b, err := dst.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			for i := 0; k != nil && i < snapshotBatchSize; i++ {
+				if err := b.Put(k, v); err != nil {
+					return err
+				}
+				k, v = c.Next()
+			}
+			return nil
+		})
+		if err != nil || k == nil {
+			return err
+		}
+	}
+}
+
+// baseEntry returns the id of the base entry of the changelog, which applies
+// the whole consensus state after it. The bool is false if the consensus set
+// is neither bootstrapped from a snapshot nor pruned, in which case the
+// changelog starts with the genesis entry.
+func baseEntry(tx *bolt.Tx) (modules.ConsensusChangeID, bool) {
+	var id modules.ConsensusChangeID
+	b := tx.Bucket(SnapshotBase)
+	if b == nil {
+		return id, false
+	}
+	copy(id[:], b.Get(SnapshotEntryID))
+	return id, true
+}
+
+// baseEntryBlock returns the most recent processed block of the base entry
+// with the diffs that apply the whole consensus state after the base entry.
+func baseEntryBlock(tx *bolt.Tx) (*processedBlock, error) {
+	b := tx.Bucket(SnapshotBase)
+	if b == nil {
+		return nil, errNilBucket
+	}
+	var pb processedBlock
+	if err := encoding.Unmarshal(b.Get(SnapshotBase), &pb); err != nil {
+		return nil, err
+	}
+	err := b.Bucket(SiacoinOutputs).ForEach(func(k, v []byte) error {
+		scod := modules.SiacoinOutputDiff{Direction: modules.DiffApply}
+		copy(scod.ID[:], k)
+		pb.SiacoinOutputDiffs = append(pb.SiacoinOutputDiffs, scod)
+		return encoding.Unmarshal(v, &pb.SiacoinOutputDiffs[len(pb.SiacoinOutputDiffs)-1].SiacoinOutput)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = b.Bucket(FileContracts).ForEach(func(k, v []byte) error {
+		fcd := modules.FileContractDiff{Direction: modules.DiffApply}
+		copy(fcd.ID[:], k)
+		pb.FileContractDiffs = append(pb.FileContractDiffs, fcd)
+		return encoding.Unmarshal(v, &pb.FileContractDiffs[len(pb.FileContractDiffs)-1].FileContract)
+	})
+	if err != nil {
+		return nil, err
+	}
+	gsa := types.GenesisSiafundAllocation
+	err = b.Bucket(SiafundOutputs).ForEach(func(k, v []byte) error {
+		sfod := modules.SiafundOutputDiff{Direction: modules.DiffApply}
+		copy(sfod.ID[:], k)
+		if err := encoding.Unmarshal(v, &sfod.SiafundOutput); err != nil {
+			return err
+		}
+		// Mirror the dev address conversion of getSiafundOutput.
+		if sfod.SiafundOutput.UnlockHash == gsa[len(gsa)-1].UnlockHash && pb.Height > 10e3 {
+			sfod.SiafundOutput.UnlockHash = devAddr
+		}
+		pb.SiafundOutputDiffs = append(pb.SiafundOutputDiffs, sfod)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pool types.Currency
+	if err := encoding.Unmarshal(b.Bucket(SiafundPool).Get(SiafundPool), &pool); err != nil {
+		return nil, err
+	}
+	pb.SiafundPoolDiffs = []modules.SiafundPoolDiff{{
+		Direction: modules.DiffApply,
+		Previous:  types.ZeroCurrency,
+		Adjusted:  pool,
+	}}
+	err = b.ForEach(func(name, v []byte) error {
+		if v != nil || !bytes.HasPrefix(name, prefixDSCO) {
+			return nil
+		}
+		maturityHeight := types.BlockHeight(encoding.DecUint64(name[len(prefixDSCO):]))
+		return b.Bucket(name).ForEach(func(k, v []byte) error {
+			dscod := modules.DelayedSiacoinOutputDiff{
+				Direction:      modules.DiffApply,
+				MaturityHeight: maturityHeight,
+			}
+			copy(dscod.ID[:], k)
+			if err := encoding.Unmarshal(v, &dscod.SiacoinOutput); err != nil {
+				return err
+			}
+			pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb, nil
+}
+
+// withoutDiffs returns the processed block without its diffs, as it is stored
+// in the SnapshotBase bucket.
+func withoutDiffs(pb processedBlock) processedBlock {
+	pb.SiacoinOutputDiffs = nil
+	pb.FileContractDiffs = nil
+	pb.SiafundOutputDiffs = nil
+	pb.DelayedSiacoinOutputDiffs = nil
+	pb.SiafundPoolDiffs = nil
+	return pb
+}
+
+// ExportSnapshot writes a snapshot of the consensus state at the given height
+// to w. The snapshot contains the blocks of a pruning window below the height,
+// which have to be available in the consensus set.
+func (cs *ConsensusSet) ExportSnapshot(w io.Writer, height types.BlockHeight) error {
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Copy the current state and the required blocks into a temporary
+	// database, in which the blocks above the snapshot height are reverted.
+	dir, err := os.MkdirTemp(cs.persistDir, "snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(dir, DatabaseFilename))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// The consensus set is only locked while the read transaction is started,
+	// the transaction keeps a consistent view of the database while new
+	// blocks are processed.
+	cs.mu.RLock()
+	src, err := cs.db.Begin(false)
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	start, err := copySnapshotState(src, db, height)
+	err = errors.Compose(err, src.Rollback())
+	if err != nil {
+		return errors.AddContext(err, "unable to copy consensus state")
+	}
+
+	for reverted := false; !reverted; {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for i := 0; i < snapshotBlockBatchSize && blockHeight(tx) > height; i++ {
+				commitDiffSet(tx, currentProcessedBlock(tx), modules.DiffRevert)
+			}
+			reverted = blockHeight(tx) == height
+			return nil
+		})
+		if err != nil {
+			return errors.AddContext(err, "unable to revert consensus state")
+		}
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		return writeSnapshot(tx, w, start)
+	})
+}
+
+// copySnapshotState copies the state buckets and the blocks from the start of
+// the pruning window below height up to the current block from src to db. It
+// returns the height of the first copied block.
+func copySnapshotState(src *bolt.Tx, db *persist.BoltDatabase, height types.BlockHeight) (types.BlockHeight, error) {
+	current := blockHeight(src)
+	if height > current {
+		return 0, errSnapshotHeight
+	}
+	var start types.BlockHeight
+	if height+1 > PrunedBlockWindow {
+		start = height + 1 - PrunedBlockWindow
+	}
+	for _, name := range snapshotStateBucketNames(src) {
+		if err := copyBucket(src, db, name); err != nil {
+			return 0, err
+		}
+	}
+	for h := start; h <= current; {
+		err := db.Update(func(dst *bolt.Tx) error {
+			blockMap, err := dst.CreateBucketIfNotExists(BlockMap)
+			if err != nil {
+				return err
+			}
+			oak, err := dst.CreateBucketIfNotExists(BucketOak)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < snapshotBlockBatchSize && h <= current; i, h = i+1, h+1 {
+				id, err := getPath(src, h)
+				if err != nil {
+					return err
+				}
+				pbBytes := src.Bucket(BlockMap).Get(id[:])
+				oakBytes := src.Bucket(BucketOak).Get(id[:])
+				if pbBytes == nil || oakBytes == nil {
+					return errSnapshotHeight
+				}
+				if err := blockMap.Put(id[:], pbBytes); err != nil {
+					return err
+				}
+				if err := oak.Put(id[:], oakBytes); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return start, nil
+}
+
+// writeSnapshot writes the snapshot of the consensus state in tx, including
+// the blocks from start up to the current block, to w.
+func writeSnapshot(tx *bolt.Tx, w io.Writer, start types.BlockHeight) error {
+	bw := bufio.NewWriter(w)
+	h := crypto.NewHash()
+	enc := encoding.NewEncoder(io.MultiWriter(bw, h))
+
+	height := blockHeight(tx)
+	names := snapshotStateBucketNames(tx)
+	err := enc.EncodeAll(snapshotMetadata.Header, snapshotMetadata.Version, snapshotHeader{
+		Height:   height,
+		BlockID:  currentBlockID(tx),
+		Checksum: consensusChecksum(tx),
+		Buckets:  uint64(len(names)),
+		Blocks:   uint64(height - start + 1),
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		b := tx.Bucket(name)
+		var entries uint64
+		_ = b.ForEach(func(_, _ []byte) error {
+			entries++
+			return nil
+		})
+		if err := enc.Encode(snapshotBucketHeader{Name: name, Entries: entries}); err != nil {
+			return err
+		}
+		err := b.ForEach(func(k, v []byte) error {
+			_ = enc.WritePrefixedBytes(k)
+			return enc.WritePrefixedBytes(v)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for bh := start; bh <= height; bh++ {
+		id, err := getPath(tx, bh)
+		if err != nil {
+			return err
+		}
+		_ = enc.WritePrefixedBytes(tx.Bucket(BlockMap).Get(id[:]))
+		if err := enc.WritePrefixedBytes(tx.Bucket(BucketOak).Get(id[:])); err != nil {
+			return err
+		}
+	}
+
+	// Write the checksum of the snapshot.
+	var checksum crypto.Hash
+	copy(checksum[:], h.Sum(nil))
+	if err := encoding.NewEncoder(bw).Encode(checksum); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportSnapshot verifies the snapshot read from r and creates a consensus
+// database from it in persistDir. The block at the snapshot height has to
+// match the trusted block id. Consensus sets which are opened in persistDir
+// afterwards continue syncing from the snapshot height.
+//
+// The consensus state of the snapshot can't be verified against the trusted
+// block id, so r has to come from a trusted source.
+func ImportSnapshot(r io.Reader, persistDir string, trusted types.BlockID) error {
+	filename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(filename); err == nil {
+		return ErrSnapshotDatabaseExists
+	}
+
+	// Read the header and check it against the trusted id before reading the
+	// rest of the snapshot.
+	br := bufio.NewReader(r)
+	h := crypto.NewHash()
+	tr := io.TeeReader(br, h)
+	var header, version string
+	var sh snapshotHeader
+	err := encoding.NewDecoder(tr, snapshotMaxRecordSize).DecodeAll(&header, &version, &sh)
+	if err != nil {
+		return errors.AddContext(err, "unable to read snapshot")
+	}
+	if header != snapshotMetadata.Header {
+		return persist.ErrBadHeader
+	} else if version != snapshotMetadata.Version {
+		return persist.ErrBadVersion
+	} else if sh.BlockID != trusted {
+		return errSnapshotUntrusted
+	} else if sh.Blocks == 0 || sh.Blocks > uint64(PrunedBlockWindow) || sh.Blocks > uint64(sh.Height)+1 {
+		return errSnapshotBlocks
+	}
+
+	// Create the database under a temporary name, so that a failed import
+	// doesn't leave a partial database behind.
+	err = os.MkdirAll(persistDir, 0700)
+	if err != nil {
+		return err
+	}
+	tmpFilename := filename + "_snapshot"
+	if err := os.RemoveAll(tmpFilename); err != nil {
+		return err
+	}
+	db, err := persist.OpenDatabase(dbMetadata, tmpFilename)
+	if err != nil {
+		return err
+	}
+	base, err := readSnapshot(db, tr, sh)
+	if err == nil {
+		// The checksum covers everything before it.
+		var checksum, expected crypto.Hash
+		copy(expected[:], h.Sum(nil))
+		err = encoding.NewDecoder(br, snapshotMaxRecordSize).Decode(&checksum)
+		if err == nil && checksum != expected {
+			err = errSnapshotChecksum
+		}
+	}
+	if err == nil {
+		err = db.Update(func(tx *bolt.Tx) error {
+			return finishSnapshotImport(tx, sh, base)
+		})
+	}
+	err = errors.Compose(err, db.Close())
+	if err != nil {
+		return errors.Compose(err, os.RemoveAll(tmpFilename))
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+// readSnapshot reads the state buckets and the blocks of a snapshot from r and
+// writes them to db in batches. The state which is needed to compute the diffs
+// of the base entry is copied into the SnapshotBase bucket. It returns the
+// processed snapshot block.
+func readSnapshot(db *persist.BoltDatabase, r io.Reader, sh snapshotHeader) (processedBlock, error) {
+	for i := uint64(0); i < sh.Buckets; i++ {
+		var bh snapshotBucketHeader
+		if err := encoding.NewDecoder(r, snapshotMaxRecordSize).Decode(&bh); err != nil {
+			return processedBlock{}, errors.AddContext(err, "unable to read snapshot bucket")
+		}
+		if !isSnapshotStateBucket(bh.Name) {
+			return processedBlock{}, errSnapshotBucket
+		}
+		remaining := bh.Entries
+		for created := false; !created || remaining > 0; created = true {
+			err := db.Update(func(tx *bolt.Tx) error {
+				b, err := tx.CreateBucketIfNotExists(bh.Name)
+				if err != nil {
+					return err
+				}
+				var base *bolt.Bucket
+				if isSnapshotBaseBucket(bh.Name) {
+					sb, err := tx.CreateBucketIfNotExists(SnapshotBase)
+					if err != nil {
+						return err
+					}
+					base, err = sb.CreateBucketIfNotExists(bh.Name)
+					if err != nil {
+						return err
+					}
+				}
+				for j := 0; j < snapshotBatchSize && remaining > 0; j, remaining = j+1, remaining-1 {
+					d := encoding.NewDecoder(r, snapshotMaxRecordSize)
+					k, v := d.ReadPrefixedBytes(), d.ReadPrefixedBytes()
+					if err := d.Err(); err != nil {
+						return errors.AddContext(err, "unable to read snapshot entry")
+					}
+					if err := b.Put(k, v); err != nil {
+						return err
+					}
+					if base == nil {
+						continue
+					}
+					if err := base.Put(k, v); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return processedBlock{}, err
+			}
+		}
+	}
+
+	// Check that the blocks form a chain which ends with the snapshot block
+	// while writing them.
+	var pb processedBlock
+	first := sh.Height + 1 - types.BlockHeight(sh.Blocks)
+	for i := uint64(0); i < sh.Blocks; {
+		err := db.Update(func(tx *bolt.Tx) error {
+			blockMap, err := tx.CreateBucketIfNotExists(BlockMap)
+			if err != nil {
+				return err
+			}
+			oak, err := tx.CreateBucketIfNotExists(BucketOak)
+			if err != nil {
+				return err
+			}
+			for j := 0; j < snapshotBlockBatchSize && i < sh.Blocks; i, j = i+1, j+1 {
+				d := encoding.NewDecoder(r, snapshotMaxRecordSize)
+				pbBytes, oakBytes := d.ReadPrefixedBytes(), d.ReadPrefixedBytes()
+				if err := d.Err(); err != nil {
+					return errors.AddContext(err, "unable to read snapshot block")
+				}
+				parentID := pb.Block.ID()
+				pb = processedBlock{}
+				if err := encoding.Unmarshal(pbBytes, &pb); err != nil {
+					return errors.Compose(errSnapshotBlocks, err)
+				}
+				if pb.Height != first+types.BlockHeight(i) || !pb.DiffsGenerated || (i > 0 && pb.Block.ParentID != parentID) {
+					return errSnapshotBlocks
+				}
+				id := pb.Block.ID()
+				if err := blockMap.Put(id[:], encoding.Marshal(pb)); err != nil {
+					return err
+				}
+				if err := oak.Put(id[:], oakBytes); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return processedBlock{}, err
+		}
+	}
+	if pb.Block.ID() != sh.BlockID {
+		return processedBlock{}, errSnapshotBlocks
+	}
+	return pb, nil
+}
+
+// finishSnapshotImport checks the imported state of a snapshot and adds the
+// remaining buckets of a consensus database, starting the changelog with the
+// base entry.
+func finishSnapshotImport(tx *bolt.Tx, sh snapshotHeader, base processedBlock) error {
+	for _, name := range snapshotStateBuckets {
+		if tx.Bucket(name) == nil {
+			return errors.New("snapshot is missing a bucket")
+		}
+	}
+	if blockHeight(tx) != sh.Height || consensusChecksum(tx) != sh.Checksum {
+		return errSnapshotChecksum
+	}
+	for h := sh.Height + 1 - types.BlockHeight(sh.Blocks); h <= sh.Height; h++ {
+		id, err := getPath(tx, h)
+		if err != nil || tx.Bucket(BlockMap).Get(id[:]) == nil {
+			return errSnapshotBlocks
+		}
+	}
+	if err := tx.Bucket(BucketOak).Put(FieldOakInit, ValueOakInit); err != nil {
+		return err
+	}
+	consistency, err := tx.CreateBucket(Consistency)
+	if err != nil {
+		return err
+	}
+	if err := consistency.Put(Consistency, encoding.Marshal(false)); err != nil {
+		return err
+	}
+
+	// Start the changelog with the snapshot block as the base entry.
+	entry := changeEntry{AppliedBlocks: []types.BlockID{sh.BlockID}}
+	entryID := entry.ID()
+	sb, err := tx.CreateBucketIfNotExists(SnapshotBase)
+	if err != nil {
+		return err
+	}
+	for _, name := range [][]byte{SiacoinOutputs, FileContracts, SiafundOutputs, SiafundPool} {
+		if _, err := sb.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	if err := sb.Put(SnapshotBase, encoding.Marshal(withoutDiffs(base))); err != nil {
+		return err
+	}
+	if err := sb.Put(SnapshotEntryID, entryID[:]); err != nil {
+		return err
+	}
+	cl, err := tx.CreateBucket(ChangeLog)
+	if err != nil {
+		return err
+	}
+	if err := cl.Put(entryID[:], encoding.Marshal(changeNode{Entry: entry})); err != nil {
+		return err
+	}
+	if err := cl.Put(ChangeLogTailID, entryID[:]); err != nil {
+		return err
+	}
+	return cl.Put(ChangeLogHeadID, entryID[:])
+}
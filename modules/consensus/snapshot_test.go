@@ -0,0 +1,228 @@
+package consensus
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// TestConsensusSnapshot checks that a consensus set which is bootstrapped from
+// a snapshot continues syncing from the snapshot height and provides the same
+// state to its subscribers as a fully synced consensus set.
+func TestConsensusSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for cst.cs.Height() < 2*PrunedBlockWindow {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Export a snapshot below the current height.
+	height := cst.cs.Height() - 5
+	snapshotBlock, _ := cst.cs.BlockAtHeight(height)
+	var buf bytes.Buffer
+	if err := cst.cs.ExportSnapshot(&buf, height); err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.ExportSnapshot(&bytes.Buffer{}, cst.cs.Height()+1); !errors.Contains(err, errSnapshotHeight) {
+		t.Fatal("expected errSnapshotHeight, got", err)
+	}
+
+	// The snapshot has to match the trusted id and must not be corrupted.
+	dir := filepath.Join(cst.persistDir, "bootstrapped")
+	csDir := filepath.Join(dir, modules.ConsensusDir)
+	err = ImportSnapshot(bytes.NewReader(buf.Bytes()), csDir, cst.cs.CurrentBlock().ID())
+	if !errors.Contains(err, errSnapshotUntrusted) {
+		t.Fatal("expected errSnapshotUntrusted, got", err)
+	}
+	corrupted := append([]byte(nil), buf.Bytes()...)
+	corrupted[len(corrupted)-1]++
+	err = ImportSnapshot(bytes.NewReader(corrupted), csDir, snapshotBlock.ID())
+	if !errors.Contains(err, errSnapshotChecksum) {
+		t.Fatal("expected errSnapshotChecksum, got", err)
+	}
+	corrupted = append([]byte(nil), buf.Bytes()...)
+	corrupted[len(corrupted)/2]++
+	if err := ImportSnapshot(bytes.NewReader(corrupted), csDir, snapshotBlock.ID()); err == nil {
+		t.Fatal("corrupted snapshot was imported")
+	}
+	truncated := buf.Bytes()[:buf.Len()/2]
+	if err := ImportSnapshot(bytes.NewReader(truncated), csDir, snapshotBlock.ID()); err == nil {
+		t.Fatal("truncated snapshot was imported")
+	}
+	if err := ImportSnapshot(bytes.NewReader(buf.Bytes()), csDir, snapshotBlock.ID()); err != nil {
+		t.Fatal(err)
+	}
+	err = ImportSnapshot(bytes.NewReader(buf.Bytes()), csDir, snapshotBlock.ID())
+	if !errors.Contains(err, ErrSnapshotDatabaseExists) {
+		t.Fatal("expected ErrSnapshotDatabaseExists, got", err)
+	}
+
+	// Open the imported database and feed it the remaining blocks.
+	g, err := gateway.New("localhost:0", false, filepath.Join(dir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, errChan := NewCustomConsensusSet(g, false, csDir, modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := errors.Compose(cs.Close(), g.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cs.Height() != height || cs.CurrentBlock().ID() != snapshotBlock.ID() {
+		t.Fatal("bootstrapped consensus set doesn't start at the snapshot", cs.Height(), height)
+	}
+	if _, exists := cs.BlockAtHeight(height - PrunedBlockWindow); exists {
+		t.Fatal("bootstrapped consensus set has blocks below the snapshot")
+	}
+	ms := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	for h := height + 1; h <= cst.cs.Height(); h++ {
+		b, _ := cst.cs.BlockAtHeight(h)
+		if err := cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cs.CurrentBlock().ID() != cst.cs.CurrentBlock().ID() {
+		t.Fatal("bootstrapped consensus set didn't follow the chain")
+	}
+	if len(ms.updates) != 6 || ms.updates[0].BlockHeight != height {
+		t.Fatal("wrong changes after subscribing to the bootstrapped consensus set", len(ms.updates))
+	}
+
+	// The subscriber sees the same outputs as a subscriber of the full
+	// consensus set.
+	full := newMockSubscriber()
+	if err := cst.cs.ConsensusSetSubscribe(&full, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&full)
+	scos, sfos := subscriberOutputs(ms.updates)
+	fullSCOs, fullSFOs := subscriberOutputs(full.updates)
+	if !reflect.DeepEqual(scos, fullSCOs) || !reflect.DeepEqual(sfos, fullSFOs) {
+		t.Fatal("subscriber of the bootstrapped consensus set has different outputs", len(scos), len(fullSCOs))
+	}
+}
+
+// subscriberOutputs returns the siacoin and siafund outputs which result from
+// the diffs of the consensus changes.
+func subscriberOutputs(updates []modules.ConsensusChange) (map[types.SiacoinOutputID]types.SiacoinOutput, map[types.SiafundOutputID]types.SiafundOutput) {
+	scos := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	sfos := make(map[types.SiafundOutputID]types.SiafundOutput)
+	for _, cc := range updates {
+		for _, diff := range cc.SiacoinOutputDiffs {
+			if diff.Direction == modules.DiffApply {
+				scos[diff.ID] = diff.SiacoinOutput
+			} else {
+				delete(scos, diff.ID)
+			}
+		}
+		for _, diff := range cc.SiafundOutputDiffs {
+			if diff.Direction == modules.DiffApply {
+				sfos[diff.ID] = diff.SiafundOutput
+			} else {
+				delete(sfos, diff.ID)
+			}
+		}
+	}
+	return scos, sfos
+}
+
+// snapshotBlockWriter is a writer which mines a block on the first write.
+type snapshotBlockWriter struct {
+	bytes.Buffer
+	mine  func() error
+	err   error
+	mined bool
+}
+
+// Write implements io.Writer.
+func (w *snapshotBlockWriter) Write(p []byte) (int, error) {
+	if !w.mined {
+		w.mined = true
+		w.err = w.mine()
+	}
+	return w.Buffer.Write(p)
+}
+
+// TestConsensusSnapshotConcurrentBlocks checks that the consensus set accepts
+// blocks while a snapshot is being written and that the snapshot isn't
+// affected by them.
+func TestConsensusSnapshotConcurrentBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	height := cst.cs.Height()
+	snapshotBlock := cst.cs.CurrentBlock()
+	w := &snapshotBlockWriter{mine: func() error {
+		_, err := cst.miner.AddBlock()
+		return err
+	}}
+	if err := cst.cs.ExportSnapshot(w, height); err != nil {
+		t.Fatal(err)
+	}
+	if w.err != nil {
+		t.Fatal(w.err)
+	}
+	if cst.cs.Height() != height+1 {
+		t.Fatal("block wasn't accepted during the export")
+	}
+
+	// The snapshot contains the state at the requested height.
+	dir := filepath.Join(cst.persistDir, "bootstrapped")
+	csDir := filepath.Join(dir, modules.ConsensusDir)
+	if err := ImportSnapshot(bytes.NewReader(w.Bytes()), csDir, snapshotBlock.ID()); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gateway.New("localhost:0", false, filepath.Join(dir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, errChan := NewCustomConsensusSet(g, false, csDir, modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := errors.Compose(cs.Close(), g.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cs.Height() != height || cs.CurrentBlock().ID() != snapshotBlock.ID() {
+		t.Fatal("bootstrapped consensus set doesn't start at the snapshot", cs.Height(), height)
+	}
+}
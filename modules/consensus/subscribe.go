@@ -62,6 +62,14 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 	cc := modules.ConsensusChange{
 		ID: ce.ID(),
 	}
+
+	// The base entry applies the whole consensus state after it in place of
+	// the blocks it reverted and applied.
+	baseID, hasBase := baseEntry(tx)
+	isBase := hasBase && cc.ID == baseID
+	if isBase {
+		ce = changeEntry{AppliedBlocks: ce.AppliedBlocks[len(ce.AppliedBlocks)-1:]}
+	}
 	for _, revertedBlockID := range ce.RevertedBlocks {
 		revertedBlock, err := getBlockMap(tx, revertedBlockID)
 		if err != nil {
@@ -75,6 +83,9 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 	}
 	for _, appliedBlockID := range ce.AppliedBlocks {
 		appliedBlock, err := getBlockMap(tx, appliedBlockID)
+		if err == nil && isBase {
+			appliedBlock, err = baseEntryBlock(tx)
+		}
 		if err != nil {
 			cs.log.Critical("getBlockMap failed in computeConsensusChange:", err)
 			return modules.ConsensusChange{}, err
@@ -163,6 +174,13 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 			// the genesis block.
 			entry = cs.genesisEntry()
 			exists = true
+			if id, ok := baseEntry(tx); ok {
+				// Bootstrapped and pruned consensus sets start with the base
+				// entry, which contains the whole consensus state after it.
+				entry, exists = getEntry(tx, id)
+			} else if cs.changeLogHead(tx) != entry.ID() {
+				return modules.ErrPrunedConsensusChange
+			}
 		} else {
 			// The subscriber has provided an existing consensus change.
 			// Because the subscriber already has this consensus change,
@@ -198,6 +216,10 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 		// lock for too long.
 		cs.mu.RLock()
 		err = cs.db.View(func(tx *bolt.Tx) error {
+			// The entry might have been pruned while the lock was released.
+			if _, stillExists := getEntry(tx, entry.ID()); !stillExists {
+				return modules.ErrPrunedConsensusChange
+			}
 			for i := 0; i < 100 && exists; i++ {
 				latestChangeID = entry.ID()
 				select {
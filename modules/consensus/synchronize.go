@@ -294,6 +294,7 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 
 	// Find the most recent block from knownBlocks in the current path.
 	found := false
+	upToDate := false
 	var start types.BlockHeight
 	var csHeight types.BlockHeight
 	cs.mu.RLock()
@@ -312,6 +313,7 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 				continue
 			}
 			if pb.Height == csHeight {
+				upToDate = true
 				break
 			}
 			found = true
@@ -326,6 +328,13 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 		return err
 	}
 
+	// A pruned or bootstrapped consensus set can't help callers which don't
+	// know any of its remaining blocks. Returning an error instead of sending 0
+	// blocks prevents them from considering themselves synced.
+	if !found && !upToDate && (cs.pruned || cs.bootstrapped) {
+		return errPrunedBlocks
+	}
+
 	// If no matching blocks are found, or if the caller has all known blocks,
 	// don't send any blocks.
 	if !found {
@@ -353,6 +362,11 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 					return err
 				}
 				pb, err := getBlockMap(tx, id)
+				if err != nil && cs.pruned {
+					// The blocks might have been pruned while the lock was
+					// released.
+					return errPrunedBlocks
+				}
 				if err != nil {
 					cs.log.Critical("Unable to get block from block map: height", height, ":: request", i, ":: id", id)
 					return err
@@ -45,6 +45,52 @@ type (
 		TotalRevisionVolume types.Currency `json:"totalrevisionvolume"`
 	}
 
+	// ExplorerAddress contains the running balances of an address.
+	ExplorerAddress struct {
+		UnlockHash         types.UnlockHash `json:"unlockhash"`
+		SiacoinBalance     types.Currency   `json:"siacoinbalance"`
+		SiafundBalance     types.Currency   `json:"siafundbalance"`
+		TotalReceived      types.Currency   `json:"totalreceived"`
+		TotalSent          types.Currency   `json:"totalsent"`
+		TransactionCount   uint64           `json:"transactioncount"`
+		UnspentOutputCount uint64           `json:"unspentoutputcount"`
+	}
+
+	// ExplorerAddressTransaction is a transaction which involves an address.
+	// Miner payouts are listed with the id of the block that created them.
+	ExplorerAddressTransaction struct {
+		ID     types.TransactionID `json:"id"`
+		Height types.BlockHeight   `json:"height"`
+	}
+
+	// ExplorerOutput is a siacoin or siafund output of an address. Height is
+	// the height at which the output became spendable.
+	ExplorerOutput struct {
+		ID          types.OutputID    `json:"id"`
+		FundType    types.Specifier   `json:"fundtype"`
+		Value       types.Currency    `json:"value"`
+		Height      types.BlockHeight `json:"height"`
+		Spent       bool              `json:"spent"`
+		SpentHeight types.BlockHeight `json:"spentheight"`
+	}
+
+	// ExplorerHistoryFilter limits the history of an address to a range of
+	// block heights and a page of entries. Entries are ordered from newest to
+	// oldest, height bounds are inclusive and a zero limit returns all entries.
+	ExplorerHistoryFilter struct {
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+		Offset      uint64            `json:"offset"`
+		Limit       uint64            `json:"limit"`
+	}
+
+	// ExplorerStatsPoint is a point of a statistics series. It contains the
+	// block facts of the last block of the step which starts at Timestamp.
+	ExplorerStatsPoint struct {
+		Timestamp types.Timestamp `json:"timestamp"`
+		BlockFacts
+	}
+
 	// Explorer tracks the blockchain and provides tools for gathering
 	// statistics and finding objects or patterns within the blockchain.
 	Explorer interface {
@@ -62,6 +108,10 @@ type (
 		// in the explorer's database.
 		LatestBlockFacts() BlockFacts
 
+		// Stats returns a series of block facts between two timestamps,
+		// downsampled to steps of the provided number of seconds.
+		Stats(start, end types.Timestamp, step uint64) ([]ExplorerStatsPoint, error)
+
 		// Transaction returns the block that contains the input transaction
 		// id. The transaction itself is either the block (indicating the miner
 		// payouts are somehow involved), or it is a transaction inside of the
@@ -73,6 +123,19 @@ type (
 		// provided unlock hash.
 		UnlockHash(types.UnlockHash) []types.TransactionID
 
+		// Address returns the running balances of the provided unlock hash.
+		// The bool indicates whether the unlock hash appears in the
+		// blockchain.
+		Address(types.UnlockHash) (ExplorerAddress, bool)
+
+		// AddressTransactions returns the transactions of the provided unlock
+		// hash which match the filter.
+		AddressTransactions(types.UnlockHash, ExplorerHistoryFilter) ([]ExplorerAddressTransaction, error)
+
+		// AddressOutputs returns either the unspent or the spent outputs of
+		// the provided unlock hash which match the filter.
+		AddressOutputs(uh types.UnlockHash, spent bool, filter ExplorerHistoryFilter) ([]ExplorerOutput, error)
+
 		// SiacoinOutput will return the siacoin output associated with the
 		// input id.
 		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
@@ -0,0 +1,306 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// The address index consists of a running balance per address and of one
+// nested bucket per address for its transactions, unspent outputs and spent
+// outputs. The keys of the nested buckets are prefixed with the big-endian
+// block height, which allows for ranged and paginated queries without
+// decoding the whole history of an address.
+
+// heightKey returns the key of an entry of the address index.
+func heightKey(height types.BlockHeight, id crypto.Hash) []byte {
+	key := make([]byte, 8+crypto.HashSize)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	copy(key[8:], id[:])
+	return key
+}
+
+// bucketHasKey returns whether the bucket contains the key. Bucket.Get can't
+// be used for this as the sets of the index are stored with nil values.
+func bucketHasKey(bucket *bolt.Bucket, key []byte) bool {
+	k, _ := bucket.Cursor().Seek(key)
+	return bytes.Equal(k, key)
+}
+
+// dbForEachInRange calls fn for the entries of the bucket which match the
+// filter, starting with the entry at the greatest height.
+func dbForEachInRange(bucket *bolt.Bucket, filter modules.ExplorerHistoryFilter, fn func(k, v []byte) error) error {
+	if bucket == nil || filter.StartHeight > filter.EndHeight {
+		return nil
+	}
+	c := bucket.Cursor()
+	var k, v []byte
+	if filter.EndHeight == math.MaxUint64 {
+		k, v = c.Last()
+	} else {
+		end := make([]byte, 8)
+		binary.BigEndian.PutUint64(end, uint64(filter.EndHeight)+1)
+		if k, _ = c.Seek(end); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+	var skipped, n uint64
+	for ; k != nil; k, v = c.Prev() {
+		if types.BlockHeight(binary.BigEndian.Uint64(k)) < filter.StartHeight {
+			break
+		} else if filter.Limit != 0 && n >= filter.Limit {
+			break
+		} else if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+		n++
+	}
+	return nil
+}
+
+// dbGetAddress returns the running balances of an address.
+func dbGetAddress(tx *bolt.Tx, uh types.UnlockHash) modules.ExplorerAddress {
+	var ea modules.ExplorerAddress
+	if err := dbGetAndDecode(bucketAddressBalances, uh, &ea)(tx); err == errNotExist {
+		ea.UnlockHash = uh
+	} else {
+		assertNil(err)
+	}
+	return ea
+}
+
+// dbPutAddress stores the running balances of an address. Addresses which
+// don't appear in the blockchain anymore are removed.
+func dbPutAddress(tx *bolt.Tx, ea modules.ExplorerAddress) {
+	empty := ea.TransactionCount == 0 && ea.UnspentOutputCount == 0 && ea.SiafundBalance.IsZero() &&
+		ea.SiacoinBalance.IsZero() && ea.TotalReceived.IsZero() && ea.TotalSent.IsZero()
+	if empty {
+		mustDelete(tx.Bucket(bucketAddressBalances), ea.UnlockHash)
+		return
+	}
+	mustPut(tx.Bucket(bucketAddressBalances), ea.UnlockHash, ea)
+}
+
+// Add/Remove txid from the transactions of an address
+func dbAddAddressTransaction(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
+	b, err := tx.Bucket(bucketAddressTransactions).CreateBucketIfNotExists(encoding.Marshal(uh))
+	assertNil(err)
+	key := heightKey(height, crypto.Hash(txid))
+	if bucketHasKey(b, key) {
+		return
+	}
+	assertNil(b.Put(key, nil))
+	ea := dbGetAddress(tx, uh)
+	ea.TransactionCount++
+	dbPutAddress(tx, ea)
+}
+func dbRemoveAddressTransaction(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
+	b := tx.Bucket(bucketAddressTransactions).Bucket(encoding.Marshal(uh))
+	key := heightKey(height, crypto.Hash(txid))
+	if b == nil || !bucketHasKey(b, key) {
+		return
+	}
+	assertNil(b.Delete(key))
+	if bucketIsEmpty(b) {
+		assertNil(tx.Bucket(bucketAddressTransactions).DeleteBucket(encoding.Marshal(uh)))
+	}
+	ea := dbGetAddress(tx, uh)
+	ea.TransactionCount--
+	dbPutAddress(tx, ea)
+}
+
+// dbPutAddressOutput puts an output of an address into a bucket of the index.
+func dbPutAddressOutput(tx *bolt.Tx, bucket []byte, uh types.UnlockHash, eo modules.ExplorerOutput) {
+	b, err := tx.Bucket(bucket).CreateBucketIfNotExists(encoding.Marshal(uh))
+	assertNil(err)
+	assertNil(b.Put(heightKey(eo.Height, crypto.Hash(eo.ID)), encoding.Marshal(eo)))
+}
+
+// dbTakeAddressOutput removes an output of an address from a bucket of the
+// index and returns it.
+func dbTakeAddressOutput(tx *bolt.Tx, bucket []byte, uh types.UnlockHash, id types.OutputID) modules.ExplorerOutput {
+	var height types.BlockHeight
+	assertNil(dbGetAndDecode(bucketOutputHeights, id, &height)(tx))
+	b := tx.Bucket(bucket).Bucket(encoding.Marshal(uh))
+	if b == nil {
+		panic("address index is missing outputs of " + uh.String())
+	}
+	key := heightKey(height, crypto.Hash(id))
+	var eo modules.ExplorerOutput
+	assertNil(encoding.Unmarshal(b.Get(key), &eo))
+	assertNil(b.Delete(key))
+	if bucketIsEmpty(b) {
+		assertNil(tx.Bucket(bucket).DeleteBucket(encoding.Marshal(uh)))
+	}
+	return eo
+}
+
+// dbAddAddressOutput adds an output which became spendable to the unspent
+// outputs of an address.
+func dbAddAddressOutput(tx *bolt.Tx, uh types.UnlockHash, eo modules.ExplorerOutput) {
+	mustPut(tx.Bucket(bucketOutputHeights), eo.ID, eo.Height)
+	dbPutAddressOutput(tx, bucketAddressOutputs, uh, eo)
+
+	ea := dbGetAddress(tx, uh)
+	if eo.FundType == types.SpecifierSiafundOutput {
+		ea.SiafundBalance = ea.SiafundBalance.Add(eo.Value)
+	} else {
+		ea.SiacoinBalance = ea.SiacoinBalance.Add(eo.Value)
+		ea.TotalReceived = ea.TotalReceived.Add(eo.Value)
+	}
+	ea.UnspentOutputCount++
+	dbPutAddress(tx, ea)
+}
+
+// dbRemoveAddressOutput removes an unspent output of an address because the
+// block that created it was reverted.
+func dbRemoveAddressOutput(tx *bolt.Tx, uh types.UnlockHash, id types.OutputID) {
+	eo := dbTakeAddressOutput(tx, bucketAddressOutputs, uh, id)
+	mustDelete(tx.Bucket(bucketOutputHeights), id)
+
+	ea := dbGetAddress(tx, uh)
+	if eo.FundType == types.SpecifierSiafundOutput {
+		ea.SiafundBalance = ea.SiafundBalance.Sub(eo.Value)
+	} else {
+		ea.SiacoinBalance = ea.SiacoinBalance.Sub(eo.Value)
+		ea.TotalReceived = ea.TotalReceived.Sub(eo.Value)
+	}
+	ea.UnspentOutputCount--
+	dbPutAddress(tx, ea)
+}
+
+// dbSpendAddressOutput moves an output of an address which was spent at the
+// given height to its spent outputs.
+func dbSpendAddressOutput(tx *bolt.Tx, uh types.UnlockHash, id types.OutputID, height types.BlockHeight) {
+	eo := dbTakeAddressOutput(tx, bucketAddressOutputs, uh, id)
+	eo.Spent = true
+	eo.SpentHeight = height
+	dbPutAddressOutput(tx, bucketAddressSpentOutputs, uh, eo)
+
+	ea := dbGetAddress(tx, uh)
+	if eo.FundType == types.SpecifierSiafundOutput {
+		ea.SiafundBalance = ea.SiafundBalance.Sub(eo.Value)
+	} else {
+		ea.SiacoinBalance = ea.SiacoinBalance.Sub(eo.Value)
+		ea.TotalSent = ea.TotalSent.Add(eo.Value)
+	}
+	ea.UnspentOutputCount--
+	dbPutAddress(tx, ea)
+}
+
+// dbUnspendAddressOutput moves a spent output of an address back to its
+// unspent outputs because the block that spent it was reverted.
+func dbUnspendAddressOutput(tx *bolt.Tx, uh types.UnlockHash, id types.OutputID) {
+	eo := dbTakeAddressOutput(tx, bucketAddressSpentOutputs, uh, id)
+	eo.Spent = false
+	eo.SpentHeight = 0
+	dbPutAddressOutput(tx, bucketAddressOutputs, uh, eo)
+
+	ea := dbGetAddress(tx, uh)
+	if eo.FundType == types.SpecifierSiafundOutput {
+		ea.SiafundBalance = ea.SiafundBalance.Add(eo.Value)
+	} else {
+		ea.SiacoinBalance = ea.SiacoinBalance.Add(eo.Value)
+		ea.TotalSent = ea.TotalSent.Sub(eo.Value)
+	}
+	ea.UnspentOutputCount++
+	dbPutAddress(tx, ea)
+}
+
+// dbApplyAddressOutputDiffs updates the outputs of the address index
+// according to the diffs of a block at the given height. If the block is
+// reverted the diffs are expected to be inverted already.
+func dbApplyAddressOutputDiffs(tx *bolt.Tx, diffs modules.ConsensusChangeDiffs, height types.BlockHeight, revert bool) {
+	update := func(dir modules.DiffDirection, uh types.UnlockHash, eo modules.ExplorerOutput) {
+		switch {
+		case dir == modules.DiffApply && !revert:
+			dbAddAddressOutput(tx, uh, eo)
+		case dir == modules.DiffRevert && !revert:
+			dbSpendAddressOutput(tx, uh, eo.ID, height)
+		case dir == modules.DiffApply && revert:
+			dbUnspendAddressOutput(tx, uh, eo.ID)
+		case dir == modules.DiffRevert && revert:
+			dbRemoveAddressOutput(tx, uh, eo.ID)
+		}
+	}
+	for _, scod := range diffs.SiacoinOutputDiffs {
+		update(scod.Direction, scod.SiacoinOutput.UnlockHash, modules.ExplorerOutput{
+			ID:       types.OutputID(scod.ID),
+			FundType: types.SpecifierSiacoinOutput,
+			Value:    scod.SiacoinOutput.Value,
+			Height:   height,
+		})
+	}
+	for _, sfod := range diffs.SiafundOutputDiffs {
+		update(sfod.Direction, sfod.SiafundOutput.UnlockHash, modules.ExplorerOutput{
+			ID:       types.OutputID(sfod.ID),
+			FundType: types.SpecifierSiafundOutput,
+			Value:    sfod.SiafundOutput.Value,
+			Height:   height,
+		})
+	}
+}
+
+// Address returns the running balances of an address and a bool indicating
+// whether the address appears in the blockchain.
+func (e *Explorer) Address(uh types.UnlockHash) (modules.ExplorerAddress, bool) {
+	var ea modules.ExplorerAddress
+	err := e.db.View(dbGetAndDecode(bucketAddressBalances, uh, &ea))
+	if err != nil {
+		return modules.ExplorerAddress{UnlockHash: uh}, false
+	}
+	return ea, true
+}
+
+// AddressTransactions returns the transactions of an address which match the
+// filter, newest first.
+func (e *Explorer) AddressTransactions(uh types.UnlockHash, filter modules.ExplorerHistoryFilter) ([]modules.ExplorerAddressTransaction, error) {
+	var txns []modules.ExplorerAddressTransaction
+	err := e.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAddressTransactions).Bucket(encoding.Marshal(uh))
+		return dbForEachInRange(b, filter, func(k, _ []byte) error {
+			var txn modules.ExplorerAddressTransaction
+			txn.Height = types.BlockHeight(binary.BigEndian.Uint64(k))
+			copy(txn.ID[:], k[8:])
+			txns = append(txns, txn)
+			return nil
+		})
+	})
+	return txns, err
+}
+
+// AddressOutputs returns either the unspent or the spent outputs of an
+// address which match the filter, ordered by the height at which they became
+// spendable, newest first.
+func (e *Explorer) AddressOutputs(uh types.UnlockHash, spent bool, filter modules.ExplorerHistoryFilter) ([]modules.ExplorerOutput, error) {
+	bucket := bucketAddressOutputs
+	if spent {
+		bucket = bucketAddressSpentOutputs
+	}
+	var outputs []modules.ExplorerOutput
+	err := e.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket(encoding.Marshal(uh))
+		return dbForEachInRange(b, filter, func(_, v []byte) error {
+			var eo modules.ExplorerOutput
+			if err := encoding.Unmarshal(v, &eo); err != nil {
+				return err
+			}
+			outputs = append(outputs, eo)
+			return nil
+		})
+	})
+	return outputs, err
+}
@@ -0,0 +1,139 @@
+package explorer
+
+import (
+	"math"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAddressIndex checks that the explorer tracks the balances, transactions
+// and outputs of addresses and that the index is paginated.
+func TestAddressIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send coins to an address in three different blocks.
+	var uh types.UnlockHash
+	fastrand.Read(uh[:])
+	amount := types.SiacoinPrecision.Mul64(10)
+	var heights []types.BlockHeight
+	var txns []types.Transaction
+	for i := 0; i < 3; i++ {
+		txns, err = et.wallet.SendSiacoins(amount, uh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		heights = append(heights, et.cs.Height())
+	}
+
+	ea, exists := et.explorer.Address(uh)
+	if !exists {
+		t.Fatal("address not found")
+	}
+	if !ea.SiacoinBalance.Equals(amount.Mul64(3)) || !ea.TotalReceived.Equals(amount.Mul64(3)) || !ea.TotalSent.IsZero() {
+		t.Fatal("wrong balance", ea.SiacoinBalance, ea.TotalReceived, ea.TotalSent)
+	}
+	if ea.TransactionCount != 3 || ea.UnspentOutputCount != 3 {
+		t.Fatal("wrong counts", ea.TransactionCount, ea.UnspentOutputCount)
+	}
+
+	// Fetch the transactions page by page, newest first.
+	all := modules.ExplorerHistoryFilter{EndHeight: math.MaxUint64}
+	page := all
+	page.Limit = 2
+	eats, err := et.explorer.AddressTransactions(uh, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eats) != 2 || eats[0].Height != heights[2] || eats[1].Height != heights[1] {
+		t.Fatal("wrong first page", eats)
+	}
+	page.Offset = 2
+	eats, err = et.explorer.AddressTransactions(uh, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eats) != 1 || eats[0].Height != heights[0] {
+		t.Fatal("wrong second page", eats)
+	}
+
+	// Filter by height.
+	ranged := modules.ExplorerHistoryFilter{StartHeight: heights[1], EndHeight: heights[1]}
+	eats, err = et.explorer.AddressTransactions(uh, ranged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eats) != 1 || eats[0].Height != heights[1] {
+		t.Fatal("wrong transactions in range", eats)
+	}
+	outputs, err := et.explorer.AddressOutputs(uh, false, ranged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || !outputs[0].Value.Equals(amount) || outputs[0].Spent {
+		t.Fatal("wrong outputs in range", outputs)
+	}
+
+	// The outputs which funded the last transaction are spent.
+	sci := txns[len(txns)-1].SiacoinInputs[0]
+	spender := sci.UnlockConditions.UnlockHash()
+	spent, err := et.explorer.AddressOutputs(spender, true, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, eo := range spent {
+		found = found || (eo.ID == types.OutputID(sci.ParentID) && eo.Spent && eo.SpentHeight == heights[2])
+	}
+	if !found {
+		t.Fatal("spent output not found", spent)
+	}
+	ea, _ = et.explorer.Address(spender)
+	if ea.TotalSent.IsZero() {
+		t.Fatal("spending address has no outgoing coins")
+	}
+
+	// Reorg the explorer to a longer chain which doesn't contain the
+	// transactions. The address shouldn't appear in the blockchain anymore.
+	et2, err := createExplorerTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for et2.cs.Height() <= et.cs.Height() {
+		if _, err := et2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for h := types.BlockHeight(1); h <= et2.cs.Height(); h++ {
+		b, _ := et2.cs.BlockAtHeight(h)
+		if err := et.cs.AcceptBlock(b); err != nil && !errors.Contains(err, modules.ErrNonExtendingBlock) {
+			t.Fatal(err)
+		}
+	}
+	if et.cs.CurrentBlock().ID() != et2.cs.CurrentBlock().ID() {
+		t.Fatal("reorg failed")
+	}
+	if _, exists := et.explorer.Address(uh); exists {
+		t.Fatal("address still exists after reorg")
+	}
+	if eats, _ := et.explorer.AddressTransactions(uh, all); len(eats) != 0 {
+		t.Fatal("transactions still exist after reorg", eats)
+	}
+	if ea, exists := et.explorer.Address(spender); exists && ea.UnspentOutputCount != 0 {
+		t.Fatal("outputs of the reverted chain still exist", ea)
+	}
+}
@@ -11,20 +11,27 @@ import (
 
 var (
 	// database buckets
+	bucketAddressBalances       = []byte("AddressBalances")
+	bucketAddressOutputs        = []byte("AddressOutputs")
+	bucketAddressSpentOutputs   = []byte("AddressSpentOutputs")
+	bucketAddressTransactions   = []byte("AddressTransactions")
 	bucketBlockFacts            = []byte("BlockFacts")
 	bucketBlockIDs              = []byte("BlockIDs")
 	bucketBlocksDifficulty      = []byte("BlocksDifficulty")
 	bucketBlockTargets          = []byte("BlockTargets")
+	bucketDailyStats            = []byte("DailyStats")
 	bucketFileContractHistories = []byte("FileContractHistories")
 	bucketFileContractIDs       = []byte("FileContractIDs")
 	// bucketInternal is used to store values internal to the explorer
 	bucketInternal         = []byte("Internal")
+	bucketOutputHeights    = []byte("OutputHeights")
 	bucketSiacoinOutputIDs = []byte("SiacoinOutputIDs")
 	bucketSiacoinOutputs   = []byte("SiacoinOutputs")
 	bucketSiafundOutputIDs = []byte("SiafundOutputIDs")
 	bucketSiafundOutputs   = []byte("SiafundOutputs")
 	bucketTransactionIDs   = []byte("TransactionIDs")
 	bucketUnlockHashes     = []byte("UnlockHashes")
+	bucketWeeklyStats      = []byte("WeeklyStats")
 
 	errNotExist = errors.New("entry does not exist")
 
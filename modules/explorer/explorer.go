@@ -70,6 +70,12 @@ func New(cs modules.ConsensusSet, persistDir string) (*Explorer, error) {
 		return nil, err
 	}
 
+	// Compute the statistics rollups if the database predates them.
+	err = e.backfillStatsRollups()
+	if err != nil {
+		return nil, err
+	}
+
 	// retrieve the current ConsensusChangeID
 	var recentChange modules.ConsensusChangeID
 	err = e.db.View(dbGetInternal(internalRecentChange, &recentChange))
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/modules"
@@ -14,7 +15,7 @@ import (
 
 var explorerMetadata = persist.Metadata{
 	Header:  "Sia Explorer",
-	Version: "0.5.2",
+	Version: "1.5.7",
 }
 
 // initPersist initializes the persistent structures of the explorer module.
@@ -25,8 +26,16 @@ func (e *Explorer) initPersist() error {
 		return err
 	}
 
-	// Open the database
-	db, err := persist.OpenDatabase(explorerMetadata, filepath.Join(e.persistDir, "explorer.db"))
+	// Open the database. Databases of older versions don't contain the address
+	// index and are rebuilt from scratch.
+	dbPath := filepath.Join(e.persistDir, "explorer.db")
+	db, err := persist.OpenDatabase(explorerMetadata, dbPath)
+	if errors.Contains(err, persist.ErrBadVersion) {
+		if err := os.Remove(dbPath); err != nil {
+			return err
+		}
+		db, err = persist.OpenDatabase(explorerMetadata, dbPath)
+	}
 	if err != nil {
 		return err
 	}
@@ -35,19 +44,26 @@ func (e *Explorer) initPersist() error {
 	// Initialize the database
 	err = e.db.Update(func(tx *bolt.Tx) error {
 		buckets := [][]byte{
+			bucketAddressBalances,
+			bucketAddressOutputs,
+			bucketAddressSpentOutputs,
+			bucketAddressTransactions,
 			bucketBlockFacts,
 			bucketBlockIDs,
 			bucketBlocksDifficulty,
 			bucketBlockTargets,
+			bucketDailyStats,
 			bucketFileContractHistories,
 			bucketFileContractIDs,
 			bucketInternal,
+			bucketOutputHeights,
 			bucketSiacoinOutputIDs,
 			bucketSiacoinOutputs,
 			bucketSiafundOutputIDs,
 			bucketSiafundOutputs,
 			bucketTransactionIDs,
 			bucketUnlockHashes,
+			bucketWeeklyStats,
 		}
 		for _, b := range buckets {
 			_, err := tx.CreateBucketIfNotExists(b)
@@ -0,0 +1,152 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// statsDay and statsWeek are the lengths in seconds of the periods of the
+	// statistics rollups.
+	statsDay  = 24 * 60 * 60
+	statsWeek = 7 * statsDay
+
+	// maxStatsPoints is the maximum number of points of a statistics series.
+	maxStatsPoints = 10000
+)
+
+var (
+	errInvalidStatsStep = errors.New("step has to be a positive multiple of a day")
+	errTooManyPoints    = errors.New("too many points requested, increase the step or shorten the range")
+)
+
+// statsRollups are the buckets of the statistics rollups and the lengths of
+// their periods. A rollup contains the block facts of the last block whose
+// timestamp falls into the period.
+var statsRollups = []struct {
+	bucket []byte
+	period types.Timestamp
+}{
+	{bucketDailyStats, statsDay},
+	{bucketWeeklyStats, statsWeek},
+}
+
+// rollupKey returns the key of the rollup of the period which contains the
+// timestamp.
+func rollupKey(ts, period types.Timestamp) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(ts-ts%period))
+	return key
+}
+
+// dbAddStatsRollups sets the facts of a new block as the rollups of the
+// periods which contain the block.
+func dbAddStatsRollups(tx *bolt.Tx, facts blockFacts) {
+	for _, r := range statsRollups {
+		assertNil(tx.Bucket(r.bucket).Put(rollupKey(facts.Timestamp, r.period), encoding.Marshal(facts)))
+	}
+}
+
+// dbRemoveStatsRollups removes a reverted block from the rollups of the
+// periods which contain the block. Its parent becomes the last block of a
+// period if it falls into the same period.
+func dbRemoveStatsRollups(tx *bolt.Tx, facts blockFacts, parentID types.BlockID) {
+	var parent blockFacts
+	parentErr := dbGetAndDecode(bucketBlockFacts, parentID, &parent)(tx)
+	for _, r := range statsRollups {
+		b := tx.Bucket(r.bucket)
+		key := rollupKey(facts.Timestamp, r.period)
+		var rollup blockFacts
+		if v := b.Get(key); v == nil || encoding.Unmarshal(v, &rollup) != nil || rollup.BlockID != facts.BlockID {
+			continue
+		}
+		if parentErr == nil && bytes.Equal(rollupKey(parent.Timestamp, r.period), key) {
+			assertNil(b.Put(key, encoding.Marshal(parent)))
+		} else {
+			assertNil(b.Delete(key))
+		}
+	}
+}
+
+// backfillStatsRollups computes the statistics rollups of databases which were
+// created before the explorer maintained them.
+func (e *Explorer) backfillStatsRollups() error {
+	return e.db.Update(func(tx *bolt.Tx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New("failed to compute statistics rollups")
+			}
+		}()
+		var recentChange modules.ConsensusChangeID
+		if err := dbGetInternal(internalRecentChange, &recentChange)(tx); err != nil {
+			return err
+		}
+		if recentChange == (modules.ConsensusChangeID{}) || !bucketIsEmpty(tx.Bucket(bucketDailyStats)) {
+			return nil
+		}
+		var height types.BlockHeight
+		if err := dbGetInternal(internalBlockHeight, &height)(tx); err != nil {
+			return err
+		}
+		for h := types.BlockHeight(0); h <= height; h++ {
+			var bf blockFacts
+			if err := e.dbGetBlockFacts(h, &bf)(tx); err != nil {
+				return err
+			}
+			dbAddStatsRollups(tx, bf)
+		}
+		return nil
+	})
+}
+
+// Stats returns a series of block facts between the start and end timestamps.
+// The series is divided into steps of the provided length in seconds, which
+// are aligned to the unix epoch. Each point contains the facts of the last
+// block of its step, steps without blocks are omitted.
+func (e *Explorer) Stats(start, end types.Timestamp, step uint64) ([]modules.ExplorerStatsPoint, error) {
+	if step == 0 || step%statsDay != 0 {
+		return nil, errInvalidStatsStep
+	}
+	if end < start {
+		return nil, nil
+	}
+	r := statsRollups[0]
+	if step%statsWeek == 0 {
+		r = statsRollups[1]
+	}
+
+	var points []modules.ExplorerStatsPoint
+	err := e.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.bucket).Cursor()
+		for k, v := c.Seek(rollupKey(start, r.period)); k != nil; k, v = c.Next() {
+			ts := types.Timestamp(binary.BigEndian.Uint64(k))
+			if ts > end {
+				break
+			}
+			var bf blockFacts
+			if err := encoding.Unmarshal(v, &bf); err != nil {
+				return err
+			}
+			point := modules.ExplorerStatsPoint{
+				Timestamp:  ts - ts%types.Timestamp(step),
+				BlockFacts: bf.BlockFacts,
+			}
+			if n := len(points); n > 0 && points[n-1].Timestamp == point.Timestamp {
+				points[n-1] = point
+			} else if n == maxStatsPoints {
+				return errTooManyPoints
+			} else {
+				points = append(points, point)
+			}
+		}
+		return nil
+	})
+	return points, err
+}
@@ -0,0 +1,100 @@
+package explorer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestStats checks that the statistics rollups follow the blockchain and can
+// be rebuilt from the block facts.
+func TestStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// last returns the last point of a series.
+	last := func(step uint64) modules.ExplorerStatsPoint {
+		points, err := et.explorer.Stats(0, math.MaxUint64, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) == 0 {
+			t.Fatal("no points in series")
+		}
+		p := points[len(points)-1]
+		if p.Timestamp%types.Timestamp(step) != 0 {
+			t.Fatal("point isn't aligned to its step", p.Timestamp)
+		}
+		return p
+	}
+	// latest returns the last points of a daily series and of a series which
+	// is downsampled from the weekly rollups.
+	latest := func() (modules.ExplorerStatsPoint, modules.ExplorerStatsPoint) {
+		return last(statsDay), last(2 * statsWeek)
+	}
+	day, week := latest()
+	if day.BlockID != et.cs.CurrentBlock().ID() || week.BlockID != day.BlockID {
+		t.Fatal("series don't end with the current block", day.Height, week.Height, et.cs.Height())
+	}
+	if !reflect.DeepEqual(day.BlockFacts, et.explorer.LatestBlockFacts()) {
+		t.Fatal("point doesn't contain the latest block facts")
+	}
+	if _, err := et.explorer.Stats(0, math.MaxUint64, statsDay+1); !errors.Contains(err, errInvalidStatsStep) {
+		t.Fatal("expected errInvalidStatsStep, got", err)
+	}
+
+	// Rebuild the rollups from scratch.
+	err = et.explorer.db.Update(func(tx *bolt.Tx) error {
+		for _, r := range statsRollups {
+			if err := tx.DeleteBucket(r.bucket); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(r.bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.explorer.backfillStatsRollups(); err != nil {
+		t.Fatal(err)
+	}
+	if d, w := latest(); !reflect.DeepEqual(d, day) || !reflect.DeepEqual(w, week) {
+		t.Fatal("rebuilt rollups don't match")
+	}
+
+	// Reorg the explorer to a longer chain. The series should end with the
+	// new current block.
+	et2, err := createExplorerTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for et2.cs.Height() <= et.cs.Height() {
+		if _, err := et2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for h := types.BlockHeight(1); h <= et2.cs.Height(); h++ {
+		b, _ := et2.cs.BlockAtHeight(h)
+		if err := et.cs.AcceptBlock(b); err != nil && !errors.Contains(err, modules.ErrNonExtendingBlock) {
+			t.Fatal(err)
+		}
+	}
+	if day, week := latest(); day.BlockID != et2.cs.CurrentBlock().ID() || week.BlockID != day.BlockID {
+		t.Fatal("series don't end with the current block after reorg")
+	}
+}
@@ -27,10 +27,14 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}()
 
 		// Update cumulative stats for reverted blocks.
-		for _, block := range cc.RevertedBlocks {
+		for i, block := range cc.RevertedBlocks {
 			bid := block.ID()
 			tbid := types.TransactionID(bid)
 
+			var height types.BlockHeight
+			assertNil(dbGetAndDecode(bucketBlockIDs, bid, &height)(tx))
+			dbApplyAddressOutputDiffs(tx, cc.RevertedDiffs[i], height, true)
+
 			dbRemoveBlockID(tx, bid)
 			dbRemoveTransactionID(tx, tbid) // Miner payouts are a transaction
 
@@ -44,7 +48,7 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
 				dbRemoveSiacoinOutputID(tx, scoid, tbid)
-				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid)
+				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid, height)
 			}
 
 			// Remove transactions
@@ -54,43 +58,43 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 
 				for _, sci := range txn.SiacoinInputs {
 					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid)
-					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, height)
 				}
 				for k, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(k))
 					dbRemoveSiacoinOutputID(tx, scoid, txid)
-					dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					dbRemoveSiacoinOutput(tx, scoid)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
 					dbRemoveFileContractID(tx, fcid, txid)
-					dbRemoveUnlockHash(tx, fc.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, fc.UnlockHash, txid, height)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					dbRemoveFileContract(tx, fcid)
 				}
 				for _, fcr := range txn.FileContractRevisions {
 					dbRemoveFileContractID(tx, fcr.ParentID, txid)
-					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, height)
+					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid, height)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					// Remove the file contract revision from the revision chain.
 					dbRemoveFileContractRevision(tx, fcr.ParentID)
@@ -100,33 +104,40 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 				for _, sfi := range txn.SiafundInputs {
 					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid)
-					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, height)
+					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid, height)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
 					dbRemoveSiafundOutputID(tx, sfoid, txid)
-					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid, height)
 				}
 			}
 
-			// remove the associated block facts
+			// remove the block from the statistics rollups and remove the
+			// associated block facts
+			var bf blockFacts
+			if dbGetAndDecode(bucketBlockFacts, bid, &bf)(tx) == nil {
+				dbRemoveStatsRollups(tx, bf, block.ParentID)
+			}
 			dbRemoveBlockFacts(tx, bid)
 		}
 
 		blockheight := cc.InitialHeight()
 		// Update cumulative stats for applied blocks.
-		for _, block := range cc.AppliedBlocks {
+		for i, block := range cc.AppliedBlocks {
 			bid := block.ID()
 			tbid := types.TransactionID(bid)
 
 			// special handling for genesis block
 			if bid == types.GenesisID {
 				dbAddGenesisBlock(tx)
+				dbApplyAddressOutputDiffs(tx, cc.AppliedDiffs[i], 0, false)
 				continue
 			}
 
 			blockheight++
+			dbApplyAddressOutputDiffs(tx, cc.AppliedDiffs[i], blockheight, false)
 			dbAddBlockID(tx, bid, blockheight)
 			dbAddTransactionID(tx, tbid, blockheight) // Miner payouts are a transaction
 
@@ -140,7 +151,7 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
 				dbAddSiacoinOutputID(tx, scoid, tbid)
-				dbAddUnlockHash(tx, payout.UnlockHash, tbid)
+				dbAddUnlockHash(tx, payout.UnlockHash, tbid, blockheight)
 			}
 
 			// Update cumulative stats for applied transactions.
@@ -151,42 +162,42 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 
 				for _, sci := range txn.SiacoinInputs {
 					dbAddSiacoinOutputID(tx, sci.ParentID, txid)
-					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, blockheight)
 				}
 				for j, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(j))
 					dbAddSiacoinOutputID(tx, scoid, txid)
-					dbAddUnlockHash(tx, sco.UnlockHash, txid)
+					dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
 					dbAddFileContractID(tx, fcid, txid)
-					dbAddUnlockHash(tx, fc.UnlockHash, txid)
+					dbAddUnlockHash(tx, fc.UnlockHash, txid, blockheight)
 					dbAddFileContract(tx, fcid, fc)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 				}
 				for _, fcr := range txn.FileContractRevisions {
 					dbAddFileContractID(tx, fcr.ParentID, txid)
-					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid, blockheight)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					dbAddFileContractRevision(tx, fcr.ParentID, fcr)
 				}
@@ -196,13 +207,13 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 				for _, sfi := range txn.SiafundInputs {
 					dbAddSiafundOutputID(tx, sfi.ParentID, txid)
-					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid, blockheight)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
 					dbAddSiafundOutputID(tx, sfoid, txid)
-					dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+					dbAddUnlockHash(tx, sfo.UnlockHash, txid, blockheight)
 				}
 			}
 
@@ -257,6 +268,15 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
+		// Update the statistics rollups with the final facts of the applied
+		// blocks.
+		for _, block := range cc.AppliedBlocks {
+			var bf blockFacts
+			if dbGetAndDecode(bucketBlockFacts, block.ID(), &bf)(tx) == nil {
+				dbAddStatsRollups(tx, bf)
+			}
+		}
+
 		// set final blockheight
 		err = dbSetInternal(internalBlockHeight, blockheight)(tx)
 		if err != nil {
@@ -421,13 +441,18 @@ func dbRemoveTransactionID(tx *bolt.Tx, id types.TransactionID) {
 }
 
 // Add/Remove txid from unlock hash bucket
-func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketUnlockHashes).CreateBucketIfNotExists(encoding.Marshal(uh))
 	assertNil(err)
 	mustPutSet(b, txid)
+	dbAddAddressTransaction(tx, uh, txid, height)
 }
-func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
+	dbRemoveAddressTransaction(tx, uh, txid, height)
 	bucket := tx.Bucket(bucketUnlockHashes).Bucket(encoding.Marshal(uh))
+	if bucket == nil {
+		return
+	}
 	mustDelete(bucket, txid)
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketUnlockHashes).DeleteBucket(encoding.Marshal(uh))
@@ -528,7 +553,7 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 		for i, sco := range transaction.SiacoinOutputs {
 			scoid := transaction.SiacoinOutputID(uint64(i))
 			dbAddSiacoinOutputID(tx, scoid, txid)
-			dbAddUnlockHash(tx, sco.UnlockHash, txid)
+			dbAddUnlockHash(tx, sco.UnlockHash, txid, 0)
 			dbAddSiacoinOutput(tx, scoid, sco)
 		}
 
@@ -536,7 +561,7 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 		for i, sfo := range transaction.SiafundOutputs {
 			sfoid := transaction.SiafundOutputID(uint64(i))
 			dbAddSiafundOutputID(tx, sfoid, txid)
-			dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+			dbAddUnlockHash(tx, sfo.UnlockHash, txid, 0)
 			dbAddSiafundOutput(tx, sfoid, sfo)
 		}
 	}
@@ -263,6 +263,39 @@ type (
 		UploadBandwidthRevenue            types.Currency `json:"uploadbandwidthrevenue"`
 	}
 
+	// HostRevenueEntry is an entry of the host's revenue ledger. An entry is
+	// recorded whenever a storage obligation is resolved and contains the
+	// revenue that was realized or lost by doing so.
+	HostRevenueEntry struct {
+		ObligationID types.FileContractID `json:"obligationid"`
+		Status       string               `json:"status"`
+		BlockHeight  types.BlockHeight    `json:"blockheight"`
+		Timestamp    int64                `json:"timestamp"`
+
+		// Revenue realized by a successful storage obligation.
+		AccountFunding           types.Currency `json:"accountfunding"`
+		ContractCompensation     types.Currency `json:"contractcompensation"`
+		DownloadBandwidthRevenue types.Currency `json:"downloadbandwidthrevenue"`
+		StorageRevenue           types.Currency `json:"storagerevenue"`
+		UploadBandwidthRevenue   types.Currency `json:"uploadbandwidthrevenue"`
+
+		// Revenue and collateral lost by a failed storage obligation.
+		LostRevenue           types.Currency `json:"lostrevenue"`
+		LostStorageCollateral types.Currency `json:"loststoragecollateral"`
+
+		// Transaction fees spent by the host on the storage obligation.
+		TransactionFeeExpenses types.Currency `json:"transactionfeeexpenses"`
+	}
+
+	// HostRevenueFilter limits the entries of the host's revenue ledger to a
+	// range of timestamps and block heights. All bounds are inclusive.
+	HostRevenueFilter struct {
+		Start       int64             `json:"start"`
+		End         int64             `json:"end"`
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+	}
+
 	// HostInternalSettings contains a list of settings that can be changed.
 	HostInternalSettings struct {
 		AcceptingContracts   bool              `json:"acceptingcontracts"`
@@ -291,6 +324,63 @@ type (
 		RegistrySize       uint64 `json:"registrysize"`
 	}
 
+	// HostPolicy restricts which renters the host serves and how many
+	// resources a single renter may use. Renters are identified by the public
+	// key in the unlock conditions of their contracts. If the allowlist is not
+	// empty, only the renters on it are served. Renters on the denylist are
+	// never served. A quota of zero means that the resource is not limited.
+	HostPolicy struct {
+		Allowlist []types.SiaPublicKey `json:"allowlist"`
+		Denylist  []types.SiaPublicKey `json:"denylist"`
+
+		MaxStorage      uint64            `json:"maxstorage"`
+		MaxBandwidth    uint64            `json:"maxbandwidth"`
+		BandwidthPeriod types.BlockHeight `json:"bandwidthperiod"`
+		MaxCollateral   types.Currency    `json:"maxcollateral"`
+	}
+
+	// HostRenterUsage contains the resources a renter currently uses on the
+	// host. The bandwidth is the amount of data transferred within the current
+	// bandwidth period of the host's policy.
+	HostRenterUsage struct {
+		Renter     types.SiaPublicKey `json:"renter"`
+		Contracts  uint64             `json:"contracts"`
+		Storage    uint64             `json:"storage"`
+		Bandwidth  uint64             `json:"bandwidth"`
+		Collateral types.Currency     `json:"collateral"`
+	}
+
+	// HostPricingSettings configures the host's pricing engine. When enabled,
+	// the engine periodically adjusts the storage price, the bandwidth prices
+	// and the collateral of the host's internal settings based on the
+	// utilization of the storage folders and the demand for contracts. A
+	// price is only managed by the engine if its ceiling is set, and it never
+	// leaves the range between its floor and ceiling.
+	HostPricingSettings struct {
+		Enabled   bool          `json:"enabled"`
+		Interval  time.Duration `json:"interval"`
+		MaxChange float64       `json:"maxchange"`
+
+		// The fiat targets are priced through the exchange rate, which is the
+		// value of one siacoin, e.g. "0.004 usd". The storage target is per
+		// TB per month and the bandwidth targets are per TB. Without an
+		// exchange rate or a target, the engine targets the middle between
+		// the floor and ceiling of a price instead.
+		ExchangeRate      string  `json:"exchangerate"`
+		FiatStoragePrice  float64 `json:"fiatstorageprice"`
+		FiatUploadPrice   float64 `json:"fiatuploadprice"`
+		FiatDownloadPrice float64 `json:"fiatdownloadprice"`
+
+		StoragePriceFloor             types.Currency `json:"storagepricefloor"`
+		StoragePriceCeiling           types.Currency `json:"storagepriceceiling"`
+		UploadBandwidthPriceFloor     types.Currency `json:"uploadbandwidthpricefloor"`
+		UploadBandwidthPriceCeiling   types.Currency `json:"uploadbandwidthpriceceiling"`
+		DownloadBandwidthPriceFloor   types.Currency `json:"downloadbandwidthpricefloor"`
+		DownloadBandwidthPriceCeiling types.Currency `json:"downloadbandwidthpriceceiling"`
+		CollateralFloor               types.Currency `json:"collateralfloor"`
+		CollateralCeiling             types.Currency `json:"collateralceiling"`
+	}
+
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
@@ -320,6 +410,11 @@ type (
 		TransactionFeesAdded     types.Currency       `json:"transactionfeesadded"`
 		TransactionID            types.TransactionID  `json:"transactionid"`
 
+		// CorruptSectors is the number of sectors of the obligation which
+		// the storage manager found to be unreadable or corrupt. The host
+		// can't provide a storage proof for these sectors.
+		CorruptSectors uint64 `json:"corruptsectors"`
+
 		// The negotiation height specifies the block height at which the file
 		// contract was negotiated. The expiration height and the proof deadline
 		// are equal to the window start and window end. Between the expiration height
@@ -419,15 +514,27 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// MigrateStorageFolder moves all sectors of a storage folder to the
+		// storage folder at the provided path, typically on a new disk, while
+		// the host keeps serving. The old storage folder is removed once every
+		// sector has been moved.
+		MigrateStorageFolder(index uint16, path string) error
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
 		PaymentProcessor
 
+		// Policy returns the host's renter policy.
+		Policy() HostPolicy
+
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
+		// PricingSettings returns the settings of the host's pricing engine.
+		PricingSettings() HostPricingSettings
+
 		// PruneStaleStorageObligations will delete storage obligations from the
 		// host that, for whatever reason, did not make it on the block chain.
 		// As these stale storage obligations have an impact on the host
@@ -438,6 +545,15 @@ type (
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// RevenueLedger returns the entries of the host's revenue ledger which
+		// match the provided filter, sorted by the order in which the storage
+		// obligations were resolved.
+		RevenueLedger(filter HostRevenueFilter) ([]HostRevenueEntry, error)
+
+		// RenterUsage returns the resources used by each renter with an active
+		// contract on the host.
+		RenterUsage() []HostRenterUsage
+
 		// ReadSector will read a sector from the host, returning the bytes that
 		// match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -479,9 +595,24 @@ type (
 		// and the resize operation completed, meaning that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// SectorCacheMetrics returns statistics about the sector read cache
+		// on the host's fast tier storage folders.
+		SectorCacheMetrics() SectorCacheMetrics
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetPolicy sets the host's renter policy.
+		SetPolicy(HostPolicy) error
+
+		// SetPricingSettings sets the settings of the host's pricing engine.
+		SetPricingSettings(HostPricingSettings) error
+
+		// SetStorageFolderFastTier tags a storage folder on the host as fast
+		// tier or standard tier storage. Fast tier storage folders hold the
+		// sector read cache of the host instead of sectors.
+		SetStorageFolderFastTier(index uint16, fastTier bool) error
+
 		// StorageObligation returns the storage obligation matching the id or
 		// an error if it does not exist
 		StorageObligation(obligationID types.FileContractID) (StorageObligation, error)
@@ -490,6 +621,11 @@ type (
 		// the host.
 		StorageObligations() []StorageObligation
 
+		// StorageObligationCounts returns the number of storage obligations
+		// held by the host by status. The keys match the ObligationStatus of
+		// the obligations returned by StorageObligations.
+		StorageObligationCounts() map[string]uint64
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// host.
 		StorageFolders() []StorageFolderMetadata
@@ -512,6 +648,13 @@ func (his HostInternalSettings) MaxSectorAccessPrice() types.Currency {
 	return his.MinDownloadBandwidthPrice.Mul64(MaxSectorAccessPriceVsBandwidth)
 }
 
+// Matches returns whether the provided entry of the host's revenue ledger lies
+// within the range of the filter.
+func (hrf HostRevenueFilter) Matches(entry HostRevenueEntry) bool {
+	return entry.Timestamp >= hrf.Start && entry.Timestamp <= hrf.End &&
+		entry.BlockHeight >= hrf.StartHeight && entry.BlockHeight <= hrf.EndHeight
+}
+
 // DefaultHostExternalSettings returns HostExternalSettings with certain default
 // fields set. NetAddress, RemainingStorage, TotalStorage, UnlockHash, RevisionNumber and SiaMuxPort are not set.
 func DefaultHostExternalSettings() HostExternalSettings {
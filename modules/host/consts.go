@@ -160,6 +160,14 @@ var (
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
+
+	// bucketRevenueLedger contains the serialized entries of the host's
+	// revenue ledger sorted by the order in which they were recorded.
+	bucketRevenueLedger = []byte("BucketRevenueLedger")
+
+	// bucketAccountOwners maps the ids of ephemeral accounts to the public
+	// keys of the renters whose contracts funded them.
+	bucketAccountOwners = []byte("BucketAccountOwners")
 )
 
 // init runs a series of sanity checks to verify that the constants have sane
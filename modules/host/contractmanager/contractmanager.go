@@ -93,6 +93,16 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// corruptSectors contains the sectors which the scrubber found to be
+	// unreadable or corrupt. They are saved to disk alongside the storage
+	// folders. corruptSectorsVersion is incremented whenever the set changes.
+	corruptSectors        map[sectorID]struct{}
+	corruptSectorsVersion uint64
+
+	// staticSectorCache is the sector read cache on the fast tier storage
+	// folders, see sectorcache.go.
+	staticSectorCache *sectorCache
+
 	// Utilities.
 	dependencies  modules.Dependencies
 	staticAlerter *modules.GenericAlerter
@@ -114,7 +124,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		storageFolders:  make(map[uint16]*storageFolder),
 		sectorLocations: make(map[sectorID]sectorLocation),
 
-		lockedSectors: make(map[sectorID]*sectorLock),
+		lockedSectors:  make(map[sectorID]*sectorLock),
+		corruptSectors: make(map[sectorID]struct{}),
+
+		staticSectorCache: newSectorCache(),
 
 		dependencies: dependencies,
 		persistDir:   persistDir,
@@ -224,6 +237,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically checks the stored sectors for
+	// corruption.
+	go cm.threadedScrubStorageFolders()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
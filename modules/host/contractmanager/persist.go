@@ -1,17 +1,20 @@
 package contractmanager
 
 import (
+	"bytes"
 	"encoding/binary"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 )
 
@@ -19,9 +22,14 @@ type (
 	// savedStorageFolder contains fields that are saved automatically to disk
 	// for each storage folder.
 	savedStorageFolder struct {
-		Index uint16
-		Path  string
-		Usage []uint64
+		Index    uint16
+		Path     string
+		Usage    []uint64
+		FastTier bool
+
+		// The progress of the scrubber, see scrub.go.
+		LastScrub     time.Time
+		ScrubProgress uint32
 	}
 
 	// savedSettings contains fields that are saved atomically to disk inside
@@ -29,19 +37,28 @@ type (
 	savedSettings struct {
 		SectorSalt     crypto.Hash
 		StorageFolders []savedStorageFolder
+		CorruptSectors []sectorID
 	}
 )
 
 // equals tests if all settings are equal between two savedSettings.
 func (s *savedSettings) equals(sb savedSettings) bool {
-	if s.SectorSalt != sb.SectorSalt || len(s.StorageFolders) != len(sb.StorageFolders) {
+	if s.SectorSalt != sb.SectorSalt || len(s.StorageFolders) != len(sb.StorageFolders) || len(s.CorruptSectors) != len(sb.CorruptSectors) {
 		return false
 	}
+	for i := range s.CorruptSectors {
+		if s.CorruptSectors[i] != sb.CorruptSectors[i] {
+			return false
+		}
+	}
 
 	for i, sf := range s.StorageFolders {
 		sfb := sb.StorageFolders[i]
 
-		if sf.Index != sfb.Index || sf.Path != sfb.Path || len(sf.Usage) != len(sfb.Usage) {
+		if sf.Index != sfb.Index || sf.Path != sfb.Path || sf.FastTier != sfb.FastTier || len(sf.Usage) != len(sfb.Usage) {
+			return false
+		}
+		if !sf.LastScrub.Equal(sfb.LastScrub) || sf.ScrubProgress != sfb.ScrubProgress {
 			return false
 		}
 
@@ -58,9 +75,13 @@ func (s *savedSettings) equals(sb savedSettings) bool {
 // savedStorageFolder returns the persistent version of the storage folder.
 func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 	ssf := savedStorageFolder{
-		Index: sf.index,
-		Path:  sf.path,
-		Usage: make([]uint64, len(sf.usage)),
+		Index:    sf.index,
+		Path:     sf.path,
+		Usage:    make([]uint64, len(sf.usage)),
+		FastTier: sf.fastTier,
+
+		LastScrub:     sf.lastScrub,
+		ScrubProgress: sf.scrubProgress,
 	}
 	copy(ssf.Usage, sf.usage)
 	return ssf
@@ -102,6 +123,9 @@ func (cm *ContractManager) loadSettings() error {
 		sf.index = ss.StorageFolders[i].Index
 		sf.path = ss.StorageFolders[i].Path
 		sf.usage = ss.StorageFolders[i].Usage
+		sf.fastTier = ss.StorageFolders[i].FastTier
+		sf.lastScrub = ss.StorageFolders[i].LastScrub
+		sf.scrubProgress = ss.StorageFolders[i].ScrubProgress
 		sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(ss.StorageFolders[i].Path, metadataFile), os.O_RDWR, 0700)
 		if err != nil {
 			// Mark the folder as unavailable and log an error.
@@ -120,6 +144,12 @@ func (cm *ContractManager) loadSettings() error {
 		sf.availableSectors = make(map[sectorID]uint32)
 		cm.storageFolders[sf.index] = sf
 	}
+	for _, id := range ss.CorruptSectors {
+		cm.corruptSectors[id] = struct{}{}
+	}
+	if len(cm.corruptSectors) > 0 {
+		cm.staticAlerter.RegisterAlert(modules.AlertIDHostCorruptSectors, AlertMSGHostDiskTrouble, "corrupt sectors found by the scrubber", modules.SeverityCritical)
+	}
 	return nil
 }
 
@@ -192,5 +222,12 @@ func (cm *ContractManager) savedSettings() savedSettings {
 	sort.Slice(ss.StorageFolders, func(i, j int) bool {
 		return ss.StorageFolders[i].Index < ss.StorageFolders[j].Index
 	})
+
+	for id := range cm.corruptSectors {
+		ss.CorruptSectors = append(ss.CorruptSectors, id)
+	}
+	sort.Slice(ss.CorruptSectors, func(i, j int) bool {
+		return bytes.Compare(ss.CorruptSectors[i][:], ss.CorruptSectors[j][:]) < 0
+	})
 	return ss
 }
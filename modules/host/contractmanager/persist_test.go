@@ -158,6 +158,40 @@ func TestSavedSettingsEquals(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "diff scrub progress",
+			a: savedSettings{
+				SectorSalt: crypto.Hash{1},
+				StorageFolders: []savedStorageFolder{
+					{Index: 1, Path: "/tmp/storage/01", Usage: []uint64{5, 1, 2, 3, 4}},
+				},
+			},
+			b: savedSettings{
+				SectorSalt: crypto.Hash{1},
+				StorageFolders: []savedStorageFolder{
+					{Index: 1, Path: "/tmp/storage/01", Usage: []uint64{5, 1, 2, 3, 4}, ScrubProgress: 2},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "diff corrupt sectors",
+			a: savedSettings{
+				SectorSalt: crypto.Hash{1},
+				StorageFolders: []savedStorageFolder{
+					{Index: 1, Path: "/tmp/storage/01", Usage: []uint64{5, 1, 2, 3, 4}},
+				},
+				CorruptSectors: []sectorID{{1}},
+			},
+			b: savedSettings{
+				SectorSalt: crypto.Hash{1},
+				StorageFolders: []savedStorageFolder{
+					{Index: 1, Path: "/tmp/storage/01", Usage: []uint64{5, 1, 2, 3, 4}},
+				},
+				CorruptSectors: []sectorID{{2}},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,301 @@
+package contractmanager
+
+// scrub.go implements the background sector scrubber. The scrubber walks the
+// usage bitfield of every storage folder, re-reads the stored sectors and
+// checks that their Merkle roots still match the sector ids in the metadata
+// file of the folder. Sectors which can't be read or don't match are reported
+// as corrupt, so that failing disks are noticed before the host has to provide
+// storage proofs for the data.
+//
+// The corrupt sectors, the time of the last pass and the progress of an
+// unfinished pass are saved to disk alongside the storage folders, so that a
+// restart of the host neither loses the findings nor restarts a pass from the
+// beginning. A folder is due for a pass once scrubPassInterval has passed since
+// its last pass, and the first check happens shortly after startup.
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errScrubInterrupted is returned if a scrubbing pass is interrupted by
+	// the shutdown of the contract manager.
+	errScrubInterrupted = errors.New("scrubbing was interrupted by shutdown")
+)
+
+var (
+	// scrubSectorInterval is the amount of time that the scrubber waits after
+	// checking a sector, which limits the disk throughput of the scrubber.
+	scrubSectorInterval = build.Select(build.Var{
+		Dev:      time.Millisecond * 10,
+		Standard: time.Millisecond * 250, // 16 MiB/s
+		Testing:  time.Millisecond,
+	}).(time.Duration)
+
+	// scrubPassInterval is the amount of time that the scrubber waits
+	// between two passes over a storage folder.
+	scrubPassInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// scrubDelay is the minimum amount of time that the scrubber waits before
+	// checking the storage folders for a pass, including the first check after
+	// startup.
+	scrubDelay = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+)
+
+// scrubDue returns whether the storage folder is due for a scrubbing pass.
+func (sf *storageFolder) scrubDue() bool {
+	return sf.scrubProgress > 0 || time.Since(sf.lastScrub) >= scrubPassInterval
+}
+
+// threadedScrubStorageFolders periodically scrubs all available storage
+// folders.
+func (cm *ContractManager) threadedScrubStorageFolders() {
+	// Don't spawn the loop if 'noScrub' disruption is set.
+	if cm.dependencies.Disrupt("noScrub") {
+		return
+	}
+
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(cm.managedNextScrub()):
+		}
+
+		err := cm.managedScrubStorageFolders(scrubSectorInterval)
+		if errors.Is(err, errScrubInterrupted) {
+			return
+		}
+	}
+}
+
+// managedNextScrub returns the amount of time until the next storage folder is
+// due for a scrubbing pass, but at least scrubDelay.
+func (cm *ContractManager) managedNextScrub() time.Duration {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	wait := scrubPassInterval
+	for _, sf := range cm.availableStorageFolders() {
+		if sf.scrubDue() {
+			wait = 0
+		} else if next := time.Until(sf.lastScrub.Add(scrubPassInterval)); next < wait {
+			wait = next
+		}
+	}
+	if wait < scrubDelay {
+		wait = scrubDelay
+	}
+	return wait
+}
+
+// managedScrubStorageFolders scrubs the available storage folders which are
+// due for a pass. Afterwards, the corrupt sector alert is unregistered if none
+// of the stored sectors are known to be corrupt.
+func (cm *ContractManager) managedScrubStorageFolders(interval time.Duration) error {
+	cm.wal.mu.Lock()
+	sfs := cm.availableStorageFolders()
+	cm.wal.mu.Unlock()
+	for _, sf := range sfs {
+		cm.wal.mu.Lock()
+		due := sf.scrubDue()
+		cm.wal.mu.Unlock()
+		if !due {
+			continue
+		}
+		err := cm.managedScrubStorageFolder(sf, interval)
+		if errors.Is(err, errScrubInterrupted) {
+			return err
+		} else if err != nil {
+			cm.log.Printf("ERROR: Unable to scrub storage folder %v: %v\n", sf.path, err)
+		}
+	}
+
+	cm.wal.mu.Lock()
+	corrupt := len(cm.corruptSectorsPerFolder()) > 0
+	cm.wal.mu.Unlock()
+	if !corrupt {
+		cm.staticAlerter.UnregisterAlert(modules.AlertIDHostCorruptSectors)
+	}
+	return nil
+}
+
+// managedScrubStorageFolder checks every sector of the storage folder, waiting
+// for the provided interval after each sector. The pass resumes at the saved
+// progress of the storage folder.
+func (cm *ContractManager) managedScrubStorageFolder(sf *storageFolder, interval time.Duration) error {
+	// Walk the usage one element at a time, the usage may change while the
+	// storage folder is scrubbed.
+	cm.wal.mu.Lock()
+	start := int(sf.scrubProgress)
+	cm.wal.mu.Unlock()
+	for i := start; ; i++ {
+		cm.wal.mu.Lock()
+		if i >= len(sf.usage) {
+			cm.wal.mu.Unlock()
+			break
+		}
+		usage := sf.usage[i]
+		cm.wal.mu.Unlock()
+
+		for j := uint32(0); j < storageFolderGranularity; j++ {
+			if usage&(1<<j) == 0 {
+				continue
+			}
+			err := cm.managedScrubSector(sf, uint32(i)*storageFolderGranularity+j)
+			if err != nil {
+				return err
+			}
+			select {
+			case <-cm.tg.StopChan():
+				return errScrubInterrupted
+			case <-time.After(interval):
+			}
+		}
+
+		// The progress is saved with the settings by the WAL.
+		cm.wal.mu.Lock()
+		sf.scrubProgress = uint32(i + 1)
+		cm.wal.mu.Unlock()
+	}
+
+	cm.wal.mu.Lock()
+	sf.lastScrub = time.Now()
+	sf.scrubProgress = 0
+	cm.wal.mu.Unlock()
+	return nil
+}
+
+// managedScrubSector checks the sector at the provided index of the storage
+// folder. The sector is reported as corrupt if it can't be read or if its
+// Merkle root doesn't match the sector id in the metadata.
+func (cm *ContractManager) managedScrubSector(sf *storageFolder, sectorIndex uint32) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return errScrubInterrupted
+	}
+	defer cm.tg.Done()
+
+	// Look up the sector which is stored at the index.
+	if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return nil
+	}
+	cm.wal.mu.Lock()
+	id, err := readSectorMetadata(sf.metadataFile, sectorIndex)
+	cm.wal.mu.Unlock()
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		cm.log.Printf("ERROR: Unable to read sector metadata for folder %v: %v\n", sf.path, err)
+		return nil
+	}
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	// Skip the sector if the storage folder is being resized or removed, and
+	// if the sector has been moved or removed since the metadata was read.
+	if !sf.mu.TryRLock() {
+		return nil
+	}
+	defer sf.mu.RUnlock()
+	cm.wal.mu.Lock()
+	sl, exists := cm.sectorLocations[id]
+	cm.wal.mu.Unlock()
+	if !exists || sl.storageFolder != sf.index || sl.index != sectorIndex {
+		return nil
+	}
+
+	// Read the sector and verify its root.
+	data, err := readSector(sf.sectorFile, sectorIndex)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+	} else {
+		atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+		if cm.managedSectorID(crypto.MerkleRoot(data)) != id {
+			err = errors.New("sector data doesn't match its root")
+		}
+	}
+	atomic.AddUint64(&sf.atomicScrubbedSectors, 1)
+
+	cm.wal.mu.Lock()
+	_, known := cm.corruptSectors[id]
+	if err != nil && !known {
+		cm.corruptSectors[id] = struct{}{}
+		cm.corruptSectorsVersion++
+	} else if err == nil {
+		cm.clearCorruptSector(id)
+	}
+	cm.wal.mu.Unlock()
+	if err != nil && !known {
+		cm.log.Printf("WARN: Corrupt sector at index %v of storage folder %v: %v\n", sectorIndex, sf.path, err)
+		cm.staticAlerter.RegisterAlert(modules.AlertIDHostCorruptSectors, AlertMSGHostDiskTrouble, fmt.Sprintf("corrupt sectors found in storage folder %v", sf.path), modules.SeverityCritical)
+	}
+	return nil
+}
+
+// clearCorruptSector removes the sector from the set of corrupt sectors. It is
+// called when the sector is found to be intact, removed or written again.
+func (cm *ContractManager) clearCorruptSector(id sectorID) {
+	if _, exists := cm.corruptSectors[id]; exists {
+		delete(cm.corruptSectors, id)
+		cm.corruptSectorsVersion++
+	}
+}
+
+// corruptSectorsPerFolder returns the number of corrupt sectors in each
+// storage folder.
+func (cm *ContractManager) corruptSectorsPerFolder() map[uint16]uint64 {
+	counts := make(map[uint16]uint64)
+	for id := range cm.corruptSectors {
+		if sl, exists := cm.sectorLocations[id]; exists {
+			counts[sl.storageFolder]++
+		}
+	}
+	return counts
+}
+
+// CorruptSectorsVersion returns a counter which is incremented whenever the
+// set of corrupt sectors changes. Callers can use it to cache the results of
+// CorruptSectors.
+func (cm *ContractManager) CorruptSectorsVersion() uint64 {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	return cm.corruptSectorsVersion
+}
+
+// CorruptSectors returns the roots of the provided sectors which the scrubber
+// found to be unreadable or corrupt.
+func (cm *ContractManager) CorruptSectors(sectorRoots []crypto.Hash) []crypto.Hash {
+	cm.wal.mu.Lock()
+	none := len(cm.corruptSectors) == 0
+	cm.wal.mu.Unlock()
+	if none {
+		return nil
+	}
+
+	var corrupt []crypto.Hash
+	for _, root := range sectorRoots {
+		id := cm.managedSectorID(root)
+		cm.wal.mu.Lock()
+		_, isCorrupt := cm.corruptSectors[id]
+		_, exists := cm.sectorLocations[id]
+		cm.wal.mu.Unlock()
+		if isCorrupt && exists {
+			corrupt = append(corrupt, root)
+		}
+	}
+	return corrupt
+}
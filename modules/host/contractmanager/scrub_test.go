@@ -0,0 +1,166 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// dependencyNoScrub prevents the background scrubber from running, so that
+// tests control the scrubbing passes.
+type dependencyNoScrub struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt prevents the scrub loop from running in the contract manager.
+func (*dependencyNoScrub) Disrupt(s string) bool {
+	return s == "noScrub"
+}
+
+// hasDiskTroubleAlert returns whether the contract manager has registered a
+// disk trouble alert.
+func hasDiskTroubleAlert(cm *ContractManager) bool {
+	crit, _, _ := cm.Alerts()
+	for _, alert := range crit {
+		if alert.Msg == AlertMSGHostDiskTrouble {
+			return true
+		}
+	}
+	return false
+}
+
+// TestScrubStorageFolder checks that the scrubber finds corrupted sectors and
+// reports them in the storage folder metadata.
+func TestScrubStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyNoScrub)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder with a few sectors.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		if err := cmt.cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	// A scrub of the intact sectors finds nothing.
+	index := cmt.cm.StorageFolders()[0].Index
+	cmt.cm.wal.mu.Lock()
+	sf := cmt.cm.storageFolders[index]
+	sl := cmt.cm.sectorLocations[cmt.cm.managedSectorID(roots[1])]
+	cmt.cm.wal.mu.Unlock()
+	if err := cmt.cm.managedScrubStorageFolder(sf, 0); err != nil {
+		t.Fatal(err)
+	}
+	sfm := cmt.cm.StorageFolders()[0]
+	if sfm.CorruptSectors != 0 || sfm.ScrubbedSectors != 3 || sfm.LastScrub.IsZero() {
+		t.Fatalf("unexpected scrub results: %+v", sfm)
+	}
+	if corrupt := cmt.cm.CorruptSectors(roots); len(corrupt) != 0 {
+		t.Fatal("intact sectors reported as corrupt", corrupt)
+	}
+
+	// Corrupt one of the sectors on disk.
+	f, err := os.OpenFile(filepath.Join(storageFolderDir, sectorFile), os.O_RDWR, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{1, 2, 3}, int64(uint64(sl.index)*modules.SectorSize+10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmt.cm.managedScrubStorageFolder(sf, 0); err != nil {
+		t.Fatal(err)
+	}
+	sfm = cmt.cm.StorageFolders()[0]
+	if sfm.CorruptSectors != 1 || sfm.ScrubbedSectors != 6 {
+		t.Fatalf("unexpected scrub results: %+v", sfm)
+	}
+	if corrupt := cmt.cm.CorruptSectors(roots); len(corrupt) != 1 || corrupt[0] != roots[1] {
+		t.Fatal("wrong corrupt sectors", corrupt)
+	}
+	if !hasDiskTroubleAlert(cmt.cm) {
+		t.Fatal("expected a disk trouble alert")
+	}
+
+	// The findings and the time of the pass survive a restart once the
+	// settings have been committed by the sync loop.
+	lastScrub := sfm.LastScrub
+	cmt.cm.wal.mu.Lock()
+	syncChan := cmt.cm.wal.syncChan
+	cmt.cm.wal.mu.Unlock()
+	<-syncChan
+	if err := cmt.cm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = newContractManager(d, filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfm = cmt.cm.StorageFolders()[0]
+	if sfm.CorruptSectors != 1 || !sfm.LastScrub.Equal(lastScrub) {
+		t.Fatalf("scrub results were not persisted: %+v", sfm)
+	}
+	if !hasDiskTroubleAlert(cmt.cm) {
+		t.Fatal("expected the alert to be registered again after the restart")
+	}
+
+	// An unfinished pass resumes at the saved progress. The storage folder only
+	// has a single element of usage, which was already checked.
+	cmt.cm.wal.mu.Lock()
+	sf = cmt.cm.storageFolders[index]
+	sf.scrubProgress = 1
+	cmt.cm.wal.mu.Unlock()
+	if err := cmt.cm.managedScrubStorageFolders(0); err != nil {
+		t.Fatal(err)
+	}
+	sfm = cmt.cm.StorageFolders()[0]
+	if sfm.ScrubbedSectors != 0 || !sfm.LastScrub.After(lastScrub) {
+		t.Fatalf("unexpected scrub results: %+v", sfm)
+	}
+	cmt.cm.wal.mu.Lock()
+	progress := sf.scrubProgress
+	cmt.cm.wal.mu.Unlock()
+	if progress != 0 {
+		t.Fatal("scrub progress wasn't reset after the pass", progress)
+	}
+
+	// Removing the corrupt sector removes it from the findings, and the next
+	// pass clears the alert.
+	if err := cmt.cm.RemoveSector(roots[1]); err != nil {
+		t.Fatal(err)
+	}
+	if sfm := cmt.cm.StorageFolders()[0]; sfm.CorruptSectors != 0 {
+		t.Fatal("removed sector is still reported as corrupt")
+	}
+	if err := cmt.cm.managedScrubStorageFolders(0); err != nil {
+		t.Fatal(err)
+	}
+	if hasDiskTroubleAlert(cmt.cm) {
+		t.Fatal("expected the alert to be unregistered")
+	}
+}
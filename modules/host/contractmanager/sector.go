@@ -79,6 +79,16 @@ func readFullMetadata(f modules.File, numSectors int) ([]byte, error) {
 	return sectorLookupBytes, nil
 }
 
+// readSectorMetadata will read the id of the sector at the given index from
+// the metadata file.
+func readSectorMetadata(f modules.File, sectorIndex uint32) (id sectorID, err error) {
+	_, err = f.ReadAt(id[:], sectorMetadataDiskSize*int64(sectorIndex))
+	if err != nil {
+		return sectorID{}, build.ExtendErr("unable to read sector metadata", err)
+	}
+	return id, nil
+}
+
 // writeSector will write the given sector into the given file at the given
 // index.
 func writeSector(f modules.File, sectorIndex uint32, data []byte) error {
@@ -134,10 +144,20 @@ func (cm *ContractManager) ReadPartialSector(root crypto.Hash, offset, length ui
 	cm.wal.mu.Lock()
 	sl, exists1 := cm.sectorLocations[id]
 	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	cached := len(cm.fastTierFolders()) > 0
 	cm.wal.mu.Unlock()
 	if !exists1 {
 		return nil, ErrSectorNotFound
 	}
+
+	// Serve the sector from the read cache if it's cached.
+	if cached {
+		sectorData, hit := cm.staticSectorCache.managedRead(id, offset, length)
+		if hit {
+			return sectorData, nil
+		}
+	}
+
 	if !exists2 {
 		cm.log.Critical("Unable to load storage folder despite having sector metadata")
 		return nil, ErrSectorNotFound
@@ -154,6 +174,11 @@ func (cm *ContractManager) ReadPartialSector(root crypto.Hash, offset, length ui
 		return nil, build.ExtendErr("unable to fetch sector", err)
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	// Admit the sector into the read cache if it's read frequently.
+	if cached && cm.staticSectorCache.managedMiss(id) {
+		go cm.threadedCacheSector(root, id)
+	}
 	return sectorData, nil
 }
 
@@ -0,0 +1,355 @@
+package contractmanager
+
+// sectorcache.go implements the sector read cache. Storage folders which are
+// tagged as fast tier storage, typically on SSDs, don't store sectors of their
+// own. Instead their sector slots hold copies of the sectors which are read
+// most frequently from the standard tier, so that those sectors can be served
+// without touching the slower disks.
+//
+// A sector is admitted into the cache on its second read, so that sectors
+// which are only read once don't push popular sectors out of the cache. Once
+// the cache is full, the least recently read sector is evicted. Admission
+// happens in the background, and the Merkle root of a sector is verified
+// before it is cached.
+//
+// The cache only keeps its index in memory. It starts out empty after a
+// restart, and a sector is always looked up in the sector locations first, so
+// sectors which were removed from the contract manager are never served from
+// the cache.
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxCacheCandidates is the maximum number of sectors that are
+	// remembered after their first read. If there are more, the candidates
+	// are forgotten and admission starts over.
+	maxCacheCandidates = 1 << 16
+)
+
+type (
+	// cacheSlot is a sector slot of a fast tier storage folder.
+	cacheSlot struct {
+		sf    *storageFolder
+		index uint32
+	}
+
+	// cachedSector is a sector which is stored in a slot of the cache.
+	cachedSector struct {
+		id   sectorID
+		slot cacheSlot
+	}
+
+	// sectorCache is the index of the sectors which are cached in the fast
+	// tier storage folders.
+	//
+	// Reads from the cache hold a readlock on mu, and sectors can only be
+	// evicted from the cache while mu is locked, so a slot is never
+	// overwritten while a sector is being read from it. accessMu protects the
+	// fields which are changed on every read.
+	sectorCache struct {
+		// entries maps the cached sectors to their element in lru. The most
+		// recently read sector is at the front of lru.
+		entries map[sectorID]*list.Element
+		lru     *list.List
+
+		// allocated is the number of slots of each fast tier storage folder
+		// which have been used by the cache, free contains the slots that
+		// were freed again.
+		allocated map[uint16]uint32
+		free      []cacheSlot
+
+		// generation is increased every time that a storage folder is
+		// purged, so that admissions which started before the purge can't
+		// put sectors into slots of the purged folder.
+		generation uint64
+
+		// candidates are the sectors which have been read once and will be
+		// admitted on their next read, pending are the sectors which are
+		// being admitted.
+		candidates map[sectorID]struct{}
+		pending    map[sectorID]struct{}
+
+		atomicHits   uint64
+		atomicMisses uint64
+
+		accessMu sync.Mutex
+		mu       sync.RWMutex
+	}
+)
+
+// newSectorCache returns an empty sector cache.
+func newSectorCache() *sectorCache {
+	return &sectorCache{
+		entries:    make(map[sectorID]*list.Element),
+		lru:        list.New(),
+		allocated:  make(map[uint16]uint32),
+		candidates: make(map[sectorID]struct{}),
+		pending:    make(map[sectorID]struct{}),
+	}
+}
+
+// managedRead reads the sector from the cache, returning false if the sector
+// isn't cached or can't be read from the cache.
+func (sc *sectorCache) managedRead(id sectorID, offset, length uint64) ([]byte, bool) {
+	sc.mu.RLock()
+	elem, exists := sc.entries[id]
+	if !exists {
+		sc.mu.RUnlock()
+		return nil, false
+	}
+	sc.accessMu.Lock()
+	sc.lru.MoveToFront(elem)
+	sc.accessMu.Unlock()
+
+	slot := elem.Value.(*cachedSector).slot
+	var data []byte
+	err := errStorageFolderNotFound
+	if atomic.LoadUint64(&slot.sf.atomicUnavailable) == 0 {
+		data, err = readPartialSector(slot.sf.sectorFile, slot.index, offset, length)
+	}
+	sc.mu.RUnlock()
+	if err != nil {
+		atomic.AddUint64(&slot.sf.atomicFailedReads, 1)
+		sc.managedEvict(id)
+		return nil, false
+	}
+	atomic.AddUint64(&slot.sf.atomicSuccessfulReads, 1)
+	atomic.AddUint64(&sc.atomicHits, 1)
+	return data, true
+}
+
+// managedMiss records a read of a sector which wasn't cached. true is returned
+// if the sector should be admitted into the cache.
+func (sc *sectorCache) managedMiss(id sectorID) bool {
+	atomic.AddUint64(&sc.atomicMisses, 1)
+
+	sc.accessMu.Lock()
+	defer sc.accessMu.Unlock()
+	if _, exists := sc.pending[id]; exists {
+		return false
+	}
+	if _, exists := sc.candidates[id]; !exists {
+		if len(sc.candidates) >= maxCacheCandidates {
+			sc.candidates = make(map[sectorID]struct{})
+		}
+		sc.candidates[id] = struct{}{}
+		return false
+	}
+	delete(sc.candidates, id)
+	sc.pending[id] = struct{}{}
+	return true
+}
+
+// managedAdmitted clears the pending admission of a sector.
+func (sc *sectorCache) managedAdmitted(id sectorID) {
+	sc.accessMu.Lock()
+	delete(sc.pending, id)
+	sc.accessMu.Unlock()
+}
+
+// managedAllocate returns a slot for a new sector in one of the provided fast
+// tier storage folders, evicting the least recently read sector if the cache
+// is full. The storage folder of the returned slot is readlocked, so that it
+// isn't resized or removed while the sector is written, and the caller needs
+// to unlock it. The generation of the cache is returned alongside the slot.
+func (sc *sectorCache) managedAllocate(sfs []*storageFolder) (cacheSlot, uint64, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if len(sc.free) > 0 {
+		slot := sc.free[len(sc.free)-1]
+		if !slot.sf.mu.TryRLock() {
+			return cacheSlot{}, 0, false
+		}
+		sc.free = sc.free[:len(sc.free)-1]
+		return slot, sc.generation, true
+	}
+	for _, sf := range sfs {
+		if !sf.mu.TryRLock() {
+			continue
+		}
+		if sc.allocated[sf.index] < uint32(len(sf.usage))*storageFolderGranularity {
+			slot := cacheSlot{sf: sf, index: sc.allocated[sf.index]}
+			sc.allocated[sf.index]++
+			return slot, sc.generation, true
+		}
+		sf.mu.RUnlock()
+	}
+
+	// The storage folder of the evicted sector is locked first, otherwise the
+	// sector would be dropped from the cache without its slot being reused.
+	elem := sc.lru.Back()
+	if elem == nil {
+		return cacheSlot{}, 0, false
+	}
+	cs := elem.Value.(*cachedSector)
+	if !cs.slot.sf.mu.TryRLock() {
+		return cacheSlot{}, 0, false
+	}
+	sc.lru.Remove(elem)
+	delete(sc.entries, cs.id)
+	return cs.slot, sc.generation, true
+}
+
+// managedInsert adds a sector which was written to the provided slot to the
+// cache. The slot is freed if the cache was purged since the slot was
+// allocated.
+func (sc *sectorCache) managedInsert(id sectorID, slot cacheSlot, generation uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if generation != sc.generation {
+		return
+	}
+	if _, exists := sc.entries[id]; exists {
+		sc.free = append(sc.free, slot)
+		return
+	}
+	sc.entries[id] = sc.lru.PushFront(&cachedSector{id: id, slot: slot})
+}
+
+// managedRelease returns an allocated slot which wasn't used to the cache.
+func (sc *sectorCache) managedRelease(slot cacheSlot, generation uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if generation == sc.generation {
+		sc.free = append(sc.free, slot)
+	}
+}
+
+// managedEvict removes a sector from the cache.
+func (sc *sectorCache) managedEvict(id sectorID) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	elem, exists := sc.entries[id]
+	if !exists {
+		return
+	}
+	cs := sc.lru.Remove(elem).(*cachedSector)
+	delete(sc.entries, id)
+	sc.free = append(sc.free, cs.slot)
+}
+
+// managedPurgeFolder removes all sectors which are cached in the storage folder
+// with the provided index from the cache. The storage folder can be modified
+// safely once managedPurgeFolder returns, as long as it's kept locked until
+// it's not part of the fast tier anymore.
+func (sc *sectorCache) managedPurgeFolder(index uint16) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.generation++
+	for elem := sc.lru.Front(); elem != nil; {
+		next := elem.Next()
+		cs := elem.Value.(*cachedSector)
+		if cs.slot.sf.index == index {
+			sc.lru.Remove(elem)
+			delete(sc.entries, cs.id)
+		}
+		elem = next
+	}
+	free := sc.free[:0]
+	for _, slot := range sc.free {
+		if slot.sf.index != index {
+			free = append(free, slot)
+		}
+	}
+	sc.free = free
+	delete(sc.allocated, index)
+}
+
+// fastTierFolders returns the available fast tier storage folders. The caller
+// needs to hold the lock of the WAL.
+func (cm *ContractManager) fastTierFolders() []*storageFolder {
+	var sfs []*storageFolder
+	for _, sf := range cm.storageFolders {
+		if sf.fastTier && atomic.LoadUint64(&sf.atomicUnavailable) == 0 {
+			sfs = append(sfs, sf)
+		}
+	}
+	return sfs
+}
+
+// threadedCacheSector copies a sector from the standard tier into the cache.
+func (cm *ContractManager) threadedCacheSector(root crypto.Hash, id sectorID) {
+	sc := cm.staticSectorCache
+	defer sc.managedAdmitted(id)
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	// Read the sector from its storage folder.
+	cm.wal.managedLockSector(id)
+	cm.wal.mu.Lock()
+	sl, exists1 := cm.sectorLocations[id]
+	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	fastSfs := cm.fastTierFolders()
+	cm.wal.mu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 || len(fastSfs) == 0 {
+		cm.wal.managedUnlockSector(id)
+		return
+	}
+	data, err := readSector(sf.sectorFile, sl.index)
+	cm.wal.managedUnlockSector(id)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	// Don't cache sectors which are corrupt.
+	if crypto.MerkleRoot(data) != root {
+		return
+	}
+
+	// Write the sector into a slot of the cache. The fast tier storage folder
+	// is readlocked by managedAllocate, so that it isn't resized or removed
+	// concurrently.
+	slot, generation, ok := sc.managedAllocate(fastSfs)
+	if !ok {
+		return
+	}
+	err = writeSector(slot.sf.sectorFile, slot.index, data)
+	slot.sf.mu.RUnlock()
+	if err != nil {
+		atomic.AddUint64(&slot.sf.atomicFailedWrites, 1)
+		sc.managedRelease(slot, generation)
+		return
+	}
+	atomic.AddUint64(&slot.sf.atomicSuccessfulWrites, 1)
+	sc.managedInsert(id, slot, generation)
+}
+
+// SectorCacheMetrics returns statistics about the sector read cache.
+func (cm *ContractManager) SectorCacheMetrics() modules.SectorCacheMetrics {
+	var m modules.SectorCacheMetrics
+	err := cm.tg.Add()
+	if err != nil {
+		return m
+	}
+	defer cm.tg.Done()
+
+	cm.wal.mu.Lock()
+	for _, sf := range cm.fastTierFolders() {
+		m.Capacity += uint64(len(sf.usage)) * storageFolderGranularity * modules.SectorSize
+	}
+	cm.wal.mu.Unlock()
+
+	sc := cm.staticSectorCache
+	sc.mu.RLock()
+	m.Size = uint64(len(sc.entries)) * modules.SectorSize
+	sc.mu.RUnlock()
+	m.Hits = atomic.LoadUint64(&sc.atomicHits)
+	m.Misses = atomic.LoadUint64(&sc.atomicMisses)
+	if m.Hits+m.Misses > 0 {
+		m.HitRate = float64(m.Hits) / float64(m.Hits+m.Misses)
+	}
+	return m
+}
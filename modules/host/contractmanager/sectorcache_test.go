@@ -0,0 +1,320 @@
+package contractmanager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorCache moves a storage folder to the fast tier and checks that
+// frequently read sectors are served from the cache, that the cache evicts the
+// least recently read sectors once it's full and that the tier survives a
+// restart.
+func TestSectorCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a standard and a fast storage folder.
+	standardDir := filepath.Join(cmt.persistDir, "storageFolderStandard")
+	fastDir := filepath.Join(cmt.persistDir, "storageFolderFast")
+	for _, dir := range []string{standardDir, fastDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmt.cm.AddStorageFolder(standardDir, modules.SectorSize*storageFolderGranularity*2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmt.cm.AddStorageFolder(fastDir, modules.SectorSize*storageFolderGranularity); err != nil {
+		t.Fatal(err)
+	}
+	var fastIndex uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == fastDir {
+			fastIndex = sf.Index
+		}
+	}
+
+	// Add more sectors than fit into the cache.
+	numSectors := storageFolderGranularity + 1
+	roots := make([]crypto.Hash, numSectors)
+	datas := make([][]byte, numSectors)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		if err := cmt.cm.AddSector(roots[i], datas[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Move the fast folder to the fast tier, its sectors are moved to the
+	// standard folder.
+	if err := cmt.cm.SetStorageFolderFastTier(fastIndex, true); err != nil {
+		t.Fatal(err)
+	}
+	checkTier := func() {
+		t.Helper()
+		for _, sf := range cmt.cm.StorageFolders() {
+			if sf.FastTier != (sf.Path == fastDir) {
+				t.Fatalf("unexpected tier %+v", sf)
+			}
+			if sf.FastTier && sf.CapacityRemaining != sf.Capacity {
+				t.Fatal("fast tier folder shouldn't hold any sectors", sf.CapacityRemaining)
+			}
+		}
+		if m := cmt.cm.SectorCacheMetrics(); m.Capacity != modules.SectorSize*storageFolderGranularity {
+			t.Fatal("unexpected cache capacity", m.Capacity)
+		}
+	}
+	checkTier()
+
+	// Migrating sectors into the fast tier isn't possible.
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == standardDir {
+			if err := cmt.cm.MigrateStorageFolder(sf.Index, fastDir); !errors.Is(err, errFastTierDestination) {
+				t.Fatalf("expected %v, got %v", errFastTierDestination, err)
+			}
+		}
+	}
+
+	// A sector is cached on its second read.
+	readSector := func(i int) {
+		t.Helper()
+		data, err := cmt.cm.ReadSector(roots[i])
+		if err != nil || !bytes.Equal(data, datas[i]) {
+			t.Fatal("unable to read sector", err)
+		}
+	}
+	readSector(0)
+	readSector(0)
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if m := cmt.cm.SectorCacheMetrics(); m.Size != modules.SectorSize {
+			return errors.New("sector wasn't cached")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readSector(0)
+	partial, err := cmt.cm.ReadPartialSector(roots[0], 64, 64)
+	if err != nil || !bytes.Equal(partial, datas[0][64:128]) {
+		t.Fatal("unable to read partial sector from the cache", err)
+	}
+	if m := cmt.cm.SectorCacheMetrics(); m.Hits != 2 || m.Misses != 2 || m.HitRate != 0.5 {
+		t.Fatalf("unexpected cache metrics %+v", m)
+	}
+
+	// Fill the cache. Once it's full, the least recently read sectors are
+	// evicted.
+	for i := range roots {
+		readSector(i)
+		readSector(i)
+		err = build.Retry(100, 10*time.Millisecond, func() error {
+			sc := cmt.cm.staticSectorCache
+			sc.mu.RLock()
+			_, cached := sc.entries[cmt.cm.managedSectorID(roots[i])]
+			sc.mu.RUnlock()
+			if !cached {
+				return errors.New("sector wasn't cached")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m := cmt.cm.SectorCacheMetrics(); m.Size != m.Capacity {
+		t.Fatalf("cache should be full %+v", m)
+	}
+	sc := cmt.cm.staticSectorCache
+	sc.mu.RLock()
+	_, cached := sc.entries[cmt.cm.managedSectorID(roots[0])]
+	sc.mu.RUnlock()
+	if cached {
+		t.Fatal("least recently read sector should have been evicted")
+	}
+	for i := range roots {
+		readSector(i)
+	}
+
+	// Removed sectors aren't served from the cache.
+	if err := cmt.cm.RemoveSector(roots[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cmt.cm.ReadSector(roots[1]); !errors.Is(err, ErrSectorNotFound) {
+		t.Fatalf("expected %v, got %v", ErrSectorNotFound, err)
+	}
+
+	// The tier survives a restart.
+	if err := cmt.cm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTier()
+
+	// Moving the folder back to the standard tier drops the cache.
+	readSector(2)
+	readSector(2)
+	if err := cmt.cm.SetStorageFolderFastTier(fastIndex, false); err != nil {
+		t.Fatal(err)
+	}
+	if m := cmt.cm.SectorCacheMetrics(); m.Size != 0 || m.Capacity != 0 {
+		t.Fatalf("cache should be empty %+v", m)
+	}
+	for i := range roots[2:] {
+		readSector(i + 2)
+	}
+}
+
+// TestSectorCacheTierRoundTrip moves a storage folder from the fast tier to
+// the standard tier and back, checking that the slots of the cached sectors
+// don't show up as used and that a sector which was added while the folder was
+// in the standard tier is moved out again.
+func TestSectorCacheTierRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a standard and a fast storage folder.
+	standardDir := filepath.Join(cmt.persistDir, "storageFolderStandard")
+	fastDir := filepath.Join(cmt.persistDir, "storageFolderFast")
+	for _, dir := range []string{standardDir, fastDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmt.cm.AddStorageFolder(standardDir, modules.SectorSize*storageFolderGranularity*2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmt.cm.AddStorageFolder(fastDir, modules.SectorSize*storageFolderGranularity); err != nil {
+		t.Fatal(err)
+	}
+	var fastIndex uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == fastDir {
+			fastIndex = sf.Index
+		}
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	addSector := func() {
+		t.Helper()
+		root, data := randSector()
+		if err := cmt.cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+	readSectors := func() {
+		t.Helper()
+		for i := range roots {
+			data, err := cmt.cm.ReadSector(roots[i])
+			if err != nil || !bytes.Equal(data, datas[i]) {
+				t.Fatal("unable to read sector", i, err)
+			}
+		}
+	}
+	cacheSector := func(i int) {
+		t.Helper()
+		sc := cmt.cm.staticSectorCache
+		id := cmt.cm.managedSectorID(roots[i])
+		err := build.Retry(100, 10*time.Millisecond, func() error {
+			if _, err := cmt.cm.ReadSector(roots[i]); err != nil {
+				return err
+			}
+			sc.mu.RLock()
+			_, cached := sc.entries[id]
+			sc.mu.RUnlock()
+			if !cached {
+				return errors.New("sector wasn't cached")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkEmpty := func() {
+		t.Helper()
+		cmt.cm.wal.mu.Lock()
+		sf := cmt.cm.storageFolders[fastIndex]
+		sectors, used := sf.sectors, len(usageSectors(sf.usage))
+		cmt.cm.wal.mu.Unlock()
+		if sectors != 0 || used != 0 {
+			t.Fatal("storage folder should be empty", sectors, used)
+		}
+	}
+	for i := 0; i < storageFolderGranularity/2; i++ {
+		addSector()
+	}
+
+	// Move the folder to the fast tier and fill the cache.
+	if err := cmt.cm.SetStorageFolderFastTier(fastIndex, true); err != nil {
+		t.Fatal(err)
+	}
+	for i := range roots {
+		cacheSector(i)
+	}
+	checkEmpty()
+
+	// Move the folder back to the standard tier. Its usage doesn't include
+	// the slots of the cached sectors.
+	if err := cmt.cm.SetStorageFolderFastTier(fastIndex, false); err != nil {
+		t.Fatal(err)
+	}
+	checkEmpty()
+	for _, sf := range cmt.cm.StorageFolders() {
+		expected := sf.Capacity
+		if sf.Path == standardDir {
+			expected -= uint64(len(roots)) * modules.SectorSize
+		}
+		if sf.CapacityRemaining != expected {
+			t.Fatal("unexpected capacity remaining", sf.Path, sf.CapacityRemaining)
+		}
+	}
+
+	// Add sectors until one of them ends up in the folder.
+	for added := false; !added; {
+		addSector()
+		id := cmt.cm.managedSectorID(roots[len(roots)-1])
+		cmt.cm.wal.mu.Lock()
+		added = cmt.cm.sectorLocations[id].storageFolder == fastIndex
+		cmt.cm.wal.mu.Unlock()
+	}
+	readSectors()
+
+	// Move the folder to the fast tier again. The sectors of the folder are
+	// moved out, and the cache serves the correct data.
+	if err := cmt.cm.SetStorageFolderFastTier(fastIndex, true); err != nil {
+		t.Fatal(err)
+	}
+	checkEmpty()
+	readSectors()
+	cacheSector(0)
+	cacheSector(len(roots) - 1)
+	readSectors()
+}
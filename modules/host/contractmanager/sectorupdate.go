@@ -133,6 +133,7 @@ func (wal *writeAheadLog) managedAddPhysicalSector(id sectorID, data []byte) err
 				SectorUpdates: []sectorUpdate{su},
 			})
 			delete(wal.cm.storageFolders[su.Folder].availableSectors, id)
+			wal.cm.clearCorruptSector(id)
 			wal.cm.sectorLocations[id] = sl
 			syncChan = wal.syncChan
 			wal.mu.Unlock()
@@ -250,6 +251,7 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 
 		// Delete the sector and mark the usage as available.
 		delete(wal.cm.sectorLocations, id)
+		wal.cm.clearCorruptSector(id)
 		sf.availableSectors[id] = location.index
 
 		// Block until the change has been committed.
@@ -311,6 +313,7 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 		if location.count == 0 {
 			// Delete the sector and mark it as available.
 			delete(wal.cm.sectorLocations, id)
+			wal.cm.clearCorruptSector(id)
 			sf.availableSectors[id] = location.index
 		} else {
 			// Reduce the sector usage.
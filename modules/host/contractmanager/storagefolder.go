@@ -89,6 +89,9 @@ type storageFolder struct {
 	atomicSuccessfulReads  uint64
 	atomicSuccessfulWrites uint64
 
+	// The number of sectors checked by the scrubber during this boot cycle.
+	atomicScrubbedSectors uint64
+
 	// Atomic bool indicating whether or not the storage folder is available. If
 	// the storage folder is not available, it will still be loaded but return
 	// an error if it is queried.
@@ -109,6 +112,18 @@ type storageFolder struct {
 	availableSectors map[sectorID]uint32
 	sectors          uint64
 
+	// fastTier indicates that the storage folder is reserved for the sector
+	// read cache. It is saved to disk alongside the usage, see
+	// storagefoldertier.go.
+	fastTier bool
+
+	// lastScrub is the time at which the scrubber finished its last pass over
+	// the storage folder, and scrubProgress is the element of the usage at
+	// which an unfinished pass resumes. Both are saved to disk alongside the
+	// usage, see scrub.go.
+	lastScrub     time.Time
+	scrubProgress uint32
+
 	// An open file handle is kept so that writes can easily be made to the
 	// storage folder without needing to grab a new file handle. This also
 	// makes it easy to do delayed-syncing.
@@ -208,6 +223,12 @@ func vacancyStorageFolder(sfs []*storageFolder) (*storageFolder, int) {
 	for _, index := range fastrand.Perm(len(sfs)) {
 		sf := sfs[index]
 
+		// Skip past fast tier storage folders, they only hold cached copies
+		// of sectors.
+		if sf.fastTier {
+			continue
+		}
+
 		// Skip past this storage folder if there is not enough room for at
 		// least one sector.
 		if sf.sectors >= uint64(len(sf.usage))*storageFolderGranularity {
@@ -414,6 +435,7 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 
 	// Iterate over the storage folders that are in memory first, and then
 	// suppliment them with the storage folders that are not in memory.
+	corruptSectors := cm.corruptSectorsPerFolder()
 	var smfs []modules.StorageFolderMetadata
 	for _, sf := range cm.storageFolders {
 		// Grab the non-computational data.
@@ -426,10 +448,15 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			SuccessfulReads:  atomic.LoadUint64(&sf.atomicSuccessfulReads),
 			SuccessfulWrites: atomic.LoadUint64(&sf.atomicSuccessfulWrites),
 
+			CorruptSectors:  corruptSectors[sf.index],
+			ScrubbedSectors: atomic.LoadUint64(&sf.atomicScrubbedSectors),
+			LastScrub:       sf.lastScrub,
+
 			Capacity:          modules.SectorSize * 64 * uint64(len(sf.usage)),
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
 			Index:             sf.index,
 			Path:              sf.path,
+			FastTier:          sf.fastTier,
 		}
 
 		// Set some of the values to extreme numbers if the storage folder is
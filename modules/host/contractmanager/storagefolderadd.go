@@ -313,9 +313,10 @@ func (wal *writeAheadLog) commitAddStorageFolder(ssf savedStorageFolder) {
 	}
 
 	sf = &storageFolder{
-		index: ssf.Index,
-		path:  ssf.Path,
-		usage: ssf.Usage,
+		index:    ssf.Index,
+		path:     ssf.Path,
+		usage:    ssf.Usage,
+		fastTier: ssf.FastTier,
 
 		availableSectors: make(map[sectorID]uint32),
 	}
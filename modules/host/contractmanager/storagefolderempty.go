@@ -17,8 +17,9 @@ var (
 )
 
 // managedMoveSector will move a sector from its current storage folder to
-// another.
-func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+// another. If a destination is provided, the sector is only moved to the
+// destination storage folder.
+func (wal *writeAheadLog) managedMoveSector(id sectorID, dst *storageFolder) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -49,9 +50,12 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	}
 
 	// Place the sector into its new folder and add the atomic move to the WAL.
-	wal.mu.Lock()
-	storageFolders := wal.cm.availableStorageFolders()
-	wal.mu.Unlock()
+	storageFolders := []*storageFolder{dst}
+	if dst == nil {
+		wal.mu.Lock()
+		storageFolders = wal.cm.availableStorageFolders()
+		wal.mu.Unlock()
+	}
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
@@ -156,13 +160,13 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 // managedEmptyStorageFolder will empty out the storage folder with the
 // provided index starting with the 'startingPoint'th sector all the way to the
 // end of the storage folder, allowing the storage folder to be safely
-// truncated. If 'force' is set to true, the function will not give up when
-// there is no more space available, instead choosing to lose data.
+// truncated. If a destination is provided, the sectors are only moved to the
+// destination storage folder.
 //
 // This function assumes that the storage folder has already been made
 // invisible to AddSector, and that this is the only thread that will be
 // interacting with the storage folder.
-func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoint uint32) (uint64, error) {
+func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoint uint32, dst *storageFolder) (uint64, error) {
 	// Allow disk trouble simulation, for testing purposes
 	if wal.cm.dependencies.Disrupt("diskTrouble") {
 		wal.cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
@@ -199,7 +203,7 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 			for {
 				select {
 				case id := <-workChan:
-					err := wal.managedMoveSector(id)
+					err := wal.managedMoveSector(id, dst)
 					if errors.Contains(err, errDiskTrouble) {
 						wal.cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
 					}
@@ -0,0 +1,144 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errMigrationNoRoom is returned if the destination of a storage folder
+	// migration doesn't have enough free sectors for the sectors of the
+	// migrated storage folder.
+	errMigrationNoRoom = errors.New("destination storage folder doesn't have enough room for the migrated sectors")
+
+	// errMigrationSamePath is returned if a storage folder is migrated to its
+	// own path.
+	errMigrationSamePath = errors.New("storage folder can't be migrated to its own path")
+
+	// errMigrationUnavailable is returned if the source or the destination of
+	// a storage folder migration is unavailable.
+	errMigrationUnavailable = errors.New("storage folder is unavailable")
+)
+
+// managedMigrationDestination returns the storage folder at the provided path,
+// adding a storage folder with the provided number of sectors if there is none
+// yet.
+func (cm *ContractManager) managedMigrationDestination(path string, sectors uint64) (*storageFolder, error) {
+	cm.wal.mu.Lock()
+	for _, sf := range cm.storageFolders {
+		if sf.path == path {
+			cm.wal.mu.Unlock()
+			return sf, nil
+		}
+	}
+	cm.wal.mu.Unlock()
+
+	// Check that the folder being linked to both exists and is a folder.
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !pathInfo.Mode().IsDir() {
+		return nil, errStorageFolderNotFolder
+	}
+
+	// Add a storage folder with the same size as the migrated folder.
+	sf := &storageFolder{
+		path:  path,
+		usage: make([]uint64, sectors/storageFolderGranularity),
+
+		availableSectors: make(map[sectorID]uint32),
+	}
+	err = cm.wal.managedAddStorageFolder(sf)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to add destination storage folder")
+	}
+	return sf, nil
+}
+
+// MigrateStorageFolder moves all sectors of a storage folder to the storage
+// folder at the provided path, typically on a new disk, and removes the old
+// storage folder once every sector has been moved. If there is no storage
+// folder at the path yet, one with the same size as the old storage folder is
+// added. An existing storage folder at the path is used as the destination,
+// which allows for resuming an interrupted migration.
+//
+// The contract manager keeps serving while the sectors are moved. Every sector
+// is moved atomically through the WAL, so a sector is always read from the
+// storage folder which currently holds it. Unlike RemoveStorageFolder, the
+// sectors are only moved to the destination, and the old storage folder is
+// kept if any of its sectors couldn't be moved.
+func (cm *ContractManager) MigrateStorageFolder(index uint16, path string) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	// Check that the path is an absolute path.
+	if !filepath.IsAbs(path) {
+		return errRelativePath
+	}
+
+	// Retrieve the specified storage folder.
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	cm.wal.mu.Unlock()
+	if !exists {
+		return errStorageFolderNotFound
+	}
+	if sf.path == path {
+		return errMigrationSamePath
+	}
+	if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return errMigrationUnavailable
+	}
+
+	// Find or add the destination.
+	cm.wal.mu.Lock()
+	sectors := uint64(len(sf.usage)) * storageFolderGranularity
+	cm.wal.mu.Unlock()
+	dst, err := cm.managedMigrationDestination(path, sectors)
+	if err != nil {
+		return err
+	}
+	if atomic.LoadUint64(&dst.atomicUnavailable) == 1 {
+		return errMigrationUnavailable
+	}
+	cm.wal.mu.Lock()
+	fastTier := dst.fastTier
+	cm.wal.mu.Unlock()
+	if fastTier {
+		return errFastTierDestination
+	}
+
+	// Lock the storage folder for the duration of the operation, so that no
+	// new sectors are added to it.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	// Check that the destination has room for all of the sectors.
+	cm.wal.mu.Lock()
+	needed := sf.sectors
+	free := uint64(len(dst.usage))*storageFolderGranularity - dst.sectors
+	cm.wal.mu.Unlock()
+	if needed > free {
+		return errMigrationNoRoom
+	}
+
+	// Move the sectors to the destination.
+	cm.log.Printf("Migrating %v sectors from storage folder %v to %v\n", needed, sf.path, dst.path)
+	_, err = cm.wal.managedEmptyStorageFolder(index, 0, dst)
+	if err != nil {
+		cm.log.Printf("ERROR: Migration of storage folder %v to %v failed, the storage folder is kept: %v\n", sf.path, dst.path, err)
+		return errors.AddContext(err, "unable to migrate storage folder, the storage folder was kept")
+	}
+
+	// All sectors were moved, remove the old storage folder.
+	cm.wal.managedRemoveStorageFolder(sf)
+	cm.log.Printf("Migrated storage folder %v to %v\n", sf.path, dst.path)
+	return nil
+}
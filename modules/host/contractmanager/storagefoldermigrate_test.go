@@ -0,0 +1,144 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestMigrateStorageFolder migrates a storage folder with sectors to a new
+// path while the sectors are being read, and checks that the sectors end up in
+// the new storage folder and the old storage folder is removed.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder with sectors.
+	oldDir := filepath.Join(cmt.persistDir, "storageFolderOld")
+	newDir := filepath.Join(cmt.persistDir, "storageFolderNew")
+	for _, dir := range []string{oldDir, newDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := modules.SectorSize * storageFolderGranularity * 2
+	if err := cmt.cm.AddStorageFolder(oldDir, size); err != nil {
+		t.Fatal(err)
+	}
+	// Add more sectors than fit into the smallest storage folder.
+	numSectors := storageFolderGranularity + 1
+	roots := make([]crypto.Hash, numSectors)
+	datas := make([][]byte, numSectors)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		if err := cmt.cm.AddSector(roots[i], datas[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldIndex := cmt.cm.StorageFolders()[0].Index
+
+	// Invalid migrations are rejected.
+	if err := cmt.cm.MigrateStorageFolder(oldIndex, oldDir); !errors.Contains(err, errMigrationSamePath) {
+		t.Fatalf("expected %v, got %v", errMigrationSamePath, err)
+	}
+	if err := cmt.cm.MigrateStorageFolder(oldIndex, "relative"); !errors.Contains(err, errRelativePath) {
+		t.Fatalf("expected %v, got %v", errRelativePath, err)
+	}
+
+	// Migrate the storage folder while reading the sectors.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for i, root := range roots {
+				data, err := cmt.cm.ReadSector(root)
+				if err != nil || !bytes.Equal(data, datas[i]) {
+					t.Error("unable to read sector during migration", err)
+					return
+				}
+			}
+		}
+	}()
+	err = cmt.cm.MigrateStorageFolder(oldIndex, newDir)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new storage folder holds the sectors, the old one is gone.
+	checkMigrated := func() {
+		t.Helper()
+		sfs := cmt.cm.StorageFolders()
+		if len(sfs) != 1 || sfs[0].Path != newDir || sfs[0].Capacity != size {
+			t.Fatalf("unexpected storage folders %+v", sfs)
+		}
+		if sfs[0].Capacity-sfs[0].CapacityRemaining != modules.SectorSize*uint64(len(roots)) {
+			t.Fatal("sectors weren't moved to the new storage folder", sfs[0].CapacityRemaining)
+		}
+		for i, root := range roots {
+			data, err := cmt.cm.ReadSector(root)
+			if err != nil || !bytes.Equal(data, datas[i]) {
+				t.Fatal("unable to read migrated sector", err)
+			}
+		}
+	}
+	checkMigrated()
+	if _, err := os.Stat(filepath.Join(oldDir, sectorFile)); !os.IsNotExist(err) {
+		t.Fatal("sector file of the old storage folder should have been removed")
+	}
+	if _, err := os.Stat(filepath.Join(oldDir, metadataFile)); !os.IsNotExist(err) {
+		t.Fatal("metadata file of the old storage folder should have been removed")
+	}
+
+	// A destination without enough room is rejected.
+	if err := cmt.cm.AddStorageFolder(oldDir, modules.SectorSize*storageFolderGranularity); err != nil {
+		t.Fatal(err)
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path != newDir {
+			continue
+		}
+		if err := cmt.cm.MigrateStorageFolder(sf.Index, oldDir); !errors.Contains(err, errMigrationNoRoom) {
+			t.Fatalf("expected %v, got %v", errMigrationNoRoom, err)
+		}
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == oldDir {
+			if err := cmt.cm.RemoveStorageFolder(sf.Index, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The migration survives a restart.
+	if err := cmt.cm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMigrated()
+}
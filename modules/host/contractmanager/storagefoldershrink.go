@@ -70,8 +70,12 @@ func (wal *writeAheadLog) shrinkStorageFolder(index uint16, newSectorCount uint3
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
 
+	// Drop the sectors cached in the storage folder, in case it's a fast tier
+	// folder. They may be stored in the part which is removed.
+	wal.cm.staticSectorCache.managedPurgeFolder(index)
+
 	// Clear out the sectors in the storage folder.
-	_, err := wal.managedEmptyStorageFolder(index, newSectorCount)
+	_, err := wal.managedEmptyStorageFolder(index, newSectorCount, nil)
 	if err != nil && !force {
 		return err
 	}
@@ -0,0 +1,130 @@
+package contractmanager
+
+import (
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errFastTierDestination is returned if a storage folder is migrated to a
+	// fast tier storage folder.
+	errFastTierDestination = errors.New("sectors can't be migrated to a fast tier storage folder")
+
+	// errFastTierNotEmpty is returned if the usage of a storage folder shows
+	// sectors when its tier is changed.
+	errFastTierNotEmpty = errors.New("storage folder still has sectors marked as used")
+)
+
+type (
+	// storageFolderTierChange indicates that a storage folder was tagged as
+	// fast tier or standard tier storage.
+	storageFolderTierChange struct {
+		Index    uint16
+		FastTier bool
+	}
+)
+
+// commitStorageFolderTierChange commits a storage folder tier change to the
+// state.
+func (wal *writeAheadLog) commitStorageFolderTierChange(sftc storageFolderTierChange) {
+	sf, exists := wal.cm.storageFolders[sftc.Index]
+	if !exists {
+		return
+	}
+	sf.fastTier = sftc.FastTier
+}
+
+// managedSetFastTier tags the storage folder and waits until the change has
+// been synced. The caller needs to hold the lock of the storage folder.
+//
+// The cache doesn't record its slots in the usage of a fast tier storage
+// folder, so the usage has to show the folder as empty in either direction.
+// Otherwise the slots of cached sectors could be considered to hold sectors
+// once the folder is back in the standard tier, or sectors of the standard
+// tier could be overwritten by the cache.
+func (wal *writeAheadLog) managedSetFastTier(sf *storageFolder, fastTier bool) error {
+	// Wait for a synchronize to confirm that all of the sectors which were
+	// moved out of the storage folder have been moved in full. Otherwise an
+	// unclean shutdown could bring back sectors whose data was overwritten by
+	// the cache.
+	wal.mu.Lock()
+	syncChan := wal.syncChan
+	wal.mu.Unlock()
+	<-syncChan
+
+	wal.mu.Lock()
+	if sf.sectors != 0 || len(usageSectors(sf.usage)) != 0 {
+		wal.mu.Unlock()
+		return errFastTierNotEmpty
+	}
+	sftc := storageFolderTierChange{
+		Index:    sf.index,
+		FastTier: fastTier,
+	}
+	wal.commitStorageFolderTierChange(sftc)
+	wal.appendChange(stateChange{
+		StorageFolderTierChanges: []storageFolderTierChange{sftc},
+	})
+	syncChan = wal.syncChan
+	wal.mu.Unlock()
+	<-syncChan
+	return nil
+}
+
+// SetStorageFolderFastTier tags a storage folder as fast tier or standard tier
+// storage. Fast tier storage folders are reserved for the sector read cache,
+// so the sectors of the storage folder are moved to the standard tier storage
+// folders before the folder is tagged. If any sector can't be moved, an error
+// is returned and the storage folder stays in the standard tier.
+func (cm *ContractManager) SetStorageFolderFastTier(index uint16, fastTier bool) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	// Retrieve the specified storage folder.
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	cm.wal.mu.Unlock()
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return errStorageFolderNotFound
+	}
+
+	// Lock the storage folder for the duration of the operation, so that no
+	// new sectors are added to it.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	cm.wal.mu.Lock()
+	current := sf.fastTier
+	cm.wal.mu.Unlock()
+	if current == fastTier {
+		return nil
+	}
+
+	if !fastTier {
+		// Drop the cached sectors before the storage folder can receive
+		// sectors again.
+		cm.staticSectorCache.managedPurgeFolder(index)
+		err = cm.wal.managedSetFastTier(sf, false)
+		if err != nil {
+			return errors.AddContext(err, "unable to move the storage folder to the standard tier")
+		}
+		cm.log.Printf("Storage folder %v was moved to the standard tier\n", sf.path)
+		return nil
+	}
+
+	// Move the sectors to the standard tier storage folders.
+	_, err = cm.wal.managedEmptyStorageFolder(index, 0, nil)
+	if err != nil {
+		return errors.AddContext(err, "unable to move the sectors out of the storage folder")
+	}
+	err = cm.wal.managedSetFastTier(sf, true)
+	if err != nil {
+		return errors.AddContext(err, "unable to move the storage folder to the fast tier")
+	}
+	cm.log.Printf("Storage folder %v was moved to the fast tier\n", sf.path)
+	return nil
+}
@@ -54,6 +54,7 @@ type (
 		StorageFolderExtensions           []storageFolderExtension
 		StorageFolderRemovals             []storageFolderRemoval
 		StorageFolderReductions           []storageFolderReduction
+		StorageFolderTierChanges          []storageFolderTierChange
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
@@ -187,6 +188,11 @@ func (wal *writeAheadLog) commitChange(sc stateChange) {
 			wal.commitStorageFolderRemoval(sfr)
 		}
 	}
+	for _, sftc := range sc.StorageFolderTierChanges {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitStorageFolderTierChange(sftc)
+		}
+	}
 	for _, su := range sc.SectorUpdates {
 		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
 			wal.commitUpdateSector(su)
@@ -177,6 +177,9 @@ type Host struct {
 	autoAddress          modules.NetAddress // Determined using automatic tooling in network.go
 	financialMetrics     modules.HostFinancialMetrics
 	settings             modules.HostInternalSettings
+	pricingSettings      modules.HostPricingSettings
+	pricingContractCount uint64 // contract count at the last price adjustment
+	policy               modules.HostPolicy
 	revisionNumber       uint64
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
@@ -186,6 +189,25 @@ type Host struct {
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*lockedObligation
 
+	// The usage of the renters, used to enforce the quotas of the host's
+	// policy. The contracts are indexed whenever the financial metrics are
+	// updated for a storage obligation.
+	accountOwners   map[modules.AccountID]types.SiaPublicKey
+	renterBandwidth map[string]renterBandwidth
+	renterContracts map[types.FileContractID]renterContract
+
+	// The number of storage obligations by status. The counts are rebuilt on
+	// startup and updated whenever an obligation is added, resolved or
+	// deleted.
+	obligationCounts map[storageObligationStatus]uint64
+
+	// The number of corrupt sectors of each storage obligation, computed
+	// lazily for the latest revision of the obligation. The counts are reset
+	// whenever the set of corrupt sectors of the storage manager changes.
+	corruptSectorCounts   map[types.FileContractID]corruptSectorCount
+	corruptSectorsVersion uint64
+	corruptSectorsMu      sync.Mutex
+
 	// A collection of rpc price tables, covered by its own RW mutex. It
 	// contains the host's current price table and the set of price tables the
 	// host has communicated to all renters, thus guaranteeing a set of prices
@@ -194,6 +216,10 @@ type Host struct {
 	// of such conditions are congestion, load, liquidity, etc.
 	staticPriceTables *hostPrices
 
+	// staticPricingSettingsChanged wakes the pricing engine when its settings
+	// change, so that a new interval takes effect right away.
+	staticPricingSettingsChanged chan struct{}
+
 	// Fields related to RHP3 bandwidhth.
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
@@ -449,14 +475,20 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		staticMux:                mux,
 		dependencies:             dependencies,
 		lockedStorageObligations: make(map[types.FileContractID]*lockedObligation),
+		accountOwners:            make(map[modules.AccountID]types.SiaPublicKey),
+		renterBandwidth:          make(map[string]renterBandwidth),
+		renterContracts:          make(map[types.FileContractID]renterContract),
+		obligationCounts:         make(map[storageObligationStatus]uint64),
+		corruptSectorCounts:      make(map[types.FileContractID]corruptSectorCount),
 		staticPriceTables: &hostPrices{
 			guaranteed: make(map[modules.UniqueID]*hostRPCPriceTable),
 			staticMinHeap: priceTableHeap{
 				heap: make([]*hostRPCPriceTable, 0),
 			},
 		},
-		staticRegistrySubscriptions: newRegistrySubscriptions(),
-		persistDir:                  persistDir,
+		staticRegistrySubscriptions:  newRegistrySubscriptions(),
+		staticPricingSettingsChanged: make(chan struct{}, 1),
+		persistDir:                   persistDir,
 	}
 
 	// Create MDM.
@@ -561,6 +593,9 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Run the pricing engine, it only adjusts prices if enabled.
+	go h.threadedAdjustPrices()
+
 	return h, nil
 }
 
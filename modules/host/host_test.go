@@ -323,6 +323,8 @@ func newRenterHostPairCustomHostTester(ht *hostTester) (*renterHostPair, error)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to add noop revision")
 	}
+	// the renter identifies itself with its contract key
+	so.Renter = renterPK
 	ht.host.managedLockStorageObligation(so.id())
 	err = ht.host.managedAddStorageObligation(so)
 	if err != nil {
@@ -173,6 +173,7 @@ var (
 type finalizeContractArgs struct {
 	builder                 modules.TransactionBuilder
 	renewedSO               *storageObligation
+	renter                  types.SiaPublicKey
 	renterPK                crypto.PublicKey
 	renterSignatures        []types.TransactionSignature
 	renterRevisionSignature types.TransactionSignature
@@ -277,6 +278,8 @@ func (h *Host) managedFinalizeContract(args finalizeContractArgs) ([]types.Trans
 		OriginTransactionSet:   fullTxnSet,
 		RevisionTransactionSet: []types.Transaction{revisionTransaction},
 
+		Renter: args.renter,
+
 		h: h,
 	}
 
@@ -115,8 +115,8 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	}
 
 	// The host verifies that the file contract coming over the wire is
-	// acceptable.
-	err = h.managedVerifyNewContract(txnSet, renterPK, settings)
+	// acceptable. The legacy protocol can't prove the renter's identity.
+	err = h.managedVerifyNewContract(txnSet, renterPK, types.SiaPublicKey{}, settings)
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
@@ -216,8 +216,9 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 }
 
 // managedVerifyNewContract checks that an incoming file contract matches the host's
-// expectations for a valid contract.
-func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK crypto.PublicKey, eSettings modules.HostExternalSettings) error {
+// expectations for a valid contract. The renter is the identity which the
+// renter proved, if any.
+func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK crypto.PublicKey, renter types.SiaPublicKey, eSettings modules.HostExternalSettings) error {
 	// Register the HostInsufficientCollateral alert if necessary.
 	var registerHostInsufficientCollateral bool
 	defer func() {
@@ -309,6 +310,11 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 		registerHostInsufficientCollateral = true
 		return errCollateralBudgetExceeded
 	}
+	// Check that the host's policy allows the renter to form the contract.
+	err = h.managedCheckRenterRequest(renter, renterRequest{collateral: expectedCollateral})
+	if err != nil {
+		return err
+	}
 	// Check that the total payouts match.
 	totalPayout, validPayout, missedPayout := fc.TotalPayout()
 	if !validPayout.Equals(missedPayout) {
@@ -84,7 +84,7 @@ func TestVerifyNewContract(t *testing.T) {
 
 	// verify a properly created payment revision is accepted
 	settings := newSettings()
-	err = h.managedVerifyNewContract(curr, renterPK, settings)
+	err = h.managedVerifyNewContract(curr, renterPK, types.SiaPublicKey{}, settings)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -101,7 +101,7 @@ func TestVerifyNewContract(t *testing.T) {
 
 	// Empty set.
 	badSet := []types.Transaction{}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if err == nil || !strings.Contains(err.Error(), "zero-length transaction set") {
 		t.Fatal("should fail", err)
 	}
@@ -109,7 +109,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// No contracts.
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts = nil
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if err == nil || !strings.Contains(err.Error(), "transaction without file contract") {
 		t.Fatal("should fail", err)
 	}
@@ -117,7 +117,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// filesize > 0
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].FileSize = 1
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadFileSize) {
 		t.Fatal("should fail", err)
 	}
@@ -125,7 +125,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// merkle root not blank
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].FileMerkleRoot = crypto.Hash{1}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadFileMerkleRoot) {
 		t.Fatal("should fail", err)
 	}
@@ -133,7 +133,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// early window
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].WindowStart = revisionSubmissionBuffer
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrEarlyWindow) {
 		t.Fatal("should fail", err)
 	}
@@ -142,7 +142,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSet = deepCopy(curr)
 	fc := &badSet[len(badSet)-1].FileContracts[0]
 	fc.WindowEnd = fc.WindowStart + settings.WindowSize - 1
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrSmallWindow) {
 		t.Fatal("should fail", err)
 	}
@@ -150,7 +150,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// long duration
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].WindowStart = settings.MaxDuration
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrSmallWindow) {
 		t.Fatal("should fail", err)
 	}
@@ -158,7 +158,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// bad output count #1
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].ValidProofOutputs = nil
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadContractOutputCounts) {
 		t.Fatal("should fail", err)
 	}
@@ -166,7 +166,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// bad output count #2
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].MissedProofOutputs = nil
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadContractOutputCounts) {
 		t.Fatal("should fail", err)
 	}
@@ -175,7 +175,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSet = deepCopy(curr)
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	badSet[len(badSet)-1].FileContracts[0].ValidProofOutputs[1].UnlockHash = types.UnlockHash{1}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadPayoutUnlockHashes) {
 		t.Fatal("should fail", err)
 	}
@@ -183,7 +183,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// bad unlock hashes - missedhost
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].MissedProofOutputs[1].UnlockHash = types.UnlockHash{1}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadPayoutUnlockHashes) {
 		t.Fatal("should fail", err)
 	}
@@ -191,7 +191,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// bad unlock hashes - void
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].MissedProofOutputs[2].UnlockHash = types.UnlockHash{1}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadPayoutUnlockHashes) {
 		t.Fatal("should fail", err)
 	}
@@ -200,7 +200,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSet = deepCopy(curr)
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	fc.SetMissedHostPayout(fc.MissedHostOutput().Value.Add64(1))
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrMismatchedHostPayouts) {
 		t.Fatal("should fail", err)
 	}
@@ -213,7 +213,7 @@ func TestVerifyNewContract(t *testing.T) {
 	fc.ValidProofOutputs[1].Value = settings.ContractPrice.Sub64(1)
 	fc.MissedProofOutputs[1].Value = settings.ContractPrice.Sub64(1)
 	fc.Payout = settings.ContractPrice.Sub64(1)
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrLowHostValidOutput) {
 		t.Fatal("should fail", err)
 	}
@@ -223,7 +223,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSettings.MaxCollateral = types.ZeroCurrency
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	fc.SetMissedHostPayout(fc.MissedHostOutput().Value.Add64(1))
-	err = h.managedVerifyNewContract(curr, renterPK, badSettings)
+	err = h.managedVerifyNewContract(curr, renterPK, types.SiaPublicKey{}, badSettings)
 	if !errors.Contains(err, errMaxCollateralReached) {
 		t.Fatal("should fail", err)
 	}
@@ -231,7 +231,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// budget exceeded
 	backup := h.settings
 	h.settings.CollateralBudget = types.ZeroCurrency
-	err = h.managedVerifyNewContract(curr, renterPK, settings)
+	err = h.managedVerifyNewContract(curr, renterPK, types.SiaPublicKey{}, settings)
 	h.settings = backup
 	if !errors.Contains(err, errCollateralBudgetExceeded) {
 		t.Fatal("should fail", err)
@@ -241,7 +241,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSet = deepCopy(curr)
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	fc.SetValidRenterPayout(fc.ValidRenterOutput().Value.Sub64(1))
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrInvalidPayoutSums) {
 		t.Fatal("should fail", err)
 	}
@@ -250,7 +250,7 @@ func TestVerifyNewContract(t *testing.T) {
 	badSet = deepCopy(curr)
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	fc.SetMissedRenterPayout(fc.MissedRenterOutput().Value.Sub64(1))
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrInvalidPayoutSums) {
 		t.Fatal("should fail", err)
 	}
@@ -260,7 +260,7 @@ func TestVerifyNewContract(t *testing.T) {
 	fc = &badSet[len(badSet)-1].FileContracts[0]
 	fc.SetValidRenterPayout(fc.ValidRenterOutput().Value.Sub64(1))
 	fc.SetMissedRenterPayout(fc.MissedRenterOutput().Value.Sub64(1))
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrInvalidPayoutSums) {
 		t.Fatal("should fail", err)
 	}
@@ -268,7 +268,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// bad unlock hashes - contract
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].FileContracts[0].UnlockHash = types.UnlockHash{1}
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrBadUnlockHash) {
 		t.Fatal("should fail", err)
 	}
@@ -276,7 +276,7 @@ func TestVerifyNewContract(t *testing.T) {
 	// low fee
 	badSet = deepCopy(curr)
 	badSet[len(badSet)-1].MinerFees = nil
-	err = h.managedVerifyNewContract(badSet, renterPK, settings)
+	err = h.managedVerifyNewContract(badSet, renterPK, types.SiaPublicKey{}, settings)
 	if !errors.Contains(err, ErrLowTransactionFees) {
 		t.Fatal("should fail", err)
 	}
@@ -181,6 +181,16 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		registerHostInsufficientCollateral = true
 		return types.Currency{}, errCollateralBudgetExceeded
 	}
+	// Check that the host's policy allows the renter to renew the contract.
+	// The renewed contract keeps the renter of the contract it renews.
+	err = h.managedCheckRenterRequest(so.Renter, renterRequest{
+		storage:    fc.FileSize,
+		collateral: expectedCollateral,
+		replaced:   so.id(),
+	})
+	if err != nil {
+		return types.Currency{}, err
+	}
 
 	// Check that the missed proof outputs contain enough money, and that the
 	// void output contains enough money.
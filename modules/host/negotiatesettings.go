@@ -15,9 +15,12 @@ import (
 // capacity.
 func (h *Host) capacity() (total, remaining uint64) {
 	// Total storage can be computed by summing the size of all the storage
-	// folders.
+	// folders. Fast tier storage folders only hold the read cache.
 	sfs := h.StorageFolders()
 	for _, sf := range sfs {
+		if sf.FastTier {
+			continue
+		}
 		total += sf.Capacity
 		remaining += sf.CapacityRemaining
 	}
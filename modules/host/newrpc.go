@@ -147,6 +147,45 @@ func (h *Host) managedRPCLoopLock(s *rpcSession) error {
 	return nil
 }
 
+// managedRPCLoopIdentify handles the LoopIdentify RPC.
+func (h *Host) managedRPCLoopIdentify(s *rpcSession) error {
+	s.extendDeadline(modules.NegotiateSettingsTime)
+
+	// Challenges can only be used once, so generate a new one immediately,
+	// regardless of the outcome of this RPC.
+	challenge := s.challenge
+	fastrand.Read(s.challenge[:])
+
+	// Read the request.
+	var req modules.LoopIdentifyRequest
+	if err := s.readRequest(&req, modules.RPCMinLen); err != nil {
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+
+	// verify the challenge response
+	if req.PublicKey.Algorithm != types.SignatureEd25519 || len(req.PublicKey.Key) != crypto.PublicKeySize {
+		err := errors.New("identity key is not an ed25519 key")
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+	var renterPK crypto.PublicKey
+	copy(renterPK[:], req.PublicKey.Key)
+	hash := crypto.HashAll(modules.RPCIdentityPrefix, challenge)
+	var renterSig crypto.Signature
+	copy(renterSig[:], req.Signature)
+	if crypto.VerifyHash(hash, renterPK, renterSig) != nil {
+		err := errors.New("identity signature is invalid")
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+	s.renter = req.PublicKey
+
+	return s.writeResponse(modules.LoopIdentifyResponse{
+		NewChallenge: s.challenge,
+	})
+}
+
 // managedRPCLoopUnlock handles the LoopUnlock RPC. No response is sent.
 func (h *Host) managedRPCLoopUnlock(s *rpcSession) error {
 	s.extendDeadline(modules.NegotiateSettingsTime)
@@ -198,6 +237,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 	newRoots := append([]crypto.Hash(nil), s.so.SectorRoots...)
 	sectorsChanged := make(map[uint64]struct{}) // for construct Merkle proof
 	var bandwidthRevenue types.Currency
+	var bandwidth uint64 // for the host's renter policy
 	var sectorsRemoved []crypto.Hash
 	sectorsGained := make(map[crypto.Hash][]byte)
 	for _, action := range req.Actions {
@@ -216,6 +256,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 
 			// Update finances
 			bandwidthRevenue = bandwidthRevenue.Add(settings.UploadBandwidthPrice.Mul64(modules.SectorSize))
+			bandwidth += modules.SectorSize
 
 		case modules.WriteActionTrim:
 			numSectors := action.A
@@ -267,6 +308,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 
 			// Update finances.
 			bandwidthRevenue = bandwidthRevenue.Add(settings.UploadBandwidthPrice.Mul64(uint64(len(action.Data))))
+			bandwidth += uint64(len(action.Data))
 
 		default:
 			err := errors.New("unknown action type " + action.Type.String())
@@ -320,6 +362,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 			proofSize = modules.RPCMinLen
 		}
 		bandwidthRevenue = bandwidthRevenue.Add(settings.DownloadBandwidthPrice.Mul64(uint64(proofSize)))
+		bandwidth += uint64(proofSize)
 	}
 
 	// construct the new revision
@@ -358,6 +401,23 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 		return err
 	}
 
+	// Check that the host's policy allows the renter to upload the data and
+	// the host to risk the additional collateral. Contracts which can't be
+	// attributed to a renter were formed before the policy applied to them,
+	// so they are not affected.
+	if knownRenter(s.so.Renter) {
+		err = h.managedCheckRenterRequest(s.so.Renter, renterRequest{
+			storage:    newRevision.NewFileSize,
+			bandwidth:  bandwidth,
+			collateral: s.so.RiskedCollateral.Add(newCollateral),
+			replaced:   s.so.id(),
+		})
+		if err != nil {
+			err = errors.Compose(err, s.writeError(err))
+			return err
+		}
+	}
+
 	// If a Merkle proof was requested, send it and wait for the renter's signature.
 	if req.MerkleProof {
 		if err := s.writeResponse(merkleResp); err != nil {
@@ -510,6 +570,11 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 		return err
 	}
 
+	// Downloads are never rejected by the host's policy, the host keeps
+	// serving the data it was paid to store. They still count towards the
+	// renter's bandwidth.
+	h.managedRecordRenterBandwidth(s.so.Renter, estBandwidth)
+
 	// Sign the new revision.
 	renterSig := types.TransactionSignature{
 		ParentID:       crypto.Hash(newRevision.ParentID),
@@ -605,7 +670,7 @@ func (h *Host) managedRPCLoopFormContract(s *rpcSession) error {
 	txnSet := req.Transactions
 	var renterPK crypto.PublicKey
 	copy(renterPK[:], req.RenterKey.Key)
-	if err := h.managedVerifyNewContract(txnSet, renterPK, settings); err != nil {
+	if err := h.managedVerifyNewContract(txnSet, renterPK, s.renter, settings); err != nil {
 		err = errors.Compose(err, s.writeError(err))
 		return err
 	}
@@ -643,6 +708,7 @@ func (h *Host) managedRPCLoopFormContract(s *rpcSession) error {
 	fca := finalizeContractArgs{
 		builder:                 txnBuilder,
 		contractPrice:           settings.ContractPrice,
+		renter:                  s.renter,
 		renterPK:                renterPK,
 		renterSignatures:        renterSigs.ContractSignatures,
 		renterRevisionSignature: renterSigs.RevisionSignature,
@@ -917,6 +983,7 @@ func (h *Host) managedRPCLoopRenewAndClearContract(s *rpcSession) error {
 		builder:                 txnBuilder,
 		contractPrice:           settings.ContractPrice,
 		renewedSO:               &s.so,
+		renter:                  s.so.Renter,
 		renterPK:                renterPK,
 		renterSignatures:        renterSigs.ContractSignatures,
 		renterRevisionSignature: renterSigs.RevisionSignature,
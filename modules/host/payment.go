@@ -162,6 +162,16 @@ func (h *Host) managedFundAccount(stream siamux.Stream, request modules.FundAcco
 		return types.ZeroCurrency, errors.AddContext(err, "Could not fetch storage obligation")
 	}
 
+	// check that the host's policy allows the renter to fund accounts,
+	// contracts which can't be attributed to a renter are not affected
+	renter := so.Renter
+	if knownRenter(renter) {
+		err = h.managedCheckRenterRequest(renter, renterRequest{})
+		if err != nil {
+			return types.ZeroCurrency, errors.AddContext(err, "Could not fund account")
+		}
+	}
+
 	// get the current blockheight
 	bh := h.BlockHeight()
 
@@ -202,6 +212,10 @@ func (h *Host) managedFundAccount(stream siamux.Stream, request modules.FundAcco
 	// fsynced we'll close this so the account manager can properly lower the
 	// host's outstanding risk induced by the (immediate) deposit.
 	syncChan := make(chan struct{})
+	err = h.managedSetAccountOwner(request.Account, renter)
+	if err != nil {
+		return types.ZeroCurrency, errors.AddContext(err, "Could not attribute the account to the renter")
+	}
 	err = h.staticAccountManager.callDeposit(request.Account, deposit, syncChan)
 	if err != nil {
 		return types.ZeroCurrency, errors.AddContext(err, "Could not deposit funds")
@@ -30,6 +30,12 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Pricing Engine.
+	PricingSettings modules.HostPricingSettings `json:"pricingsettings"`
+
+	// Renter Policy.
+	Policy modules.HostPolicy `json:"policy"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +54,12 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Pricing Engine.
+		PricingSettings: h.pricingSettings,
+
+		// Renter Policy.
+		Policy: h.policy,
 	}
 }
 
@@ -76,6 +88,7 @@ func (h *Host) establishDefaults() error {
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
 	}
+	h.pricingSettings = defaultPricingSettings()
 
 	// Load the host's key pair, use the same keys as the SiaMux.
 	var sk crypto.SecretKey
@@ -121,6 +134,16 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+
+	// Copy over the pricing engine settings. Hosts which were persisted before
+	// the pricing engine existed use the defaults.
+	h.pricingSettings = p.PricingSettings
+	if p.PricingSettings.Interval == 0 {
+		h.pricingSettings = defaultPricingSettings()
+	}
+
+	// Copy over the renter policy.
+	h.policy = p.Policy
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -142,7 +165,9 @@ func (h *Host) initDB() (err error) {
 		// The storage obligation bucket does not exist, which means the
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
+			bucketAccountOwners,
 			bucketActionItems,
+			bucketRevenueLedger,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
@@ -235,6 +260,7 @@ func (h *Host) load() error {
 	// contract renewals. This leads to an offset to the real value over time.
 	h.financialMetrics.ContractCount = 0
 	h.financialMetrics.LockedStorageCollateral = types.NewCurrency64(0)
+	h.obligationCounts = make(map[storageObligationStatus]uint64)
 	err = h.db.View(func(tx *bolt.Tx) error {
 		cursor := tx.Bucket(bucketStorageObligations).Cursor()
 		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
@@ -243,9 +269,11 @@ func (h *Host) load() error {
 			if err != nil {
 				return err
 			}
+			h.obligationCounts[so.ObligationStatus]++
 			if so.ObligationStatus == obligationUnresolved {
 				h.financialMetrics.ContractCount++
 				h.financialMetrics.LockedStorageCollateral = h.financialMetrics.LockedStorageCollateral.Add(so.LockedCollateral)
+				h.indexRenterContract(so)
 			}
 		}
 		return nil
@@ -254,7 +282,8 @@ func (h *Host) load() error {
 		return err
 	}
 
-	return nil
+	// Load the owners of the ephemeral accounts.
+	return h.loadAccountOwners()
 }
 
 // saveSync stores all of the persist data to disk and then syncs to disk.
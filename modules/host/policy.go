@@ -0,0 +1,351 @@
+package host
+
+// policy.go implements the host's renter policy. The policy restricts which
+// renters are served by the host through an allowlist and a denylist of renter
+// identities, and limits the data stored, the bandwidth used per period and
+// the collateral locked on behalf of a single renter across all of its
+// contracts.
+//
+// The keys of a renter's contracts differ for every contract, so renters are
+// identified by an identity key instead. A renter proves its identity with
+// RPCLoopIdentify before forming a contract, and the identity is stored in the
+// storage obligation. Renewed contracts keep the identity of the contract they
+// renew.
+//
+// The policy is checked when a contract is formed or renewed, when data is
+// uploaded to a contract and when an ephemeral account is funded. New
+// contracts of renters which didn't prove an identity are rejected while the
+// policy restricts the renters. Existing contracts are not affected otherwise:
+// they can always be locked and downloaded from, since the host still has to
+// prove the storage of their data, and contracts which can't be attributed to
+// a renter, e.g. because they were formed before the renter proved its
+// identity, can still be uploaded to. Downloads count towards the bandwidth of
+// known renters, but are never rejected.
+//
+// Ephemeral accounts can only be funded through a contract, so an account is
+// attributed to the renter of the contract which funded it. The owners of the
+// accounts are persisted in the host's database. The bandwidth counters are
+// kept in memory and restart with every boot cycle.
+//
+// The collateral of a contract counts towards the quota with the amount locked
+// in the contract, or with the amount risked by it when it is revised.
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errRenterNotAllowed is returned if the host's policy doesn't allow the
+	// renter to use the host.
+	errRenterNotAllowed = errors.New("renter is not allowed by the host's policy")
+
+	// errRenterBandwidthQuota is returned if a request exceeds the renter's
+	// bandwidth quota for the current period.
+	errRenterBandwidthQuota = errors.New("request exceeds the renter's bandwidth quota")
+
+	// errRenterCollateralQuota is returned if a request exceeds the renter's
+	// collateral quota.
+	errRenterCollateralQuota = errors.New("request exceeds the renter's collateral quota")
+
+	// errUnknownRenter is returned if the host's policy restricts the renters
+	// which are served by the host, and a new contract can't be attributed to
+	// a renter because the renter didn't prove its identity.
+	errUnknownRenter = errors.New("renter didn't prove its identity to the host")
+
+	// errRenterStorageQuota is returned if a request exceeds the renter's
+	// storage quota.
+	errRenterStorageQuota = errors.New("request exceeds the renter's storage quota")
+
+	// errPolicyBandwidthPeriod is returned if a bandwidth quota is set without
+	// a bandwidth period.
+	errPolicyBandwidthPeriod = errors.New("bandwidth quota requires a bandwidth period")
+)
+
+type (
+	// renterContract is the usage of a single active contract, indexed by the
+	// host to enforce the quotas of the renters.
+	renterContract struct {
+		renter     string
+		storage    uint64
+		collateral types.Currency
+	}
+
+	// renterBandwidth is the bandwidth used by a renter within a period.
+	renterBandwidth struct {
+		period types.BlockHeight
+		bytes  uint64
+	}
+
+	// renterRequest describes the resources which a renter requests from the
+	// host.
+	renterRequest struct {
+		storage    uint64
+		bandwidth  uint64
+		collateral types.Currency
+
+		// replaced is the contract which is being renewed or revised. Its
+		// current usage isn't counted towards the renter's quota, the request
+		// contains the usage of the contract after the renewal or revision.
+		replaced types.FileContractID
+	}
+)
+
+// knownRenter returns whether the renter proved its identity to the host.
+func knownRenter(renter types.SiaPublicKey) bool {
+	return len(renter.Key) > 0
+}
+
+// restrictsRenters returns whether the policy restricts the renters which are
+// served by the host, either through its lists or through its quotas.
+func restrictsRenters(p modules.HostPolicy) bool {
+	return len(p.Allowlist) > 0 || len(p.Denylist) > 0 || p.MaxStorage > 0 || p.MaxBandwidth > 0 || !p.MaxCollateral.IsZero()
+}
+
+// validatePolicy checks that the host's policy is sane.
+func validatePolicy(p modules.HostPolicy) error {
+	if p.MaxBandwidth > 0 && p.BandwidthPeriod == 0 {
+		return errPolicyBandwidthPeriod
+	}
+	for _, spk := range p.Allowlist {
+		for _, denied := range p.Denylist {
+			if spk.Equals(denied) {
+				return errors.New("renter " + spk.String() + " is on both the allowlist and the denylist")
+			}
+		}
+	}
+	return nil
+}
+
+// renterAllowed returns whether the policy allows the renter to use the host.
+func renterAllowed(p modules.HostPolicy, renter types.SiaPublicKey) bool {
+	for _, spk := range p.Denylist {
+		if spk.Equals(renter) {
+			return false
+		}
+	}
+	if len(p.Allowlist) == 0 {
+		return true
+	}
+	for _, spk := range p.Allowlist {
+		if spk.Equals(renter) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexRenterContract adds or updates an active storage obligation in the
+// index of the renters' usage. Contracts of unknown renters are not indexed.
+func (h *Host) indexRenterContract(so storageObligation) {
+	if !knownRenter(so.Renter) {
+		return
+	}
+	h.renterContracts[so.id()] = renterContract{
+		renter:     so.Renter.String(),
+		storage:    so.fileSize(),
+		collateral: so.LockedCollateral,
+	}
+}
+
+// renterUsage returns the storage and collateral used by the renter's active
+// contracts, excluding the provided contract.
+func (h *Host) renterUsage(renter string, exclude types.FileContractID) (storage uint64, collateral types.Currency) {
+	for id, rc := range h.renterContracts {
+		if rc.renter != renter || id == exclude {
+			continue
+		}
+		storage += rc.storage
+		collateral = collateral.Add(rc.collateral)
+	}
+	return
+}
+
+// managedCheckRenterRequest checks that the host's policy allows the renter to
+// use the requested resources. If it does, the requested bandwidth is added to
+// the renter's bandwidth for the current period. An empty renter is a renter
+// which didn't prove its identity, which is only allowed while the policy
+// doesn't restrict the renters. Requests on existing contracts which can't be
+// attributed to a renter shouldn't be checked.
+func (h *Host) managedCheckRenterRequest(renter types.SiaPublicKey, req renterRequest) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.policy
+	if !knownRenter(renter) {
+		if restrictsRenters(p) {
+			return errUnknownRenter
+		}
+		return nil
+	}
+	if !renterAllowed(p, renter) {
+		return errRenterNotAllowed
+	}
+
+	key := renter.String()
+	if p.MaxStorage > 0 || !p.MaxCollateral.IsZero() {
+		storage, collateral := h.renterUsage(key, req.replaced)
+		if p.MaxStorage > 0 && storage+req.storage > p.MaxStorage {
+			return errRenterStorageQuota
+		}
+		if !p.MaxCollateral.IsZero() && collateral.Add(req.collateral).Cmp(p.MaxCollateral) > 0 {
+			return errRenterCollateralQuota
+		}
+	}
+
+	if p.MaxBandwidth == 0 || req.bandwidth == 0 {
+		return nil
+	}
+	period := h.blockHeight - h.blockHeight%p.BandwidthPeriod
+	rb := h.renterBandwidth[key]
+	if rb.period != period {
+		rb = renterBandwidth{period: period}
+	}
+	if rb.bytes+req.bandwidth > p.MaxBandwidth {
+		return errRenterBandwidthQuota
+	}
+	rb.bytes += req.bandwidth
+	h.renterBandwidth[key] = rb
+	return nil
+}
+
+// managedRecordRenterBandwidth adds bandwidth which was used by a renter after
+// its request was checked.
+func (h *Host) managedRecordRenterBandwidth(renter types.SiaPublicKey, bandwidth uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.policy.MaxBandwidth == 0 || !knownRenter(renter) {
+		return
+	}
+	key := renter.String()
+	period := h.blockHeight - h.blockHeight%h.policy.BandwidthPeriod
+	rb := h.renterBandwidth[key]
+	if rb.period != period {
+		rb = renterBandwidth{period: period}
+	}
+	rb.bytes += bandwidth
+	h.renterBandwidth[key] = rb
+}
+
+// managedAccountOwner returns the renter which funded the ephemeral account.
+// Accounts which were funded before the host started tracking their owners
+// are not attributed to any renter.
+func (h *Host) managedAccountOwner(id modules.AccountID) (types.SiaPublicKey, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	renter, exists := h.accountOwners[id]
+	return renter, exists
+}
+
+// managedSetAccountOwner attributes the ephemeral account to the renter which
+// funded it. Accounts funded by unknown renters are not attributed.
+func (h *Host) managedSetAccountOwner(id modules.AccountID, renter types.SiaPublicKey) error {
+	if !knownRenter(renter) {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if owner, exists := h.accountOwners[id]; exists && owner.Equals(renter) {
+		return nil
+	}
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAccountOwners).Put([]byte(id.SPK().String()), []byte(renter.String()))
+	})
+	if err != nil {
+		return err
+	}
+	h.accountOwners[id] = renter
+	return nil
+}
+
+// loadAccountOwners loads the owners of the ephemeral accounts from the
+// database.
+func (h *Host) loadAccountOwners() error {
+	return h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAccountOwners).ForEach(func(k, v []byte) error {
+			var id modules.AccountID
+			if err := id.LoadString(string(k)); err != nil {
+				return errors.AddContext(err, "unable to load account id")
+			}
+			var renter types.SiaPublicKey
+			if err := renter.LoadString(string(v)); err != nil {
+				return errors.AddContext(err, "unable to load renter key")
+			}
+			h.accountOwners[id] = renter
+			return nil
+		})
+	})
+}
+
+// Policy returns the host's renter policy.
+func (h *Host) Policy() modules.HostPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.policy
+}
+
+// SetPolicy sets the host's renter policy. The policy applies to new contracts,
+// renewals, uploads and account fundings of the renters. Existing contracts can
+// still be locked and downloaded from.
+func (h *Host) SetPolicy(p modules.HostPolicy) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	if err := validatePolicy(p); err != nil {
+		return errors.AddContext(err, "policy not updated")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policy = p
+	err = h.saveSync()
+	if err != nil {
+		return errors.AddContext(err, "policy updated, but failed saving to disk")
+	}
+	return nil
+}
+
+// RenterUsage returns the resources used by each renter with an active
+// contract on the host, sorted by the amount of stored data.
+func (h *Host) RenterUsage() []modules.HostRenterUsage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	usage := make(map[string]*modules.HostRenterUsage)
+	for _, rc := range h.renterContracts {
+		u, exists := usage[rc.renter]
+		if !exists {
+			u = &modules.HostRenterUsage{}
+			if err := u.Renter.LoadString(rc.renter); err != nil {
+				continue
+			}
+			usage[rc.renter] = u
+		}
+		u.Contracts++
+		u.Storage += rc.storage
+		u.Collateral = u.Collateral.Add(rc.collateral)
+	}
+	var period types.BlockHeight
+	if h.policy.BandwidthPeriod > 0 {
+		period = h.blockHeight - h.blockHeight%h.policy.BandwidthPeriod
+	}
+	usages := make([]modules.HostRenterUsage, 0, len(usage))
+	for renter, u := range usage {
+		if rb := h.renterBandwidth[renter]; rb.period == period {
+			u.Bandwidth = rb.bytes
+		}
+		usages = append(usages, *u)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Storage != usages[j].Storage {
+			return usages[i].Storage > usages[j].Storage
+		}
+		return usages[i].Renter.String() < usages[j].Renter.String()
+	})
+	return usages
+}
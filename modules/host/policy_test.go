@@ -0,0 +1,187 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRenterAllowed is a unit test for renterAllowed and validatePolicy.
+func TestRenterAllowed(t *testing.T) {
+	t.Parallel()
+
+	renter := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	other := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+
+	tests := []struct {
+		policy  modules.HostPolicy
+		allowed bool
+	}{
+		{modules.HostPolicy{}, true},
+		{modules.HostPolicy{Denylist: []types.SiaPublicKey{other}}, true},
+		{modules.HostPolicy{Denylist: []types.SiaPublicKey{renter}}, false},
+		{modules.HostPolicy{Allowlist: []types.SiaPublicKey{renter}}, true},
+		{modules.HostPolicy{Allowlist: []types.SiaPublicKey{other}}, false},
+	}
+	for i, test := range tests {
+		if allowed := renterAllowed(test.policy, renter); allowed != test.allowed {
+			t.Errorf("%v: expected %v, got %v", i, test.allowed, allowed)
+		}
+	}
+
+	// A renter can't be on both lists.
+	p := modules.HostPolicy{Allowlist: []types.SiaPublicKey{renter}, Denylist: []types.SiaPublicKey{renter}}
+	if err := validatePolicy(p); err == nil {
+		t.Fatal("expected policy with a renter on both lists to be invalid")
+	}
+	// A bandwidth quota requires a period.
+	p = modules.HostPolicy{MaxBandwidth: 1}
+	if err := validatePolicy(p); !errors.Contains(err, errPolicyBandwidthPeriod) {
+		t.Fatalf("expected %v, got %v", errPolicyBandwidthPeriod, err)
+	}
+	p.BandwidthPeriod = 1
+	if err := validatePolicy(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRenterPolicy verifies that the host enforces its renter policy when
+// accounts are funded, applies the per-renter quotas and persists the policy
+// and the owners of the ephemeral accounts.
+func TestRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := pair.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ht := pair.staticHT
+	renter := pair.staticRenterPK
+
+	// Funding an account attributes it to the renter of the contract.
+	his := ht.host.managedInternalSettings()
+	funding := his.MaxEphemeralAccountBalance.Div64(10)
+	if _, err := pair.managedFundEphemeralAccount(funding, true); err != nil {
+		t.Fatal(err)
+	}
+	if owner, known := ht.host.managedAccountOwner(pair.staticAccountID); !known || !owner.Equals(renter) {
+		t.Fatal("account wasn't attributed to the renter", owner, known)
+	}
+	usage := ht.host.RenterUsage()
+	if len(usage) != 1 || !usage[0].Renter.Equals(renter) || usage[0].Contracts != 1 {
+		t.Fatalf("unexpected renter usage %+v", usage)
+	}
+
+	// A denied renter can't fund its account anymore.
+	p := modules.HostPolicy{Denylist: []types.SiaPublicKey{renter}}
+	if err := ht.host.SetPolicy(p); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.managedFundEphemeralAccount(funding, false)
+	if err == nil || !strings.Contains(err.Error(), errRenterNotAllowed.Error()) {
+		t.Fatalf("expected %v, got %v", errRenterNotAllowed, err)
+	}
+
+	// Readonly programs are never rejected, even if they are paid by an
+	// account which can't be attributed to a renter.
+	p = modules.HostPolicy{MaxStorage: modules.SectorSize}
+	if err := ht.host.SetPolicy(p); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.mu.Lock()
+	delete(ht.host.accountOwners, pair.staticAccountID)
+	ht.host.mu.Unlock()
+	pt, err := pair.managedFetchPriceTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := newTestHasSectorProgram(pt, crypto.Hash{})
+	epr := modules.RPCExecuteProgramRequest{
+		Program:           program.program,
+		ProgramDataLength: uint64(len(program.data)),
+	}
+	budget := funding.Div64(10)
+	if _, _, err := pair.managedExecuteProgram(epr, program.data, budget, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedSetAccountOwner(pair.staticAccountID, renter); err != nil {
+		t.Fatal(err)
+	}
+
+	// New contracts of renters which didn't prove their identity are only
+	// allowed while the policy doesn't restrict the renters.
+	unknown := types.SiaPublicKey{}
+	if err := ht.host.SetPolicy(modules.HostPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedCheckRenterRequest(unknown, renterRequest{storage: modules.SectorSize}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.SetPolicy(modules.HostPolicy{MaxStorage: modules.SectorSize}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedCheckRenterRequest(unknown, renterRequest{}); !errors.Contains(err, errUnknownRenter) {
+		t.Fatalf("expected %v, got %v", errUnknownRenter, err)
+	}
+
+	// Check the quotas. The usage of a replaced contract doesn't count towards
+	// the renter's quota.
+	p = modules.HostPolicy{
+		MaxStorage:      modules.SectorSize,
+		MaxBandwidth:    modules.SectorSize,
+		BandwidthPeriod: 10,
+		MaxCollateral:   usage[0].Collateral.Add(types.SiacoinPrecision),
+	}
+	if err := ht.host.SetPolicy(p); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req renterRequest
+		err error
+	}{
+		{renterRequest{storage: modules.SectorSize + 1}, errRenterStorageQuota},
+		{renterRequest{collateral: types.SiacoinPrecision.Mul64(2)}, errRenterCollateralQuota},
+		{renterRequest{collateral: p.MaxCollateral, replaced: pair.staticFCID}, nil},
+		{renterRequest{storage: modules.SectorSize, collateral: types.SiacoinPrecision, bandwidth: modules.SectorSize}, nil},
+		{renterRequest{bandwidth: 1}, errRenterBandwidthQuota},
+	}
+	for i, test := range tests {
+		err := ht.host.managedCheckRenterRequest(renter, test.req)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Fatalf("%v: expected %v, got %v", i, test.err, err)
+		}
+	}
+	if usage := ht.host.RenterUsage(); usage[0].Bandwidth != modules.SectorSize {
+		t.Fatal("bandwidth wasn't recorded", usage[0].Bandwidth)
+	}
+
+	// The policy and the owners of the accounts survive a restart.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopenHost(ht); err != nil {
+		t.Fatal(err)
+	}
+	if loaded := ht.host.Policy(); loaded.MaxStorage != p.MaxStorage || !loaded.MaxCollateral.Equals(p.MaxCollateral) {
+		t.Fatal("policy wasn't persisted", loaded)
+	}
+	if owner, known := ht.host.managedAccountOwner(pair.staticAccountID); !known || !owner.Equals(renter) {
+		t.Fatal("account owner wasn't persisted", owner, known)
+	}
+	if usage := ht.host.RenterUsage(); len(usage) != 1 || usage[0].Contracts != 1 {
+		t.Fatalf("renter usage wasn't rebuilt %+v", usage)
+	}
+}
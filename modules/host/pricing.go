@@ -0,0 +1,313 @@
+package host
+
+// pricing.go implements the host's pricing engine. The engine is opt-in and
+// periodically adjusts the storage price, the bandwidth prices and the
+// collateral of the host's internal settings. Every price targets a base price
+// which is either derived from a fiat target or the middle of the range the
+// operator allows for the price. The base price is scaled by the utilization
+// of the host's storage folders, going from half the base price for an empty
+// host to one and a half times the base price for a full host, and by the
+// demand for contracts. To avoid sudden jumps, a price changes by at most
+// MaxChange per adjustment and never leaves the range between its floor and
+// ceiling.
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errPricingBounds is returned if the floor of a price is above its
+	// ceiling.
+	errPricingBounds = errors.New("price floor must not be above the price ceiling")
+
+	// errPricingInterval is returned if the interval of the pricing engine is
+	// too short.
+	errPricingInterval = fmt.Errorf("pricing interval must be at least %v", minPricingInterval)
+
+	// errPricingMaxChange is returned if the maximum change per adjustment is
+	// not within (0, 1].
+	errPricingMaxChange = errors.New("max change must be greater than 0 and at most 1")
+
+	// errPricingNoCeilings is returned if the pricing engine is enabled
+	// without any price to manage.
+	errPricingNoCeilings = errors.New("pricing engine requires at least one price ceiling")
+)
+
+const (
+	// defaultPricingMaxChange is the default maximum relative change of a
+	// price per adjustment.
+	defaultPricingMaxChange = 0.05
+
+	// pricingDemandAdjustment is the relative amount by which the target
+	// prices are raised if the number of active contracts grew since the last
+	// adjustment and lowered if it shrank.
+	pricingDemandAdjustment = 0.1
+)
+
+var (
+	// defaultPricingInterval is the default amount of time between two
+	// adjustments of the pricing engine.
+	defaultPricingInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 6,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
+	// minPricingInterval is the minimum amount of time between two
+	// adjustments of the pricing engine.
+	minPricingInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+// defaultPricingSettings returns the settings of the pricing engine of a new
+// host. The engine is disabled by default.
+func defaultPricingSettings() modules.HostPricingSettings {
+	return modules.HostPricingSettings{
+		Interval:  defaultPricingInterval,
+		MaxChange: defaultPricingMaxChange,
+	}
+}
+
+// validatePricingSettings checks that the settings of the pricing engine are
+// sane.
+func validatePricingSettings(ps modules.HostPricingSettings) error {
+	if ps.Interval < minPricingInterval {
+		return errPricingInterval
+	}
+	if ps.MaxChange <= 0 || ps.MaxChange > 1 {
+		return errPricingMaxChange
+	}
+	if _, err := types.ParseExchangeRate(ps.ExchangeRate); err != nil {
+		return errors.AddContext(err, "invalid exchange rate")
+	}
+	if ps.FiatStoragePrice < 0 || ps.FiatUploadPrice < 0 || ps.FiatDownloadPrice < 0 {
+		return errors.New("fiat targets must not be negative")
+	}
+	bounds := [][2]types.Currency{
+		{ps.StoragePriceFloor, ps.StoragePriceCeiling},
+		{ps.UploadBandwidthPriceFloor, ps.UploadBandwidthPriceCeiling},
+		{ps.DownloadBandwidthPriceFloor, ps.DownloadBandwidthPriceCeiling},
+		{ps.CollateralFloor, ps.CollateralCeiling},
+	}
+	var managed bool
+	for _, b := range bounds {
+		if b[0].Cmp(b[1]) > 0 {
+			return errPricingBounds
+		}
+		managed = managed || !b[1].IsZero()
+	}
+	if ps.Enabled && !managed {
+		return errPricingNoCeilings
+	}
+	return nil
+}
+
+// adjustPrice moves the current price towards the target price by at most
+// maxChange and clamps the result to the range between floor and ceiling.
+func adjustPrice(current, target, floor, ceiling types.Currency, maxChange float64) types.Currency {
+	price := target
+	if !current.IsZero() {
+		if upper := current.MulFloat(1 + maxChange); price.Cmp(upper) > 0 {
+			price = upper
+		} else if lower := current.MulFloat(1 - maxChange); price.Cmp(lower) < 0 {
+			price = lower
+		}
+	}
+	if price.Cmp(floor) < 0 {
+		price = floor
+	} else if price.Cmp(ceiling) > 0 {
+		price = ceiling
+	}
+	return price
+}
+
+// basePrice returns the price the pricing engine targets before taking the
+// utilization and demand into account. The fiat target is converted into a
+// price per unit if an exchange rate is available.
+func basePrice(rate *types.ExchangeRate, fiat float64, unit, floor, ceiling types.Currency) types.Currency {
+	if rate != nil && fiat > 0 {
+		return rate.ApplyInverse(fiat).Div(unit)
+	}
+	return floor.Add(ceiling).Div64(2)
+}
+
+// managedStorageUtilization returns the fraction of the host's storage which
+// is in use.
+func (h *Host) managedStorageUtilization() float64 {
+	var capacity, remaining uint64
+	for _, sf := range h.StorageFolders() {
+		if sf.FastTier {
+			continue
+		}
+		capacity += sf.Capacity
+		remaining += sf.CapacityRemaining
+	}
+	if capacity == 0 {
+		return 0
+	}
+	return float64(capacity-remaining) / float64(capacity)
+}
+
+// managedAdjustPrices runs a single adjustment of the pricing engine, updating
+// the host's internal settings and price table if any of the managed prices
+// changed.
+func (h *Host) managedAdjustPrices() error {
+	h.mu.Lock()
+	ps := h.pricingSettings
+	old := h.settings
+	demand := int64(h.financialMetrics.ContractCount) - int64(h.pricingContractCount)
+	h.pricingContractCount = h.financialMetrics.ContractCount
+	h.mu.Unlock()
+	if !ps.Enabled {
+		return nil
+	}
+
+	// Determine the factor by which the base prices are scaled.
+	factor := 0.5 + h.managedStorageUtilization()
+	if demand > 0 {
+		factor *= 1 + pricingDemandAdjustment
+	} else if demand < 0 {
+		factor *= 1 - pricingDemandAdjustment
+	}
+
+	// Compute the new prices. The exchange rate was validated when the
+	// settings were set.
+	rate, _ := types.ParseExchangeRate(ps.ExchangeRate)
+	storagePrice := old.MinStoragePrice
+	if !ps.StoragePriceCeiling.IsZero() {
+		base := basePrice(rate, ps.FiatStoragePrice, modules.BlockBytesPerMonthTerabyte, ps.StoragePriceFloor, ps.StoragePriceCeiling)
+		storagePrice = adjustPrice(storagePrice, base.MulFloat(factor), ps.StoragePriceFloor, ps.StoragePriceCeiling, ps.MaxChange)
+	}
+	uploadPrice := old.MinUploadBandwidthPrice
+	if !ps.UploadBandwidthPriceCeiling.IsZero() {
+		base := basePrice(rate, ps.FiatUploadPrice, modules.BytesPerTerabyte, ps.UploadBandwidthPriceFloor, ps.UploadBandwidthPriceCeiling)
+		uploadPrice = adjustPrice(uploadPrice, base.MulFloat(factor), ps.UploadBandwidthPriceFloor, ps.UploadBandwidthPriceCeiling, ps.MaxChange)
+	}
+	downloadPrice := old.MinDownloadBandwidthPrice
+	if !ps.DownloadBandwidthPriceCeiling.IsZero() {
+		base := basePrice(rate, ps.FiatDownloadPrice, modules.BytesPerTerabyte, ps.DownloadBandwidthPriceFloor, ps.DownloadBandwidthPriceCeiling)
+		downloadPrice = adjustPrice(downloadPrice, base.MulFloat(factor), ps.DownloadBandwidthPriceFloor, ps.DownloadBandwidthPriceCeiling, ps.MaxChange)
+	}
+	collateral := old.Collateral
+	if !ps.CollateralCeiling.IsZero() {
+		base := ps.CollateralFloor.Add(ps.CollateralCeiling).Div64(2)
+		collateral = adjustPrice(collateral, base.MulFloat(factor), ps.CollateralFloor, ps.CollateralCeiling, ps.MaxChange)
+	}
+
+	// Apply the new prices to the current settings, which might have been
+	// changed by the operator in the meantime.
+	h.mu.Lock()
+	settings := h.settings
+	settings.MinStoragePrice = storagePrice
+	settings.MinUploadBandwidthPrice = uploadPrice
+	settings.MinDownloadBandwidthPrice = downloadPrice
+	settings.Collateral = collateral
+	// Don't lower the download price below the ratios enforced for the base
+	// RPC price and the sector access price.
+	if settings.MinBaseRPCPrice.Cmp(settings.MaxBaseRPCPrice()) > 0 || settings.MinSectorAccessPrice.Cmp(settings.MaxSectorAccessPrice()) > 0 {
+		settings.MinDownloadBandwidthPrice = h.settings.MinDownloadBandwidthPrice
+	}
+	unchanged := settings.MinStoragePrice.Equals(h.settings.MinStoragePrice) &&
+		settings.MinUploadBandwidthPrice.Equals(h.settings.MinUploadBandwidthPrice) &&
+		settings.MinDownloadBandwidthPrice.Equals(h.settings.MinDownloadBandwidthPrice) &&
+		settings.Collateral.Equals(h.settings.Collateral)
+	if unchanged {
+		h.mu.Unlock()
+		return nil
+	}
+	h.settings = settings
+	h.revisionNumber++
+	err := h.saveSync()
+	h.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "prices updated, but failed saving to disk")
+	}
+	h.log.Printf("Pricing engine adjusted prices (factor %.3f): storage %v -> %v, upload %v -> %v, download %v -> %v, collateral %v -> %v\n",
+		factor, old.MinStoragePrice, storagePrice, old.MinUploadBandwidthPrice, uploadPrice, old.MinDownloadBandwidthPrice, settings.MinDownloadBandwidthPrice, old.Collateral, collateral)
+
+	// Update the price table so renters see the new prices. Renters keep the
+	// prices of the price tables they already paid for until those expire.
+	h.managedUpdatePriceTable()
+	return nil
+}
+
+// threadedAdjustPrices periodically runs the pricing engine.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedAdjustPrices() {
+	for {
+		h.mu.RLock()
+		interval := h.pricingSettings.Interval
+		h.mu.RUnlock()
+		if interval < minPricingInterval {
+			interval = defaultPricingInterval
+		}
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-h.staticPricingSettingsChanged:
+			// Start over with the new interval.
+			continue
+		case <-time.After(interval):
+		}
+
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.managedAdjustPrices(); err != nil {
+				h.log.Println("ERROR: pricing engine failed to adjust prices:", err)
+			}
+		}()
+	}
+}
+
+// PricingSettings returns the settings of the host's pricing engine.
+func (h *Host) PricingSettings() modules.HostPricingSettings {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pricingSettings
+}
+
+// SetPricingSettings sets the settings of the host's pricing engine.
+func (h *Host) SetPricingSettings(ps modules.HostPricingSettings) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	if err := validatePricingSettings(ps); err != nil {
+		return errors.AddContext(err, "pricing settings not updated")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ps.Enabled && !h.pricingSettings.Enabled {
+		// Only contracts formed after enabling the engine count as demand.
+		h.pricingContractCount = h.financialMetrics.ContractCount
+	}
+	h.pricingSettings = ps
+	select {
+	case h.staticPricingSettingsChanged <- struct{}{}:
+	default:
+	}
+	err = h.saveSync()
+	if err != nil {
+		return errors.AddContext(err, "pricing settings updated, but failed saving to disk")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPricingEngine verifies that the pricing engine validates its settings,
+// moves the managed prices towards their targets within the configured bounds
+// and updates the host's price table.
+func TestPricingEngine(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+	settings := h.InternalSettings()
+	storagePrice := settings.MinStoragePrice
+
+	// Check the validation of the settings.
+	ps := h.PricingSettings()
+	if ps.Enabled || ps.Interval != defaultPricingInterval || ps.MaxChange != defaultPricingMaxChange {
+		t.Fatal("unexpected default pricing settings", ps)
+	}
+	invalid := []struct {
+		modify func(*modules.HostPricingSettings)
+		err    error
+	}{
+		{func(ps *modules.HostPricingSettings) { ps.Interval = 0 }, errPricingInterval},
+		{func(ps *modules.HostPricingSettings) { ps.MaxChange = 0 }, errPricingMaxChange},
+		{func(ps *modules.HostPricingSettings) { ps.MaxChange = 1.5 }, errPricingMaxChange},
+		{func(ps *modules.HostPricingSettings) { ps.ExchangeRate = "0 usd" }, types.ErrZeroNotAllowed},
+		{func(ps *modules.HostPricingSettings) { ps.CollateralFloor = types.NewCurrency64(1) }, errPricingBounds},
+		{func(ps *modules.HostPricingSettings) { ps.Enabled = true }, errPricingNoCeilings},
+	}
+	for _, test := range invalid {
+		ps := h.PricingSettings()
+		test.modify(&ps)
+		if err := h.SetPricingSettings(ps); !errors.Contains(err, test.err) {
+			t.Fatalf("expected %v, got %v", test.err, err)
+		}
+	}
+
+	// A disabled engine doesn't touch the prices.
+	ps.StoragePriceFloor = storagePrice.Div64(4)
+	ps.StoragePriceCeiling = storagePrice.Mul64(2)
+	if err := h.SetPricingSettings(ps); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedAdjustPrices(); err != nil {
+		t.Fatal(err)
+	}
+	if !h.InternalSettings().MinStoragePrice.Equals(storagePrice) {
+		t.Fatal("disabled pricing engine changed the storage price")
+	}
+
+	// The host is empty, so the storage price targets half the middle of its
+	// range and decreases by at most 5% per adjustment.
+	ps.Enabled = true
+	if err := h.SetPricingSettings(ps); err != nil {
+		t.Fatal(err)
+	}
+	h.mu.RLock()
+	revision := h.revisionNumber
+	h.mu.RUnlock()
+	if err := h.managedAdjustPrices(); err != nil {
+		t.Fatal(err)
+	}
+	newSettings := h.InternalSettings()
+	expected := storagePrice.MulFloat(1 - defaultPricingMaxChange)
+	if !newSettings.MinStoragePrice.Equals(expected) {
+		t.Fatalf("expected storage price %v, got %v", expected, newSettings.MinStoragePrice)
+	}
+	if !newSettings.MinUploadBandwidthPrice.Equals(settings.MinUploadBandwidthPrice) || !newSettings.Collateral.Equals(settings.Collateral) {
+		t.Fatal("pricing engine changed prices without a ceiling")
+	}
+	if !h.PriceTable().WriteStoreCost.Equals(expected) {
+		t.Fatal("price table wasn't updated", h.PriceTable().WriteStoreCost)
+	}
+	h.mu.RLock()
+	increased := h.revisionNumber > revision
+	h.mu.RUnlock()
+	if !increased {
+		t.Fatal("revision number wasn't increased")
+	}
+
+	// The storage price never drops below its floor.
+	for i := 0; i < 50; i++ {
+		if err := h.managedAdjustPrices(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if price := h.InternalSettings().MinStoragePrice; price.Cmp(ps.StoragePriceFloor) < 0 {
+		t.Fatalf("storage price %v dropped below floor %v", price, ps.StoragePriceFloor)
+	}
+
+	// A fiat target is converted through the exchange rate. 1 SC per TB at
+	// half the target results in 0.5 SC per TB.
+	ps.ExchangeRate = "1 usd"
+	ps.FiatUploadPrice = 1
+	ps.MaxChange = 1
+	ps.UploadBandwidthPriceCeiling = types.SiacoinPrecision
+	if err := h.SetPricingSettings(ps); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedAdjustPrices(); err != nil {
+		t.Fatal(err)
+	}
+	expected = types.SiacoinPrecision.Div(modules.BytesPerTerabyte).Div64(2)
+	if price := h.InternalSettings().MinUploadBandwidthPrice; !price.Equals(expected) {
+		t.Fatalf("expected upload price %v, got %v", expected, price)
+	}
+	if !h.PriceTable().UploadBandwidthCost.Equals(expected) {
+		t.Fatal("price table wasn't updated", h.PriceTable().UploadBandwidthCost)
+	}
+
+	// The pricing settings survive a restart.
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded := ht.host.PricingSettings(); !loaded.Enabled || loaded.ExchangeRate != ps.ExchangeRate || !loaded.UploadBandwidthPriceCeiling.Equals(ps.UploadBandwidthPriceCeiling) {
+		t.Fatal("pricing settings weren't persisted", loaded)
+	}
+}
+
+// TestPricingEngineInterval verifies that a new interval of the pricing engine
+// takes effect without waiting for the previous interval to pass.
+func TestPricingEngineInterval(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+	storagePrice := h.InternalSettings().MinStoragePrice
+
+	// Enable the engine with the shortest interval. The engine is waiting for
+	// the much longer default interval at this point.
+	ps := h.PricingSettings()
+	ps.Enabled = true
+	ps.Interval = minPricingInterval
+	ps.StoragePriceFloor = storagePrice.Div64(4)
+	ps.StoragePriceCeiling = storagePrice.Mul64(2)
+	if err := h.SetPricingSettings(ps); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for less than the default interval of 10 seconds.
+	err = build.Retry(25, 200*time.Millisecond, func() error {
+		if h.InternalSettings().MinStoragePrice.Equals(storagePrice) {
+			return errors.New("storage price wasn't adjusted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,88 @@
+package host
+
+// revenue.go contains the host's revenue ledger. Whenever a storage obligation
+// is resolved as either succeeded or failed, an entry with the realized or
+// lost revenue is recorded together with the block height and time of the
+// resolution. Unlike the storage obligations themselves, the entries are never
+// pruned which makes the ledger suitable for accounting purposes.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newRevenueEntry creates the revenue ledger entry for a storage obligation
+// which is resolved with the provided status. Rejected obligations neither
+// realize nor lose any revenue so no entry is created for them.
+func newRevenueEntry(so storageObligation, sos storageObligationStatus, height types.BlockHeight, timestamp time.Time) (modules.HostRevenueEntry, bool) {
+	if sos != obligationSucceeded && sos != obligationFailed {
+		return modules.HostRevenueEntry{}, false
+	}
+	entry := modules.HostRevenueEntry{
+		ObligationID: so.id(),
+		Status:       sos.String(),
+		BlockHeight:  height,
+		Timestamp:    timestamp.Unix(),
+
+		TransactionFeeExpenses: so.TransactionFeesAdded,
+	}
+	if sos == obligationSucceeded {
+		entry.AccountFunding = so.PotentialAccountFunding
+		entry.ContractCompensation = so.ContractCost
+		entry.DownloadBandwidthRevenue = so.PotentialDownloadRevenue
+		entry.StorageRevenue = so.PotentialStorageRevenue
+		entry.UploadBandwidthRevenue = so.PotentialUploadRevenue
+	} else {
+		entry.LostRevenue = so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue).Add(so.PotentialAccountFunding)
+		entry.LostStorageCollateral = so.RiskedCollateral
+	}
+	return entry, true
+}
+
+// putRevenueEntry appends an entry to the revenue ledger. The entries are
+// keyed by a big endian sequence number which keeps them in the order in
+// which they were recorded.
+func putRevenueEntry(tx *bolt.Tx, entry modules.HostRevenueEntry) error {
+	b := tx.Bucket(bucketRevenueLedger)
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return b.Put(key, entryBytes)
+}
+
+// RevenueLedger returns the entries of the host's revenue ledger which match
+// the provided filter.
+func (h *Host) RevenueLedger(filter modules.HostRevenueFilter) (entries []modules.HostRevenueEntry, err error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRevenueLedger).ForEach(func(_, v []byte) error {
+			var entry modules.HostRevenueEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return errors.AddContext(err, "unable to unmarshal revenue entry")
+			}
+			if filter.Matches(entry) {
+				entries = append(entries, entry)
+			}
+			return nil
+		})
+	})
+	return entries, err
+}
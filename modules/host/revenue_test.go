@@ -0,0 +1,132 @@
+package host
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRevenueLedger verifies that resolving storage obligations records their
+// revenue in the ledger and that the ledger survives pruning the obligations
+// and restarting the host.
+func TestRevenueLedger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	all := modules.HostRevenueFilter{End: math.MaxInt64, EndHeight: math.MaxUint64}
+
+	// Create a succeeding and a failing storage obligation.
+	newSO := func() storageObligation {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		so.ContractCost = types.NewCurrency64(1)
+		so.PotentialStorageRevenue = types.NewCurrency64(2)
+		so.PotentialDownloadRevenue = types.NewCurrency64(3)
+		so.PotentialUploadRevenue = types.NewCurrency64(4)
+		so.PotentialAccountFunding = types.NewCurrency64(5)
+		so.RiskedCollateral = types.NewCurrency64(6)
+		ht.host.managedLockStorageObligation(so.id())
+		defer ht.host.managedUnlockStorageObligation(so.id())
+		if err := ht.host.managedAddStorageObligation(so); err != nil {
+			t.Fatal(err)
+		}
+		return so
+	}
+	succeeded, failed := newSO(), newSO()
+	if err := ht.host.removeStorageObligation(succeeded, obligationSucceeded); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.removeStorageObligation(failed, obligationFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	// checkEntries checks the entries of the ledger.
+	checkEntries := func(entries []modules.HostRevenueEntry) {
+		t.Helper()
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries but got %v", len(entries))
+		}
+		s, f := entries[0], entries[1]
+		if s.ObligationID != succeeded.id() || s.Status != obligationSucceeded.String() {
+			t.Fatal("wrong succeeded entry", s.ObligationID, s.Status)
+		}
+		if !s.ContractCompensation.Equals64(1) || !s.StorageRevenue.Equals64(2) || !s.DownloadBandwidthRevenue.Equals64(3) || !s.UploadBandwidthRevenue.Equals64(4) || !s.AccountFunding.Equals64(5) {
+			t.Fatal("wrong revenue", s)
+		}
+		if !s.LostRevenue.IsZero() || !s.LostStorageCollateral.IsZero() {
+			t.Fatal("succeeded entry shouldn't lose revenue", s)
+		}
+		if f.ObligationID != failed.id() || f.Status != obligationFailed.String() {
+			t.Fatal("wrong failed entry", f.ObligationID, f.Status)
+		}
+		if !f.LostRevenue.Equals64(15) || !f.LostStorageCollateral.Equals64(6) || !f.StorageRevenue.IsZero() {
+			t.Fatal("wrong lost revenue", f)
+		}
+		if s.BlockHeight != ht.host.BlockHeight() || s.Timestamp == 0 {
+			t.Fatal("wrong height or timestamp", s.BlockHeight, s.Timestamp)
+		}
+	}
+	entries, err := ht.host.RevenueLedger(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(entries)
+
+	// Filter the entries.
+	height := entries[0].BlockHeight
+	timestamp := entries[0].Timestamp
+	filters := []struct {
+		filter   modules.HostRevenueFilter
+		expected int
+	}{
+		{modules.HostRevenueFilter{Start: timestamp, End: math.MaxInt64, StartHeight: height, EndHeight: height}, 2},
+		{modules.HostRevenueFilter{End: math.MaxInt64, StartHeight: height + 1, EndHeight: math.MaxUint64}, 0},
+		{modules.HostRevenueFilter{End: timestamp - 1, EndHeight: math.MaxUint64}, 0},
+	}
+	for i, test := range filters {
+		filtered, err := ht.host.RevenueLedger(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filtered) != test.expected {
+			t.Fatalf("%v: expected %v entries but got %v", i, test.expected, len(filtered))
+		}
+	}
+
+	// Pruning the obligations and restarting the host shouldn't affect the
+	// ledger.
+	if err := ht.host.deleteStorageObligations([]types.FileContractID{succeeded.id(), failed.id()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.PruneStaleStorageObligations(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ht.host.RevenueLedger(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(entries)
+}
@@ -88,6 +88,28 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		}
 	}
 
+	// Check that the host's policy allows the renter to execute the program.
+	// The renter is identified by the contract of the program or otherwise by
+	// the contract which funded the paying account. Only programs which upload
+	// data of a known renter are checked, like uploads through RPCLoopWrite.
+	// The data of all programs counts towards the renter's bandwidth.
+	renter, _ := h.managedAccountOwner(pd.AccountID())
+	if program.RequiresSnapshot() {
+		renter = sos.Renter()
+	}
+	var outputBytes uint64
+	if !readonly && knownRenter(renter) {
+		err = h.managedCheckRenterRequest(renter, renterRequest{storage: dataLength, bandwidth: dataLength})
+		if err != nil {
+			return errors.AddContext(err, "program rejected by the host's policy")
+		}
+	} else {
+		h.managedRecordRenterBandwidth(renter, dataLength)
+	}
+	defer func() {
+		h.managedRecordRenterBandwidth(renter, outputBytes)
+	}()
+
 	// Get the remaining unallocated collateral.
 	collateralBudget := sos.UnallocatedCollateral()
 
@@ -196,6 +218,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		}
 
 		// Write output.
+		outputBytes += uint64(len(output.Output))
 		_, err = buffer.Write(output.Output)
 		if err != nil {
 			return errors.AddContext(err, "failed to send output data to peer")
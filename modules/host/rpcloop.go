@@ -22,6 +22,10 @@ type rpcSession struct {
 	aead      cipher.AEAD
 	so        storageObligation
 	challenge [16]byte
+
+	// renter is the identity which the renter proved within the session. It
+	// is attributed to the contracts formed within the session.
+	renter types.SiaPublicKey
 }
 
 // extendDeadline extends the read/write deadline on the underlying connection
@@ -125,6 +129,7 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		modules.RPCLoopUnlock:             h.managedRPCLoopUnlock,
 		modules.RPCLoopSettings:           h.managedRPCLoopSettings,
 		modules.RPCLoopFormContract:       h.managedRPCLoopFormContract,
+		modules.RPCLoopIdentify:           h.managedRPCLoopIdentify,
 		modules.RPCLoopRenewClearContract: h.managedRPCLoopRenewAndClearContract,
 		modules.RPCLoopWrite:              h.managedRPCLoopWrite,
 		modules.RPCLoopRead:               h.managedRPCLoopRead,
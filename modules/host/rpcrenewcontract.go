@@ -140,6 +140,17 @@ func (h *Host) managedRPCRenewContract(stream siamux.Stream) error {
 		return errors.AddContext(err, "managedRPCRenewContract: failed to verify new contract")
 	}
 
+	// Check that the host's policy allows the renter to renew the contract.
+	// The renewed contract keeps the renter of the contract it renews.
+	err = h.managedCheckRenterRequest(so.Renter, renterRequest{
+		storage:    newContract.FileSize,
+		collateral: hostCollateral,
+		replaced:   fcid,
+	})
+	if err != nil {
+		return errors.AddContext(err, "managedRPCRenewContract: renewal rejected by the host's policy")
+	}
+
 	// Add the collateral to the contract as well as the renter's pre-payment.
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddRenewCollateral(hostCollateral, so, txns)
 	if err != nil {
@@ -195,6 +206,7 @@ func (h *Host) managedRPCRenewContract(stream siamux.Stream) error {
 		builder:                 txnBuilder,
 		contractPrice:           contractPrice,
 		renewedSO:               &so,
+		renter:                  so.Renter,
 		renterPK:                renterPK,
 		renterSignatures:        renterTxnSigs,
 		renterRevisionSignature: renterNoOpRevisionSig,
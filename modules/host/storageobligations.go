@@ -167,6 +167,11 @@ type storageObligation struct {
 	OriginTransactionSet   []types.Transaction
 	RevisionTransactionSet []types.Transaction
 
+	// Renter is the identity which the renter proved when forming the
+	// contract. Renewed contracts keep the identity of the contract they
+	// renew. It is empty if the renter didn't prove an identity.
+	Renter types.SiaPublicKey
+
 	// Variables indicating whether the critical transactions in a storage
 	// obligation have been confirmed on the blockchain.
 	ObligationStatus    storageObligationStatus
@@ -179,6 +184,13 @@ type storageObligation struct {
 	h *Host
 }
 
+// corruptSectorCount is the cached number of corrupt sectors of a storage
+// obligation at a revision.
+type corruptSectorCount struct {
+	revision uint64
+	count    uint64
+}
+
 // storageObligationStatus indicates the current status of a storage obligation
 type storageObligationStatus uint64
 
@@ -227,6 +239,7 @@ func (h *Host) managedGetStorageObligationSnapshot(id types.FileContractID) (Sto
 		staticContractSize:  so.fileSize(),
 		staticMerkleRoot:    so.merkleRoot(),
 		staticProofDeadline: so.proofDeadline(),
+		staticRenter:        so.Renter,
 		staticRevisionTxn:   revTxn,
 		staticSectorRoots:   so.SectorRoots,
 	}, nil
@@ -266,6 +279,7 @@ type StorageObligationSnapshot struct {
 	staticContractSize  uint64
 	staticMerkleRoot    crypto.Hash
 	staticProofDeadline types.BlockHeight
+	staticRenter        types.SiaPublicKey
 	staticRevisionTxn   types.Transaction
 	staticSectorRoots   []crypto.Hash
 }
@@ -312,6 +326,11 @@ func (sos StorageObligationSnapshot) RecentRevision() types.FileContractRevision
 	return sos.staticRevisionTxn.FileContractRevisions[0]
 }
 
+// Renter returns the identity of the renter of the underlying contract.
+func (sos StorageObligationSnapshot) Renter() types.SiaPublicKey {
+	return sos.staticRenter
+}
+
 // RevisionTxn returns the txn containing the filecontract revision.
 func (sos StorageObligationSnapshot) RevisionTxn() types.Transaction {
 	return sos.staticRevisionTxn
@@ -546,16 +565,24 @@ func (h *Host) managedGetStorageObligation(fcid types.FileContractID) (so storag
 // the deleted obligations don't belong in the database in the first place, so
 // no financial metrics are updated.
 func (h *Host) deleteStorageObligations(soids []types.FileContractID) error {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var deleted []storageObligationStatus
 	err := h.db.Update(func(tx *bolt.Tx) error {
 		// Delete obligations.
 		b := tx.Bucket(bucketStorageObligations)
 		for _, soid := range soids {
-			err := b.Delete([]byte(soid[:]))
+			so, err := h.getStorageObligation(tx, soid)
+			if errors.Contains(err, errNoStorageObligation) {
+				continue
+			} else if err != nil {
+				return build.ExtendErr("unable to get storage obligation:", err)
+			}
+			err = b.Delete([]byte(soid[:]))
 			if err != nil {
 				return build.ExtendErr("unable to delete contract id:", err)
 			}
+			deleted = append(deleted, so.ObligationStatus)
 		}
 		return nil
 	})
@@ -563,6 +590,12 @@ func (h *Host) deleteStorageObligations(soids []types.FileContractID) error {
 		h.log.Println(build.ExtendErr("database failed to delete storage obligations:", err))
 		return err
 	}
+	for _, status := range deleted {
+		h.obligationCounts[status]--
+	}
+	for _, soid := range soids {
+		delete(h.renterContracts, soid)
+	}
 	return nil
 }
 
@@ -792,6 +825,8 @@ func (h *Host) managedQueueActionItemsForNewSO(so storageObligation) error {
 // updateFinancialMetricsAddSO updates the host's financial metrics for a newly
 // added storage obligation.
 func (h *Host) updateFinancialMetricsAddSO(so storageObligation) {
+	h.indexRenterContract(so)
+	h.obligationCounts[so.ObligationStatus]++
 	h.financialMetrics.ContractCount++
 	h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Add(so.ContractCost)
 	h.financialMetrics.LockedStorageCollateral = h.financialMetrics.LockedStorageCollateral.Add(so.LockedCollateral)
@@ -806,6 +841,8 @@ func (h *Host) updateFinancialMetricsAddSO(so storageObligation) {
 // updateFinancialMetricsAddSO updates the host's financial metrics for a
 // modified storage obligation.
 func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
+	h.indexRenterContract(newSO)
+
 	// Update the financial information for the storage obligation - apply the
 	// new values.
 	h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Add(newSO.ContractCost)
@@ -1062,14 +1099,27 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.tryUnregisterInsufficientCollateralBudgetAlert()
 	}
 
+	// Record the realized or lost revenue in the revenue ledger unless the
+	// obligation was already resolved before.
+	entry, record := newRevenueEntry(so, sos, h.blockHeight, time.Now())
+	record = record && so.ObligationStatus == obligationUnresolved
+
 	// Update the storage obligation to be finalized but still in-database. The
 	// obligation status is updated so that the user can see how the obligation
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
+	delete(h.renterContracts, so.id())
+	h.obligationCounts[so.ObligationStatus]--
+	h.obligationCounts[sos]++
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
+		if record {
+			if err := putRevenueEntry(tx, entry); err != nil {
+				return errors.AddContext(err, "unable to record revenue entry")
+			}
+		}
 		return putStorageObligation(tx, so)
 	})
 }
@@ -1457,7 +1507,9 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
 
-			sos = append(sos, so.StorageObligation())
+			mso := so.StorageObligation()
+			mso.CorruptSectors = h.managedCorruptSectors(so)
+			sos = append(sos, mso)
 			return nil
 		})
 		if err != nil {
@@ -1472,6 +1524,19 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 	return sos
 }
 
+// StorageObligationCounts returns the number of storage obligations in the host
+// by status. Unlike StorageObligations, it doesn't read the obligations from
+// the database.
+func (h *Host) StorageObligationCounts() map[string]uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	counts := make(map[string]uint64, len(h.obligationCounts))
+	for status, n := range h.obligationCounts {
+		counts[status.String()] = n
+	}
+	return counts
+}
+
 // StorageObligation returns the storage obligation matching the id or
 // an error if it does not exist
 func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.StorageObligation, error) {
@@ -1480,5 +1545,32 @@ func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.Sto
 		return modules.StorageObligation{}, errors.AddContext(err, "failed to fetch storage obligation")
 	}
 
-	return so.StorageObligation(), nil
+	mso := so.StorageObligation()
+	mso.CorruptSectors = h.managedCorruptSectors(so)
+	return mso, nil
+}
+
+// managedCorruptSectors returns the number of corrupt sectors of the storage
+// obligation. Looking up the sectors is expensive for large obligations, so the
+// count is cached until the obligation is revised or the set of corrupt sectors
+// changes.
+func (h *Host) managedCorruptSectors(so storageObligation) uint64 {
+	version := h.CorruptSectorsVersion()
+	h.corruptSectorsMu.Lock()
+	defer h.corruptSectorsMu.Unlock()
+	if version != h.corruptSectorsVersion {
+		h.corruptSectorCounts = make(map[types.FileContractID]corruptSectorCount)
+		h.corruptSectorsVersion = version
+	}
+
+	id, revision := so.id(), so.revisionNumber()
+	if c, exists := h.corruptSectorCounts[id]; exists && c.revision == revision {
+		return c.count
+	}
+	count := uint64(len(h.CorruptSectors(so.SectorRoots)))
+	h.corruptSectorCounts[id] = corruptSectorCount{
+		revision: revision,
+		count:    count,
+	}
+	return count
 }
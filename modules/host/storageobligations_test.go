@@ -520,3 +520,81 @@ func TestStorageObligationRequiresProof(t *testing.T) {
 		t.Fatal("obligation shouldn't require proof")
 	}
 }
+
+// TestStorageObligationCounts checks that the host keeps the number of storage
+// obligations by status in sync with the obligations in the database.
+func TestStorageObligationCounts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// checkCounts compares the counts of the host to the obligations in the
+	// database.
+	checkCounts := func(expected int) {
+		t.Helper()
+		expectedCounts := make(map[string]uint64)
+		sos := ht.host.StorageObligations()
+		for _, so := range sos {
+			expectedCounts[so.ObligationStatus]++
+		}
+		if len(sos) != expected {
+			t.Fatalf("expected %v obligations, got %v", expected, len(sos))
+		}
+		counts := ht.host.StorageObligationCounts()
+		for _, status := range []storageObligationStatus{obligationUnresolved, obligationRejected, obligationSucceeded, obligationFailed} {
+			if counts[status.String()] != expectedCounts[status.String()] {
+				t.Fatalf("expected %v %v obligations, got %v", expectedCounts[status.String()], status, counts[status.String()])
+			}
+		}
+	}
+
+	// Add obligations and resolve some of them.
+	var sos []storageObligation
+	for i := 0; i < 4; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ht.host.managedLockStorageObligation(so.id())
+		err = ht.host.managedAddStorageObligation(so)
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sos = append(sos, so)
+	}
+	checkCounts(4)
+	ht.host.mu.Lock()
+	err = errors.Compose(ht.host.removeStorageObligation(sos[0], obligationSucceeded), ht.host.removeStorageObligation(sos[1], obligationFailed), ht.host.removeStorageObligation(sos[2], obligationRejected))
+	ht.host.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(4)
+
+	// Delete an obligation.
+	if err := ht.host.deleteStorageObligations([]types.FileContractID{sos[0].id()}); err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(3)
+
+	// The counts are rebuilt after a restart.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopenHost(ht); err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(3)
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"io"
+	"time"
 
 	"go.sia.tech/siad/types"
 )
@@ -30,6 +31,29 @@ type BlockManager interface {
 	// BlocksMined returns the number of blocks and stale blocks that have been
 	// mined using this miner.
 	BlocksMined() (goodBlocks, staleBlocks int)
+
+	// StratumWorkers returns the statistics of the workers which connected to
+	// the stratum server of the miner, sorted by name.
+	StratumWorkers() []StratumWorker
+}
+
+// StratumWorker contains the share statistics of a worker of the miner's
+// stratum server. Workers are identified by the username they authorize
+// with, all connections of a worker share the same statistics.
+type StratumWorker struct {
+	Name string `json:"name"`
+	// Address is the address which receives the block rewards of the worker
+	// in solo mode.
+	Address        types.UnlockHash `json:"address"`
+	Connections    int              `json:"connections"`
+	AcceptedShares uint64           `json:"acceptedshares"`
+	RejectedShares uint64           `json:"rejectedshares"`
+	StaleShares    uint64           `json:"staleshares"`
+	BlocksFound    uint64           `json:"blocksfound"`
+	LastShare      time.Time        `json:"lastshare"`
+	// Hashrate is the hashrate of the worker in hashes per second, estimated
+	// from the shares of the last ten minutes.
+	Hashrate float64 `json:"hashrate"`
 }
 
 // CPUMiner provides access to a single-threaded cpu miner.
@@ -15,11 +15,10 @@ var (
 	errLateHeader = errors.New("header is old, block could not be recovered")
 )
 
-// blockForWork returns a block that is ready for nonce grinding, including
-// correct miner payouts and a random transaction to prevent collisions and
-// overlapping work with other blocks being mined in parallel or for different
-// forks (during testing).
-func (m *Miner) blockForWork() types.Block {
+// blockTemplate returns the unsolved block with an updated timestamp and
+// correct miner payouts. The transactions of the template share memory with
+// the unsolved block and must be copied before they are modified.
+func (m *Miner) blockTemplate() types.Block {
 	b := m.persist.UnsolvedBlock
 
 	// Update the timestamp.
@@ -36,6 +35,15 @@ func (m *Miner) blockForWork() types.Block {
 		Value:      b.CalculateSubsidy(m.persist.Height + 1),
 		UnlockHash: m.persist.Address,
 	}}
+	return b
+}
+
+// blockForWork returns a block that is ready for nonce grinding, including
+// correct miner payouts and a random transaction to prevent collisions and
+// overlapping work with other blocks being mined in parallel or for different
+// forks (during testing).
+func (m *Miner) blockForWork() types.Block {
+	b := m.blockTemplate()
 
 	// Add an arb-data txn to the block to create a unique merkle root.
 	randBytes := fastrand.Bytes(types.SpecifierLen)
@@ -65,6 +73,11 @@ func (m *Miner) newSourceBlock() {
 	block := m.blockForWork()
 	m.sourceBlock = &block
 	m.sourceBlockTime = time.Now()
+
+	// Push new jobs to the stratum workers.
+	if m.stratum != nil {
+		m.stratum.signalNewJobs()
+	}
 }
 
 // HeaderForWork returns a header that is ready for nonce grinding. The miner
@@ -101,6 +101,10 @@ type Miner struct {
 	mining   bool  // indicates if the miner is actually running
 	hashRate int64 // indicates hashes per second
 
+	// stratum is the stratum server of the miner, it is nil if the server
+	// hasn't been started.
+	stratum *stratumServer
+
 	// Utils
 	log        *persist.Logger
 	mu         sync.RWMutex
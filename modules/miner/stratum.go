@@ -0,0 +1,788 @@
+package miner
+
+// stratum.go implements a stratum server which hands out work to external
+// mining hardware. The server speaks the Sia dialect of the stratum protocol:
+// the coinbase of a job is an arbitrary data transaction which is appended to
+// the block and contains the extranonces, and the merkle branch contains the
+// roots needed to compute the merkle root of the block from that transaction.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// stratumExtranonce1Size is the size of the extranonce which the server
+	// assigns to a session.
+	stratumExtranonce1Size = 4
+
+	// stratumExtranonce2Size is the size of the extranonce which is chosen by
+	// the worker.
+	stratumExtranonce2Size = 4
+
+	// stratumJobMemory is the number of jobs a session remembers. Shares for
+	// older jobs are rejected as stale.
+	stratumJobMemory = 8
+
+	// stratumHashrateWindow is the period over which the hashrate of a worker
+	// is estimated.
+	stratumHashrateWindow = 10 * time.Minute
+
+	// stratumMaxMessageSize is the maximum size of a message sent by a worker.
+	stratumMaxMessageSize = 1 << 14
+
+	// stratumWriteTimeout is the timeout for sending a message to a worker.
+	stratumWriteTimeout = 10 * time.Second
+
+	// stratumNotifyQueueSize is the number of jobs which can be queued for a
+	// session. A session which falls further behind is closed so that a
+	// stalled worker doesn't delay the jobs of the other workers.
+	stratumNotifyQueueSize = 4
+)
+
+// The error codes of the stratum protocol.
+const (
+	stratumErrOther          = 20
+	stratumErrJobNotFound    = 21
+	stratumErrDuplicateShare = 22
+	stratumErrLowDifficulty  = 23
+	stratumErrUnauthorized   = 24
+	stratumErrNotSubscribed  = 25
+)
+
+var (
+	// DefaultStratumDifficulty is the default share difficulty of the stratum
+	// server.
+	DefaultStratumDifficulty = build.Select(build.Var{
+		Standard: uint64(1 << 34),
+		Dev:      uint64(1 << 10),
+		Testing:  uint64(1),
+	}).(uint64)
+
+	// stratumMaxWorkers is the maximum number of workers the stratum server
+	// keeps statistics for. Once it is reached, the least recently active
+	// worker without an open connection is dropped to make room for a new
+	// one.
+	stratumMaxWorkers = build.Select(build.Var{
+		Standard: 1024,
+		Dev:      128,
+		Testing:  2,
+	}).(int)
+
+	errStratumRunning      = errors.New("stratum server is already running")
+	errZeroShareDifficulty = errors.New("share difficulty must be greater than zero")
+)
+
+type (
+	// StratumConfig contains the settings of the stratum server.
+	StratumConfig struct {
+		// Address is the address the server listens on.
+		Address string
+
+		// Difficulty is the share difficulty. A share is a header whose ID
+		// meets the target types.RootDepth / Difficulty.
+		Difficulty uint64
+
+		// Solo enables the solo pool mode. In solo mode, workers authorize
+		// with a username of the form 'address.rig' and the blocks they find
+		// pay the full reward to their address. Otherwise the rewards go to
+		// the wallet of the miner.
+		Solo bool
+	}
+
+	// stratumServer accepts stratum connections and pushes jobs to the
+	// workers.
+	stratumServer struct {
+		m           *Miner
+		config      StratumConfig
+		shareTarget types.Target
+		listener    net.Listener
+		newJobs     chan struct{}
+
+		sessions       map[*stratumSession]struct{}
+		workers        map[string]*stratumWorker
+		nextExtranonce uint32
+		nextJobID      uint64
+		mu             sync.Mutex
+	}
+
+	// stratumSession is a connection of a worker.
+	stratumSession struct {
+		conn        net.Conn
+		extranonce1 [stratumExtranonce1Size]byte
+		writeMu     sync.Mutex
+
+		// notify queues the jobs of the session, which are sent by
+		// threadedSendJobs until closed is closed.
+		notify chan stratumNotification
+		closed chan struct{}
+
+		// The following fields are protected by the mutex of the server.
+		subscribed bool
+		worker     *stratumWorker
+		jobs       []*stratumJob
+	}
+
+	// stratumJob is a block which is handed out to workers. The last
+	// transaction of the block contains the extranonces, which are left blank
+	// in the job.
+	stratumJob struct {
+		id     string
+		block  types.Block
+		height types.BlockHeight
+		target types.Target
+		branch []crypto.Hash
+		coinb1 []byte
+		coinb2 []byte
+
+		// shares contains the shares which were submitted for the job to
+		// detect duplicates.
+		shares map[string]struct{}
+	}
+
+	// stratumWorker contains the statistics of a worker.
+	stratumWorker struct {
+		name        string
+		address     types.UnlockHash
+		connections int
+		accepted    uint64
+		rejected    uint64
+		stale       uint64
+		blocks      uint64
+		firstSeen   time.Time
+		lastShare   time.Time
+		shareTimes  []time.Time
+	}
+
+	// stratumPush is a job which is about to be sent to a session.
+	stratumPush struct {
+		session *stratumSession
+		job     *stratumJob
+		clean   bool
+	}
+
+	// stratumRequest is a request sent by a worker.
+	stratumRequest struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	// stratumResponse is the response to a request.
+	stratumResponse struct {
+		ID     json.RawMessage `json:"id"`
+		Result interface{}     `json:"result"`
+		Error  *stratumError   `json:"error"`
+	}
+
+	// stratumNotification is a message sent by the server without a request.
+	stratumNotification struct {
+		ID     interface{}   `json:"id"`
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}
+
+	// stratumError is an error returned to a worker.
+	stratumError struct {
+		code    int
+		message string
+	}
+)
+
+// Error implements the error interface.
+func (se *stratumError) Error() string {
+	return se.message
+}
+
+// MarshalJSON encodes the error as a [code, message, traceback] triple.
+func (se *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{se.code, se.message, nil})
+}
+
+// stratumLeafHash returns the merkle tree hash of a leaf.
+func stratumLeafHash(data []byte) crypto.Hash {
+	return crypto.HashBytes(append([]byte{0}, data...))
+}
+
+// stratumNodeHash returns the merkle tree hash of a node.
+func stratumNodeHash(left, right crypto.Hash) crypto.Hash {
+	return crypto.HashBytes(append(append([]byte{1}, left[:]...), right[:]...))
+}
+
+// newStratumJob creates a job from a block template by appending a blank
+// extranonce transaction to the block.
+func newStratumJob(id string, b types.Block, height types.BlockHeight, target types.Target) *stratumJob {
+	extranonceTxn := types.Transaction{
+		ArbitraryData: [][]byte{make([]byte, types.SpecifierLen+stratumExtranonce1Size+stratumExtranonce2Size)},
+	}
+	copy(extranonceTxn.ArbitraryData[0], modules.PrefixNonSia[:])
+	txns := make([]types.Transaction, len(b.Transactions), len(b.Transactions)+1)
+	copy(txns, b.Transactions)
+	b.Transactions = append(txns, extranonceTxn)
+
+	// The extranonces are followed by the empty signatures of the transaction.
+	enc := encoding.Marshal(extranonceTxn)
+	split := len(enc) - len(encoding.Marshal(extranonceTxn.TransactionSignatures)) - stratumExtranonce1Size - stratumExtranonce2Size
+	job := &stratumJob{
+		id:     id,
+		block:  b,
+		height: height,
+		target: target,
+		coinb1: enc[:split],
+		coinb2: enc[split+stratumExtranonce1Size+stratumExtranonce2Size:],
+		shares: make(map[string]struct{}),
+	}
+
+	// The merkle branch consists of the roots of the complete subtrees formed
+	// by the leaves preceding the extranonce transaction, smallest first.
+	type subtree struct {
+		root   crypto.Hash
+		height int
+	}
+	var stack []subtree
+	push := func(data []byte) {
+		stack = append(stack, subtree{root: stratumLeafHash(data)})
+		for n := len(stack); n > 1 && stack[n-1].height == stack[n-2].height; n = len(stack) {
+			stack[n-2] = subtree{
+				root:   stratumNodeHash(stack[n-2].root, stack[n-1].root),
+				height: stack[n-2].height + 1,
+			}
+			stack = stack[:n-1]
+		}
+	}
+	for _, payout := range b.MinerPayouts {
+		push(encoding.Marshal(payout))
+	}
+	for _, txn := range b.Transactions[:len(b.Transactions)-1] {
+		push(encoding.Marshal(txn))
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		job.branch = append(job.branch, stack[i].root)
+	}
+	return job
+}
+
+// header returns the header of the job's block for the provided extranonces,
+// timestamp and nonce.
+func (job *stratumJob) header(extranonce []byte, timestamp types.Timestamp, nonce types.BlockNonce) types.BlockHeader {
+	root := stratumLeafHash(append(append(append([]byte(nil), job.coinb1...), extranonce...), job.coinb2...))
+	for _, h := range job.branch {
+		root = stratumNodeHash(h, root)
+	}
+	return types.BlockHeader{
+		ParentID:   job.block.ParentID,
+		Nonce:      nonce,
+		Timestamp:  timestamp,
+		MerkleRoot: root,
+	}
+}
+
+// solvedBlock returns the job's block for the provided extranonces, timestamp
+// and nonce.
+func (job *stratumJob) solvedBlock(extranonce []byte, timestamp types.Timestamp, nonce types.BlockNonce) types.Block {
+	b := job.block
+	b.Timestamp = timestamp
+	b.Nonce = nonce
+	b.Transactions = append([]types.Transaction(nil), b.Transactions...)
+	data := append(modules.PrefixNonSia[:], extranonce...)
+	b.Transactions[len(b.Transactions)-1] = types.Transaction{ArbitraryData: [][]byte{data}}
+	return b
+}
+
+// notifyParams returns the parameters of the mining.notify message of the job.
+func (job *stratumJob) notifyParams(clean bool) []interface{} {
+	branch := make([]string, len(job.branch))
+	for i, h := range job.branch {
+		branch[i] = hex.EncodeToString(h[:])
+	}
+	return []interface{}{
+		job.id,
+		hex.EncodeToString(job.block.ParentID[:]),
+		hex.EncodeToString(job.coinb1),
+		hex.EncodeToString(job.coinb2),
+		branch,
+		"",
+		"",
+		hex.EncodeToString(encoding.Marshal(job.block.Timestamp)),
+		clean,
+	}
+}
+
+// hashrate estimates the hashrate of the worker from its recent shares.
+func (w *stratumWorker) hashrate(difficulty uint64) float64 {
+	window := stratumHashrateWindow
+	if since := time.Since(w.firstSeen); since < window {
+		window = since
+	}
+	if window < time.Second {
+		window = time.Second
+	}
+	return float64(len(w.shareTimes)) * float64(difficulty) / window.Seconds()
+}
+
+// pruneShares removes the shares which are outside of the hashrate window.
+func (w *stratumWorker) pruneShares() {
+	cutoff := time.Now().Add(-stratumHashrateWindow)
+	i := sort.Search(len(w.shareTimes), func(i int) bool {
+		return w.shareTimes[i].After(cutoff)
+	})
+	w.shareTimes = w.shareTimes[i:]
+}
+
+// lastSeen returns the time of the last share of the worker, or the time it
+// first authorized if it didn't submit any shares.
+func (w *stratumWorker) lastSeen() time.Time {
+	if w.lastShare.After(w.firstSeen) {
+		return w.lastShare
+	}
+	return w.firstSeen
+}
+
+// write sends a message to the worker of the session.
+func (sess *stratumSession) write(msg interface{}) error {
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+	if err := sess.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout)); err != nil {
+		return err
+	}
+	return json.NewEncoder(sess.conn).Encode(msg)
+}
+
+// StartStratum starts the stratum server of the miner. The server runs until
+// the miner is closed.
+func (m *Miner) StartStratum(config StratumConfig) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+	if config.Difficulty == 0 {
+		return errZeroShareDifficulty
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stratum != nil {
+		return errStratumRunning
+	}
+	l, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return errors.AddContext(err, "unable to start stratum server")
+	}
+	s := &stratumServer{
+		m:           m,
+		config:      config,
+		shareTarget: types.IntToTarget(new(big.Int).Div(types.RootDepth.Int(), new(big.Int).SetUint64(config.Difficulty))),
+		listener:    l,
+		newJobs:     make(chan struct{}, 1),
+		sessions:    make(map[*stratumSession]struct{}),
+		workers:     make(map[string]*stratumWorker),
+	}
+	m.stratum = s
+
+	// Close the listener and all connections when the miner is stopped.
+	m.tg.OnStop(func() error {
+		err := l.Close()
+		s.mu.Lock()
+		for sess := range s.sessions {
+			err = errors.Compose(err, sess.conn.Close())
+		}
+		s.mu.Unlock()
+		return err
+	})
+	go s.threadedListen()
+	go s.threadedPushJobs()
+	m.log.Println("Stratum server listening on", l.Addr())
+	return nil
+}
+
+// StratumWorkers returns the statistics of the workers which connected to the
+// stratum server of the miner, sorted by name.
+func (m *Miner) StratumWorkers() []modules.StratumWorker {
+	m.mu.RLock()
+	s := m.stratum
+	m.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workers := make([]modules.StratumWorker, 0, len(s.workers))
+	for _, w := range s.workers {
+		w.pruneShares()
+		workers = append(workers, modules.StratumWorker{
+			Name:           w.name,
+			Address:        w.address,
+			Connections:    w.connections,
+			AcceptedShares: w.accepted,
+			RejectedShares: w.rejected,
+			StaleShares:    w.stale,
+			BlocksFound:    w.blocks,
+			LastShare:      w.lastShare,
+			Hashrate:       w.hashrate(s.config.Difficulty),
+		})
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Name < workers[j].Name
+	})
+	return workers
+}
+
+// signalNewJobs signals the server to push new jobs to its workers. It doesn't
+// block and may be called while holding the miner's lock.
+func (s *stratumServer) signalNewJobs() {
+	select {
+	case s.newJobs <- struct{}{}:
+	default:
+	}
+}
+
+// threadedListen accepts stratum connections until the listener is closed.
+func (s *stratumServer) threadedListen() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.threadedHandleConn(conn)
+	}
+}
+
+// threadedPushJobs pushes new jobs to the workers whenever the miner creates a
+// new source block. Jobs are refreshed periodically to include new
+// transactions and to keep the timestamp of the block current.
+func (s *stratumServer) threadedPushJobs() {
+	if err := s.m.tg.Add(); err != nil {
+		return
+	}
+	defer s.m.tg.Done()
+
+	ticker := time.NewTicker(MaxSourceBlockAge)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.m.tg.StopChan():
+			return
+		case <-s.newJobs:
+		case <-ticker.C:
+		}
+		s.managedPushJobs(nil)
+	}
+}
+
+// managedPushJobs queues new jobs for the authorized sessions of the server. If
+// only is not nil, a job is queued only for that session.
+func (s *stratumServer) managedPushJobs(only *stratumSession) {
+	s.m.mu.Lock()
+	template := s.m.blockTemplate()
+	height := s.m.persist.Height + 1
+	target := s.m.persist.Target
+	s.m.mu.Unlock()
+
+	s.mu.Lock()
+	var pushes []stratumPush
+	jobs := make(map[types.UnlockHash]*stratumJob)
+	for sess := range s.sessions {
+		if sess.worker == nil || (only != nil && sess != only) {
+			continue
+		}
+		// In solo mode the block reward goes to the address of the worker.
+		payout := template.MinerPayouts[0]
+		if s.config.Solo {
+			payout.UnlockHash = sess.worker.address
+		}
+		if payout.UnlockHash == (types.UnlockHash{}) {
+			s.m.log.Println("WARN: not sending stratum job, the miner has no payout address")
+			break
+		}
+		job, exists := jobs[payout.UnlockHash]
+		if !exists {
+			b := template
+			b.MinerPayouts = []types.SiacoinOutput{payout}
+			job = newStratumJob(strconv.FormatUint(s.nextJobID, 16), b, height, target)
+			s.nextJobID++
+			jobs[payout.UnlockHash] = job
+		}
+
+		// Old jobs can be dropped if the job builds on a new parent.
+		clean := len(sess.jobs) == 0 || sess.jobs[len(sess.jobs)-1].block.ParentID != job.block.ParentID
+		if clean {
+			sess.jobs = sess.jobs[:0]
+		}
+		sess.jobs = append(sess.jobs, job)
+		if len(sess.jobs) > stratumJobMemory {
+			sess.jobs = sess.jobs[1:]
+		}
+		pushes = append(pushes, stratumPush{session: sess, job: job, clean: clean})
+	}
+	s.mu.Unlock()
+
+	for _, p := range pushes {
+		select {
+		case p.session.notify <- stratumNotification{
+			Method: "mining.notify",
+			Params: p.job.notifyParams(p.clean),
+		}:
+		default:
+			p.session.conn.Close()
+		}
+	}
+}
+
+// threadedSendJobs sends the queued jobs of a session until the session is
+// closed.
+func (s *stratumServer) threadedSendJobs(sess *stratumSession) {
+	for {
+		select {
+		case <-sess.closed:
+			return
+		case n := <-sess.notify:
+			if err := sess.write(n); err != nil {
+				sess.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// threadedHandleConn handles the requests of a stratum connection.
+func (s *stratumServer) threadedHandleConn(conn net.Conn) {
+	if err := s.m.tg.Add(); err != nil {
+		conn.Close()
+		return
+	}
+	defer s.m.tg.Done()
+
+	sess := &stratumSession{
+		conn:   conn,
+		notify: make(chan stratumNotification, stratumNotifyQueueSize),
+		closed: make(chan struct{}),
+	}
+	s.mu.Lock()
+	binary.BigEndian.PutUint32(sess.extranonce1[:], s.nextExtranonce)
+	s.nextExtranonce++
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, sess)
+		if sess.worker != nil {
+			sess.worker.connections--
+		}
+		s.mu.Unlock()
+		close(sess.closed)
+		conn.Close()
+	}()
+	go s.threadedSendJobs(sess)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), stratumMaxMessageSize)
+	for scanner.Scan() {
+		var req stratumRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+		result, serr := s.managedHandleRequest(sess, req)
+		if err := sess.write(stratumResponse{ID: req.ID, Result: result, Error: serr}); err != nil {
+			return
+		}
+
+		// Newly authorized workers receive the share difficulty and a job.
+		if req.Method == "mining.authorize" && serr == nil {
+			err := sess.write(stratumNotification{
+				Method: "mining.set_difficulty",
+				Params: []interface{}{s.config.Difficulty},
+			})
+			if err != nil {
+				return
+			}
+			s.managedPushJobs(sess)
+		}
+	}
+}
+
+// managedHandleRequest handles a request of a worker.
+func (s *stratumServer) managedHandleRequest(sess *stratumSession, req stratumRequest) (interface{}, *stratumError) {
+	var params []string
+	for _, p := range req.Params {
+		var param string
+		_ = json.Unmarshal(p, &param)
+		params = append(params, param)
+	}
+
+	switch req.Method {
+	case "mining.subscribe":
+		s.mu.Lock()
+		sess.subscribed = true
+		s.mu.Unlock()
+		id := hex.EncodeToString(sess.extranonce1[:])
+		subscriptions := [][]string{{"mining.set_difficulty", id}, {"mining.notify", id}}
+		return []interface{}{subscriptions, id, stratumExtranonce2Size}, nil
+	case "mining.authorize":
+		if len(params) < 1 {
+			return false, &stratumError{stratumErrOther, "missing username"}
+		}
+		return s.managedAuthorize(sess, params[0])
+	case "mining.submit":
+		if len(params) < 5 {
+			return false, &stratumError{stratumErrOther, "missing parameters"}
+		}
+		return s.managedSubmit(sess, params)
+	default:
+		return nil, &stratumError{stratumErrOther, "unknown method " + req.Method}
+	}
+}
+
+// managedAuthorize authorizes a session as a worker.
+func (s *stratumServer) managedAuthorize(sess *stratumSession, name string) (interface{}, *stratumError) {
+	var address types.UnlockHash
+	if s.config.Solo {
+		if err := address.LoadString(strings.SplitN(name, ".", 2)[0]); err != nil {
+			return false, &stratumError{stratumErrUnauthorized, "username has to start with a payout address in solo mode"}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !sess.subscribed {
+		return false, &stratumError{stratumErrNotSubscribed, "not subscribed"}
+	}
+	if sess.worker != nil {
+		sess.worker.connections--
+		sess.worker = nil
+	}
+	w, exists := s.workers[name]
+	if !exists {
+		if len(s.workers) >= stratumMaxWorkers && !s.evictIdleWorker() {
+			return false, &stratumError{stratumErrUnauthorized, "too many workers"}
+		}
+		w = &stratumWorker{
+			name:      name,
+			address:   address,
+			firstSeen: time.Now(),
+		}
+		s.workers[name] = w
+	}
+	w.connections++
+	sess.worker = w
+	return true, nil
+}
+
+// evictIdleWorker drops the least recently active worker which has no open
+// connections. It returns false if all workers are connected.
+func (s *stratumServer) evictIdleWorker() bool {
+	var oldest *stratumWorker
+	for _, w := range s.workers {
+		if w.connections == 0 && (oldest == nil || w.lastSeen().Before(oldest.lastSeen())) {
+			oldest = w
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(s.workers, oldest.name)
+	return true
+}
+
+// managedSubmit checks a share submitted by a worker and submits the block if
+// the share solves it.
+func (s *stratumServer) managedSubmit(sess *stratumSession, params []string) (interface{}, *stratumError) {
+	name, jobID := params[0], params[1]
+	extranonce2, err2 := hex.DecodeString(params[2])
+	ntime, err3 := hex.DecodeString(params[3])
+	nonceBytes, err4 := hex.DecodeString(params[4])
+	if errors.Compose(err2, err3, err4) != nil || len(extranonce2) != stratumExtranonce2Size || len(ntime) != 8 || len(nonceBytes) != len(types.BlockNonce{}) {
+		return false, &stratumError{stratumErrOther, "malformed share"}
+	}
+	timestamp := types.Timestamp(binary.LittleEndian.Uint64(ntime))
+	var nonce types.BlockNonce
+	copy(nonce[:], nonceBytes)
+
+	var block *types.Block
+	var w *stratumWorker
+	serr := func() *stratumError {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w = sess.worker
+		if w == nil || w.name != name {
+			return &stratumError{stratumErrUnauthorized, "unauthorized worker"}
+		}
+		var job *stratumJob
+		for _, j := range sess.jobs {
+			if j.id == jobID {
+				job = j
+			}
+		}
+		if job == nil {
+			w.stale++
+			return &stratumError{stratumErrJobNotFound, "job not found"}
+		}
+
+		extranonce := append(sess.extranonce1[:], extranonce2...)
+		key := string(extranonce) + string(ntime) + string(nonceBytes)
+		if _, exists := job.shares[key]; exists {
+			w.rejected++
+			return &stratumError{stratumErrDuplicateShare, "duplicate share"}
+		}
+		if job.height >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(nonce[:])%types.ASICHardforkFactor != 0 {
+			w.rejected++
+			return &stratumError{stratumErrOther, "nonce doesn't meet the nonce requirements"}
+		}
+		if timestamp < job.block.Timestamp || timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+			w.rejected++
+			return &stratumError{stratumErrOther, "ntime out of range"}
+		}
+		id := job.header(extranonce, timestamp, nonce).ID()
+		if bytes.Compare(id[:], s.shareTarget[:]) > 0 {
+			w.rejected++
+			return &stratumError{stratumErrLowDifficulty, "low difficulty share"}
+		}
+
+		job.shares[key] = struct{}{}
+		w.accepted++
+		w.lastShare = time.Now()
+		w.pruneShares()
+		w.shareTimes = append(w.shareTimes, w.lastShare)
+		if bytes.Compare(id[:], job.target[:]) <= 0 {
+			b := job.solvedBlock(extranonce, timestamp, nonce)
+			block = &b
+		}
+		return nil
+	}()
+	if serr != nil {
+		return false, serr
+	}
+	if block == nil {
+		return true, nil
+	}
+
+	// The share solves the block.
+	err := s.m.managedSubmitBlock(*block)
+	if err != nil && !errors.Contains(err, modules.ErrNonExtendingBlock) {
+		s.m.log.Println("ERROR: block submitted by stratum worker was rejected:", err)
+		return true, nil
+	}
+	s.m.log.Printf("Stratum worker %v found block %v", name, block.ID())
+	s.mu.Lock()
+	w.blocks++
+	s.mu.Unlock()
+	return true, nil
+}
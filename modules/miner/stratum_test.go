@@ -0,0 +1,403 @@
+package miner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// stratumTestClient is a minimal stratum client.
+type stratumTestClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	nextID  int
+	jobs    []stratumTestJob
+}
+
+// stratumTestJob is a job received by a stratumTestClient.
+type stratumTestJob struct {
+	id     string
+	parent []byte
+	coinb1 []byte
+	coinb2 []byte
+	branch [][]byte
+	ntime  []byte
+	clean  bool
+}
+
+// stratumTestMessage is a message received by a stratumTestClient.
+type stratumTestMessage struct {
+	ID     *int              `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  []interface{}     `json:"error"`
+}
+
+// call sends a request and returns the response, recording the jobs which are
+// received in the meantime.
+func (c *stratumTestClient) call(t *testing.T, method string, params ...interface{}) stratumTestMessage {
+	t.Helper()
+	c.nextID++
+	req, _ := json.Marshal(map[string]interface{}{"id": c.nextID, "method": method, "params": params})
+	if _, err := c.conn.Write(append(req, '\n')); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		msg := c.read(t)
+		if msg.ID != nil && *msg.ID == c.nextID {
+			return msg
+		}
+	}
+}
+
+// read reads the next message and records it if it is a job.
+func (c *stratumTestClient) read(t *testing.T) stratumTestMessage {
+	t.Helper()
+	if !c.scanner.Scan() {
+		t.Fatal("connection closed", c.scanner.Err())
+	}
+	var msg stratumTestMessage
+	if err := json.Unmarshal(c.scanner.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Method == "mining.notify" {
+		var job stratumTestJob
+		var parent, coinb1, coinb2, ntime string
+		var branch []string
+		for i, dst := range []interface{}{&job.id, &parent, &coinb1, &coinb2, &branch} {
+			if err := json.Unmarshal(msg.Params[i], dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := json.Unmarshal(msg.Params[7], &ntime); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(msg.Params[8], &job.clean); err != nil {
+			t.Fatal(err)
+		}
+		job.parent, _ = hex.DecodeString(parent)
+		job.coinb1, _ = hex.DecodeString(coinb1)
+		job.coinb2, _ = hex.DecodeString(coinb2)
+		job.ntime, _ = hex.DecodeString(ntime)
+		for _, h := range branch {
+			b, _ := hex.DecodeString(h)
+			job.branch = append(job.branch, b)
+		}
+		c.jobs = append(c.jobs, job)
+	}
+	return msg
+}
+
+// grind searches a nonce for the latest job whose header ID is above or below
+// the target, like an external miner would. Only nonces which meet the nonce
+// requirements of the ASIC hardfork are tried.
+func (c *stratumTestClient) grind(extranonce []byte, target types.Target, below bool) (nonce []byte) {
+	job := c.jobs[len(c.jobs)-1]
+	coinbase := append(append(append([]byte(nil), job.coinb1...), extranonce...), job.coinb2...)
+	root := crypto.HashBytes(append([]byte{0}, coinbase...))
+	for _, h := range job.branch {
+		root = crypto.HashBytes(append(append([]byte{1}, h...), root[:]...))
+	}
+	header := append(append(append(append([]byte(nil), job.parent...), make([]byte, 8)...), job.ntime...), root[:]...)
+	for i := uint64(0); ; i += types.ASICHardforkFactor {
+		binary.LittleEndian.PutUint64(header[32:40], i)
+		id := crypto.HashBytes(header)
+		if (bytes.Compare(id[:], target[:]) <= 0) == below {
+			return header[32:40]
+		}
+	}
+}
+
+// TestStratumJobMerkleRoot checks that the merkle root computed from the
+// merkle branch of a job matches the merkle root of the block.
+func TestStratumJobMerkleRoot(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		b := types.Block{
+			MinerPayouts: []types.SiacoinOutput{{Value: types.NewCurrency64(fastrand.Uint64n(1e6))}},
+		}
+		for i := 0; i < n; i++ {
+			b.Transactions = append(b.Transactions, types.Transaction{
+				ArbitraryData: [][]byte{fastrand.Bytes(fastrand.Intn(100))},
+			})
+		}
+		job := newStratumJob("0", b, 0, types.RootTarget)
+		extranonce := fastrand.Bytes(stratumExtranonce1Size + stratumExtranonce2Size)
+		var nonce types.BlockNonce
+		fastrand.Read(nonce[:])
+		header := job.header(extranonce, b.Timestamp, nonce)
+		solved := job.solvedBlock(extranonce, b.Timestamp, nonce)
+		if header.ID() != solved.ID() {
+			t.Fatalf("header doesn't match block with %v transactions", n)
+		}
+		if len(solved.Transactions) != n+1 {
+			t.Fatal("extranonce transaction missing")
+		}
+	}
+}
+
+// TestStratum mines blocks through the stratum server in solo mode.
+func TestStratum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mt.miner.StartStratum(StratumConfig{
+		Address:    "localhost:0",
+		Difficulty: 1,
+		Solo:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.miner.StartStratum(StratumConfig{Address: "localhost:0", Difficulty: 1}); err != errStratumRunning {
+		t.Fatal("expected errStratumRunning, got", err)
+	}
+	conn, err := net.Dial("tcp", mt.miner.stratum.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &stratumTestClient{conn: conn, scanner: bufio.NewScanner(conn)}
+
+	// Authorizing requires a subscription and, in solo mode, an address.
+	var uh types.UnlockHash
+	fastrand.Read(uh[:])
+	worker := uh.String() + ".rig1"
+	if msg := c.call(t, "mining.authorize", worker, ""); msg.Error == nil {
+		t.Fatal("authorized without subscription")
+	}
+	msg := c.call(t, "mining.subscribe")
+	var sub []json.RawMessage
+	var en1Hex string
+	if err := json.Unmarshal(msg.Result, &sub); err != nil || len(sub) != 3 {
+		t.Fatal("bad subscribe result", string(msg.Result), err)
+	}
+	if err := json.Unmarshal(sub[1], &en1Hex); err != nil {
+		t.Fatal(err)
+	}
+	en1, _ := hex.DecodeString(en1Hex)
+	if msg := c.call(t, "mining.authorize", "rig1", ""); msg.Error == nil {
+		t.Fatal("authorized without address in solo mode")
+	}
+	if msg := c.call(t, "mining.authorize", worker, ""); msg.Error != nil {
+		t.Fatal("authorize failed", msg.Error)
+	}
+	for len(c.jobs) == 0 {
+		c.read(t)
+	}
+	if !c.jobs[0].clean {
+		t.Fatal("first job should be clean")
+	}
+
+	// Submit a share which doesn't solve the block.
+	target := mt.miner.persist.Target
+	en2 := []byte{0, 0, 0, 1}
+	extranonce := append(append([]byte(nil), en1...), en2...)
+	submit := func(jobID string, nonce []byte) stratumTestMessage {
+		job := c.jobs[len(c.jobs)-1]
+		return c.call(t, "mining.submit", worker, jobID, hex.EncodeToString(en2), hex.EncodeToString(job.ntime), hex.EncodeToString(nonce))
+	}
+	jobID := c.jobs[len(c.jobs)-1].id
+	height := mt.cs.Height()
+	nonce := c.grind(extranonce, target, false)
+	if msg := submit(jobID, nonce); msg.Error != nil {
+		t.Fatal("share rejected", msg.Error)
+	}
+	if msg := submit(jobID, nonce); msg.Error == nil || msg.Error[0] != float64(stratumErrDuplicateShare) {
+		t.Fatal("expected duplicate share error", msg.Error)
+	}
+	if msg := submit("unknown", nonce); msg.Error == nil || msg.Error[0] != float64(stratumErrJobNotFound) {
+		t.Fatal("expected stale share error", msg.Error)
+	}
+	if mt.cs.Height() != height {
+		t.Fatal("share shouldn't have solved a block")
+	}
+
+	// Submit a share which solves the block. The block should pay the worker
+	// and a new job should be pushed.
+	jobs := len(c.jobs)
+	if msg := submit(jobID, c.grind(extranonce, target, true)); msg.Error != nil {
+		t.Fatal("block share rejected", msg.Error)
+	}
+	if mt.cs.Height() != height+1 {
+		t.Fatal("block wasn't accepted")
+	}
+	if mt.cs.CurrentBlock().MinerPayouts[0].UnlockHash != uh {
+		t.Fatal("block doesn't pay the worker")
+	}
+	for len(c.jobs) == jobs || !c.jobs[len(c.jobs)-1].clean {
+		c.read(t)
+	}
+	if id := mt.cs.CurrentBlock().ID(); !bytes.Equal(c.jobs[len(c.jobs)-1].parent, id[:]) {
+		t.Fatal("new job doesn't build on the current block")
+	}
+
+	// Check the statistics of the worker.
+	workers := mt.miner.StratumWorkers()
+	if len(workers) != 1 {
+		t.Fatal("expected one worker, got", len(workers))
+	}
+	w := workers[0]
+	if w.Name != worker || w.Address != uh || w.Connections != 1 {
+		t.Fatal("wrong worker", w)
+	}
+	if w.AcceptedShares != 2 || w.RejectedShares != 1 || w.StaleShares != 1 || w.BlocksFound != 1 || w.Hashrate <= 0 {
+		t.Fatal("wrong worker statistics", w)
+	}
+}
+
+// TestStratumMaxWorkers checks that the stratum server drops idle workers to
+// stay below stratumMaxWorkers and refuses new workers if all of them are
+// connected.
+func TestStratumMaxWorkers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mt.miner.StartStratum(StratumConfig{Address: "localhost:0", Difficulty: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func() *stratumTestClient {
+		conn, err := net.Dial("tcp", mt.miner.stratum.listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &stratumTestClient{conn: conn, scanner: bufio.NewScanner(conn)}
+		c.call(t, "mining.subscribe")
+		return c
+	}
+	names := func() (names []string) {
+		for _, w := range mt.miner.StratumWorkers() {
+			names = append(names, w.Name)
+		}
+		return
+	}
+
+	// Fill up the workers. Re-authorizing under a new name leaves the old
+	// worker idle, so it is dropped.
+	c1, c2, c3 := dial(), dial(), dial()
+	defer c1.conn.Close()
+	defer c3.conn.Close()
+	for i, name := range []string{"rig0", "rig1", "rig2"} {
+		c := c1
+		if i > 0 {
+			c = c2
+		}
+		if msg := c.call(t, "mining.authorize", name, ""); msg.Error != nil {
+			t.Fatal("authorize failed", msg.Error)
+		}
+	}
+	if n := names(); len(n) != stratumMaxWorkers || n[0] != "rig0" || n[1] != "rig2" {
+		t.Fatal("idle worker wasn't dropped", n)
+	}
+
+	// All workers are connected, so new workers are refused until a
+	// connection is closed.
+	if msg := c3.call(t, "mining.authorize", "rig3", ""); msg.Error == nil {
+		t.Fatal("authorized more than stratumMaxWorkers workers")
+	}
+	c2.conn.Close()
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if msg := c3.call(t, "mining.authorize", "rig3", ""); msg.Error != nil {
+			return fmt.Errorf("authorize failed: %v", msg.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := names(); len(n) != stratumMaxWorkers || n[0] != "rig0" || n[1] != "rig3" {
+		t.Fatal("closed worker wasn't dropped", n)
+	}
+}
+
+// TestStratumSlowWorker checks that a worker which doesn't read its jobs
+// doesn't delay the jobs of the other workers and is disconnected once it
+// falls too far behind.
+func TestStratumSlowWorker(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mt.miner.StartStratum(StratumConfig{Address: "localhost:0", Difficulty: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := mt.miner.stratum
+	authorize := func(conn net.Conn, name string) *stratumTestClient {
+		c := &stratumTestClient{conn: conn, scanner: bufio.NewScanner(conn)}
+		c.call(t, "mining.subscribe")
+		if msg := c.call(t, "mining.authorize", name, ""); msg.Error != nil {
+			t.Fatal("authorize failed", msg.Error)
+		}
+		for len(c.jobs) == 0 {
+			c.read(t)
+		}
+		return c
+	}
+
+	// The slow worker uses an unbuffered pipe, so writing to it blocks as soon
+	// as it stops reading.
+	slowConn, serverConn := net.Pipe()
+	defer slowConn.Close()
+	go s.threadedHandleConn(serverConn)
+	authorize(slowConn, "slow")
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := authorize(conn, "fast")
+
+	// The fast worker receives its jobs without waiting for the slow worker.
+	start := time.Now()
+	for i := 0; i < stratumNotifyQueueSize+2; i++ {
+		jobs := len(c.jobs)
+		s.managedPushJobs(nil)
+		for len(c.jobs) == jobs {
+			c.read(t)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > stratumWriteTimeout/2 {
+		t.Fatal("jobs were delayed by the slow worker", elapsed)
+	}
+
+	// The slow worker fell behind and is disconnected.
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.sessions) != 1 {
+			return fmt.Errorf("expected 1 session, got %v", len(s.sessions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,192 @@
+package modules
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// PartiallySignedTransactionVersion is the current version of the partially
+// signed transaction format.
+const PartiallySignedTransactionVersion = 1
+
+var (
+	// ErrInsufficientSignatures is returned when a partially signed
+	// transaction doesn't contain enough signatures to be finalized.
+	ErrInsufficientSignatures = errors.New("partially signed transaction doesn't have enough signatures")
+
+	// ErrTransactionMismatch is returned when partially signed transactions
+	// that don't belong to the same transaction are combined.
+	ErrTransactionMismatch = errors.New("partially signed transactions don't belong to the same transaction")
+
+	// ErrUnknownPartiallySignedTransactionVersion is returned when a
+	// partially signed transaction was created with an unsupported version of
+	// the format.
+	ErrUnknownPartiallySignedTransactionVersion = errors.New("unknown partially signed transaction version")
+)
+
+type (
+	// MultisigAccount is an M-of-N multisig address which is tracked by the
+	// wallet.
+	MultisigAccount struct {
+		Name             string                 `json:"name"`
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+
+		// ConfirmedSiacoinBalance is the sum of the confirmed siacoin outputs
+		// of the address.
+		ConfirmedSiacoinBalance types.Currency `json:"confirmedsiacoinbalance"`
+	}
+
+	// PartiallySignedTransaction is a transaction that spends outputs of a
+	// multisig address and is passed between the signers until enough
+	// signatures were collected. It contains the values of the spent outputs
+	// so that signers can verify the fee without access to the blockchain.
+	PartiallySignedTransaction struct {
+		Version     uint64            `json:"version"`
+		Transaction types.Transaction `json:"transaction"`
+		InputValues []types.Currency  `json:"inputvalues"`
+	}
+
+	// SignatureProgress reports how many signatures an input of a partially
+	// signed transaction has and how many it requires.
+	SignatureProgress struct {
+		ParentID types.SiacoinOutputID `json:"parentid"`
+		Signed   uint64                `json:"signed"`
+		Required uint64                `json:"required"`
+	}
+)
+
+// ValidateMultisigUnlockConditions checks that the unlock conditions describe
+// a valid multisig address.
+func ValidateMultisigUnlockConditions(uc types.UnlockConditions) error {
+	if len(uc.PublicKeys) == 0 {
+		return errors.New("multisig address requires at least one public key")
+	}
+	if uc.SignaturesRequired == 0 || uc.SignaturesRequired > uint64(len(uc.PublicKeys)) {
+		return errors.New("number of required signatures must be between 1 and the number of public keys")
+	}
+	seen := make(map[string]struct{})
+	for _, pk := range uc.PublicKeys {
+		if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+			return errors.New("multisig addresses only support ed25519 public keys")
+		}
+		if _, exists := seen[pk.String()]; exists {
+			return errors.New("multisig address contains duplicate public key " + pk.String())
+		}
+		seen[pk.String()] = struct{}{}
+	}
+	return nil
+}
+
+// Fee returns the sum of the miner fees of the transaction.
+func (pst PartiallySignedTransaction) Fee() types.Currency {
+	var fee types.Currency
+	for _, mf := range pst.Transaction.MinerFees {
+		fee = fee.Add(mf)
+	}
+	return fee
+}
+
+// Complete returns whether every input has enough signatures for the
+// transaction to be finalized.
+func (pst PartiallySignedTransaction) Complete() bool {
+	for _, sp := range pst.Progress() {
+		if sp.Signed < sp.Required {
+			return false
+		}
+	}
+	return true
+}
+
+// Progress returns the number of valid signature slots that were filled in
+// for every input of the transaction.
+func (pst PartiallySignedTransaction) Progress() []SignatureProgress {
+	progress := make([]SignatureProgress, 0, len(pst.Transaction.SiacoinInputs))
+	for _, sci := range pst.Transaction.SiacoinInputs {
+		sp := SignatureProgress{
+			ParentID: sci.ParentID,
+			Required: sci.UnlockConditions.SignaturesRequired,
+		}
+		for _, sig := range pst.Transaction.TransactionSignatures {
+			if sig.ParentID == crypto.Hash(sci.ParentID) && len(sig.Signature) > 0 {
+				sp.Signed++
+			}
+		}
+		progress = append(progress, sp)
+	}
+	return progress
+}
+
+// CombinePartiallySignedTransactions merges the signatures of multiple
+// partially signed versions of the same transaction.
+func CombinePartiallySignedTransactions(psts ...PartiallySignedTransaction) (PartiallySignedTransaction, error) {
+	if len(psts) == 0 {
+		return PartiallySignedTransaction{}, errors.New("no partially signed transactions to combine")
+	}
+	// The signatures are collected in a new slice, so the inputs aren't
+	// modified.
+	for _, pst := range psts {
+		if pst.Version != PartiallySignedTransactionVersion {
+			return PartiallySignedTransaction{}, ErrUnknownPartiallySignedTransactionVersion
+		}
+	}
+	combined := psts[0]
+	combined.Transaction.TransactionSignatures = nil
+	txnID := combined.Transaction.ID()
+
+	type sigKey struct {
+		parentID crypto.Hash
+		index    uint64
+	}
+	sigs := make(map[sigKey]int)
+	for _, pst := range psts {
+		if pst.Transaction.ID() != txnID {
+			return PartiallySignedTransaction{}, ErrTransactionMismatch
+		}
+		for _, sig := range pst.Transaction.TransactionSignatures {
+			key := sigKey{sig.ParentID, sig.PublicKeyIndex}
+			i, exists := sigs[key]
+			if !exists {
+				sigs[key] = len(combined.Transaction.TransactionSignatures)
+				combined.Transaction.TransactionSignatures = append(combined.Transaction.TransactionSignatures, sig)
+				continue
+			}
+			// Prefer filled in signatures over empty slots.
+			if len(combined.Transaction.TransactionSignatures[i].Signature) == 0 {
+				combined.Transaction.TransactionSignatures[i] = sig
+			}
+		}
+	}
+	return combined, nil
+}
+
+// FinalizePartiallySignedTransaction returns the transaction with exactly the
+// number of required signatures for every input. Unsigned slots and surplus
+// signatures are dropped.
+func FinalizePartiallySignedTransaction(pst PartiallySignedTransaction) (types.Transaction, error) {
+	if pst.Version != PartiallySignedTransactionVersion {
+		return types.Transaction{}, ErrUnknownPartiallySignedTransactionVersion
+	}
+	txn := pst.Transaction
+	required := make(map[crypto.Hash]uint64)
+	for _, sci := range txn.SiacoinInputs {
+		required[crypto.Hash(sci.ParentID)] = sci.UnlockConditions.SignaturesRequired
+	}
+	var sigs []types.TransactionSignature
+	for _, sig := range txn.TransactionSignatures {
+		if len(sig.Signature) == 0 || required[sig.ParentID] == 0 {
+			continue
+		}
+		required[sig.ParentID]--
+		sigs = append(sigs, sig)
+	}
+	for _, remaining := range required {
+		if remaining > 0 {
+			return types.Transaction{}, ErrInsufficientSignatures
+		}
+	}
+	txn.TransactionSignatures = sigs
+	return txn, nil
+}
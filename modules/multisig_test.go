@@ -0,0 +1,143 @@
+package modules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestValidateMultisigUnlockConditions probes
+// ValidateMultisigUnlockConditions.
+func TestValidateMultisigUnlockConditions(t *testing.T) {
+	_, pk1 := crypto.GenerateKeyPair()
+	_, pk2 := crypto.GenerateKeyPair()
+	spk1, spk2 := types.Ed25519PublicKey(pk1), types.Ed25519PublicKey(pk2)
+
+	tests := []struct {
+		pks      []types.SiaPublicKey
+		required uint64
+		valid    bool
+	}{
+		{[]types.SiaPublicKey{spk1, spk2}, 1, true},
+		{[]types.SiaPublicKey{spk1, spk2}, 2, true},
+		{[]types.SiaPublicKey{spk1, spk2}, 3, false},
+		{[]types.SiaPublicKey{spk1, spk2}, 0, false},
+		{[]types.SiaPublicKey{spk1, spk1}, 2, false},
+		{[]types.SiaPublicKey{spk1, {Algorithm: types.SignatureEntropy}}, 1, false},
+		{nil, 0, false},
+	}
+	for i, test := range tests {
+		uc := types.UnlockConditions{
+			PublicKeys:         test.pks,
+			SignaturesRequired: test.required,
+		}
+		if err := ValidateMultisigUnlockConditions(uc); (err == nil) != test.valid {
+			t.Errorf("test %v: expected valid %v, got %v", i, test.valid, err)
+		}
+	}
+}
+
+// TestCombinePartiallySignedTransactions tests combining and finalizing
+// partially signed transactions.
+func TestCombinePartiallySignedTransactions(t *testing.T) {
+	var pks []types.SiaPublicKey
+	var sks []crypto.SecretKey
+	for i := 0; i < 3; i++ {
+		sk, pk := crypto.GenerateKeyPair()
+		pks = append(pks, types.Ed25519PublicKey(pk))
+		sks = append(sks, sk)
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         pks,
+		SignaturesRequired: 2,
+	}
+	parentID := types.SiacoinOutputID{1}
+	pst := PartiallySignedTransaction{
+		Version: PartiallySignedTransactionVersion,
+		Transaction: types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{
+				ParentID:         parentID,
+				UnlockConditions: uc,
+			}},
+			SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(90)}},
+			MinerFees:      []types.Currency{types.NewCurrency64(10)},
+		},
+		InputValues: []types.Currency{types.NewCurrency64(100)},
+	}
+	if !pst.Fee().Equals64(10) {
+		t.Fatal("unexpected fee", pst.Fee())
+	}
+
+	// sign returns a copy of pst signed with the key at index i.
+	sign := func(i int) PartiallySignedTransaction {
+		signed := pst
+		signed.Transaction.TransactionSignatures = []types.TransactionSignature{{
+			ParentID:       crypto.Hash(parentID),
+			PublicKeyIndex: uint64(i),
+			CoveredFields:  types.FullCoveredFields,
+		}}
+		sig := crypto.SignHash(signed.Transaction.SigHash(0, 0), sks[i])
+		signed.Transaction.TransactionSignatures[0].Signature = sig[:]
+		return signed
+	}
+	signed0, signed1, signed2 := sign(0), sign(1), sign(2)
+	if signed0.Complete() {
+		t.Fatal("transaction with one signature shouldn't be complete")
+	}
+	if _, err := FinalizePartiallySignedTransaction(signed0); !errors.Contains(err, ErrInsufficientSignatures) {
+		t.Fatal("expected ErrInsufficientSignatures, got", err)
+	}
+
+	// Combining the same signature twice doesn't add a signature.
+	combined, err := CombinePartiallySignedTransactions(signed0, signed0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if combined.Complete() || len(combined.Transaction.TransactionSignatures) != 1 {
+		t.Fatal("duplicate signature was added")
+	}
+
+	// Combine all three signatures. Finalizing drops the surplus signature.
+	combined, err = CombinePartiallySignedTransactions(signed0, signed1, signed2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !combined.Complete() || combined.Progress()[0].Signed != 3 {
+		t.Fatal("unexpected progress", combined.Progress())
+	}
+	txn, err := FinalizePartiallySignedTransaction(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures) != 2 {
+		t.Fatal("expected 2 signatures, got", len(txn.TransactionSignatures))
+	}
+	if txn.ID() != pst.Transaction.ID() {
+		t.Fatal("finalized transaction has a different id")
+	}
+	for i, sig := range txn.TransactionSignatures {
+		var pk crypto.PublicKey
+		copy(pk[:], pks[sig.PublicKeyIndex].Key)
+		var s crypto.Signature
+		copy(s[:], sig.Signature)
+		if err := crypto.VerifyHash(txn.SigHash(i, 0), pk, s); err != nil {
+			t.Fatal("invalid signature", i, err)
+		}
+	}
+
+	// Different transactions can't be combined.
+	other := sign(1)
+	other.Transaction.MinerFees = []types.Currency{types.NewCurrency64(20)}
+	if _, err := CombinePartiallySignedTransactions(signed0, other); !errors.Contains(err, ErrTransactionMismatch) {
+		t.Fatal("expected ErrTransactionMismatch, got", err)
+	}
+
+	// Unknown versions are rejected.
+	signed0.Version++
+	if _, err := CombinePartiallySignedTransactions(signed0, signed1); !errors.Contains(err, ErrUnknownPartiallySignedTransactionVersion) {
+		t.Fatal("expected ErrUnknownPartiallySignedTransactionVersion, got", err)
+	}
+}
@@ -23,7 +23,13 @@ import (
 const (
 	// RHPVersion is the version of the Sia renter-host protocol currently
 	// implemented by the host module.
-	RHPVersion = "1.5.6"
+	RHPVersion = "1.5.7"
+
+	// RHPIdentifyVersion is the minimum version of the renter-host protocol
+	// that supports RPCLoopIdentify. Hosts of older versions close the
+	// connection on unknown RPCs, so renters must not call it on them. See
+	// doc/Renter Identity.md.
+	RHPIdentifyVersion = "1.5.7"
 
 	// MinimumSupportedRenterHostProtocolVersion is the minimum version of Sia
 	// that supports the currently used version of the renter-host protocol.
@@ -409,6 +415,7 @@ var (
 	RPCLoopEnter              = types.NewSpecifier("LoopEnter")
 	RPCLoopExit               = types.NewSpecifier("LoopExit")
 	RPCLoopFormContract       = types.NewSpecifier("LoopFormContract")
+	RPCLoopIdentify           = types.NewSpecifier("LoopIdentify")
 	RPCLoopLock               = types.NewSpecifier("LoopLock")
 	RPCLoopRead               = types.NewSpecifier("LoopRead")
 	RPCLoopRenewClearContract = types.NewSpecifier("LoopRenewClear")
@@ -441,6 +448,10 @@ var (
 	// RPCChallengePrefix is the prefix prepended to the challenge data
 	// supplied by the host when proving ownership of a contract's secret key.
 	RPCChallengePrefix = types.NewSpecifier("challenge")
+
+	// RPCIdentityPrefix is the prefix prepended to the challenge data
+	// supplied by the host when proving ownership of a renter's identity key.
+	RPCIdentityPrefix = types.NewSpecifier("identity")
 )
 
 // New RPC request and response types
@@ -508,6 +519,21 @@ type (
 		Signatures   []types.TransactionSignature
 	}
 
+	// LoopIdentifyRequest contains the request parameters for
+	// RPCLoopIdentify. The identity key is the stable key of the renter, which
+	// the host uses to attribute the contracts formed within the session.
+	LoopIdentifyRequest struct {
+		PublicKey types.SiaPublicKey
+
+		// The host's challenge, signed by the renter's identity key.
+		Signature []byte
+	}
+
+	// LoopIdentifyResponse contains the response data for RPCLoopIdentify.
+	LoopIdentifyResponse struct {
+		NewChallenge [16]byte
+	}
+
 	// LoopReadRequestSection is a section requested in LoopReadRequest.
 	LoopReadRequestSection struct {
 		MerkleRoot [32]byte
@@ -31,10 +31,10 @@ var (
 type (
 	// FilePlacement contains the sector of a file and its offset in the sector.
 	FilePlacement struct {
-		FileID       string
-		Size         uint64
-		SectorIndex  uint64
-		SectorOffset uint64
+		FileID       string `json:"fileid"`
+		Size         uint64 `json:"size"`
+		SectorIndex  uint64 `json:"sectorindex"`
+		SectorOffset uint64 `json:"sectoroffset"`
 	}
 
 	// bucket defines a temporary bucket used when packing files.
@@ -26,6 +26,10 @@ type (
 		RefundAddress types.UnlockHash
 		RenterSeed    EphemeralRenterSeed
 
+		// IdentityKey is the renter's identity key, which is used to prove the
+		// renter's identity to the host when forming a contract.
+		IdentityKey crypto.SecretKey
+
 		// TODO: add optional keypair
 	}
 )
@@ -371,6 +375,7 @@ type DirectoryInfo struct {
 	NumFiles            uint64      `json:"numfiles"`
 	NumStuckChunks      uint64      `json:"numstuckchunks"`
 	NumSubDirs          uint64      `json:"numsubdirs"`
+	Policy              DirPolicy   `json:"policy"`
 	RepairSize          uint64      `json:"repairsize"`
 	SiaPath             SiaPath     `json:"siapath"`
 	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
@@ -397,6 +402,82 @@ func (d DirectoryInfo) IsDir() bool { return true }
 // Sys implements os.FileInfo.
 func (d DirectoryInfo) Sys() interface{} { return nil }
 
+// DirPolicy is the upload policy of a siadir. Files which are uploaded to the
+// siadir or any of its subdirs use the policy for the upload params which are
+// not set explicitly. Fields which are not set are inherited from the parent
+// siadir.
+type DirPolicy struct {
+	// DataPieces and ParityPieces are the erasure coding settings of new
+	// files. They are either both set or both 0.
+	DataPieces   int `json:"datapieces"`
+	ParityPieces int `json:"paritypieces"`
+
+	// CipherType is the string representation of the cipher type used to
+	// encrypt new files.
+	CipherType string `json:"ciphertype"`
+
+	// Hosts pins the files to a set of hosts. Pieces of the files are only
+	// uploaded to hosts within the set.
+	Hosts []types.SiaPublicKey `json:"hosts"`
+}
+
+// ErasureCode returns the erasure coder of the policy or nil if the policy
+// doesn't specify the erasure coding settings.
+func (dp DirPolicy) ErasureCode() (ErasureCoder, error) {
+	if dp.DataPieces == 0 && dp.ParityPieces == 0 {
+		return nil, nil
+	}
+	return NewRSSubCode(dp.DataPieces, dp.ParityPieces, crypto.SegmentSize)
+}
+
+// Cipher returns the cipher type of the policy or crypto.TypeInvalid if the
+// policy doesn't specify a cipher type.
+func (dp DirPolicy) Cipher() (crypto.CipherType, error) {
+	if dp.CipherType == "" {
+		return crypto.TypeInvalid, nil
+	}
+	var ct crypto.CipherType
+	err := ct.FromString(dp.CipherType)
+	return ct, err
+}
+
+// Inherit returns a copy of the policy where the fields which are not set are
+// replaced by the fields of the parent's policy.
+func (dp DirPolicy) Inherit(parent DirPolicy) DirPolicy {
+	if dp.DataPieces == 0 && dp.ParityPieces == 0 {
+		dp.DataPieces = parent.DataPieces
+		dp.ParityPieces = parent.ParityPieces
+	}
+	if dp.CipherType == "" {
+		dp.CipherType = parent.CipherType
+	}
+	if len(dp.Hosts) == 0 {
+		dp.Hosts = append([]types.SiaPublicKey(nil), parent.Hosts...)
+	}
+	return dp
+}
+
+// Validate checks that the policy's fields are valid.
+func (dp DirPolicy) Validate() error {
+	if (dp.DataPieces == 0) != (dp.ParityPieces == 0) {
+		return errors.New("data pieces and parity pieces need to be set together")
+	}
+	if _, err := dp.ErasureCode(); err != nil {
+		return errors.AddContext(err, "invalid erasure code settings")
+	}
+	if _, err := dp.Cipher(); err != nil {
+		return errors.AddContext(err, "invalid cipher type")
+	}
+	hosts := make(map[string]struct{}, len(dp.Hosts))
+	for _, host := range dp.Hosts {
+		if _, exists := hosts[host.String()]; exists {
+			return fmt.Errorf("host %v is pinned more than once", host)
+		}
+		hosts[host.String()] = struct{}{}
+	}
+	return nil
+}
+
 // DownloadInfo provides information about a file that has been requested for
 // download.
 type DownloadInfo struct {
@@ -435,6 +516,21 @@ type FileUploadParams struct {
 	CipherKey crypto.CipherKey
 }
 
+// BatchUploadFile is a local file which is uploaded as part of a batch.
+type BatchUploadFile struct {
+	Source  string  `json:"source"`
+	SiaPath SiaPath `json:"siapath"`
+}
+
+// BatchUploadParams contains the information used by the Renter to upload
+// many small files packed into shared sectors.
+type BatchUploadParams struct {
+	Files       []BatchUploadFile
+	ErasureCode ErasureCoder
+	CipherType  crypto.CipherType
+	Force       bool
+}
+
 // FileInfo provides information about a file.
 type FileInfo struct {
 	AccessTime       time.Time         `json:"accesstime"`
@@ -484,6 +580,16 @@ func (f FileInfo) IsDir() bool { return false }
 // Sys implements os.FileInfo.
 func (f FileInfo) Sys() interface{} { return nil }
 
+// RedundancyChange provides information about an ongoing redundancy change of
+// a file.
+type RedundancyChange struct {
+	DataPieces     int     `json:"datapieces"`
+	ParityPieces   int     `json:"paritypieces"`
+	Health         float64 `json:"health"`
+	Redundancy     float64 `json:"redundancy"`
+	UploadProgress float64 `json:"uploadprogress"`
+}
+
 // A HostDBEntry represents one host entry in the Renter's host DB. It
 // aggregates the host's external settings and metrics with its public key.
 type HostDBEntry struct {
@@ -614,6 +720,7 @@ type RenterPriceEstimation struct {
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
 	Allowance        Allowance     `json:"allowance"`
+	Dedup            bool          `json:"dedup"`
 	IPViolationCheck bool          `json:"ipviolationcheck"`
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
@@ -997,6 +1104,28 @@ type (
 	}
 )
 
+type (
+	// RegistryNotifyFunc is the function a RegistrySubscriber calls whenever
+	// an update for one of its subscribed entries is received. If it returns
+	// an error, the subscriber is closed.
+	RegistryNotifyFunc func(spk types.SiaPublicKey, srv SignedRegistryValue) error
+
+	// RegistrySubscriber is an object which can subscribe to registry entries
+	// and is notified about updates to the subscribed entries.
+	RegistrySubscriber interface {
+		// Close closes the subscriber and unsubscribes it from all entries.
+		Close() error
+
+		// Subscribe subscribes to the entry with the given public key and
+		// tweak. It returns the latest known value of the entry or 'nil' if
+		// the entry doesn't exist yet.
+		Subscribe(spk types.SiaPublicKey, tweak crypto.Hash) (*SignedRegistryValue, error)
+
+		// Unsubscribe unsubscribes from the entry with the given id.
+		Unsubscribe(eid RegistryEntryID)
+	}
+)
+
 // A Renter uploads, tracks, repairs, and downloads a set of files for the
 // user.
 type Renter interface {
@@ -1065,6 +1194,10 @@ type Renter interface {
 	// Unmount unmounts the FUSE filesystem currently mounted at mountPoint.
 	Unmount(mountPoint string) error
 
+	// Identity returns the public key which identifies the renter to hosts.
+	// Hosts apply their renter policy to the contracts of this identity.
+	Identity() (types.SiaPublicKey, error)
+
 	// PeriodSpending returns the amount spent on contracts in the current
 	// billing period.
 	PeriodSpending() (ContractorSpending, error)
@@ -1155,10 +1288,23 @@ type Renter interface {
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath SiaPath) error
 
+	// ChangeRedundancy starts re-encoding a file with a new erasure code. The
+	// file is replaced once the re-encoded file was uploaded.
+	ChangeRedundancy(siaPath SiaPath, ec ErasureCoder) error
+
+	// RedundancyChange returns the progress of an ongoing redundancy change
+	// of a file or nil if the file's redundancy isn't being changed.
+	RedundancyChange(siaPath SiaPath) (*RedundancyChange, error)
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry, allowance Allowance) (HostScoreBreakdown, error)
 
+	// NewRegistrySubscriber creates a new registry subscriber which calls
+	// notifyFunc whenever an update for one of its subscribed entries is
+	// received.
+	NewRegistrySubscriber(notifyFunc RegistryNotifyFunc) (RegistrySubscriber, error)
+
 	// ReadRegistry starts a registry lookup on all available workers. The
 	// jobs have 'timeout' amount of time to finish their jobs and return a
 	// response. Otherwise the response with the highest revision number will be
@@ -1202,6 +1348,11 @@ type Renter interface {
 	// reached and upload the data to the Sia network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
 
+	// UploadBatch packs many small files into shared sectors and uploads
+	// them. It returns the siapath of the pack which holds the data and the
+	// placement of the files within the pack.
+	UploadBatch(bup BatchUploadParams) (SiaPath, []FilePlacement, error)
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath SiaPath, mode os.FileMode) error
 
@@ -1211,6 +1362,13 @@ type Renter interface {
 	// DirList lists the directories in a siadir
 	DirList(siaPath SiaPath) ([]DirectoryInfo, error)
 
+	// DirPolicy returns the effective policy of a siadir, which includes the
+	// fields inherited from its parents.
+	DirPolicy(siaPath SiaPath) (DirPolicy, error)
+
+	// SetDirPolicy sets the policy of a siadir.
+	SetDirPolicy(siaPath SiaPath, policy DirPolicy) error
+
 	// WorkerPoolStatus returns the current status of the Renter's worker pool
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 
@@ -419,21 +419,14 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 				return errors.AddContext(err, fmt.Sprintf("could not create dir at  %v", siaPath))
 			}
 			// Update the metadata.
-			dirEntry, err := r.staticFileSystem.OpenSiaDir(siaPath)
-			if err != nil {
-				return errors.AddContext(err, fmt.Sprintf("could not open dir at %v", siaPath))
-			}
-			if err := dirEntry.UpdateMetadata(md); err != nil {
-				dirEntry.Close()
-				return errors.AddContext(err, "could not update metadata")
+			if err := r.staticFileSystem.UpdateDirMetadata(siaPath, md); err != nil {
+				return errors.AddContext(err, fmt.Sprintf("could not update metadata of dir at %v", siaPath))
 			}
 			// Metadata was updated so add to list of directories to be updated
 			err = dirsToUpdate.callAdd(siaPath)
 			if err != nil {
 				return errors.AddContext(err, fmt.Sprintf("could not add directory %v to the list of directories to be updated", siaPath))
 			}
-			// Close Directory
-			dirEntry.Close()
 		} else if filepath.Ext(info.Name()) == modules.SiaFileExtension {
 			// Add the file to the SiaFileSet.
 			reader := bytes.NewReader(b)
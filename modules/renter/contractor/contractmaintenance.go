@@ -410,9 +410,13 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		RenterSeed:    renterSeed.EphemeralRenterSeed(endHeight),
 	}
 	c.mu.RUnlock()
+	if !c.staticDeps.Disrupt("DisableRenterIdentity") {
+		params.IdentityKey, _ = renterSeed.IdentityKeyPair()
+	}
 
-	// wipe the renter seed once we are done using it.
+	// wipe the renter seed and identity key once we are done using them.
 	defer fastrand.Read(params.RenterSeed[:])
+	defer fastrand.Read(params.IdentityKey[:])
 
 	// create transaction builder and trigger contract formation.
 	txnBuilder, err := c.wallet.StartTransaction()
@@ -11,6 +11,7 @@ import (
 	"sync/atomic"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/threadgroup"
 
@@ -154,6 +155,20 @@ func (c *Contractor) InitRecoveryScan() (err error) {
 	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning)
 }
 
+// Identity returns the public key which identifies the renter to hosts. It is
+// derived from the wallet seed, so it requires an unlocked wallet.
+func (c *Contractor) Identity() (types.SiaPublicKey, error) {
+	seed, _, err := c.wallet.PrimarySeed()
+	if err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	renterSeed := modules.DeriveRenterSeed(seed)
+	defer fastrand.Read(renterSeed[:])
+	sk, pk := renterSeed.IdentityKeyPair()
+	defer fastrand.Read(sk[:])
+	return types.Ed25519PublicKey(pk), nil
+}
+
 // PeriodSpending returns the amount spent on contracts during the current
 // billing period.
 func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
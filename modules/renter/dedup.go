@@ -0,0 +1,463 @@
+package renter
+
+// dedup.go contains the content-addressed deduplication of chunks. If it is
+// enabled, every chunk that is uploaded for the first time is identified by a
+// hash of its plaintext which is keyed with a secret of the renter. The pieces
+// of the chunk are then encrypted with a key derived from that id instead of
+// the master key of the file. That way the pieces of a chunk can be referenced
+// by any other siafile of the renter containing the same data, and the chunk
+// doesn't need to be uploaded again.
+//
+// The secret and the keys are derived from the renter seed, which means that
+// deduplicated chunks can be recovered from the wallet seed just like regular
+// siafiles. The wallet needs to be unlocked once after startup before chunks
+// can be deduplicated or deduplicated chunks can be downloaded.
+//
+// The dedupIndex tracks the pieces of every deduplicated chunk as well as the
+// number of siafile chunks referencing it. Deleting a siafile releases its
+// references and once a chunk is no longer referenced it is dropped from the
+// index. Sectors are never deleted from hosts by the renter, so a dropped
+// chunk's sectors are freed once the contracts storing them expire.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// dedupFilename is the filename of the dedup index within the renter's
+	// persist dir.
+	dedupFilename = "dedup.db"
+)
+
+var (
+	// dedupMetadata is the metadata of the persisted dedup index.
+	dedupMetadata = persist.Metadata{
+		Header:  "Renter Dedup Index",
+		Version: "1.0",
+	}
+
+	// bucketDedupEntries maps the DedupIDs of the deduplicated chunks to
+	// their JSON encoded dedupEntry.
+	bucketDedupEntries = []byte("DedupEntries")
+
+	// dedupCipherTypes are the cipher types for which the dedup index holds a
+	// key.
+	dedupCipherTypes = []crypto.CipherType{crypto.TypePlain, crypto.TypeTwofish, crypto.TypeThreefish, crypto.TypeXChaCha20}
+
+	// dedupKeySpecifier is the specifier used for deriving the dedup secret
+	// from the RenterSeed.
+	dedupKeySpecifier = types.NewSpecifier("dedup")
+	// dedupSeedSpecifier is the specifier used for deriving the seed of the
+	// DedupIDs from the dedup secret.
+	dedupSeedSpecifier = types.NewSpecifier("dedupseed")
+)
+
+type (
+	// dedupIndex tracks the chunks which were uploaded with dedup enabled.
+	dedupIndex struct {
+		// keys are the per-renter keys used to derive the keys of the
+		// deduplicated chunks of each cipher type. seed is the secret which
+		// is used to compute the DedupIDs of chunks. Both are derived from
+		// the secret returned by staticSecret the first time they are needed.
+		keys map[crypto.CipherType]crypto.CipherKey
+		seed crypto.Hash
+
+		entries      map[siafile.DedupID]*dedupEntry
+		staticDB     *persist.BoltDatabase
+		staticSecret func() (crypto.Hash, error)
+		mu           sync.Mutex
+	}
+
+	// dedupEntry contains the pieces of a deduplicated chunk and the number of
+	// siafile chunks referencing it.
+	dedupEntry struct {
+		Pieces     [][]siafile.Piece `json:"pieces"`
+		References uint64            `json:"references"`
+	}
+)
+
+// newDedupIndex loads the dedup index from the database at the provided path
+// or creates a new one if it doesn't exist yet. secret is used to derive the
+// keys and the seed of the index.
+func newDedupIndex(path string, secret func() (crypto.Hash, error)) (*dedupIndex, error) {
+	db, err := persist.OpenDatabase(dedupMetadata, path)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open dedup index")
+	}
+	di := &dedupIndex{
+		entries:      make(map[siafile.DedupID]*dedupEntry),
+		staticDB:     db,
+		staticSecret: secret,
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketDedupEntries)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var id siafile.DedupID
+			if len(k) != len(id) {
+				return errors.New("invalid DedupID in dedup index")
+			}
+			copy(id[:], k)
+			entry := new(dedupEntry)
+			if err := json.Unmarshal(v, entry); err != nil {
+				return err
+			}
+			di.entries[id] = entry
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "failed to load dedup index"), db.Close())
+	}
+	return di, nil
+}
+
+// Close closes the database of the dedup index.
+func (di *dedupIndex) Close() error {
+	return di.staticDB.Close()
+}
+
+// managedKeys returns the seed and the keys of the index. They are derived
+// from the secret of the index the first time they are needed.
+func (di *dedupIndex) managedKeys() (crypto.Hash, map[crypto.CipherType]crypto.CipherKey, error) {
+	di.mu.Lock()
+	seed, keys := di.seed, di.keys
+	di.mu.Unlock()
+	if keys != nil {
+		return seed, keys, nil
+	}
+
+	// Derive the seed and the keys and wipe the secret afterwards.
+	secret, err := di.staticSecret()
+	if err != nil {
+		return crypto.Hash{}, nil, errors.AddContext(err, "failed to get dedup secret")
+	}
+	defer fastrand.Read(secret[:])
+	seed = crypto.HashAll(secret, dedupSeedSpecifier)
+	keys = make(map[crypto.CipherType]crypto.CipherKey, len(dedupCipherTypes))
+	for _, ct := range dedupCipherTypes {
+		// Derive enough entropy for any of the cipher types and truncate it
+		// to the size of the cipher's keys.
+		h1, h2 := crypto.HashAll(secret, ct, 0), crypto.HashAll(secret, ct, 1)
+		entropy := append(h1[:], h2[:]...)
+		keys[ct], err = crypto.NewSiaKey(ct, entropy[:len(crypto.GenerateSiaKey(ct).Key())])
+		if err != nil {
+			return crypto.Hash{}, nil, errors.AddContext(err, "failed to derive dedup key")
+		}
+	}
+
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	di.seed, di.keys = seed, keys
+	return seed, keys, nil
+}
+
+// saveEntries persists the entries of the chunks with the provided ids in a
+// single transaction. Entries which are no longer in the index are removed
+// from the database. The caller needs to hold the lock.
+func (di *dedupIndex) saveEntries(ids ...siafile.DedupID) error {
+	return di.staticDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDedupEntries)
+		for _, id := range ids {
+			entry, exists := di.entries[id]
+			if !exists {
+				if err := b.Delete(id[:]); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(id[:], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// managedAddReference adds a reference to the chunk with the provided id and
+// returns the pieces of the chunk if it was uploaded before.
+func (di *dedupIndex) managedAddReference(id siafile.DedupID) ([][]siafile.Piece, error) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists {
+		entry = &dedupEntry{}
+		di.entries[id] = entry
+	}
+	entry.References++
+	if err := di.saveEntries(id); err != nil {
+		// Revert the change to keep the index in sync with the database.
+		entry.References--
+		if !exists {
+			delete(di.entries, id)
+		}
+		return nil, errors.AddContext(err, "failed to save dedup index")
+	}
+	return copyPieces(entry.Pieces), nil
+}
+
+// managedEntry returns a copy of the entry of the chunk with the provided id.
+func (di *dedupIndex) managedEntry(id siafile.DedupID) (dedupEntry, bool) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists {
+		return dedupEntry{}, false
+	}
+	return dedupEntry{
+		Pieces:     copyPieces(entry.Pieces),
+		References: entry.References,
+	}, true
+}
+
+// managedRemoveReferences removes a reference from each of the chunks with the
+// provided ids. Chunks which are no longer referenced are dropped from the
+// index.
+func (di *dedupIndex) managedRemoveReferences(ids []siafile.DedupID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	for _, id := range ids {
+		entry, exists := di.entries[id]
+		if !exists {
+			continue
+		}
+		if entry.References > 0 {
+			entry.References--
+		}
+		if entry.References == 0 {
+			delete(di.entries, id)
+		}
+	}
+	return di.saveEntries(ids...)
+}
+
+// managedUpdatePieces sets the pieces of the chunk with the provided id if the
+// chunk is still referenced and the new pieces cover at least as many piece
+// indices as the known ones.
+func (di *dedupIndex) managedUpdatePieces(id siafile.DedupID, pieces [][]siafile.Piece) error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists || numUploadedPieces(pieces) < numUploadedPieces(entry.Pieces) {
+		return nil
+	}
+	entry.Pieces = copyPieces(pieces)
+	return di.saveEntries(id)
+}
+
+// managedChunkID computes the DedupID of a chunk from its data pieces. The
+// erasure code and cipher type are part of the id since chunks can only share
+// pieces if they agree on both.
+func (di *dedupIndex) managedChunkID(ct crypto.CipherType, ec modules.ErasureCoder, dataPieces [][]byte) (siafile.DedupID, error) {
+	seed, _, err := di.managedKeys()
+	if err != nil {
+		return siafile.DedupID{}, err
+	}
+	h := crypto.NewHash()
+	_, _ = h.Write(seed[:])
+	_, _ = h.Write(ct[:])
+	_, _ = h.Write([]byte(ec.Identifier()))
+	for _, piece := range dataPieces {
+		_, _ = h.Write(piece)
+	}
+	var id siafile.DedupID
+	copy(id[:], h.Sum(nil))
+	return id, nil
+}
+
+// managedChunkKey derives the key of the deduplicated chunk with the provided
+// id. Its pieces are encrypted using keys derived from it.
+func (di *dedupIndex) managedChunkKey(ct crypto.CipherType, id siafile.DedupID) (crypto.CipherKey, error) {
+	_, keys, err := di.managedKeys()
+	if err != nil {
+		return nil, err
+	}
+	key, exists := keys[ct]
+	if !exists {
+		return nil, crypto.ErrInvalidCipherType
+	}
+	return key.Derive(binary.LittleEndian.Uint64(id[:8]), binary.LittleEndian.Uint64(id[8:])), nil
+}
+
+// copyPieces returns a deep copy of the provided pieces.
+func copyPieces(pieces [][]siafile.Piece) [][]siafile.Piece {
+	if pieces == nil {
+		return nil
+	}
+	cpy := make([][]siafile.Piece, len(pieces))
+	for i := range pieces {
+		cpy[i] = append([]siafile.Piece{}, pieces[i]...)
+	}
+	return cpy
+}
+
+// numUploadedPieces returns the number of piece indices for which at least one
+// piece was uploaded.
+func numUploadedPieces(pieces [][]siafile.Piece) int {
+	var n int
+	for _, pieceSet := range pieces {
+		if len(pieceSet) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// pieceKey returns the key of a piece. The pieces of deduplicated chunks are
+// encrypted with keys derived from the chunk's key since the chunk might be
+// referenced at different indices of different files.
+func pieceKey(masterKey, dedupKey crypto.CipherKey, chunkIndex, pieceIndex uint64) crypto.CipherKey {
+	if dedupKey != nil {
+		return dedupKey.Derive(0, pieceIndex)
+	}
+	return masterKey.Derive(chunkIndex, pieceIndex)
+}
+
+// managedDedupEnabled returns whether new chunks should be deduplicated.
+func (r *Renter) managedDedupEnabled() bool {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.persist.Dedup
+}
+
+// managedDedupKey returns the dedup key of a chunk or nil if the chunk isn't
+// deduplicated.
+func (r *Renter) managedDedupKey(ct crypto.CipherType, id siafile.DedupID) (crypto.CipherKey, error) {
+	if id.IsEmpty() {
+		return nil, nil
+	}
+	return r.staticDedupIndex.managedChunkKey(ct, id)
+}
+
+// managedDedupSecret derives the secret of the dedup index from the renter
+// seed.
+func (r *Renter) managedDedupSecret() (crypto.Hash, error) {
+	// Get the wallet seed.
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	return crypto.HashAll(rs, dedupKeySpecifier), nil
+}
+
+// managedTryDedupChunk deduplicates a chunk that has never been uploaded
+// before if dedup is enabled. The chunk is assigned a DedupID and will be
+// encrypted with the corresponding key. If a chunk with the same id was
+// uploaded before, its pieces are added to the file and the chunk is marked as
+// deduplicated, which means it doesn't need to be uploaded.
+func (r *Renter) managedTryDedupChunk(uc *unfinishedUploadChunk) error {
+	if uc.dedupKey != nil || !r.managedDedupEnabled() {
+		return nil
+	}
+	pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "failed to get pieces of chunk")
+	}
+	if numUploadedPieces(pieces) > 0 {
+		return nil
+	}
+
+	ec := uc.fileEntry.ErasureCode()
+	ct := uc.fileEntry.MasterKey().Type()
+	id, err := r.staticDedupIndex.managedChunkID(ct, ec, uc.logicalChunkData[:ec.MinPieces()])
+	if err != nil {
+		// The secret is not available while the wallet is locked. The chunk
+		// is uploaded without deduplication in that case.
+		r.log.Printf("WARN: unable to deduplicate chunk %v: %v", uc.staticIndex, err)
+		return nil
+	}
+	key, err := r.staticDedupIndex.managedChunkKey(ct, id)
+	if err != nil {
+		return errors.AddContext(err, "failed to derive dedup key")
+	}
+	// Add the reference before setting the DedupID. Otherwise a failure could
+	// leave the file referencing a chunk that might be dropped from the index.
+	existing, err := r.staticDedupIndex.managedAddReference(id)
+	if err != nil {
+		return err
+	}
+	if err := uc.fileEntry.SetDedupID(uc.staticIndex, id); err != nil {
+		err = errors.Compose(err, r.staticDedupIndex.managedRemoveReferences([]siafile.DedupID{id}))
+		return errors.AddContext(err, "failed to set DedupID of chunk")
+	}
+	uc.dedupKey = key
+
+	// If the chunk is not available on the network yet, it needs to be
+	// uploaded.
+	if numUploadedPieces(existing) < ec.MinPieces() {
+		return nil
+	}
+	for pieceIndex, pieceSet := range existing {
+		for _, piece := range pieceSet {
+			err := uc.fileEntry.AddPiece(piece.HostPubKey, uc.staticIndex, uint64(pieceIndex), piece.MerkleRoot)
+			if err != nil {
+				return errors.AddContext(err, "failed to add deduplicated piece")
+			}
+		}
+	}
+	uc.deduplicated = true
+	return nil
+}
+
+// managedUpdateDedupPieces updates the pieces of a deduplicated chunk in the
+// dedup index after the chunk was uploaded or repaired.
+func (r *Renter) managedUpdateDedupPieces(uc *unfinishedUploadChunk) error {
+	id, err := uc.fileEntry.DedupID(uc.staticIndex)
+	if err != nil || id.IsEmpty() {
+		return err
+	}
+	pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+	if err != nil {
+		return err
+	}
+	return r.staticDedupIndex.managedUpdatePieces(id, pieces)
+}
+
+// managedReleaseDedupReferences releases the references of the provided
+// DedupIDs.
+func (r *Renter) managedReleaseDedupReferences(ids []siafile.DedupID) {
+	if err := r.staticDedupIndex.managedRemoveReferences(ids); err != nil {
+		r.log.Println("WARN: failed to release dedup references:", err)
+	}
+}
+
+// fileDedupIDs returns the DedupIDs of all the deduplicated chunks of
+// a file.
+func fileDedupIDs(entry *filesystem.FileNode) ([]siafile.DedupID, error) {
+	var ids []siafile.DedupID
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		id, err := entry.DedupID(chunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		if !id.IsEmpty() {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,354 @@
+package renter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestDedupIndex probes the reference counting and persistence of the
+// dedupIndex.
+func TestDedupIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, dedupFilename)
+	secret := crypto.Hash{1, 2, 3}
+	secretFn := func() (crypto.Hash, error) { return secret, nil }
+	di, err := newDedupIndex(path, secretFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkID := func(di *dedupIndex, ct crypto.CipherType, ec modules.ErasureCoder, data [][]byte) siafile.DedupID {
+		id, err := di.managedChunkID(ct, ec, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// The id of a chunk depends on its data, the erasure code and the cipher
+	// type.
+	ec := modules.NewRSSubCodeDefault()
+	data := [][]byte{fastrand.Bytes(64), fastrand.Bytes(64)}
+	id := chunkID(di, crypto.TypeThreefish, ec, data)
+	if id.IsEmpty() {
+		t.Fatal("id shouldn't be empty")
+	}
+	if chunkID(di, crypto.TypeThreefish, ec, data) != id {
+		t.Fatal("id should be deterministic")
+	}
+	if chunkID(di, crypto.TypeXChaCha20, ec, data) == id {
+		t.Fatal("id should depend on the cipher type")
+	}
+	if chunkID(di, crypto.TypeThreefish, modules.NewPassthroughErasureCoder(), data) == id {
+		t.Fatal("id should depend on the erasure code")
+	}
+	if chunkID(di, crypto.TypeThreefish, ec, [][]byte{data[0], fastrand.Bytes(64)}) == id {
+		t.Fatal("id should depend on the data")
+	}
+	key, err := di.managedChunkKey(crypto.TypeThreefish, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add two references. The first one shouldn't return any pieces.
+	pieces, err := di.managedAddReference(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pieces != nil {
+		t.Fatal("expected no pieces", pieces)
+	}
+	pieces = [][]siafile.Piece{{{HostPubKey: types.SiaPublicKey{Key: fastrand.Bytes(32)}, MerkleRoot: crypto.Hash{1}}}, {}}
+	if err := di.managedUpdatePieces(id, pieces); err != nil {
+		t.Fatal(err)
+	}
+	added, err := di.managedAddReference(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, pieces) {
+		t.Fatal("pieces don't match", added, pieces)
+	}
+	// Fewer pieces shouldn't replace the known ones.
+	if err := di.managedUpdatePieces(id, make([][]siafile.Piece, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the index.
+	if err := di.Close(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(path, secretFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := di.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if chunkID(di, crypto.TypeThreefish, ec, data) != id {
+		t.Fatal("seed should be derived from the secret")
+	}
+	reloadedKey, err := di.managedChunkKey(crypto.TypeThreefish, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reloadedKey.Key(), key.Key()) {
+		t.Fatal("keys should be derived from the secret")
+	}
+	entry, exists := di.managedEntry(id)
+	if !exists || entry.References != 2 || !reflect.DeepEqual(entry.Pieces, pieces) {
+		t.Fatal("entry wasn't persisted", entry, exists)
+	}
+
+	// An index with a different secret should use a different seed and
+	// different keys.
+	otherDir := build.TempDir("renter", t.Name(), "other")
+	if err := os.MkdirAll(otherDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	other, err := newDedupIndex(filepath.Join(otherDir, dedupFilename), func() (crypto.Hash, error) {
+		return crypto.Hash{4, 5, 6}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunkID(other, crypto.TypeThreefish, ec, data) == id {
+		t.Fatal("seed should depend on the secret")
+	}
+	otherKey, err := other.managedChunkKey(crypto.TypeThreefish, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(otherKey.Key(), key.Key()) {
+		t.Fatal("keys should depend on the secret")
+	}
+	if err := other.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Releasing both references should drop the entry.
+	if err := di.managedRemoveReferences([]siafile.DedupID{id}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, exists := di.managedEntry(id); !exists || entry.References != 1 {
+		t.Fatal("expected one reference", entry, exists)
+	}
+	if err := di.managedRemoveReferences([]siafile.DedupID{id, id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := di.managedEntry(id); exists {
+		t.Fatal("entry should have been dropped")
+	}
+}
+
+// TestDedupChunk probes deduplicating identical chunks of different files and
+// releasing them when the files are deleted.
+func TestDedupChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	id := r.mu.Lock()
+	r.persist.Dedup = true
+	r.mu.Unlock(id)
+
+	// Create two files and the same chunk of data for both of them.
+	_, ec := testingFileParamsCustom(1, 1)
+	newFile := func() *filesystem.FileNode {
+		siaPath := modules.RandomSiaPath()
+		err := r.staticFileSystem.NewSiaFile(siaPath, "", ec, crypto.GenerateSiaKey(crypto.TypeDefaultRenter), 1000, persist.DefaultDiskPermissionsTest, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	file1, file2 := newFile(), newFile()
+	data := fastrand.Bytes(int(file1.PieceSize()))
+	newChunk := func(entry *filesystem.FileNode) *unfinishedUploadChunk {
+		shards, err := ec.EncodeShards([][]byte{append([]byte{}, data...)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &unfinishedUploadChunk{
+			fileEntry:        entry,
+			logicalChunkData: shards,
+		}
+	}
+
+	// The first chunk gets a DedupID and needs to be uploaded.
+	uc1 := newChunk(file1)
+	if err := r.managedTryDedupChunk(uc1); err != nil {
+		t.Fatal(err)
+	}
+	if uc1.dedupKey == nil || uc1.deduplicated {
+		t.Fatal("expected chunk to be assigned a dedup key", uc1.dedupKey, uc1.deduplicated)
+	}
+	dedupID, err := file1.DedupID(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dedupID.IsEmpty() {
+		t.Fatal("DedupID wasn't set")
+	}
+
+	// Pretend that the chunk was uploaded.
+	hpk := types.SiaPublicKey{Key: fastrand.Bytes(32)}
+	for pieceIndex := 0; pieceIndex < ec.NumPieces(); pieceIndex++ {
+		if err := file1.AddPiece(hpk, 0, uint64(pieceIndex), crypto.Hash{byte(pieceIndex)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.managedUpdateDedupPieces(uc1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chunk of the second file should reference the pieces of the first
+	// one.
+	uc2 := newChunk(file2)
+	if err := r.managedTryDedupChunk(uc2); err != nil {
+		t.Fatal(err)
+	}
+	if !uc2.deduplicated {
+		t.Fatal("expected chunk to be deduplicated")
+	}
+	if !bytes.Equal(uc1.dedupKey.Key(), uc2.dedupKey.Key()) {
+		t.Fatal("dedup keys don't match")
+	}
+	pieces1, err := file1.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces2, err := file2.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pieces1, pieces2) {
+		t.Fatal("pieces don't match", pieces1, pieces2)
+	}
+	if entry, _ := r.staticDedupIndex.managedEntry(dedupID); entry.References != 2 {
+		t.Fatal("expected 2 references", entry.References)
+	}
+
+	// Deleting the files should release their references.
+	siaPath1, siaPath2 := r.staticFileSystem.FileSiaPath(file1), r.staticFileSystem.FileSiaPath(file2)
+	if err := file1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteFile(siaPath1); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := r.staticDedupIndex.managedEntry(dedupID); entry.References != 1 {
+		t.Fatal("expected 1 reference", entry.References)
+	}
+	if err := r.DeleteFile(siaPath2); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := r.staticDedupIndex.managedEntry(dedupID); exists {
+		t.Fatal("entry should have been dropped")
+	}
+}
+
+// TestDedupChunkRollback probes that a failure to set the DedupID of a chunk
+// releases the reference that was added for it.
+func TestDedupChunkRollback(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	id := r.mu.Lock()
+	r.persist.Dedup = true
+	r.mu.Unlock(id)
+
+	// Create a file and delete it while it's still open. Setting the DedupID
+	// of its chunks will fail.
+	_, ec := testingFileParamsCustom(1, 1)
+	siaPath := modules.RandomSiaPath()
+	err = r.staticFileSystem.NewSiaFile(siaPath, "", ec, crypto.GenerateSiaKey(crypto.TypeDefaultRenter), 1000, persist.DefaultDiskPermissionsTest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := r.staticFileSystem.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(entry.PieceSize()))
+	shards, err := ec.EncodeShards([][]byte{data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := &unfinishedUploadChunk{
+		fileEntry:        entry,
+		logicalChunkData: shards,
+	}
+	if err := r.managedTryDedupChunk(uc); !errors.Contains(err, siafile.ErrDeleted) {
+		t.Fatal("expected ErrDeleted", err)
+	}
+	if uc.dedupKey != nil {
+		t.Fatal("chunk shouldn't have a dedup key")
+	}
+
+	// The reference should have been released.
+	dedupID, err := r.staticDedupIndex.managedChunkID(entry.MasterKey().Type(), ec, shards[:ec.MinPieces()])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := r.staticDedupIndex.managedEntry(dedupID); exists {
+		t.Fatal("reference wasn't released")
+	}
+}
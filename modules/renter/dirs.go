@@ -6,7 +6,9 @@ import (
 	"sync"
 
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
 )
 
 // CreateDir creates a directory for the renter
@@ -26,7 +28,21 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+	// Stop changing the redundancy of the files within the dir.
+	if err := r.managedAbortRedundancyChanges(siaPath); err != nil {
+		return err
+	}
+	// Remember the deduplicated chunks and the packs of the files within the
+	// dir to release them after the dir is deleted.
+	refs, err := r.managedDirFileReferences(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to get references of siadir")
+	}
+	if err := r.staticFileSystem.DeleteDir(siaPath); err != nil {
+		return err
+	}
+	r.managedReleaseFileReferences(refs)
+	return nil
 }
 
 // DirList lists the directories in a siadir
@@ -38,6 +54,92 @@ func (r *Renter) DirList(siaPath modules.SiaPath) (dis []modules.DirectoryInfo,
 	return r.managedDirList(siaPath)
 }
 
+// DirPolicy returns the effective policy of a siadir, which includes the fields
+// inherited from its parents.
+func (r *Renter) DirPolicy(siaPath modules.SiaPath) (modules.DirPolicy, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirPolicy{}, err
+	}
+	defer r.tg.Done()
+	return r.staticFileSystem.DirPolicy(siaPath)
+}
+
+// SetDirPolicy sets the policy of a siadir.
+func (r *Renter) SetDirPolicy(siaPath modules.SiaPath, policy modules.DirPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return errors.AddContext(err, "invalid policy")
+	}
+	return r.staticFileSystem.SetDirPolicy(siaPath, policy)
+}
+
+// managedApplyDirPolicy sets the erasure code and cipher type of the upload
+// params to the values of the policy of the file's siadir unless they were set
+// explicitly. If the policy doesn't specify them either, the renter's defaults
+// are used.
+func (r *Renter) managedApplyDirPolicy(up *modules.FileUploadParams) error {
+	dirSiaPath, err := up.SiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	policy, err := r.staticFileSystem.DirPolicy(dirSiaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get policy of siadir")
+	}
+	if up.ErasureCode == nil {
+		up.ErasureCode, err = policy.ErasureCode()
+		if err != nil {
+			return errors.AddContext(err, "invalid erasure code in policy")
+		}
+	}
+	if up.ErasureCode == nil {
+		up.ErasureCode = modules.NewRSSubCodeDefault()
+	}
+	if up.CipherType == crypto.TypeInvalid {
+		up.CipherType, err = policy.Cipher()
+		if err != nil {
+			return errors.AddContext(err, "invalid cipher type in policy")
+		}
+	}
+	if up.CipherType == crypto.TypeInvalid {
+		up.CipherType = crypto.TypeDefaultRenter
+	}
+	return nil
+}
+
+// managedPinnedHosts returns the subset of hosts the file may be uploaded to
+// according to the hosts pinned by the policy of the file's siadir.
+func (r *Renter) managedPinnedHosts(fileNode *filesystem.FileNode, hosts map[string]struct{}) map[string]struct{} {
+	// Re-encoded copies of files use the policy of the original file.
+	siaPath := r.staticFileSystem.FileSiaPath(fileNode)
+	if source, ok := redundancyChangeSource(siaPath); ok {
+		siaPath = source
+	}
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		r.log.Println("WARN: unable to get siadir of file:", err)
+		return hosts
+	}
+	policy, err := r.staticFileSystem.DirPolicy(dirSiaPath)
+	if err != nil {
+		r.log.Printf("WARN: unable to get policy of siadir %v: %v", dirSiaPath, err)
+		return hosts
+	}
+	if len(policy.Hosts) == 0 {
+		return hosts
+	}
+	pinned := make(map[string]struct{}, len(policy.Hosts))
+	for _, host := range policy.Hosts {
+		if _, exists := hosts[host.String()]; exists {
+			pinned[host.String()] = struct{}{}
+		}
+	}
+	return pinned
+}
+
 // managedDirList lists the directories in a siadir
 func (r *Renter) managedDirList(siaPath modules.SiaPath) (dis []modules.DirectoryInfo, _ error) {
 	var mu sync.Mutex
@@ -69,5 +171,9 @@ func (r *Renter) RenameDir(oldPath, newPath modules.SiaPath) error {
 	if newPath.IsRoot() {
 		return errors.New("cannot rename a file to the root directory")
 	}
+	// Stop changing the redundancy of the files within the dir.
+	if err := r.managedAbortRedundancyChanges(oldPath); err != nil {
+		return err
+	}
 	return r.staticFileSystem.RenameDir(oldPath, newPath)
 }
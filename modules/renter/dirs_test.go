@@ -11,11 +11,14 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
 )
 
 // FileListCollect returns information on all of the files stored by the
@@ -440,3 +443,152 @@ func compareDirectoryInfoAndMetadataCustom(di modules.DirectoryInfo, siaDir *fil
 	}
 	return nil
 }
+
+// TestRenterDirPolicy tests that the upload params and hosts of files are
+// determined by the policy of their siadir.
+func TestRenterDirPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create renterTester
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create the dirs.
+	archive, err := modules.NewSiaPath("archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hot, err := modules.NewSiaPath("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sp := range []modules.SiaPath{archive, hot} {
+		if err := r.CreateDir(sp, modules.DefaultDirPerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archiveFilePath, err := archive.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiveSubDirFilePath, err := archive.Join("photos/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotFilePath, err := hot.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid policies should be rejected.
+	if err := r.SetDirPolicy(archive, modules.DirPolicy{DataPieces: 1}); err == nil {
+		t.Fatal("policy with only data pieces should be rejected")
+	}
+	if err := r.SetDirPolicy(archive, modules.DirPolicy{CipherType: "rot13"}); err == nil {
+		t.Fatal("policy with unknown cipher type should be rejected")
+	}
+
+	// Set a policy for the archive.
+	pinned := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	other := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	policy := modules.DirPolicy{
+		DataPieces:   2,
+		ParityPieces: 3,
+		CipherType:   crypto.TypePlain.String(),
+		Hosts:        []types.SiaPublicKey{pinned},
+	}
+	if err := r.SetDirPolicy(archive, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files in the archive should use the policy, even in subdirs which
+	// don't exist yet.
+	up := modules.FileUploadParams{SiaPath: archiveSubDirFilePath}
+	if err := r.managedApplyDirPolicy(&up); err != nil {
+		t.Fatal(err)
+	}
+	if up.ErasureCode.MinPieces() != 2 || up.ErasureCode.NumPieces() != 5 {
+		t.Fatal("wrong erasure code", up.ErasureCode.MinPieces(), up.ErasureCode.NumPieces())
+	}
+	if up.CipherType != crypto.TypePlain {
+		t.Fatal("wrong cipher type", up.CipherType)
+	}
+
+	// Explicitly set params take precedence.
+	up = modules.FileUploadParams{
+		SiaPath:     archiveFilePath,
+		ErasureCode: modules.NewRSSubCodeDefault(),
+		CipherType:  crypto.TypeXChaCha20,
+	}
+	if err := r.managedApplyDirPolicy(&up); err != nil {
+		t.Fatal(err)
+	}
+	if up.ErasureCode.MinPieces() != modules.RenterDefaultDataPieces || up.CipherType != crypto.TypeXChaCha20 {
+		t.Fatal("explicit params were overwritten", up.ErasureCode.MinPieces(), up.CipherType)
+	}
+
+	// Files in other dirs use the defaults.
+	up = modules.FileUploadParams{SiaPath: hotFilePath}
+	if err := r.managedApplyDirPolicy(&up); err != nil {
+		t.Fatal(err)
+	}
+	if up.ErasureCode.MinPieces() != modules.RenterDefaultDataPieces || up.CipherType != crypto.TypeDefaultRenter {
+		t.Fatal("defaults weren't used", up.ErasureCode.MinPieces(), up.CipherType)
+	}
+
+	// Only the pinned hosts should be used for files in the archive.
+	hosts := map[string]struct{}{
+		pinned.String(): {},
+		other.String():  {},
+	}
+	for _, sp := range []modules.SiaPath{archiveFilePath, hotFilePath} {
+		err = r.staticFileSystem.NewSiaFile(sp, "", modules.NewRSSubCodeDefault(), crypto.GenerateSiaKey(crypto.TypeDefaultRenter), 0, modules.DefaultFilePerm, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	archiveFile, err := r.staticFileSystem.OpenSiaFile(archiveFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archiveFile.Close()
+	if pinnedHosts := r.managedPinnedHosts(archiveFile, hosts); len(pinnedHosts) != 1 {
+		t.Fatal("wrong number of hosts", len(pinnedHosts))
+	} else if _, exists := pinnedHosts[pinned.String()]; !exists {
+		t.Fatal("pinned host is missing")
+	}
+	hotFile, err := r.staticFileSystem.OpenSiaFile(hotFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hotFile.Close()
+	if len(r.managedPinnedHosts(hotFile, hosts)) != len(hosts) {
+		t.Fatal("hosts shouldn't be filtered")
+	}
+
+	// The DirectoryInfo should contain the policy.
+	dis, err := r.DirList(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dis[0].Policy.DataPieces != 2 || dis[0].Policy.CipherType != policy.CipherType {
+		t.Fatal("wrong policy in DirectoryInfo", dis[0].Policy)
+	}
+}
@@ -312,8 +312,15 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		}
 	}
 
-	// Prepare snapshot.
-	snap, err := entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
+	// Prepare snapshot. The data of packed files is downloaded from their
+	// pack.
+	var snap *siafile.Snapshot
+	offset := p.Offset
+	if info, packed := entry.Packed(); packed {
+		snap, offset, err = r.managedPackedSnapshot(p.SiaPath, info, p.Offset, p.Length)
+	} else {
+		snap, err = entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -328,7 +335,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		latencyTarget: 25e3 * time.Millisecond, // TODO: high default until full latency support is added.
 		length:        p.Length,
 		needsMemory:   true,
-		offset:        p.Offset,
+		offset:        offset,
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
 		priority:      5, // TODO: moderate default until full priority support is added.
 
@@ -472,10 +479,15 @@ func (d *download) Start() error {
 	writeOffset := int64(0) // where to write a chunk within the download destination.
 	d.chunksRemaining += maxChunk - minChunk + 1
 	for i := minChunk; i <= maxChunk; i++ {
+		dedupKey, err := d.r.managedDedupKey(params.file.MasterKey().Type(), params.file.DedupID(i))
+		if err != nil {
+			return errors.AddContext(err, "unable to derive dedup key")
+		}
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
 			erasureCode: params.file.ErasureCode(),
 			masterKey:   params.file.MasterKey(),
+			dedupKey:    dedupKey,
 
 			staticChunkIndex: i,
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticSiaPath, i),
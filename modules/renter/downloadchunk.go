@@ -39,6 +39,7 @@ type unfinishedDownloadChunk struct {
 	destination downloadDestination // Where to write the recovered logical chunk.
 	erasureCode modules.ErasureCoder
 	masterKey   crypto.CipherKey
+	dedupKey    crypto.CipherKey // Only set for deduplicated chunks.
 
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	staticChunkIndex  uint64                       // Required for deriving the encryption keys for each piece.
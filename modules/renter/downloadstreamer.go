@@ -487,6 +487,15 @@ func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (_ st
 		err = errors.Compose(err, node.Close())
 	}()
 
+	// Packed files are streamed from their pack.
+	if info, packed := node.Packed(); packed {
+		s, err := r.managedPackedStreamer(siaPath, node.Size(), info, disableLocalFetch)
+		if err != nil {
+			return "", nil, err
+		}
+		return siaPath.String(), s, nil
+	}
+
 	// Create the streamer
 	snap, err := node.Snapshot(siaPath)
 	if err != nil {
@@ -507,6 +516,9 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 
 	// Grab the current SiaPath of the FileNode and then create a snapshot.
 	sp := r.staticFileSystem.FileSiaPath(node)
+	if info, packed := node.Packed(); packed {
+		return r.managedPackedStreamer(sp, node.Size(), info, disableLocalFetch)
+	}
 	snap, err := node.Snapshot(sp)
 	if err != nil {
 		return nil, err
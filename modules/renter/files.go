@@ -1,7 +1,11 @@
 package renter
 
 import (
+	"sync"
+
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -15,11 +19,25 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// Stop changing the redundancy of the file.
+	err = r.managedAbortRedundancyChange(siaPath)
+	if err != nil {
+		return err
+	}
+
+	// Remember the deduplicated chunks and the pack of the file to release
+	// them after the file is deleted.
+	refs, err := r.managedFileReferences(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to get references of siafile")
+	}
+
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
 	if err != nil {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
+	r.managedReleaseFileReferences(refs)
 
 	// Update the filesystem metadata.
 	//
@@ -40,6 +58,72 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	return nil
 }
 
+// fileReferences are the resources shared with other files which are
+// referenced by a file. They need to be released after the file is deleted.
+type fileReferences struct {
+	dedupIDs []siafile.DedupID
+	packs    []modules.SiaPath
+}
+
+// fileReferencesOf returns the resources referenced by a file.
+func fileReferencesOf(entry *filesystem.FileNode) (fileReferences, error) {
+	dedupIDs, err := fileDedupIDs(entry)
+	if err != nil {
+		return fileReferences{}, err
+	}
+	refs := fileReferences{dedupIDs: dedupIDs}
+	if packed, ok := entry.Packed(); ok {
+		refs.packs = append(refs.packs, packed.Pack)
+	}
+	return refs, nil
+}
+
+// managedFileReferences returns the resources referenced by the file at the
+// provided siapath.
+func (r *Renter) managedFileReferences(siaPath modules.SiaPath) (_ fileReferences, err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return fileReferences{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	return fileReferencesOf(entry)
+}
+
+// managedDirFileReferences returns the resources referenced by the files
+// within the dir at the provided siapath and its subdirs.
+func (r *Renter) managedDirFileReferences(siaPath modules.SiaPath) (fileReferences, error) {
+	var siaPaths []modules.SiaPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(siaPath, true, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return fileReferences{}, err
+	}
+	var refs fileReferences
+	for _, sp := range siaPaths {
+		fileRefs, err := r.managedFileReferences(sp)
+		if err != nil {
+			return fileReferences{}, errors.AddContext(err, "failed to get references of "+sp.String())
+		}
+		refs.dedupIDs = append(refs.dedupIDs, fileRefs.dedupIDs...)
+		refs.packs = append(refs.packs, fileRefs.packs...)
+	}
+	return refs, nil
+}
+
+// managedReleaseFileReferences releases the provided references of deleted
+// files.
+func (r *Renter) managedReleaseFileReferences(refs fileReferences) {
+	r.managedReleaseDedupReferences(refs.dedupIDs)
+	r.managedReleasePackReferences(refs.packs)
+}
+
 // FileList loops over all the files within the directory specified by siaPath
 // and will then call the provided listing function on the file.
 func (r *Renter) FileList(siaPath modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error {
@@ -94,8 +178,14 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// Stop changing the redundancy of the file.
+	err := r.managedAbortRedundancyChange(currentName)
+	if err != nil {
+		return err
+	}
+
 	// Rename file.
-	err := r.staticFileSystem.RenameFile(currentName, newName)
+	err = r.staticFileSystem.RenameFile(currentName, newName)
 	if err != nil {
 		return err
 	}
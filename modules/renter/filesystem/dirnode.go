@@ -134,6 +134,17 @@ func (n *DirNode) Path() (string, error) {
 	return sd.Path(), nil
 }
 
+// SetPolicy is a wrapper for SiaDir.SetPolicy.
+func (n *DirNode) SetPolicy(policy modules.DirPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetPolicy(policy)
+}
+
 // UpdateBubbledMetadata is a wrapper for SiaDir.UpdateBubbledMetadata.
 func (n *DirNode) UpdateBubbledMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -603,6 +614,7 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		NumFiles:            metadata.NumFiles,
 		NumStuckChunks:      metadata.NumStuckChunks,
 		NumSubDirs:          metadata.NumSubDirs,
+		Policy:              metadata.Policy,
 		RepairSize:          metadata.RepairSize,
 		DirSize:             metadata.Size,
 		StuckHealth:         metadata.StuckHealth,
@@ -648,15 +660,22 @@ func (n *DirNode) childFiles() []*FileNode {
 	return files
 }
 
-// managedNewSiaFile creates a new SiaFile in the directory.
-func (n *DirNode) managedNewSiaFile(fileName string, source string, ec modules.ErasureCoder, mk crypto.CipherKey, fileSize uint64, fileMode os.FileMode, disablePartialUpload bool) error {
+// managedNewSiaFile creates a new SiaFile in the directory. If packed is set,
+// a packed SiaFile which stores its data within a pack is created instead.
+func (n *DirNode) managedNewSiaFile(fileName string, source string, ec modules.ErasureCoder, mk crypto.CipherKey, fileSize uint64, fileMode os.FileMode, disablePartialUpload bool, packed *siafile.PackedFileInfo) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	// Make sure we don't have a file or folder with that name already.
 	if exists := n.childExists(fileName); exists {
 		return ErrExists
 	}
-	_, err := siafile.New(filepath.Join(n.absPath(), fileName+modules.SiaFileExtension), source, n.staticWal, ec, mk, fileSize, fileMode, nil, disablePartialUpload)
+	path := filepath.Join(n.absPath(), fileName+modules.SiaFileExtension)
+	var err error
+	if packed != nil {
+		_, err = siafile.NewPacked(path, source, n.staticWal, ec, mk, fileSize, fileMode, *packed)
+	} else {
+		_, err = siafile.New(path, source, n.staticWal, ec, mk, fileSize, fileMode, nil, disablePartialUpload)
+	}
 	return errors.AddContext(err, "NewSiaFile: failed to create file")
 }
 
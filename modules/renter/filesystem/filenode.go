@@ -207,6 +207,44 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 	return err
 }
 
+// managedReplace moves the fNode's underlying file to the location of
+// replaced and deletes replaced.
+func (n *FileNode) managedReplace(replaced *FileNode, parent, replacedParent *DirNode) error {
+	if n.SiaFile == replaced.SiaFile {
+		return errors.New("can't replace file with itself")
+	}
+	// Lock the parents. If they are the same, only lock one.
+	if parent.staticUID == replacedParent.staticUID {
+		parent.node.mu.Lock()
+		defer parent.node.mu.Unlock()
+	} else {
+		parent.node.mu.Lock()
+		defer parent.node.mu.Unlock()
+		replacedParent.node.mu.Lock()
+		defer replacedParent.node.mu.Unlock()
+	}
+	n.node.mu.Lock()
+	defer n.node.mu.Unlock()
+	replaced.node.mu.Lock()
+	defer replaced.node.mu.Unlock()
+	// Replace the file.
+	err := n.SiaFile.Replace(replaced.SiaFile)
+	if err != nil {
+		return err
+	}
+	// Remove both files from their parents and add the file to the parent of
+	// the replaced file.
+	parent.removeFile(n)
+	replacedParent.removeFile(replaced)
+	// Update parent and name.
+	n.parent = replacedParent
+	*n.name = *replaced.name
+	*n.path = *replaced.path
+	// Add file to new parent.
+	n.parent.files[*n.name] = n
+	return nil
+}
+
 // cachedFileInfo returns information on a siafile. As a performance
 // optimization, the fileInfo takes the maps returned by
 // renter.managedContractUtilityMaps for many files at once.
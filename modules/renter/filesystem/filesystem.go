@@ -19,6 +19,7 @@ import (
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -40,6 +41,14 @@ type (
 	// future.
 	FileSystem struct {
 		DirNode
+
+		// policyCache caches the effective policies returned by DirPolicy.
+		// Every change to a siadir's policy clears the whole cache and
+		// increments policyCacheGen. Lookups that raced with such a change
+		// are not added to the cache.
+		policyCache    map[modules.SiaPath]modules.DirPolicy
+		policyCacheGen uint64
+		policyCacheMu  sync.Mutex
 	}
 
 	// node is a struct that contains the common fields of every node.
@@ -157,6 +166,7 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 			files:       make(map[string]*FileNode),
 			lazySiaDir:  new(*siadir.SiaDir),
 		},
+		policyCache: make(map[modules.SiaPath]modules.DirPolicy),
 	}
 	// Prepare root folder.
 	err := fs.NewSiaDir(modules.RootSiaPath(), modules.DefaultDirPerm)
@@ -241,6 +251,7 @@ func (fs *FileSystem) CachedListOnNode(d *DirNode) (fis []modules.FileInfo, dis
 // file of the same path can be created and the existing file can't be opened
 // until all instances of it are closed.
 func (fs *FileSystem) DeleteDir(siaPath modules.SiaPath) error {
+	defer fs.managedInvalidatePolicyCache()
 	return fs.managedDeleteDir(siaPath.String())
 }
 
@@ -278,6 +289,69 @@ func (fs *FileSystem) DirNodeInfo(n *DirNode) (modules.DirectoryInfo, error) {
 	return n.managedInfo(sp)
 }
 
+// DirPolicy returns the effective policy of the siadir at siaPath. Fields which
+// are not set in the siadir's policy are inherited from its parents. Siadirs
+// which don't exist yet are skipped, which makes it possible to look up the
+// policy of a file's siadir before the file is created.
+func (fs *FileSystem) DirPolicy(siaPath modules.SiaPath) (modules.DirPolicy, error) {
+	fs.policyCacheMu.Lock()
+	policy, cached := fs.policyCache[siaPath]
+	gen := fs.policyCacheGen
+	fs.policyCacheMu.Unlock()
+	if cached {
+		policy.Hosts = append([]types.SiaPublicKey(nil), policy.Hosts...)
+		return policy, nil
+	}
+	policy, err := fs.managedResolveDirPolicy(siaPath)
+	if err != nil {
+		return modules.DirPolicy{}, err
+	}
+	fs.policyCacheMu.Lock()
+	if gen == fs.policyCacheGen {
+		cachedPolicy := policy
+		cachedPolicy.Hosts = append([]types.SiaPublicKey(nil), policy.Hosts...)
+		fs.policyCache[siaPath] = cachedPolicy
+	}
+	fs.policyCacheMu.Unlock()
+	return policy, nil
+}
+
+// managedInvalidatePolicyCache clears the cached policies. It needs to be
+// called after any change to the policy of a siadir or to the tree of siadirs.
+func (fs *FileSystem) managedInvalidatePolicyCache() {
+	fs.policyCacheMu.Lock()
+	defer fs.policyCacheMu.Unlock()
+	fs.policyCache = make(map[modules.SiaPath]modules.DirPolicy)
+	fs.policyCacheGen++
+}
+
+// managedResolveDirPolicy walks up the tree from siaPath to the root and
+// combines the policies of all siadirs on the way.
+func (fs *FileSystem) managedResolveDirPolicy(siaPath modules.SiaPath) (modules.DirPolicy, error) {
+	var policy modules.DirPolicy
+	for {
+		dir, err := fs.OpenSiaDir(siaPath)
+		if err == nil {
+			var md siadir.Metadata
+			md, err = dir.Metadata()
+			err = errors.Compose(err, dir.Close())
+			if err != nil {
+				return modules.DirPolicy{}, errors.AddContext(err, fmt.Sprintf("unable to get metadata of %v", siaPath))
+			}
+			policy = policy.Inherit(md.Policy)
+		} else if !errors.Contains(err, ErrNotExist) {
+			return modules.DirPolicy{}, errors.AddContext(err, fmt.Sprintf("unable to open %v", siaPath))
+		}
+		if siaPath.IsRoot() {
+			return policy, nil
+		}
+		siaPath, err = siaPath.Dir()
+		if err != nil {
+			return modules.DirPolicy{}, err
+		}
+	}
+}
+
 // FileInfo returns the File Information of the siafile
 func (fs *FileSystem) FileInfo(siaPath modules.SiaPath, offline map[string]bool, goodForRenew map[string]bool, contracts map[string]modules.RenterContract) (modules.FileInfo, error) {
 	return fs.managedFileInfo(siaPath, false, offline, goodForRenew, contracts)
@@ -326,7 +400,21 @@ func (fs *FileSystem) NewSiaFile(siaPath modules.SiaPath, source string, ec modu
 	if err = fs.NewSiaDir(dirSiaPath, fileMode); err != nil {
 		return errors.AddContext(err, fmt.Sprintf("failed to create SiaDir %v for SiaFile %v", dirSiaPath.String(), siaPath.String()))
 	}
-	return fs.managedNewSiaFile(siaPath.String(), source, ec, mk, fileSize, fileMode, disablePartialUpload)
+	return fs.managedNewSiaFile(siaPath.String(), source, ec, mk, fileSize, fileMode, disablePartialUpload, nil)
+}
+
+// NewPackedSiaFile creates a packed SiaFile at the specified siaPath. The data
+// of the file is stored within the pack described by info.
+func (fs *FileSystem) NewPackedSiaFile(siaPath modules.SiaPath, source string, ec modules.ErasureCoder, mk crypto.CipherKey, fileSize uint64, fileMode os.FileMode, info siafile.PackedFileInfo) error {
+	// Create SiaDir for file.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	if err = fs.NewSiaDir(dirSiaPath, fileMode); err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to create SiaDir %v for SiaFile %v", dirSiaPath.String(), siaPath.String()))
+	}
+	return fs.managedNewSiaFile(siaPath.String(), source, ec, mk, fileSize, fileMode, true, &info)
 }
 
 // ReadDir reads all the fileinfos of the specified dir.
@@ -375,6 +463,19 @@ func (fs *FileSystem) DirSiaPath(n *DirNode) (sp modules.SiaPath) {
 	return fs.managedSiaPath(&n.node)
 }
 
+// SetDirPolicy sets the policy of a SiaDir.
+func (fs *FileSystem) SetDirPolicy(siaPath modules.SiaPath, policy modules.DirPolicy) (err error) {
+	dir, err := fs.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+		fs.managedInvalidatePolicyCache()
+	}()
+	return dir.SetPolicy(policy)
+}
+
 // UpdateDirMetadata updates the metadata of a SiaDir.
 func (fs *FileSystem) UpdateDirMetadata(siaPath modules.SiaPath, metadata siadir.Metadata) (err error) {
 	dir, err := fs.OpenSiaDir(siaPath)
@@ -383,6 +484,7 @@ func (fs *FileSystem) UpdateDirMetadata(siaPath modules.SiaPath, metadata siadir
 	}
 	defer func() {
 		err = errors.Compose(err, dir.Close())
+		fs.managedInvalidatePolicyCache()
 	}()
 	return dir.UpdateMetadata(metadata)
 }
@@ -519,10 +621,65 @@ func (fs *FileSystem) RenameFile(oldSiaPath, newSiaPath modules.SiaPath) (err er
 	return sf.managedRename(newSiaPath.Name(), oldDir, newDir)
 }
 
+// ReplaceFile moves the file with siaPath to replacedSiaPath. The file which
+// previously existed at replacedSiaPath is deleted.
+func (fs *FileSystem) ReplaceFile(siaPath, replacedSiaPath modules.SiaPath) (err error) {
+	// Open SiaDir of the file.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	dir, err := fs.managedOpenSiaDir(dirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	// Open the file.
+	sf, err := dir.managedOpenFile(siaPath.Name())
+	if errors.Contains(err, ErrNotExist) {
+		return ErrNotExist
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open file for replacing")
+	}
+	defer func() {
+		err = errors.Compose(err, sf.Close())
+	}()
+
+	// Open SiaDir of the file to replace.
+	replacedDirSiaPath, err := replacedSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	replacedDir, err := fs.managedOpenSiaDir(replacedDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, replacedDir.Close())
+	}()
+	// Open the file to replace.
+	replaced, err := replacedDir.managedOpenFile(replacedSiaPath.Name())
+	if errors.Contains(err, ErrNotExist) {
+		return ErrNotExist
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open replaced file")
+	}
+	defer func() {
+		err = errors.Compose(err, replaced.Close())
+	}()
+	// Replace the file.
+	return sf.managedReplace(replaced, dir, replacedDir)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
 func (fs *FileSystem) RenameDir(oldSiaPath, newSiaPath modules.SiaPath) error {
+	defer fs.managedInvalidatePolicyCache()
 	// Open SiaDir for parent dir at old location.
 	oldDirSiaPath, err := oldSiaPath.Dir()
 	if err != nil {
@@ -703,7 +860,7 @@ func (fs *FileSystem) managedOpenFile(relPath string) (_ *FileNode, err error) {
 
 // managedNewSiaFile opens the parent folder of the new SiaFile and calls
 // managedNewSiaFile on it.
-func (fs *FileSystem) managedNewSiaFile(relPath string, source string, ec modules.ErasureCoder, mk crypto.CipherKey, fileSize uint64, fileMode os.FileMode, disablePartialUpload bool) (err error) {
+func (fs *FileSystem) managedNewSiaFile(relPath string, source string, ec modules.ErasureCoder, mk crypto.CipherKey, fileSize uint64, fileMode os.FileMode, disablePartialUpload bool, packed *siafile.PackedFileInfo) (err error) {
 	// Open the folder that contains the file.
 	dirPath, fileName := filepath.Split(relPath)
 	var dir *DirNode
@@ -719,7 +876,7 @@ func (fs *FileSystem) managedNewSiaFile(relPath string, source string, ec module
 			err = errors.Compose(err, dir.Close())
 		}()
 	}
-	return dir.managedNewSiaFile(fileName, source, ec, mk, fileSize, fileMode, disablePartialUpload)
+	return dir.managedNewSiaFile(fileName, source, ec, mk, fileSize, fileMode, disablePartialUpload, packed)
 }
 
 // managedOpenSiaDir opens a SiaDir and adds it and all of its parents to the
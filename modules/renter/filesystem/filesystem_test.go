@@ -23,6 +23,7 @@ import (
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 
 	"go.sia.tech/siad/build"
 )
@@ -757,6 +758,62 @@ func TestRenameFile(t *testing.T) {
 	sf.Close()
 }
 
+// TestReplaceFile tests replacing a file with another one.
+func TestReplaceFile(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	// Add the files.
+	foo := newSiaPath("foo")
+	barfoo := newSiaPath("bar/foo")
+	fs.addTestSiaFile(foo)
+	fs.addTestSiaFile(barfoo)
+	// Keep the replaced file open.
+	replaced, err := fs.OpenSiaFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := fs.OpenSiaFile(barfoo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := sf.UID()
+	sf.Close()
+	// Replacing a non-existent file should fail.
+	if err := fs.ReplaceFile(barfoo, newSiaPath("foobar")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got:", err)
+	}
+	// Replace the file.
+	if err := fs.ReplaceFile(barfoo, foo); err != nil {
+		t.Fatal(err)
+	}
+	// The open instance of the replaced file should be deleted.
+	if !replaced.Deleted() {
+		t.Fatal("replaced file should be deleted")
+	}
+	replaced.Close()
+	// Check that the file was moved.
+	if _, err := fs.OpenSiaFile(barfoo); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got:", err)
+	}
+	sf, err = fs.OpenSiaFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.UID() != uid {
+		t.Fatal("file wasn't replaced", sf.UID(), uid)
+	}
+	sf.Close()
+	// Nothing should be left in memory.
+	if len(fs.files) != 0 || len(fs.directories) != 0 {
+		t.Fatal("expected nothing in memory", len(fs.files), len(fs.directories))
+	}
+}
+
 // TestThreadedAccess tests rapidly opening and closing files and directories
 // from multiple threads to check the locking conventions.
 func TestThreadedAccess(t *testing.T) {
@@ -2319,3 +2376,192 @@ func TestList(t *testing.T) {
 		t.Fatal("wrong number of dirs", len(dis), len(dirStructure))
 	}
 }
+
+// TestDirPolicy tests that the policies of siadirs are inherited by their
+// subdirs.
+func TestDirPolicy(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Create dir /archive/photos.
+	archive := newSiaPath("archive")
+	photos := newSiaPath("archive/photos")
+	if err := fs.NewSiaDir(photos, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a policy for the root and the archive.
+	host := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	rootPolicy := modules.DirPolicy{
+		CipherType: "threefish512",
+		Hosts:      []types.SiaPublicKey{host},
+	}
+	if err := fs.SetDirPolicy(modules.RootSiaPath(), rootPolicy); err != nil {
+		t.Fatal(err)
+	}
+	archivePolicy := modules.DirPolicy{
+		DataPieces:   10,
+		ParityPieces: 50,
+		CipherType:   "XChaCha20",
+	}
+	if err := fs.SetDirPolicy(archive, archivePolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	// The DirectoryInfo should only contain the dir's own policy.
+	di, err := fs.DirInfo(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(di.Policy, archivePolicy) {
+		t.Fatal("wrong policy in DirectoryInfo", di.Policy)
+	}
+
+	// The photos dir and non-existent subdirs should inherit from both the
+	// archive and the root.
+	expected := modules.DirPolicy{
+		DataPieces:   10,
+		ParityPieces: 50,
+		CipherType:   "XChaCha20",
+		Hosts:        []types.SiaPublicKey{host},
+	}
+	for _, sp := range []modules.SiaPath{archive, photos, newSiaPath("archive/photos/2020/01")} {
+		policy, err := fs.DirPolicy(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(policy, expected) {
+			t.Fatalf("wrong policy for %v: %v", sp, policy)
+		}
+	}
+
+	// Other dirs only inherit from the root.
+	policy, err := fs.DirPolicy(newSiaPath("hot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, rootPolicy) {
+		t.Fatal("wrong policy", policy)
+	}
+
+	// Renaming the photos dir out of the archive should change its policy.
+	moved := newSiaPath("photos")
+	if err := fs.RenameDir(photos, moved); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.DirPolicy(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, rootPolicy) {
+		t.Fatal("wrong policy", policy)
+	}
+}
+
+// TestDirPolicyCache tests that cached policies are invalidated when the
+// policy of a siadir changes.
+func TestDirPolicyCache(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Create dir /archive and resolve its policy to populate the cache.
+	archive := newSiaPath("archive")
+	if err := fs.NewSiaDir(archive, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, modules.DirPolicy{}) {
+		t.Fatal("expected empty policy", policy)
+	}
+	if _, cached := fs.policyCache[archive]; !cached {
+		t.Fatal("policy wasn't cached")
+	}
+
+	// Setting the root's policy should be reflected in the archive.
+	rootPolicy := modules.DirPolicy{CipherType: "threefish512"}
+	if err := fs.SetDirPolicy(modules.RootSiaPath(), rootPolicy); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, rootPolicy) {
+		t.Fatal("wrong policy", policy)
+	}
+
+	// Updating the metadata of the root should be reflected as well.
+	rootDir, err := fs.OpenSiaDir(modules.RootSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := rootDir.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rootDir.Close(); err != nil {
+		t.Fatal(err)
+	}
+	md.Policy = modules.DirPolicy{CipherType: "XChaCha20"}
+	if err := fs.UpdateDirMetadata(modules.RootSiaPath(), md); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, md.Policy) {
+		t.Fatal("wrong policy", policy)
+	}
+
+	// Modifying a returned policy shouldn't modify the cache.
+	hostsPolicy := modules.DirPolicy{
+		Hosts: []types.SiaPublicKey{{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}},
+	}
+	if err := fs.SetDirPolicy(archive, hostsPolicy); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.Hosts[0].Key = nil
+	policy, err = fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy.Hosts, hostsPolicy.Hosts) {
+		t.Fatal("cached policy was modified", policy.Hosts)
+	}
+
+	// Deleting the archive should drop its policy.
+	if err := fs.DeleteDir(archive); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.DirPolicy(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, md.Policy) {
+		t.Fatal("wrong policy", policy)
+	}
+}
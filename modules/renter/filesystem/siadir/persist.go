@@ -142,6 +142,15 @@ func (sd *SiaDir) SetPath(targetPath string) error {
 	return nil
 }
 
+// SetPolicy sets the policy of the SiaDir and saves the change to disk.
+func (sd *SiaDir) SetPolicy(policy modules.DirPolicy) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Policy = policy
+	return sd.updateMetadata(md)
+}
+
 // UpdateBubbledMetadata updates the SiaDir Metadata that is bubbled and saves
 // the changes to disk. For fields that are not bubbled, this method sets them
 // to the current values in the SiaDir metadata
@@ -149,6 +158,7 @@ func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.Policy = sd.metadata.Policy
 	metadata.Version = sd.metadata.Version
 	return sd.updateMetadata(metadata)
 }
@@ -219,6 +229,7 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.NumFiles = metadata.NumFiles
 	sd.metadata.NumStuckChunks = metadata.NumStuckChunks
 	sd.metadata.NumSubDirs = metadata.NumSubDirs
+	sd.metadata.Policy = metadata.Policy
 	sd.metadata.RemoteHealth = metadata.RemoteHealth
 	sd.metadata.RepairSize = metadata.RepairSize
 	sd.metadata.Size = metadata.Size
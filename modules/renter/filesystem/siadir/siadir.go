@@ -50,6 +50,9 @@ type (
 		//
 		// NumSubDirs is the number of sub-siadirs in a siadir
 		//
+		// Policy is the upload policy of the siadir. It is not an aggregate
+		// value and isn't updated by bubble
+		//
 		// Size is the total amount of data stored in the siafiles of the siadir
 		//
 		// StuckHealth is the health of the most in need siafile in the siadir,
@@ -73,19 +76,20 @@ type (
 
 		// The following fields are information specific to the siadir that is not
 		// an aggregate of the entire sub directory tree
-		Health              float64     `json:"health"`
-		LastHealthCheckTime time.Time   `json:"lasthealthchecktime"`
-		MinRedundancy       float64     `json:"minredundancy"`
-		Mode                os.FileMode `json:"mode"`
-		ModTime             time.Time   `json:"modtime"`
-		NumFiles            uint64      `json:"numfiles"`
-		NumStuckChunks      uint64      `json:"numstuckchunks"`
-		NumSubDirs          uint64      `json:"numsubdirs"`
-		RemoteHealth        float64     `json:"remotehealth"`
-		RepairSize          uint64      `json:"repairsize"`
-		Size                uint64      `json:"size"`
-		StuckHealth         float64     `json:"stuckhealth"`
-		StuckSize           uint64      `json:"stucksize"`
+		Health              float64           `json:"health"`
+		LastHealthCheckTime time.Time         `json:"lasthealthchecktime"`
+		MinRedundancy       float64           `json:"minredundancy"`
+		Mode                os.FileMode       `json:"mode"`
+		ModTime             time.Time         `json:"modtime"`
+		NumFiles            uint64            `json:"numfiles"`
+		NumStuckChunks      uint64            `json:"numstuckchunks"`
+		NumSubDirs          uint64            `json:"numsubdirs"`
+		Policy              modules.DirPolicy `json:"policy"`
+		RemoteHealth        float64           `json:"remotehealth"`
+		RepairSize          uint64            `json:"repairsize"`
+		Size                uint64            `json:"size"`
+		StuckHealth         float64           `json:"stuckhealth"`
+		StuckSize           uint64            `json:"stucksize"`
 
 		// Version is the used version of the header file.
 		Version string `json:"version"`
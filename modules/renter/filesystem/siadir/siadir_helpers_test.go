@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 )
 
 // checkMetadataInit is a helper that verifies that the metadata was initialized
@@ -106,6 +109,9 @@ func equalMetadatas(md, md2 Metadata) error {
 	if md.NumSubDirs != md2.NumSubDirs {
 		return fmt.Errorf("NumSubDirs not equal, %v and %v", md.NumSubDirs, md2.NumSubDirs)
 	}
+	if !reflect.DeepEqual(md.Policy, md2.Policy) {
+		return fmt.Errorf("Policy not equal, %v and %v", md.Policy, md2.Policy)
+	}
 	if md.RemoteHealth != md2.RemoteHealth {
 		return fmt.Errorf("RemoteHealth not equal, %v and %v", md.RemoteHealth, md2.RemoteHealth)
 	}
@@ -158,6 +164,7 @@ func randomMetadata() Metadata {
 		NumFiles:            fastrand.Uint64n(100),
 		NumStuckChunks:      fastrand.Uint64n(100),
 		NumSubDirs:          fastrand.Uint64n(100),
+		Policy:              randomPolicy(),
 		RemoteHealth:        float64(fastrand.Intn(100)),
 		RepairSize:          fastrand.Uint64n(100),
 		Size:                fastrand.Uint64n(100),
@@ -167,6 +174,19 @@ func randomMetadata() Metadata {
 	return md
 }
 
+// randomPolicy returns a siadir policy with random values set
+func randomPolicy() modules.DirPolicy {
+	return modules.DirPolicy{
+		DataPieces:   fastrand.Intn(10) + 1,
+		ParityPieces: fastrand.Intn(10) + 1,
+		CipherType:   "threefish512",
+		Hosts: []types.SiaPublicKey{{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}},
+	}
+}
+
 // newSiaDirTestDir creates a test directory for a siadir test
 func newSiaDirTestDir(testDir string) (string, error) {
 	rootPath := filepath.Join(os.TempDir(), "siadirs", testDir)
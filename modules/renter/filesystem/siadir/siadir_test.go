@@ -3,6 +3,7 @@ package siadir
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -19,6 +20,7 @@ func TestSiaDir(t *testing.T) {
 
 	t.Run("Basic", testSiaDirBasic)
 	t.Run("Delete", testSiaDirDelete)
+	t.Run("Policy", testSiaDirPolicy)
 	t.Run("UpdatedMetadata", testUpdateMetadata)
 }
 
@@ -237,3 +239,44 @@ func testUpdateMetadata(t *testing.T) {
 
 	// TODO Add checks for other update metadata methods
 }
+
+// testSiaDirPolicy probes setting the policy of a SiaDir.
+func testSiaDirPolicy(t *testing.T) {
+	siaDir, err := newTestDir(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set the policy and check that it was persisted.
+	policy := randomPolicy()
+	if err := siaDir.SetPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	loadedDir, err := LoadSiaDir(siaDir.Path(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loadedDir.Metadata().Policy, policy) {
+		t.Fatal("policy wasn't persisted", loadedDir.Metadata().Policy, policy)
+	}
+
+	// Bubbling the metadata shouldn't change the policy.
+	if err := siaDir.UpdateBubbledMetadata(randomMetadata()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(siaDir.Metadata().Policy, policy) {
+		t.Fatal("policy was changed by bubble", siaDir.Metadata().Policy, policy)
+	}
+
+	// Clear the policy.
+	if err := siaDir.SetPolicy(modules.DirPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	loadedDir, err = LoadSiaDir(siaDir.Path(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loadedDir.Metadata().Policy, modules.DirPolicy{}) {
+		t.Fatal("policy wasn't cleared", loadedDir.Metadata().Policy)
+	}
+}
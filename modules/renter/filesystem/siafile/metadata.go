@@ -60,6 +60,10 @@ type (
 		PartialChunks       []PartialChunkInfo `json:"partialchunks"`       // information about the partial chunk.
 		HasPartialChunk     bool               `json:"haspartialchunk"`     // indicates whether this file is supposed to have a partial chunk or not
 
+		// Packed is set for small files whose data is stored within the shared
+		// sectors of a pack file instead of chunks of their own.
+		Packed *PackedFileInfo `json:"packed,omitempty"`
+
 		// The following fields are the usual unix timestamps of files.
 		ModTime    time.Time `json:"modtime"`    // time of last content modification
 		ChangeTime time.Time `json:"changetime"` // time of last metadata modification
@@ -288,6 +292,20 @@ func (sf *SiaFile) Rename(newSiaFilePath string) error {
 	return sf.rename(newSiaFilePath)
 }
 
+// Replace moves the file to the location of old, replacing it. old is marked
+// as deleted. Like Rename, this is atomic since both files are updated within
+// a single wal transaction.
+func (sf *SiaFile) Replace(old *SiaFile) error {
+	if sf == old {
+		return errors.New("can't replace siafile with itself")
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	return sf.replace(old)
+}
+
 // backup creates a deep-copy of a Metadata.
 func (md Metadata) backup() (b Metadata) {
 	// Copy the static fields first. They are shallow copies since they are not
@@ -346,6 +364,10 @@ func (md Metadata) backup() (b Metadata) {
 		b.PartialChunks = make([]PartialChunkInfo, len(md.PartialChunks), cap(md.PartialChunks))
 		copy(b.PartialChunks, md.PartialChunks)
 	}
+	if md.Packed != nil {
+		packed := *md.Packed
+		b.Packed = &packed
+	}
 	// If the backup was successful it should match the original.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -363,6 +385,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
+	md.Packed = b.Packed
 	md.ModTime = b.ModTime
 	md.ChangeTime = b.ChangeTime
 	md.AccessTime = b.AccessTime
@@ -436,11 +459,23 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 	if _, err := os.Stat(newSiaFilePath); err == nil {
 		return ErrPathOverload
 	}
+	// Create the updates which move the file.
+	updates, err := sf.moveUpdates(newSiaFilePath)
+	if err != nil {
+		return err
+	}
+	// Apply updates.
+	return createAndApplyTransaction(sf.wal, updates...)
+}
+
+// moveUpdates changes the path of the file in memory and returns the updates
+// which delete the file at the old path and write it to the new one.
+func (sf *SiaFile) moveUpdates(newSiaFilePath string) ([]writeaheadlog.Update, error) {
 	// Create path to renamed location.
 	dir, _ := filepath.Split(newSiaFilePath)
-	err = os.MkdirAll(dir, 0700)
+	err := os.MkdirAll(dir, 0700)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Create the delete update before changing the path to the new one.
 	updates := []writeaheadlog.Update{sf.createDeleteUpdate()}
@@ -454,7 +489,7 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Rename file in memory.
 	sf.siaFilePath = newSiaFilePath
@@ -463,15 +498,47 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 	// Write the header to the new location.
 	headerUpdate, err := sf.saveHeaderUpdates()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	updates = append(updates, headerUpdate...)
 	// Write the chunks to the new location.
 	for _, chunk := range chunks {
 		updates = append(updates, sf.saveChunkUpdate(chunk))
 	}
-	// Apply updates.
-	return createAndApplyTransaction(sf.wal, updates...)
+	return updates, nil
+}
+
+// replace moves the file to the location of old and marks old as deleted.
+// Deleting old and moving the file happen within the same wal transaction.
+func (sf *SiaFile) replace(old *SiaFile) (err error) {
+	if sf.deleted {
+		return errors.New("can't move deleted siafile")
+	}
+	if old.deleted {
+		return errors.AddContext(ErrDeleted, "can't replace deleted siafile")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPath := sf.siaFilePath
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.siaFilePath = oldPath
+		}
+	}(sf.staticMetadata.backup())
+	// Delete the old file before moving the file to its location.
+	updates := []writeaheadlog.Update{old.createDeleteUpdate()}
+	moveUpdates, err := sf.moveUpdates(old.siaFilePath)
+	if err != nil {
+		return err
+	}
+	updates = append(updates, moveUpdates...)
+	err = createAndApplyTransaction(sf.wal, updates...)
+	if err != nil {
+		return err
+	}
+	old.deleted = true
+	return nil
 }
 
 // SetMode sets the filemode of the sia file.
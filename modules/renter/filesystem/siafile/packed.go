@@ -0,0 +1,94 @@
+package siafile
+
+import (
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// ErrNotPacked is returned when a method which is only meant to be called
+	// on packed files is called on a regular file.
+	ErrNotPacked = errors.New("siafile is not packed")
+)
+
+type (
+	// PackedFileInfo contains the information about where the data of a
+	// packed file is stored. Packed files don't have any chunks of their own.
+	// Instead, their data is stored together with the data of other small
+	// files within the sectors of a shared pack file.
+	PackedFileInfo struct {
+		Pack         modules.SiaPath `json:"pack"`         // siapath of the pack
+		SectorIndex  uint64          `json:"sectorindex"`  // index of the sector within the pack
+		SectorOffset uint64          `json:"sectoroffset"` // offset of the file within the sector
+	}
+)
+
+// Offset returns the offset of the packed file within its pack.
+func (pfi PackedFileInfo) Offset() uint64 {
+	return pfi.SectorIndex*modules.SectorSize + pfi.SectorOffset
+}
+
+// NewPacked creates a new packed SiaFile of the given size. The data of the
+// file is stored within the pack described by info.
+func NewPacked(siaFilePath, source string, wal *writeaheadlog.WAL, erasureCode modules.ErasureCoder, masterKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode, info PackedFileInfo) (*SiaFile, error) {
+	if fileSize > modules.SectorSize {
+		return nil, modules.ErrSizeTooLarge
+	}
+	// Create a file without chunks first.
+	sf, err := New(siaFilePath, source, wal, erasureCode, masterKey, 0, fileMode, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	sf.mu.Lock()
+	sf.staticMetadata.FileSize = int64(fileSize)
+	sf.staticMetadata.Packed = &info
+	sf.mu.Unlock()
+	return sf, sf.SaveMetadata()
+}
+
+// Packed returns the information about where the data of a packed file is
+// stored. The second return value is false for regular files.
+func (sf *SiaFile) Packed() (PackedFileInfo, bool) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.staticMetadata.Packed == nil {
+		return PackedFileInfo{}, false
+	}
+	return *sf.staticMetadata.Packed, true
+}
+
+// UpdatePackedCache updates the cached health, redundancy, expiration and
+// upload progress of a packed file using the metadata of its pack. The
+// uploaded bytes of the pack are attributed to the file proportionally to its
+// size. Repair and stuck bytes are left at 0 since they are accounted for by
+// the pack.
+func (sf *SiaFile) UpdatePackedCache(pack Metadata) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't update cache of deleted file")
+	}
+	if sf.staticMetadata.Packed == nil {
+		return ErrNotPacked
+	}
+	md := &sf.staticMetadata
+	md.CachedHealth = pack.CachedHealth
+	md.CachedStuckHealth = pack.CachedStuckHealth
+	md.CachedRedundancy = pack.CachedRedundancy
+	md.CachedUserRedundancy = pack.CachedUserRedundancy
+	md.CachedExpiration = pack.CachedExpiration
+	md.CachedUploadProgress = pack.CachedUploadProgress
+	md.CachedUploadedBytes = 0
+	if pack.FileSize > 0 {
+		md.CachedUploadedBytes = uint64(float64(pack.CachedUploadedBytes) * float64(md.FileSize) / float64(pack.FileSize))
+	}
+	md.CachedNumStuckChunks = 0
+	md.CachedRepairBytes = 0
+	md.CachedStuckBytes = 0
+	return nil
+}
@@ -0,0 +1,97 @@
+package siafile
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestPackedSiaFile probes creating, reloading and updating the cache of a
+// packed siafile.
+func TestPackedSiaFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a pack and a packed file which is stored within its second
+	// sector.
+	pack := newTestFile()
+	siaFilePath, _, source, rc, sk, _, _, fileMode := newTestFileParams(1, false)
+	wal, _ := newTestWAL()
+	info := PackedFileInfo{
+		Pack:         modules.RandomSiaPath(),
+		SectorIndex:  1,
+		SectorOffset: 4096,
+	}
+	if info.Offset() != modules.SectorSize+4096 {
+		t.Fatal("wrong offset", info.Offset())
+	}
+	if _, err := NewPacked(siaFilePath, source, wal, rc, sk, modules.SectorSize+1, fileMode, info); err == nil {
+		t.Fatal("shouldn't be able to pack a file larger than a sector")
+	}
+	sf, err := NewPacked(siaFilePath, source, wal, rc, sk, 100, fileMode, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, packed := pack.Packed(); packed {
+		t.Fatal("pack shouldn't be packed")
+	}
+
+	// The file shouldn't have any chunks, even after reloading it.
+	check := func(sf *SiaFile) {
+		t.Helper()
+		if sf.NumChunks() != 0 {
+			t.Fatal("packed file shouldn't have chunks", sf.NumChunks())
+		}
+		if sf.Size() != 100 {
+			t.Fatal("wrong size", sf.Size())
+		}
+		packed, ok := sf.Packed()
+		if !ok || !packed.Pack.Equals(info.Pack) || packed.Offset() != info.Offset() {
+			t.Fatal("wrong packed info", packed, ok)
+		}
+	}
+	check(sf)
+	sf, err = LoadSiaFile(siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(sf)
+	if err := sf.GrowNumChunks(1); err == nil {
+		t.Fatal("shouldn't be able to grow a packed file")
+	}
+
+	// Health, redundancy and upload progress are copied from the pack.
+	offline, goodForRenew := make(map[string]bool), make(map[string]bool)
+	for _, pk := range pack.HostPublicKeys() {
+		offline[pk.String()] = false
+		goodForRenew[pk.String()] = true
+	}
+	health, _, _, _, _, _, _ := pack.Health(offline, goodForRenew)
+	redundancy, _, err := pack.Redundancy(offline, goodForRenew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, _, err := pack.UploadProgressAndBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.UpdatePackedCache(pack.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+	if h, _, _, _, nsc, rb, _ := sf.Health(offline, goodForRenew); h != health || nsc != 0 || rb != 0 {
+		t.Fatal("wrong health", h, health, nsc, rb)
+	}
+	if r, _, err := sf.Redundancy(offline, goodForRenew); err != nil || r != redundancy {
+		t.Fatal("wrong redundancy", r, redundancy, err)
+	}
+	if p, uploaded, err := sf.UploadProgressAndBytes(); err != nil || p != progress || uploaded > pack.Metadata().CachedUploadedBytes {
+		t.Fatal("wrong upload progress", p, progress, uploaded, err)
+	}
+
+	// Regular files can't copy the cache of a pack.
+	if err := pack.UpdatePackedCache(sf.Metadata()); err != ErrNotPacked {
+		t.Fatal("expected ErrNotPacked but got", err)
+	}
+}
@@ -309,6 +309,10 @@ func loadSiaFileFromReader(r io.ReadSeeker, path string, wal *writeaheadlog.WAL,
 	if len(sf.staticMetadata.PartialChunks) > 0 {
 		sf.numChunks = sf.numChunks - 1 + len(sf.staticMetadata.PartialChunks)
 	}
+	// Packed files don't have chunks of their own.
+	if sf.staticMetadata.Packed != nil {
+		sf.numChunks = 0
+	}
 	return sf, nil
 }
 
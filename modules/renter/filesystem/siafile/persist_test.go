@@ -476,6 +476,60 @@ func TestRename(t *testing.T) {
 	}
 }
 
+// TestReplace tests if replacing a siafile with another one moves the file
+// correctly and marks the replaced file as deleted.
+func TestReplace(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create the file to replace and the file which replaces it.
+	old := newTestFile()
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	oldSiaFilePath := old.SiaFilePath()
+	siaFilePath := sf.SiaFilePath()
+
+	// A file can't replace itself.
+	if err := sf.Replace(sf); err == nil {
+		t.Fatal("file shouldn't be able to replace itself")
+	}
+
+	// Replace the file.
+	if err := sf.Replace(old); err != nil {
+		t.Fatal("Failed to replace file", err)
+	}
+
+	// The replacing file should be gone from its old location.
+	if _, err := os.Open(siaFilePath); !os.IsNotExist(err) {
+		t.Fatal("Expected a file doesn't exist error but got", err)
+	}
+	if sf.SiaFilePath() != oldSiaFilePath {
+		t.Fatal("SiaFilePath wasn't updated correctly", sf.SiaFilePath(), oldSiaFilePath)
+	}
+	if !old.Deleted() {
+		t.Fatal("replaced file should be marked as deleted")
+	}
+
+	// Load the file at the old location. It should be the replacing file.
+	loaded, err := LoadSiaFile(oldSiaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UID() != sf.UID() {
+		t.Fatal("wrong file at replaced location", loaded.UID(), sf.UID())
+	}
+	if loaded.ErasureCode().Identifier() != sf.ErasureCode().Identifier() {
+		t.Fatal("wrong erasure code")
+	}
+
+	// The file can't replace a deleted file.
+	other := newBlankTestFile()
+	if err := other.Replace(old); !errors.Contains(err, ErrDeleted) {
+		t.Fatal("expected ErrDeleted but got", err)
+	}
+}
+
 // TestApplyUpdates tests a variety of functions that are used to apply
 // updates.
 func TestApplyUpdates(t *testing.T) {
@@ -93,9 +93,15 @@ type (
 
 	// Chunk is an exported chunk. It contains exported pieces.
 	Chunk struct {
-		Pieces [][]Piece
+		DedupID DedupID
+		Pieces  [][]Piece
 	}
 
+	// DedupID identifies the content of a deduplicated chunk. It is stored in
+	// the ExtensionInfo of the chunk. Chunks which are not deduplicated have an
+	// empty DedupID.
+	DedupID [16]byte
+
 	// piece represents a single piece of a chunk on disk
 	piece struct {
 		HostTableOffset uint32      // offset of the host's key within the pubKeyTable
@@ -593,6 +599,10 @@ func (sf *SiaFile) SaveMetadata() (err error) {
 func (sf *SiaFile) Expiration(contracts map[string]modules.RenterContract) types.BlockHeight {
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
+	// The expiration of packed files is copied from their pack.
+	if sf.staticMetadata.Packed != nil {
+		return sf.staticMetadata.CachedExpiration
+	}
 	if len(sf.pubKeyTable) == 0 {
 		sf.staticMetadata.CachedExpiration = 0
 		return 0
@@ -670,6 +680,10 @@ func (sf *SiaFile) Health(offline map[string]bool, goodForRenew map[string]bool)
 		// misrepresenting the health information of a directory
 		return 0, 0, 0, 0, 0, 0, 0
 	}
+	// The health of packed files is copied from their pack.
+	if md := sf.staticMetadata; md.Packed != nil {
+		return md.CachedHealth, md.CachedStuckHealth, md.CachedHealth, md.CachedStuckHealth, 0, 0, 0
+	}
 	// Check for Zero byte files
 	if sf.staticMetadata.FileSize == 0 {
 		// Return default health information for zero byte files to prevent
@@ -854,6 +868,10 @@ func (sf *SiaFile) Redundancy(offlineMap map[string]bool, goodForRenewMap map[st
 		sf.staticMetadata.CachedRedundancy = r
 		sf.staticMetadata.CachedUserRedundancy = ur
 	}()
+	// The redundancy of packed files is copied from their pack.
+	if md := sf.staticMetadata; md.Packed != nil {
+		return md.CachedRedundancy, md.CachedUserRedundancy, nil
+	}
 	if sf.staticMetadata.FileSize == 0 {
 		// TODO change this once tiny files are supported.
 		if sf.numChunks != 1 {
@@ -1036,6 +1054,49 @@ func (sf *SiaFile) StuckChunkByIndex(index uint64) (bool, error) {
 	return chunk.Stuck, nil
 }
 
+// DedupID returns the DedupID of the chunk at the index. It is empty if the
+// chunk is not deduplicated.
+func (sf *SiaFile) DedupID(index uint64) (DedupID, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return DedupID{}, nil
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return DedupID{}, errors.AddContext(err, "failed to read chunk")
+	}
+	return DedupID(chunk.ExtensionInfo), nil
+}
+
+// SetDedupID sets the DedupID of the chunk at the index.
+func (sf *SiaFile) SetDedupID(index uint64, id DedupID) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// Partial chunks can't be deduplicated.
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return errors.New("can't set DedupID of partial chunk")
+	}
+	// If the file has been deleted we can't change a chunk.
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't call SetDedupID on deleted file")
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return err
+	}
+	if DedupID(chunk.ExtensionInfo) == id {
+		return nil
+	}
+	chunk.ExtensionInfo = id
+	return sf.createAndApplyTransaction(sf.saveChunkUpdate(chunk))
+}
+
+// IsEmpty returns true if the DedupID is empty.
+func (id DedupID) IsEmpty() bool {
+	return id == DedupID{}
+}
+
 // UID returns a unique identifier for this file.
 func (sf *SiaFile) UID() SiafileUID {
 	sf.mu.RLock()
@@ -1313,6 +1374,10 @@ func (sf *SiaFile) growNumChunks(numChunks uint64) (updates []writeaheadlog.Upda
 	if sf.deleted {
 		return nil, errors.AddContext(ErrDeleted, "can't grow number of chunks of deleted file")
 	}
+	// Packed files don't have chunks of their own.
+	if sf.staticMetadata.Packed != nil {
+		return nil, errors.New("can't grow a packed siafile")
+	}
 	// Don't allow a SiaFile with a partial chunk to grow.
 	if sf.staticMetadata.HasPartialChunk {
 		return nil, errors.New("can't grow a siafile with a partial chunk")
@@ -1443,6 +1508,10 @@ func (sf *SiaFile) setStuck(index uint64, stuck bool) (err error) {
 // bytes have been uploaded of that file in total. Note that a file may be
 // Available long before UploadProgress reaches 100%.
 func (sf *SiaFile) uploadProgressAndBytes() (float64, uint64, error) {
+	// The upload progress of packed files is copied from their pack.
+	if md := sf.staticMetadata; md.Packed != nil {
+		return md.CachedUploadProgress, md.CachedUploadedBytes, nil
+	}
 	_, uploaded, err := sf.uploadedBytes()
 	if err != nil {
 		return 0, 0, err
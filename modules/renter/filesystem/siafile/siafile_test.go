@@ -1161,6 +1161,64 @@ func TestStuckChunks(t *testing.T) {
 	}
 }
 
+// TestDedupID tests setting and getting the DedupID of chunks.
+func TestDedupID(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create siafile
+	sf := newTestFile()
+
+	// Set a DedupID for every full chunk.
+	ids := make(map[uint64]DedupID)
+	for chunkIndex := uint64(0); chunkIndex < sf.NumChunks(); chunkIndex++ {
+		if _, ok := sf.isIncludedPartialChunk(chunkIndex); ok || sf.isIncompletePartialChunk(chunkIndex) {
+			if err := sf.SetDedupID(chunkIndex, DedupID{1}); err == nil {
+				t.Fatal("shouldn't be able to set DedupID of partial chunk")
+			}
+			continue
+		}
+		var id DedupID
+		fastrand.Read(id[:])
+		if err := sf.SetDedupID(chunkIndex, id); err != nil {
+			t.Fatal(err)
+		}
+		ids[chunkIndex] = id
+	}
+	if len(ids) == 0 {
+		t.Fatal("no chunks were deduplicated")
+	}
+
+	// Check the ids after reloading the file and in a snapshot.
+	sf, err := LoadSiaFile(sf.SiaFilePath(), sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := sf.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chunkIndex, expected := range ids {
+		id, err := sf.DedupID(chunkIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected || id.IsEmpty() {
+			t.Fatal("wrong id", id, expected)
+		}
+		if snap.DedupID(chunkIndex) != expected {
+			t.Fatal("wrong id in snapshot", snap.DedupID(chunkIndex), expected)
+		}
+	}
+
+	// The pieces of the chunks shouldn't be affected.
+	if err := ensureMetadataValid(sf.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestUploadedBytes tests that uploadedBytes() returns the expected values for
 // total and unique uploaded bytes.
 func TestUploadedBytes(t *testing.T) {
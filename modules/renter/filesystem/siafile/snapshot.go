@@ -174,6 +174,12 @@ func (s *Snapshot) Pieces(chunkIndex uint64) [][]Piece {
 	return s.staticChunks[chunkIndex].Pieces
 }
 
+// DedupID returns the DedupID of a chunk. It is empty if the chunk is not
+// deduplicated.
+func (s *Snapshot) DedupID(chunkIndex uint64) DedupID {
+	return s.staticChunks[chunkIndex].DedupID
+}
+
 // PieceSize returns the size of a single piece of the file.
 func (s *Snapshot) PieceSize() uint64 {
 	return s.staticPieceSize
@@ -270,7 +276,8 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 			}
 		}
 		exportedChunks = append(exportedChunks, Chunk{
-			Pieces: pieces,
+			DedupID: DedupID(chunk.ExtensionInfo),
+			Pieces:  pieces,
 		})
 	}
 	// Get non-static metadata fields under lock.
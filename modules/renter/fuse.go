@@ -3,9 +3,12 @@
 package renter
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"math"
+	"os"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -13,10 +16,18 @@ import (
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 )
 
+// fuseRenameNoReplace is the flag passed to Rename by renameat2 if the
+// target must not be replaced.
+const fuseRenameNoReplace = 0x1
+
+// errFuseIsDir is returned if a file operation targets a directory.
+var errFuseIsDir = errors.New("target is a directory")
+
 // fuseDirnode is a fuse node for the fs package that covers a siadir.
 //
 // NOTE: The fuseDirnode is _very_hot_ in that it gets hit rapidly and
@@ -38,6 +49,8 @@ type fuseDirnode struct {
 // NodeAccesser is necessary for telling certain programs that it is okay to
 // access the file.
 //
+// NodeCreater is necessary for creating files in read-write mounts.
+//
 // NodeFlusher is necessary for cleaning up resources such as the filesystem
 // node.
 //
@@ -46,29 +59,54 @@ type fuseDirnode struct {
 //
 // NodeLookuper is necessary to have files added to the filesystem tree.
 //
+// NodeMkdirer is necessary for creating directories in read-write mounts.
+//
 // NodeReaddirer is necessary to list the files in a directory.
 //
+// NodeRenamer is necessary for renaming files and directories in read-write
+// mounts.
+//
+// NodeRmdirer is necessary for deleting directories in read-write mounts.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the directory.
+//
+// NodeUnlinker is necessary for deleting files in read-write mounts.
 var _ = (fs.NodeAccesser)((*fuseDirnode)(nil))
+var _ = (fs.NodeCreater)((*fuseDirnode)(nil))
 var _ = (fs.NodeFlusher)((*fuseDirnode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseDirnode)(nil))
 var _ = (fs.NodeLookuper)((*fuseDirnode)(nil))
+var _ = (fs.NodeMkdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeReaddirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRenamer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRmdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeStatfser)((*fuseDirnode)(nil))
+var _ = (fs.NodeUnlinker)((*fuseDirnode)(nil))
 
 // fuseFilenode is a fuse node for the fs package that covers a siafile.
 //
 // Data is fetched using a download streamer. This download streamer needs to be
-// closed when the filehandle is released.
+// closed when the filehandle is released. Files which are opened for writing
+// use a fuseWriteHandle instead.
 type fuseFilenode struct {
 	atomicClosed uint32
 
+	// atomicTruncated is set once the file was truncated. The siafile was
+	// replaced by an empty one in that case.
+	atomicTruncated uint32
+
 	fs.Inode
 	staticFilesystem *fuseFS
 	staticFileNode   *filesystem.FileNode
 	stream           modules.Streamer
 	mu               sync.Mutex
+
+	// writeHandles are the handles which currently have the file open for
+	// writing. They are protected by a separate mutex since mu is held
+	// during reads.
+	writeHandles map[*fuseWriteHandle]struct{}
+	writeMu      sync.Mutex
 }
 
 // Ensure the file nodes satisfy the required interfaces.
@@ -81,10 +119,13 @@ type fuseFilenode struct {
 //
 // NodeGetattrer is necessary for providing the filesize to file browsers.
 //
-// NodeOpener is necessary for opening files to be read.
+// NodeOpener is necessary for opening files to be read or written.
 //
 // NodeReader is necessary for reading files.
 //
+// NodeSetattrer is necessary for programs which truncate files or set their
+// timestamps after writing them.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the file.
 var _ = (fs.NodeAccesser)((*fuseFilenode)(nil))
@@ -92,6 +133,7 @@ var _ = (fs.NodeFlusher)((*fuseFilenode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeOpener)((*fuseFilenode)(nil))
 var _ = (fs.NodeReader)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeStatfser)((*fuseFilenode)(nil))
 
 // fuseRoot is the root directory for a mounted fuse filesystem.
@@ -107,8 +149,12 @@ type fuseFS struct {
 func errToStatus(err error) syscall.Errno {
 	if err == nil {
 		return syscall.F_OK
-	} else if errors.IsOSNotExist(err) {
+	} else if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
 		return syscall.ENOENT
+	} else if errors.Contains(err, filesystem.ErrExists) {
+		return syscall.EEXIST
+	} else if errors.Contains(err, errFuseIsDir) {
+		return syscall.EISDIR
 	}
 	return syscall.EIO
 }
@@ -137,11 +183,20 @@ func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Err
 	return errToStatus(err)
 }
 
-// Flush is called when a file is being closed.
+// Flush is called when a file is being closed. If the file was opened for
+// writing, Flush waits for the upload of the file to finish.
 func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
+	var uploadErr error
+	if wh, ok := fh.(*fuseWriteHandle); ok {
+		uploadErr = wh.managedFinish()
+		ffn.writeMu.Lock()
+		delete(ffn.writeHandles, wh)
+		ffn.writeMu.Unlock()
+	}
+
 	swapped := atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1)
 	if !swapped {
-		return errToStatus(nil)
+		return errToStatus(uploadErr)
 	}
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
@@ -156,7 +211,7 @@ func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Er
 
 	// Check all of the errors.
 	closeErr := ffn.staticFileNode.Close()
-	err := errors.Compose(streamErr, closeErr)
+	err := errors.Compose(uploadErr, streamErr, closeErr)
 	if err != nil {
 		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
 		ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, err)
@@ -179,24 +234,7 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 			return nil, errToStatus(err)
 		}
 		// Convert the file to an inode.
-		filenode := &fuseFilenode{
-			staticFilesystem: fdn.staticFilesystem,
-			staticFileNode:   fileNode,
-		}
-		attrs := fs.StableAttr{
-			Ino:  fileInfo.UID,
-			Mode: fuse.S_IFREG,
-		}
-
-		// Set the crticial entry out values.
-		//
-		// TODO: Set more of these, there are like 20 of them.
-		out.Ino = fileInfo.UID
-		out.Size = fileInfo.Filesize
-		out.Mode = uint32(fileInfo.Mode())
-
-		inode := fdn.NewInode(ctx, filenode, attrs)
-		return inode, errToStatus(nil)
+		return fdn.newFileInode(ctx, fileNode, fileInfo, out), errToStatus(nil)
 	}
 
 	childDir, dirErr := fdn.staticDirNode.Dir(name)
@@ -212,8 +250,13 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 	}
 
 	// We found the directory we want, convert to an inode.
+	return fdn.newDirInode(ctx, childDir, dirInfo, out), errToStatus(nil)
+}
+
+// newDirInode creates the inode of a child directory of the directory.
+func (fdn *fuseDirnode) newDirInode(ctx context.Context, dirNode *filesystem.DirNode, dirInfo modules.DirectoryInfo, out *fuse.EntryOut) *fs.Inode {
 	dirnode := &fuseDirnode{
-		staticDirNode:    childDir,
+		staticDirNode:    dirNode,
 		staticFilesystem: fdn.staticFilesystem,
 	}
 	attrs := fs.StableAttr{
@@ -222,8 +265,27 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 	}
 	out.Ino = dirInfo.UID
 	out.Mode = uint32(dirInfo.Mode())
-	inode := fdn.NewInode(ctx, dirnode, attrs)
-	return inode, errToStatus(nil)
+	return fdn.NewInode(ctx, dirnode, attrs)
+}
+
+// newFileInode creates the inode of a file within the directory.
+func (fdn *fuseDirnode) newFileInode(ctx context.Context, fileNode *filesystem.FileNode, fileInfo modules.FileInfo, out *fuse.EntryOut) *fs.Inode {
+	filenode := &fuseFilenode{
+		staticFilesystem: fdn.staticFilesystem,
+		staticFileNode:   fileNode,
+	}
+	attrs := fs.StableAttr{
+		Ino:  fileInfo.UID,
+		Mode: fuse.S_IFREG,
+	}
+
+	// Set the crticial entry out values.
+	//
+	// TODO: Set more of these, there are like 20 of them.
+	out.Ino = fileInfo.UID
+	out.Size = fileInfo.Filesize
+	out.Mode = uint32(fileInfo.Mode())
+	return fdn.NewInode(ctx, filenode, attrs)
 }
 
 // Getattr returns the attributes of a fuse dir.
@@ -252,6 +314,15 @@ func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fus
 	out.Size = fileInfo.Filesize
 	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
 	out.Ino = fileInfo.UID
+	if atomic.LoadUint32(&ffn.atomicTruncated) == 1 {
+		out.Size = 0
+	}
+
+	// If the file is being replaced, report the amount of data written so
+	// far.
+	if wh, ok := fh.(*fuseWriteHandle); ok && wh.managedTruncated() {
+		out.Size = wh.managedSize()
+	}
 	return errToStatus(nil)
 }
 
@@ -261,6 +332,9 @@ func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fus
 // out from the documentation what the flags are supposed to represent. So far,
 // this has not seemed to cause problems.
 func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if flags&syscall.O_ACCMODE != syscall.O_RDONLY {
+		return ffn.managedOpenWrite(flags)
+	}
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
@@ -274,6 +348,29 @@ func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle,
 	return ffn, 0, errToStatus(nil)
 }
 
+// managedOpenWrite opens the file for writing. Since uploaded data can't be
+// modified, the file is replaced by a new upload with the same redundancy.
+// This requires the file to be either empty or truncated.
+func (ffn *fuseFilenode) managedOpenWrite(flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	ffs := ffn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return nil, 0, syscall.EROFS
+	}
+	siaPath := ffs.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+	truncated := flags&syscall.O_TRUNC != 0 || atomic.LoadUint32(&ffn.atomicTruncated) == 1 || ffn.staticFileNode.Size() == 0
+	wh := ffs.newWriteHandle(siaPath, ffn.staticFileNode.ErasureCode(), ffn.staticFileNode.MasterKey().Type(), truncated)
+
+	// Remember the handle. The kernel truncates files opened with O_TRUNC
+	// without passing the handle to Setattr.
+	ffn.writeMu.Lock()
+	if ffn.writeHandles == nil {
+		ffn.writeHandles = make(map[*fuseWriteHandle]struct{})
+	}
+	ffn.writeHandles[wh] = struct{}{}
+	ffn.writeMu.Unlock()
+	return wh, 0, errToStatus(nil)
+}
+
 // Read will read data from the file and place it in dest.
 func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte, offset int64) (fuse.ReadResult, syscall.Errno) {
 	// TODO: Right now only one call to Read from a file can be in effect at
@@ -284,6 +381,11 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
+	// Files which are being written can't be read.
+	if _, ok := f.(*fuseWriteHandle); ok {
+		return nil, syscall.EBADF
+	}
+
 	_, err := ffn.stream.Seek(offset, io.SeekStart)
 	if err != nil {
 		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
@@ -306,6 +408,67 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	return fuse.ReadResultData(dest[:n]), errToStatus(nil)
 }
 
+// Setattr is called when the attributes of a file are changed. Changes to the
+// mode and timestamps of a file are ignored. Changing the size is only
+// supported for truncating a file before writing to it, which is how the
+// kernel handles opening files with O_TRUNC.
+func (ffn *fuseFilenode) Setattr(ctx context.Context, fh fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	ffs := ffn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return syscall.EROFS
+	}
+	if errno := ffn.Getattr(ctx, fh, out); errno != 0 {
+		return errno
+	}
+	size, ok := in.GetSize()
+	if !ok || size == out.Size {
+		return errToStatus(nil)
+	}
+	if size != 0 {
+		return syscall.ENOTSUP
+	}
+	if wh, ok := fh.(*fuseWriteHandle); ok {
+		if err := wh.managedTruncate(); err != nil {
+			return syscall.ENOTSUP
+		}
+		out.Size = 0
+		return errToStatus(nil)
+	}
+
+	// If the file is open for writing, truncating the open handles is enough
+	// since they will replace the file once they are closed.
+	ffn.writeMu.Lock()
+	numHandles := len(ffn.writeHandles)
+	var truncateErr error
+	for wh := range ffn.writeHandles {
+		truncateErr = errors.Compose(truncateErr, wh.managedTruncate())
+	}
+	ffn.writeMu.Unlock()
+	if truncateErr != nil {
+		return syscall.ENOTSUP
+	}
+	if numHandles > 0 {
+		out.Size = 0
+		return errToStatus(nil)
+	}
+
+	// Truncate the file by replacing it with an empty one.
+	siaPath := ffs.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+	err := ffs.renter.UploadStreamFromReader(modules.FileUploadParams{
+		SiaPath:     siaPath,
+		ErasureCode: ffn.staticFileNode.ErasureCode(),
+		Force:       true,
+		CipherType:  ffn.staticFileNode.MasterKey().Type(),
+	}, bytes.NewReader(nil))
+	if err != nil {
+		ffs.renter.log.Printf("Unable to truncate fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	atomic.StoreUint32(&ffn.atomicTruncated, 1)
+	out.Size = 0
+	return errToStatus(nil)
+}
+
 // Readdir will return a dirstream that can be used to look at all of the files
 // in the directory.
 func (fdn *fuseDirnode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
@@ -338,6 +501,198 @@ func (fdn *fuseDirnode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 	return fs.NewListDirStream(dirEntries), errToStatus(nil)
 }
 
+// childSiaPath returns the siapath of the child of the directory with the
+// provided name.
+func (fdn *fuseDirnode) childSiaPath(name string) (modules.SiaPath, error) {
+	return fdn.staticFilesystem.renter.staticFileSystem.DirSiaPath(fdn.staticDirNode).Join(name)
+}
+
+// Create will create a new file in the directory and open it for writing. The
+// data written to the file is streamed to the Sia network.
+func (fdn *fuseDirnode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	ffs := fdn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return nil, nil, 0, syscall.EROFS
+	}
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return nil, nil, 0, syscall.EINVAL
+	}
+
+	// Create the siafile using the policy of the siadir.
+	fileNode, err := ffs.renter.managedInitUploadStream(modules.FileUploadParams{
+		SiaPath: siaPath,
+	})
+	if err != nil {
+		ffs.renter.log.Printf("Unable to create fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	fileInfo, err := ffs.renter.staticFileSystem.FileNodeInfo(fileNode)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to fetch fileinfo on new fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(errors.Compose(err, fileNode.Close()))
+	}
+
+	// Start the upload.
+	wh, err := ffs.managedNewCreateWriteHandle(fileNode.Copy())
+	if err != nil {
+		ffs.renter.log.Printf("Unable to start upload of fuse file %v: %v", siaPath, err)
+		err = errors.Compose(err, fileNode.Close(), ffs.renter.DeleteFile(siaPath))
+		return nil, nil, 0, errToStatus(err)
+	}
+	return fdn.newFileInode(ctx, fileNode, fileInfo, out), wh, 0, errToStatus(nil)
+}
+
+// Mkdir will create a new directory within the directory.
+func (fdn *fuseDirnode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	ffs := fdn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return nil, syscall.EROFS
+	}
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return nil, syscall.EINVAL
+	}
+	err = ffs.renter.CreateDir(siaPath, os.FileMode(mode)&os.ModePerm)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to create fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		return nil, errToStatus(err)
+	}
+	dirInfo, err := ffs.renter.staticFileSystem.DirNodeInfo(childDir)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to fetch info from new fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(errors.Compose(err, childDir.Close()))
+	}
+	return fdn.newDirInode(ctx, childDir, dirInfo, out), errToStatus(nil)
+}
+
+// Rename will rename a file or directory within the directory. Like
+// rename(2), an existing file at the target is replaced unless
+// RENAME_NOREPLACE is set.
+func (fdn *fuseDirnode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	ffs := fdn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return syscall.EROFS
+	}
+	if flags&fs.RENAME_EXCHANGE != 0 {
+		return syscall.ENOTSUP
+	}
+	newDir, ok := newParent.(*fuseDirnode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	oldPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	newPath, err := newDir.childSiaPath(newName)
+	if err != nil {
+		return syscall.EINVAL
+	}
+
+	// Check whether a directory is renamed.
+	fileNode, err := fdn.staticDirNode.File(name)
+	if err != nil {
+		err = ffs.renter.RenameDir(oldPath, newPath)
+		if err != nil {
+			ffs.renter.log.Printf("Unable to rename fuse dir %v to %v: %v", oldPath, newPath, err)
+		}
+		return errToStatus(err)
+	}
+	if err := fileNode.Close(); err != nil {
+		return errToStatus(err)
+	}
+
+	// Move the target aside if it's replaced, so that it can be restored if
+	// the rename fails.
+	var backupPath modules.SiaPath
+	replaced := false
+	if flags&fuseRenameNoReplace == 0 {
+		if dirNode, err := newDir.staticDirNode.Dir(newName); err == nil {
+			return errToStatus(errors.Compose(dirNode.Close(), errFuseIsDir))
+		}
+		backupPath, err = newDir.childSiaPath(fmt.Sprintf(".%v.replaced-%x", newName, fastrand.Bytes(8)))
+		if err != nil {
+			return syscall.EINVAL
+		}
+		err = ffs.renter.RenameFile(newPath, backupPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errToStatus(err)
+		}
+		replaced = err == nil
+	}
+	err = ffs.renter.RenameFile(oldPath, newPath)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to rename fuse file %v to %v: %v", oldPath, newPath, err)
+		if replaced {
+			err = errors.Compose(err, ffs.renter.RenameFile(backupPath, newPath))
+		}
+		return errToStatus(err)
+	}
+	if replaced {
+		err = ffs.renter.DeleteFile(backupPath)
+		if err != nil {
+			ffs.renter.log.Printf("Unable to delete replaced fuse file %v: %v", backupPath, err)
+		}
+	}
+	return syscall.F_OK
+}
+
+// Rmdir will delete an empty directory within the directory.
+func (fdn *fuseDirnode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	ffs := fdn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return syscall.EROFS
+	}
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+
+	// DeleteDir deletes the contents of the directory as well. Make sure that
+	// it is empty first.
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		return errToStatus(err)
+	}
+	fileinfos, dirinfos, err := ffs.renter.staticFileSystem.CachedListOnNode(childDir)
+	err = errors.Compose(err, childDir.Close())
+	if err != nil {
+		return errToStatus(err)
+	}
+	if len(fileinfos) > 0 || len(dirinfos) > 1 {
+		return syscall.ENOTEMPTY
+	}
+	err = ffs.renter.DeleteDir(siaPath)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to delete fuse dir %v: %v", siaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// Unlink will delete a file within the directory.
+func (fdn *fuseDirnode) Unlink(ctx context.Context, name string) syscall.Errno {
+	ffs := fdn.staticFilesystem
+	if ffs.options.ReadOnly {
+		return syscall.EROFS
+	}
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	err = ffs.renter.DeleteFile(siaPath)
+	if errors.Contains(err, filesystem.ErrDeleteFileIsDir) {
+		return syscall.EISDIR
+	} else if err != nil {
+		ffs.renter.log.Printf("Unable to delete fuse file %v: %v", siaPath, err)
+	}
+	return errToStatus(err)
+}
+
 // setStatfsOut is a method that will set the StatfsOut fields which are
 // consistent across the fuse filesystem.
 func (ffs *fuseFS) setStatfsOut(out *fuse.StatfsOut) error {
@@ -4,6 +4,8 @@ package renter
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/hanwen/go-fuse/v2/fs"
@@ -30,6 +32,13 @@ func newFuseManager(r *Renter) renterFuseManager {
 		renter:      r,
 	}
 
+	// Remove the spool files of writes which weren't finished before the
+	// last shutdown.
+	err := os.RemoveAll(filepath.Join(r.persistDir, fuseSpoolDir))
+	if err != nil {
+		r.log.Println("Unable to remove fuse spool dir:", err)
+	}
+
 	// Close the fuse manager on shutdown.
 	r.tg.OnStop(func() error {
 		return fm.managedCloseFuseManager()
@@ -98,11 +107,6 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.SiaPath, opts modules
 		}
 	}()
 
-	// Enforce read-only requirements for now.
-	if !opts.ReadOnly {
-		return errors.New("fuse must be mounted as read-only")
-	}
-
 	// Get the mountpoint's root from the filesystem.
 	rootDirNode, err := fm.renter.staticFileSystem.OpenSiaDir(sp)
 	if err != nil {
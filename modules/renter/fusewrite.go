@@ -0,0 +1,435 @@
+// +build linux darwin
+
+package renter
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+const (
+	// fuseSpoolDir is the name of the directory within the renter's persist
+	// dir which contains the spool files of fuse files that are being
+	// written.
+	fuseSpoolDir = "fusespool"
+
+	// fuseZeroBufSize is the size of the buffer used to write the zeros of
+	// sparse regions of a fuse file to the upload.
+	fuseZeroBufSize = 1 << 16
+)
+
+var (
+	// errFuseWriteUploaded is returned when a write to a fuse file targets
+	// data that was already streamed to the upload.
+	errFuseWriteUploaded = errors.New("can't overwrite data of a fuse file that was already uploaded")
+
+	// errFuseWriteHandleClosed is returned when a write handle of a fuse
+	// file is used after it was flushed.
+	errFuseWriteHandleClosed = errors.New("fuse write handle was already closed")
+)
+
+// fuseWriteHandle is the file handle of a fuse file that was opened for
+// writing.
+//
+// Writes that continue at the end of the data written so far are streamed
+// directly into an upload of the file. Writes that arrive ahead of that offset
+// are stored in a local spool file until the gap in front of them was filled
+// by other writes. Any gaps that remain when the handle is flushed are treated
+// as sparse regions and uploaded as zeros.
+//
+// Since uploaded data can't be modified, writing to an existing file replaces
+// it. This requires the file to be truncated first. The upload is started on
+// the first write after the truncation.
+type fuseWriteHandle struct {
+	// offset is the number of bytes that were streamed to the upload.
+	offset int64
+
+	// spool is the local file which holds writes that can't be streamed
+	// yet. The data is stored at the same offset it has within the fuse
+	// file. extents are the ranges of the spool that contain data. The spool
+	// is created lazily on the first out-of-order write.
+	spool   *os.File
+	extents []fuseSpoolExtent
+
+	// closed indicates whether the handle was flushed already. err is the
+	// result of the upload once the handle is closed or the first error
+	// that occurred while streaming data to the upload.
+	closed bool
+	err    error
+
+	// truncated indicates whether the content of the file is replaced by
+	// the data written to the handle. upload and uploadErr are set once the
+	// upload was started. The result of the upload is sent on uploadErr
+	// after upload was closed.
+	truncated bool
+	upload    *io.PipeWriter
+	uploadErr <-chan error
+
+	staticSpoolDir    string
+	staticStartUpload func() (*io.PipeWriter, <-chan error, error)
+	mu                sync.Mutex
+}
+
+// fuseSpoolExtent is a range of a spool file that contains data.
+type fuseSpoolExtent struct {
+	start int64
+	end   int64
+}
+
+// Ensure the write handles satisfy the required interfaces.
+//
+// FileReleaser is necessary for finishing uploads of files which were never
+// flushed.
+//
+// FileWriter is necessary for writing files.
+var _ = (fs.FileReleaser)((*fuseWriteHandle)(nil))
+var _ = (fs.FileWriter)((*fuseWriteHandle)(nil))
+
+// newFuseWriteHandle creates a write handle which uses startUpload to start
+// the upload of the data written to it. If truncated is false, the handle
+// needs to be truncated before it can be written to.
+func newFuseWriteHandle(startUpload func() (*io.PipeWriter, <-chan error, error), truncated bool, spoolDir string) *fuseWriteHandle {
+	return &fuseWriteHandle{
+		truncated:         truncated,
+		staticSpoolDir:    spoolDir,
+		staticStartUpload: startUpload,
+	}
+}
+
+// newWriteHandle returns a write handle which uploads the data written to it
+// to the provided siaPath. The upload replaces the existing file using the
+// provided erasure code and cipher type. Unless truncated is true, the handle
+// needs to be truncated before it can be written to.
+func (ffs *fuseFS) newWriteHandle(siaPath modules.SiaPath, ec modules.ErasureCoder, ct crypto.CipherType, truncated bool) *fuseWriteHandle {
+	startUpload := func() (*io.PipeWriter, <-chan error, error) {
+		fileNode, err := ffs.renter.managedInitUploadStream(modules.FileUploadParams{
+			SiaPath:     siaPath,
+			ErasureCode: ec,
+			Force:       true,
+			CipherType:  ct,
+		})
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to replace file")
+		}
+		return ffs.managedStartUpload(fileNode)
+	}
+	return newFuseWriteHandle(startUpload, truncated, filepath.Join(ffs.renter.persistDir, fuseSpoolDir))
+}
+
+// managedNewCreateWriteHandle returns a write handle for a file that was just
+// created by Create. The upload of the file is started right away.
+func (ffs *fuseFS) managedNewCreateWriteHandle(fileNode *filesystem.FileNode) (*fuseWriteHandle, error) {
+	upload, uploadErr, err := ffs.managedStartUpload(fileNode)
+	if err != nil {
+		return nil, err
+	}
+	wh := newFuseWriteHandle(nil, true, filepath.Join(ffs.renter.persistDir, fuseSpoolDir))
+	wh.upload = upload
+	wh.uploadErr = uploadErr
+	return wh, nil
+}
+
+// managedStartUpload starts the upload of a fuse file. The data written to the
+// returned pipe is streamed to the provided fileNode which needs to be
+// prepared by managedInitUploadStream. The upload takes ownership of the
+// fileNode. If the upload fails, the partially uploaded file is deleted.
+func (ffs *fuseFS) managedStartUpload(fileNode *filesystem.FileNode) (*io.PipeWriter, <-chan error, error) {
+	r := ffs.renter
+	siaPath := r.staticFileSystem.FileSiaPath(fileNode)
+	pr, pw := io.Pipe()
+	uploadErr := make(chan error, 1)
+	done := make(chan struct{})
+	err := r.tg.Launch(func() {
+		defer close(done)
+		// Empty files don't need to be uploaded, the siafile created by
+		// managedInitUploadStream is already empty.
+		br := bufio.NewReader(pr)
+		_, err := br.Peek(1)
+		if errors.Contains(err, io.EOF) {
+			err = nil
+		} else if err == nil {
+			err = r.callUploadStreamToFileNode(fileNode, br)
+		}
+		err = errors.Compose(err, fileNode.Close())
+		if err != nil {
+			r.log.Printf("Fuse upload of %v failed: %v", siaPath, err)
+			err = errors.Compose(err, r.DeleteFile(siaPath))
+		}
+		// Unblock any writes that are still waiting for the upload.
+		_ = pr.CloseWithError(err)
+		uploadErr <- err
+	})
+	if err != nil {
+		return nil, nil, errors.Compose(err, fileNode.Close())
+	}
+	// Interrupt the upload on shutdown in case the handle is never released.
+	go func() {
+		select {
+		case <-r.tg.StopChan():
+			_ = pw.CloseWithError(errors.New("renter was shut down"))
+		case <-done:
+		}
+	}()
+	return pw, uploadErr, nil
+}
+
+// Release is called when the last reference to the handle was dropped. It
+// makes sure that the upload is finished even if the handle was never
+// flushed.
+func (wh *fuseWriteHandle) Release(ctx context.Context) syscall.Errno {
+	return errToStatus(wh.managedFinish())
+}
+
+// Write writes data to the file at the provided offset.
+func (wh *fuseWriteHandle) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if wh.closed {
+		return 0, syscall.EBADF
+	}
+	if wh.err != nil {
+		return 0, errToStatus(wh.err)
+	}
+	if !wh.truncated {
+		return 0, syscall.ENOTSUP
+	}
+	if wh.upload == nil {
+		wh.upload, wh.uploadErr, wh.err = wh.staticStartUpload()
+		if wh.err != nil {
+			return 0, errToStatus(wh.err)
+		}
+	}
+	err := wh.write(data, off)
+	if errors.Contains(err, errFuseWriteUploaded) {
+		return 0, syscall.ENOTSUP
+	} else if err != nil {
+		wh.err = err
+		return 0, errToStatus(err)
+	}
+	return uint32(len(data)), errToStatus(nil)
+}
+
+// managedFinish fills any remaining gaps of the file, closes the upload and
+// waits for it to finish. Calling managedFinish more than once returns the
+// result of the first call.
+func (wh *fuseWriteHandle) managedFinish() error {
+	wh.mu.Lock()
+	if wh.closed {
+		err := wh.err
+		wh.mu.Unlock()
+		return err
+	}
+	wh.closed = true
+	err := wh.err
+	if err == nil && wh.upload == nil {
+		if !wh.truncated {
+			// Nothing was written.
+			wh.mu.Unlock()
+			return nil
+		}
+		// The file was truncated without being written to. Upload an empty
+		// file.
+		wh.upload, wh.uploadErr, err = wh.staticStartUpload()
+		if err != nil {
+			wh.err = err
+			wh.mu.Unlock()
+			return err
+		}
+	}
+	if wh.upload == nil {
+		// Starting the upload failed.
+		wh.mu.Unlock()
+		return err
+	}
+	if err == nil {
+		err = wh.fillGaps()
+	}
+	if err != nil {
+		_ = wh.upload.CloseWithError(err)
+	} else {
+		err = wh.upload.Close()
+	}
+	err = errors.Compose(err, wh.removeSpool())
+	uploadErr := wh.uploadErr
+	wh.mu.Unlock()
+
+	// Wait for the upload to finish.
+	err = errors.Compose(err, <-uploadErr)
+	wh.mu.Lock()
+	wh.err = err
+	wh.mu.Unlock()
+	return err
+}
+
+// managedTruncate truncates the file. Only truncating the file before any
+// data was written is supported.
+func (wh *fuseWriteHandle) managedTruncate() error {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if wh.closed {
+		return errFuseWriteHandleClosed
+	}
+	if wh.offset > 0 || len(wh.extents) > 0 {
+		return errFuseWriteUploaded
+	}
+	wh.truncated = true
+	return nil
+}
+
+// managedTruncated returns whether the file was truncated.
+func (wh *fuseWriteHandle) managedTruncated() bool {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	return wh.truncated
+}
+
+// managedSize returns the size of the file written so far.
+func (wh *fuseWriteHandle) managedSize() uint64 {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	size := wh.offset
+	for _, e := range wh.extents {
+		if e.end > size {
+			size = e.end
+		}
+	}
+	return uint64(size)
+}
+
+// drainSpool streams the data of the spool which continues at the current
+// offset to the upload.
+func (wh *fuseWriteHandle) drainSpool() error {
+	for {
+		// Drop the extents which were uploaded already and find the extent
+		// reaching the furthest beyond the current offset.
+		var next *fuseSpoolExtent
+		extents := wh.extents[:0]
+		for _, e := range wh.extents {
+			if e.end <= wh.offset {
+				continue
+			}
+			extents = append(extents, e)
+			if e.start <= wh.offset && (next == nil || e.end > next.end) {
+				e := e
+				next = &e
+			}
+		}
+		wh.extents = extents
+		if next == nil {
+			return nil
+		}
+		n, err := io.Copy(wh.upload, io.NewSectionReader(wh.spool, wh.offset, next.end-wh.offset))
+		wh.offset += n
+		if err != nil {
+			return errors.AddContext(err, "failed to stream spooled data to the upload")
+		}
+	}
+}
+
+// fillGaps streams the remaining spooled data to the upload and fills the
+// gaps between the spooled extents with zeros.
+func (wh *fuseWriteHandle) fillGaps() error {
+	for len(wh.extents) > 0 {
+		// Find the start of the next extent.
+		start := wh.extents[0].start
+		for _, e := range wh.extents[1:] {
+			if e.start < start {
+				start = e.start
+			}
+		}
+		if start > wh.offset {
+			if err := wh.writeZeros(start - wh.offset); err != nil {
+				return err
+			}
+		}
+		if err := wh.drainSpool(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSpool closes and deletes the spool file.
+func (wh *fuseWriteHandle) removeSpool() error {
+	if wh.spool == nil {
+		return nil
+	}
+	err := errors.Compose(wh.spool.Close(), os.Remove(wh.spool.Name()))
+	wh.spool = nil
+	wh.extents = nil
+	return errors.AddContext(err, "failed to remove spool file")
+}
+
+// write writes data at the provided offset. The data is either streamed to
+// the upload or stored in the spool.
+func (wh *fuseWriteHandle) write(data []byte, off int64) error {
+	if off < wh.offset {
+		return errFuseWriteUploaded
+	}
+	if off > wh.offset {
+		return wh.writeSpool(data, off)
+	}
+	n, err := wh.upload.Write(data)
+	wh.offset += int64(n)
+	if err != nil {
+		return errors.AddContext(err, "failed to stream data to the upload")
+	}
+	return wh.drainSpool()
+}
+
+// writeSpool stores data in the spool file at the provided offset.
+func (wh *fuseWriteHandle) writeSpool(data []byte, off int64) error {
+	if wh.spool == nil {
+		err := os.MkdirAll(wh.staticSpoolDir, modules.DefaultDirPerm)
+		if err != nil {
+			return errors.AddContext(err, "failed to create spool dir")
+		}
+		wh.spool, err = ioutil.TempFile(wh.staticSpoolDir, "spool")
+		if err != nil {
+			return errors.AddContext(err, "failed to create spool file")
+		}
+	}
+	_, err := wh.spool.WriteAt(data, off)
+	if err != nil {
+		return errors.AddContext(err, "failed to write to spool file")
+	}
+	wh.extents = append(wh.extents, fuseSpoolExtent{
+		start: off,
+		end:   off + int64(len(data)),
+	})
+	return nil
+}
+
+// writeZeros streams n zeros to the upload.
+func (wh *fuseWriteHandle) writeZeros(n int64) error {
+	bufSize := int64(fuseZeroBufSize)
+	if n < bufSize {
+		bufSize = n
+	}
+	zeros := make([]byte, bufSize)
+	for n > 0 {
+		toWrite := zeros
+		if n < int64(len(toWrite)) {
+			toWrite = toWrite[:n]
+		}
+		written, err := wh.upload.Write(toWrite)
+		wh.offset += int64(written)
+		n -= int64(written)
+		if err != nil {
+			return errors.AddContext(err, "failed to stream zeros to the upload")
+		}
+	}
+	return nil
+}
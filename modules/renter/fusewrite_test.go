@@ -0,0 +1,234 @@
+// +build linux darwin
+
+package renter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+)
+
+// newTestFuseWriteHandle creates a write handle which writes into a buffer
+// instead of an upload. The returned function closes the handle and returns
+// the uploaded data and whether the upload was started.
+func newTestFuseWriteHandle(t *testing.T, truncated bool) (*fuseWriteHandle, func() ([]byte, bool, error)) {
+	spoolDir := filepath.Join(build.TempDir("renter", t.Name()), fuseSpoolDir)
+	if err := os.RemoveAll(spoolDir); err != nil {
+		t.Fatal(err)
+	}
+	var uploaded bytes.Buffer
+	var started bool
+	startUpload := func() (*io.PipeWriter, <-chan error, error) {
+		started = true
+		pr, pw := io.Pipe()
+		uploadErr := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(&uploaded, pr)
+			_ = pr.CloseWithError(err)
+			uploadErr <- err
+		}()
+		return pw, uploadErr, nil
+	}
+	wh := newFuseWriteHandle(startUpload, truncated, spoolDir)
+	return wh, func() ([]byte, bool, error) {
+		err := wh.managedFinish()
+		return uploaded.Bytes(), started, err
+	}
+}
+
+// TestFuseWriteHandle tests that sequential and out-of-order writes are
+// uploaded correctly by the fuseWriteHandle.
+func TestFuseWriteHandle(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	t.Run("Sequential", testFuseWriteHandleSequential)
+	t.Run("OutOfOrder", testFuseWriteHandleOutOfOrder)
+	t.Run("Sparse", testFuseWriteHandleSparse)
+	t.Run("Overwrite", testFuseWriteHandleOverwrite)
+	t.Run("Truncate", testFuseWriteHandleTruncate)
+}
+
+// testFuseWriteHandleSequential tests sequential writes which don't need the
+// spool.
+func testFuseWriteHandleSequential(t *testing.T) {
+	wh, finish := newTestFuseWriteHandle(t, true)
+	data := fastrand.Bytes(1000)
+	for off := 0; off < len(data); off += 100 {
+		n, errno := wh.Write(context.Background(), data[off:off+100], int64(off))
+		if errno != 0 || n != 100 {
+			t.Fatal("write failed", n, errno)
+		}
+	}
+	if wh.spool != nil {
+		t.Fatal("sequential writes shouldn't create a spool")
+	}
+	if size := wh.managedSize(); size != uint64(len(data)) {
+		t.Fatal("wrong size", size)
+	}
+	uploaded, _, err := finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uploaded, data) {
+		t.Fatal("uploaded data doesn't match")
+	}
+
+	// Writes after closing the handle should fail.
+	_, errno := wh.Write(context.Background(), data, int64(len(data)))
+	if errno != syscall.EBADF {
+		t.Fatal("expected EBADF but got", errno)
+	}
+}
+
+// testFuseWriteHandleOutOfOrder tests writes which arrive out of order and
+// overlap.
+func testFuseWriteHandleOutOfOrder(t *testing.T) {
+	wh, finish := newTestFuseWriteHandle(t, true)
+	data := fastrand.Bytes(1000)
+	writes := []struct{ start, end int }{
+		{500, 700},
+		{300, 600},
+		{800, 1000},
+		{0, 300},
+		{700, 800},
+	}
+	for _, w := range writes {
+		_, errno := wh.Write(context.Background(), data[w.start:w.end], int64(w.start))
+		if errno != 0 {
+			t.Fatal("write failed", errno)
+		}
+	}
+	wh.mu.Lock()
+	spoolName := wh.spool.Name()
+	if wh.offset != int64(len(data)) || len(wh.extents) != 0 {
+		t.Error("spool wasn't drained", wh.offset, wh.extents)
+	}
+	wh.mu.Unlock()
+	uploaded, _, err := finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uploaded, data) {
+		t.Fatal("uploaded data doesn't match")
+	}
+
+	// The spool should be gone.
+	if _, err := os.Stat(spoolName); !os.IsNotExist(err) {
+		t.Fatal("spool wasn't removed", err)
+	}
+}
+
+// testFuseWriteHandleSparse tests that gaps which are never written are
+// uploaded as zeros.
+func testFuseWriteHandleSparse(t *testing.T) {
+	wh, finish := newTestFuseWriteHandle(t, true)
+	data := fastrand.Bytes(100)
+	_, errno := wh.Write(context.Background(), data, fuseZeroBufSize+1)
+	if errno != 0 {
+		t.Fatal("write failed", errno)
+	}
+	if size := wh.managedSize(); size != fuseZeroBufSize+1+100 {
+		t.Fatal("wrong size", size)
+	}
+	uploaded, _, err := finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(make([]byte, fuseZeroBufSize+1), data...)
+	if !bytes.Equal(uploaded, expected) {
+		t.Fatal("uploaded data doesn't match")
+	}
+}
+
+// testFuseWriteHandleOverwrite tests that data which was uploaded already
+// can't be overwritten and that upload errors are returned.
+func testFuseWriteHandleOverwrite(t *testing.T) {
+	wh, finish := newTestFuseWriteHandle(t, true)
+	data := fastrand.Bytes(100)
+	_, errno := wh.Write(context.Background(), data, 0)
+	if errno != 0 {
+		t.Fatal("write failed", errno)
+	}
+	_, errno = wh.Write(context.Background(), data[:10], 50)
+	if errno != syscall.ENOTSUP {
+		t.Fatal("expected ENOTSUP but got", errno)
+	}
+
+	// Interrupt the upload. The next write should fail and so should
+	// finishing the upload.
+	uploadErr := errors.New("upload failed")
+	_ = wh.upload.CloseWithError(uploadErr)
+	_, errno = wh.Write(context.Background(), data, 100)
+	if errno != syscall.EIO {
+		t.Fatal("expected EIO but got", errno)
+	}
+	_, _, err := finish()
+	if !errors.Contains(err, uploadErr) {
+		t.Fatal("expected upload error but got", err)
+	}
+}
+
+// testFuseWriteHandleTruncate tests that a handle of an existing file needs to
+// be truncated before it can be written to.
+func testFuseWriteHandleTruncate(t *testing.T) {
+	// Closing a handle which was never truncated shouldn't start an upload.
+	wh, finish := newTestFuseWriteHandle(t, false)
+	_, errno := wh.Write(context.Background(), fastrand.Bytes(10), 0)
+	if errno != syscall.ENOTSUP {
+		t.Fatal("expected ENOTSUP but got", errno)
+	}
+	_, started, err := finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started {
+		t.Fatal("upload shouldn't have been started")
+	}
+
+	// Truncating a handle without writing to it should upload an empty file.
+	wh, finish = newTestFuseWriteHandle(t, false)
+	if err := wh.managedTruncate(); err != nil {
+		t.Fatal(err)
+	}
+	uploaded, started, err := finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !started || len(uploaded) != 0 {
+		t.Fatal("expected an empty upload", started, len(uploaded))
+	}
+
+	// Truncate a handle and write to it.
+	wh, finish = newTestFuseWriteHandle(t, false)
+	if err := wh.managedTruncate(); err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(100)
+	_, errno = wh.Write(context.Background(), data, 0)
+	if errno != 0 {
+		t.Fatal("write failed", errno)
+	}
+
+	// Truncating the handle again isn't possible.
+	if err := wh.managedTruncate(); !errors.Contains(err, errFuseWriteUploaded) {
+		t.Fatal("expected errFuseWriteUploaded but got", err)
+	}
+	uploaded, _, err = finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uploaded, data) {
+		t.Fatal("uploaded data doesn't match")
+	}
+}
@@ -0,0 +1,503 @@
+package renter
+
+// pack.go contains the batch upload of small files. Uploading a small file on
+// its own still uses a full erasure-coded chunk, so the data of the files of a
+// batch is packed into shared sectors using modules.PackFiles instead. The
+// sectors are uploaded as a single siafile within the PackFolder, the pack.
+// Every file of the batch becomes a packed siafile without chunks of its own
+// which records its placement within the pack. Downloads of packed files are
+// served from the corresponding range of the pack, and repairs only need to
+// consider the pack.
+//
+// The packIndex tracks the number of packed files referencing every pack.
+// Deleting a packed file releases its reference and once a pack is no longer
+// referenced, it is deleted as well.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// packsFilename is the filename of the pack index within the renter's
+	// persist dir.
+	packsFilename = "packs.json"
+)
+
+var (
+	// packsMetadata is the metadata of the persisted pack index.
+	packsMetadata = persist.Metadata{
+		Header:  "Renter Pack Index",
+		Version: "1.0",
+	}
+
+	// errNoBatchFiles is returned when uploading a batch without files.
+	errNoBatchFiles = errors.New("batch doesn't contain any files")
+)
+
+type (
+	// packIndex tracks the number of packed files referencing every pack.
+	packIndex struct {
+		references map[string]uint64
+		staticPath string
+		mu         sync.Mutex
+	}
+
+	// batchFile is a local file which is uploaded as part of a batch.
+	batchFile struct {
+		modules.BatchUploadFile
+		staticInfo os.FileInfo
+	}
+
+	// packReader is an io.Reader which reads the data of a pack. It reads the
+	// local files of the batch one after another and pads the data with zeros
+	// to place the files at their offsets.
+	packReader struct {
+		file       *os.File
+		files      map[string]batchFile
+		offset     uint64
+		placements []modules.FilePlacement
+		remaining  uint64
+	}
+
+	// packedStreamer is the modules.Streamer of a packed file. Packed files
+	// are smaller than a sector so they are streamed from memory.
+	packedStreamer struct {
+		*bytes.Reader
+	}
+)
+
+// newPackIndex loads the pack index from the provided path or creates a new
+// one if it doesn't exist yet.
+func newPackIndex(path string) (*packIndex, error) {
+	pi := &packIndex{
+		references: make(map[string]uint64),
+		staticPath: path,
+	}
+	err := persist.LoadJSON(packsMetadata, &pi.references, path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load pack index")
+	}
+	return pi, pi.save()
+}
+
+// save persists the pack index. The caller needs to hold the lock.
+func (pi *packIndex) save() error {
+	return persist.SaveJSON(packsMetadata, pi.references, pi.staticPath)
+}
+
+// managedAddReferences adds n references to the pack at the provided siapath.
+func (pi *packIndex) managedAddReferences(pack modules.SiaPath, n uint64) error {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	pi.references[pack.String()] += n
+	return pi.save()
+}
+
+// managedReferences returns the number of references to the pack at the
+// provided siapath.
+func (pi *packIndex) managedReferences(pack modules.SiaPath) uint64 {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	return pi.references[pack.String()]
+}
+
+// managedRemoveReferences removes a reference for every provided siapath and
+// returns the packs which are no longer referenced.
+func (pi *packIndex) managedRemoveReferences(packs []modules.SiaPath) ([]modules.SiaPath, error) {
+	if len(packs) == 0 {
+		return nil, nil
+	}
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	var unreferenced []modules.SiaPath
+	for _, pack := range packs {
+		refs, exists := pi.references[pack.String()]
+		if !exists {
+			continue
+		}
+		if refs > 1 {
+			pi.references[pack.String()]--
+			continue
+		}
+		delete(pi.references, pack.String())
+		unreferenced = append(unreferenced, pack)
+	}
+	return unreferenced, pi.save()
+}
+
+// newPackReader creates a reader for the data of a pack. The placements are
+// expected to be sorted by their offset.
+func newPackReader(placements []modules.FilePlacement, files map[string]batchFile) *packReader {
+	return &packReader{
+		files:      files,
+		placements: placements,
+	}
+}
+
+// Close closes the file which is currently read.
+func (pr *packReader) Close() error {
+	if pr.file == nil {
+		return nil
+	}
+	err := pr.file.Close()
+	pr.file = nil
+	return err
+}
+
+// Read implements the io.Reader interface.
+func (pr *packReader) Read(b []byte) (int, error) {
+	for {
+		// Read from the current file.
+		if pr.file != nil && pr.remaining > 0 {
+			if uint64(len(b)) > pr.remaining {
+				b = b[:pr.remaining]
+			}
+			n, err := pr.file.Read(b)
+			pr.remaining -= uint64(n)
+			pr.offset += uint64(n)
+			if errors.Contains(err, io.EOF) && pr.remaining > 0 {
+				return n, errors.AddContext(io.ErrUnexpectedEOF, fmt.Sprintf("file %v was truncated during upload", pr.file.Name()))
+			} else if err != nil && !errors.Contains(err, io.EOF) {
+				return n, err
+			}
+			return n, nil
+		}
+		if err := pr.Close(); err != nil {
+			return 0, err
+		}
+		if len(pr.placements) == 0 {
+			return 0, io.EOF
+		}
+
+		// Pad the data up to the offset of the next file.
+		next := pr.placements[0]
+		nextOffset := next.SectorIndex*modules.SectorSize + next.SectorOffset
+		if pr.offset < nextOffset {
+			if uint64(len(b)) > nextOffset-pr.offset {
+				b = b[:nextOffset-pr.offset]
+			}
+			for i := range b {
+				b[i] = 0
+			}
+			pr.offset += uint64(len(b))
+			return len(b), nil
+		}
+
+		// Open the next file.
+		f, err := os.Open(pr.files[next.FileID].Source)
+		if err != nil {
+			return 0, errors.AddContext(err, "unable to open file of batch")
+		}
+		pr.file = f
+		pr.remaining = next.Size
+		pr.placements = pr.placements[1:]
+	}
+}
+
+// sortPlacements sorts the placements of a pack by their offset.
+func sortPlacements(placements []modules.FilePlacement) {
+	sort.Slice(placements, func(i, j int) bool {
+		if placements[i].SectorIndex != placements[j].SectorIndex {
+			return placements[i].SectorIndex < placements[j].SectorIndex
+		}
+		return placements[i].SectorOffset < placements[j].SectorOffset
+	})
+}
+
+// Close implements the io.Closer interface.
+func (ps packedStreamer) Close() error {
+	return nil
+}
+
+// UploadBatch packs the provided local files into shared sectors and uploads
+// them as a new pack. Afterwards a packed siafile is created for every file.
+// Files larger than a sector can't be packed. Empty files don't need to be
+// uploaded and are created as regular siafiles.
+func (r *Renter) UploadBatch(bup modules.BatchUploadParams) (_ modules.SiaPath, _ []modules.FilePlacement, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SiaPath{}, nil, err
+	}
+	defer r.tg.Done()
+	if len(bup.Files) == 0 {
+		return modules.SiaPath{}, nil, errNoBatchFiles
+	}
+
+	// Check the files and gather their sizes.
+	files := make(map[string]batchFile, len(bup.Files))
+	sizes := make(map[string]uint64, len(bup.Files))
+	for _, f := range bup.Files {
+		id := f.SiaPath.String()
+		if _, exists := files[id]; exists {
+			return modules.SiaPath{}, nil, fmt.Errorf("siapath %v is used by more than one file of the batch", id)
+		}
+		info, err := os.Stat(f.Source)
+		if err != nil {
+			return modules.SiaPath{}, nil, errors.AddContext(err, "unable to stat input file")
+		}
+		if info.IsDir() {
+			return modules.SiaPath{}, nil, ErrUploadDirectory
+		}
+		if info.Size() > 0 {
+			sizes[id] = uint64(info.Size())
+		}
+		files[id] = batchFile{BatchUploadFile: f, staticInfo: info}
+	}
+	placements, _, err := modules.PackFiles(sizes)
+	if err != nil {
+		return modules.SiaPath{}, nil, errors.AddContext(err, "unable to pack files")
+	}
+	sortPlacements(placements)
+
+	// Make sure the siapaths are available.
+	for _, f := range files {
+		if bup.Force {
+			err := r.DeleteFile(f.SiaPath)
+			if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+				return modules.SiaPath{}, nil, errors.AddContext(err, "unable to delete existing file")
+			}
+			continue
+		}
+		entry, err := r.staticFileSystem.OpenSiaFile(f.SiaPath)
+		if err == nil {
+			return modules.SiaPath{}, nil, errors.Compose(errors.AddContext(filesystem.ErrExists, f.SiaPath.String()), entry.Close())
+		} else if !errors.Contains(err, filesystem.ErrNotExist) {
+			return modules.SiaPath{}, nil, err
+		}
+	}
+
+	// Fill in the missing upload params of the pack using the policy of the
+	// PackFolder or sensible defaults.
+	packSiaPath, err := modules.PackFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return modules.SiaPath{}, nil, err
+	}
+	up := modules.FileUploadParams{
+		SiaPath:             packSiaPath,
+		ErasureCode:         bup.ErasureCode,
+		CipherType:          bup.CipherType,
+		DisablePartialChunk: true,
+	}
+	if err := r.managedApplyDirPolicy(&up); err != nil {
+		return modules.SiaPath{}, nil, err
+	}
+
+	// Create the packed files.
+	if len(placements) > 0 {
+		if err := r.managedUploadPack(up, placements, files); err != nil {
+			return modules.SiaPath{}, nil, err
+		}
+	}
+
+	// Create the empty files.
+	for id, f := range files {
+		if _, packed := sizes[id]; packed {
+			continue
+		}
+		err := r.staticFileSystem.NewSiaFile(f.SiaPath, f.Source, up.ErasureCode, crypto.GenerateSiaKey(up.CipherType), 0, f.staticInfo.Mode(), true)
+		if err != nil {
+			return modules.SiaPath{}, nil, errors.AddContext(err, "unable to create empty siafile")
+		}
+	}
+
+	// Bubble the directories of the files.
+	dirs := make(map[modules.SiaPath]struct{})
+	for _, f := range files {
+		dirSiaPath, err := f.SiaPath.Dir()
+		if err != nil {
+			return modules.SiaPath{}, nil, err
+		}
+		dirs[dirSiaPath] = struct{}{}
+	}
+	for dirSiaPath := range dirs {
+		_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	}
+	if len(placements) == 0 {
+		return modules.SiaPath{}, nil, nil
+	}
+	return packSiaPath, placements, nil
+}
+
+// managedUploadPack uploads the data of the packed files of a batch as a new
+// pack and creates the packed siafiles.
+func (r *Renter) managedUploadPack(up modules.FileUploadParams, placements []modules.FilePlacement, files map[string]batchFile) (err error) {
+	pr := newPackReader(placements, files)
+	pack, err := r.callUploadStreamFromReader(up, pr)
+	err = errors.Compose(err, pr.Close())
+	if err != nil {
+		deleteErr := r.DeleteFile(up.SiaPath)
+		if errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			deleteErr = nil
+		}
+		if pack != nil {
+			deleteErr = errors.Compose(deleteErr, pack.Close())
+		}
+		return errors.Compose(errors.AddContext(err, "unable to upload pack"), deleteErr)
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	packDirSiaPath, err := up.SiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(packDirSiaPath)
+
+	// Reference the pack before creating the files to never delete a pack
+	// which is still referenced. The references of files that couldn't be
+	// created are released again.
+	if err := r.staticPackIndex.managedAddReferences(up.SiaPath, uint64(len(placements))); err != nil {
+		return errors.AddContext(err, "unable to reference pack")
+	}
+	for i, p := range placements {
+		err := r.managedNewPackedFile(pack, up.SiaPath, p, files[p.FileID])
+		if err != nil {
+			uncreated := make([]modules.SiaPath, len(placements)-i)
+			for j := range uncreated {
+				uncreated[j] = up.SiaPath
+			}
+			r.managedReleasePackReferences(uncreated)
+			return errors.AddContext(err, "unable to create packed siafile")
+		}
+	}
+	return nil
+}
+
+// managedNewPackedFile creates the packed siafile of a file of a batch.
+func (r *Renter) managedNewPackedFile(pack *filesystem.FileNode, packSiaPath modules.SiaPath, p modules.FilePlacement, f batchFile) (err error) {
+	info := siafile.PackedFileInfo{
+		Pack:         packSiaPath,
+		SectorIndex:  p.SectorIndex,
+		SectorOffset: p.SectorOffset,
+	}
+	err = r.staticFileSystem.NewPackedSiaFile(f.SiaPath, f.Source, pack.ErasureCode(), pack.MasterKey(), p.Size, f.staticInfo.Mode(), info)
+	if err != nil {
+		return err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(f.SiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if err := entry.UpdatePackedCache(pack.Metadata()); err != nil {
+		return err
+	}
+	return entry.SaveMetadata()
+}
+
+// managedReleasePackReferences releases the references of the provided packs
+// and deletes the packs which are no longer referenced.
+func (r *Renter) managedReleasePackReferences(packs []modules.SiaPath) {
+	unreferenced, err := r.staticPackIndex.managedRemoveReferences(packs)
+	if err != nil {
+		r.log.Println("WARN: failed to release pack references:", err)
+	}
+	for _, pack := range unreferenced {
+		err := r.DeleteFile(pack)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			r.log.Printf("WARN: failed to delete unreferenced pack %v: %v", pack, err)
+		}
+	}
+}
+
+// managedPackedSnapshot creates a snapshot of the range of a packed file's
+// pack which holds the provided range of the file. It also returns the offset
+// of the range within the pack.
+func (r *Renter) managedPackedSnapshot(siaPath modules.SiaPath, info siafile.PackedFileInfo, offset, length uint64) (_ *siafile.Snapshot, _ uint64, err error) {
+	pack, err := r.staticFileSystem.OpenSiaFile(info.Pack)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to open pack")
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	offset += info.Offset()
+	snap, err := pack.SnapshotRange(siaPath, offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	return snap, offset, nil
+}
+
+// managedPackedStreamer creates a streamer for a packed file. Packed files
+// are smaller than a sector, so the whole file is downloaded right away.
+func (r *Renter) managedPackedStreamer(siaPath modules.SiaPath, size uint64, info siafile.PackedFileInfo, disableLocalFetch bool) (modules.Streamer, error) {
+	snap, offset, err := r.managedPackedSnapshot(siaPath, info, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	buffer := bytes.NewBuffer(make([]byte, 0, size))
+	ddw := newDownloadDestinationWriter(buffer)
+	d, err := r.managedNewDownload(downloadParams{
+		destination:       ddw,
+		destinationType:   destinationTypeSeekStream,
+		destinationString: "httpresponse",
+		disableLocalFetch: disableLocalFetch,
+		file:              snap,
+
+		latencyTarget: 50 * time.Millisecond, // TODO: low default until full latency support is added.
+		length:        size,
+		needsMemory:   true,
+		offset:        offset,
+		overdrive:     5,    // TODO: high default until full overdrive support is added.
+		priority:      1000, // TODO: high default until full priority support is added.
+
+		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
+		staticSpendingCategory: categoryDownload,
+	})
+	if err != nil {
+		return nil, errors.Compose(err, ddw.Close())
+	}
+	// Register some cleanup for when the download is done.
+	d.OnComplete(func(_ error) error {
+		// close the destination buffer to avoid deadlocks.
+		return ddw.Close()
+	})
+	if err := d.Start(); err != nil {
+		return nil, err
+	}
+	// Block until the download has completed.
+	select {
+	case <-d.completeChan:
+		if err := d.Err(); err != nil {
+			return nil, errors.AddContext(err, "download failed")
+		}
+	case <-r.tg.StopChan():
+		return nil, errors.New("download interrupted by shutdown")
+	}
+	return packedStreamer{bytes.NewReader(buffer.Bytes())}, nil
+}
+
+// managedUpdatePackedFileMetadata updates the metadata of a packed siafile
+// using the metadata of its pack.
+func (r *Renter) managedUpdatePackedFileMetadata(sf *filesystem.FileNode, info siafile.PackedFileInfo) (err error) {
+	pack, err := r.staticFileSystem.OpenSiaFile(info.Pack)
+	if err != nil {
+		return errors.AddContext(err, "unable to open pack")
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	if err := sf.UpdatePackedCache(pack.Metadata()); err != nil {
+		return err
+	}
+	sf.SetLastHealthCheckTime()
+	return sf.SaveMetadata()
+}
@@ -0,0 +1,144 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestPackIndex probes the reference counting and persistence of the
+// packIndex.
+func TestPackIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, packsFilename)
+	pi, err := newPackIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reference two packs.
+	pack1, pack2 := modules.RandomSiaPath(), modules.RandomSiaPath()
+	if err := pi.managedAddReferences(pack1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := pi.managedAddReferences(pack2, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the index.
+	pi, err = newPackIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := pi.managedReferences(pack1); refs != 2 {
+		t.Fatal("wrong number of references", refs)
+	}
+	if refs := pi.managedReferences(pack2); refs != 1 {
+		t.Fatal("wrong number of references", refs)
+	}
+
+	// Only packs without references should be returned. Unknown packs are
+	// ignored.
+	unreferenced, err := pi.managedRemoveReferences([]modules.SiaPath{pack1, pack2, modules.RandomSiaPath()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unreferenced) != 1 || !unreferenced[0].Equals(pack2) {
+		t.Fatal("wrong unreferenced packs", unreferenced)
+	}
+	unreferenced, err = pi.managedRemoveReferences([]modules.SiaPath{pack1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unreferenced) != 1 || !unreferenced[0].Equals(pack1) {
+		t.Fatal("wrong unreferenced packs", unreferenced)
+	}
+	if refs := pi.managedReferences(pack1); refs != 0 {
+		t.Fatal("pack should be unreferenced", refs)
+	}
+}
+
+// TestPackReader probes that the packReader places the files of a batch at
+// their offsets within the pack.
+func TestPackReader(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a few files of different sizes and pack them.
+	files := make(map[string]batchFile)
+	sizes := make(map[string]uint64)
+	data := make(map[string][]byte)
+	for _, size := range []uint64{1, 100, modules.SectorSize / 2, modules.SectorSize} {
+		siaPath := modules.RandomSiaPath()
+		source := filepath.Join(dir, siaPath.Name())
+		data[siaPath.String()] = fastrand.Bytes(int(size))
+		if err := ioutil.WriteFile(source, data[siaPath.String()], persist.DefaultDiskPermissionsTest); err != nil {
+			t.Fatal(err)
+		}
+		files[siaPath.String()] = batchFile{BatchUploadFile: modules.BatchUploadFile{Source: source, SiaPath: siaPath}}
+		sizes[siaPath.String()] = size
+	}
+	placements, numSectors, err := modules.PackFiles(sizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortedPlacements := append([]modules.FilePlacement{}, placements...)
+	sortPlacements(sortedPlacements)
+
+	// Read the pack and check that every file is at its offset.
+	pr := newPackReader(sortedPlacements, files)
+	pack, err := ioutil.ReadAll(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(pack)) > numSectors*modules.SectorSize {
+		t.Fatal("pack is larger than its sectors", len(pack), numSectors)
+	}
+	for _, p := range placements {
+		offset := p.SectorIndex*modules.SectorSize + p.SectorOffset
+		if !bytes.Equal(pack[offset:offset+p.Size], data[p.FileID]) {
+			t.Fatal("file isn't placed at its offset", p)
+		}
+	}
+
+	// Truncating a file should result in an error.
+	for id, f := range files {
+		if sizes[id] < 100 {
+			continue
+		}
+		if err := os.Truncate(f.Source, int64(sizes[id]-1)); err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	pr = newPackReader(append([]modules.FilePlacement{}, sortedPlacements...), files)
+	if _, err := ioutil.ReadAll(pr); err == nil {
+		t.Fatal("expected error when reading a truncated file")
+	}
+	if err := pr.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
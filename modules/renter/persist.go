@@ -56,6 +56,7 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
+		Dedup            bool
 		MaxDownloadSpeed int64
 		MaxUploadSpeed   int64
 		UploadedBackups  []modules.UploadedBackup
@@ -133,6 +133,14 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 		err = errors.Compose(err, s.Close())
 	}()
 
+	// Prove the renter's identity to hosts which support it, so that the host
+	// can apply its renter policy to the contract.
+	if params.IdentityKey != (crypto.SecretKey{}) && build.VersionCmp(host.Version, modules.RHPIdentifyVersion) >= 0 {
+		if err := s.Identify(params.IdentityKey); err != nil {
+			return modules.RenterContract{}, nil, types.Transaction{}, nil, err
+		}
+	}
+
 	// Send the FormContract request.
 	req := modules.LoopFormContractRequest{
 		Transactions: txnSet,
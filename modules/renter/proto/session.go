@@ -112,6 +112,24 @@ func (s *Session) Lock(id types.FileContractID, secretKey crypto.SecretKey) (typ
 	return resp.Revision, resp.Signatures, nil
 }
 
+// Identify calls the Identify RPC, proving the renter's identity to the host.
+// The host attributes the contracts formed within the session to the identity.
+func (s *Session) Identify(identityKey crypto.SecretKey) error {
+	sig := crypto.SignHash(crypto.HashAll(modules.RPCIdentityPrefix, s.challenge), identityKey)
+	req := modules.LoopIdentifyRequest{
+		PublicKey: types.Ed25519PublicKey(identityKey.PublicKey()),
+		Signature: sig[:],
+	}
+
+	extendDeadline(s.conn, modules.NegotiateSettingsTime)
+	var resp modules.LoopIdentifyResponse
+	if err := s.call(modules.RPCLoopIdentify, req, &resp, modules.RPCMinLen); err != nil {
+		return errors.AddContext(err, "identify request on host session has failed")
+	}
+	s.challenge = resp.NewChallenge
+	return nil
+}
+
 // Unlock calls the Unlock RPC, unlocking the currently-locked contract.
 func (s *Session) Unlock() error {
 	if s.contractID == (types.FileContractID{}) {
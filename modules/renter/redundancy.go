@@ -0,0 +1,359 @@
+package renter
+
+// redundancy.go contains the logic for changing the erasure code of a file
+// which was already uploaded. Instead of modifying the file in place, the
+// renter creates a copy of the siafile with the new erasure code within the
+// RedundancyFolder. The repair loop uploads the chunks of that copy by
+// downloading the corresponding data of the original file, re-encoding it and
+// uploading the new pieces. Once the copy is fully uploaded, it atomically
+// replaces the original siafile.
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errRedundancyUnchanged is returned when the redundancy of a file is
+	// changed to the erasure code the file already uses.
+	errRedundancyUnchanged = errors.New("file already uses the requested erasure code")
+
+	// errRedundancyFolder is returned when trying to change the redundancy of a
+	// file within the RedundancyFolder.
+	errRedundancyFolder = errors.New("can't change the redundancy of a file within the redundancy folder")
+
+	// errRedundancyPacked is returned when trying to change the redundancy of
+	// a packed file. Its data is stored within its pack, whose redundancy can
+	// be changed instead.
+	errRedundancyPacked = errors.New("can't change the redundancy of a packed file, change the redundancy of its pack instead")
+)
+
+// redundancyChangeSiaPath returns the siapath of the re-encoded copy of the
+// file at siaPath.
+func redundancyChangeSiaPath(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	return modules.RedundancyFolder.Join(siaPath.String())
+}
+
+// redundancyChangeSource returns the siapath of the original file if siaPath
+// is the siapath of a re-encoded copy.
+func redundancyChangeSource(siaPath modules.SiaPath) (modules.SiaPath, bool) {
+	prefix := modules.RedundancyFolder.String() + "/"
+	if !strings.HasPrefix(siaPath.String(), prefix) {
+		return modules.SiaPath{}, false
+	}
+	source, err := modules.NewSiaPath(strings.TrimPrefix(siaPath.String(), prefix))
+	if err != nil {
+		return modules.SiaPath{}, false
+	}
+	return source, true
+}
+
+// ChangeRedundancy starts re-encoding the file at siaPath with a new erasure
+// code. The file is re-uploaded in the background by the repair loop and
+// replaced once the upload is complete. Calling ChangeRedundancy on a file
+// whose redundancy is already being changed restarts the process.
+func (r *Renter) ChangeRedundancy(siaPath modules.SiaPath, ec modules.ErasureCoder) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if _, ok := redundancyChangeSource(siaPath); ok || siaPath.Equals(modules.RedundancyFolder) {
+		return errRedundancyFolder
+	}
+	stagingSiaPath, err := redundancyChangeSiaPath(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to create siapath for re-encoded file")
+	}
+
+	// Open the original file.
+	source, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, source.Close())
+	}()
+	if _, packed := source.Packed(); packed {
+		return errRedundancyPacked
+	}
+	if source.ErasureCode().Identifier() == ec.Identifier() {
+		return errRedundancyUnchanged
+	}
+
+	// Make sure the new erasure code doesn't require more hosts than we have
+	// contracts with.
+	numContracts := len(r.hostContractor.Contracts())
+	requiredContracts := (ec.NumPieces() + ec.MinPieces()) / 2
+	if numContracts < requiredContracts && build.Release != "testing" {
+		return fmt.Errorf("not enough contracts to change the redundancy of the file: got %v, needed %v", numContracts, requiredContracts)
+	}
+
+	// Drop a previous re-encoded copy of the file.
+	err = r.staticFileSystem.DeleteFile(stagingSiaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete previous re-encoded copy of file")
+	}
+
+	// Create the copy. It uses a new key to avoid encrypting different pieces
+	// with the same key and nonce. The local path is not set since the local
+	// file can't be verified against the new pieces. It is copied over once the
+	// copy replaces the original file.
+	cipherKey := crypto.GenerateSiaKey(source.MasterKey().Type())
+	err = r.staticFileSystem.NewSiaFile(stagingSiaPath, "", ec, cipherKey, source.Size(), source.Mode(), true)
+	if err != nil {
+		return errors.AddContext(err, "unable to create re-encoded copy of file")
+	}
+
+	// There is nothing to upload for empty files.
+	if source.Size() == 0 {
+		return r.managedFinishRedundancyChange(stagingSiaPath, siaPath)
+	}
+
+	// Send the copy to the repair loop.
+	entry, err := r.staticFileSystem.OpenSiaFile(stagingSiaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open re-encoded copy of file")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	stagingDirSiaPath, err := stagingSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(stagingDirSiaPath)
+	nilMap := make(map[string]bool)
+	hosts := r.managedRefreshHostsAndWorkers()
+	r.callBuildAndPushChunks([]*filesystem.FileNode{entry}, hosts, targetUnstuckChunks, nilMap, nilMap)
+	select {
+	case r.uploadHeap.newUploads <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RedundancyChange returns the progress of an ongoing redundancy change of the
+// file at siaPath. If there is no ongoing change, nil is returned.
+func (r *Renter) RedundancyChange(siaPath modules.SiaPath) (_ *modules.RedundancyChange, err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	stagingSiaPath, err := redundancyChangeSiaPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(stagingSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	_, _, health, _, _, _, _ := entry.Health(offline, goodForRenew)
+	redundancy, _, err := entry.Redundancy(offline, goodForRenew)
+	if err != nil {
+		return nil, err
+	}
+	uploadProgress, _, err := entry.UploadProgressAndBytes()
+	if err != nil {
+		return nil, err
+	}
+	ec := entry.ErasureCode()
+	return &modules.RedundancyChange{
+		DataPieces:     ec.MinPieces(),
+		ParityPieces:   ec.NumPieces() - ec.MinPieces(),
+		Health:         health,
+		Redundancy:     redundancy,
+		UploadProgress: uploadProgress,
+	}, nil
+}
+
+// managedDownloadSourceChunkData fetches the logical data for a chunk of a
+// re-encoded copy by downloading the same range of the original file. The data
+// is then encoded using the erasure code of the copy.
+func (r *Renter) managedDownloadSourceChunkData(chunk *unfinishedUploadChunk, sourceSiaPath modules.SiaPath) (err error) {
+	source, err := r.staticFileSystem.OpenSiaFile(sourceSiaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open original file")
+	}
+	defer func() {
+		err = errors.Compose(err, source.Close())
+	}()
+
+	// The chunks of the copy don't necessarily line up with the chunks of the
+	// original file since the erasure codes differ. That's why the range is
+	// downloaded into a writer which can handle multiple chunks.
+	offset := uint64(chunk.offset)
+	if offset >= source.Size() {
+		return errors.New("chunk is out of bounds of the original file")
+	}
+	length := chunk.length
+	if offset+length > source.Size() {
+		length = source.Size() - offset
+	}
+	snap, err := source.SnapshotRange(sourceSiaPath, offset, length)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, length))
+	ddw := newDownloadDestinationWriter(buf)
+	d, err := r.managedNewDownload(downloadParams{
+		destination:       ddw,
+		destinationType:   "buffer",
+		disableLocalFetch: true,
+		file:              snap,
+
+		latencyTarget: 200e3, // No need to rush latency on repair downloads.
+		length:        length,
+		needsMemory:   false, // We already requested memory, the download memory fits inside of that.
+		offset:        offset,
+		overdrive:     0, // No need to rush the latency on repair downloads.
+		priority:      0, // Repair downloads are completely de-prioritized.
+
+		staticMemoryManager:    chunk.staticMemoryManager, // Same memory manager as upload chunk
+		staticSpendingCategory: categoryRepairDownload,
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.Start(); err != nil {
+		return err
+	}
+
+	// Wait for the download to complete.
+	select {
+	case <-d.completeChan:
+	case <-r.tg.StopChan():
+		return errors.New("redundancy change download interrupted by stop call")
+	}
+	if err := errors.Compose(d.Err(), ddw.Close()); err != nil {
+		return err
+	}
+
+	// Encode the data using the new erasure code.
+	dataPieces, _, err := readDataPieces(buf, chunk.fileEntry.ErasureCode(), chunk.fileEntry.PieceSize())
+	if err != nil {
+		return errors.AddContext(err, "unable to read the data downloaded from the original file")
+	}
+	chunk.logicalChunkData, _ = chunk.fileEntry.ErasureCode().EncodeShards(dataPieces)
+	return chunk.staticEncryptAndCheckIntegrity()
+}
+
+// managedTryFinishRedundancyChange replaces the original file with its
+// re-encoded copy if entry is a re-encoded copy which is fully uploaded.
+func (r *Renter) managedTryFinishRedundancyChange(entry *filesystem.FileNode, offline, goodForRenew map[string]bool) {
+	siaPath := r.staticFileSystem.FileSiaPath(entry)
+	sourceSiaPath, ok := redundancyChangeSource(siaPath)
+	if !ok {
+		return
+	}
+	_, _, health, _, numStuckChunks, _, _ := entry.Health(offline, goodForRenew)
+	if numStuckChunks > 0 || modules.NeedsRepair(health) {
+		return
+	}
+	err := r.managedFinishRedundancyChange(siaPath, sourceSiaPath)
+	if err != nil {
+		r.log.Printf("WARN: unable to replace %v with its re-encoded copy: %v", sourceSiaPath, err)
+		return
+	}
+	r.repairLog.Printf("Finished changing the redundancy of %v", sourceSiaPath)
+}
+
+// managedFinishRedundancyChange replaces the original file at sourceSiaPath
+// with the re-encoded copy at siaPath. If the original file was deleted or
+// replaced in the meantime, the copy is deleted instead.
+func (r *Renter) managedFinishRedundancyChange(siaPath, sourceSiaPath modules.SiaPath) (err error) {
+	source, err := r.staticFileSystem.OpenSiaFile(sourceSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.Compose(errors.New("original file doesn't exist anymore"), r.staticFileSystem.DeleteFile(siaPath))
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to open original file")
+	}
+	defer func() {
+		err = errors.Compose(err, source.Close())
+	}()
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open re-encoded copy of file")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// Make sure the copy was created from the current version of the file.
+	if source.CreateTime().After(entry.CreateTime()) || source.Size() != entry.Size() {
+		return errors.Compose(errors.New("original file was replaced"), r.staticFileSystem.DeleteFile(siaPath))
+	}
+
+	// Carry over the local path of the original file.
+	if err := entry.SetLocalPath(source.LocalPath()); err != nil {
+		return errors.AddContext(err, "unable to set local path of re-encoded copy")
+	}
+	refs, err := fileReferencesOf(source)
+	if err != nil {
+		return errors.AddContext(err, "unable to get references of original file")
+	}
+	if err := r.staticFileSystem.ReplaceFile(siaPath, sourceSiaPath); err != nil {
+		return errors.AddContext(err, "unable to replace original file")
+	}
+	r.managedReleaseFileReferences(refs)
+
+	// Update the metadata of both directories.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	sourceDirSiaPath, err := sourceSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	_ = r.staticBubbleScheduler.callQueueBubble(sourceDirSiaPath)
+	return nil
+}
+
+// managedAbortRedundancyChange deletes the re-encoded copy of the file at
+// siaPath if there is one.
+func (r *Renter) managedAbortRedundancyChange(siaPath modules.SiaPath) error {
+	stagingSiaPath, err := redundancyChangeSiaPath(siaPath)
+	if err != nil {
+		return err
+	}
+	err = r.staticFileSystem.DeleteFile(stagingSiaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete re-encoded copy of file")
+	}
+	return nil
+}
+
+// managedAbortRedundancyChanges deletes the re-encoded copies of all files
+// within the siadir at siaPath.
+func (r *Renter) managedAbortRedundancyChanges(siaPath modules.SiaPath) error {
+	stagingSiaPath := modules.RedundancyFolder
+	if !siaPath.IsRoot() {
+		var err error
+		stagingSiaPath, err = redundancyChangeSiaPath(siaPath)
+		if err != nil {
+			return err
+		}
+	}
+	err := r.staticFileSystem.DeleteDir(stagingSiaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete re-encoded copies of files")
+	}
+	return nil
+}
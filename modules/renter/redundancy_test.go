@@ -0,0 +1,166 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestChangeRedundancy probes starting, aborting and finishing a redundancy
+// change of a file.
+func TestChangeRedundancy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file.
+	siaPath, oldEC := testingFileParamsCustom(1, 1)
+	entry, err := r.createRenterTestFileWithParams(siaPath, oldEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.SetLocalPath("TestPath"); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	newEC, err := modules.NewRSSubCode(2, 1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stagingSiaPath, err := redundancyChangeSiaPath(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// There is no change in progress yet.
+	rc, err := r.RedundancyChange(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc != nil {
+		t.Fatal("expected no redundancy change", rc)
+	}
+
+	// Changing the redundancy to the same erasure code should fail.
+	err = r.ChangeRedundancy(siaPath, oldEC)
+	if !errors.Contains(err, errRedundancyUnchanged) {
+		t.Fatal("expected errRedundancyUnchanged but got", err)
+	}
+	// Changing the redundancy of the copy should fail.
+	err = r.ChangeRedundancy(stagingSiaPath, newEC)
+	if !errors.Contains(err, errRedundancyFolder) {
+		t.Fatal("expected errRedundancyFolder but got", err)
+	}
+
+	// Start the change.
+	if err := r.ChangeRedundancy(siaPath, newEC); err != nil {
+		t.Fatal(err)
+	}
+	rc, err = r.RedundancyChange(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil || rc.DataPieces != 2 || rc.ParityPieces != 1 {
+		t.Fatal("unexpected redundancy change", rc)
+	}
+	if source, ok := redundancyChangeSource(stagingSiaPath); !ok || !source.Equals(siaPath) {
+		t.Fatal("wrong source for copy", source, ok)
+	}
+
+	// Deleting the file should abort the change.
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if rc, err = r.RedundancyChange(siaPath); err != nil || rc != nil {
+		t.Fatal("expected change to be aborted", rc, err)
+	}
+
+	// Recreate the file and start again. Renaming the file should abort the
+	// change too.
+	entry, err = r.createRenterTestFileWithParams(siaPath, oldEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.SetLocalPath("TestPath"); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChangeRedundancy(siaPath, newEC); err != nil {
+		t.Fatal(err)
+	}
+	newSiaPath := modules.RandomSiaPath()
+	if err := r.RenameFile(siaPath, newSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	if rc, err = r.RedundancyChange(siaPath); err != nil || rc != nil {
+		t.Fatal("expected change to be aborted", rc, err)
+	}
+
+	// Start again and finish the change.
+	siaPath = newSiaPath
+	stagingSiaPath, err = redundancyChangeSiaPath(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChangeRedundancy(siaPath, newEC); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedFinishRedundancyChange(stagingSiaPath, siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if rc, err = r.RedundancyChange(siaPath); err != nil || rc != nil {
+		t.Fatal("expected change to be finished", rc, err)
+	}
+	entry, err = r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ErasureCode().Identifier() != newEC.Identifier() {
+		t.Fatal("file wasn't replaced")
+	}
+	if entry.LocalPath() != "TestPath" {
+		t.Fatal("local path wasn't carried over", entry.LocalPath())
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A copy of a file that was replaced in the meantime should be dropped
+	// instead of replacing the new file.
+	if err := r.ChangeRedundancy(siaPath, oldEC); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.staticFileSystem.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	entry, err = r.createRenterTestFileWithParams(siaPath, newEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedFinishRedundancyChange(stagingSiaPath, siaPath); err == nil {
+		t.Fatal("expected finishing the change to fail")
+	}
+	if rc, err = r.RedundancyChange(siaPath); err != nil || rc != nil {
+		t.Fatal("expected copy to be dropped", rc, err)
+	}
+}
@@ -0,0 +1,338 @@
+package renter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errSubscriberClosed is returned when a closed registry subscriber is
+	// used.
+	errSubscriberClosed = errors.New("registry subscriber was closed")
+
+	// workerSubscriptionTimeout is the amount of time the subscription manager
+	// waits for a worker to establish a subscription before giving up.
+	workerSubscriptionTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// registrySubscriptionManager manages the renter's subscriptions to
+	// registry entries. It forwards subscriptions to the workers and notifies
+	// the renter's registry subscribers whenever a worker receives an update
+	// for a subscribed entry.
+	registrySubscriptionManager struct {
+		// subscriptions contains all the entries that at least one subscriber
+		// is subscribed to.
+		subscriptions map[modules.RegistryEntryID]*renterSubscription
+
+		staticRenter *Renter
+		mu           sync.Mutex
+	}
+
+	// renterSubscription is a single subscription to a registry entry that
+	// can be shared by multiple subscribers.
+	renterSubscription struct {
+		staticRequest modules.RPCRegistrySubscriptionRequest
+
+		// latestRV is the latest known value of the entry. It is 'nil' until
+		// the first value was received.
+		latestRV *modules.SignedRegistryValue
+
+		// subscribers are the subscribers that are subscribed to the entry.
+		subscribers map[*registrySubscriber]struct{}
+	}
+
+	// registrySubscriber is a subscriber of the renter that is notified
+	// about updates to the registry entries it is subscribed to. It
+	// implements the modules.RegistrySubscriber interface.
+	registrySubscriber struct {
+		// subscriptions contains the entries the subscriber is subscribed
+		// to and the revision number of the latest value the subscriber was
+		// notified about.
+		subscriptions map[modules.RegistryEntryID]*uint64
+		closed        bool
+
+		staticManager    *registrySubscriptionManager
+		staticNotifyFunc modules.RegistryNotifyFunc
+
+		// notifyMu makes sure that notifications are handed to the notify
+		// func one at a time and not while the subscriber is subscribing to
+		// an entry.
+		notifyMu sync.Mutex
+		mu       sync.Mutex
+	}
+)
+
+// newRegistrySubscriptionManager creates a new subscription manager for the
+// renter.
+func newRegistrySubscriptionManager(r *Renter) *registrySubscriptionManager {
+	return &registrySubscriptionManager{
+		subscriptions: make(map[modules.RegistryEntryID]*renterSubscription),
+		staticRenter:  r,
+	}
+}
+
+// NewRegistrySubscriber creates a new registry subscriber which calls
+// notifyFunc whenever an update for an entry it is subscribed to is received.
+func (r *Renter) NewRegistrySubscriber(notifyFunc modules.RegistryNotifyFunc) (modules.RegistrySubscriber, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return &registrySubscriber{
+		subscriptions:    make(map[modules.RegistryEntryID]*uint64),
+		staticManager:    r.staticSubscriptionManager,
+		staticNotifyFunc: notifyFunc,
+	}, nil
+}
+
+// callSubscribeWorker subscribes a newly created worker to all the entries
+// the renter is subscribed to.
+func (m *registrySubscriptionManager) callSubscribeWorker(w *worker) {
+	m.mu.Lock()
+	requests := make([]modules.RPCRegistrySubscriptionRequest, 0, len(m.subscriptions))
+	for _, sub := range m.subscriptions {
+		requests = append(requests, sub.staticRequest)
+	}
+	m.mu.Unlock()
+	if len(requests) == 0 {
+		return
+	}
+	go m.threadedSubscribeWorker(w, requests)
+}
+
+// managedAddSubscriber adds a subscriber to the subscription of an entry,
+// creating the subscription if necessary. It returns the latest known value
+// of the entry.
+func (m *registrySubscriptionManager) managedAddSubscriber(rs *registrySubscriber, spk types.SiaPublicKey, tweak crypto.Hash) *modules.SignedRegistryValue {
+	eid := modules.DeriveRegistryEntryID(spk, tweak)
+	m.mu.Lock()
+	sub, exists := m.subscriptions[eid]
+	if !exists {
+		sub = &renterSubscription{
+			staticRequest: modules.RPCRegistrySubscriptionRequest{
+				PubKey: spk,
+				Tweak:  tweak,
+			},
+			subscribers: make(map[*registrySubscriber]struct{}),
+		}
+		m.subscriptions[eid] = sub
+	}
+	sub.subscribers[rs] = struct{}{}
+	latestRV := sub.latestRV
+	m.mu.Unlock()
+
+	// If the subscription is new, subscribe the workers.
+	if !exists {
+		for _, w := range m.staticRenter.staticWorkerPool.callWorkers() {
+			go m.threadedSubscribeWorker(w, []modules.RPCRegistrySubscriptionRequest{sub.staticRequest})
+		}
+	}
+	return latestRV
+}
+
+// managedNotify is called by the workers whenever they receive an update for
+// a subscribed entry. If the update is newer than the latest known value of
+// the entry, all of the entry's subscribers are notified.
+func (m *registrySubscriptionManager) managedNotify(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	eid := modules.DeriveRegistryEntryID(spk, srv.Tweak)
+	m.mu.Lock()
+	sub, exists := m.subscriptions[eid]
+	if !exists || (sub.latestRV != nil && sub.latestRV.Revision >= srv.Revision) {
+		// Either nobody is subscribed anymore or the update was already
+		// received from another worker.
+		m.mu.Unlock()
+		return
+	}
+	sub.latestRV = &srv
+	subscribers := make([]*registrySubscriber, 0, len(sub.subscribers))
+	for rs := range sub.subscribers {
+		subscribers = append(subscribers, rs)
+	}
+	m.mu.Unlock()
+
+	// Notify the subscribers in the background to avoid blocking the worker.
+	for _, rs := range subscribers {
+		rs := rs
+		err := m.staticRenter.tg.Launch(func() {
+			rs.managedNotify(spk, srv)
+		})
+		if err != nil {
+			return // shutdown
+		}
+	}
+}
+
+// managedRemoveSubscriber removes a subscriber from the subscription of an
+// entry. If it was the last subscriber, the workers are unsubscribed from the
+// entry.
+func (m *registrySubscriptionManager) managedRemoveSubscriber(rs *registrySubscriber, eid modules.RegistryEntryID) {
+	m.mu.Lock()
+	sub, exists := m.subscriptions[eid]
+	if !exists {
+		m.mu.Unlock()
+		return
+	}
+	delete(sub.subscribers, rs)
+	if len(sub.subscribers) > 0 {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.subscriptions, eid)
+	m.mu.Unlock()
+
+	// Nobody is subscribed to the entry anymore.
+	for _, w := range m.staticRenter.staticWorkerPool.callWorkers() {
+		w.Unsubscribe(sub.staticRequest)
+	}
+}
+
+// threadedSubscribeWorker subscribes a worker to the provided entries and
+// forwards the initial values returned by the host to the subscribers.
+func (m *registrySubscriptionManager) threadedSubscribeWorker(w *worker, requests []modules.RPCRegistrySubscriptionRequest) {
+	r := m.staticRenter
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	// Ignore hosts that don't support subscriptions.
+	if build.VersionCmp(w.staticCache().staticHostVersion, minSubscriptionVersion) < 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), workerSubscriptionTimeout)
+	defer cancel()
+	notifications, err := w.Subscribe(ctx, requests...)
+	if err != nil {
+		r.log.Debugf("failed to subscribe worker %v to %v registry entries: %v", w.staticHostPubKeyStr, len(requests), err)
+		return
+	}
+	for _, n := range notifications {
+		m.managedNotify(n.PubKey, n.Entry)
+	}
+}
+
+// Close closes the subscriber and unsubscribes it from all of its entries.
+func (rs *registrySubscriber) Close() error {
+	rs.mu.Lock()
+	if rs.closed {
+		rs.mu.Unlock()
+		return nil
+	}
+	rs.closed = true
+	eids := make([]modules.RegistryEntryID, 0, len(rs.subscriptions))
+	for eid := range rs.subscriptions {
+		eids = append(eids, eid)
+	}
+	rs.subscriptions = make(map[modules.RegistryEntryID]*uint64)
+	rs.mu.Unlock()
+
+	for _, eid := range eids {
+		rs.staticManager.managedRemoveSubscriber(rs, eid)
+	}
+	return nil
+}
+
+// Subscribe subscribes the subscriber to the entry with the given public key
+// and tweak. It returns the latest known value of the entry or 'nil' if the
+// entry can't be found.
+func (rs *registrySubscriber) Subscribe(spk types.SiaPublicKey, tweak crypto.Hash) (*modules.SignedRegistryValue, error) {
+	// Block notifications until the initial value was determined to avoid
+	// notifying the subscriber about the value it is about to receive.
+	rs.notifyMu.Lock()
+	defer rs.notifyMu.Unlock()
+
+	eid := modules.DeriveRegistryEntryID(spk, tweak)
+	rs.mu.Lock()
+	if rs.closed {
+		rs.mu.Unlock()
+		return nil, errSubscriberClosed
+	}
+	_, subscribed := rs.subscriptions[eid]
+	if !subscribed {
+		rs.subscriptions[eid] = nil
+	}
+	rs.mu.Unlock()
+
+	// Add the subscriber to the manager.
+	latestRV := rs.staticManager.managedAddSubscriber(rs, spk, tweak)
+
+	// If the latest value isn't known yet, look it up. If the renter has no
+	// workers yet, the workers will pick up the subscription once they are
+	// added to the pool.
+	if latestRV == nil {
+		srv, err := rs.staticManager.staticRenter.ReadRegistry(spk, tweak, MaxRegistryReadTimeout)
+		if errors.Contains(err, ErrRegistryEntryNotFound) ||
+			errors.Contains(err, ErrRegistryLookupTimeout) ||
+			errors.Contains(err, modules.ErrNotEnoughWorkersInWorkerPool) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to look up the initial value of the entry")
+		}
+		latestRV = &srv
+	}
+
+	// Remember the revision that was returned to avoid notifying the
+	// subscriber about it again.
+	rs.mu.Lock()
+	if rev, subscribed := rs.subscriptions[eid]; subscribed && (rev == nil || *rev < latestRV.Revision) {
+		revision := latestRV.Revision
+		rs.subscriptions[eid] = &revision
+	}
+	rs.mu.Unlock()
+
+	// Make sure the other subscribers learn about the value as well.
+	rs.staticManager.managedNotify(spk, *latestRV)
+	return latestRV, nil
+}
+
+// Unsubscribe unsubscribes the subscriber from the entry with the given id.
+func (rs *registrySubscriber) Unsubscribe(eid modules.RegistryEntryID) {
+	rs.mu.Lock()
+	_, subscribed := rs.subscriptions[eid]
+	delete(rs.subscriptions, eid)
+	rs.mu.Unlock()
+	if !subscribed {
+		return
+	}
+	rs.staticManager.managedRemoveSubscriber(rs, eid)
+}
+
+// managedNotify calls the subscriber's notify func for the provided value if
+// the subscriber is still subscribed to the entry and hasn't been notified
+// about the value's revision yet. If the notify func returns an error, the
+// subscriber is closed.
+func (rs *registrySubscriber) managedNotify(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	rs.notifyMu.Lock()
+	defer rs.notifyMu.Unlock()
+
+	eid := modules.DeriveRegistryEntryID(spk, srv.Tweak)
+	rs.mu.Lock()
+	rev, subscribed := rs.subscriptions[eid]
+	if rs.closed || !subscribed || (rev != nil && *rev >= srv.Revision) {
+		rs.mu.Unlock()
+		return
+	}
+	revision := srv.Revision
+	rs.subscriptions[eid] = &revision
+	rs.mu.Unlock()
+
+	err := rs.staticNotifyFunc(spk, srv)
+	if err != nil {
+		rs.staticManager.staticRenter.log.Debugln("closing registry subscriber after failed notification:", err)
+		_ = rs.Close()
+	}
+}
@@ -0,0 +1,194 @@
+package renter
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRegistrySubscriber tests the basic functionality of the renter's
+// registry subscribers.
+func TestRegistrySubscriber(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	m := r.staticSubscriptionManager
+
+	// Create a subscriber which collects the notifications.
+	var mu sync.Mutex
+	var notifications []modules.SignedRegistryValue
+	subscriber, err := r.NewRegistrySubscriber(func(_ types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+		mu.Lock()
+		defer mu.Unlock()
+		notifications = append(notifications, srv)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	numNotifications := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(notifications)
+	}
+
+	// Subscribe to a random entry. The renter has no workers so the entry
+	// can't be found.
+	rv, spk, sk := randomRegistryValue()
+	eid := modules.DeriveRegistryEntryID(spk, rv.Tweak)
+	initialValue, err := subscriber.Subscribe(spk, rv.Tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initialValue != nil {
+		t.Fatal("expected no initial value", initialValue)
+	}
+	m.mu.Lock()
+	if len(m.subscriptions) != 1 {
+		t.Fatal("wrong number of subscriptions", len(m.subscriptions))
+	}
+	m.mu.Unlock()
+
+	// Notify the manager about an update. The subscriber should be notified.
+	m.managedNotify(spk, rv)
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if n := numNotifications(); n != 1 {
+			return fmt.Errorf("expected 1 notification but got %v", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Notify the manager about the same revision again. This shouldn't
+	// result in a notification.
+	m.managedNotify(spk, rv)
+
+	// Notify the manager about a newer revision.
+	rv2 := rv
+	rv2.Revision++
+	rv2 = rv2.Sign(sk)
+	m.managedNotify(spk, rv2)
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if n := numNotifications(); n != 2 {
+			return fmt.Errorf("expected 2 notifications but got %v", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if !reflect.DeepEqual(notifications[0], rv) || !reflect.DeepEqual(notifications[1], rv2) {
+		t.Error("wrong notifications", notifications)
+	}
+	mu.Unlock()
+
+	// Subscribing again should return the latest value.
+	initialValue, err = subscriber.Subscribe(spk, rv.Tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initialValue == nil || !reflect.DeepEqual(*initialValue, rv2) {
+		t.Fatal("wrong initial value", initialValue)
+	}
+
+	// Unsubscribe. The manager shouldn't track the entry anymore and
+	// further updates shouldn't reach the subscriber.
+	subscriber.Unsubscribe(eid)
+	m.mu.Lock()
+	if len(m.subscriptions) != 0 {
+		t.Fatal("wrong number of subscriptions", len(m.subscriptions))
+	}
+	m.mu.Unlock()
+	rv3 := rv2
+	rv3.Revision++
+	rv3 = rv3.Sign(sk)
+	m.managedNotify(spk, rv3)
+	time.Sleep(100 * time.Millisecond)
+	if n := numNotifications(); n != 2 {
+		t.Fatal("expected 2 notifications but got", n)
+	}
+
+	// Close the subscriber. It can't be used afterwards.
+	if err := subscriber.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = subscriber.Subscribe(spk, rv.Tweak)
+	if !errors.Contains(err, errSubscriberClosed) {
+		t.Fatal("expected errSubscriberClosed but got", err)
+	}
+}
+
+// TestRegistrySubscriberNotifyError tests that a subscriber is closed once its
+// notify func returns an error.
+func TestRegistrySubscriberNotifyError(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	m := r.staticSubscriptionManager
+
+	// Create a subscriber which fails to handle notifications.
+	subscriber, err := r.NewRegistrySubscriber(func(_ types.SiaPublicKey, _ modules.SignedRegistryValue) error {
+		return errors.New("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv, spk, _ := randomRegistryValue()
+	_, err = subscriber.Subscribe(spk, rv.Tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Notify the manager. The subscriber should be closed and the
+	// subscription removed.
+	m.managedNotify(spk, rv)
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if len(m.subscriptions) != 0 {
+			return fmt.Errorf("wrong number of subscriptions %v", len(m.subscriptions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = subscriber.Subscribe(spk, rv.Tweak)
+	if !errors.Contains(err, errSubscriberClosed) {
+		t.Fatal("expected errSubscriberClosed but got", err)
+	}
+}
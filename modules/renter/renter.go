@@ -109,6 +109,9 @@ type hostContractor interface {
 	// contracts within a separate thread.
 	InitRecoveryScan() error
 
+	// Identity returns the public key which identifies the renter to hosts.
+	Identity() (types.SiaPublicKey, error)
+
 	// PeriodSpending returns the amount spent on contracts during the current
 	// billing period.
 	PeriodSpending() (modules.ContractorSpending, error)
@@ -255,9 +258,12 @@ type Renter struct {
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
 	staticAlerter                      *modules.GenericAlerter
+	staticDedupIndex                   *dedupIndex
+	staticPackIndex                    *packIndex
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
 	staticStreamBufferSet              *streamBufferSet
+	staticSubscriptionManager          *registrySubscriptionManager
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
 	wal                                *writeaheadlog.WAL
@@ -624,6 +630,7 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.Dedup = s.Dedup
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -789,6 +796,11 @@ func (r *Renter) OldContracts() []modules.RenterContract {
 	return r.hostContractor.OldContracts()
 }
 
+// Identity returns the public key which identifies the renter to hosts.
+func (r *Renter) Identity() (types.SiaPublicKey, error) {
+	return r.hostContractor.Identity()
+}
+
 // PeriodSpending returns the host contractor's period spending
 func (r *Renter) PeriodSpending() (modules.ContractorSpending, error) {
 	return r.hostContractor.PeriodSpending()
@@ -819,6 +831,7 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 	paused, endTime := r.uploadHeap.managedPauseStatus()
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
+		Dedup:            r.managedDedupEnabled(),
 		IPViolationCheck: enabled,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
@@ -978,8 +991,21 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	if err != nil {
 		return nil, err
 	}
+	r.staticDedupIndex, err = newDedupIndex(filepath.Join(r.persistDir, dedupFilename), r.managedDedupSecret)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.AfterStop(r.staticDedupIndex.Close); err != nil {
+		return nil, err
+	}
+	r.staticPackIndex, err = newPackIndex(filepath.Join(r.persistDir, packsFilename))
+	if err != nil {
+		return nil, err
+	}
 
-	// After persist is initialized, create the worker pool.
+	// After persist is initialized, create the worker pool. The subscription
+	// manager needs to exist before the workers are created.
+	r.staticSubscriptionManager = newRegistrySubscriptionManager(r)
 	r.staticWorkerPool = r.newWorkerPool()
 
 	// Set the worker pool on the contractor.
@@ -774,6 +774,10 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, of
 
 // managedUpdateFileMetadata updates the metadata of a siafile.
 func (r *Renter) managedUpdateFileMetadata(sf *filesystem.FileNode, offlineMap, goodForRenew map[string]bool, contracts map[string]modules.RenterContract, used []types.SiaPublicKey) (err error) {
+	// Packed files copy their metadata from their pack.
+	if info, packed := sf.Packed(); packed {
+		return r.managedUpdatePackedFileMetadata(sf, info)
+	}
 	// Update the siafile's used hosts.
 	if err := sf.UpdateUsedHosts(used); err != nil {
 		return errors.AddContext(err, "WARN: Could not update used hosts")
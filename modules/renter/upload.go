@@ -60,9 +60,10 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		}
 	}
 
-	// Fill in any missing upload params with sensible defaults.
-	if up.ErasureCode == nil {
-		up.ErasureCode = modules.NewRSSubCodeDefault()
+	// Fill in any missing upload params using the policy of the siadir or
+	// sensible defaults.
+	if err := r.managedApplyDirPolicy(&up); err != nil {
+		return err
 	}
 
 	// Check that we have contracts to upload to. We need at least data +
@@ -82,12 +83,6 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return err
 	}
 
-	// Determine what type of encryption key to use. If no cipher type has been
-	// set, the default renter type will be used.
-	var ct crypto.CipherType
-	if up.CipherType == ct {
-		up.CipherType = crypto.TypeDefaultRenter
-	}
 	// Generate a key using the cipher type.
 	cipherKey := crypto.GenerateSiaKey(up.CipherType)
 
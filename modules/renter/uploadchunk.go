@@ -68,6 +68,13 @@ type unfinishedUploadChunk struct {
 	// available it will be tried before the repair path or remote repair.
 	sourceReader io.ReadCloser
 
+	// dedupKey is the key of the chunk if it is deduplicated. If set, the
+	// pieces are encrypted with keys derived from it instead of the file's
+	// master key. deduplicated indicates that the pieces of an identical chunk
+	// were added to the file and that the chunk doesn't need to be uploaded.
+	dedupKey     crypto.CipherKey
+	deduplicated bool
+
 	// Performance information.
 	chunkCreationTime        time.Time
 	chunkPoppedFromHeapTime  time.Time
@@ -236,11 +243,13 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
 // index i and then encrypt it.
 func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+	key := pieceKey(uc.fileEntry.MasterKey(), uc.dedupKey, uc.staticIndex, uint64(i))
+	padAndEncryptPiece(uint64(i), uc.logicalChunkData, key)
 }
 
-// padAndEncryptPiece will add padding to a piece and then encrypt it.
-func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte, masterKey crypto.CipherKey) {
+// padAndEncryptPiece will add padding to a piece and then encrypt it with the
+// provided key.
+func padAndEncryptPiece(pieceIndex uint64, logicalChunkData [][]byte, key crypto.CipherKey) {
 	// If the piece is not a full sector, pad it with empty bytes. The padding
 	// is done before applying encryption, meaning the data fed to the host does
 	// not have a bunch of zeroes in it.
@@ -254,7 +263,6 @@ func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte
 		logicalChunkData[pieceIndex] = append(logicalChunkData[pieceIndex], make([]byte, short)...)
 	}
 	// Encrypt the piece.
-	key := masterKey.Derive(chunkIndex, pieceIndex)
 	// TODO: Switch this to perform in-place encryption.
 	logicalChunkData[pieceIndex] = key.EncryptBytes(logicalChunkData[pieceIndex])
 }
@@ -263,6 +271,11 @@ func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte
 // download to the renter's downloader, and then using the data that gets
 // returned.
 func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) error {
+	// The data of re-encoded copies is downloaded from the original file.
+	if source, ok := redundancyChangeSource(r.staticFileSystem.FileSiaPath(chunk.fileEntry)); ok {
+		return r.managedDownloadSourceChunkData(chunk, source)
+	}
+
 	//  Determine what the download length should be. Normally it is just the
 	//  chunk size, but if this is the last chunk we need to download less
 	//  because the file is not that large.
@@ -421,6 +434,24 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	// fetching, where the erasure coding occurs.
 	chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
 	chunk.memoryReleased += erasureCodingMemory + pieceCompletedMemory
+
+	// A deduplicated chunk already references the pieces of an identical
+	// chunk and doesn't need to be distributed to the workers. The cleanup
+	// code will free the remaining memory.
+	if chunk.deduplicated {
+		pieces, err := chunk.fileEntry.Pieces(chunk.staticIndex)
+		if err != nil {
+			r.repairLog.Printf("Unable to get pieces of deduplicated chunk %v of %s: %v", chunk.staticIndex, chunk.staticSiaPath, err)
+		}
+		chunk.mu.Lock()
+		chunk.piecesCompleted = numUploadedPieces(pieces)
+		chunk.workersRemaining = 0
+		chunk.logicalChunkData = nil
+		chunk.mu.Unlock()
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Swap the physical chunk data and the logical chunk data. There is
 	// probably no point to having both, given that we perform such a clean
 	// handoff here, but since the code is already written this way, it may be
@@ -517,8 +548,16 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 		return errors.AddContext(err, "unable to read the chunk data from the source reader")
 	}
 
+	// Deduplicate the chunk if possible. A deduplicated chunk won't be
+	// uploaded which means it doesn't need to be encrypted.
+	if err := r.managedTryDedupChunk(uc); err != nil {
+		return errors.AddContext(err, "unable to deduplicate chunk")
+	}
+
 	// Perform an integrity check on the data that was pulled from the reader.
-	err = uc.staticEncryptAndCheckIntegrity()
+	if !uc.deduplicated {
+		err = uc.staticEncryptAndCheckIntegrity()
+	}
 	if err != nil {
 		return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
 	}
@@ -580,6 +619,12 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
 		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+		if err := r.managedTryDedupChunk(uc); err != nil {
+			return errors.AddContext(err, "unable to deduplicate chunk")
+		}
+		if uc.deduplicated {
+			return nil
+		}
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -697,6 +742,13 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 		if err != nil {
 			r.log.Print("managedCleanUpUploadChunk: failed to update file metadata", err)
 		}
+		// Remember the pieces of a deduplicated chunk for future uploads of
+		// the same data.
+		if err := r.managedUpdateDedupPieces(uc); err != nil {
+			r.log.Print("managedCleanUpUploadChunk: failed to update dedup index", err)
+		}
+		// Replace the original file if this chunk completed a re-encoded copy.
+		r.managedTryFinishRedundancyChange(uc.fileEntry, offlineMap, goodForRenewMap)
 
 		// Close the file entry for the completed chunk unless disrupted.
 		if !r.deps.Disrupt("disableCloseUploadEntry") {
@@ -564,6 +564,17 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		uuc.unusedHosts[host] = struct{}{}
 	}
 
+	// Pieces of deduplicated chunks are encrypted with the chunk's dedup key.
+	dedupID, err := entry.DedupID(chunkIndex)
+	if err != nil {
+		r.log.Println("WARN: unable to get DedupID:", err)
+		return nil, errors.AddContext(err, "unable to get DedupID")
+	}
+	uuc.dedupKey, err = r.managedDedupKey(entry.MasterKey().Type(), dedupID)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to derive dedup key")
+	}
+
 	// Iterate through the pieces of all chunks of the file and mark which
 	// hosts are already in use for a particular chunk. As you delete hosts
 	// from the 'unusedHosts' map, also increment the 'piecesCompleted' value.
@@ -676,6 +687,13 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		pks[string(pk.Key)] = pk
 	}
 
+	// Only repair the chunks using the hosts pinned by the siadir's policy.
+	hosts = r.managedPinnedHosts(entry, hosts)
+
+	// Chunks of re-encoded copies are always repairable since their data is
+	// fetched from the original file.
+	_, isRedundancyChange := redundancyChangeSource(r.staticFileSystem.FileSiaPath(entry))
+
 	// Assemble the set of chunks.
 	newUnfinishedChunks := make([]*unfinishedUploadChunk, 0, len(chunkIndexes))
 	for _, index := range chunkIndexes {
@@ -709,7 +727,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		// accessed without error. If there is an error accessing the file then
 		// it is likely that we can not read the file in which case it can not
 		// be used for repair.
-		repairable := chunk.health <= 1 || chunk.onDisk
+		repairable := chunk.health <= 1 || chunk.onDisk || isRedundancyChange
 		needsRepair := modules.NeedsRepair(chunk.health)
 
 		if r.deps.Disrupt("AddUnrepairableChunks") && needsRepair {
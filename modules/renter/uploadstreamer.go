@@ -137,12 +137,15 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // SiaFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
 	siaPath, ec, force, repair, cipherType := up.SiaPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
-	// Check if ec was set. If not use defaults.
+	// Check if ec was set. If not use the siadir's policy or defaults.
 	var err error
-	if ec == nil && !repair {
-		ec = modules.NewRSSubCodeDefault()
-		up.ErasureCode = ec
-	} else if ec != nil && repair {
+	if !repair {
+		err = r.managedApplyDirPolicy(&up)
+		if err != nil {
+			return nil, err
+		}
+		ec, cipherType = up.ErasureCode, up.CipherType
+	} else if ec != nil {
 		return nil, errors.New("can't provide erasure code settings when doing repairs")
 	}
 
@@ -200,32 +203,34 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 // the Sia network, this will happen faster than the entire upload is complete -
 // the streamer may continue uploading in the background after returning while
 // it is boosting redundancy.
-func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (fileNode *filesystem.FileNode, err error) {
+func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (*filesystem.FileNode, error) {
 	// Check the upload params first.
-	fileNode, err = r.managedInitUploadStream(up)
+	fileNode, err := r.managedInitUploadStream(up)
 	if err != nil {
 		return nil, err
 	}
-	// Need to make a copy of this value for the defer statement. Because
-	// 'fileNode' is a named value, if you run the call `return nil, err`, then
-	// 'fileNode' will be set to 'nil' when 'fileNode.Close()' gets called in
-	// the 'defer'.
-	fn := fileNode
-	defer func() {
+	err = r.callUploadStreamToFileNode(fileNode, reader)
+	if err != nil {
 		// Ensure the fileNode is closed if there is an error upon return.
-		if err != nil {
-			err = errors.Compose(err, fn.Close())
-		}
-	}()
+		return nil, errors.Compose(err, fileNode.Close())
+	}
+	return fileNode, nil
+}
 
+// callUploadStreamToFileNode reads from the provided reader until io.EOF is
+// reached and uploads the data to the SiaFile of the provided fileNode, which
+// is expected to be prepared by managedInitUploadStream. The fileNode is not
+// closed.
+func (r *Renter) callUploadStreamToFileNode(fileNode *filesystem.FileNode, reader io.Reader) (err error) {
 	// Build a map of host public keys.
 	pks := make(map[string]types.SiaPublicKey)
 	for _, pk := range fileNode.HostPublicKeys() {
 		pks[string(pk.Key)] = pk
 	}
 
-	// Get the most recent workers.
-	hosts := r.managedRefreshHostsAndWorkers()
+	// Get the most recent workers and limit them to the hosts pinned by the
+	// siadir's policy.
+	hosts := r.managedPinnedHosts(fileNode, r.managedRefreshHostsAndWorkers())
 
 	// Check if we currently have enough workers for the specified redundancy.
 	minWorkers := fileNode.ErasureCode().MinPieces()
@@ -233,7 +238,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	availableWorkers := len(r.staticWorkerPool.workers)
 	r.staticWorkerPool.mu.RUnlock()
 	if availableWorkers < minWorkers {
-		return nil, fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
+		return fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
 	}
 
 	// Read the chunks we want to upload one by one from the input stream using
@@ -253,14 +258,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		// Grow the SiaFile to the right size. Otherwise buildUnfinishedChunk
 		// won't realize that there are pieces which haven't been repaired yet.
 		if err := fileNode.SiaFile.GrowNumChunks(chunkIndex + 1); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Start the chunk upload.
 		offline, goodForRenew, _ := r.managedContractUtilityMaps()
 		uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.userUploadMemoryManager)
 		if err != nil {
-			return nil, errors.AddContext(err, "unable to fetch chunk for stream")
+			return errors.AddContext(err, "unable to fetch chunk for stream")
 		}
 
 		// Create a new shard set it to be the source reader of the chunk.
@@ -272,14 +277,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// Add the chunk to the upload heap's repair map.
 			pushed, err := r.managedPushChunkForRepair(uuc, chunkTypeStreamChunk)
 			if err != nil {
-				return nil, errors.AddContext(err, "unable to push chunk")
+				return errors.AddContext(err, "unable to push chunk")
 			}
 			if !pushed {
 				// The chunk wasn't added to the repair map meaning it must have
 				// already been in the repair map
 				_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 				if err := ss.Close(); err != nil {
-					return nil, err
+					return err
 				}
 			}
 			chunks = append(chunks, uuc)
@@ -290,13 +295,13 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// since we check that anyway at the end of the loop.
 			_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 			if err := ss.Close(); err != nil {
-				return nil, err
+				return err
 			}
 		}
 		// Wait for the shard to be read.
 		select {
 		case <-r.tg.StopChan():
-			return nil, errors.New("interrupted by shutdown")
+			return errors.New("interrupted by shutdown")
 		case <-ss.signalChan:
 		}
 
@@ -306,7 +311,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// All chunks successfully submitted.
 			break
 		} else if ss.err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 
 		// Call Peek to make sure that there's more data for another shard.
@@ -314,7 +319,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
 			break
 		} else if err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 	}
 
@@ -329,14 +334,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			chunk.mu.Unlock()
 		}
 		if err != nil {
-			return nil, errors.AddContext(err, "upload streamer failed to get all data available")
+			return errors.AddContext(err, "upload streamer failed to get all data available")
 		}
 	}
 
 	// Disrupt to force an error and ensure the fileNode is being closed
 	// correctly.
 	if r.deps.Disrupt("failUploadStreamFromReader") {
-		return nil, errors.New("disrupted by failUploadStreamFromReader")
+		return errors.New("disrupted by failUploadStreamFromReader")
 	}
-	return fileNode, nil
+	return nil
 }
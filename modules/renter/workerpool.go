@@ -109,6 +109,8 @@ func (wp *workerPool) callUpdate() {
 		if err != nil {
 			return
 		}
+		// Subscribe the worker to the renter's registry subscriptions.
+		wp.renter.staticSubscriptionManager.callSubscribeWorker(w)
 	}
 
 	// Remove a worker for any worker that is not in the set of new contracts.
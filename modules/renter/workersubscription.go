@@ -203,9 +203,9 @@ func (nh *notificationHandler) managedHandleRegistryEntry(stream siamux.Stream,
 	// not seem bad, but the host might want to spam us with valid entries that
 	// we are not interested in simply to have us pay for bandwidth.
 	subInfo.mu.Lock()
-	defer subInfo.mu.Unlock()
 	sub, exists := subInfo.subscriptions[modules.DeriveRegistryEntryID(sneu.PubKey, sneu.Entry.Tweak)]
 	if !exists || (sub.latestRV != nil && sub.latestRV.Revision >= sneu.Entry.Revision) {
+		defer subInfo.mu.Unlock()
 		if exists && sub.latestRV != nil {
 			return fmt.Errorf("host sent an outdated revision %v >= %v", sub.latestRV.Revision, sneu.Entry.Revision)
 		}
@@ -214,6 +214,10 @@ func (nh *notificationHandler) managedHandleRegistryEntry(stream siamux.Stream,
 
 	// Update the subscription.
 	sub.latestRV = &sneu.Entry
+	subInfo.mu.Unlock()
+
+	// Notify the renter's subscribers about the update.
+	w.renter.staticSubscriptionManager.managedNotify(sneu.PubKey, sneu.Entry)
 	return nil
 }
 
@@ -837,6 +841,9 @@ func (w *worker) Subscribe(ctx context.Context, requests ...modules.RPCRegistryS
 			sub = newSubscription(&requests[i])
 			subInfo.subscriptions[sid] = sub
 		}
+		// Make sure a subscription that was previously unsubscribed from is
+		// subscribed to again.
+		sub.subscribe = true
 		subs = append(subs, sub)
 		subChans = append(subChans, sub.subscribed)
 	}
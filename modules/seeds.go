@@ -26,6 +26,7 @@ var (
 	// The following specifiers are used for deriving different seeds from the
 	// wallet seed.
 	identifierSeedSpecifier = types.NewSpecifier("identifierseed")
+	identitySeedSpecifier   = types.NewSpecifier("identityseed")
 	renterSeedSpecifier     = types.NewSpecifier("renter")
 	secretKeySeedSpecifier  = types.NewSpecifier("secretkeyseed")
 	signingKeySeedSpecifier = types.NewSpecifier("signingkeyseed")
@@ -145,6 +146,15 @@ func GenerateContractKeyPairWithOutputID(renterSeed EphemeralRenterSeed, inputPa
 	return crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte(entropy))
 }
 
+// IdentityKeyPair derives the renter's identity key pair from the renter seed.
+// Unlike the keys of the contracts, the identity is the same for every contract
+// of the renter. It is used to prove the renter's identity to hosts.
+func (rs RenterSeed) IdentityKeyPair() (sk crypto.SecretKey, pk crypto.PublicKey) {
+	entropy := crypto.HashAll(rs, identitySeedSpecifier)
+	defer fastrand.Read(entropy[:])
+	return crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte(entropy))
+}
+
 // DeriveRenterSeed creates a renterSeed for creating file contracts.
 // NOTE: The seed returned by this function should be wiped once it's no longer
 // in use.
@@ -55,6 +55,14 @@ var (
 
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
+
+	// RedundancyFolder is the Sia folder where the renter stores the
+	// re-encoded copies of siafiles whose redundancy is being changed.
+	RedundancyFolder = NewGlobalSiaPath("/var/redundancy")
+
+	// PackFolder is the Sia folder where the renter stores the pack files
+	// which hold the data of small files uploaded in a batch.
+	PackFolder = NewGlobalSiaPath("/var/packs")
 )
 
 type (
@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"go.sia.tech/siad/crypto"
 )
 
@@ -23,6 +25,12 @@ type (
 		Index             uint16 `json:"index"`
 		Path              string `json:"path"`
 
+		// FastTier indicates that the storage folder is on fast storage and
+		// is reserved for the sector read cache. Fast tier folders don't
+		// store sectors of their own and don't count towards the storage
+		// offered by the host.
+		FastTier bool `json:"fasttier"`
+
 		// Below are statistics about the filesystem. FailedReads and
 		// FailedWrites are only incremented if the filesystem is returning
 		// errors when operations are being performed. A large number of
@@ -34,6 +42,16 @@ type (
 		SuccessfulReads  uint64 `json:"successfulreads"`
 		SuccessfulWrites uint64 `json:"successfulwrites"`
 
+		// The storage manager periodically scrubs the storage folders by
+		// re-reading the stored sectors and verifying their Merkle roots.
+		// CorruptSectors is the number of sectors in the folder which were
+		// found to be unreadable or corrupt, ScrubbedSectors the number of
+		// sectors checked during this boot cycle and LastScrub the time at
+		// which the last complete pass over the folder finished.
+		CorruptSectors  uint64    `json:"corruptsectors"`
+		ScrubbedSectors uint64    `json:"scrubbedsectors"`
+		LastScrub       time.Time `json:"lastscrub"`
+
 		// Certain operations on a storage folder can take a long time (Add,
 		// Remove, and Resize). The fields below indicate the progress of any
 		// long running operations that might be under way in the storage
@@ -42,6 +60,21 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// SectorCacheMetrics contains statistics about the sector read cache
+	// which the storage manager keeps on its fast tier storage folders. The
+	// statistics are kept for the current boot cycle.
+	SectorCacheMetrics struct {
+		Capacity uint64 `json:"capacity"` // bytes
+		Size     uint64 `json:"size"`     // bytes
+
+		// Hits and Misses count the sector reads which were served from and
+		// which missed the cache. Reads are only counted while there is at
+		// least one fast tier storage folder.
+		Hits    uint64  `json:"hits"`
+		Misses  uint64  `json:"misses"`
+		HitRate float64 `json:"hitrate"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -78,6 +111,15 @@ type (
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CorruptSectors returns the roots of the provided sectors which were
+		// found to be unreadable or corrupt while scrubbing the storage
+		// folders.
+		CorruptSectors(sectorRoots []crypto.Hash) []crypto.Hash
+
+		// CorruptSectorsVersion returns a counter which is incremented
+		// whenever the set of corrupt sectors changes.
+		CorruptSectorsVersion() uint64
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -86,6 +128,14 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MigrateStorageFolder moves all sectors of a storage folder to the
+		// storage folder at the provided path, adding a storage folder of the
+		// same size if there is none, and removes the old storage folder once
+		// every sector has been moved. The manager keeps serving sectors while
+		// they are moved. If any sector can't be moved, an error is returned
+		// and the old storage folder is kept.
+		MigrateStorageFolder(index uint16, path string) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -128,6 +178,15 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// SectorCacheMetrics returns statistics about the sector read cache.
+		SectorCacheMetrics() SectorCacheMetrics
+
+		// SetStorageFolderFastTier tags a storage folder as fast tier or
+		// standard tier storage. The sectors of a storage folder are moved to
+		// other storage folders before it becomes part of the fast tier,
+		// which is then used for the sector read cache.
+		SetStorageFolderFastTier(index uint16, fastTier bool) error
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
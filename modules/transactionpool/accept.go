@@ -21,6 +21,10 @@ var (
 	errEmptySet     = errors.New("transaction set is empty")
 	errLowMinerFees = errors.New("transaction set needs more miner fees to be accepted")
 
+	// errLowReplacementFees is returned when a transaction set double spends
+	// transactions in the pool without paying enough fees to replace them.
+	errLowReplacementFees = errors.New("transaction set needs more miner fees to replace the conflicting transactions")
+
 	// ErrTxnSetNotAccepted is the error returned when the dependency
 	// DoNotAcceptTxnSet is used
 	ErrTxnSetNotAccepted = errors.New("transaction set was not accepted")
@@ -163,17 +167,43 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 	// small enough to be legal and valid as a set. If no, return an error. If
 	// yes, add the new set to the pool, and eliminate the old set. The output
 	// diff objects can be repeated, (no need to remove those). Just need to
-	// remove the conflicts from tp.transactionSets.
+	// remove the conflicts from tp.transactionSets. Transactions that are
+	// double spent by the input set are replaced and left out of the superset.
 	var superset []types.Transaction
 	supersetMap := make(map[modules.TransactionSetID]struct{})
 	for _, conflict := range conflictMap {
 		supersetMap[conflict] = struct{}{}
 	}
+	replaced := tp.replacedTransactions(dedupSet, supersetMap)
 	for conflict := range supersetMap {
-		superset = append(superset, tp.transactionSets[conflict]...)
+		for _, txn := range tp.transactionSets[conflict] {
+			if _, exists := replaced[txn.ID()]; !exists {
+				superset = append(superset, txn)
+			}
+		}
 	}
 	superset = append(superset, dedupSet...)
 
+	// A replacement has to pay for the replaced transactions and the
+	// bandwidth of relaying the input set.
+	if len(replaced) > 0 {
+		var replacedFees, dedupFees types.Currency
+		for _, txn := range replaced {
+			for _, fee := range txn.MinerFees {
+				replacedFees = replacedFees.Add(fee)
+			}
+		}
+		for _, txn := range dedupSet {
+			for _, fee := range txn.MinerFees {
+				dedupFees = dedupFees.Add(fee)
+			}
+		}
+		dedupSize := uint64(len(encoding.Marshal(dedupSet)))
+		if dedupFees.Cmp(replacedFees.Add(minReplacementFeeIncrease.Mul64(dedupSize))) < 0 {
+			return nil, errLowReplacementFees
+		}
+	}
+
 	// Check the composition of the transaction set, including fees and
 	// IsStandard rules (this is a new set, the rules must be rechecked).
 	setSize, err := tp.checkTransactionSetComposition(superset)
@@ -209,6 +239,17 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 		delete(tp.transactionSets, conflict)
 		delete(tp.transactionSetDiffs, conflict)
 	}
+	// Forget the objects of the replaced transactions. The objects that are
+	// still part of the superset are added back below.
+	for id, txn := range replaced {
+		for _, oid := range relatedObjectIDs([]types.Transaction{txn}) {
+			if _, exists := supersetMap[tp.knownObjects[oid]]; exists {
+				delete(tp.knownObjects, oid)
+			}
+		}
+		delete(tp.transactionHeights, id)
+		tp.log.Debugf("transaction %v was replaced", id)
+	}
 
 	// Add the transaction set to the pool.
 	setID := modules.TransactionSetID(crypto.HashObject(superset))
@@ -243,6 +284,66 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 	return superset, nil
 }
 
+// replacedTransactions returns the transactions of the conflicting sets that
+// are replaced by the transaction set ts. A transaction is replaced if it
+// spends a siacoin or siafund output that is also spent by ts, or if it depends
+// on a replaced transaction.
+func (tp *TransactionPool) replacedTransactions(ts []types.Transaction, conflicts map[modules.TransactionSetID]struct{}) map[types.TransactionID]types.Transaction {
+	spent := make(map[ObjectID]struct{})
+	for _, txn := range ts {
+		for _, sci := range txn.SiacoinInputs {
+			spent[ObjectID(sci.ParentID)] = struct{}{}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			spent[ObjectID(sfi.ParentID)] = struct{}{}
+		}
+	}
+
+	// Transaction sets are ordered by dependency, so a transaction's parents
+	// are always visited before the transaction itself.
+	replaced := make(map[types.TransactionID]types.Transaction)
+	removed := make(map[ObjectID]struct{})
+	for conflict := range conflicts {
+		for _, txn := range tp.transactionSets[conflict] {
+			var parents []ObjectID
+			for _, sci := range txn.SiacoinInputs {
+				parents = append(parents, ObjectID(sci.ParentID))
+			}
+			for _, sfi := range txn.SiafundInputs {
+				parents = append(parents, ObjectID(sfi.ParentID))
+			}
+			var isReplaced bool
+			for _, oid := range parents {
+				_, doubleSpent := spent[oid]
+				_, removedParent := removed[oid]
+				isReplaced = isReplaced || doubleSpent || removedParent
+			}
+			for _, fcr := range txn.FileContractRevisions {
+				_, removedParent := removed[ObjectID(fcr.ParentID)]
+				isReplaced = isReplaced || removedParent
+			}
+			for _, sp := range txn.StorageProofs {
+				_, removedParent := removed[ObjectID(sp.ParentID)]
+				isReplaced = isReplaced || removedParent
+			}
+			if !isReplaced {
+				continue
+			}
+			replaced[txn.ID()] = txn
+			for i := range txn.SiacoinOutputs {
+				removed[ObjectID(txn.SiacoinOutputID(uint64(i)))] = struct{}{}
+			}
+			for i := range txn.FileContracts {
+				removed[ObjectID(txn.FileContractID(uint64(i)))] = struct{}{}
+			}
+			for i := range txn.SiafundOutputs {
+				removed[ObjectID(txn.SiafundOutputID(uint64(i)))] = struct{}{}
+			}
+		}
+	}
+	return replaced
+}
+
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
 // transaction pool, and then adds it to the transaction pool.
 func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) (superset []types.Transaction, err error) {
@@ -130,6 +130,87 @@ func TestConflictingTransactionSets(t *testing.T) {
 	}
 }
 
+// TestReplaceByFee checks that a transaction in the pool can be replaced by a
+// conflicting transaction which pays higher fees.
+func TestReplaceByFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Create a transaction pool tester.
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Fund a partial transaction.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// wholeTransaction is set to false so that we can use the same signature
+	// to create conflicting transactions.
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnIndex := len(txnSet) - 1
+
+	// lowFee sends most of the funds to an output, highFee pays them as a fee.
+	// insufficientFee pays exactly the fee of lowFee, which isn't enough to
+	// replace it.
+	lowFee := make([]types.Transaction, len(txnSet))
+	copy(lowFee, txnSet)
+	lowFee[txnIndex].SiacoinOutputs = append(lowFee[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund.Div64(2)})
+	lowFee[txnIndex].MinerFees = append(lowFee[txnIndex].MinerFees, fund.Div64(2))
+	insufficientFee := make([]types.Transaction, len(txnSet))
+	copy(insufficientFee, txnSet)
+	insufficientFee[txnIndex].SiacoinOutputs = append(insufficientFee[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund.Div64(2), UnlockHash: types.UnlockHash{1}})
+	insufficientFee[txnIndex].MinerFees = append(insufficientFee[txnIndex].MinerFees, fund.Div64(2))
+	highFee := make([]types.Transaction, len(txnSet))
+	copy(highFee, txnSet)
+	highFee[txnIndex].MinerFees = append(highFee[txnIndex].MinerFees, fund)
+
+	if err := tpt.tpool.AcceptTransactionSet(lowFee); err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.AcceptTransactionSet(insufficientFee)
+	if !errors.Contains(err, errLowReplacementFees) {
+		t.Fatal("expected errLowReplacementFees, got", err)
+	}
+	if err := tpt.tpool.AcceptTransactionSet(highFee); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool should contain the replacement but not the original.
+	if _, _, exists := tpt.tpool.Transaction(lowFee[txnIndex].ID()); exists {
+		t.Fatal("replaced transaction is still in the pool")
+	}
+	if _, _, exists := tpt.tpool.Transaction(highFee[txnIndex].ID()); !exists {
+		t.Fatal("replacement is not in the pool")
+	}
+	if len(tpt.tpool.TransactionList()) != len(txnSet) {
+		t.Fatal("unexpected number of transactions in the pool", len(tpt.tpool.TransactionList()))
+	}
+
+	// Mine a block to confirm the replacement.
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("replacement wasn't mined")
+	}
+}
+
 // TestCheckMinerFees probes the checkMinerFees method of the
 // transaction pool.
 func TestCheckMinerFees(t *testing.T) {
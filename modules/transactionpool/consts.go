@@ -74,6 +74,12 @@ var (
 	// minEstimation defines a sane minimum fee per byte for transactions.  This
 	// will typically be only suggested as a fee in the absence of congestion.
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
+	// minReplacementFeeIncrease is the fee per byte of the replacing
+	// transactions that a replacement has to pay on top of the fees of the
+	// transactions it replaces. This prevents replacements from being relayed
+	// across the network for free.
+	minReplacementFeeIncrease = minEstimation
 )
 
 // Variables related to propagating transactions through the network.
@@ -27,6 +27,16 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// FeeBumpReplace bumps the fee of an unconfirmed transaction by replacing
+	// it with a conflicting transaction that pays a higher fee.
+	FeeBumpReplace FeeBumpMethod = "rbf"
+
+	// FeeBumpChild bumps the fee of an unconfirmed transaction by spending
+	// one of its outputs in a child transaction that pays a higher fee.
+	FeeBumpChild FeeBumpMethod = "cpfp"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -59,6 +69,10 @@ type (
 	// WalletTransactionID is a unique identifier for a wallet transaction.
 	WalletTransactionID crypto.Hash
 
+	// FeeBumpMethod is the method used to increase the fee of an unconfirmed
+	// transaction.
+	FeeBumpMethod string
+
 	// A ProcessedInput represents funding to a transaction. The input is
 	// coming from an address and going to the outputs. The fund types are
 	// 'SiacoinInput', 'SiafundInput'.
@@ -117,6 +131,31 @@ type (
 		ConfirmedOutgoingValue types.Currency `json:"confirmedoutgoingvalue"`
 	}
 
+	// A PaymentSchedule is a payment which the wallet sends once the
+	// blockchain reaches NextHeight. Recurring payments are sent again every
+	// Interval blocks.
+	PaymentSchedule struct {
+		ID         crypto.Hash           `json:"id"`
+		Label      string                `json:"label"`
+		Outputs    []types.SiacoinOutput `json:"outputs"`
+		NextHeight types.BlockHeight     `json:"nextheight"`
+
+		// Interval is the number of blocks between payments. A zero interval
+		// means that the payment is only sent once.
+		Interval types.BlockHeight `json:"interval"`
+
+		// Remaining is the number of payments which are left before the
+		// schedule completes. Zero means that a recurring payment is sent
+		// until the schedule is removed.
+		Remaining uint64 `json:"remaining"`
+
+		// The following fields are maintained by the wallet.
+		Completed         bool                `json:"completed"`
+		Payments          uint64              `json:"payments"`
+		LastTransactionID types.TransactionID `json:"lasttransactionid"`
+		LastError         string              `json:"lasterror"`
+	}
+
 	// A UnspentOutput is a SiacoinOutput or SiafundOutput that the wallet
 	// is tracking.
 	UnspentOutput struct {
@@ -126,6 +165,9 @@ type (
 		Value              types.Currency    `json:"value"`
 		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
 		IsWatchOnly        bool              `json:"iswatchonly"`
+		Label              string            `json:"label"`
+		AddressLabel       string            `json:"addresslabel"`
+		Frozen             bool              `json:"frozen"`
 	}
 
 	// TransactionBuilder is used to construct custom transactions. A transaction
@@ -426,6 +468,12 @@ type (
 		// AddUnlockConditions adds a set of UnlockConditions to the wallet database.
 		AddUnlockConditions(uc types.UnlockConditions) error
 
+		// BumpTransactionFee increases the fee of an unconfirmed wallet
+		// transaction by paying feePerByte for every byte of the transaction
+		// on top of its current fee. It returns the transactions that were
+		// submitted to the transaction pool.
+		BumpTransactionFee(id types.TransactionID, method FeeBumpMethod, feePerByte types.Currency) ([]types.Transaction, error)
+
 		// AddWatchAddresses instructs the wallet to begin tracking a set of
 		// addresses, in addition to the addresses it was previously tracking.
 		// If none of the addresses have appeared in the blockchain, the
@@ -488,6 +536,45 @@ type (
 		// blockchain.
 		Rescanning() (bool, error)
 
+		// AddMultisigAccount starts tracking a multisig address under the
+		// provided name. The unused flag has the same meaning as for
+		// AddWatchAddresses.
+		AddMultisigAccount(name string, uc types.UnlockConditions, unused bool) (MultisigAccount, error)
+
+		// MultisigAccounts returns the multisig accounts tracked by the
+		// wallet.
+		MultisigAccounts() ([]MultisigAccount, error)
+
+		// RemoveMultisigAccount stops tracking a multisig address. The unused
+		// flag has the same meaning as for RemoveWatchAddresses.
+		RemoveMultisigAccount(addr types.UnlockHash, unused bool) error
+
+		// CreateMultisigTransaction creates an unsigned transaction which
+		// sends siacoins from a multisig account to the provided outputs.
+		CreateMultisigTransaction(addr types.UnlockHash, outputs []types.SiacoinOutput) (PartiallySignedTransaction, error)
+
+		// SignMultisigTransaction adds the signatures of the wallet's keys to
+		// a partially signed transaction.
+		SignMultisigTransaction(pst PartiallySignedTransaction) (PartiallySignedTransaction, error)
+
+		// BroadcastMultisigTransaction finalizes a partially signed
+		// transaction and submits it to the transaction pool.
+		BroadcastMultisigTransaction(pst PartiallySignedTransaction) (types.Transaction, error)
+
+		// AddPaymentSchedule adds a scheduled payment to the wallet. The
+		// wallet sends the payment while it is unlocked once it is due.
+		AddPaymentSchedule(ps PaymentSchedule) (PaymentSchedule, error)
+
+		// PaymentSchedules returns the scheduled payments of the wallet.
+		PaymentSchedules() ([]PaymentSchedule, error)
+
+		// RemovePaymentSchedule removes a scheduled payment from the wallet.
+		RemovePaymentSchedule(id crypto.Hash) error
+
+		// UpdatePaymentSchedule replaces the label, outputs, next height,
+		// interval and remaining payments of a scheduled payment.
+		UpdatePaymentSchedule(ps PaymentSchedule) error
+
 		// Settings returns the Wallet's current settings.
 		Settings() (WalletSettings, error)
 
@@ -509,6 +596,11 @@ type (
 
 		SiacoinSenderMulti
 
+		// SendSiacoinsFromInputs sends coins to multiple addresses, spending
+		// exactly the provided confirmed wallet outputs. Frozen outputs may be
+		// spent this way. The change is sent to a new wallet address.
+		SendSiacoinsFromInputs(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput) ([]types.Transaction, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -522,6 +614,22 @@ type (
 		// UnspentOutputs returns the unspent outputs tracked by the wallet.
 		UnspentOutputs() ([]UnspentOutput, error)
 
+		// AddressLabels returns the labels of the wallet's addresses.
+		AddressLabels() (map[types.UnlockHash]string, error)
+
+		// SetAddressLabel labels an address of the wallet. An empty label
+		// removes the label.
+		SetAddressLabel(addr types.UnlockHash, label string) error
+
+		// SetOutputLabel labels an output tracked by the wallet. An empty
+		// label removes the label.
+		SetOutputLabel(id types.OutputID, label string) error
+
+		// SetOutputFrozen freezes or unfreezes an output tracked by the
+		// wallet. Frozen outputs are never used to fund transactions unless
+		// they are explicitly selected as inputs.
+		SetOutputFrozen(id types.OutputID, frozen bool) error
+
 		// UnlockConditions returns the UnlockConditions for the specified
 		// address, if they are known to the wallet.
 		UnlockConditions(addr types.UnlockHash) (types.UnlockConditions, error)
@@ -543,6 +651,17 @@ func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) W
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// TimelockUnlockConditions returns the unlock conditions of an address whose
+// outputs can only be spent with the secret key of pk once the blockchain
+// reached the height timelock.
+func TimelockUnlockConditions(pk types.SiaPublicKey, timelock types.BlockHeight) types.UnlockConditions {
+	return types.UnlockConditions{
+		Timelock:           timelock,
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
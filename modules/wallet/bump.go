@@ -0,0 +1,285 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errBumpNoChange is returned when a transaction has no wallet output
+	// which can pay for the higher fee.
+	errBumpNoChange = errors.New("transaction has no wallet output large enough to pay the higher fee")
+
+	// errBumpNotReplaceable is returned when a transaction can't be replaced
+	// because the wallet can't sign all of its inputs.
+	errBumpNotReplaceable = errors.New("transaction contains inputs or revisions the wallet can't sign")
+
+	// errBumpNotUnconfirmed is returned when the transaction to bump is not an
+	// unconfirmed wallet transaction.
+	errBumpNotUnconfirmed = errors.New("transaction is not an unconfirmed wallet transaction")
+
+	// errBumpUnknownMethod is returned when an unknown fee bump method is
+	// requested.
+	errBumpUnknownMethod = errors.New("unknown fee bump method")
+)
+
+// BumpTransactionFee increases the fee of an unconfirmed wallet transaction.
+// The FeeBumpReplace method replaces the transaction with a conflicting
+// version that pays the additional fee from its change output. Unconfirmed
+// children of the transaction are dropped by the transaction pool and have to
+// be sent again. The FeeBumpChild method spends the change output in a child
+// transaction that pays the additional fee for both transactions. If
+// feePerByte is zero, the maximum fee recommended by the transaction pool is
+// used.
+func (w *Wallet) BumpTransactionFee(id types.TransactionID, method modules.FeeBumpMethod, feePerByte types.Currency) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if method != modules.FeeBumpReplace && method != modules.FeeBumpChild {
+		return nil, errBumpUnknownMethod
+	}
+	minFee, maxFee := w.tpool.FeeEstimation()
+	if feePerByte.IsZero() {
+		feePerByte = maxFee
+	}
+	dustThreshold := minFee.Mul64(3)
+
+	// Look up the transaction in the pool before acquiring the lock, since the
+	// pool notifies the wallet while holding its own lock.
+	txn, parents, exists := w.tpool.Transaction(id)
+	if !exists {
+		return nil, errBumpNotUnconfirmed
+	}
+
+	set, err := func() ([]types.Transaction, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return nil, modules.ErrLockedWallet
+		}
+		var isWalletTxn bool
+		for _, pt := range w.unconfirmedProcessedTransactions {
+			isWalletTxn = isWalletTxn || pt.TransactionID == id
+		}
+		if !isWalletTxn {
+			return nil, errBumpNotUnconfirmed
+		}
+		height, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return nil, err
+		}
+		if method == modules.FeeBumpReplace {
+			replacement, err := w.replaceTransaction(txn, feePerByte, dustThreshold, height)
+			if err != nil {
+				return nil, err
+			}
+			return []types.Transaction{replacement}, nil
+		}
+		child, err := w.childTransaction(txn, parents, feePerByte, dustThreshold, height)
+		if err != nil {
+			return nil, err
+		}
+		set := append([]types.Transaction(nil), parents...)
+		return append(set, txn, child), nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.tpool.AcceptTransactionSet(set); err != nil {
+		return nil, errors.AddContext(err, "transaction pool rejected the fee bump")
+	}
+	return set, nil
+}
+
+// changeOutput returns the index of the largest output of txn which belongs
+// to the wallet.
+func (w *Wallet) changeOutput(txn types.Transaction) (int, bool) {
+	index := -1
+	for i, sco := range txn.SiacoinOutputs {
+		if _, exists := w.keys[sco.UnlockHash]; !exists {
+			continue
+		}
+		if index == -1 || sco.Value.Cmp(txn.SiacoinOutputs[index].Value) > 0 {
+			index = i
+		}
+	}
+	return index, index != -1
+}
+
+// replaceTransaction returns a copy of txn which pays feePerByte for every
+// byte of the transaction on top of its current fee. The additional fee is
+// taken from the change output, or from an additional confirmed wallet output
+// if the change is too small, and all inputs are signed again.
+func (w *Wallet) replaceTransaction(txn types.Transaction, feePerByte, dustThreshold types.Currency, height types.BlockHeight) (types.Transaction, error) {
+	// The wallet has to be able to sign every input and nothing else may be
+	// signed, since the signatures of other parties would be invalidated.
+	if len(txn.FileContractRevisions) > 0 || len(txn.StorageProofs) > 0 {
+		return types.Transaction{}, errBumpNotReplaceable
+	}
+	var inputs []types.UnlockConditions
+	var parentIDs []crypto.Hash
+	for _, sci := range txn.SiacoinInputs {
+		inputs = append(inputs, sci.UnlockConditions)
+		parentIDs = append(parentIDs, crypto.Hash(sci.ParentID))
+	}
+	for _, sfi := range txn.SiafundInputs {
+		inputs = append(inputs, sfi.UnlockConditions)
+		parentIDs = append(parentIDs, crypto.Hash(sfi.ParentID))
+	}
+	if len(inputs) == 0 {
+		return types.Transaction{}, errBumpNotReplaceable
+	}
+	for _, uc := range inputs {
+		if _, exists := w.keys[uc.UnlockHash()]; !exists {
+			return types.Transaction{}, errBumpNotReplaceable
+		}
+	}
+	base := txn
+	base.SiacoinInputs = append([]types.SiacoinInput(nil), txn.SiacoinInputs...)
+	base.SiacoinOutputs = append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...)
+
+	// If the change output can't pay for the increase, add a confirmed wallet
+	// output to the transaction and pay the increase from its change instead.
+	increase := feePerByte.Mul64(uint64(len(encoding.Marshal(txn))))
+	index, ok := w.changeOutput(txn)
+	var extraID types.SiacoinOutputID
+	if !ok || txn.SiacoinOutputs[index].Value.Cmp(increase.Add(dustThreshold)) < 0 {
+		id, sco, err := w.feeOutput(dustThreshold, height)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+		uc := w.keys[sco.UnlockHash].UnlockConditions
+		base.SiacoinInputs = append(base.SiacoinInputs, types.SiacoinInput{
+			ParentID:         id,
+			UnlockConditions: uc,
+		})
+		base.SiacoinOutputs = append(base.SiacoinOutputs, sco)
+		inputs = append(inputs, uc)
+		parentIDs = append(parentIDs, crypto.Hash(id))
+		index = len(base.SiacoinOutputs) - 1
+		extraID = id
+	}
+
+	// newReplacement creates and signs the replacement paying the provided
+	// additional fee.
+	newReplacement := func(fee types.Currency) types.Transaction {
+		replacement := base
+		replacement.SiacoinOutputs = append([]types.SiacoinOutput(nil), base.SiacoinOutputs...)
+		replacement.SiacoinOutputs[index].Value = replacement.SiacoinOutputs[index].Value.Sub(fee)
+		replacement.MinerFees = append([]types.Currency(nil), base.MinerFees...)
+		if len(replacement.MinerFees) == 0 {
+			replacement.MinerFees = append(replacement.MinerFees, fee)
+		} else {
+			replacement.MinerFees[0] = replacement.MinerFees[0].Add(fee)
+		}
+		replacement.TransactionSignatures = nil
+		for i, uc := range inputs {
+			addSignatures(&replacement, types.FullCoveredFields, uc, parentIDs[i], w.keys[uc.UnlockHash()], height)
+		}
+		return replacement
+	}
+
+	// The increase is recomputed from the size of the signed replacement.
+	increase = feePerByte.Mul64(uint64(len(encoding.Marshal(newReplacement(types.ZeroCurrency)))))
+	if base.SiacoinOutputs[index].Value.Cmp(increase.Add(dustThreshold)) < 0 {
+		return types.Transaction{}, errBumpNoChange
+	}
+	if extraID != (types.SiacoinOutputID{}) {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(extraID), height); err != nil {
+			return types.Transaction{}, err
+		}
+	}
+	return newReplacement(increase), nil
+}
+
+// feeOutput returns the largest confirmed wallet output which can be spent to
+// pay for a fee bump.
+func (w *Wallet) feeOutput(dustThreshold types.Currency, height types.BlockHeight) (types.SiacoinOutputID, types.SiacoinOutput, error) {
+	var id types.SiacoinOutputID
+	var output types.SiacoinOutput
+	err := dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.Value.Cmp(output.Value) <= 0 {
+			return
+		}
+		if w.checkOutput(w.dbTx, height, scoid, sco, dustThreshold) != nil {
+			return
+		}
+		id, output = scoid, sco
+	})
+	if err != nil {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, err
+	}
+	if output.Value.IsZero() {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, errBumpNoChange
+	}
+	return id, output, nil
+}
+
+// childTransaction returns a transaction which spends a wallet output of txn
+// or of its unconfirmed parents and pays feePerByte for every byte of itself,
+// txn and its parents on top of their current fees.
+func (w *Wallet) childTransaction(txn types.Transaction, parents []types.Transaction, feePerByte, dustThreshold types.Currency, height types.BlockHeight) (types.Transaction, error) {
+	set := append(append([]types.Transaction(nil), parents...), txn)
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, t := range set {
+		for _, sci := range t.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	// Prefer the change output of txn itself. A wallet send keeps its change
+	// in the parent that splits the inputs, so fall back to the largest
+	// unspent wallet output of the set.
+	var parentID types.SiacoinOutputID
+	var change types.SiacoinOutput
+	if index, ok := w.changeOutput(txn); ok {
+		parentID, change = txn.SiacoinOutputID(uint64(index)), txn.SiacoinOutputs[index]
+	} else {
+		for _, t := range set {
+			for i, sco := range t.SiacoinOutputs {
+				id := t.SiacoinOutputID(uint64(i))
+				if _, exists := spent[id]; exists {
+					continue
+				}
+				if _, exists := w.keys[sco.UnlockHash]; !exists || sco.Value.Cmp(change.Value) <= 0 {
+					continue
+				}
+				parentID, change = id, sco
+			}
+		}
+	}
+	if change.Value.IsZero() {
+		return types.Transaction{}, errBumpNoChange
+	}
+	sk := w.keys[change.UnlockHash]
+
+	// newChild creates and signs the child paying the provided fee.
+	newChild := func(fee types.Currency) types.Transaction {
+		child := types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{
+				ParentID:         parentID,
+				UnlockConditions: sk.UnlockConditions,
+			}},
+			SiacoinOutputs: []types.SiacoinOutput{{
+				Value:      change.Value.Sub(fee),
+				UnlockHash: change.UnlockHash,
+			}},
+			MinerFees: []types.Currency{fee},
+		}
+		addSignatures(&child, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(parentID), sk, height)
+		return child
+	}
+
+	// The child pays for the whole set. The size of the child is estimated
+	// by building it with the maximum fee.
+	size := len(encoding.Marshal(append(set, newChild(change.Value))))
+	fee := feePerByte.Mul64(uint64(size))
+	if change.Value.Cmp(fee.Add(dustThreshold)) < 0 {
+		return types.Transaction{}, errBumpNoChange
+	}
+	return newChild(fee), nil
+}
@@ -0,0 +1,286 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errDuplicateInput is returned when an output is selected more than once
+	// as an input of a transaction.
+	errDuplicateInput = errors.New("output was selected as input more than once")
+
+	// errNoInputs is returned when a transaction is sent from an empty set of
+	// inputs.
+	errNoInputs = errors.New("no inputs were selected")
+
+	// errUnknownAddress is returned when a label is assigned to an address
+	// which doesn't belong to the wallet.
+	errUnknownAddress = errors.New("address is not tracked by the wallet")
+
+	// errUnknownOutput is returned when an output is not tracked by the
+	// wallet.
+	errUnknownOutput = errors.New("output is not tracked by the wallet")
+
+	// errUnspendableInput is returned when an input is selected which the
+	// wallet can't sign.
+	errUnspendableInput = errors.New("wallet can't spend the selected output")
+)
+
+// outputMetadata is the user provided metadata of an output as it is stored
+// in the database.
+type outputMetadata struct {
+	Label  string
+	Frozen bool
+}
+
+// isTrackedOutput returns whether the output is a confirmed or unconfirmed
+// output tracked by the wallet.
+func (w *Wallet) isTrackedOutput(id types.OutputID) bool {
+	if _, err := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(id)); err == nil {
+		return true
+	}
+	if _, err := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(id)); err == nil {
+		return true
+	}
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		for _, o := range pt.Outputs {
+			if o.ID == id && o.WalletAddress {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// managedUpdateOutputMetadata applies fn to the metadata of an output tracked
+// by the wallet and stores the result.
+func (w *Wallet) managedUpdateOutputMetadata(id types.OutputID, fn func(*outputMetadata)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.isTrackedOutput(id) {
+		return errUnknownOutput
+	}
+	om, err := dbGetOutputMetadata(w.dbTx, id)
+	if err != nil && !errors.Contains(err, errNoKey) {
+		return err
+	}
+	fn(&om)
+	if om == (outputMetadata{}) {
+		return dbDeleteOutputMetadata(w.dbTx, id)
+	}
+	return dbPutOutputMetadata(w.dbTx, id, om)
+}
+
+// AddressLabels returns the labels of the wallet's addresses.
+func (w *Wallet) AddressLabels() (map[types.UnlockHash]string, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	labels := make(map[types.UnlockHash]string)
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		labels[addr] = label
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// SetAddressLabel labels an address of the wallet. An empty label removes the
+// label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+
+	_, isKey := w.keys[addr]
+	_, isWatched := w.watchedAddrs[addr]
+	if !isKey && !isWatched {
+		return errUnknownAddress
+	}
+	if label == "" {
+		return dbDeleteAddressLabel(w.dbTx, addr)
+	}
+	return dbPutAddressLabel(w.dbTx, addr, label)
+}
+
+// SetOutputLabel labels an output tracked by the wallet. An empty label
+// removes the label.
+func (w *Wallet) SetOutputLabel(id types.OutputID, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	return w.managedUpdateOutputMetadata(id, func(om *outputMetadata) {
+		om.Label = label
+	})
+}
+
+// SetOutputFrozen freezes or unfreezes an output tracked by the wallet. Frozen
+// outputs are skipped when the wallet funds transactions.
+func (w *Wallet) SetOutputFrozen(id types.OutputID, frozen bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	return w.managedUpdateOutputMetadata(id, func(om *outputMetadata) {
+		om.Frozen = frozen
+	})
+}
+
+// SendSiacoinsFromInputs creates a transaction which spends exactly the
+// provided confirmed wallet outputs and sends coins to the provided outputs.
+// The remaining value minus the fee is sent to a new wallet address. The
+// transaction is submitted to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromInputs(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(inputs) == 0 {
+		return nil, errNoInputs
+	}
+
+	// Check if consensus is synced
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return nil, errors.New("cannot send siacoin until fully synced")
+	}
+	minFee, feePerByte := w.tpool.FeeEstimation()
+	dustThreshold := minFee.Mul64(3)
+
+	var changeUC types.UnlockConditions
+	txn, err := func() (types.Transaction, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return types.Transaction{}, modules.ErrLockedWallet
+		}
+		height, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+
+		// Collect the inputs. Frozen outputs may be spent since they were
+		// selected explicitly.
+		var base types.Transaction
+		var fund types.Currency
+		seen := make(map[types.SiacoinOutputID]struct{})
+		for _, id := range inputs {
+			if _, exists := seen[id]; exists {
+				return types.Transaction{}, errors.AddContext(errDuplicateInput, id.String())
+			}
+			seen[id] = struct{}{}
+			sco, err := dbGetSiacoinOutput(w.dbTx, id)
+			if err != nil {
+				return types.Transaction{}, errors.AddContext(errUnknownOutput, id.String())
+			}
+			sk, exists := w.keys[sco.UnlockHash]
+			if !exists {
+				return types.Transaction{}, errors.AddContext(errUnspendableInput, id.String())
+			}
+			if spendHeight, err := dbGetSpentOutput(w.dbTx, types.OutputID(id)); err == nil && spendHeight+RespendTimeout > height {
+				return types.Transaction{}, errors.AddContext(errSpendHeightTooHigh, id.String())
+			}
+			if height < sk.UnlockConditions.Timelock {
+				return types.Transaction{}, errors.AddContext(errOutputTimelock, id.String())
+			}
+			base.SiacoinInputs = append(base.SiacoinInputs, types.SiacoinInput{
+				ParentID:         id,
+				UnlockConditions: sk.UnlockConditions,
+			})
+			fund = fund.Add(sco.Value)
+		}
+		var total types.Currency
+		for _, sco := range outputs {
+			total = total.Add(sco.Value)
+		}
+		base.SiacoinOutputs = append([]types.SiacoinOutput(nil), outputs...)
+
+		changeUC, err = w.nextPrimarySeedAddress(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+
+		// newTxn creates and signs the transaction paying the provided fee.
+		// If the change is dust it is added to the fee instead.
+		newTxn := func(fee types.Currency) types.Transaction {
+			txn := base
+			txn.SiacoinOutputs = append([]types.SiacoinOutput(nil), base.SiacoinOutputs...)
+			if change := fund.Sub(total).Sub(fee); change.Cmp(dustThreshold) >= 0 {
+				txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+					Value:      change,
+					UnlockHash: changeUC.UnlockHash(),
+				})
+			} else {
+				fee = fund.Sub(total)
+			}
+			txn.MinerFees = []types.Currency{fee}
+			for _, sci := range txn.SiacoinInputs {
+				addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()], height)
+			}
+			return txn
+		}
+
+		// The fee is computed from the size of the transaction including the
+		// change output.
+		if fund.Cmp(total) < 0 {
+			return types.Transaction{}, modules.ErrLowBalance
+		}
+		fee := feePerByte.Mul64(uint64(len(encoding.Marshal(newTxn(types.ZeroCurrency)))))
+		if fund.Cmp(total.Add(fee)) < 0 {
+			return types.Transaction{}, modules.ErrLowBalance
+		}
+		txn := newTxn(fee)
+
+		// Mark the inputs as spent.
+		for _, sci := range txn.SiacoinInputs {
+			if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), height); err != nil {
+				return types.Transaction{}, err
+			}
+		}
+		return txn, nil
+	}()
+	if err != nil {
+		if len(changeUC.PublicKeys) > 0 {
+			w.managedMarkAddressUnused(changeUC)
+		}
+		return nil, err
+	}
+
+	if len(txn.SiacoinOutputs) == len(outputs) {
+		// The change was added to the fee.
+		w.managedMarkAddressUnused(changeUC)
+	}
+	txnSet := []types.Transaction{txn}
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.mu.Lock()
+		for _, sci := range txn.SiacoinInputs {
+			dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
+		}
+		w.mu.Unlock()
+		if len(txn.SiacoinOutputs) > len(outputs) {
+			w.managedMarkAddressUnused(changeUC)
+		}
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	w.log.Printf("Submitted a transaction spending %v selected inputs with id %v and fee %v", len(inputs), txn.ID(), txn.MinerFees[0].HumanString())
+	return txnSet, nil
+}
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCoinControl checks that labels are reported with the unspent outputs,
+// that frozen outputs aren't used to fund transactions and that transactions
+// can be sent from an explicit set of inputs.
+func TestCoinControl(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Label and freeze all confirmed siacoin outputs.
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scos []modules.UnspentOutput
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput {
+			scos = append(scos, o)
+		}
+	}
+	if len(scos) == 0 {
+		t.Fatal("wallet has no siacoin outputs")
+	}
+	for _, o := range scos {
+		if err := wt.wallet.SetOutputLabel(o.ID, "cold storage"); err != nil {
+			t.Fatal(err)
+		}
+		if err := wt.wallet.SetOutputFrozen(o.ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wt.wallet.SetAddressLabel(scos[0].UnlockHash, "mining"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetOutputFrozen(types.OutputID{1}, true); !errors.Contains(err, errUnknownOutput) {
+		t.Fatal("expected errUnknownOutput, got", err)
+	}
+	if err := wt.wallet.SetAddressLabel(types.UnlockHash{1}, "unknown"); !errors.Contains(err, errUnknownAddress) {
+		t.Fatal("expected errUnknownAddress, got", err)
+	}
+	outputs, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if o.FundType != types.SpecifierSiacoinOutput {
+			continue
+		}
+		if o.Label != "cold storage" || !o.Frozen {
+			t.Fatal("output is missing its label or frozen state", o)
+		}
+		if o.UnlockHash == scos[0].UnlockHash && o.AddressLabel != "mining" {
+			t.Fatal("output is missing its address label", o)
+		}
+	}
+
+	// Sending coins should fail since all outputs are frozen.
+	amount := types.SiacoinPrecision
+	if _, err := wt.wallet.SendSiacoins(amount, types.UnlockHash{}); err == nil || !strings.Contains(err.Error(), modules.ErrLowBalance.Error()) {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+
+	// Frozen outputs can be selected explicitly.
+	dest := []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{}}}
+	id := types.SiacoinOutputID(scos[0].ID)
+	if _, err := wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutputID{id, id}, dest); !errors.Contains(err, errDuplicateInput) {
+		t.Fatal("expected errDuplicateInput, got", err)
+	}
+	txns, err := wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutputID{id}, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || len(txns[0].SiacoinInputs) != 1 || txns[0].SiacoinInputs[0].ParentID != id {
+		t.Fatal("transaction doesn't spend the selected input")
+	}
+	if len(txns[0].SiacoinOutputs) != 2 || !txns[0].SiacoinOutputs[0].Value.Equals(amount) {
+		t.Fatal("transaction is missing the payment or the change")
+	}
+	if _, err := wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutputID{id}, dest); !errors.Contains(err, errSpendHeightTooHigh) {
+		t.Fatal("expected errSpendHeightTooHigh, got", err)
+	}
+
+	// After unfreezing an output the wallet can fund transactions again.
+	if err := wt.wallet.SetOutputFrozen(scos[len(scos)-1].ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(amount, types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+}
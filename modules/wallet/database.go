@@ -11,11 +11,27 @@ import (
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
 var (
+	// bucketAddressLabels maps an UnlockHash of the wallet to the label the
+	// user assigned to it.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketMultisigAccounts maps the UnlockHash of a multisig address
+	// tracked by the wallet to its name and UnlockConditions.
+	bucketMultisigAccounts = []byte("bucketMultisigAccounts")
+	// bucketOutputMetadata maps an OutputID to the label and frozen state the
+	// user assigned to the output.
+	bucketOutputMetadata = []byte("bucketOutputMetadata")
+	// bucketPaymentSchedules maps the ID of a scheduled payment to its
+	// PaymentSchedule.
+	bucketPaymentSchedules = []byte("bucketPaymentSchedules")
+	// bucketPendingPayments maps the ID of a scheduled payment to the signed
+	// transactions of a payment which is being broadcast.
+	bucketPendingPayments = []byte("bucketPendingPayments")
 	// bucketProcessedTransactions stores ProcessedTransactions in
 	// chronological order. Only transactions relevant to the wallet are
 	// stored. The key of this bucket is an autoincrementing integer.
@@ -48,6 +64,11 @@ var (
 	bucketWallet = []byte("bucketWallet")
 
 	dbBuckets = [][]byte{
+		bucketAddressLabels,
+		bucketMultisigAccounts,
+		bucketOutputMetadata,
+		bucketPaymentSchedules,
+		bucketPendingPayments,
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
@@ -204,6 +225,10 @@ func dbForEach(b *bolt.Bucket, fn interface{}) error {
 func dbPutSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
 	return dbPut(tx.Bucket(bucketSiacoinOutputs), id, output)
 }
+func dbGetSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (output types.SiacoinOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiacoinOutputs), id, &output)
+	return
+}
 func dbDeleteSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiacoinOutputs), id)
 }
@@ -214,6 +239,10 @@ func dbForEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.Si
 func dbPutSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
 	return dbPut(tx.Bucket(bucketSiafundOutputs), id, output)
 }
+func dbGetSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) (output types.SiafundOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiafundOutputs), id, &output)
+	return
+}
 func dbDeleteSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiafundOutputs), id)
 }
@@ -248,6 +277,61 @@ func dbGetUnlockConditions(tx *bolt.Tx, addr types.UnlockHash) (uc types.UnlockC
 	return
 }
 
+func dbPutMultisigAccount(tx *bolt.Tx, addr types.UnlockHash, ma multisigAccount) error {
+	return dbPut(tx.Bucket(bucketMultisigAccounts), addr, ma)
+}
+func dbDeleteMultisigAccount(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketMultisigAccounts), addr)
+}
+func dbForEachMultisigAccount(tx *bolt.Tx, fn func(types.UnlockHash, multisigAccount)) error {
+	return dbForEach(tx.Bucket(bucketMultisigAccounts), fn)
+}
+
+func dbPutAddressLabel(tx *bolt.Tx, addr types.UnlockHash, label string) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), addr, label)
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutOutputMetadata(tx *bolt.Tx, id types.OutputID, om outputMetadata) error {
+	return dbPut(tx.Bucket(bucketOutputMetadata), id, om)
+}
+func dbGetOutputMetadata(tx *bolt.Tx, id types.OutputID) (om outputMetadata, err error) {
+	err = dbGet(tx.Bucket(bucketOutputMetadata), id, &om)
+	return
+}
+func dbDeleteOutputMetadata(tx *bolt.Tx, id types.OutputID) error {
+	return dbDelete(tx.Bucket(bucketOutputMetadata), id)
+}
+
+func dbPutPaymentSchedule(tx *bolt.Tx, ps modules.PaymentSchedule) error {
+	return dbPut(tx.Bucket(bucketPaymentSchedules), ps.ID, ps)
+}
+func dbGetPaymentSchedule(tx *bolt.Tx, id crypto.Hash) (ps modules.PaymentSchedule, err error) {
+	err = dbGet(tx.Bucket(bucketPaymentSchedules), id, &ps)
+	return
+}
+func dbDeletePaymentSchedule(tx *bolt.Tx, id crypto.Hash) error {
+	return dbDelete(tx.Bucket(bucketPaymentSchedules), id)
+}
+func dbForEachPaymentSchedule(tx *bolt.Tx, fn func(crypto.Hash, modules.PaymentSchedule)) error {
+	return dbForEach(tx.Bucket(bucketPaymentSchedules), fn)
+}
+
+func dbPutPendingPayment(tx *bolt.Tx, id crypto.Hash, pp pendingPayment) error {
+	return dbPut(tx.Bucket(bucketPendingPayments), id, pp)
+}
+func dbDeletePendingPayment(tx *bolt.Tx, id crypto.Hash) error {
+	return dbDelete(tx.Bucket(bucketPendingPayments), id)
+}
+func dbForEachPendingPayment(tx *bolt.Tx, fn func(crypto.Hash, pendingPayment)) error {
+	return dbForEach(tx.Bucket(bucketPendingPayments), fn)
+}
+
 // dbAddAddrTransaction appends a single transaction index to the set of
 // transactions associated with addr. If the index is already in the set, it is
 // not added again.
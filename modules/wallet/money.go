@@ -205,6 +205,14 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 // outputs. The transaction is submitted to the transaction pool and is also
 // returned.
 func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.Transaction, err error) {
+	return w.managedSendSiacoinsMulti(outputs, nil)
+}
+
+// managedSendSiacoinsMulti creates a transaction that includes the specified
+// outputs and submits it to the transaction pool. If beforeBroadcast is not
+// nil, it is called with the signed transaction set before the set is
+// submitted and the set is only submitted if it succeeds.
+func (w *Wallet) managedSendSiacoinsMulti(outputs []types.SiacoinOutput, beforeBroadcast func([]types.Transaction) error) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		err = modules.ErrWalletShutdown
 		return nil, err
@@ -264,6 +272,12 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 		w.log.Println("Attempt to send coins has failed - failed to sign transaction:", err)
 		return nil, build.ExtendErr("unable to sign transaction", err)
 	}
+	if beforeBroadcast != nil {
+		err = beforeBroadcast(txnSet)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if w.deps.Disrupt("SendSiacoinsInterrupted") {
 		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
 	}
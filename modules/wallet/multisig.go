@@ -0,0 +1,415 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// multisigTxnBaseSize is the estimated size of a multisig transaction
+	// without its inputs and outputs.
+	multisigTxnBaseSize = 200
+
+	// multisigInputBaseSize is the estimated size of a multisig input without
+	// its public keys and signatures.
+	multisigInputBaseSize = 100
+
+	// multisigPublicKeySize is the estimated size of a public key of a
+	// multisig input.
+	multisigPublicKeySize = 60
+
+	// multisigSignatureSize is the estimated size of a signature of a
+	// multisig input.
+	multisigSignatureSize = 150
+
+	// multisigOutputSize is the estimated size of a siacoin output.
+	multisigOutputSize = 60
+)
+
+var (
+	// errMultisigAccountExists is returned when a multisig account is added
+	// twice.
+	errMultisigAccountExists = errors.New("multisig account already exists")
+
+	// errMultisigAccountNotFound is returned when a multisig account is not
+	// tracked by the wallet.
+	errMultisigAccountNotFound = errors.New("multisig account not found")
+
+	// errNoMultisigKeys is returned when the wallet can't contribute any
+	// signatures to a partially signed transaction.
+	errNoMultisigKeys = errors.New("wallet has no keys to sign the transaction")
+)
+
+// multisigAccount is a multisig account as it is stored in the database.
+type multisigAccount struct {
+	Name             string
+	UnlockConditions types.UnlockConditions
+}
+
+// multisigTxnSize estimates the size of a transaction spending numInputs
+// inputs with the provided unlock conditions to numOutputs outputs.
+func multisigTxnSize(uc types.UnlockConditions, numInputs, numOutputs int) uint64 {
+	inputSize := multisigInputBaseSize + multisigPublicKeySize*uint64(len(uc.PublicKeys)) + multisigSignatureSize*uc.SignaturesRequired
+	return multisigTxnBaseSize + inputSize*uint64(numInputs) + multisigOutputSize*uint64(numOutputs)
+}
+
+// AddMultisigAccount starts tracking the multisig address described by uc
+// under the provided name. If none of the addresses have appeared in the
+// blockchain, the unused flag may be set to true. Otherwise, the wallet must
+// rescan the blockchain to search for transactions containing the address.
+func (w *Wallet) AddMultisigAccount(name string, uc types.UnlockConditions, unused bool) (modules.MultisigAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigAccount{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if name == "" {
+		return modules.MultisigAccount{}, errors.New("multisig account name can't be empty")
+	}
+	if err := modules.ValidateMultisigUnlockConditions(uc); err != nil {
+		return modules.MultisigAccount{}, err
+	}
+	addr := uc.UnlockHash()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		var exists bool
+		err := dbForEachMultisigAccount(w.dbTx, func(a types.UnlockHash, ma multisigAccount) {
+			exists = exists || a == addr || ma.Name == name
+		})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errMultisigAccountExists
+		}
+		if err := dbPutUnlockConditions(w.dbTx, uc); err != nil {
+			return err
+		}
+		return dbPutMultisigAccount(w.dbTx, addr, multisigAccount{
+			Name:             name,
+			UnlockConditions: uc,
+		})
+	}()
+	if err != nil {
+		return modules.MultisigAccount{}, err
+	}
+
+	// Watch the address to track its outputs.
+	if err := w.AddWatchAddresses([]types.UnlockHash{addr}, unused); err != nil {
+		return modules.MultisigAccount{}, errors.AddContext(err, "failed to watch multisig address")
+	}
+	return modules.MultisigAccount{
+		Name:             name,
+		Address:          addr,
+		UnlockConditions: uc,
+	}, nil
+}
+
+// MultisigAccounts returns the multisig accounts tracked by the wallet.
+func (w *Wallet) MultisigAccounts() ([]modules.MultisigAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var accounts []modules.MultisigAccount
+	err := dbForEachMultisigAccount(w.dbTx, func(addr types.UnlockHash, ma multisigAccount) {
+		accounts = append(accounts, modules.MultisigAccount{
+			Name:             ma.Name,
+			Address:          addr,
+			UnlockConditions: ma.UnlockConditions,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[types.UnlockHash]types.Currency)
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		balances[sco.UnlockHash] = balances[sco.UnlockHash].Add(sco.Value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		accounts[i].ConfirmedSiacoinBalance = balances[accounts[i].Address]
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
+
+// RemoveMultisigAccount stops tracking the multisig account with the provided
+// address. If the address hasn't appeared in the blockchain, the unused flag
+// may be set to true. Otherwise, the wallet must rescan the blockchain to
+// rebuild its transaction history.
+func (w *Wallet) RemoveMultisigAccount(addr types.UnlockHash, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		if _, err := w.multisigAccountByAddress(addr); err != nil {
+			return err
+		}
+		return dbDeleteMultisigAccount(w.dbTx, addr)
+	}()
+	if err != nil {
+		return err
+	}
+	return w.RemoveWatchAddresses([]types.UnlockHash{addr}, unused)
+}
+
+// multisigAccountByAddress returns the multisig account with the provided
+// address. The caller must hold the lock.
+func (w *Wallet) multisigAccountByAddress(addr types.UnlockHash) (multisigAccount, error) {
+	var ma multisigAccount
+	var found bool
+	err := dbForEachMultisigAccount(w.dbTx, func(a types.UnlockHash, account multisigAccount) {
+		if a == addr {
+			ma, found = account, true
+		}
+	})
+	if err != nil {
+		return multisigAccount{}, err
+	}
+	if !found {
+		return multisigAccount{}, errMultisigAccountNotFound
+	}
+	return ma, nil
+}
+
+// CreateMultisigTransaction creates an unsigned transaction which sends
+// siacoins from the multisig account with the provided address to the
+// provided outputs. The transaction is funded with the confirmed outputs of
+// the account and returns the change to the account. The transaction fee is
+// based on the current fee estimation of the transaction pool.
+func (w *Wallet) CreateMultisigTransaction(addr types.UnlockHash, outputs []types.SiacoinOutput) (modules.PartiallySignedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PartiallySignedTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return modules.PartiallySignedTransaction{}, errors.New("transaction requires at least one output")
+	}
+	var amount types.Currency
+	for _, sco := range outputs {
+		if sco.Value.IsZero() {
+			return modules.PartiallySignedTransaction{}, errors.New("can't send zero siacoins")
+		}
+		amount = amount.Add(sco.Value)
+	}
+	_, feePerByte := w.tpool.FeeEstimation()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.PartiallySignedTransaction{}, modules.ErrLockedWallet
+	}
+	ma, err := w.multisigAccountByAddress(addr)
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+
+	// Collect the confirmed outputs of the account which are not spent by
+	// unconfirmed transactions, largest first.
+	pending := make(map[types.OutputID]struct{})
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		for _, input := range pt.Inputs {
+			pending[input.ParentID] = struct{}{}
+		}
+	}
+	var so sortedOutputs
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, spent := pending[types.OutputID(scoid)]; sco.UnlockHash == addr && !spent {
+			so.ids = append(so.ids, scoid)
+			so.outputs = append(so.outputs, sco)
+		}
+	})
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	// Add inputs until they cover the outputs and the fee of a transaction
+	// with a change output.
+	pst := modules.PartiallySignedTransaction{
+		Version: modules.PartiallySignedTransactionVersion,
+	}
+	var fund, fee types.Currency
+	for i := range so.ids {
+		pst.Transaction.SiacoinInputs = append(pst.Transaction.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: ma.UnlockConditions,
+		})
+		pst.InputValues = append(pst.InputValues, so.outputs[i].Value)
+		fund = fund.Add(so.outputs[i].Value)
+		fee = feePerByte.Mul64(multisigTxnSize(ma.UnlockConditions, i+1, len(outputs)+1))
+		if fund.Cmp(amount.Add(fee)) >= 0 {
+			break
+		}
+	}
+	if fund.Cmp(amount.Add(fee)) < 0 {
+		return modules.PartiallySignedTransaction{}, modules.ErrLowBalance
+	}
+	pst.Transaction.SiacoinOutputs = append([]types.SiacoinOutput(nil), outputs...)
+	if change := fund.Sub(amount).Sub(fee); !change.IsZero() {
+		pst.Transaction.SiacoinOutputs = append(pst.Transaction.SiacoinOutputs, types.SiacoinOutput{
+			Value:      change,
+			UnlockHash: addr,
+		})
+	}
+	pst.Transaction.MinerFees = []types.Currency{fee}
+	return pst, nil
+}
+
+// SignMultisigTransaction adds the signatures of all keys of the wallet which
+// are part of the unlock conditions of the transaction's inputs. Only inputs
+// spending the outputs of registered multisig accounts are signed.
+func (w *Wallet) SignMultisigTransaction(pst modules.PartiallySignedTransaction) (modules.PartiallySignedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PartiallySignedTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if pst.Version != modules.PartiallySignedTransactionVersion {
+		return modules.PartiallySignedTransaction{}, modules.ErrUnknownPartiallySignedTransactionVersion
+	}
+	if len(pst.InputValues) != len(pst.Transaction.SiacoinInputs) {
+		return modules.PartiallySignedTransaction{}, errors.New("partially signed transaction is missing input values")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.PartiallySignedTransaction{}, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+
+	// Verify that the transaction spends exactly its inputs. The values of
+	// the inputs are checked against the outputs known to the wallet.
+	outputs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		outputs[scoid] = sco
+	})
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+	var in, out types.Currency
+	for i, sci := range pst.Transaction.SiacoinInputs {
+		if sco, exists := outputs[sci.ParentID]; exists && !sco.Value.Equals(pst.InputValues[i]) {
+			return modules.PartiallySignedTransaction{}, errors.New("input value doesn't match the value of the spent output")
+		}
+		in = in.Add(pst.InputValues[i])
+	}
+	for _, sco := range pst.Transaction.SiacoinOutputs {
+		out = out.Add(sco.Value)
+	}
+	if !in.Equals(out.Add(pst.Fee())) {
+		return modules.PartiallySignedTransaction{}, errors.New("inputs don't match the sum of the outputs and the fee")
+	}
+
+	// Only inputs of the registered multisig accounts are signed. Otherwise
+	// the wallet could be tricked into signing inputs spending its regular
+	// addresses.
+	accounts := make(map[types.UnlockHash]struct{})
+	err = dbForEachMultisigAccount(w.dbTx, func(addr types.UnlockHash, _ multisigAccount) {
+		accounts[addr] = struct{}{}
+	})
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+
+	// Sign every input with all keys the wallet has.
+	txn := pst.Transaction
+	txn.TransactionSignatures = append([]types.TransactionSignature(nil), txn.TransactionSignatures...)
+	var signed bool
+	for _, sci := range txn.SiacoinInputs {
+		if _, exists := accounts[sci.UnlockConditions.UnlockHash()]; !exists {
+			continue
+		}
+		for j, pk := range sci.UnlockConditions.PublicKeys {
+			sk, ok := w.secretKeyForPublicKey(pk)
+			if !ok {
+				continue
+			}
+			sigIndex := -1
+			for k, sig := range txn.TransactionSignatures {
+				if sig.ParentID == crypto.Hash(sci.ParentID) && sig.PublicKeyIndex == uint64(j) {
+					sigIndex = k
+					break
+				}
+			}
+			if sigIndex == -1 {
+				sigIndex = len(txn.TransactionSignatures)
+				txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+					ParentID:       crypto.Hash(sci.ParentID),
+					PublicKeyIndex: uint64(j),
+					CoveredFields:  types.FullCoveredFields,
+				})
+			}
+			sig := crypto.SignHash(txn.SigHash(sigIndex, height), sk)
+			txn.TransactionSignatures[sigIndex].Signature = sig[:]
+			signed = true
+		}
+	}
+	if !signed {
+		return modules.PartiallySignedTransaction{}, errNoMultisigKeys
+	}
+	pst.Transaction = txn
+	return pst, nil
+}
+
+// secretKeyForPublicKey returns the secret key of the wallet which belongs to the
+// provided public key. The caller must hold the lock.
+func (w *Wallet) secretKeyForPublicKey(pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+	if pk.Algorithm != types.SignatureEd25519 {
+		return crypto.SecretKey{}, false
+	}
+	for _, sk := range w.keys {
+		for _, key := range sk.SecretKeys {
+			pubKey := key.PublicKey()
+			if bytes.Equal(pk.Key, pubKey[:]) {
+				return key, true
+			}
+		}
+	}
+	return crypto.SecretKey{}, false
+}
+
+// BroadcastMultisigTransaction finalizes a partially signed transaction which
+// has enough signatures and submits it to the transaction pool.
+func (w *Wallet) BroadcastMultisigTransaction(pst modules.PartiallySignedTransaction) (types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	txn, err := modules.FinalizePartiallySignedTransaction(pst)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	if err := w.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		return types.Transaction{}, errors.AddContext(err, "transaction pool rejected the transaction")
+	}
+	return txn, nil
+}
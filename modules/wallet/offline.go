@@ -103,6 +103,22 @@ outer:
 		outputs[i].IsWatchOnly = ok
 	}
 
+	// add the labels and frozen state
+	addrLabels := make(map[types.UnlockHash]string)
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		addrLabels[addr] = label
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range outputs {
+		outputs[i].AddressLabel = addrLabels[o.UnlockHash]
+		if om, err := dbGetOutputMetadata(w.dbTx, o.ID); err == nil {
+			outputs[i].Label = om.Label
+			outputs[i].Frozen = om.Frozen
+		}
+	}
+
 	return outputs, nil
 }
 
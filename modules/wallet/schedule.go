@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errScheduleNotFound is returned when a scheduled payment doesn't exist.
+	errScheduleNotFound = errors.New("scheduled payment not found")
+)
+
+// pendingPayment contains the signed transactions of a scheduled payment. It
+// is persisted before the transactions are broadcast and removed once the
+// result of the broadcast was recorded in the schedule. If the wallet shuts
+// down in between, the transactions are broadcast again instead of sending a
+// new payment.
+type pendingPayment struct {
+	NextHeight   types.BlockHeight
+	Transactions []types.Transaction
+}
+
+// validatePaymentSchedule checks that a scheduled payment can be sent.
+func validatePaymentSchedule(ps modules.PaymentSchedule) error {
+	if len(ps.Outputs) == 0 {
+		return errors.New("scheduled payment has no outputs")
+	}
+	for _, sco := range ps.Outputs {
+		if sco.Value.IsZero() {
+			return errors.New("scheduled payment can't send zero siacoins")
+		}
+	}
+	if ps.Interval == 0 && ps.Remaining > 1 {
+		return errors.New("a one-off payment can't have more than one remaining payment")
+	}
+	return nil
+}
+
+// AddPaymentSchedule adds a scheduled payment to the wallet. The wallet sends
+// the payment while it is unlocked once the blockchain reaches NextHeight.
+func (w *Wallet) AddPaymentSchedule(ps modules.PaymentSchedule) (modules.PaymentSchedule, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PaymentSchedule{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := validatePaymentSchedule(ps); err != nil {
+		return modules.PaymentSchedule{}, err
+	}
+	fastrand.Read(ps.ID[:])
+	ps.Completed = false
+	ps.Payments = 0
+	ps.LastTransactionID = types.TransactionID{}
+	ps.LastError = ""
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutPaymentSchedule(w.dbTx, ps); err != nil {
+		return modules.PaymentSchedule{}, err
+	}
+	w.log.Printf("Added scheduled payment %v due at height %v", ps.ID, ps.NextHeight)
+	return ps, nil
+}
+
+// PaymentSchedules returns the scheduled payments of the wallet ordered by
+// the height of their next payment.
+func (w *Wallet) PaymentSchedules() ([]modules.PaymentSchedule, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	schedules := []modules.PaymentSchedule{}
+	err := dbForEachPaymentSchedule(w.dbTx, func(_ crypto.Hash, ps modules.PaymentSchedule) {
+		schedules = append(schedules, ps)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextHeight < schedules[j].NextHeight
+	})
+	return schedules, nil
+}
+
+// RemovePaymentSchedule removes a scheduled payment from the wallet.
+func (w *Wallet) RemovePaymentSchedule(id crypto.Hash) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetPaymentSchedule(w.dbTx, id); err != nil {
+		return errScheduleNotFound
+	}
+	return dbDeletePaymentSchedule(w.dbTx, id)
+}
+
+// UpdatePaymentSchedule replaces the label, outputs, next height, interval and
+// remaining payments of a scheduled payment. A completed schedule becomes
+// active again.
+func (w *Wallet) UpdatePaymentSchedule(ps modules.PaymentSchedule) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := validatePaymentSchedule(ps); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	old, err := dbGetPaymentSchedule(w.dbTx, ps.ID)
+	if err != nil {
+		return errScheduleNotFound
+	}
+	old.Label = ps.Label
+	old.Outputs = ps.Outputs
+	old.NextHeight = ps.NextHeight
+	old.Interval = ps.Interval
+	old.Remaining = ps.Remaining
+	old.Completed = false
+	return dbPutPaymentSchedule(w.dbTx, old)
+}
+
+// managedDuePaymentSchedules returns the scheduled payments which are due at
+// the current height.
+func (w *Wallet) managedDuePaymentSchedules() ([]modules.PaymentSchedule, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, nil
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	// Schedules with a pending payment are not due until the payment was
+	// resolved.
+	pending := make(map[crypto.Hash]struct{})
+	err = dbForEachPendingPayment(w.dbTx, func(id crypto.Hash, _ pendingPayment) {
+		pending[id] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+	var due []modules.PaymentSchedule
+	err = dbForEachPaymentSchedule(w.dbTx, func(id crypto.Hash, ps modules.PaymentSchedule) {
+		if _, exists := pending[id]; !exists && !ps.Completed && ps.NextHeight <= height {
+			due = append(due, ps)
+		}
+	})
+	return due, err
+}
+
+// managedPersistPendingPayment persists the signed transactions of a scheduled
+// payment before they are broadcast.
+func (w *Wallet) managedPersistPendingPayment(ps modules.PaymentSchedule, txns []types.Transaction) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := dbPutPendingPayment(w.dbTx, ps.ID, pendingPayment{
+		NextHeight:   ps.NextHeight,
+		Transactions: txns,
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to persist pending payment")
+	}
+	return w.syncDB()
+}
+
+// managedResolvePendingPayments resolves the payments which were being
+// broadcast when the wallet shut down. Payments which are known to the wallet
+// or the transaction pool are recorded as sent. The others are broadcast
+// again.
+func (w *Wallet) managedResolvePendingPayments() error {
+	w.mu.Lock()
+	pending := make(map[crypto.Hash]pendingPayment)
+	err := dbForEachPendingPayment(w.dbTx, func(id crypto.Hash, pp pendingPayment) {
+		pending[id] = pp
+	})
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for id, pp := range pending {
+		ps := modules.PaymentSchedule{ID: id, NextHeight: pp.NextHeight}
+		var sendErr error
+		if len(pp.Transactions) == 0 {
+			sendErr = errors.New("pending payment has no transactions")
+		} else if _, known, err := w.Transaction(pp.Transactions[len(pp.Transactions)-1].ID()); err != nil {
+			return err
+		} else if !known {
+			sendErr = w.tpool.AcceptTransactionSet(pp.Transactions)
+			if errors.Contains(sendErr, modules.ErrDuplicateTransactionSet) {
+				sendErr = nil
+			}
+		}
+		if sendErr != nil {
+			w.log.Printf("WARN: couldn't broadcast pending payment %v: %v", id, sendErr)
+		} else {
+			w.log.Printf("Recovered scheduled payment %v in transaction %v", id, pp.Transactions[len(pp.Transactions)-1].ID())
+		}
+		if err := w.managedUpdatePaymentSchedule(ps, pp.Transactions, sendErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedUpdatePaymentSchedule records the result of sending a scheduled
+// payment and advances the schedule to its next payment if it succeeded. The
+// next payment is due at the first interval after the current height, so a
+// payment which fell behind is only sent once.
+func (w *Wallet) managedUpdatePaymentSchedule(sent modules.PaymentSchedule, txns []types.Transaction, sendErr error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The payment is no longer pending.
+	if err := dbDeletePendingPayment(w.dbTx, sent.ID); err != nil {
+		return err
+	}
+
+	// The schedule might have been removed or updated while the payment was
+	// sent.
+	ps, err := dbGetPaymentSchedule(w.dbTx, sent.ID)
+	if err != nil {
+		return w.syncDB()
+	}
+	if sendErr != nil {
+		ps.LastError = sendErr.Error()
+	} else {
+		ps.LastError = ""
+		ps.Payments++
+		ps.LastTransactionID = txns[len(txns)-1].ID()
+		if ps.NextHeight == sent.NextHeight && ps.Interval > 0 {
+			// Payments which were missed, e.g. because the wallet was
+			// locked, are skipped instead of being sent one per block.
+			height, err := dbGetConsensusHeight(w.dbTx)
+			if err != nil {
+				return err
+			}
+			ps.NextHeight += ps.Interval
+			if ps.NextHeight <= height {
+				ps.NextHeight += (height-ps.NextHeight)/ps.Interval*ps.Interval + ps.Interval
+			}
+		}
+		if ps.Remaining > 0 {
+			ps.Remaining--
+			ps.Completed = ps.Remaining == 0
+		}
+		ps.Completed = ps.Completed || ps.Interval == 0
+	}
+	if err := dbPutPaymentSchedule(w.dbTx, ps); err != nil {
+		return err
+	}
+	// Persist the schedule right away to avoid sending the payment again
+	// after an unclean shutdown.
+	return w.syncDB()
+}
+
+// threadedSendScheduledPayments sends the scheduled payments which are due.
+func (w *Wallet) threadedSendScheduledPayments() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	if !w.scheduleLock.TryLock() {
+		return
+	}
+	defer w.scheduleLock.Unlock()
+
+	if !w.managedUnlocked() {
+		return
+	}
+	if err := w.managedResolvePendingPayments(); err != nil {
+		w.log.Println("WARN: couldn't resolve pending payments:", err)
+		return
+	}
+	due, err := w.managedDuePaymentSchedules()
+	if err != nil {
+		w.log.Println("WARN: couldn't load scheduled payments:", err)
+		return
+	}
+	for _, ps := range due {
+		ps := ps
+		txns, sendErr := w.managedSendSiacoinsMulti(ps.Outputs, func(txns []types.Transaction) error {
+			return w.managedPersistPendingPayment(ps, txns)
+		})
+		if sendErr != nil {
+			w.log.Printf("WARN: couldn't send scheduled payment %v: %v", ps.ID, sendErr)
+		} else {
+			w.log.Printf("Sent scheduled payment %v in transaction %v", ps.ID, txns[len(txns)-1].ID())
+		}
+		if err := w.managedUpdatePaymentSchedule(ps, txns, sendErr); err != nil {
+			w.log.Println("WARN: couldn't update scheduled payment:", err)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPaymentSchedules checks that one-off and recurring scheduled payments
+// are sent once they are due.
+func TestPaymentSchedules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(10)
+
+	// Invalid schedules are rejected.
+	if _, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{NextHeight: height + 1}); err == nil {
+		t.Fatal("schedule without outputs should be rejected")
+	}
+
+	// Add a one-off and a recurring payment.
+	oneOff, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Label:      "one-off",
+		Outputs:    []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{1}}},
+		NextHeight: height + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recurring, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Label:      "recurring",
+		Outputs:    []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{2}}},
+		NextHeight: height + 1,
+		Interval:   1,
+		Remaining:  2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	future, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Outputs:    []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{3}}},
+		NextHeight: height + 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedules, err := wt.wallet.PaymentSchedules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 3 || schedules[2].ID != future.ID {
+		t.Fatal("unexpected schedules", schedules)
+	}
+
+	// schedule returns the current state of a schedule.
+	schedule := func(ps modules.PaymentSchedule) modules.PaymentSchedule {
+		schedules, err := wt.wallet.PaymentSchedules()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range schedules {
+			if s.ID == ps.ID {
+				return s
+			}
+		}
+		t.Fatal("schedule not found", ps.ID)
+		return modules.PaymentSchedule{}
+	}
+
+	// Mine blocks until both payments were sent twice.
+	for i := 0; i < 2; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		err = build.Retry(50, 100*time.Millisecond, func() error {
+			if ps := schedule(recurring); ps.Payments != uint64(i+1) {
+				return errors.New("recurring payment wasn't sent")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ps := schedule(oneOff); !ps.Completed || ps.Payments != 1 || ps.LastError != "" {
+		t.Fatal("one-off payment wasn't sent exactly once", ps)
+	}
+	if ps := schedule(recurring); !ps.Completed || ps.Remaining != 0 || ps.NextHeight != height+3 {
+		t.Fatal("recurring payment didn't complete", ps)
+	}
+	if ps := schedule(future); ps.Completed || ps.Payments != 0 {
+		t.Fatal("future payment was sent", ps)
+	}
+
+	// Update and remove schedules.
+	future.Label = "updated"
+	if err := wt.wallet.UpdatePaymentSchedule(future); err != nil {
+		t.Fatal(err)
+	}
+	if ps := schedule(future); ps.Label != "updated" {
+		t.Fatal("schedule wasn't updated", ps)
+	}
+	if err := wt.wallet.RemovePaymentSchedule(future.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.RemovePaymentSchedule(future.ID); !errors.Contains(err, errScheduleNotFound) {
+		t.Fatal("expected errScheduleNotFound, got", err)
+	}
+}
+
+// TestPaymentScheduleMissed checks that a recurring payment which missed
+// several intervals is sent once and continues at the next interval after the
+// current height.
+func TestPaymentScheduleMissed(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a payment whose first payment is several intervals in the past.
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := types.BlockHeight(4)
+	for ; height < 3*interval; height++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	added, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Outputs:    []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{1}}},
+		NextHeight: 1,
+		Interval:   interval,
+		Remaining:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule := func() modules.PaymentSchedule {
+		schedules, err := wt.wallet.PaymentSchedules()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schedules) != 1 || schedules[0].ID != added.ID {
+			t.Fatal("unexpected schedules", schedules)
+		}
+		return schedules[0]
+	}
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		height++
+	}
+	waitForPayments := func(payments uint64) {
+		err := build.Retry(50, 100*time.Millisecond, func() error {
+			if ps := schedule(); ps.Payments != payments {
+				return errors.New("payment wasn't sent")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The missed payments are sent as a single payment, and the schedule
+	// continues at the next interval after the current height.
+	mine()
+	waitForPayments(1)
+	expected := 1 + ((height-1)/interval+1)*interval
+	if ps := schedule(); ps.NextHeight != expected || ps.Remaining != 2 {
+		t.Fatal("unexpected schedule after catching up", ps, expected)
+	}
+
+	// No other payment is sent until the next interval.
+	for height+1 < expected {
+		mine()
+	}
+	time.Sleep(time.Second)
+	if ps := schedule(); ps.Payments != 1 {
+		t.Fatal("missed payment was sent", ps)
+	}
+	mine()
+	waitForPayments(2)
+	if ps := schedule(); ps.NextHeight != expected+interval || ps.Remaining != 1 {
+		t.Fatal("unexpected schedule after the next interval", ps)
+	}
+}
+
+// TestPaymentSchedulePending checks that scheduled payments which were
+// interrupted before or after their transactions were broadcast are not sent
+// twice.
+func TestPaymentSchedulePending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	deps := &dependencySendSiacoinsInterrupted{}
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Signatures are only valid across blocks once the hardforks changing
+	// the replay protection are active.
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ; height < types.FoundationHardforkHeight; height++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	amount := types.SiacoinPrecision.Mul64(10)
+
+	// Add two payments which are due at the next block.
+	newSchedule := func(dest types.UnlockHash) modules.PaymentSchedule {
+		ps, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+			Outputs:    []types.SiacoinOutput{{Value: amount, UnlockHash: dest}},
+			NextHeight: height + 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ps
+	}
+	beforeBroadcast, afterBroadcast := newSchedule(types.UnlockHash{1}), newSchedule(types.UnlockHash{2})
+
+	// send sends a payment the way the wallet does but without recording the
+	// result, as if the wallet crashed. It returns the id of the persisted
+	// transaction.
+	send := func(ps modules.PaymentSchedule) types.TransactionID {
+		var id types.TransactionID
+		_, _ = wt.wallet.managedSendSiacoinsMulti(ps.Outputs, func(txns []types.Transaction) error {
+			id = txns[len(txns)-1].ID()
+			return wt.wallet.managedPersistPendingPayment(ps, txns)
+		})
+		return id
+	}
+
+	// Send the second payment and interrupt the first one before its
+	// transactions are broadcast. The schedule lock keeps the goroutine which
+	// was started by the last block from resolving the pending payments in the
+	// meantime.
+	wt.wallet.scheduleLock.Lock()
+	afterID := send(afterBroadcast)
+	if _, known, err := wt.wallet.Transaction(afterID); err != nil || !known {
+		t.Fatal("transaction should have been broadcast", known, err)
+	}
+	deps.fail()
+	beforeID := send(beforeBroadcast)
+	if _, known, err := wt.wallet.Transaction(beforeID); err != nil || known {
+		t.Fatal("transaction shouldn't have been broadcast", known, err)
+	}
+	wt.wallet.scheduleLock.Unlock()
+
+	// Once the payments are due, the first transaction should be broadcast
+	// again and the second one should be recorded. Neither of the payments
+	// should be sent again.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[types.TransactionID]modules.PaymentSchedule{beforeID: beforeBroadcast, afterID: afterBroadcast}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		schedules, err := wt.wallet.PaymentSchedules()
+		if err != nil {
+			return err
+		}
+		for _, ps := range schedules {
+			if ps.Payments != 1 {
+				return errors.New("payment wasn't recorded")
+			}
+			if expected[ps.LastTransactionID].ID != ps.ID {
+				return errors.New("payment was sent again")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, known, err := wt.wallet.Transaction(beforeID); err != nil || !known {
+		t.Fatal("pending transaction wasn't broadcast", known, err)
+	}
+}
@@ -25,6 +25,9 @@ var (
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
+	// errOutputFrozen indicates an output was frozen by the user.
+	errOutputFrozen = errors.New("output is frozen")
+
 	// errSpendHeightTooHigh indicates an output's spend height is greater than
 	// the allowed height.
 	errSpendHeightTooHigh = errors.New("output spend height exceeds the allowed height")
@@ -112,6 +115,10 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	if currentHeight < outputUnlockConditions.Timelock {
 		return errOutputTimelock
 	}
+	// Check that the output hasn't been frozen by the user.
+	if om, err := dbGetOutputMetadata(tx, types.OutputID(id)); err == nil && om.Frozen {
+		return errOutputFrozen
+	}
 
 	return nil
 }
@@ -393,6 +400,10 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 		if consensusHeight < outputUnlockConditions.Timelock {
 			continue
 		}
+		// Skip outputs which were frozen by the user.
+		if om, err := dbGetOutputMetadata(tb.wallet.dbTx, types.OutputID(sfoid)); err == nil && om.Frozen {
+			continue
+		}
 
 		// Add a siafund input for this output.
 		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
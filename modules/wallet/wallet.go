@@ -109,6 +109,10 @@ type Wallet struct {
 	// initialization.
 	scanLock siasync.TryMutex
 
+	// scheduleLock prevents scheduled payments from being processed by more
+	// than one thread at a time.
+	scheduleLock siasync.TryMutex
+
 	// The wallet's ThreadGroup tells tracked functions to shut down and
 	// blocks until they have all exited before returning from Close.
 	tg threadgroup.ThreadGroup
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// AccountingGET contains the current accounting information of the node
+	// as well as the persisted accounting history within the requested range.
+	AccountingGET struct {
+		Current modules.AccountingInfo   `json:"current"`
+		History []modules.AccountingInfo `json:"history"`
+	}
+)
+
+// accountingHandlerGET handles the API call to /accounting.
+func (api *API) accountingHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional start and end timestamps. By default the entire
+	// history is returned.
+	start, end := int64(0), int64(math.MaxInt64)
+	var err error
+	if startStr := req.FormValue("start"); startStr != "" {
+		start, err = strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `start` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if endStr := req.FormValue("end"); endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `end` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if start > end {
+		WriteError(w, Error{"parameter `start` cannot be greater than parameter `end`"}, http.StatusBadRequest)
+		return
+	}
+
+	// Grab the current accounting information and the history.
+	current, err := api.accounting.Accounting()
+	if err != nil {
+		WriteError(w, Error{"unable to get the current accounting information: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	history, err := api.accounting.AccountingHistory(start, end)
+	if err != nil {
+		WriteError(w, Error{"unable to get the accounting history: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, AccountingGET{
+		Current: current,
+		History: history,
+	})
+}
@@ -117,8 +117,15 @@ type (
 		tpool               modules.TransactionPool
 		wallet              modules.Wallet
 		staticConfigModules configModules
+		metricsEnabled      bool
 		modulesSet          bool
 
+		// tokens contains the scoped API tokens. If authenticateReads is set,
+		// routes which don't require the API password still require a token
+		// or the password.
+		tokens            *tokenStore
+		authenticateReads bool
+
 		downloadMu sync.Mutex
 		downloads  map[modules.DownloadID]func()
 		router     http.Handler
@@ -185,6 +192,36 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 	api.buildHTTPRoutes()
 }
 
+// EnableMetrics enables the /metrics endpoint which exports the metrics of the
+// loaded modules in the OpenMetrics text format.
+func (api *API) EnableMetrics() {
+	api.metricsEnabled = true
+	api.buildHTTPRoutes()
+}
+
+// EnableTokens loads the API tokens persisted in dir and enables
+// authentication with scoped API tokens. If authenticateReads is set, all
+// routes require authentication, including those which are not password
+// protected by default.
+func (api *API) EnableTokens(dir string, authenticateReads bool) error {
+	ts, err := newTokenStore(dir)
+	if err != nil {
+		return err
+	}
+	api.tokens = ts
+	api.authenticateReads = authenticateReads
+	api.buildHTTPRoutes()
+	return nil
+}
+
+// Close closes the API.
+func (api *API) Close() error {
+	if api.tokens == nil {
+		return nil
+	}
+	return api.tokens.close()
+}
+
 // StartTime returns the time at which the API started
 func (api *API) StartTime() time.Time {
 	return api.staticStartTime
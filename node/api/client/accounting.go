@@ -0,0 +1,14 @@
+package client
+
+import (
+	"fmt"
+
+	"go.sia.tech/siad/node/api"
+)
+
+// AccountingGet requests the /accounting resource. The history is filtered to
+// the entries with a Unix timestamp between start and end, inclusive.
+func (c *Client) AccountingGet(start, end int64) (ag api.AccountingGET, err error) {
+	err = c.get(fmt.Sprintf("/accounting?start=%v&end=%v", start, end), &ag)
+	return
+}
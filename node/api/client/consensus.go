@@ -32,6 +32,18 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	return
 }
 
+// ConsensusSnapshotGet requests the /consensus/snapshot api resource and writes
+// the snapshot of the consensus state at the provided height to w.
+func (c *Client) ConsensusSnapshotGet(height types.BlockHeight, w io.Writer) error {
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/consensus/snapshot?height=%v", height))
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(body)
+	_, err = io.Copy(w, body)
+	return err
+}
+
 // ConsensusSubscribeSingle streams consensus changes from the
 // /consensus/subscribe endpoint to the provided subscriber. Multiple calls may
 // be required before the subscriber is fully caught up. It returns the latest
@@ -3,6 +3,7 @@ package client
 import (
 	"net/url"
 	"strconv"
+	"strings"
 
 	"go.sia.tech/siad/node/api"
 )
@@ -74,3 +75,28 @@ func (c *Client) DaemonUpdatePost() (err error) {
 	err = c.post("/daemon/update", "", nil)
 	return
 }
+
+// DaemonTokensGet requests the /daemon/tokens resource.
+func (c *Client) DaemonTokensGet() (dtg api.DaemonTokensGet, err error) {
+	err = c.get("/daemon/tokens", &dtg)
+	return
+}
+
+// DaemonTokensCreatePost uses the /daemon/tokens/create endpoint to create a
+// new API token with the provided name and scopes.
+func (c *Client) DaemonTokensCreatePost(name string, scopes []string) (dtcp api.DaemonTokensCreatePOST, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("scopes", strings.Join(scopes, ","))
+	err = c.post("/daemon/tokens/create", values.Encode(), &dtcp)
+	return
+}
+
+// DaemonTokensRevokePost uses the /daemon/tokens/revoke endpoint to revoke the
+// API token with the provided name.
+func (c *Client) DaemonTokensRevokePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/daemon/tokens/revoke", values.Encode(), nil)
+	return
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -123,6 +124,44 @@ func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
 	return
 }
 
+// HostPolicyGet requests the /host/policy api resource.
+func (c *Client) HostPolicyGet() (hpg api.HostPolicyGET, err error) {
+	err = c.get("/host/policy", &hpg)
+	return
+}
+
+// HostPolicyPost uses the /host/policy api endpoint to replace the host's
+// renter policy.
+func (c *Client) HostPolicyPost(policy modules.HostPolicy) (err error) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	err = c.post("/host/policy", string(data), nil)
+	return
+}
+
+// HostPricingGet requests the /host/pricing api resource.
+func (c *Client) HostPricingGet() (hpg api.HostPricingGET, err error) {
+	err = c.get("/host/pricing", &hpg)
+	return
+}
+
+// HostPricingPost uses the /host/pricing api endpoint to change the settings
+// of the host's pricing engine. Only the provided parameters are changed.
+func (c *Client) HostPricingPost(values url.Values) (err error) {
+	err = c.post("/host/pricing", values.Encode(), nil)
+	return
+}
+
+// HostRevenueGet requests the /host/revenue api resource. Only the entries of
+// the revenue ledger within the range of the filter are returned.
+func (c *Client) HostRevenueGet(filter modules.HostRevenueFilter) (hrg api.HostRevenueGET, err error) {
+	query := fmt.Sprintf("?start=%v&end=%v&startheight=%v&endheight=%v", filter.Start, filter.End, filter.StartHeight, filter.EndHeight)
+	err = c.get("/host/revenue"+query, &hrg)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -133,6 +172,16 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersMigratePost uses the /host/storage/folders/migrate api
+// endpoint to migrate a storage folder to a new path.
+func (c *Client) HostStorageFoldersMigratePost(path, newPath string) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("newpath", newPath)
+	err = c.post("/host/storage/folders/migrate", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string, force bool) (err error) {
@@ -153,6 +202,16 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostStorageFoldersTierPost uses the /host/storage/folders/tier api endpoint
+// to move a storage folder to the fast tier or the standard tier.
+func (c *Client) HostStorageFoldersTierPost(path string, fastTier bool) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("fasttier", strconv.FormatBool(fastTier))
+	err = c.post("/host/storage/folders/tier", values.Encode(), nil)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
@@ -0,0 +1,8 @@
+package client
+
+// MetricsGet requests the /metrics resource and returns the metrics of the
+// node in the OpenMetrics text format.
+func (c *Client) MetricsGet() ([]byte, error) {
+	_, metrics, err := c.getRawResponse("/metrics")
+	return metrics, err
+}
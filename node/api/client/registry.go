@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+// RegistrySubscription is an active subscription to registry entries created
+// by RegistrySubscribePost.
+type RegistrySubscription struct {
+	staticBody    io.ReadCloser
+	staticDecoder *json.Decoder
+}
+
+// Close closes the subscription.
+func (rs *RegistrySubscription) Close() error {
+	return rs.staticBody.Close()
+}
+
+// Next blocks until the next notification is received and returns it.
+func (rs *RegistrySubscription) Next() (api.RegistrySubscriptionNotification, error) {
+	var n api.RegistrySubscriptionNotification
+	err := rs.staticDecoder.Decode(&n)
+	return n, err
+}
+
+// RegistryRead queries the /renter/registry [GET] endpoint.
+func (c *Client) RegistryRead(spk types.SiaPublicKey, dataKey crypto.Hash) (modules.SignedRegistryValue, error) {
+	return c.RegistryReadWithTimeout(spk, dataKey, 0)
+}
+
+// RegistryReadWithTimeout queries the /renter/registry [GET] endpoint with the
+// specified timeout. A timeout of 0 uses the default timeout of the renter.
+func (c *Client) RegistryReadWithTimeout(spk types.SiaPublicKey, dataKey crypto.Hash, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	// Set the values.
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+	if timeout > 0 {
+		values.Set("timeout", fmt.Sprint(int(timeout.Seconds())))
+	}
+
+	// Send request.
+	var rhg api.RegistryHandlerGET
+	err := c.get(fmt.Sprintf("/renter/registry?%v", values.Encode()), &rhg)
+	if err != nil {
+		return modules.SignedRegistryValue{}, err
+	}
+
+	// Decode the data.
+	data, err := hex.DecodeString(rhg.Data)
+	if err != nil {
+		return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode data")
+	}
+	sig, err := hex.DecodeString(rhg.Signature)
+	if err != nil {
+		return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode signature")
+	}
+	var signature crypto.Signature
+	copy(signature[:], sig)
+
+	// Verify the signature.
+	srv := modules.NewSignedRegistryValue(dataKey, data, rhg.Revision, signature, rhg.Type)
+	err = srv.Verify(spk.ToPublicKey())
+	if err != nil {
+		return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to verify signature")
+	}
+	return srv, nil
+}
+
+// RegistryUpdate queries the /renter/registry [POST] endpoint.
+func (c *Client) RegistryUpdate(spk types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+	req := api.RegistryHandlerRequestPOST{
+		PublicKey: spk,
+		DataKey:   srv.Tweak,
+		Revision:  srv.Revision,
+		Signature: srv.Signature,
+		Data:      srv.Data,
+		Type:      srv.Type,
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.postRawResponse("/renter/registry", bytes.NewReader(reqBytes))
+	return err
+}
+
+// RegistrySubscribePost queries the /renter/registry/subscribe [POST]
+// endpoint. The returned subscription needs to be closed by the caller.
+func (c *Client) RegistrySubscribePost(entries []api.RegistrySubscriptionRequest) (*RegistrySubscription, error) {
+	reqBytes, err := json.Marshal(api.RegistrySubscribePOST{Entries: entries})
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.NewRequest("POST", "/renter/registry/subscribe", bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to construct POST request")
+	}
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.AddContext(err, "POST request failed")
+	}
+
+	// Add ErrAPICallNotRecognized if StatusCode is StatusModuleNotLoaded to
+	// allow for handling of modules that are not loaded
+	if res.StatusCode == api.StatusModuleNotLoaded || res.StatusCode == api.StatusModuleDisabled {
+		err = errors.Compose(readAPIError(res.Body), api.ErrAPICallNotRecognized)
+		drainAndClose(res.Body)
+		return nil, errors.AddContext(err, "unable to perform POST on /renter/registry/subscribe")
+	}
+
+	// If the status code is not 2xx, decode and return the accompanying
+	// api.Error.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err := readAPIError(res.Body)
+		drainAndClose(res.Body)
+		return nil, errors.AddContext(err, "POST request error")
+	}
+	return &RegistrySubscription{
+		staticBody:    res.Body,
+		staticDecoder: json.NewDecoder(res.Body),
+	}, nil
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -525,6 +526,15 @@ func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
 	return
 }
 
+// RenterSetDedupPost uses the /renter endpoint to enable/disable the
+// deduplication of newly uploaded chunks in the renter.
+func (c *Client) RenterSetDedupPost(enabled bool) (err error) {
+	values := url.Values{}
+	values.Set("dedup", fmt.Sprint(enabled))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterStreamGet uses the /renter/stream endpoint to download data as a
 // stream.
 func (c *Client) RenterStreamGet(siaPath modules.SiaPath, disableLocalFetch, root bool) (resp []byte, err error) {
@@ -557,6 +567,17 @@ func (c *Client) RenterSetRepairPathPost(siaPath modules.SiaPath, newPath string
 	return
 }
 
+// RenterFileChangeRedundancyPost uses the /renter/file endpoint to start
+// re-encoding the file at siaPath with a new erasure code.
+func (c *Client) RenterFileChangeRedundancyPost(siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
 // RenterSetFileStuckPost sets the 'stuck' field of the siafile at siaPath to
 // stuck.
 func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck bool) (err error) {
@@ -586,6 +607,22 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.SiaPath, dat
 	return
 }
 
+// RenterUploadBatchPost uses the /renter/uploadbatch endpoint to upload many
+// small files packed into shared sectors.
+func (c *Client) RenterUploadBatchPost(files []modules.BatchUploadFile, dataPieces, parityPieces uint64, force bool) (rub api.RenterUploadBatch, err error) {
+	data, err := json.Marshal(api.RenterUploadBatchPOST{
+		Files:        files,
+		DataPieces:   dataPieces,
+		ParityPieces: parityPieces,
+		Force:        force,
+	})
+	if err != nil {
+		return api.RenterUploadBatch{}, err
+	}
+	err = c.post("/renter/uploadbatch", string(data), &rub)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (err error) {
@@ -661,6 +698,30 @@ func (c *Client) RenterDirRenamePost(siaPath, newSiaPath modules.SiaPath) (err e
 	return
 }
 
+// RenterDirSetPolicyPost uses the /renter/dir/ endpoint to set the policy of a
+// directory for the renter
+func (c *Client) RenterDirSetPolicyPost(siaPath modules.SiaPath, policy modules.DirPolicy) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setpolicy")
+	if policy.DataPieces != 0 || policy.ParityPieces != 0 {
+		values.Set("datapieces", strconv.Itoa(policy.DataPieces))
+		values.Set("paritypieces", strconv.Itoa(policy.ParityPieces))
+	}
+	if policy.CipherType != "" {
+		values.Set("ciphertype", policy.CipherType)
+	}
+	var hosts []string
+	for _, host := range policy.Hosts {
+		hosts = append(hosts, host.String())
+	}
+	if len(hosts) > 0 {
+		values.Set("hosts", strings.Join(hosts, ","))
+	}
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.SiaPath) (rd api.RenterDirectory, err error) {
@@ -141,6 +141,24 @@ func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api
 	return
 }
 
+// WalletSiacoinsInputsPost uses the /wallet/siacoins api endpoint to send
+// money to multiple addresses, spending exactly the provided inputs.
+func (c *Client) WalletSiacoinsInputsPost(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	marshaledInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("inputs", string(marshaledInputs))
+	values.Set("outputs", string(marshaledOutputs))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletSiacoinsPost uses the /wallet/siacoins api endpoint to send money to a
 // single address
 func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool) (wsp api.WalletSiacoinsPOST, err error) {
@@ -202,10 +220,45 @@ func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight
 	return
 }
 
+// WalletTimelockGet requests the /wallet/timelock api resource to get the
+// address of a time-locked output.
+func (c *Client) WalletTimelockGet(pk types.SiaPublicKey, timelock types.BlockHeight) (wtg api.WalletTimelockGET, err error) {
+	values := url.Values{}
+	values.Set("publickey", pk.String())
+	values.Set("timelock", fmt.Sprint(timelock))
+	err = c.get("/wallet/timelock?"+values.Encode(), &wtg)
+	return
+}
+
+// WalletTimelockPost uses the /wallet/timelock endpoint to send siacoins to
+// an output which can only be spent by pk once the blockchain reached the
+// height timelock.
+func (c *Client) WalletTimelockPost(amount types.Currency, pk types.SiaPublicKey, timelock types.BlockHeight) (wtp api.WalletTimelockPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("publickey", pk.String())
+	values.Set("timelock", fmt.Sprint(timelock))
+	err = c.post("/wallet/timelock", values.Encode(), &wtp)
+	return
+}
+
 // WalletTransactionGet requests the /wallet/transaction/:id api resource for a
 // certain TransactionID.
 func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
-	err = c.get("/wallet/transaction/"+id.String(), wtg)
+	err = c.get("/wallet/transaction/"+id.String(), &wtg)
+	return
+}
+
+// WalletTransactionBumpPost uses the /wallet/transaction/:id/bump endpoint to
+// increase the fee of an unconfirmed transaction. A zero feePerByte uses the
+// fee recommended by the transaction pool.
+func (c *Client) WalletTransactionBumpPost(id types.TransactionID, method modules.FeeBumpMethod, feePerByte types.Currency) (wtbp api.WalletTransactionBumpPOST, err error) {
+	values := url.Values{}
+	values.Set("method", string(method))
+	if !feePerByte.IsZero() {
+		values.Set("feeperbyte", feePerByte.String())
+	}
+	err = c.post("/wallet/transaction/"+id.String()+"/bump", values.Encode(), &wtbp)
 	return
 }
 
@@ -232,6 +285,38 @@ func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
 	return
 }
 
+// WalletUnspentFreezePost uses the /wallet/unspent/:id/freeze endpoint to
+// freeze or unfreeze an output.
+func (c *Client) WalletUnspentFreezePost(id types.OutputID, frozen bool) error {
+	values := url.Values{}
+	values.Set("frozen", strconv.FormatBool(frozen))
+	return c.post("/wallet/unspent/"+id.String()+"/freeze", values.Encode(), nil)
+}
+
+// WalletUnspentLabelPost uses the /wallet/unspent/:id/label endpoint to label
+// an output. An empty label removes the label.
+func (c *Client) WalletUnspentLabelPost(id types.OutputID, label string) error {
+	values := url.Values{}
+	values.Set("label", label)
+	return c.post("/wallet/unspent/"+id.String()+"/label", values.Encode(), nil)
+}
+
+// WalletLabelsGet requests the /wallet/labels endpoint and returns the labels
+// of the wallet's addresses.
+func (c *Client) WalletLabelsGet() (wlg api.WalletLabelsGET, err error) {
+	err = c.get("/wallet/labels", &wlg)
+	return
+}
+
+// WalletLabelsPost uses the /wallet/labels endpoint to label an address of the
+// wallet. An empty label removes the label.
+func (c *Client) WalletLabelsPost(addr types.UnlockHash, label string) error {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("label", label)
+	return c.post("/wallet/labels", values.Encode(), nil)
+}
+
 // WalletWatchGet requests the /wallet/watch endpoint and returns the set of
 // currently watched addresses.
 func (c *Client) WalletWatchGet() (wwg api.WalletWatchGET, err error) {
@@ -278,3 +363,127 @@ func (c *Client) Wallet033xPost(path, password string) (err error) {
 	err = c.post("/wallet/033x", values.Encode(), nil)
 	return
 }
+
+// WalletMultisigGet requests the /wallet/multisig endpoint and returns the
+// multisig accounts tracked by the wallet.
+func (c *Client) WalletMultisigGet() (wmg api.WalletMultisigGET, err error) {
+	err = c.get("/wallet/multisig", &wmg)
+	return
+}
+
+// WalletMultisigPost uses the /wallet/multisig endpoint to add a multisig
+// account to the wallet. The unused flag should be set to true if the address
+// has never appeared in the blockchain.
+func (c *Client) WalletMultisigPost(name string, pks []types.SiaPublicKey, required uint64, unused bool) (wmp api.WalletMultisigPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigPOSTParams{
+		Name:       name,
+		PublicKeys: pks,
+		Required:   required,
+		Unused:     unused,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig", string(json), &wmp)
+	return
+}
+
+// WalletMultisigRemovePost uses the /wallet/multisig/remove endpoint to remove
+// a multisig account from the wallet.
+func (c *Client) WalletMultisigRemovePost(addr types.UnlockHash, unused bool) error {
+	json, err := json.Marshal(api.WalletMultisigRemovePOSTParams{
+		Address: addr,
+		Unused:  unused,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/multisig/remove", string(json), nil)
+}
+
+// WalletMultisigTransactionPost uses the /wallet/multisig/transaction endpoint
+// to create an unsigned transaction spending from a multisig account.
+func (c *Client) WalletMultisigTransactionPost(addr types.UnlockHash, outputs []types.SiacoinOutput) (wmtp api.WalletMultisigTransactionPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigTransactionPOSTParams{
+		Address: addr,
+		Outputs: outputs,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/transaction", string(json), &wmtp)
+	return
+}
+
+// WalletMultisigSignPost uses the /wallet/multisig/sign endpoint to add the
+// wallet's signatures to a partially signed transaction.
+func (c *Client) WalletMultisigSignPost(pst modules.PartiallySignedTransaction) (wmtp api.WalletMultisigTransactionPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigSignPOSTParams{
+		PartiallySignedTransaction: pst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/sign", string(json), &wmtp)
+	return
+}
+
+// WalletMultisigCombinePost uses the /wallet/multisig/combine endpoint to merge
+// the signatures of partially signed transactions.
+func (c *Client) WalletMultisigCombinePost(psts ...modules.PartiallySignedTransaction) (wmtp api.WalletMultisigTransactionPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigCombinePOSTParams{
+		PartiallySignedTransactions: psts,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/combine", string(json), &wmtp)
+	return
+}
+
+// WalletMultisigBroadcastPost uses the /wallet/multisig/broadcast endpoint to
+// finalize a fully signed transaction and submit it to the transaction pool.
+func (c *Client) WalletMultisigBroadcastPost(pst modules.PartiallySignedTransaction) (wmbp api.WalletMultisigBroadcastPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigSignPOSTParams{
+		PartiallySignedTransaction: pst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/broadcast", string(json), &wmbp)
+	return
+}
+
+// WalletSchedulesGet requests the /wallet/schedules endpoint and returns the
+// scheduled payments of the wallet.
+func (c *Client) WalletSchedulesGet() (wsg api.WalletSchedulesGET, err error) {
+	err = c.get("/wallet/schedules", &wsg)
+	return
+}
+
+// WalletSchedulesPost uses the /wallet/schedules endpoint to add a scheduled
+// payment to the wallet.
+func (c *Client) WalletSchedulesPost(params api.WalletSchedulePOSTParams) (wsp api.WalletSchedulesPOST, err error) {
+	json, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/schedules", string(json), &wsp)
+	return
+}
+
+// WalletSchedulePost uses the /wallet/schedules/:id endpoint to update a
+// scheduled payment.
+func (c *Client) WalletSchedulePost(id crypto.Hash, params api.WalletSchedulePOSTParams) error {
+	json, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/schedules/"+id.String(), string(json), nil)
+}
+
+// WalletScheduleRemovePost uses the /wallet/schedules/:id/remove endpoint to
+// remove a scheduled payment from the wallet.
+func (c *Client) WalletScheduleRemovePost(id crypto.Hash) error {
+	return c.post("/wallet/schedules/"+id.String()+"/remove", "", nil)
+}
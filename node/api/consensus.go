@@ -116,13 +116,19 @@ type ConsensusBlocksGetSiafundOutput struct {
 }
 
 // RegisterRoutesConsensus is a helper function to register all consensus routes.
-func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet) {
+func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet, requiredPassword string) {
 	router.GET("/consensus", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusHandler(cs, w, req, ps)
 	})
 	router.GET("/consensus/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusBlocksHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/feed", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusFeedHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/snapshot", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusSnapshotHandler(cs, w, req, ps)
+	}, requiredPassword))
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
@@ -323,6 +329,24 @@ func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.Resp
 	WriteSuccess(w)
 }
 
+// consensusSnapshotHandler handles the API calls to the /consensus/snapshot
+// endpoint.
+func consensusSnapshotHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	height := cs.Height()
+	if h := req.FormValue("height"); h != "" {
+		if _, err := fmt.Sscan(h, &height); err != nil {
+			WriteError(w, Error{"failed to parse block height"}, http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	err := cs.ExportSnapshot(w, height)
+	if err != nil {
+		WriteError(w, Error{"failed to export snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+}
+
 // consensusSubscribeHandler handles the API calls to the /consensus/subscribe
 // endpoint.
 func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -1,13 +1,21 @@
 package api
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/consensus"
 	"go.sia.tech/siad/types"
 )
 
@@ -168,3 +176,216 @@ func TestIntegrationConsensusSubscribe(t *testing.T) {
 		}
 	}
 }
+
+// readConsensusFeedChange reads the next change of a consensus feed and
+// returns it together with the id of its event.
+func readConsensusFeedChange(r *bufio.Reader) (change ConsensusFeedChange, id string, err error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return ConsensusFeedChange{}, "", err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &change); err != nil {
+				return ConsensusFeedChange{}, "", err
+			}
+		case line == "" && id != "":
+			return change, id, nil
+		}
+	}
+}
+
+// TestConsensusFeed probes the /consensus/feed endpoint.
+func TestConsensusFeed(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// openFeed opens a consensus feed with the provided query and
+	// Last-Event-ID.
+	client := &http.Client{Timeout: time.Minute}
+	openFeed := func(query, lastEventID string) *http.Response {
+		req, err := http.NewRequest("GET", "http://"+st.server.listener.Addr().String()+"/consensus/feed?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("User-Agent", "Sia-Agent")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Follow the feed from the beginning until it reaches the current block.
+	resp := openFeed("since=beginning", "")
+	defer resp.Body.Close()
+	if non2xx(resp.StatusCode) {
+		t.Fatal(decodeError(resp))
+	}
+	r := bufio.NewReader(resp.Body)
+	var ids []string
+	for {
+		change, id, err := readConsensusFeedChange(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != change.ID.String() {
+			t.Fatal("event id doesn't match change id", id, change.ID)
+		}
+		if len(ids) == 0 && (len(change.AppliedBlocks) == 0 || change.AppliedBlocks[0].ID != types.GenesisID || change.AppliedBlocks[0].Height != 0) {
+			t.Fatal("feed doesn't start with the genesis block")
+		}
+		last := change.AppliedBlocks[len(change.AppliedBlocks)-1]
+		if b, _ := st.cs.BlockAtHeight(last.Height); b.ID() != last.ID || change.Height != last.Height {
+			t.Fatal("wrong block height", last.Height, change.Height)
+		}
+		ids = append(ids, id)
+		if change.Height == st.cs.Height() {
+			break
+		}
+	}
+
+	// Resume the feed with the Last-Event-ID header.
+	resp = openFeed("", ids[2])
+	defer resp.Body.Close()
+	if _, id, err := readConsensusFeedChange(bufio.NewReader(resp.Body)); err != nil || id != ids[3] {
+		t.Fatal("feed didn't resume after the last event", id, err)
+	}
+
+	// Follow new changes for an address.
+	var uh types.UnlockHash
+	fastrand.Read(uh[:])
+	resp = openFeed("since=recent&types=siacoinoutputs&addresses="+uh.String(), "")
+	defer resp.Body.Close()
+	if non2xx(resp.StatusCode) {
+		t.Fatal(decodeError(resp))
+	}
+	amount := types.SiacoinPrecision.Mul64(3)
+	if _, err := st.wallet.SendSiacoins(amount, uh); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	change, _, err := readConsensusFeedChange(bufio.NewReader(resp.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(change.AppliedBlocks) != 1 || change.AppliedBlocks[0].ID != st.cs.CurrentBlock().ID() {
+		t.Fatal("expected the new block", change)
+	}
+	b := change.AppliedBlocks[0]
+	if len(b.SiacoinOutputDiffs) != 1 || len(b.DelayedSiacoinOutputDiffs) != 0 || len(b.SiafundPoolDiffs) != 0 {
+		t.Fatal("diffs weren't filtered", b)
+	}
+	if d := b.SiacoinOutputDiffs[0]; d.Direction != "apply" || d.SiacoinOutput.UnlockHash != uh || !d.SiacoinOutput.Value.Equals(amount) {
+		t.Fatal("wrong diff", d)
+	}
+
+	// Invalid parameters are rejected.
+	for _, query := range []string{"since=foo", "types=foo", "addresses=foo"} {
+		resp := openFeed(query, "")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatal("expected bad request for", query, resp.StatusCode)
+		}
+	}
+}
+
+// TestConsensusSnapshotGET probes the GET call to /consensus/snapshot.
+func TestConsensusSnapshotGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createAuthenticatedServerTester(t.Name(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	addr := "http://" + st.server.listener.Addr().String()
+
+	// Exporting a snapshot requires the password.
+	resp, err := HttpGET(addr + "/consensus/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected unauthorized, got", resp.Status)
+	}
+
+	// Export a snapshot at the current height and import it.
+	resp, err = HttpGETAuthenticated(addr+"/consensus/snapshot", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	dir := build.TempDir("api", t.Name(), "bootstrapped")
+	if err := consensus.ImportSnapshot(resp.Body, dir, st.cs.CurrentBlock().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Snapshots above the current height can't be exported.
+	resp, err = HttpGETAuthenticated(fmt.Sprintf("%v/consensus/snapshot?height=%v", addr, st.cs.Height()+1), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected an error for a height above the current height, got", resp.Status)
+	}
+}
+
+// TestConsensusFeedOverflow checks that a live feed subscriber stops passing
+// on changes once it had to drop one.
+func TestConsensusFeedOverflow(t *testing.T) {
+	t.Parallel()
+	sub := &consensusFeedSubscriber{
+		changes:  make(chan ConsensusFeedChange, 1),
+		cancel:   make(chan struct{}),
+		overflow: make(chan struct{}),
+		live:     true,
+	}
+	cc := func(i byte) modules.ConsensusChange {
+		return modules.ConsensusChange{ID: modules.ConsensusChangeID{i}}
+	}
+
+	// The second change doesn't fit into the buffer.
+	sub.ProcessConsensusChange(cc(1))
+	sub.ProcessConsensusChange(cc(2))
+	select {
+	case <-sub.overflow:
+	default:
+		t.Fatal("expected an overflow")
+	}
+	if change := <-sub.changes; change.ID[0] != 1 {
+		t.Fatal("wrong change", change.ID)
+	}
+
+	// The buffer has room again, but the third change must not follow the
+	// first one.
+	sub.ProcessConsensusChange(cc(3))
+	select {
+	case change := <-sub.changes:
+		t.Fatal("change was sent after the overflow", change.ID)
+	default:
+	}
+}
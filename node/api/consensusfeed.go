@@ -0,0 +1,452 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// consensusFeedBuffer is the number of consensus changes which are
+	// buffered for a client of the consensus feed. Clients which fall further
+	// behind are disconnected and have to resume the feed.
+	consensusFeedBuffer = 100
+
+	// consensusFeedKeepAlive is the interval at which a comment is sent to
+	// idle clients of the consensus feed to keep the connection open.
+	consensusFeedKeepAlive = 30 * time.Second
+)
+
+// The object types which can be selected with the types filter of the
+// consensus feed.
+const (
+	consensusFeedTypeSiacoinOutputs        = "siacoinoutputs"
+	consensusFeedTypeFileContracts         = "filecontracts"
+	consensusFeedTypeSiafundOutputs        = "siafundoutputs"
+	consensusFeedTypeDelayedSiacoinOutputs = "delayedsiacoinoutputs"
+	consensusFeedTypeSiafundPool           = "siafundpool"
+)
+
+type (
+	// ConsensusFeedChange is a consensus change sent by the /consensus/feed
+	// endpoint. The ID is the hex encoded modules.ConsensusChangeID of the
+	// change.
+	ConsensusFeedChange struct {
+		ID             crypto.Hash          `json:"id"`
+		Height         types.BlockHeight    `json:"height"`
+		Synced         bool                 `json:"synced"`
+		RevertedBlocks []ConsensusFeedBlock `json:"revertedblocks"`
+		AppliedBlocks  []ConsensusFeedBlock `json:"appliedblocks"`
+	}
+
+	// ConsensusFeedBlock is a block which was reverted or applied by a
+	// consensus change, together with the diffs it caused that match the
+	// filters of the feed.
+	ConsensusFeedBlock struct {
+		ID                        types.BlockID                           `json:"id"`
+		Height                    types.BlockHeight                       `json:"height"`
+		ParentID                  types.BlockID                           `json:"parentid"`
+		Timestamp                 types.Timestamp                         `json:"timestamp"`
+		SiacoinOutputDiffs        []ConsensusFeedSiacoinOutputDiff        `json:"siacoinoutputdiffs"`
+		FileContractDiffs         []ConsensusFeedFileContractDiff         `json:"filecontractdiffs"`
+		SiafundOutputDiffs        []ConsensusFeedSiafundOutputDiff        `json:"siafundoutputdiffs"`
+		DelayedSiacoinOutputDiffs []ConsensusFeedDelayedSiacoinOutputDiff `json:"delayedsiacoinoutputdiffs"`
+		SiafundPoolDiffs          []ConsensusFeedSiafundPoolDiff          `json:"siafundpooldiffs"`
+	}
+
+	// ConsensusFeedSiacoinOutputDiff is the json representation of a
+	// modules.SiacoinOutputDiff.
+	ConsensusFeedSiacoinOutputDiff struct {
+		Direction     string                `json:"direction"`
+		ID            types.SiacoinOutputID `json:"id"`
+		SiacoinOutput types.SiacoinOutput   `json:"siacoinoutput"`
+	}
+
+	// ConsensusFeedFileContractDiff is the json representation of a
+	// modules.FileContractDiff.
+	ConsensusFeedFileContractDiff struct {
+		Direction    string               `json:"direction"`
+		ID           types.FileContractID `json:"id"`
+		FileContract types.FileContract   `json:"filecontract"`
+	}
+
+	// ConsensusFeedSiafundOutputDiff is the json representation of a
+	// modules.SiafundOutputDiff.
+	ConsensusFeedSiafundOutputDiff struct {
+		Direction     string                `json:"direction"`
+		ID            types.SiafundOutputID `json:"id"`
+		SiafundOutput types.SiafundOutput   `json:"siafundoutput"`
+	}
+
+	// ConsensusFeedDelayedSiacoinOutputDiff is the json representation of a
+	// modules.DelayedSiacoinOutputDiff.
+	ConsensusFeedDelayedSiacoinOutputDiff struct {
+		Direction      string                `json:"direction"`
+		ID             types.SiacoinOutputID `json:"id"`
+		SiacoinOutput  types.SiacoinOutput   `json:"siacoinoutput"`
+		MaturityHeight types.BlockHeight     `json:"maturityheight"`
+	}
+
+	// ConsensusFeedSiafundPoolDiff is the json representation of a
+	// modules.SiafundPoolDiff.
+	ConsensusFeedSiafundPoolDiff struct {
+		Direction string         `json:"direction"`
+		Previous  types.Currency `json:"previous"`
+		Adjusted  types.Currency `json:"adjusted"`
+	}
+
+	// consensusFeedFilter selects the diffs which are sent to a client of the
+	// consensus feed.
+	consensusFeedFilter struct {
+		addresses map[types.UnlockHash]struct{}
+		contracts map[types.FileContractID]struct{}
+		types     map[string]struct{}
+	}
+
+	// consensusFeedSubscriber is the consensus set subscriber of a client of
+	// the consensus feed. It passes the filtered changes to the handler
+	// through a channel since the ResponseWriter can only be used by the
+	// handler's goroutine.
+	consensusFeedSubscriber struct {
+		filter  consensusFeedFilter
+		changes chan ConsensusFeedChange
+		cancel  <-chan struct{}
+
+		// live is set once the subscriber has caught up with the consensus
+		// set. Changes are then delivered while the consensus set is locked,
+		// so the subscriber must not block and drops clients which fall
+		// behind instead.
+		live         bool
+		overflow     chan struct{}
+		overflowOnce sync.Once
+		mu           sync.Mutex
+	}
+)
+
+// diffDirection returns the json representation of a diff direction.
+func diffDirection(dir modules.DiffDirection) string {
+	if dir == modules.DiffApply {
+		return "apply"
+	}
+	return "revert"
+}
+
+// parseConsensusFeedFilter parses the filter query parameters of the consensus
+// feed.
+func parseConsensusFeedFilter(req *http.Request) (consensusFeedFilter, error) {
+	f := consensusFeedFilter{
+		addresses: make(map[types.UnlockHash]struct{}),
+		contracts: make(map[types.FileContractID]struct{}),
+	}
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, ",")
+	}
+	for _, s := range split(req.FormValue("addresses")) {
+		var uh types.UnlockHash
+		if err := uh.LoadString(s); err != nil {
+			return consensusFeedFilter{}, errors.AddContext(err, "unable to parse address")
+		}
+		f.addresses[uh] = struct{}{}
+	}
+	for _, s := range split(req.FormValue("contracts")) {
+		var id types.FileContractID
+		if err := (*crypto.Hash)(&id).LoadString(s); err != nil {
+			return consensusFeedFilter{}, errors.AddContext(err, "unable to parse contract id")
+		}
+		f.contracts[id] = struct{}{}
+	}
+	if ts := split(req.FormValue("types")); len(ts) > 0 {
+		f.types = make(map[string]struct{})
+		for _, t := range ts {
+			switch t {
+			case consensusFeedTypeSiacoinOutputs, consensusFeedTypeFileContracts, consensusFeedTypeSiafundOutputs,
+				consensusFeedTypeDelayedSiacoinOutputs, consensusFeedTypeSiafundPool:
+				f.types[t] = struct{}{}
+			default:
+				return consensusFeedFilter{}, fmt.Errorf("unknown object type '%v'", t)
+			}
+		}
+	}
+	return f, nil
+}
+
+// wantsType returns whether the filter selects diffs of the provided type.
+func (f consensusFeedFilter) wantsType(t string) bool {
+	if f.types == nil {
+		return true
+	}
+	_, ok := f.types[t]
+	return ok
+}
+
+// filtersObjects returns whether the filter selects diffs by address or
+// contract id.
+func (f consensusFeedFilter) filtersObjects() bool {
+	return len(f.addresses) > 0 || len(f.contracts) > 0
+}
+
+// matchesAddress returns whether the filter selects diffs of the address.
+func (f consensusFeedFilter) matchesAddress(uh types.UnlockHash) bool {
+	if !f.filtersObjects() {
+		return true
+	}
+	_, ok := f.addresses[uh]
+	return ok
+}
+
+// matchesContract returns whether the filter selects the diffs of a file
+// contract. Contracts are selected by their id or by the addresses of their
+// proof outputs.
+func (f consensusFeedFilter) matchesContract(id types.FileContractID, fc types.FileContract) bool {
+	if !f.filtersObjects() {
+		return true
+	}
+	if _, ok := f.contracts[id]; ok {
+		return true
+	}
+	for _, sco := range fc.ValidProofOutputs {
+		if _, ok := f.addresses[sco.UnlockHash]; ok {
+			return true
+		}
+	}
+	for _, sco := range fc.MissedProofOutputs {
+		if _, ok := f.addresses[sco.UnlockHash]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// block returns the json representation of a block and the filtered diffs it
+// caused.
+func (f consensusFeedFilter) block(b types.Block, height types.BlockHeight, diffs modules.ConsensusChangeDiffs) ConsensusFeedBlock {
+	fb := ConsensusFeedBlock{
+		ID:                        b.ID(),
+		Height:                    height,
+		ParentID:                  b.ParentID,
+		Timestamp:                 b.Timestamp,
+		SiacoinOutputDiffs:        []ConsensusFeedSiacoinOutputDiff{},
+		FileContractDiffs:         []ConsensusFeedFileContractDiff{},
+		SiafundOutputDiffs:        []ConsensusFeedSiafundOutputDiff{},
+		DelayedSiacoinOutputDiffs: []ConsensusFeedDelayedSiacoinOutputDiff{},
+		SiafundPoolDiffs:          []ConsensusFeedSiafundPoolDiff{},
+	}
+	if f.wantsType(consensusFeedTypeSiacoinOutputs) {
+		for _, d := range diffs.SiacoinOutputDiffs {
+			if f.matchesAddress(d.SiacoinOutput.UnlockHash) {
+				fb.SiacoinOutputDiffs = append(fb.SiacoinOutputDiffs, ConsensusFeedSiacoinOutputDiff{diffDirection(d.Direction), d.ID, d.SiacoinOutput})
+			}
+		}
+	}
+	if f.wantsType(consensusFeedTypeFileContracts) {
+		for _, d := range diffs.FileContractDiffs {
+			if f.matchesContract(d.ID, d.FileContract) {
+				fb.FileContractDiffs = append(fb.FileContractDiffs, ConsensusFeedFileContractDiff{diffDirection(d.Direction), d.ID, d.FileContract})
+			}
+		}
+	}
+	if f.wantsType(consensusFeedTypeSiafundOutputs) {
+		for _, d := range diffs.SiafundOutputDiffs {
+			if f.matchesAddress(d.SiafundOutput.UnlockHash) {
+				fb.SiafundOutputDiffs = append(fb.SiafundOutputDiffs, ConsensusFeedSiafundOutputDiff{diffDirection(d.Direction), d.ID, d.SiafundOutput})
+			}
+		}
+	}
+	if f.wantsType(consensusFeedTypeDelayedSiacoinOutputs) {
+		for _, d := range diffs.DelayedSiacoinOutputDiffs {
+			if f.matchesAddress(d.SiacoinOutput.UnlockHash) {
+				fb.DelayedSiacoinOutputDiffs = append(fb.DelayedSiacoinOutputDiffs, ConsensusFeedDelayedSiacoinOutputDiff{diffDirection(d.Direction), d.ID, d.SiacoinOutput, d.MaturityHeight})
+			}
+		}
+	}
+	if f.wantsType(consensusFeedTypeSiafundPool) && !f.filtersObjects() {
+		for _, d := range diffs.SiafundPoolDiffs {
+			fb.SiafundPoolDiffs = append(fb.SiafundPoolDiffs, ConsensusFeedSiafundPoolDiff{diffDirection(d.Direction), d.Previous, d.Adjusted})
+		}
+	}
+	return fb
+}
+
+// change returns the json representation of a consensus change.
+func (f consensusFeedFilter) change(cc modules.ConsensusChange) ConsensusFeedChange {
+	fc := ConsensusFeedChange{
+		ID:             crypto.Hash(cc.ID),
+		Height:         cc.BlockHeight,
+		Synced:         cc.Synced,
+		RevertedBlocks: make([]ConsensusFeedBlock, len(cc.RevertedBlocks)),
+		AppliedBlocks:  make([]ConsensusFeedBlock, len(cc.AppliedBlocks)),
+	}
+	// The applied blocks end at the height of the change and the reverted
+	// blocks end right below the first applied block.
+	firstApplied := cc.BlockHeight + 1 - types.BlockHeight(len(cc.AppliedBlocks))
+	for i, b := range cc.RevertedBlocks {
+		fc.RevertedBlocks[i] = f.block(b, firstApplied+types.BlockHeight(len(cc.RevertedBlocks)-1-i), cc.RevertedDiffs[i])
+	}
+	for i, b := range cc.AppliedBlocks {
+		fc.AppliedBlocks[i] = f.block(b, firstApplied+types.BlockHeight(i), cc.AppliedDiffs[i])
+	}
+	return fc
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (s *consensusFeedSubscriber) ProcessConsensusChange(cc modules.ConsensusChange) {
+	change := s.filter.change(cc)
+	s.mu.Lock()
+	live := s.live
+	s.mu.Unlock()
+	if !live {
+		select {
+		case s.changes <- change:
+		case <-s.cancel:
+		}
+		return
+	}
+	select {
+	case <-s.overflow:
+		// A change was dropped already, later changes would leave a gap in
+		// the feed.
+		return
+	default:
+	}
+	select {
+	case s.changes <- change:
+	case <-s.cancel:
+	default:
+		s.overflowOnce.Do(func() { close(s.overflow) })
+	}
+}
+
+// consensusFeedHandler handles the API calls to /consensus/feed. The handler
+// streams the consensus changes after the provided change as server-sent
+// events until the client closes the connection. The id of each event is the
+// id of its change, which allows clients to resume the feed.
+func consensusFeedHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// A reconnecting EventSource provides the id of the last event it received
+	// in the Last-Event-ID header.
+	since := req.FormValue("since")
+	if id := req.Header.Get("Last-Event-ID"); id != "" {
+		since = id
+	}
+	var ccid modules.ConsensusChangeID
+	switch since {
+	case "", "beginning":
+		ccid = modules.ConsensusChangeBeginning
+	case "recent":
+		ccid = modules.ConsensusChangeRecent
+	default:
+		if err := (*crypto.Hash)(&ccid).LoadString(since); err != nil {
+			WriteError(w, Error{"could not decode ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	filter, err := parseConsensusFeedFilter(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, Error{"streaming is not supported by the connection"}, http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe in a separate goroutine since the subscriber blocks until the
+	// handler consumes the changes of the initial sync.
+	ctx, cancel := context.WithCancel(req.Context())
+	sub := &consensusFeedSubscriber{
+		filter:   filter,
+		changes:  make(chan ConsensusFeedChange, consensusFeedBuffer),
+		cancel:   ctx.Done(),
+		overflow: make(chan struct{}),
+	}
+	subscribed := make(chan error, 1)
+	go func() {
+		err := cs.ConsensusSetSubscribe(sub, ccid, ctx.Done())
+		if err == nil {
+			sub.mu.Lock()
+			sub.live = true
+			sub.mu.Unlock()
+		}
+		subscribed <- err
+	}()
+	defer func() {
+		cancel()
+		if subscribed != nil {
+			<-subscribed
+		}
+		cs.Unsubscribe(sub)
+	}()
+
+	// The response is started lazily to be able to report subscription
+	// errors.
+	started := false
+	start := func() {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+	keepAlive := time.NewTicker(consensusFeedKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.overflow:
+			return
+		case err := <-subscribed:
+			subscribed = nil
+			if err != nil && started {
+				return
+			} else if errors.Contains(err, modules.ErrInvalidConsensusChangeID) || errors.Contains(err, modules.ErrPrunedConsensusChange) {
+				WriteError(w, Error{"failed to subscribe: " + err.Error()}, http.StatusBadRequest)
+				return
+			} else if err != nil {
+				WriteError(w, Error{"failed to subscribe: " + err.Error()}, http.StatusInternalServerError)
+				return
+			}
+			start()
+			flusher.Flush()
+		case change := <-sub.changes:
+			// The select picks a ready case at random, so check for an
+			// overflow before writing the change.
+			select {
+			case <-sub.overflow:
+				return
+			default:
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				return
+			}
+			start()
+			if _, err := fmt.Fprintf(w, "id: %v\nevent: change\ndata: %s\n\n", change.ID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if started {
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -138,6 +138,18 @@ type (
 		Modules          configModules `json:"modules"`
 	}
 
+	// DaemonTokensGet contains the API tokens of the daemon.
+	DaemonTokensGet struct {
+		Tokens []APIToken `json:"tokens"`
+	}
+
+	// DaemonTokensCreatePOST contains a newly created API token and its
+	// secret.
+	DaemonTokensCreatePOST struct {
+		APIToken
+		Token string `json:"token"`
+	}
+
 	// DaemonVersion holds the version information for siad
 	DaemonVersion struct {
 		Version     string `json:"version"`
@@ -541,3 +553,47 @@ func (api *API) daemonSettingsHandlerPOST(w http.ResponseWriter, req *http.Reque
 	}
 	WriteSuccess(w)
 }
+
+// daemonTokensHandlerGET handles the API call that lists the API tokens of the
+// daemon.
+func (api *API) daemonTokensHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonTokensGet{
+		Tokens: api.tokens.list(),
+	})
+}
+
+// daemonTokensCreateHandlerPOST handles the API call that creates a new API
+// token.
+func (api *API) daemonTokensCreateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	name := req.FormValue("name")
+	var scopes []string
+	for _, scope := range strings.Split(req.FormValue("scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	secret, err := api.tokens.create(name, scopes)
+	if err != nil {
+		WriteError(w, Error{"unable to create API token: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	token, _ := api.tokens.authenticate(secret)
+	WriteJSON(w, DaemonTokensCreatePOST{
+		APIToken: token,
+		Token:    secret,
+	})
+}
+
+// daemonTokensRevokeHandlerPOST handles the API call that revokes an API
+// token.
+func (api *API) daemonTokensRevokeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.tokens.revoke(req.FormValue("name"))
+	if errors.Contains(err, errTokenNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"unable to revoke API token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -12,6 +15,16 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// explorerDefaultLimit is the number of entries returned by the paginated
+	// explorer endpoints if no limit is provided.
+	explorerDefaultLimit = 100
+
+	// explorerMaxLimit is the maximum number of entries returned by a single
+	// call to the paginated explorer endpoints.
+	explorerMaxLimit = 1000
+)
+
 type (
 	// ExplorerBlock is a block with some extra information such as the id and
 	// height. This information is provided for programs that may not be
@@ -74,6 +87,51 @@ type (
 		Transaction  ExplorerTransaction   `json:"transaction"`
 		Transactions []ExplorerTransaction `json:"transactions"`
 	}
+
+	// ExplorerAddressTransaction is a transaction of an address. Miner payouts
+	// are listed with the id of the block which created them and without a
+	// transaction.
+	ExplorerAddressTransaction struct {
+		ID          types.TransactionID `json:"id"`
+		Height      types.BlockHeight   `json:"height"`
+		MinerPayout bool                `json:"minerpayout"`
+		Transaction ExplorerTransaction `json:"transaction"`
+	}
+
+	// ExplorerAddressGET is the object returned by a GET request to
+	// /explorer/addresses/:addr.
+	ExplorerAddressGET struct {
+		modules.ExplorerAddress
+		Transactions []ExplorerAddressTransaction `json:"transactions"`
+	}
+
+	// ExplorerAddressUTXOsGET is the object returned by a GET request to
+	// /explorer/addresses/:addr/utxos.
+	ExplorerAddressUTXOsGET struct {
+		Outputs []modules.ExplorerOutput `json:"outputs"`
+	}
+
+	// ExplorerStatsGET is the object returned by a GET request to
+	// /explorer/stats. Every point contains the timestamp of its step, the
+	// height of its last block and the requested fields of the block facts of
+	// that block.
+	ExplorerStatsGET struct {
+		Step   uint64                       `json:"step"`
+		Points []map[string]json.RawMessage `json:"points"`
+	}
+
+	// ExplorerContractGET is the object returned by a GET request to
+	// /explorer/contracts/:id. Revisions are paginated and ordered from newest
+	// to oldest.
+	ExplorerContractGET struct {
+		ID                    types.FileContractID         `json:"id"`
+		Contract              types.FileContract           `json:"contract"`
+		Revisions             []types.FileContractRevision `json:"revisions"`
+		RevisionCount         uint64                       `json:"revisioncount"`
+		Status                string                       `json:"status"`
+		StorageProofConfirmed bool                         `json:"storageproofconfirmed"`
+		TransactionIDs        []types.TransactionID        `json:"transactionids"`
+	}
 )
 
 // RegisterRoutesExplorer is a helper function to register all explorer routes.
@@ -81,12 +139,24 @@ func RegisterRoutesExplorer(router *httprouter.Router, e modules.Explorer, cs mo
 	router.GET("/explorer", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHandler(e, w, req, ps)
 	})
+	router.GET("/explorer/addresses/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerAddressHandler(e, w, req, ps)
+	})
+	router.GET("/explorer/addresses/:addr/utxos", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerAddressUTXOsHandler(e, w, req, ps)
+	})
 	router.GET("/explorer/blocks/:height", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerBlocksHandler(e, cs, w, req, ps)
 	})
+	router.GET("/explorer/contracts/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerContractHandler(e, cs, w, req, ps)
+	})
 	router.GET("/explorer/hashes/:hash", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHashHandler(e, w, req, ps)
 	})
+	router.GET("/explorer/stats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerStatsHandler(e, w, req, ps)
+	})
 }
 
 // buildExplorerTransaction takes a transaction and the height + id of the
@@ -371,3 +441,242 @@ func explorerHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.R
 		BlockFacts: facts,
 	})
 }
+
+// scanExplorerHistoryFilter parses the optional startheight, endheight,
+// offset and limit parameters of a request to a paginated explorer endpoint.
+func scanExplorerHistoryFilter(req *http.Request) (modules.ExplorerHistoryFilter, error) {
+	filter := modules.ExplorerHistoryFilter{
+		EndHeight: math.MaxUint64,
+		Limit:     explorerDefaultLimit,
+	}
+	params := []struct {
+		name string
+		val  interface{}
+	}{
+		{"startheight", &filter.StartHeight},
+		{"endheight", &filter.EndHeight},
+		{"offset", &filter.Offset},
+		{"limit", &filter.Limit},
+	}
+	for _, p := range params {
+		if str := req.FormValue(p.name); str != "" {
+			if _, err := fmt.Sscan(str, p.val); err != nil {
+				return modules.ExplorerHistoryFilter{}, fmt.Errorf("parsing integer value for parameter `%v` failed: %v", p.name, err)
+			}
+		}
+	}
+	if filter.Limit == 0 || filter.Limit > explorerMaxLimit {
+		return modules.ExplorerHistoryFilter{}, fmt.Errorf("limit must be between 1 and %v", explorerMaxLimit)
+	}
+	return filter, nil
+}
+
+// explorerAddressHandler handles GET requests to /explorer/addresses/:addr.
+func explorerAddressHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	filter, err := scanExplorerHistoryFilter(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	ea, exists := explorer.Address(addr)
+	if !exists {
+		WriteError(w, Error{"address does not appear in the blockchain"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := explorer.AddressTransactions(addr, filter)
+	if err != nil {
+		WriteError(w, Error{"failed to get address transactions: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	eag := ExplorerAddressGET{
+		ExplorerAddress: ea,
+		Transactions:    make([]ExplorerAddressTransaction, 0, len(txns)),
+	}
+	for _, txn := range txns {
+		eat := ExplorerAddressTransaction{
+			ID:     txn.ID,
+			Height: txn.Height,
+		}
+		block, height, exists := explorer.Transaction(txn.ID)
+		if !exists && build.DEBUG {
+			panic("explorer pointing to nonexistent txn")
+		}
+		if types.TransactionID(block.ID()) == txn.ID {
+			eat.MinerPayout = true
+		} else {
+			for _, t := range block.Transactions {
+				if t.ID() == txn.ID {
+					eat.Transaction = buildExplorerTransaction(explorer, height, block.ID(), t)
+					break
+				}
+			}
+		}
+		eag.Transactions = append(eag.Transactions, eat)
+	}
+	WriteJSON(w, eag)
+}
+
+// explorerAddressUTXOsHandler handles GET requests to
+// /explorer/addresses/:addr/utxos.
+func explorerAddressUTXOsHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	filter, err := scanExplorerHistoryFilter(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var spent bool
+	if spentStr := req.FormValue("spent"); spentStr != "" {
+		spent, err = scanBool(spentStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse spent: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	outputs, err := explorer.AddressOutputs(addr, spent, filter)
+	if err != nil {
+		WriteError(w, Error{"failed to get address outputs: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if outputs == nil {
+		outputs = []modules.ExplorerOutput{}
+	}
+	WriteJSON(w, ExplorerAddressUTXOsGET{
+		Outputs: outputs,
+	})
+}
+
+// explorerContractHandler handles GET requests to /explorer/contracts/:id.
+func explorerContractHandler(explorer modules.Explorer, cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	filter, err := scanExplorerHistoryFilter(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fcid := types.FileContractID(hash)
+	fc, fcrs, exists, proofConfirmed := explorer.FileContractHistory(fcid)
+	if !exists {
+		WriteError(w, Error{"file contract does not appear in the blockchain"}, http.StatusBadRequest)
+		return
+	}
+
+	// The status of the contract depends on the proof window of its latest
+	// revision.
+	windowEnd := fc.WindowEnd
+	if len(fcrs) > 0 {
+		windowEnd = fcrs[len(fcrs)-1].NewWindowEnd
+	}
+	status := "active"
+	if proofConfirmed {
+		status = "succeeded"
+	} else if cs.Height() >= windowEnd {
+		status = "failed"
+	}
+
+	// Return a page of the revisions, newest first.
+	revisions := []types.FileContractRevision{}
+	for i := len(fcrs) - 1 - int(filter.Offset); i >= 0 && uint64(len(revisions)) < filter.Limit; i-- {
+		revisions = append(revisions, fcrs[i])
+	}
+	WriteJSON(w, ExplorerContractGET{
+		ID:                    fcid,
+		Contract:              fc,
+		Revisions:             revisions,
+		RevisionCount:         uint64(len(fcrs)),
+		Status:                status,
+		StorageProofConfirmed: proofConfirmed,
+		TransactionIDs:        explorer.FileContractID(fcid),
+	})
+}
+
+// explorerStatsHandler handles GET requests to /explorer/stats.
+func explorerStatsHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional range and step. By default the daily series of the
+	// whole blockchain is returned.
+	var start types.Timestamp
+	end := types.Timestamp(math.MaxUint64)
+	step := uint64(24 * 60 * 60)
+	params := []struct {
+		name string
+		val  interface{}
+	}{
+		{"start", &start},
+		{"end", &end},
+		{"step", &step},
+	}
+	for _, p := range params {
+		if str := req.FormValue(p.name); str != "" {
+			if _, err := fmt.Sscan(str, p.val); err != nil {
+				WriteError(w, Error{fmt.Sprintf("parsing integer value for parameter `%v` failed: %v", p.name, err)}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	// Parse the requested fields. By default all fields are returned.
+	var all map[string]json.RawMessage
+	js, _ := json.Marshal(modules.ExplorerStatsPoint{})
+	if err := json.Unmarshal(js, &all); err != nil {
+		WriteError(w, Error{"failed to list block facts fields: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var fields []string
+	if fieldsStr := req.FormValue("fields"); fieldsStr != "" {
+		for _, f := range strings.Split(fieldsStr, ",") {
+			f = strings.TrimSpace(f)
+			if _, exists := all[f]; !exists {
+				WriteError(w, Error{"unknown block facts field: " + f}, http.StatusBadRequest)
+				return
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	points, err := explorer.Stats(start, end, step)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	esg := ExplorerStatsGET{
+		Step:   step,
+		Points: make([]map[string]json.RawMessage, 0, len(points)),
+	}
+	for _, p := range points {
+		var values map[string]json.RawMessage
+		js, err := json.Marshal(p)
+		if err == nil {
+			err = json.Unmarshal(js, &values)
+		}
+		if err != nil {
+			WriteError(w, Error{"failed to encode statistics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if len(fields) > 0 {
+			filtered := map[string]json.RawMessage{
+				"timestamp": values["timestamp"],
+				"height":    values["height"],
+			}
+			for _, f := range fields {
+				filtered[f] = values[f]
+			}
+			values = filtered
+		}
+		esg.Points = append(esg.Points, values)
+	}
+	WriteJSON(w, esg)
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -67,11 +70,32 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostPolicyGET contains the information that is returned after a GET
+	// request to /host/policy - the host's renter policy and the resources
+	// used by the renters.
+	HostPolicyGET struct {
+		Policy modules.HostPolicy        `json:"policy"`
+		Usage  []modules.HostRenterUsage `json:"usage"`
+	}
+
+	// HostPricingGET contains the information that is returned after a GET
+	// request to /host/pricing - the settings of the host's pricing engine.
+	HostPricingGET struct {
+		Settings modules.HostPricingSettings `json:"settings"`
+	}
+
+	// HostRevenueGET contains the information that is returned after a GET
+	// request to /host/revenue - the entries of the host's revenue ledger.
+	HostRevenueGET struct {
+		Entries []modules.HostRevenueEntry `json:"entries"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
 		Folders []modules.StorageFolderMetadata `json:"folders"`
+		Cache   modules.SectorCacheMetrics      `json:"cache"`
 	}
 )
 
@@ -96,6 +120,21 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/policy", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPolicyHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/policy", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPolicyHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/pricing", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/pricing", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/revenue", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRevenueHandlerGET(h, w, req, ps)
+	})
 
 	// Calls pertaining to the storage manager that the host uses.
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -104,12 +143,18 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/storage/folders/add", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersAddHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/migrate", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersMigrateHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/folders/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersRemoveHandler(h, w, req, ps)
 	}, requiredPassword))
 	router.POST("/host/storage/folders/resize", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersResizeHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/tier", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersTierHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageSectorsDeleteHandler(h, w, req, ps)
 	}, requiredPassword))
@@ -159,6 +204,176 @@ func hostContractInfoHandler(host modules.Host, w http.ResponseWriter, _ *http.R
 	WriteJSON(w, cg)
 }
 
+// hostPolicyHandlerGET handles GET requests to the /host/policy API endpoint,
+// returning the host's renter policy and the resources used by the renters.
+func hostPolicyHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPolicyGET{
+		Policy: host.Policy(),
+		Usage:  host.RenterUsage(),
+	})
+}
+
+// hostPolicyHandlerPOST handles POST requests to the /host/policy API
+// endpoint, which replaces the host's renter policy with the policy in the
+// request body.
+func hostPolicyHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var policy modules.HostPolicy
+	err := json.NewDecoder(req.Body).Decode(&policy)
+	if err != nil {
+		WriteError(w, Error{"unable to decode policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = host.SetPolicy(policy)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostPricingHandlerGET handles GET requests to the /host/pricing API
+// endpoint, returning the settings of the host's pricing engine.
+func hostPricingHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPricingGET{
+		Settings: host.PricingSettings(),
+	})
+}
+
+// hostPricingHandlerPOST handles POST requests to the /host/pricing API
+// endpoint, which sets the settings of the host's pricing engine.
+func hostPricingHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := parseHostPricingSettings(host, req)
+	if err != nil {
+		WriteError(w, Error{"error parsing pricing settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = host.SetPricingSettings(settings)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// parseHostPricingSettings updates the current settings of the host's pricing
+// engine with the parameters of the request.
+func parseHostPricingSettings(host modules.Host, req *http.Request) (modules.HostPricingSettings, error) {
+	settings := host.PricingSettings()
+
+	if req.FormValue("enabled") != "" {
+		_, err := fmt.Sscan(req.FormValue("enabled"), &settings.Enabled)
+		if err != nil {
+			return modules.HostPricingSettings{}, err
+		}
+	}
+	if req.FormValue("interval") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("interval"), &x)
+		if err != nil {
+			return modules.HostPricingSettings{}, err
+		}
+		settings.Interval = time.Duration(x) * time.Second
+	}
+	if req.FormValue("exchangerate") != "" {
+		settings.ExchangeRate = req.FormValue("exchangerate")
+	}
+
+	floats := map[string]*float64{
+		"maxchange":         &settings.MaxChange,
+		"fiatstorageprice":  &settings.FiatStoragePrice,
+		"fiatuploadprice":   &settings.FiatUploadPrice,
+		"fiatdownloadprice": &settings.FiatDownloadPrice,
+	}
+	for param, x := range floats {
+		if req.FormValue(param) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(param), x)
+		if err != nil {
+			return modules.HostPricingSettings{}, fmt.Errorf("unable to parse %v: %v", param, err)
+		}
+	}
+	currencies := map[string]*types.Currency{
+		"storagepricefloor":             &settings.StoragePriceFloor,
+		"storagepriceceiling":           &settings.StoragePriceCeiling,
+		"uploadbandwidthpricefloor":     &settings.UploadBandwidthPriceFloor,
+		"uploadbandwidthpriceceiling":   &settings.UploadBandwidthPriceCeiling,
+		"downloadbandwidthpricefloor":   &settings.DownloadBandwidthPriceFloor,
+		"downloadbandwidthpriceceiling": &settings.DownloadBandwidthPriceCeiling,
+		"collateralfloor":               &settings.CollateralFloor,
+		"collateralceiling":             &settings.CollateralCeiling,
+	}
+	for param, x := range currencies {
+		if req.FormValue(param) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(param), x)
+		if err != nil {
+			return modules.HostPricingSettings{}, fmt.Errorf("unable to parse %v: %v", param, err)
+		}
+	}
+	return settings, nil
+}
+
+// hostRevenueHandlerGET handles GET requests to the /host/revenue API
+// endpoint, returning the entries of the host's revenue ledger within the
+// requested range.
+func hostRevenueHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional bounds. By default the entire ledger is returned.
+	filter := modules.HostRevenueFilter{
+		End:       math.MaxInt64,
+		EndHeight: math.MaxUint64,
+	}
+	var err error
+	if startStr := req.FormValue("start"); startStr != "" {
+		filter.Start, err = strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `start` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if endStr := req.FormValue("end"); endStr != "" {
+		filter.End, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `end` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if startHeightStr := req.FormValue("startheight"); startHeightStr != "" {
+		_, err = fmt.Sscan(startHeightStr, &filter.StartHeight)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if endHeightStr := req.FormValue("endheight"); endHeightStr != "" {
+		_, err = fmt.Sscan(endHeightStr, &filter.EndHeight)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.Start > filter.End {
+		WriteError(w, Error{"parameter `start` cannot be greater than parameter `end`"}, http.StatusBadRequest)
+		return
+	}
+	if filter.StartHeight > filter.EndHeight {
+		WriteError(w, Error{"parameter `startheight` cannot be greater than parameter `endheight`"}, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := host.RevenueLedger(filter)
+	if err != nil {
+		WriteError(w, Error{"unable to get the revenue ledger: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostRevenueGET{
+		Entries: entries,
+	})
+}
+
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
 // information about the host.
 func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Dependencies, _ *http.Request, _ httprouter.Params) {
@@ -398,6 +613,9 @@ func hostEstimateScoreGET(host modules.Host, renter modules.Renter, w http.Respo
 	}
 	var totalStorage, remainingStorage uint64
 	for _, sf := range host.StorageFolders() {
+		if sf.FastTier {
+			continue
+		}
 		totalStorage += sf.Capacity
 		remainingStorage += sf.CapacityRemaining
 	}
@@ -479,6 +697,7 @@ func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Req
 func storageHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	WriteJSON(w, StorageGET{
 		Folders: host.StorageFolders(),
+		Cache:   host.SectorCacheMetrics(),
 	})
 }
 
@@ -528,6 +747,64 @@ func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *
 	WriteSuccess(w)
 }
 
+// storageFoldersMigrateHandler handles the call to migrate a storage folder to
+// a new path.
+func storageFoldersMigrateHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	newPath := req.FormValue("newpath")
+	if newPath == "" {
+		WriteError(w, Error{"newpath parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = host.MigrateStorageFolder(uint16(folderIndex), newPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// storageFoldersTierHandler handles the call to move a storage folder to the
+// fast tier or the standard tier.
+func storageFoldersTierHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	fastTier, err := strconv.ParseBool(req.FormValue("fasttier"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse fasttier: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = host.SetStorageFolderFastTier(uint16(folderIndex), fastTier)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
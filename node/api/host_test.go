@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -179,6 +180,103 @@ func TestHostSettingsHandlerParsing(t *testing.T) {
 	}
 }
 
+// TestHostPricingHandler checks that the settings of the host's pricing
+// engine can be changed through the /host/pricing endpoint.
+func TestHostPricingHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Enabling the engine without a ceiling fails.
+	values := url.Values{}
+	values.Set("enabled", "true")
+	if err := st.stdPostAPI("/host/pricing", values); err == nil {
+		t.Fatal("expected enabling the pricing engine without a ceiling to fail")
+	}
+
+	values.Set("interval", "60")
+	values.Set("exchangerate", "0.004 usd")
+	values.Set("storagepriceceiling", "1000")
+	if err := st.stdPostAPI("/host/pricing", values); err != nil {
+		t.Fatal(err)
+	}
+	var hpg HostPricingGET
+	if err := st.getAPI("/host/pricing", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	ps := hpg.Settings
+	if !ps.Enabled || ps.Interval != time.Minute || ps.ExchangeRate != "0.004 usd" || !ps.StoragePriceCeiling.Equals64(1000) {
+		t.Fatal("pricing settings weren't updated", ps)
+	}
+	if !reflect.DeepEqual(ps, st.host.PricingSettings()) {
+		t.Fatal("mismatch between API and host", ps, st.host.PricingSettings())
+	}
+}
+
+// TestHostPolicyHandler tests the /host/policy GET and POST endpoints.
+func TestHostPolicyHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	postPolicy := func(p modules.HostPolicy) error {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		resp, err := HttpPOST("http://"+st.server.listener.Addr().String()+"/host/policy", string(data))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if non2xx(resp.StatusCode) {
+			return decodeError(resp)
+		}
+		return nil
+	}
+
+	// A bandwidth quota without a period is rejected.
+	if err := postPolicy(modules.HostPolicy{MaxBandwidth: 1}); err == nil {
+		t.Fatal("expected a bandwidth quota without a period to fail")
+	}
+
+	renter := types.Ed25519PublicKey(crypto.PublicKey{1})
+	policy := modules.HostPolicy{
+		Denylist:        []types.SiaPublicKey{renter},
+		MaxStorage:      modules.SectorSize,
+		MaxBandwidth:    modules.SectorSize,
+		BandwidthPeriod: 144,
+		MaxCollateral:   types.SiacoinPrecision,
+	}
+	if err := postPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	var hpg HostPolicyGET
+	if err := st.getAPI("/host/policy", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hpg.Policy, policy) {
+		t.Fatal("policy wasn't updated", hpg.Policy)
+	}
+	if len(hpg.Usage) != 0 {
+		t.Fatal("expected no renter usage", hpg.Usage)
+	}
+}
+
 // TestWorkingStatus tests that the host's WorkingStatus field is set
 // correctly.
 func TestWorkingStatus(t *testing.T) {
@@ -721,6 +819,118 @@ func TestResizeNonexistentFolder(t *testing.T) {
 	}
 }
 
+// TestMigrateStorageFolder tests the /host/storage/folders/migrate endpoint.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	var sg StorageGET
+	if err := st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	capacity := sg.Folders[0].Capacity
+
+	// The new path is required.
+	migrateValues := url.Values{}
+	migrateValues.Set("path", st.dir)
+	if err := st.stdPostAPI("/host/storage/folders/migrate", migrateValues); err == nil {
+		t.Fatal("expected migration without a new path to fail")
+	}
+
+	newDir := filepath.Join(st.dir, "migrated")
+	if err := os.MkdirAll(newDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	migrateValues.Set("newpath", newDir)
+	if err := st.stdPostAPI("/host/storage/folders/migrate", migrateValues); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Folders) != 1 || sg.Folders[0].Path != newDir || sg.Folders[0].Capacity != capacity {
+		t.Fatalf("storage folder wasn't migrated: %+v", sg.Folders)
+	}
+}
+
+// TestStorageFolderTier moves a storage folder to the fast tier and back
+// through the API.
+func TestStorageFolderTier(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	fastDir := filepath.Join(st.dir, "fast")
+	if err := os.MkdirAll(fastDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	addValues := url.Values{}
+	addValues.Set("path", fastDir)
+	addValues.Set("size", "1048576")
+	if err := st.stdPostAPI("/host/storage/folders/add", addValues); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tier is required.
+	tierValues := url.Values{}
+	tierValues.Set("path", fastDir)
+	if err := st.stdPostAPI("/host/storage/folders/tier", tierValues); err == nil {
+		t.Fatal("expected tier change without a tier to fail")
+	}
+
+	// Move the folder to the fast tier.
+	checkTier := func(fastTier bool) {
+		t.Helper()
+		var sg StorageGET
+		if err := st.getAPI("/host/storage", &sg); err != nil {
+			t.Fatal(err)
+		}
+		var cacheCapacity uint64
+		for _, sf := range sg.Folders {
+			if sf.Path == fastDir && sf.FastTier != fastTier {
+				t.Fatalf("expected fast tier to be %v: %+v", fastTier, sf)
+			}
+			if sf.FastTier {
+				cacheCapacity += sf.Capacity
+			}
+		}
+		if sg.Cache.Capacity != cacheCapacity {
+			t.Fatalf("expected cache capacity %v, got %v", cacheCapacity, sg.Cache.Capacity)
+		}
+	}
+	tierValues.Set("fasttier", "true")
+	if err := st.stdPostAPI("/host/storage/folders/tier", tierValues); err != nil {
+		t.Fatal(err)
+	}
+	checkTier(true)
+
+	// Move it back to the standard tier.
+	tierValues.Set("fasttier", "false")
+	if err := st.stdPostAPI("/host/storage/folders/tier", tierValues); err != nil {
+		t.Fatal(err)
+	}
+	checkTier(false)
+}
+
 // TestStorageFolderUnavailable simulates the situation where a storage folder
 // is not available to the host when the host starts, verifying that it sets
 // FailedWrites and FailedReads correctly and eventually finds the storage
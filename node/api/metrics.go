@@ -0,0 +1,318 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// metricsContentType is the content type of the OpenMetrics text format.
+	metricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	// metricsPrefix is the prefix of the names of all metrics exported by
+	// siad.
+	metricsPrefix = "siad_"
+)
+
+var (
+	// metricsLabelEscaper escapes the values of labels as required by the
+	// OpenMetrics text format.
+	metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// metricsWriter writes metrics in the OpenMetrics text format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+// family writes the metadata of a metric family. It needs to be called before
+// writing the samples of the family.
+func (mw *metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(&mw.buf, "# TYPE %s%s %s\n", metricsPrefix, name, typ)
+	fmt.Fprintf(&mw.buf, "# HELP %s%s %s\n", metricsPrefix, name, help)
+}
+
+// sample writes a single sample of a metric family. The labels are provided as
+// pairs of names and values.
+func (mw *metricsWriter) sample(name string, value float64, labels ...string) {
+	mw.buf.WriteString(metricsPrefix + name)
+	if len(labels) > 0 {
+		mw.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				mw.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&mw.buf, `%s="%s"`, labels[i], metricsLabelEscaper.Replace(labels[i+1]))
+		}
+		mw.buf.WriteByte('}')
+	}
+	mw.buf.WriteByte(' ')
+	mw.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	mw.buf.WriteByte('\n')
+}
+
+// gauge writes a gauge without labels.
+func (mw *metricsWriter) gauge(name, help string, value float64) {
+	mw.family(name, "gauge", help)
+	mw.sample(name, value)
+}
+
+// counter writes a counter without labels.
+func (mw *metricsWriter) counter(name, help string, value float64) {
+	mw.family(name, "counter", help)
+	mw.sample(name+"_total", value)
+}
+
+// bytes returns the written metrics terminated by the EOF marker.
+func (mw *metricsWriter) bytes() []byte {
+	mw.buf.WriteString("# EOF\n")
+	return mw.buf.Bytes()
+}
+
+// boolToFloat converts a bool to a sample value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// currencyToFloat converts a currency in hastings to a sample value.
+func currencyToFloat(c types.Currency) float64 {
+	f, _ := c.Float64()
+	return f
+}
+
+// metricsHandlerGET handles the API call to /metrics. It renders the
+// counters and gauges of all loaded modules in the OpenMetrics text format.
+func (api *API) metricsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var mw metricsWriter
+	mw.gauge("uptime_seconds", "Time since the API was started.", time.Since(api.StartTime()).Seconds())
+	if api.cs != nil {
+		writeConsensusMetrics(&mw, api.cs)
+	}
+	if api.explorer != nil {
+		writeExplorerMetrics(&mw, api.explorer)
+	}
+	if api.gateway != nil {
+		if err := writeGatewayMetrics(&mw, api.gateway); err != nil {
+			WriteError(w, Error{"failed to get gateway metrics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if api.host != nil {
+		if err := writeHostMetrics(&mw, api.host); err != nil {
+			WriteError(w, Error{"failed to get host metrics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if api.miner != nil {
+		writeMinerMetrics(&mw, api.miner)
+	}
+	if api.renter != nil {
+		if err := writeRenterMetrics(&mw, api.renter); err != nil {
+			WriteError(w, Error{"failed to get renter metrics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if api.tpool != nil {
+		writeTransactionPoolMetrics(&mw, api.tpool)
+	}
+	if api.wallet != nil {
+		if err := writeWalletMetrics(&mw, api.wallet); err != nil {
+			WriteError(w, Error{"failed to get wallet metrics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	_, _ = w.Write(mw.bytes())
+}
+
+// writeConsensusMetrics writes the metrics of the consensus set.
+func writeConsensusMetrics(mw *metricsWriter, cs modules.ConsensusSet) {
+	mw.gauge("consensus_height", "Height of the current block.", float64(cs.Height()))
+	mw.gauge("consensus_synced", "Whether the consensus set is synced with the network.", boolToFloat(cs.Synced()))
+}
+
+// writeExplorerMetrics writes the metrics of the explorer.
+func writeExplorerMetrics(mw *metricsWriter, e modules.Explorer) {
+	facts := e.LatestBlockFacts()
+	mw.gauge("explorer_difficulty", "Difficulty of the current block.", currencyToFloat(facts.Difficulty))
+	mw.gauge("explorer_estimated_hashrate", "Estimated hashrate of the network in hashes per second.", currencyToFloat(facts.EstimatedHashrate))
+	mw.gauge("explorer_total_coins_hastings", "Total number of hastings in circulation.", currencyToFloat(facts.TotalCoins))
+	mw.gauge("explorer_active_contracts", "Number of active file contracts.", float64(facts.ActiveContractCount))
+	mw.gauge("explorer_active_contract_size_bytes", "Total size of the active file contracts.", currencyToFloat(facts.ActiveContractSize))
+	mw.gauge("explorer_active_contract_cost_hastings", "Total cost of the active file contracts.", currencyToFloat(facts.ActiveContractCost))
+}
+
+// writeGatewayMetrics writes the metrics of the gateway.
+func writeGatewayMetrics(mw *metricsWriter, g modules.Gateway) error {
+	upload, download, _, err := g.BandwidthCounters()
+	if err != nil {
+		return err
+	}
+	mw.gauge("gateway_peers", "Number of peers the gateway is connected to.", float64(len(g.Peers())))
+	mw.counter("gateway_upload_bytes", "Bytes uploaded by the gateway since startup.", float64(upload))
+	mw.counter("gateway_download_bytes", "Bytes downloaded by the gateway since startup.", float64(download))
+	return nil
+}
+
+// writeHostMetrics writes the metrics of the host.
+func writeHostMetrics(mw *metricsWriter, h modules.Host) error {
+	upload, download, _, err := h.BandwidthCounters()
+	if err != nil {
+		return err
+	}
+	mw.counter("host_upload_bytes", "Bytes uploaded by the host since startup.", float64(upload))
+	mw.counter("host_download_bytes", "Bytes downloaded by the host since startup.", float64(download))
+
+	// Storage folders.
+	folders := h.StorageFolders()
+	mw.family("host_storage_capacity_bytes", "gauge", "Capacity of a storage folder.")
+	for _, sf := range folders {
+		mw.sample("host_storage_capacity_bytes", float64(sf.Capacity), "folder", sf.Path)
+	}
+	mw.family("host_storage_remaining_bytes", "gauge", "Remaining capacity of a storage folder.")
+	for _, sf := range folders {
+		mw.sample("host_storage_remaining_bytes", float64(sf.CapacityRemaining), "folder", sf.Path)
+	}
+
+	// Storage obligations by status.
+	statuses := h.StorageObligationCounts()
+	mw.family("host_obligations", "gauge", "Number of storage obligations by status.")
+	for _, status := range []string{"obligationFailed", "obligationRejected", "obligationSucceeded", "obligationUnresolved"} {
+		mw.sample("host_obligations", float64(statuses[status]), "status", strings.TrimPrefix(status, "obligation"))
+	}
+
+	// Financial metrics.
+	fm := h.FinancialMetrics()
+	mw.gauge("host_contracts", "Number of active contracts.", float64(fm.ContractCount))
+	mw.gauge("host_locked_collateral_hastings", "Collateral locked in active contracts.", currencyToFloat(fm.LockedStorageCollateral))
+	mw.gauge("host_risked_collateral_hastings", "Collateral at risk in active contracts.", currencyToFloat(fm.RiskedStorageCollateral))
+	mw.gauge("host_lost_collateral_hastings", "Collateral lost by failed contracts.", currencyToFloat(fm.LostStorageCollateral))
+	mw.gauge("host_lost_revenue_hastings", "Revenue lost by failed contracts.", currencyToFloat(fm.LostRevenue))
+	mw.family("host_revenue_hastings", "gauge", "Revenue realized by successful contracts by source.")
+	mw.sample("host_revenue_hastings", currencyToFloat(fm.AccountFunding), "source", "accountfunding")
+	mw.sample("host_revenue_hastings", currencyToFloat(fm.ContractCompensation), "source", "contract")
+	mw.sample("host_revenue_hastings", currencyToFloat(fm.DownloadBandwidthRevenue), "source", "download")
+	mw.sample("host_revenue_hastings", currencyToFloat(fm.StorageRevenue), "source", "storage")
+	mw.sample("host_revenue_hastings", currencyToFloat(fm.UploadBandwidthRevenue), "source", "upload")
+	mw.family("host_potential_revenue_hastings", "gauge", "Revenue of active contracts by source.")
+	mw.sample("host_potential_revenue_hastings", currencyToFloat(fm.PotentialAccountFunding), "source", "accountfunding")
+	mw.sample("host_potential_revenue_hastings", currencyToFloat(fm.PotentialContractCompensation), "source", "contract")
+	mw.sample("host_potential_revenue_hastings", currencyToFloat(fm.PotentialDownloadBandwidthRevenue), "source", "download")
+	mw.sample("host_potential_revenue_hastings", currencyToFloat(fm.PotentialStorageRevenue), "source", "storage")
+	mw.sample("host_potential_revenue_hastings", currencyToFloat(fm.PotentialUploadBandwidthRevenue), "source", "upload")
+	return nil
+}
+
+// writeMinerMetrics writes the metrics of the miner.
+func writeMinerMetrics(mw *metricsWriter, m modules.Miner) {
+	good, stale := m.BlocksMined()
+	mw.family("miner_blocks_mined", "counter", "Number of blocks mined by state.")
+	mw.sample("miner_blocks_mined_total", float64(good), "state", "good")
+	mw.sample("miner_blocks_mined_total", float64(stale), "state", "stale")
+	mw.gauge("miner_cpu_hashrate", "Hashrate of the CPU miner in hashes per second.", float64(m.CPUHashrate()))
+	mw.gauge("miner_cpu_mining", "Whether the CPU miner is running.", boolToFloat(m.CPUMining()))
+}
+
+// writeRenterMetrics writes the metrics of the renter.
+func writeRenterMetrics(mw *metricsWriter, r modules.Renter) error {
+	// Memory managers.
+	ms, err := r.MemoryStatus()
+	if err != nil {
+		return err
+	}
+	managers := []struct {
+		name   string
+		status modules.MemoryManagerStatus
+	}{
+		{"registry", ms.Registry},
+		{"system", ms.System},
+		{"userdownload", ms.UserDownload},
+		{"userupload", ms.UserUpload},
+	}
+	mw.family("renter_memory_available_bytes", "gauge", "Memory available in a memory manager.")
+	for _, m := range managers {
+		mw.sample("renter_memory_available_bytes", float64(m.status.Available), "manager", m.name)
+	}
+	mw.family("renter_memory_base_bytes", "gauge", "Memory base of a memory manager.")
+	for _, m := range managers {
+		mw.sample("renter_memory_base_bytes", float64(m.status.Base), "manager", m.name)
+	}
+	mw.family("renter_memory_requested_bytes", "gauge", "Memory requested from a memory manager.")
+	for _, m := range managers {
+		mw.sample("renter_memory_requested_bytes", float64(m.status.Requested), "manager", m.name)
+	}
+
+	// Workers.
+	wps, err := r.WorkerPoolStatus()
+	if err != nil {
+		return err
+	}
+	mw.gauge("renter_workers", "Number of workers in the worker pool.", float64(wps.NumWorkers))
+	mw.family("renter_workers_on_cooldown", "gauge", "Number of workers on cooldown by type.")
+	mw.sample("renter_workers_on_cooldown", float64(wps.TotalDownloadCoolDown), "type", "download")
+	mw.sample("renter_workers_on_cooldown", float64(wps.TotalMaintenanceCoolDown), "type", "maintenance")
+	mw.sample("renter_workers_on_cooldown", float64(wps.TotalUploadCoolDown), "type", "upload")
+	mw.family("renter_worker_queue_size", "gauge", "Number of jobs in a job queue of a worker.")
+	for _, ws := range wps.Workers {
+		host := ws.HostPubKey.String()
+		mw.sample("renter_worker_queue_size", float64(ws.DownloadQueueSize), "host", host, "queue", "download")
+		mw.sample("renter_worker_queue_size", float64(ws.DownloadSnapshotJobQueueSize), "host", host, "queue", "downloadsnapshot")
+		mw.sample("renter_worker_queue_size", float64(ws.HasSectorJobsStatus.JobQueueSize), "host", host, "queue", "hassector")
+		mw.sample("renter_worker_queue_size", float64(ws.ReadJobsStatus.JobQueueSize), "host", host, "queue", "read")
+		mw.sample("renter_worker_queue_size", float64(ws.ReadRegistryJobsStatus.JobQueueSize), "host", host, "queue", "readregistry")
+		mw.sample("renter_worker_queue_size", float64(ws.UpdateRegistryJobsStatus.JobQueueSize), "host", host, "queue", "updateregistry")
+		mw.sample("renter_worker_queue_size", float64(ws.UploadQueueSize), "host", host, "queue", "upload")
+		mw.sample("renter_worker_queue_size", float64(ws.UploadSnapshotJobQueueSize), "host", host, "queue", "uploadsnapshot")
+	}
+	mw.family("renter_worker_cooldown_seconds", "gauge", "Remaining cooldown of a worker by type.")
+	for _, ws := range wps.Workers {
+		host := ws.HostPubKey.String()
+		mw.sample("renter_worker_cooldown_seconds", ws.DownloadCoolDownTime.Seconds(), "host", host, "type", "download")
+		mw.sample("renter_worker_cooldown_seconds", ws.MaintenanceCoolDownTime.Seconds(), "host", host, "type", "maintenance")
+		mw.sample("renter_worker_cooldown_seconds", ws.UploadCoolDownTime.Seconds(), "host", host, "type", "upload")
+	}
+	return nil
+}
+
+// writeTransactionPoolMetrics writes the metrics of the transaction pool.
+func writeTransactionPoolMetrics(mw *metricsWriter, tp modules.TransactionPool) {
+	txns := tp.TransactionList()
+	size := 0
+	for _, txn := range txns {
+		size += txn.MarshalSiaSize()
+	}
+	mw.gauge("tpool_transactions", "Number of transactions in the transaction pool.", float64(len(txns)))
+	mw.gauge("tpool_size_bytes", "Encoded size of the transactions in the transaction pool.", float64(size))
+}
+
+// writeWalletMetrics writes the metrics of the wallet. The balance is only
+// available while the wallet is unlocked.
+func writeWalletMetrics(mw *metricsWriter, w modules.Wallet) error {
+	unlocked, err := w.Unlocked()
+	if err != nil {
+		return err
+	}
+	mw.gauge("wallet_unlocked", "Whether the wallet is unlocked.", boolToFloat(unlocked))
+	if !unlocked {
+		return nil
+	}
+	siacoins, siafunds, _, err := w.ConfirmedBalance()
+	if err != nil {
+		return err
+	}
+	mw.gauge("wallet_confirmed_siacoin_balance_hastings", "Confirmed siacoin balance of the wallet.", currencyToFloat(siacoins))
+	mw.gauge("wallet_confirmed_siafund_balance", "Confirmed siafund balance of the wallet.", currencyToFloat(siafunds))
+	return nil
+}
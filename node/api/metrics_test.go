@@ -0,0 +1,27 @@
+package api
+
+import "testing"
+
+// TestMetricsWriter probes the OpenMetrics output of the metricsWriter.
+func TestMetricsWriter(t *testing.T) {
+	var mw metricsWriter
+	mw.gauge("height", "Block height.", 42)
+	mw.counter("upload_bytes", "Uploaded bytes.", 1.5e9)
+	mw.family("queue_size", "gauge", "Queue size.")
+	mw.sample("queue_size", 3, "host", `ed25519:"a\b`+"\n", "queue", "read")
+
+	expected := `# TYPE siad_height gauge
+# HELP siad_height Block height.
+siad_height 42
+# TYPE siad_upload_bytes counter
+# HELP siad_upload_bytes Uploaded bytes.
+siad_upload_bytes_total 1.5e+09
+# TYPE siad_queue_size gauge
+# HELP siad_queue_size Queue size.
+siad_queue_size{host="ed25519:\"a\\b\n",queue="read"} 3
+# EOF
+`
+	if out := string(mw.bytes()); out != expected {
+		t.Fatalf("unexpected output:\n%v\nexpected:\n%v", out, expected)
+	}
+}
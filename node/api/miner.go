@@ -14,10 +14,11 @@ type (
 	// MinerGET contains the information that is returned after a GET request
 	// to /miner.
 	MinerGET struct {
-		BlocksMined      int  `json:"blocksmined"`
-		CPUHashrate      int  `json:"cpuhashrate"`
-		CPUMining        bool `json:"cpumining"`
-		StaleBlocksMined int  `json:"staleblocksmined"`
+		BlocksMined      int                     `json:"blocksmined"`
+		CPUHashrate      int                     `json:"cpuhashrate"`
+		CPUMining        bool                    `json:"cpumining"`
+		StaleBlocksMined int                     `json:"staleblocksmined"`
+		StratumWorkers   []modules.StratumWorker `json:"stratumworkers"`
 	}
 )
 
@@ -51,6 +52,7 @@ func minerHandler(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _
 		CPUHashrate:      miner.CPUHashrate(),
 		CPUMining:        miner.CPUMining(),
 		StaleBlocksMined: staleMined,
+		StratumWorkers:   miner.StratumWorkers(),
 	}
 	WriteJSON(w, mg)
 }
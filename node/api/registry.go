@@ -0,0 +1,244 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// registrySubscriptionNotificationBuffer is the number of notifications
+	// that can be queued for a subscription stream before the subscriber
+	// starts blocking.
+	registrySubscriptionNotificationBuffer = 100
+)
+
+type (
+	// RegistryHandlerGET is the response returned by the registryHandlerGET
+	// handler.
+	RegistryHandlerGET struct {
+		Data      string                    `json:"data"`
+		Revision  uint64                    `json:"revision"`
+		Signature string                    `json:"signature"`
+		Type      modules.RegistryEntryType `json:"type"`
+	}
+
+	// RegistryHandlerRequestPOST is the expected format of the json request
+	// for /renter/registry [POST].
+	RegistryHandlerRequestPOST struct {
+		PublicKey types.SiaPublicKey        `json:"publickey"`
+		DataKey   crypto.Hash               `json:"datakey"`
+		Revision  uint64                    `json:"revision"`
+		Signature crypto.Signature          `json:"signature"`
+		Data      []byte                    `json:"data"`
+		Type      modules.RegistryEntryType `json:"type"`
+	}
+
+	// RegistrySubscriptionRequest identifies a single registry entry to
+	// subscribe to.
+	RegistrySubscriptionRequest struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		DataKey   crypto.Hash        `json:"datakey"`
+	}
+
+	// RegistrySubscribePOST is the expected format of the json request for
+	// /renter/registry/subscribe [POST].
+	RegistrySubscribePOST struct {
+		Entries []RegistrySubscriptionRequest `json:"entries"`
+	}
+
+	// RegistrySubscriptionNotification is a single update of a subscribed
+	// registry entry. The /renter/registry/subscribe endpoint streams one
+	// notification per line.
+	RegistrySubscriptionNotification struct {
+		EntryID   modules.RegistryEntryID   `json:"entryid"`
+		PublicKey types.SiaPublicKey        `json:"publickey"`
+		DataKey   crypto.Hash               `json:"datakey"`
+		Data      string                    `json:"data"`
+		Revision  uint64                    `json:"revision"`
+		Signature string                    `json:"signature"`
+		Type      modules.RegistryEntryType `json:"type"`
+	}
+)
+
+// newRegistrySubscriptionNotification creates the notification for an update
+// of a registry entry.
+func newRegistrySubscriptionNotification(spk types.SiaPublicKey, srv modules.SignedRegistryValue) RegistrySubscriptionNotification {
+	return RegistrySubscriptionNotification{
+		EntryID:   modules.DeriveRegistryEntryID(spk, srv.Tweak),
+		PublicKey: spk,
+		DataKey:   srv.Tweak,
+		Data:      hex.EncodeToString(srv.Data),
+		Revision:  srv.Revision,
+		Signature: hex.EncodeToString(srv.Signature[:]),
+		Type:      srv.Type,
+	}
+}
+
+// registryHandlerGET handles the GET requests to /renter/registry.
+func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse public key
+	var spk types.SiaPublicKey
+	err := spk.LoadString(req.FormValue("publickey"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'publickey' param: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse datakey.
+	var dataKey crypto.Hash
+	err = dataKey.LoadString(req.FormValue("datakey"))
+	if err != nil {
+		WriteError(w, Error{"unable to decode 'datakey' param: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the optional timeout.
+	timeout := renter.MaxRegistryReadTimeout
+	if timeoutStr := req.FormValue("timeout"); timeoutStr != "" {
+		timeoutInt, err := strconv.Atoi(timeoutStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'timeout' param: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if timeoutInt < 1 || time.Duration(timeoutInt)*time.Second > renter.MaxRegistryReadTimeout {
+			WriteError(w, Error{"'timeout' param out of bounds"}, http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(timeoutInt) * time.Second
+	}
+
+	// Read registry.
+	srv, err := api.renter.ReadRegistry(spk, dataKey, timeout)
+	if errors.Contains(err, renter.ErrRegistryEntryNotFound) ||
+		errors.Contains(err, renter.ErrRegistryLookupTimeout) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to read registry: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Send response.
+	WriteJSON(w, RegistryHandlerGET{
+		Data:      hex.EncodeToString(srv.Data),
+		Revision:  srv.Revision,
+		Signature: hex.EncodeToString(srv.Signature[:]),
+		Type:      srv.Type,
+	})
+}
+
+// registryHandlerPOST handles the POST requests to /renter/registry.
+func (api *API) registryHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var rhp RegistryHandlerRequestPOST
+	err := json.NewDecoder(req.Body).Decode(&rhp)
+	if err != nil {
+		WriteError(w, Error{"failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Check the signature before updating the registry.
+	srv := modules.NewSignedRegistryValue(rhp.DataKey, rhp.Data, rhp.Revision, rhp.Signature, rhp.Type)
+	err = srv.Verify(rhp.PublicKey.ToPublicKey())
+	if err != nil {
+		WriteError(w, Error{"failed to verify signature: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Update the registry.
+	err = api.renter.UpdateRegistry(rhp.PublicKey, srv, renter.DefaultRegistryUpdateTimeout)
+	if err != nil {
+		WriteError(w, Error{"failed to update registry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// registrySubscribeHandlerPOST handles the POST requests to
+// /renter/registry/subscribe. The handler streams a json encoded
+// RegistrySubscriptionNotification per line for every update of the
+// subscribed entries until the client closes the connection.
+func (api *API) registrySubscribeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var rsp RegistrySubscribePOST
+	err := json.NewDecoder(req.Body).Decode(&rsp)
+	if err != nil {
+		WriteError(w, Error{"failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if len(rsp.Entries) == 0 {
+		WriteError(w, Error{"no entries to subscribe to"}, http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, Error{"streaming is not supported by the connection"}, http.StatusInternalServerError)
+		return
+	}
+
+	// The notifications are passed on to this thread through a channel since
+	// the ResponseWriter can only be used by the handler's goroutine.
+	notifications := make(chan RegistrySubscriptionNotification, registrySubscriptionNotificationBuffer)
+	subscriber, err := api.renter.NewRegistrySubscriber(func(spk types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+		select {
+		case notifications <- newRegistrySubscriptionNotification(spk, srv):
+			return nil
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to create subscriber: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = subscriber.Close()
+	}()
+
+	// Subscribe to the entries and collect their initial values.
+	var initialValues []RegistrySubscriptionNotification
+	for _, entry := range rsp.Entries {
+		srv, err := subscriber.Subscribe(entry.PublicKey, entry.DataKey)
+		if err != nil {
+			WriteError(w, Error{"failed to subscribe to entry: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if srv != nil {
+			initialValues = append(initialValues, newRegistrySubscriptionNotification(entry.PublicKey, *srv))
+		}
+	}
+
+	// Start streaming.
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for _, n := range initialValues {
+		if err := enc.Encode(n); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case n := <-notifications:
+			if err := enc.Encode(n); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
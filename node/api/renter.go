@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -85,6 +86,10 @@ type (
 		CurrentPeriod    types.BlockHeight          `json:"currentperiod"`
 		NextPeriod       types.BlockHeight          `json:"nextperiod"`
 
+		// Identity is the public key which identifies the renter to hosts. It
+		// is empty while the wallet is locked.
+		Identity types.SiaPublicKey `json:"identity"`
+
 		MemoryStatus modules.MemoryStatus `json:"memorystatus"`
 	}
 
@@ -162,6 +167,10 @@ type (
 	RenterDirectory struct {
 		Directories []modules.DirectoryInfo `json:"directories"`
 		Files       []modules.FileInfo      `json:"files"`
+
+		// EffectivePolicy is the policy of the directory including the
+		// fields inherited from its parent directories.
+		EffectivePolicy modules.DirPolicy `json:"effectivepolicy"`
 	}
 
 	// RenterDownloadQueue contains the renter's download queue.
@@ -172,6 +181,10 @@ type (
 	// RenterFile lists the file queried.
 	RenterFile struct {
 		File modules.FileInfo `json:"file"`
+
+		// RedundancyChange is set if the redundancy of the file is currently
+		// being changed.
+		RedundancyChange *modules.RedundancyChange `json:"redundancychange,omitempty"`
 	}
 
 	// RenterFiles lists the files known to the renter.
@@ -222,6 +235,22 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterUploadBatchPOST contains the information needed to upload many
+	// small files packed into shared sectors.
+	RenterUploadBatchPOST struct {
+		Files        []modules.BatchUploadFile `json:"files"`
+		DataPieces   uint64                    `json:"datapieces"`
+		ParityPieces uint64                    `json:"paritypieces"`
+		Force        bool                      `json:"force"`
+	}
+
+	// RenterUploadBatch contains the siapath of the pack which holds the data
+	// of an uploaded batch and the placement of the files within the pack.
+	RenterUploadBatch struct {
+		Pack       modules.SiaPath         `json:"pack"`
+		Placements []modules.FilePlacement `json:"placements"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -671,11 +700,14 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httpr
 		WriteError(w, Error{"unable to get renter memory information: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// The identity can't be derived while the wallet is locked.
+	identity, _ := api.renter.Identity()
 	WriteJSON(w, RenterGET{
 		Settings:         settings,
 		FinancialMetrics: spending,
 		CurrentPeriod:    currentPeriod,
 		NextPeriod:       nextPeriod,
+		Identity:         identity,
 
 		MemoryStatus: memoryStatus,
 	})
@@ -955,6 +987,16 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.IPViolationCheck = ipviolationcheck
 	}
 
+	// Scan the dedup flag.
+	if d := req.FormValue("dedup"); d != "" {
+		var dedup bool
+		if _, err := fmt.Sscan(d, &dedup); err != nil {
+			WriteError(w, Error{"unable to parse dedup: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Dedup = dedup
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
@@ -1499,6 +1541,13 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 		return
 	}
 
+	// Fetch the progress of an ongoing redundancy change.
+	rc, err := api.renter.RedundancyChange(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// If the user requested the user siapath, trim the dir folder so that the
 	// output is all centered around the user's folder.
 	if !root {
@@ -1511,7 +1560,8 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	}
 
 	WriteJSON(w, RenterFile{
-		File: file,
+		File:             file,
+		RedundancyChange: rc,
 	})
 }
 
@@ -1555,6 +1605,18 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle changing the redundancy of a file.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ec != nil {
+		if err := api.renter.ChangeRedundancy(siaPath, ec); err != nil {
+			WriteError(w, Error{"failed to change redundancy of file: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1964,8 +2026,8 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
 
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+		// NOTE: the cipher type is taken from the directory's policy. Could
+		// be made an optional param.
 	})
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
@@ -1974,6 +2036,68 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// renterUploadBatchHandler handles the API call to upload many small files
+// packed into shared sectors.
+func (api *API) renterUploadBatchHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse parameters
+	var params RenterUploadBatchPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if len(params.Files) == 0 {
+		WriteError(w, Error{"no files provided"}, http.StatusBadRequest)
+		return
+	}
+	for i, f := range params.Files {
+		// Source must be absolute path.
+		if !filepath.IsAbs(f.Source) {
+			WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+			return
+		}
+		params.Files[i].SiaPath, err = rebaseInputSiaPath(f.SiaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(strconv.FormatUint(params.DataPieces, 10), strconv.FormatUint(params.ParityPieces, 10))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Call the renter to upload the files.
+	pack, placements, err := api.renter.UploadBatch(modules.BatchUploadParams{
+		Files:       params.Files,
+		ErasureCode: ec,
+		Force:       params.Force,
+	})
+	if err != nil {
+		WriteError(w, Error{"batch upload failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	// The placements should refer to the siapaths provided by the user.
+	for i := range placements {
+		var siaPath modules.SiaPath
+		err := siaPath.LoadString(placements[i].FileID)
+		if err == nil {
+			siaPath, err = siaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		}
+		if err != nil {
+			WriteError(w, Error{"unable to rebase siapath: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		placements[i].FileID = siaPath.String()
+	}
+	WriteJSON(w, RenterUploadBatch{
+		Pack:       pack,
+		Placements: placements,
+	})
+}
+
 // renterUploadReadyHandler handles the API call to check whether or not the
 // renter is ready to upload files
 func (api *API) renterUploadReadyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -2094,8 +2218,8 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		Force:       force,
 		Repair:      repair,
 
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+		// NOTE: the cipher type is taken from the directory's policy. Could
+		// be made an optional param.
 	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
 	if err != nil {
@@ -2152,6 +2276,11 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 		WriteError(w, Error{"failed to get directory contents: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	policy, err := api.renter.DirPolicy(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to get directory policy: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
 
 	if !root {
 		directories, err = trimSiaDirFolder(directories...)
@@ -2182,14 +2311,52 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 	}
 
 	WriteJSON(w, RenterDirectory{
-		Directories: directories,
-		Files:       files,
+		Directories:     directories,
+		Files:           files,
+		EffectivePolicy: policy,
 	})
 	return
 }
 
+// parseDirPolicy parses the policy of a directory from the request.
+func parseDirPolicy(req *http.Request) (modules.DirPolicy, error) {
+	var policy modules.DirPolicy
+	// Parse the erasure coding parameters. They are validated the same way as
+	// for uploads.
+	dataPiecesStr, parityPiecesStr := req.FormValue("datapieces"), req.FormValue("paritypieces")
+	if _, err := parseErasureCodingParameters(dataPiecesStr, parityPiecesStr); err != nil {
+		return modules.DirPolicy{}, errors.AddContext(err, "unable to parse erasure code settings")
+	}
+	dataPieces, parityPieces, err := ParseDataAndParityPieces(dataPiecesStr, parityPiecesStr)
+	if err != nil {
+		return modules.DirPolicy{}, errors.AddContext(err, "unable to parse erasure code settings")
+	}
+	policy.DataPieces, policy.ParityPieces = dataPieces, parityPieces
+
+	// Parse the cipher type.
+	if ctStr := req.FormValue("ciphertype"); ctStr != "" {
+		var ct crypto.CipherType
+		if err := ct.FromString(ctStr); err != nil {
+			return modules.DirPolicy{}, errors.AddContext(err, "unable to parse 'ciphertype'")
+		}
+		policy.CipherType = ctStr
+	}
+
+	// Parse the pinned hosts.
+	if hostsStr := req.FormValue("hosts"); hostsStr != "" {
+		for _, hostStr := range strings.Split(hostsStr, ",") {
+			var spk types.SiaPublicKey
+			if err := spk.LoadString(hostStr); err != nil {
+				return modules.DirPolicy{}, errors.AddContext(err, "unable to parse 'hosts'")
+			}
+			policy.Hosts = append(policy.Hosts, spk)
+		}
+	}
+	return policy, nil
+}
+
 // renterDirHandlerPOST handles POST requests to /renter/dir/:siapath?action=<>
-// in order to create, delete, and rename a directory
+// in order to create, delete, and rename a directory and to set its policy
 func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse action
 	action := req.FormValue("action")
@@ -2266,6 +2433,20 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "setpolicy" {
+		policy, err := parseDirPolicy(req)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirPolicy(siaPath, policy)
+		if err != nil {
+			WriteError(w, Error{"failed to set directory policy: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
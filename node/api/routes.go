@@ -45,10 +45,25 @@ func (api *API) buildHTTPRoutes() {
 	router.GET("/daemon/update", api.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
+	if api.tokens != nil {
+		router.GET("/daemon/tokens", RequirePassword(api.daemonTokensHandlerGET, requiredPassword))
+		router.POST("/daemon/tokens/create", RequirePassword(api.daemonTokensCreateHandlerPOST, requiredPassword))
+		router.POST("/daemon/tokens/revoke", RequirePassword(api.daemonTokensRevokeHandlerPOST, requiredPassword))
+	}
+
+	// Metrics API Calls
+	if api.metricsEnabled {
+		router.GET("/metrics", RequirePassword(api.metricsHandlerGET, requiredPassword))
+	}
+
+	// Accounting API Calls
+	if api.accounting != nil {
+		router.GET("/accounting", RequirePassword(api.accountingHandlerGET, requiredPassword))
+	}
 
 	// Consensus API Calls
 	if api.cs != nil {
-		RegisterRoutesConsensus(router, api.cs)
+		RegisterRoutesConsensus(router, api.cs, requiredPassword)
 	}
 
 	// Explorer API Calls
@@ -96,6 +111,9 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
+		router.GET("/renter/registry", RequirePassword(api.registryHandlerGET, requiredPassword))
+		router.POST("/renter/registry", RequirePassword(api.registryHandlerPOST, requiredPassword))
+		router.POST("/renter/registry/subscribe", RequirePassword(api.registrySubscribeHandlerPOST, requiredPassword))
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
@@ -109,6 +127,7 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/uploadbatch", RequirePassword(api.renterUploadBatchHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
@@ -152,8 +171,19 @@ func (api *API) buildHTTPRoutes() {
 	if err != nil {
 		build.Critical("marshalling error on object that should be safe to marshal:", err)
 	}
+	scopeRouter := requireScope(router, api.tokens, requiredPassword, api.authenticateReads)
+	uaRouter := RequireUserAgent(scopeRouter, requiredUserAgent)
+	timeoutRouter := http.TimeoutHandler(uaRouter, httpServerTimeout, string(jsonErr))
 	api.routerMu.Lock()
-	api.router = http.TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout, string(jsonErr))
+	api.router = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Long-lived streams can't be wrapped by the TimeoutHandler since it
+		// buffers the whole response.
+		if isStream(req) {
+			uaRouter.ServeHTTP(w, req)
+			return
+		}
+		timeoutRouter.ServeHTTP(w, req)
+	})
 	api.routerMu.Unlock()
 	return
 }
@@ -172,7 +202,8 @@ func RequireUserAgent(h http.Handler, ua string) http.Handler {
 
 // RequirePassword is middleware that requires a request to authenticate with a
 // password using HTTP basic auth. Usernames are ignored. Empty passwords
-// indicate no authentication is required.
+// indicate no authentication is required. Requests that were authenticated by
+// an API token with the required scope are accepted as well.
 func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	// An empty password is equivalent to no password.
 	if password == "" {
@@ -180,7 +211,7 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		_, pass, ok := req.BasicAuth()
-		if !ok || pass != password {
+		if (!ok || pass != password) && !isTokenAuthenticated(req) {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
 			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
 			return
@@ -189,7 +220,15 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 }
 
-// isUnrestricted checks if a request may bypass the useragent check.
+// isStream checks if a request is for an endpoint which streams its response
+// for an unlimited amount of time, or whose response is too large to be
+// buffered by the TimeoutHandler.
+func isStream(req *http.Request) bool {
+	return req.URL.Path == "/renter/registry/subscribe" || req.URL.Path == "/consensus/feed" || req.URL.Path == "/consensus/snapshot"
+}
+
+// isUnrestricted checks if a request may bypass the useragent check. Metrics
+// are unrestricted since scrapers can't set the useragent.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || req.URL.Path == "/metrics"
 }
@@ -71,6 +71,7 @@ func (srv *Server) Close() error {
 	if srv.node != nil {
 		err = errors.Compose(err, srv.node.Close())
 	}
+	err = errors.Compose(err, srv.api.Close())
 	return errors.AddContext(err, "error while closing server")
 }
 
@@ -176,6 +177,9 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Create the api for the server.
 		api := api.New(cfg, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		if nodeParams.EnableMetrics {
+			api.EnableMetrics()
+		}
 		srv := &Server{
 			api: api,
 			apiServer: &http.Server{
@@ -234,8 +238,12 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 		default:
 		}
 
-		// Server wasn't shut down. Add node and replace modules.
+		// Server wasn't shut down. Add node, load the API tokens and replace
+		// modules.
 		srv.node = n
+		if err := api.EnableTokens(nodeParams.Dir, nodeParams.AuthenticateAPIReads); err != nil {
+			return nil, errors.AddContext(err, "unable to load API tokens")
+		}
 		api.SetModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
 		return srv, nil
 	}()
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// tokensFile is the name of the file the API tokens are persisted to.
+	tokensFile = "apitokens.json"
+
+	// tokensLogFile is the name of the audit log which records every request
+	// that was authenticated with an API token.
+	tokensLogFile = "apiaudit.log"
+
+	// tokenSecretSize is the number of random bytes of a token secret.
+	tokenSecretSize = 32
+)
+
+var (
+	// tokensMetadata is the metadata of the persisted API tokens.
+	tokensMetadata = persist.Metadata{
+		Header:  "siad.apitokens",
+		Version: "1.0.0",
+	}
+
+	// APIScopes is the list of all scopes an API token can be granted.
+	APIScopes = []string{
+		"accounting:read",
+		"consensus:read",
+		"daemon:admin",
+		"daemon:read",
+		"explorer:read",
+		"gateway:read",
+		"gateway:write",
+		"host:admin",
+		"host:read",
+		"metrics:read",
+		"miner:read",
+		"miner:write",
+		"renter:read",
+		"renter:write",
+		"tpool:read",
+		"tpool:write",
+		"wallet:admin",
+		"wallet:read",
+		"wallet:spend",
+		"wallet:write",
+	}
+
+	// scopeOverrides maps the routes which don't follow the default
+	// <module>:read and <module>:write scheme to the scope they require. The
+	// keys are a method followed by a path which also matches all of its
+	// subpaths. An empty scope means that the route can only be accessed with
+	// the API password.
+	scopeOverrides = map[string]string{
+		"GET /daemon/tokens":                      "",
+		"POST /daemon/tokens":                     "",
+		"GET /daemon/stop":                        "daemon:admin",
+		"POST /consensus/validate/transactionset": "consensus:read",
+		"GET /miner/header":                       "miner:write",
+		"GET /miner/start":                        "miner:write",
+		"GET /miner/stop":                         "miner:write",
+		"GET /renter/download/":                   "renter:write",
+		"GET /renter/downloadasync/":              "renter:write",
+		"GET /wallet/address":                     "wallet:write",
+		"GET /wallet/backup":                      "wallet:admin",
+		"GET /wallet/seeds":                       "wallet:admin",
+		"POST /wallet/033x":                       "wallet:admin",
+		"POST /wallet/changepassword":             "wallet:admin",
+		"POST /wallet/init":                       "wallet:admin",
+		"POST /wallet/multisig/broadcast":         "wallet:spend",
+		"POST /wallet/multisig/sign":              "wallet:spend",
+		"POST /wallet/schedules":                  "wallet:spend",
+		"POST /wallet/seed":                       "wallet:admin",
+		"POST /wallet/siagkey":                    "wallet:admin",
+		"POST /wallet/siacoins":                   "wallet:spend",
+		"POST /wallet/siafunds":                   "wallet:spend",
+		"POST /wallet/sign":                       "wallet:spend",
+		"POST /wallet/sweep/seed":                 "wallet:spend",
+		"POST /wallet/timelock":                   "wallet:spend",
+		"POST /wallet/transaction":                "wallet:spend",
+	}
+
+	// errTokenNotFound is returned when a token that doesn't exist is
+	// revoked.
+	errTokenNotFound = errors.New("API token not found")
+)
+
+type (
+	// APIToken is a named API token and the scopes it was granted. The secret
+	// of the token is only returned once when the token is created.
+	APIToken struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		CreatedAt int64    `json:"createdat"`
+	}
+
+	// persistedToken is an API token as it is stored on disk. Only the hash
+	// of the secret is stored.
+	persistedToken struct {
+		APIToken
+		SecretHash crypto.Hash `json:"secrethash"`
+	}
+
+	// tokenStore manages the API tokens of the daemon and the audit log of
+	// their usage.
+	tokenStore struct {
+		tokens map[crypto.Hash]APIToken
+
+		staticLog  *persist.Logger
+		staticPath string
+		mu         sync.Mutex
+	}
+
+	// tokenContextKey is the key of the name of the API token that
+	// authenticated a request in the request's context.
+	tokenContextKey struct{}
+)
+
+// HasScope returns whether the token was granted the provided scope.
+func (t APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidScope returns whether the scope is a known API scope.
+func isValidScope(scope string) bool {
+	for _, s := range APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// routeScope returns the scope an API token requires to access the route. An
+// empty scope means that the route can only be accessed using the API
+// password.
+func routeScope(method, path string) string {
+	// Check the overrides first. The most specific match wins.
+	var match, scope string
+	for route, s := range scopeOverrides {
+		i := strings.Index(route, " ")
+		m, p := route[:i], strings.TrimSuffix(route[i+1:], "/")
+		if m != method || (path != p && !strings.HasPrefix(path, p+"/")) || len(route) <= len(match) {
+			continue
+		}
+		match, scope = route, s
+	}
+	if match != "" {
+		return scope
+	}
+
+	// Derive the scope from the module and the method.
+	module := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	switch module {
+	case "hostdb":
+		module = "renter"
+	case "accounting", "consensus", "daemon", "explorer", "gateway", "host", "metrics", "miner", "renter", "tpool", "wallet":
+	default:
+		return ""
+	}
+	if method == http.MethodGet {
+		scope = module + ":read"
+	} else if module == "daemon" || module == "host" {
+		scope = module + ":admin"
+	} else {
+		scope = module + ":write"
+	}
+	if !isValidScope(scope) {
+		return ""
+	}
+	return scope
+}
+
+// newTokenStore loads the API tokens from dir and opens the audit log.
+func newTokenStore(dir string) (*tokenStore, error) {
+	log, err := persist.NewFileLogger(filepath.Join(dir, tokensLogFile))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open API audit log")
+	}
+	ts := &tokenStore{
+		tokens:     make(map[crypto.Hash]APIToken),
+		staticLog:  log,
+		staticPath: filepath.Join(dir, tokensFile),
+	}
+	var persisted []persistedToken
+	err = persist.LoadJSON(tokensMetadata, &persisted, ts.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Compose(errors.AddContext(err, "failed to load API tokens"), log.Close())
+	}
+	for _, pt := range persisted {
+		ts.tokens[pt.SecretHash] = pt.APIToken
+	}
+	return ts, nil
+}
+
+// authenticate returns the token with the provided secret.
+func (ts *tokenStore) authenticate(secret string) (APIToken, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	token, exists := ts.tokens[crypto.HashBytes([]byte(secret))]
+	return token, exists
+}
+
+// close closes the audit log.
+func (ts *tokenStore) close() error {
+	return ts.staticLog.Close()
+}
+
+// create creates a new token with the provided name and scopes and returns its
+// secret.
+func (ts *tokenStore) create(name string, scopes []string) (string, error) {
+	if name == "" {
+		return "", errors.New("API token name can't be empty")
+	}
+	if len(scopes) == 0 {
+		return "", errors.New("API token requires at least one scope")
+	}
+	for _, scope := range scopes {
+		if !isValidScope(scope) {
+			return "", errors.New("unknown API scope: " + scope)
+		}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, token := range ts.tokens {
+		if token.Name == name {
+			return "", errors.New("API token with that name already exists")
+		}
+	}
+	secret := hex.EncodeToString(fastrand.Bytes(tokenSecretSize))
+	hash := crypto.HashBytes([]byte(secret))
+	ts.tokens[hash] = APIToken{
+		Name:      name,
+		Scopes:    append([]string(nil), scopes...),
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := ts.save(); err != nil {
+		delete(ts.tokens, hash)
+		return "", errors.AddContext(err, "failed to persist API tokens")
+	}
+	ts.staticLog.Printf("created token %q with scopes %v", name, scopes)
+	return secret, nil
+}
+
+// revoke deletes the token with the provided name.
+func (ts *tokenStore) revoke(name string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for hash, token := range ts.tokens {
+		if token.Name != name {
+			continue
+		}
+		delete(ts.tokens, hash)
+		if err := ts.save(); err != nil {
+			ts.tokens[hash] = token
+			return errors.AddContext(err, "failed to persist API tokens")
+		}
+		ts.staticLog.Printf("revoked token %q", name)
+		return nil
+	}
+	return errTokenNotFound
+}
+
+// list returns all tokens sorted by name.
+func (ts *tokenStore) list() []APIToken {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tokens := make([]APIToken, 0, len(ts.tokens))
+	for _, token := range ts.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Name < tokens[j].Name
+	})
+	return tokens
+}
+
+// save persists the tokens. The caller must hold the lock.
+func (ts *tokenStore) save() error {
+	persisted := make([]persistedToken, 0, len(ts.tokens))
+	for hash, token := range ts.tokens {
+		persisted = append(persisted, persistedToken{
+			APIToken:   token,
+			SecretHash: hash,
+		})
+	}
+	sort.Slice(persisted, func(i, j int) bool {
+		return persisted[i].Name < persisted[j].Name
+	})
+	return persist.SaveJSON(tokensMetadata, persisted, ts.staticPath)
+}
+
+// apiCredential returns the credential a request was made with. Both the HTTP
+// basic auth password and bearer tokens are supported.
+func apiCredential(req *http.Request) (string, bool) {
+	if _, pass, ok := req.BasicAuth(); ok {
+		return pass, true
+	}
+	auth := req.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer "), true
+	}
+	return "", false
+}
+
+// isTokenAuthenticated returns whether the request was authenticated by an API
+// token that was granted the scope of the requested route.
+func isTokenAuthenticated(req *http.Request) bool {
+	_, ok := req.Context().Value(tokenContextKey{}).(string)
+	return ok
+}
+
+// requireScope is middleware that authenticates requests made with an API
+// token. Requests using a token are only passed on if the token was granted the
+// scope of the requested route and are recorded in the audit log. Requests
+// without a token are passed on unchanged unless authenticateReads is set, in
+// which case they need to provide the API password. Empty passwords indicate
+// no authentication is required.
+func requireScope(h http.Handler, ts *tokenStore, password string, authenticateReads bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if password == "" {
+			h.ServeHTTP(w, req)
+			return
+		}
+		cred, ok := apiCredential(req)
+		if ok && cred == password {
+			h.ServeHTTP(w, req)
+			return
+		}
+		if ok && ts != nil {
+			if token, exists := ts.authenticate(cred); exists {
+				scope := routeScope(req.Method, req.URL.Path)
+				if scope == "" || !token.HasScope(scope) {
+					ts.staticLog.Printf("denied token %q: %v %v requires scope %q", token.Name, req.Method, req.URL.Path, scope)
+					WriteError(w, Error{"API token is missing the required scope " + scope}, http.StatusForbidden)
+					return
+				}
+				ts.staticLog.Printf("allowed token %q: %v %v", token.Name, req.Method, req.URL.Path)
+				h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), tokenContextKey{}, token.Name)))
+				return
+			}
+		}
+		if authenticateReads {
+			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
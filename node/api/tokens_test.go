@@ -0,0 +1,197 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestRouteScope probes the scopes that are required to access the API routes.
+func TestRouteScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{"GET", "/consensus", "consensus:read"},
+		{"POST", "/consensus/validate/transactionset", "consensus:read"},
+		{"GET", "/daemon/stop", "daemon:admin"},
+		{"GET", "/daemon/tokens", ""},
+		{"POST", "/daemon/tokens/create", ""},
+		{"GET", "/host", "host:read"},
+		{"POST", "/host/storage/folders/add", "host:admin"},
+		{"GET", "/hostdb/active", "renter:read"},
+		{"GET", "/metrics", "metrics:read"},
+		{"GET", "/renter/files", "renter:read"},
+		{"GET", "/renter/download/foo", "renter:write"},
+		{"GET", "/renter/downloads", "renter:read"},
+		{"POST", "/renter/upload/foo", "renter:write"},
+		{"GET", "/wallet/address", "wallet:write"},
+		{"GET", "/wallet/addresses", "wallet:read"},
+		{"GET", "/wallet/seeds", "wallet:admin"},
+		{"POST", "/wallet/init/seed", "wallet:admin"},
+		{"POST", "/wallet/multisig", "wallet:write"},
+		{"POST", "/wallet/multisig/sign", "wallet:spend"},
+		{"POST", "/wallet/siacoins", "wallet:spend"},
+		{"POST", "/wallet/transaction/abcd/bump", "wallet:spend"},
+		{"POST", "/wallet/schedules/abcd/remove", "wallet:spend"},
+		{"GET", "/wallet/schedules", "wallet:read"},
+		{"POST", "/wallet/timelock", "wallet:spend"},
+		{"GET", "/wallet/timelock", "wallet:read"},
+		{"POST", "/wallet/unlock", "wallet:write"},
+		{"POST", "/accounting", ""},
+		{"GET", "/unknown", ""},
+	}
+	for _, test := range tests {
+		if scope := routeScope(test.method, test.path); scope != test.scope {
+			t.Errorf("%v %v: expected scope %q, got %q", test.method, test.path, test.scope, scope)
+		}
+	}
+}
+
+// TestTokenStore tests creating, persisting and revoking API tokens.
+func TestTokenStore(t *testing.T) {
+	dir := build.TempDir("api", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := newTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid tokens can't be created.
+	if _, err := ts.create("", []string{"host:read"}); err == nil {
+		t.Error("expected token without name to fail")
+	}
+	if _, err := ts.create("dashboard", nil); err == nil {
+		t.Error("expected token without scopes to fail")
+	}
+	if _, err := ts.create("dashboard", []string{"host:everything"}); err == nil {
+		t.Error("expected token with unknown scope to fail")
+	}
+
+	// Create a token.
+	secret, err := ts.create("dashboard", []string{"host:read", "renter:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.create("dashboard", []string{"host:read"}); err == nil {
+		t.Error("expected duplicate token name to fail")
+	}
+	token, ok := ts.authenticate(secret)
+	if !ok || token.Name != "dashboard" || !token.HasScope("host:read") || token.HasScope("wallet:spend") {
+		t.Fatal("unexpected token", token, ok)
+	}
+	if _, ok := ts.authenticate("wrong"); ok {
+		t.Fatal("wrong secret shouldn't authenticate")
+	}
+
+	// Reload the store.
+	if err := ts.close(); err != nil {
+		t.Fatal(err)
+	}
+	ts, err = newTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := ts.list(); len(tokens) != 1 || tokens[0].Name != "dashboard" {
+		t.Fatal("token wasn't persisted", tokens)
+	}
+	if _, ok := ts.authenticate(secret); !ok {
+		t.Fatal("persisted token should authenticate")
+	}
+
+	// Revoke the token.
+	if err := ts.revoke("dashboard"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.revoke("dashboard"); !errors.Contains(err, errTokenNotFound) {
+		t.Fatal("expected errTokenNotFound, got", err)
+	}
+	if _, ok := ts.authenticate(secret); ok {
+		t.Fatal("revoked token shouldn't authenticate")
+	}
+	if err := ts.close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRequireScope tests the scope middleware.
+func TestRequireScope(t *testing.T) {
+	dir := build.TempDir("api", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := newTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ts.close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	secret, err := ts.create("dashboard", []string{"host:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const password = "password"
+	handler := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteSuccess(w)
+	}
+	router := httprouter.New()
+	router.GET("/host", handler)
+	router.GET("/host/contracts", RequirePassword(handler, password))
+	router.POST("/host", RequirePassword(handler, password))
+	router.POST("/wallet/siacoins", RequirePassword(handler, password))
+
+	// request performs a request and returns the status code.
+	request := func(h http.Handler, method, path, cred string, bearer bool) int {
+		req := httptest.NewRequest(method, path, nil)
+		if cred != "" && bearer {
+			req.Header.Set("Authorization", "Bearer "+cred)
+		} else if cred != "" {
+			req.SetBasicAuth("", cred)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		authenticateReads bool
+		method            string
+		path              string
+		cred              string
+		bearer            bool
+		status            int
+	}{
+		{false, "GET", "/host", "", false, http.StatusNoContent},
+		{false, "GET", "/host/contracts", "", false, http.StatusUnauthorized},
+		{false, "GET", "/host/contracts", password, false, http.StatusNoContent},
+		{false, "GET", "/host/contracts", secret, false, http.StatusNoContent},
+		{false, "GET", "/host/contracts", secret, true, http.StatusNoContent},
+		{false, "POST", "/host", secret, false, http.StatusForbidden},
+		{false, "POST", "/host", password, false, http.StatusNoContent},
+		{false, "POST", "/wallet/siacoins", secret, true, http.StatusForbidden},
+		{false, "POST", "/wallet/siacoins", "wrong", false, http.StatusUnauthorized},
+		{true, "GET", "/host", "", false, http.StatusUnauthorized},
+		{true, "GET", "/host", "wrong", false, http.StatusUnauthorized},
+		{true, "GET", "/host", secret, true, http.StatusNoContent},
+		{true, "GET", "/host", password, false, http.StatusNoContent},
+	}
+	for i, test := range tests {
+		h := requireScope(router, ts, password, test.authenticateReads)
+		if status := request(h, test.method, test.path, test.cred, test.bearer); status != test.status {
+			t.Errorf("test %v: %v %v: expected status %v, got %v", i, test.method, test.path, test.status, status)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -68,6 +69,67 @@ type (
 		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
+	// WalletMultisigGET contains the multisig accounts tracked by the wallet.
+	WalletMultisigGET struct {
+		Accounts []modules.MultisigAccount `json:"accounts"`
+	}
+
+	// WalletMultisigPOSTParams contains the parameters of a multisig account
+	// which is added to the wallet.
+	WalletMultisigPOSTParams struct {
+		Name       string               `json:"name"`
+		PublicKeys []types.SiaPublicKey `json:"publickeys"`
+		Required   uint64               `json:"required"`
+		Unused     bool                 `json:"unused"`
+	}
+
+	// WalletMultisigPOST contains the multisig account that was added to the
+	// wallet.
+	WalletMultisigPOST struct {
+		Account modules.MultisigAccount `json:"account"`
+	}
+
+	// WalletMultisigRemovePOSTParams contains the address of the multisig
+	// account that is removed from the wallet.
+	WalletMultisigRemovePOSTParams struct {
+		Address types.UnlockHash `json:"address"`
+		Unused  bool             `json:"unused"`
+	}
+
+	// WalletMultisigTransactionPOSTParams contains the multisig address to
+	// spend from and the outputs of the new transaction.
+	WalletMultisigTransactionPOSTParams struct {
+		Address types.UnlockHash      `json:"address"`
+		Outputs []types.SiacoinOutput `json:"outputs"`
+	}
+
+	// WalletMultisigSignPOSTParams contains the partially signed transaction
+	// to sign or broadcast.
+	WalletMultisigSignPOSTParams struct {
+		PartiallySignedTransaction modules.PartiallySignedTransaction `json:"partiallysignedtransaction"`
+	}
+
+	// WalletMultisigCombinePOSTParams contains the partially signed
+	// transactions to combine.
+	WalletMultisigCombinePOSTParams struct {
+		PartiallySignedTransactions []modules.PartiallySignedTransaction `json:"partiallysignedtransactions"`
+	}
+
+	// WalletMultisigTransactionPOST contains a partially signed transaction
+	// and the signing progress of its inputs.
+	WalletMultisigTransactionPOST struct {
+		PartiallySignedTransaction modules.PartiallySignedTransaction `json:"partiallysignedtransaction"`
+		Progress                   []modules.SignatureProgress        `json:"progress"`
+		Complete                   bool                               `json:"complete"`
+	}
+
+	// WalletMultisigBroadcastPOST contains the finalized transaction that was
+	// broadcast.
+	WalletMultisigBroadcastPOST struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Transaction   types.Transaction   `json:"transaction"`
+	}
+
 	// WalletSignPOSTParams contains the unsigned transaction and a set of
 	// inputs to sign.
 	WalletSignPOSTParams struct {
@@ -80,6 +142,27 @@ type (
 		Transaction types.Transaction `json:"transaction"`
 	}
 
+	// WalletSchedulesGET contains the scheduled payments of the wallet.
+	WalletSchedulesGET struct {
+		Schedules []modules.PaymentSchedule `json:"schedules"`
+	}
+
+	// WalletSchedulePOSTParams contains the parameters of a scheduled
+	// payment which is added or updated.
+	WalletSchedulePOSTParams struct {
+		Label      string                `json:"label"`
+		Outputs    []types.SiacoinOutput `json:"outputs"`
+		NextHeight types.BlockHeight     `json:"nextheight"`
+		Interval   types.BlockHeight     `json:"interval"`
+		Remaining  uint64                `json:"remaining"`
+	}
+
+	// WalletSchedulesPOST contains the scheduled payment that was added to
+	// the wallet.
+	WalletSchedulesPOST struct {
+		Schedule modules.PaymentSchedule `json:"schedule"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -94,12 +177,35 @@ type (
 		Funds types.Currency `json:"funds"`
 	}
 
+	// WalletTimelockGET contains the address and the unlock conditions of a
+	// time-locked output.
+	WalletTimelockGET struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// WalletTimelockPOST contains the address and the unlock conditions of a
+	// time-locked output and the transactions which created it.
+	WalletTimelockPOST struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		Transactions     []types.Transaction    `json:"transactions"`
+		TransactionIDs   []types.TransactionID  `json:"transactionids"`
+	}
+
 	// WalletTransactionGETid contains the transaction returned by a call to
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
 		Transaction modules.ProcessedTransaction `json:"transaction"`
 	}
 
+	// WalletTransactionBumpPOST contains the transactions that were submitted
+	// to the transaction pool to bump the fee of a transaction.
+	WalletTransactionBumpPOST struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+		Transactions   []types.Transaction   `json:"transactions"`
+	}
+
 	// WalletTransactionsGET contains the specified set of confirmed and
 	// unconfirmed transactions.
 	WalletTransactionsGET struct {
@@ -125,6 +231,17 @@ type (
 		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
 	}
 
+	// WalletAddressLabel is the label of a wallet address.
+	WalletAddressLabel struct {
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+	}
+
+	// WalletLabelsGET contains the labels of the wallet's addresses.
+	WalletLabelsGET struct {
+		Labels []WalletAddressLabel `json:"labels"`
+	}
+
 	// WalletUnspentGET contains the unspent outputs tracked by the wallet.
 	// The MaturityHeight field of each output indicates the height of the
 	// block that the output appeared in.
@@ -195,6 +312,18 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/seeds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedsHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/schedules", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSchedulesHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/schedules", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSchedulesHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/schedules/:id", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletScheduleHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/schedules/:id/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletScheduleRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/siacoins", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSiacoinsHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -207,9 +336,18 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/sweep/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSweepSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/timelock", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTimelockHandlerGET(w, req, ps)
+	})
+	router.POST("/wallet/timelock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTimelockHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/transaction/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionHandler(wallet, w, req, ps)
 	})
+	router.POST("/wallet/transaction/:id/bump", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTransactionBumpHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionsHandler(wallet, w, req, ps)
 	})
@@ -237,9 +375,42 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/unspent", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletUnspentHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/unspent/:id/freeze", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletUnspentFreezeHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/unspent/:id/label", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletUnspentLabelHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSignHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/multisig", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/transaction", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigTransactionHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigSignHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/combine", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigCombineHandler(w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/broadcast", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigBroadcastHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletWatchHandlerGET(wallet, w, req, ps)
 	}, requiredPassword))
@@ -579,9 +750,101 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 	})
 }
 
+// walletSchedulesHandlerGET handles GET calls to /wallet/schedules.
+func walletSchedulesHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	schedules, err := wallet.PaymentSchedules()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedules: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletSchedulesGET{
+		Schedules: schedules,
+	})
+}
+
+// walletSchedulesHandlerPOST handles POST calls to /wallet/schedules.
+func walletSchedulesHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletSchedulePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	schedule, err := wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Label:      params.Label,
+		Outputs:    params.Outputs,
+		NextHeight: params.NextHeight,
+		Interval:   params.Interval,
+		Remaining:  params.Remaining,
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to add scheduled payment: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSchedulesPOST{
+		Schedule: schedule,
+	})
+}
+
+// walletScheduleHandlerPOST handles POST calls to /wallet/schedules/:id.
+func walletScheduleHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse schedule id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var params WalletSchedulePOSTParams
+	err = json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.UpdatePaymentSchedule(modules.PaymentSchedule{
+		ID:         id,
+		Label:      params.Label,
+		Outputs:    params.Outputs,
+		NextHeight: params.NextHeight,
+		Interval:   params.Interval,
+		Remaining:  params.Remaining,
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to update scheduled payment: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletScheduleRemoveHandler handles API calls to
+// /wallet/schedules/:id/remove.
+func walletScheduleRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse schedule id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.RemovePaymentSchedule(id)
+	if err != nil {
+		WriteError(w, Error{"failed to remove scheduled payment: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var txns []types.Transaction
+	var inputs []types.SiacoinOutputID
+	if req.FormValue("inputs") != "" {
+		if req.FormValue("feeIncluded") != "" {
+			WriteError(w, Error{"cannot supply both 'inputs' and feeIncluded parameter"}, http.StatusBadRequest)
+			return
+		}
+		err := json.Unmarshal([]byte(req.FormValue("inputs")), &inputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode inputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" || req.FormValue("feeIncluded") != "" {
@@ -595,7 +858,11 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
+		if inputs != nil {
+			txns, err = wallet.SendSiacoinsFromInputs(inputs, outputs)
+		} else {
+			txns, err = wallet.SendSiacoinsMulti(outputs)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -618,7 +885,9 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			return
 		}
 
-		if feeIncluded {
+		if inputs != nil {
+			txns, err = wallet.SendSiacoinsFromInputs(inputs, []types.SiacoinOutput{{Value: amount, UnlockHash: dest}})
+		} else if feeIncluded {
 			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
 		} else {
 			txns, err = wallet.SendSiacoins(amount, dest)
@@ -639,6 +908,62 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	})
 }
 
+// scanTimelockUnlockConditions parses the unlock conditions of a time-locked
+// output from the publickey and timelock parameters of a request.
+func scanTimelockUnlockConditions(req *http.Request) (types.UnlockConditions, error) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(req.FormValue("publickey")); err != nil {
+		return types.UnlockConditions{}, errors.AddContext(err, "unable to parse publickey")
+	}
+	timelock, err := strconv.ParseUint(req.FormValue("timelock"), 10, 64)
+	if err != nil {
+		return types.UnlockConditions{}, errors.AddContext(err, "unable to parse timelock")
+	}
+	return modules.TimelockUnlockConditions(pk, types.BlockHeight(timelock)), nil
+}
+
+// walletTimelockHandlerGET handles GET calls to /wallet/timelock.
+func walletTimelockHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	uc, err := scanTimelockUnlockConditions(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletTimelockGET{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	})
+}
+
+// walletTimelockHandlerPOST handles POST calls to /wallet/timelock.
+func walletTimelockHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/timelock"}, http.StatusBadRequest)
+		return
+	}
+	uc, err := scanTimelockUnlockConditions(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.SendSiacoins(amount, uc.UnlockHash())
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/timelock: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletTimelockPOST{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+		Transactions:     txns,
+		TransactionIDs:   txids,
+	})
+}
+
 // walletSiafundsHandler handles API calls to /wallet/siafunds.
 func walletSiafundsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	amount, ok := scanAmount(req.FormValue("amount"))
@@ -716,6 +1041,40 @@ func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *h
 	})
 }
 
+// walletTransactionBumpHandler handles API calls to
+// /wallet/transaction/:id/bump.
+func walletTransactionBumpHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	if err := id.UnmarshalJSON([]byte("\"" + ps.ByName("id") + "\"")); err != nil {
+		WriteError(w, Error{"unable to parse transaction id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	method := modules.FeeBumpReplace
+	if m := req.FormValue("method"); m != "" {
+		method = modules.FeeBumpMethod(m)
+	}
+	var feePerByte types.Currency
+	if f := req.FormValue("feeperbyte"); f != "" {
+		fee, ok := scanAmount(f)
+		if !ok {
+			WriteError(w, Error{"could not read feeperbyte"}, http.StatusBadRequest)
+			return
+		}
+		feePerByte = fee
+	}
+	txns, err := wallet.BumpTransactionFee(id, method, feePerByte)
+	if err != nil {
+		WriteError(w, Error{"failed to bump transaction fee: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var resp WalletTransactionBumpPOST
+	for _, txn := range txns {
+		resp.TransactionIDs = append(resp.TransactionIDs, txn.ID())
+		resp.Transactions = append(resp.Transactions, txn)
+	}
+	WriteJSON(w, resp)
+}
+
 // walletTransactionsHandler handles API calls to /wallet/transactions.
 func walletTransactionsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
@@ -909,6 +1268,76 @@ func walletUnspentHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.
 	})
 }
 
+// walletUnspentFreezeHandler handles API calls to /wallet/unspent/:id/freeze.
+func walletUnspentFreezeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse output id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	frozen := true
+	if f := req.FormValue("frozen"); f != "" {
+		frozen, err = scanBool(f)
+		if err != nil {
+			WriteError(w, Error{"could not read frozen: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err = wallet.SetOutputFrozen(types.OutputID(id), frozen)
+	if err != nil {
+		WriteError(w, Error{"failed to freeze output: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletUnspentLabelHandler handles API calls to /wallet/unspent/:id/label.
+func walletUnspentLabelHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse output id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.SetOutputLabel(types.OutputID(id), req.FormValue("label"))
+	if err != nil {
+		WriteError(w, Error{"failed to label output: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletLabelsHandlerGET handles GET calls to /wallet/labels.
+func walletLabelsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	labels, err := wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	resp := WalletLabelsGET{Labels: make([]WalletAddressLabel, 0, len(labels))}
+	for addr, label := range labels {
+		resp.Labels = append(resp.Labels, WalletAddressLabel{Address: addr, Label: label})
+	}
+	sort.Slice(resp.Labels, func(i, j int) bool {
+		return resp.Labels[i].Address.String() < resp.Labels[j].Address.String()
+	})
+	WriteJSON(w, resp)
+}
+
+// walletLabelsHandlerPOST handles POST calls to /wallet/labels.
+func walletLabelsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"could not read address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.SetAddressLabel(addr, req.FormValue("label"))
+	if err != nil {
+		WriteError(w, Error{"failed to label address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletSignHandler handles API calls to /wallet/sign.
 func walletSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var params WalletSignPOSTParams
@@ -958,3 +1387,132 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 	}
 	WriteSuccess(w)
 }
+
+// walletMultisigTransactionResponse returns the response of the API calls
+// returning a partially signed transaction.
+func walletMultisigTransactionResponse(pst modules.PartiallySignedTransaction) WalletMultisigTransactionPOST {
+	return WalletMultisigTransactionPOST{
+		PartiallySignedTransaction: pst,
+		Progress:                   pst.Progress(),
+		Complete:                   pst.Complete(),
+	}
+}
+
+// walletMultisigHandlerGET handles GET calls to /wallet/multisig.
+func walletMultisigHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	accounts, err := wallet.MultisigAccounts()
+	if err != nil {
+		WriteError(w, Error{"failed to get multisig accounts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigGET{
+		Accounts: accounts,
+	})
+}
+
+// walletMultisigHandlerPOST handles POST calls to /wallet/multisig.
+func walletMultisigHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         params.PublicKeys,
+		SignaturesRequired: params.Required,
+	}
+	account, err := wallet.AddMultisigAccount(params.Name, uc, params.Unused)
+	if err != nil {
+		WriteError(w, Error{"failed to add multisig account: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		Account: account,
+	})
+}
+
+// walletMultisigRemoveHandler handles API calls to /wallet/multisig/remove.
+func walletMultisigRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigRemovePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.RemoveMultisigAccount(params.Address, params.Unused)
+	if err != nil {
+		WriteError(w, Error{"failed to remove multisig account: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletMultisigTransactionHandler handles API calls to
+// /wallet/multisig/transaction.
+func walletMultisigTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigTransactionPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := wallet.CreateMultisigTransaction(params.Address, params.Outputs)
+	if err != nil {
+		WriteError(w, Error{"failed to create multisig transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, walletMultisigTransactionResponse(pst))
+}
+
+// walletMultisigSignHandler handles API calls to /wallet/multisig/sign.
+func walletMultisigSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigSignPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := wallet.SignMultisigTransaction(params.PartiallySignedTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to sign multisig transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, walletMultisigTransactionResponse(pst))
+}
+
+// walletMultisigCombineHandler handles API calls to /wallet/multisig/combine.
+func walletMultisigCombineHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigCombinePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := modules.CombinePartiallySignedTransactions(params.PartiallySignedTransactions...)
+	if err != nil {
+		WriteError(w, Error{"failed to combine multisig transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, walletMultisigTransactionResponse(pst))
+}
+
+// walletMultisigBroadcastHandler handles API calls to
+// /wallet/multisig/broadcast.
+func walletMultisigBroadcastHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigSignPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, err := wallet.BroadcastMultisigTransaction(params.PartiallySignedTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to broadcast multisig transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigBroadcastPOST{
+		TransactionID: txn.ID(),
+		Transaction:   txn,
+	})
+}
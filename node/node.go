@@ -8,6 +8,7 @@ package node
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -30,8 +31,15 @@ import (
 	"go.sia.tech/siad/modules/transactionpool"
 	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 )
 
+// errPrunedExplorer is returned when a node with a pruned or bootstrapped
+// consensus set is created with an explorer. The explorer indexes every block
+// from the genesis block, which is not possible once the old blocks have been
+// pruned or weren't imported in the first place.
+var errPrunedExplorer = errors.New("the explorer can't be used with a pruned or bootstrapped consensus set")
+
 // NodeParams contains a bunch of parameters for creating a new test node. As
 // there are many options, templates are provided that you can modify which
 // cover the most common use cases.
@@ -110,6 +118,32 @@ type NodeParams struct {
 	// Initialize node from existing seed.
 	PrimarySeed string
 
+	// EnableMetrics enables the /metrics endpoint of the node's API.
+	EnableMetrics bool
+
+	// StratumAddress is the address of the miner's stratum server. The server
+	// is only started if the address is not empty. A StratumDifficulty of 0
+	// uses the default share difficulty.
+	StratumAddress    string
+	StratumDifficulty uint64
+	StratumSolo       bool
+
+	// PrunedConsensus creates a consensus set which only keeps the most
+	// recent blocks. It can't be combined with an explorer.
+	PrunedConsensus bool
+
+	// BootstrapSnapshot is the path of a consensus snapshot which is imported
+	// before the consensus set is created, if the node doesn't have a
+	// consensus database yet. The block at the snapshot height has to match
+	// BootstrapSnapshotID. It can't be combined with an explorer.
+	BootstrapSnapshot   string
+	BootstrapSnapshotID types.BlockID
+
+	// AuthenticateAPIReads requires authentication for all routes of the
+	// node's API, including the ones that are not password protected by
+	// default.
+	AuthenticateAPIReads bool
+
 	// The following fields are used to skip parts of the node set up
 	SkipSetAllowance     bool
 	SkipHostDiscovery    bool
@@ -189,6 +223,17 @@ func printfRelease(format string, a ...interface{}) {
 	}
 }
 
+// importConsensusSnapshot imports the consensus snapshot file into the
+// consensus directory.
+func importConsensusSnapshot(filename, dir string, id types.BlockID) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return consensus.ImportSnapshot(f, dir, id)
+}
+
 // Close will call close on every module within the node, combining and
 // returning the errors.
 func (n *Node) Close() (err error) {
@@ -248,6 +293,10 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		errChan <- err
 		return nil, errChan
 	}
+	if (params.PrunedConsensus || params.BootstrapSnapshot != "") && (params.CreateExplorer || params.Explorer != nil) {
+		errChan <- errPrunedExplorer
+		return nil, errChan
+	}
 
 	// Create the siamux.
 	mux, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
@@ -307,6 +356,18 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
+		if params.BootstrapSnapshot != "" {
+			err := importConsensusSnapshot(params.BootstrapSnapshot, filepath.Join(dir, modules.ConsensusDir), params.BootstrapSnapshotID)
+			if errors.Contains(err, consensus.ErrSnapshotDatabaseExists) {
+				printfRelease("Consensus database already exists, skipping snapshot import\n")
+			} else if err != nil {
+				c <- errors.AddContext(err, "unable to import consensus snapshot")
+				return nil, c
+			}
+		}
+		if params.PrunedConsensus {
+			return consensus.NewPrunedConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		}
 		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
@@ -403,6 +464,20 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if err != nil {
 			return nil, err
 		}
+		if params.StratumAddress != "" {
+			difficulty := params.StratumDifficulty
+			if difficulty == 0 {
+				difficulty = miner.DefaultStratumDifficulty
+			}
+			err = m.StartStratum(miner.StratumConfig{
+				Address:    params.StratumAddress,
+				Difficulty: difficulty,
+				Solo:       params.StratumSolo,
+			})
+			if err != nil {
+				return nil, errors.Compose(err, m.Close())
+			}
+		}
 		return m, nil
 	}()
 	if err != nil {
@@ -1,10 +1,16 @@
 package node
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules/consensus"
+	"go.sia.tech/siad/modules/wallet"
 )
 
 // TestNew is a basic smoke test for New that uses all of the templates to
@@ -85,3 +91,91 @@ func TestNew(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestNewPrunedWallet checks that the wallet can be used with a pruned
+// consensus set, and that a wallet which is restored from its seed finds the
+// outputs which were created by the pruned blocks.
+func TestNewPrunedWallet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	params := Miner(build.TempDir("node", t.Name()))
+	params.PrunedConsensus = true
+	n, errChan := New(params, time.Now())
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	key := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	seed, err := n.Wallet.Encrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Wallet.Unlock(key); err != nil {
+		t.Fatal(err)
+	}
+	for n.ConsensusSet.Height() < 3*consensus.PrunedBlockWindow {
+		if _, err := n.Miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, exists := n.ConsensusSet.BlockAtHeight(1); exists {
+		t.Fatal("consensus set wasn't pruned")
+	}
+	balance, _, _, err := n.Wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.IsZero() {
+		t.Fatal("wallet didn't receive the block rewards")
+	}
+
+	// Restore the wallet from its seed.
+	w, err := wallet.New(n.ConsensusSet, n.TransactionPool, filepath.Join(params.Dir, "restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := w.InitFromSeed(nil, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(crypto.NewWalletKey(crypto.HashObject(seed))); err != nil {
+		t.Fatal(err)
+	}
+	restored, _, _, err := w.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Equals(balance) {
+		t.Fatalf("restored wallet has a balance of %v, expected %v", restored, balance)
+	}
+}
+
+// TestNewPrunedExplorer checks that a node with a pruned or bootstrapped
+// consensus set can't be created with an explorer.
+func TestNewPrunedExplorer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	params := Miner(build.TempDir("node", t.Name()+"-Pruned"))
+	params.CreateExplorer = true
+	params.PrunedConsensus = true
+	if _, errChan := New(params, time.Now()); !errors.Contains(<-errChan, errPrunedExplorer) {
+		t.Fatal("expected errPrunedExplorer")
+	}
+
+	params = Miner(build.TempDir("node", t.Name()+"-Bootstrapped"))
+	params.CreateExplorer = true
+	params.BootstrapSnapshot = filepath.Join(params.Dir, "snapshot")
+	if _, errChan := New(params, time.Now()); !errors.Contains(<-errChan, errPrunedExplorer) {
+		t.Fatal("expected errPrunedExplorer")
+	}
+}
@@ -1 +1,2 @@
+// Package accounting contains tests related to the /accounting endpoint.
 package accounting
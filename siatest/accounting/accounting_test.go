@@ -0,0 +1,49 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+
+	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/siatest"
+)
+
+// TestAccountingGet probes the /accounting endpoint.
+func TestAccountingGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a node with the accounting module
+	testDir := accountingTestDir(t.Name())
+	testNode, err := siatest.NewCleanNode(node.Accounting(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Request the accounting information
+	ag, err := testNode.AccountingGet(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ag.Current.Timestamp == 0 {
+		t.Error("current accounting information should have a timestamp")
+	}
+	for _, ai := range ag.History {
+		if ai.Timestamp > ag.Current.Timestamp {
+			t.Error("history entry is newer than the current accounting information")
+		}
+	}
+
+	// An invalid range should be rejected
+	_, err = testNode.AccountingGet(1, 0)
+	if err == nil {
+		t.Fatal("expected an error for an invalid range")
+	}
+}
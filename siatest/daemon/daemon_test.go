@@ -2,6 +2,8 @@ package daemon
 
 import (
 	"encoding/hex"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +19,7 @@ import (
 	"go.sia.tech/siad/node/api/client"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/types"
 )
 
 // TestDaemonAPIPassword makes sure that the daemon rejects requests with the
@@ -437,3 +440,181 @@ func TestDaemonProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDaemonMetrics tests the opt-in /metrics endpoint.
+func TestDaemonMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a node without metrics enabled.
+	testNode, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "disabled")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testNode.MetricsGet()
+	if err == nil {
+		t.Error("expected /metrics to be unavailable by default")
+	}
+	if err := testNode.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a host node with metrics enabled.
+	params := node.Host(filepath.Join(testDir, "enabled"))
+	params.EnableMetrics = true
+	testNode, err = siatest.NewCleanNode(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Scrape the metrics through the client.
+	metrics, err := testNode.MetricsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"siad_uptime_seconds", "siad_consensus_height", "siad_consensus_synced", "siad_gateway_peers", "siad_tpool_transactions", "siad_host_contracts", "siad_wallet_unlocked"} {
+		if !strings.Contains(string(metrics), "\n"+name+" ") {
+			t.Errorf("metric %v missing from output:\n%v", name, string(metrics))
+		}
+	}
+	if !strings.HasSuffix(string(metrics), "# EOF\n") {
+		t.Error("metrics should end with the EOF marker")
+	}
+
+	// Scrape the metrics like Prometheus would, without the Sia user agent.
+	req, err := http.NewRequest("GET", "http://"+testNode.Server.APIAddress()+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "Prometheus/2.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %v without password, got %v", http.StatusUnauthorized, resp.StatusCode)
+	}
+	req.SetBasicAuth("", testNode.Password)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("unexpected content type %v", ct)
+	}
+}
+
+// TestDaemonTokens tests authenticating with scoped API tokens.
+func TestDaemonTokens(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a node that requires authentication for all routes.
+	params := node.Wallet(testDir)
+	params.AuthenticateAPIReads = true
+	testNode, err := siatest.NewCleanNode(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a token which can read the consensus and wallet.
+	dtcp, err := testNode.DaemonTokensCreatePost("dashboard", []string{"consensus:read", "wallet:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dtcp.Name != "dashboard" || dtcp.Token == "" || len(dtcp.Scopes) != 2 {
+		t.Fatal("unexpected token", dtcp)
+	}
+	if _, err := testNode.DaemonTokensCreatePost("invalid", []string{"wallet:everything"}); err == nil {
+		t.Fatal("expected token with unknown scope to fail")
+	}
+	dtg, err := testNode.DaemonTokensGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dtg.Tokens) != 1 || dtg.Tokens[0].Name != "dashboard" {
+		t.Fatal("unexpected tokens", dtg.Tokens)
+	}
+
+	// Create clients without credentials and with the token.
+	opts, err := client.DefaultOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Address = testNode.Server.APIAddress()
+	opts.Password = ""
+	anon := client.New(opts)
+	opts.Password = dtcp.Token
+	dashboard := client.New(opts)
+
+	// Read-only routes require authentication.
+	if _, err := anon.ConsensusGet(); err == nil {
+		t.Error("expected unauthenticated read to fail")
+	}
+	if _, err := dashboard.ConsensusGet(); err != nil {
+		t.Error(err)
+	}
+	if _, err := dashboard.WalletGet(); err != nil {
+		t.Error(err)
+	}
+
+	// The token can't access routes outside of its scopes.
+	if _, err := dashboard.GatewayGet(); err == nil {
+		t.Error("expected gateway access to fail")
+	}
+	if _, err := dashboard.WalletSeedsGet(); err == nil {
+		t.Error("expected access to the seeds to fail")
+	}
+	addr, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dashboard.WalletSiacoinsPost(types.SiacoinPrecision, addr.Address, false); err == nil {
+		t.Error("expected spending to fail")
+	}
+	if _, err := dashboard.DaemonTokensCreatePost("escalate", []string{"wallet:spend"}); err == nil {
+		t.Error("expected token creation with a token to fail")
+	}
+
+	// The token usage is recorded in the audit log.
+	auditLog, err := ioutil.ReadFile(filepath.Join(testNode.Dir, "apiaudit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(auditLog), `allowed token "dashboard": GET /consensus`) {
+		t.Error("allowed request missing from audit log")
+	}
+	if !strings.Contains(string(auditLog), `denied token "dashboard": POST /wallet/siacoins`) {
+		t.Error("denied request missing from audit log")
+	}
+
+	// Revoke the token.
+	if err := testNode.DaemonTokensRevokePost("dashboard"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.DaemonTokensRevokePost("dashboard"); err == nil {
+		t.Fatal("expected revoking a missing token to fail")
+	}
+	if _, err := dashboard.ConsensusGet(); err == nil {
+		t.Error("expected revoked token to fail")
+	}
+}
@@ -17,6 +17,18 @@ func (d *DependencyTimeoutProjectDownloadByRoot) Disrupt(s string) bool {
 	return s == "timeoutProjectDownloadByRoot"
 }
 
+// DependencyDisableRenterIdentity prevents the contractor from proving the
+// renter's identity to hosts when forming contracts, like renters of older
+// versions.
+type DependencyDisableRenterIdentity struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt prevents the contractor from proving the renter's identity.
+func (d *DependencyDisableRenterIdentity) Disrupt(s string) bool {
+	return s == "DisableRenterIdentity"
+}
+
 // DependencyDisableCloseUploadEntry prevents SiaFileEntries in the upload code
 // from being closed.
 type DependencyDisableCloseUploadEntry struct {
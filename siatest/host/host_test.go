@@ -3,6 +3,8 @@ package host
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -698,3 +700,122 @@ func TestHostGetPriceTable(t *testing.T) {
 		t.Fatal("wrong subscription notification cost")
 	}
 }
+
+// TestHostRevenue confirms that the host records the revenue of resolved
+// storage obligations in its revenue ledger.
+func TestHostRevenue(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	gp := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(hostTestDir(t.Name()), gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	hostNode := tg.Hosts()[0]
+	all := modules.HostRevenueFilter{End: math.MaxInt64, EndHeight: math.MaxUint64}
+
+	// The ledger should be empty before any obligation is resolved.
+	hrg, err := hostNode.HostRevenueGet(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hrg.Entries) != 0 {
+		t.Fatal("expected ledger to be empty", hrg.Entries)
+	}
+
+	// Mine blocks until the proof deadline of the renter's first contract
+	// has passed.
+	hc, err := hostNode.HostContractInfoGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.Contracts) == 0 {
+		t.Fatal("expected host to have a contract")
+	}
+	deadline := hc.Contracts[0].ProofDeadLine
+	for {
+		cg, err := hostNode.ConsensusGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cg.Height > deadline {
+			break
+		}
+		if err := tg.Miners()[0].MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every succeeded or failed obligation should be recorded in the ledger.
+	// Obligations might also be rejected when the renter renews its contract
+	// early, those aren't recorded. Keep mining in case the host needs to wait
+	// for its storage proofs to be confirmed.
+	var entry modules.HostRevenueEntry
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if err := tg.Miners()[0].MineBlock(); err != nil {
+			return err
+		}
+		hc, err := hostNode.HostContractInfoGet()
+		if err != nil {
+			return err
+		}
+		hrg, err := hostNode.HostRevenueGet(all)
+		if err != nil {
+			return err
+		}
+		recorded := make(map[types.FileContractID]modules.HostRevenueEntry)
+		for _, e := range hrg.Entries {
+			if _, exists := recorded[e.ObligationID]; exists {
+				return fmt.Errorf("obligation %v was recorded twice", e.ObligationID)
+			}
+			recorded[e.ObligationID] = e
+		}
+		resolved := 0
+		for _, so := range hc.Contracts {
+			e, exists := recorded[so.ObligationId]
+			switch so.ObligationStatus {
+			case "obligationSucceeded", "obligationFailed":
+				if !exists || e.Status != so.ObligationStatus {
+					return fmt.Errorf("obligation %v wasn't recorded", so.ObligationId)
+				}
+				if so.ObligationStatus == "obligationSucceeded" && !e.ContractCompensation.Equals(so.ContractCost) {
+					return fmt.Errorf("wrong contract compensation %v != %v", e.ContractCompensation, so.ContractCost)
+				}
+				entry = e
+				resolved++
+			default:
+				if exists {
+					return fmt.Errorf("obligation %v shouldn't be recorded", so.ObligationId)
+				}
+			}
+		}
+		if resolved == 0 || resolved != len(recorded) {
+			return fmt.Errorf("expected %v entries but got %v", resolved, len(recorded))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Filtering by block height should exclude the entry.
+	hrg, err = hostNode.HostRevenueGet(modules.HostRevenueFilter{End: math.MaxInt64, StartHeight: entry.BlockHeight + 1, EndHeight: math.MaxUint64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hrg.Entries) != 0 {
+		t.Fatal("expected no entries after the obligation's height", hrg.Entries)
+	}
+}
@@ -732,10 +732,6 @@ func TestFuse(t *testing.T) {
 		t.Fatal("should not be able to make a directory in a read-only fuse system")
 	}
 
-	// TODO: When read-write fuse is supported, switch over to read-write mode
-	// here and begin testing the write features. Extend the concurrency test to
-	// probe write features as well, probably by adding more phases.
-
 	// Inode check. Mount the root siafile to a special inode mountpoint then
 	// open several files and directoriesk. Grab their inodes. Keep the folder
 	// mounted and the files and dirs open while the rest of the tests are
@@ -1163,3 +1159,182 @@ func TestFuse(t *testing.T) {
 		err = r.RenterFuseUnmount(unmount)
 	}
 }
+
+// TestFuseReadWrite tests writing to a fuse filesystem which was mounted in
+// read-write mode. This test is only run on Linux.
+func TestFuseReadWrite(t *testing.T) {
+	if !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := fuseTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// Mount the root directory in read-write mode.
+	mountpoint := filepath.Join(testDir, "mount")
+	err = os.MkdirAll(mountpoint, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFuseMount(mountpoint, modules.RootSiaPath(), modules.MountOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.RenterFuse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fi.MountPoints) != 1 || fi.MountPoints[0].MountOptions.ReadOnly {
+		t.Fatal("expected a single read-write mount", fi.MountPoints)
+	}
+
+	// readFile is a helper to compare the content of a fuse file with the
+	// expected data.
+	readFile := func(path string, expected []byte) {
+		t.Helper()
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("data of %v doesn't match: got %v bytes, expected %v bytes", path, len(data), len(expected))
+		}
+	}
+
+	// Create a directory and write a file to it.
+	dir := filepath.Join(mountpoint, "dir")
+	err = os.Mkdir(dir, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(dir, "file")
+	data := fastrand.Bytes(int(3*modules.SectorSize + 100))
+	err = ioutil.WriteFile(filePath, data, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readFile(filePath, data)
+
+	// The file should be known to the renter.
+	siaPath, err := modules.NewSiaPath("dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Filesize != uint64(len(data)) {
+		t.Fatal("wrong filesize", rf.File.Filesize, len(data))
+	}
+
+	// Write a file out of order.
+	outOfOrderPath := filepath.Join(dir, "outoforder")
+	f, err := os.OpenFile(outOfOrderPath, os.O_CREATE|os.O_WRONLY, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := int64(len(data) / 2)
+	if _, err := f.WriteAt(data[half:], half); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(data[:half], 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	readFile(outOfOrderPath, data)
+
+	// Overwrite the file.
+	newData := fastrand.Bytes(int(modules.SectorSize))
+	err = ioutil.WriteFile(outOfOrderPath, newData, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readFile(outOfOrderPath, newData)
+
+	// Rename the file, replacing the first file.
+	err = os.Rename(outOfOrderPath, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outOfOrderPath); !os.IsNotExist(err) {
+		t.Fatal("renamed file still exists", err)
+	}
+	readFile(filePath, newData)
+
+	// The replaced file shouldn't leave anything behind.
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 1 || fis[0].Name() != "file" {
+		t.Fatal("unexpected directory contents after replacing rename", len(fis))
+	}
+
+	// Empty files can be written too.
+	emptyPath := filepath.Join(dir, "empty")
+	err = ioutil.WriteFile(emptyPath, nil, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readFile(emptyPath, nil)
+	err = os.Remove(emptyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the non-empty directory should fail.
+	err = syscall.Rmdir(dir)
+	if !errors.Contains(err, syscall.ENOTEMPTY) {
+		t.Fatal("expected ENOTEMPTY but got", err)
+	}
+
+	// Delete the file and the directory.
+	err = os.Remove(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(siaPath); err == nil {
+		t.Fatal("deleted file is still known to the renter")
+	}
+	err = r.RenterFuseUnmount(mountpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing to a read-only mount should fail.
+	err = r.RenterFuseMount(mountpoint, modules.RootSiaPath(), modules.MountOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(mountpoint, "file"), data, persist.DefaultDiskPermissionsTest)
+	if pathErr, ok := err.(*os.PathError); !ok || pathErr.Err != syscall.EROFS {
+		t.Fatal("expected EROFS but got", err)
+	}
+	err = r.RenterFuseUnmount(mountpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
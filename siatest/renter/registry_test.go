@@ -0,0 +1,113 @@
+package renter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/types"
+)
+
+// TestRegistryAPI tests reading, updating and subscribing to registry entries
+// through the renter's API.
+func TestRegistryAPI(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for the test.
+	groupParams := siatest.GroupParams{
+		Hosts:   3,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// Create a random entry.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+	srv := modules.NewRegistryValue(dataKey, fastrand.Bytes(modules.RegistryDataSize), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+
+	// Reading the entry should fail since it doesn't exist yet.
+	_, err = r.RegistryReadWithTimeout(spk, dataKey, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "registry entry not found") {
+		t.Fatal("expected entry to not be found", err)
+	}
+
+	// Update the entry. Retry until the renter's workers are ready.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		return r.RegistryUpdate(spk, srv)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read it again.
+	readSRV, err := r.RegistryRead(spk, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(readSRV, srv) {
+		t.Fatal("entries don't match", readSRV, srv)
+	}
+
+	// Subscribe to the entry. The first notification should be the current
+	// value.
+	sub, err := r.RegistrySubscribePost([]api.RegistrySubscriptionRequest{{PublicKey: spk, DataKey: dataKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := sub.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	n, err := sub.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.EntryID != modules.DeriveRegistryEntryID(spk, dataKey) || n.Revision != srv.Revision {
+		t.Fatal("wrong initial notification", n)
+	}
+
+	// Update the entry again. The subscription should receive the update.
+	srv.Revision++
+	srv = srv.Sign(sk)
+	err = r.RegistryUpdate(spk, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = sub.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Revision != srv.Revision {
+		t.Fatalf("expected revision %v but got %v", srv.Revision, n.Revision)
+	}
+
+	// Updating the entry with an invalid signature should fail.
+	srv.Revision++
+	err = r.RegistryUpdate(spk, srv)
+	if err == nil {
+		t.Fatal("update with invalid signature should fail")
+	}
+}
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -93,6 +94,9 @@ func TestRenterTwo(t *testing.T) {
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
+		{Name: "TestChangeRedundancy", Test: testChangeRedundancy},
+		{Name: "TestDedup", Test: testDedup},
+		{Name: "TestUploadBatch", Test: testUploadBatch},
 	}
 
 	// Run tests
@@ -279,6 +283,7 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
 		{Name: "TestDirMode", Test: testDirMode},
+		{Name: "TestDirPolicy", Test: testDirPolicy},
 		{Name: "TestEscapeSiaPath", Test: testEscapeSiaPath}, // Runs last because it uploads many files
 	}
 
@@ -4723,6 +4728,94 @@ func testDirMode(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testDirPolicy tests that files uploaded to a directory use the directory's
+// policy.
+func testDirPolicy(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+
+	// Create a dir and set its policy. The hosts of the group are pinned.
+	dirSP := modules.RandomSiaPath()
+	if err := renter.RenterDirCreatePost(dirSP); err != nil {
+		t.Fatal(err)
+	}
+	policy := modules.DirPolicy{
+		DataPieces:   1,
+		ParityPieces: len(tg.Hosts()) - 1,
+		CipherType:   crypto.TypePlain.String(),
+	}
+	for _, host := range tg.Hosts() {
+		pk, err := host.HostPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy.Hosts = append(policy.Hosts, pk)
+	}
+	if err := renter.RenterDirSetPolicyPost(dirSP, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// An invalid policy should be rejected.
+	if err := renter.RenterDirSetPolicyPost(dirSP, modules.DirPolicy{CipherType: "rot13"}); err == nil {
+		t.Fatal("invalid policy should be rejected")
+	}
+
+	// The policy should be returned for the dir and inherited by its
+	// subdirs.
+	rd, err := renter.RenterDirGet(dirSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].Policy, policy) {
+		t.Fatal("wrong policy", rd.Directories[0].Policy)
+	}
+	subDirSP, err := dirSP.Join("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renter.RenterDirCreatePost(subDirSP); err != nil {
+		t.Fatal(err)
+	}
+	rd, err = renter.RenterDirGet(subDirSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].Policy, modules.DirPolicy{}) {
+		t.Fatal("subdir shouldn't have a policy", rd.Directories[0].Policy)
+	}
+	if !reflect.DeepEqual(rd.EffectivePolicy, policy) {
+		t.Fatal("wrong effective policy", rd.EffectivePolicy)
+	}
+
+	// Upload a file to the subdir without specifying any parameters.
+	fileSP, err := subDirSP.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(100)
+	if err := renter.RenterUploadStreamPost(bytes.NewReader(data), fileSP, 0, 0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should use the policy.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := renter.RenterFileGet(fileSP)
+		if err != nil {
+			return err
+		}
+		if rf.File.CipherType != policy.CipherType {
+			return fmt.Errorf("expected cipher type %v but got %v", policy.CipherType, rf.File.CipherType)
+		}
+		if rf.File.Redundancy != float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but got %v", len(tg.Hosts()), rf.File.Redundancy)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestWorkerStatus probes the WorkerPoolStatus
 func TestWorkerStatus(t *testing.T) {
 	if testing.Short() {
@@ -5746,3 +5839,227 @@ func TestRenterBubble(t *testing.T) {
 		}
 	}
 }
+
+// testChangeRedundancy tests changing the redundancy of a file which is only
+// available on the network.
+func testChangeRedundancy(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Check that we have enough hosts for this test.
+	if len(tg.Hosts()) < 3 {
+		t.Fatal("This test requires at least 3 hosts")
+	}
+
+	// Upload a file spanning multiple chunks with 1 data piece and 2 parity
+	// pieces and delete it locally to force the renter to download it.
+	fileSize := 2*int(modules.SectorSize) + siatest.Fuzz()
+	localFile, remoteFile, err := r.UploadNewFileBlocking(fileSize, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := localFile.Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the redundancy to 2 data pieces and 1 parity piece.
+	if err := r.RenterFileChangeRedundancyPost(remoteFile.SiaPath(), 2, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the file to be replaced.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(remoteFile.SiaPath())
+		if err != nil {
+			return err
+		}
+		if rf.RedundancyChange != nil {
+			return fmt.Errorf("redundancy change still in progress: %v", *rf.RedundancyChange)
+		}
+		if rf.File.Redundancy != 1.5 {
+			return fmt.Errorf("expected redundancy 1.5 but was %v", rf.File.Redundancy)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should still be downloadable.
+	if _, _, err := r.DownloadByStream(remoteFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testDedup tests that uploading the same file twice with dedup enabled
+// doesn't store the data again and that both copies stay downloadable.
+func testDedup(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Enable dedup.
+	if err := r.RenterSetDedupPost(true); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.RenterSetDedupPost(false); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.Dedup {
+		t.Fatal("dedup wasn't enabled")
+	}
+
+	// contractSize returns the amount of data stored in the renter's
+	// contracts.
+	contractSize := func() (size uint64) {
+		rc, err := r.RenterContractsGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range rc.ActiveContracts {
+			size += c.Size
+		}
+		return
+	}
+
+	// Upload a file spanning multiple chunks.
+	fileSize := 2*int(modules.SectorSize) + siatest.Fuzz()
+	localFile, remoteFile, err := r.UploadNewFileBlocking(fileSize, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := contractSize()
+
+	// Upload the same data again to a different siapath. It should reference
+	// the chunks of the first file instead of being uploaded again.
+	dupSiaPath, err := modules.NewSiaPath(remoteFile.SiaPath().String() + "_dup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dupFile, err := r.Upload(localFile, dupSiaPath, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitForUploadHealth(dupFile); err != nil {
+		t.Fatal(err)
+	}
+	if newSize := contractSize(); newSize != size {
+		t.Fatalf("expected contract size to stay at %v but was %v", size, newSize)
+	}
+
+	// Delete the first file and the local file. The copy should still be
+	// downloadable from the network.
+	expected, err := localFile.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterFileDeletePost(remoteFile.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := localFile.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	_, data, err := r.DownloadByStream(dupFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatal("downloaded data doesn't match the uploaded data")
+	}
+}
+
+// testUploadBatch tests uploading a batch of small files which are packed
+// into shared sectors.
+func testUploadBatch(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Create a few small files and an empty one.
+	var files []modules.BatchUploadFile
+	data := make(map[modules.SiaPath][]byte)
+	for _, size := range []int{0, 1, 100 + siatest.Fuzz(), int(modules.SectorSize) / 2} {
+		lf, err := r.FilesDir().NewFile(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := modules.NewSiaPath(lf.FileName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data[siaPath], err = lf.Data(); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, modules.BatchUploadFile{Source: lf.Path(), SiaPath: siaPath})
+	}
+
+	// Upload the batch. The empty file shouldn't be packed.
+	rub, err := r.RenterUploadBatchPost(files, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rub.Placements) != len(files)-1 {
+		t.Fatalf("expected %v placements but got %v", len(files)-1, len(rub.Placements))
+	}
+	if _, err := r.RenterFileRootGet(rub.Pack); err != nil {
+		t.Fatal("pack wasn't created", err)
+	}
+
+	// Uploading the batch again should fail without force.
+	if _, err := r.RenterUploadBatchPost(files, 1, 2, false); err == nil {
+		t.Fatal("expected batch upload to fail without force")
+	}
+
+	// Every file should be downloadable, both as a whole and as a range.
+	for siaPath, expected := range data {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rf.File.Filesize != uint64(len(expected)) {
+			t.Fatalf("expected filesize %v but got %v", len(expected), rf.File.Filesize)
+		}
+		if len(expected) == 0 {
+			continue
+		}
+		_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(expected)), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, expected) {
+			t.Fatal("downloaded data doesn't match the uploaded data", siaPath)
+		}
+		streamed, err := r.RenterStreamGet(siaPath, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(streamed, expected) {
+			t.Fatal("streamed data doesn't match the uploaded data", siaPath)
+		}
+		offset := uint64(len(expected)) / 2
+		_, downloaded, err = r.RenterDownloadHTTPResponseGet(siaPath, offset, uint64(len(expected))-offset, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, expected[offset:]) {
+			t.Fatal("downloaded range doesn't match the uploaded data", siaPath)
+		}
+	}
+
+	// Deleting all the packed files should delete the pack.
+	for i, f := range files {
+		if err := r.RenterFileDeletePost(f.SiaPath); err != nil {
+			t.Fatal(err)
+		}
+		_, err := r.RenterFileRootGet(rub.Pack)
+		if i < len(files)-1 && err != nil {
+			t.Fatal("pack was deleted too early", err)
+		} else if i == len(files)-1 && err == nil {
+			t.Fatal("pack wasn't deleted")
+		}
+	}
+}
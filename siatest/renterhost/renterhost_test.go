@@ -11,11 +11,14 @@ import (
 	"gitlab.com/NebulousLabs/log"
 	"gitlab.com/NebulousLabs/ratelimit"
 
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/proto"
+	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api/client"
 	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/siatest/dependencies"
 	"go.sia.tech/siad/types"
 )
 
@@ -355,6 +358,269 @@ func TestHostBaseRPCPrice(t *testing.T) {
 	}
 }
 
+// TestHostRenterPolicy tests that the host enforces its renter policy in the
+// RPC loop. The policy is configured with the renter's identity before the
+// renter forms any contracts.
+func TestHostRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := renterHostTestDir(t.Name())
+	gp := siatest.GroupParams{
+		Hosts:  1,
+		Miners: 1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(testDir, gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a renter without an allowance and get its identity.
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.SkipSetAllowance = true
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renter := nodes[0]
+	rg, err := renter.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := rg.Identity
+	if len(identity.Key) == 0 {
+		t.Fatal("renter has no identity")
+	}
+
+	// Only allow the renter and let it form and renew its contract. Both the
+	// new and the renewed contract are attributed to the renter's identity.
+	host := tg.Hosts()[0]
+	err = host.HostPolicyPost(modules.HostPolicy{Allowlist: []types.SiaPublicKey{identity}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.SetRenterAllowance(renter, siatest.DefaultAllowance); err != nil {
+		t.Fatal(err)
+	}
+	if err := siatest.RenewContractsByRenewWindow(renter, tg); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if err := tg.Miners()[0].MineBlock(); err != nil {
+			return err
+		}
+		return siatest.CheckExpectedNumberOfContracts(renter, 1, 0, 0, 0, 1, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hpg, err := host.HostPolicyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hpg.Usage) != 1 || !hpg.Usage[0].Renter.Equals(identity) || hpg.Usage[0].Contracts != 2 {
+		t.Fatalf("contracts weren't attributed to the renter %+v", hpg.Usage)
+	}
+
+	// manually grab the renewed contract
+	rc, err := renter.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := proto.NewContractSet(filepath.Join(renter.Dir, "renter", "contracts"), rl, new(modules.ProductionDependencies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, ok := cs.View(rc.ActiveContracts[0].ID)
+	if !ok {
+		t.Fatal("renewed contract not found")
+	}
+
+	hhg, err := renter.HostDbHostsGet(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := renter.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Limit the renter's collateral to less than the host would risk for a
+	// sector.
+	err = host.HostPolicyPost(modules.HostPolicy{MaxCollateral: types.NewCurrency64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	_, _, err = s.Append(sector)
+	if err == nil || !strings.Contains(err.Error(), "collateral quota") {
+		t.Fatal("expected collateral quota error, got", err)
+	}
+	s.Close()
+
+	// Limit the renter's storage to less than a sector.
+	err = host.HostPolicyPost(modules.HostPolicy{MaxStorage: modules.SectorSize / 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Append(sector)
+	if err == nil || !strings.Contains(err.Error(), "storage quota") {
+		t.Fatal("expected storage quota error, got", err)
+	}
+	s.Close()
+
+	// Upload a sector through a session within the storage quota.
+	err = host.HostPolicyPost(modules.HostPolicy{MaxStorage: modules.SectorSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, root, err := s.Append(sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Limit the renter's bandwidth to less than a sector. Downloading the
+	// sector still works but uses up the bandwidth, so uploads are rejected.
+	err = host.HostPolicyPost(modules.HostPolicy{MaxBandwidth: modules.SectorSize / 2, BandwidthPeriod: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = s.ReadSection(root, 0, uint32(modules.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Append(sector)
+	if err == nil || !strings.Contains(err.Error(), "bandwidth quota") {
+		t.Fatal("expected bandwidth quota error, got", err)
+	}
+	s.Close()
+
+	// Deny the renter. It can still lock its contract and download its data,
+	// but it can't upload anymore.
+	err = host.HostPolicyPost(modules.HostPolicy{Denylist: []types.SiaPublicKey{identity}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, _, err = s.ReadSection(root, 0, uint32(modules.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Append(sector)
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatal("expected renter not allowed error, got", err)
+	}
+}
+
+// TestHostRenterPolicyExistingContracts tests that a contract which was formed
+// before the host set its policy, and which can't be attributed to a renter,
+// can still be used after the host sets a quota.
+func TestHostRenterPolicyExistingContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := renterHostTestDir(t.Name())
+	gp := siatest.GroupParams{
+		Hosts:  1,
+		Miners: 1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(testDir, gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a renter which doesn't prove its identity, like a renter of an
+	// older version.
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.ContractorDeps = &dependencies.DependencyDisableRenterIdentity{}
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renter := nodes[0]
+	host := tg.Hosts()[0]
+	hpg, err := host.HostPolicyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hpg.Usage) != 0 {
+		t.Fatalf("contract shouldn't be attributed to a renter %+v", hpg.Usage)
+	}
+
+	// Set a quota-only policy.
+	err = host.HostPolicyPost(modules.HostPolicy{MaxStorage: modules.SectorSize / 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// manually grab the renter contract
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := proto.NewContractSet(filepath.Join(renter.Dir, "renter", "contracts"), rl, new(modules.ProductionDependencies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := cs.ViewAll()[0]
+	hhg, err := renter.HostDbHostsGet(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := renter.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contract can still be locked, uploaded to and downloaded from.
+	s, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	_, root, err := s.Append(sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, dsector, err := s.ReadSection(root, 0, uint32(len(sector)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dsector, sector) {
+		t.Fatal("downloaded sector does not match")
+	}
+}
+
 // TestMultiRead tests the Read RPC.
 func TestMultiRead(t *testing.T) {
 	t.Skip("Test does not pass online due to timing. Needs to be updated")
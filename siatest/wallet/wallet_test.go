@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
 	"go.sia.tech/siad/types"
@@ -969,3 +970,539 @@ func TestWalletVerifyPassword(t *testing.T) {
 		t.Error("Password should not be valid")
 	}
 }
+
+// TestMultisig tests spending from a 2-of-3 multisig account which is shared
+// by two wallets.
+func TestMultisig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a testgroup
+	groupParams := siatest.GroupParams{
+		Miners: 2,
+	}
+	tg, err := siatest.NewGroupFromTemplate(walletTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	nodeA, nodeB := tg.Miners()[0], tg.Miners()[1]
+
+	// Get a public key from both wallets. The third key is kept offline.
+	pubkey := func(n *siatest.TestNode) types.SiaPublicKey {
+		wag, err := n.WalletAddressGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wucg, err := n.WalletUnlockConditionsGet(wag.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wucg.UnlockConditions.PublicKeys[0]
+	}
+	_, pkC := crypto.GenerateKeyPair()
+	pks := []types.SiaPublicKey{pubkey(nodeA), pubkey(nodeB), types.Ed25519PublicKey(pkC)}
+
+	// Add the account to both wallets.
+	var addr types.UnlockHash
+	for _, n := range []*siatest.TestNode{nodeA, nodeB} {
+		wmp, err := n.WalletMultisigPost("shared", pks, 2, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr = wmp.Account.Address
+	}
+	if _, err := nodeA.WalletMultisigPost("shared", pks, 2, true); err == nil {
+		t.Fatal("adding the same account twice should fail")
+	}
+
+	// Fund the account.
+	funding := types.SiacoinPrecision.Mul64(100)
+	if _, err := nodeA.WalletSiacoinsPost(funding, addr, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodeA.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	wmg, err := nodeB.WalletMultisigGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wmg.Accounts) != 1 || !wmg.Accounts[0].ConfirmedSiacoinBalance.Equals(funding) {
+		t.Fatal("unexpected multisig accounts", wmg.Accounts)
+	}
+
+	// Create a transaction and sign it with both wallets independently.
+	dest := types.UnlockHash{1}
+	amount := types.SiacoinPrecision.Mul64(10)
+	wmtp, err := nodeA.WalletMultisigTransactionPost(addr, []types.SiacoinOutput{{Value: amount, UnlockHash: dest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pst := wmtp.PartiallySignedTransaction
+	if wmtp.Complete {
+		t.Fatal("unsigned transaction shouldn't be complete")
+	}
+	signedA, err := nodeA.WalletMultisigSignPost(pst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedB, err := nodeB.WalletMultisigSignPost(pst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedA.Complete || signedA.Progress[0].Signed != 1 {
+		t.Fatal("unexpected progress", signedA.Progress)
+	}
+	if _, err := nodeA.WalletMultisigBroadcastPost(signedA.PartiallySignedTransaction); err == nil {
+		t.Fatal("broadcasting a transaction without enough signatures should fail")
+	}
+
+	// Combine the signatures and broadcast the transaction.
+	combined, err := nodeA.WalletMultisigCombinePost(signedA.PartiallySignedTransaction, signedB.PartiallySignedTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !combined.Complete {
+		t.Fatal("combined transaction should be complete", combined.Progress)
+	}
+	wmbp, err := nodeB.WalletMultisigBroadcastPost(combined.PartiallySignedTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wmbp.TransactionID != pst.Transaction.ID() {
+		t.Fatal("broadcast transaction has unexpected id")
+	}
+	if err := nodeB.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The account should have been charged the amount and the fee.
+	wmg, err = nodeA.WalletMultisigGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := funding.Sub(amount).Sub(pst.Fee())
+	if !wmg.Accounts[0].ConfirmedSiacoinBalance.Equals(expected) {
+		t.Fatalf("expected balance %v, got %v", expected, wmg.Accounts[0].ConfirmedSiacoinBalance)
+	}
+
+	// The wallet shouldn't sign inputs which don't belong to a multisig
+	// account, even if it has the keys for them.
+	wug, err := nodeA.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var regular modules.UnspentOutput
+	for _, uo := range wug.Outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput && !uo.IsWatchOnly && uo.UnlockHash != addr {
+			regular = uo
+			break
+		}
+	}
+	if regular.Value.IsZero() {
+		t.Fatal("no regular output found")
+	}
+	wucg, err := nodeA.WalletUnlockConditionsGet(regular.UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := types.SiacoinPrecision
+	regularPST := modules.PartiallySignedTransaction{
+		Version: modules.PartiallySignedTransactionVersion,
+		Transaction: types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{
+				ParentID:         types.SiacoinOutputID(regular.ID),
+				UnlockConditions: wucg.UnlockConditions,
+			}},
+			SiacoinOutputs: []types.SiacoinOutput{{Value: regular.Value.Sub(fee), UnlockHash: dest}},
+			MinerFees:      []types.Currency{fee},
+		},
+		InputValues: []types.Currency{regular.Value},
+	}
+	if _, err := nodeA.WalletMultisigSignPost(regularPST); err == nil {
+		t.Fatal("wallet shouldn't sign inputs of regular addresses")
+	}
+
+	// Remove the account.
+	if err := nodeA.WalletMultisigRemovePost(addr, false); err != nil {
+		t.Fatal(err)
+	}
+	if wmg, err = nodeA.WalletMultisigGet(); err != nil || len(wmg.Accounts) != 0 {
+		t.Fatal("account wasn't removed", wmg.Accounts, err)
+	}
+}
+
+// TestWalletBumpFee tests increasing the fee of unconfirmed transactions by
+// replacing them and by spending their change in a child transaction.
+func TestWalletBumpFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// inPool returns whether the transaction is in the transaction pool.
+	inPool := func(id types.TransactionID) bool {
+		tptg, err := testNode.TransactionPoolTransactionsGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, txn := range tptg.Transactions {
+			if txn.ID() == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Replace a transaction.
+	dest := types.UnlockHash{1}
+	amount := types.SiacoinPrecision.Mul64(100)
+	wsp, err := testNode.WalletSiacoinsPost(amount, dest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	if _, err := testNode.WalletTransactionBumpPost(original, "unknown", types.ZeroCurrency); err == nil {
+		t.Fatal("unknown bump method should fail")
+	}
+	wtbp, err := testNode.WalletTransactionBumpPost(original, modules.FeeBumpReplace, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement := wtbp.TransactionIDs[0]
+	if replacement == original || !inPool(replacement) || inPool(original) {
+		t.Fatal("transaction wasn't replaced")
+	}
+	if wtbp.Transactions[0].MinerFees[0].Cmp(wsp.Transactions[len(wsp.Transactions)-1].MinerFees[0]) <= 0 {
+		t.Fatal("replacement doesn't pay a higher fee")
+	}
+
+	// Spend the change of a transaction in a child.
+	wsp, err = testNode.WalletSiacoinsPost(amount, dest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	wtbp, err = testNode.WalletTransactionBumpPost(parent, modules.FeeBumpChild, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := wtbp.TransactionIDs[len(wtbp.TransactionIDs)-1]
+	if !inPool(parent) || !inPool(child) {
+		t.Fatal("parent and child should be in the pool")
+	}
+
+	// Mine a block and check that the bumped transactions were confirmed.
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []types.TransactionID{replacement, parent, child} {
+		wtg, err := testNode.WalletTransactionGet(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wtg.Transaction.ConfirmationHeight == math.MaxUint64 {
+			t.Fatal("transaction wasn't confirmed", id)
+		}
+	}
+	if _, err := testNode.WalletTransactionBumpPost(child, modules.FeeBumpReplace, types.ZeroCurrency); err == nil {
+		t.Fatal("confirmed transactions can't be bumped")
+	}
+}
+
+// TestWalletCoinControl tests labelling and freezing outputs and sending
+// siacoins from an explicit set of inputs through the API.
+func TestWalletCoinControl(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Label an address and freeze one of its outputs.
+	wug, err := testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wug.Outputs) == 0 {
+		t.Fatal("wallet has no outputs")
+	}
+	frozen := wug.Outputs[0]
+	if err := testNode.WalletLabelsPost(frozen.UnlockHash, "collateral"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.WalletUnspentLabelPost(frozen.ID, "cold storage"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.WalletUnspentFreezePost(frozen.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	wlg, err := testNode.WalletLabelsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wlg.Labels) != 1 || wlg.Labels[0].Address != frozen.UnlockHash || wlg.Labels[0].Label != "collateral" {
+		t.Fatal("unexpected address labels", wlg.Labels)
+	}
+	wug, err = testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range wug.Outputs {
+		if o.ID != frozen.ID {
+			continue
+		}
+		if !o.Frozen || o.Label != "cold storage" || o.AddressLabel != "collateral" {
+			t.Fatal("output is missing its labels or frozen state", o)
+		}
+	}
+
+	// A regular send must not spend the frozen output.
+	amount := types.SiacoinPrecision.Mul64(100)
+	wsp, err := testNode.WalletSiacoinsPost(amount, types.UnlockHash{1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range wsp.Transactions {
+		for _, sci := range txn.SiacoinInputs {
+			if types.OutputID(sci.ParentID) == frozen.ID {
+				t.Fatal("frozen output was spent")
+			}
+		}
+	}
+
+	// Spend the frozen output explicitly.
+	outputs := []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{1}}}
+	wsp, err = testNode.WalletSiacoinsInputsPost([]types.SiacoinOutputID{types.SiacoinOutputID(frozen.ID)}, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wsp.Transactions) != 1 || len(wsp.Transactions[0].SiacoinInputs) != 1 || types.OutputID(wsp.Transactions[0].SiacoinInputs[0].ParentID) != frozen.ID {
+		t.Fatal("transaction doesn't spend the selected input")
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	wtg, err := testNode.WalletTransactionGet(wsp.TransactionIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wtg.Transaction.ConfirmationHeight == math.MaxUint64 {
+		t.Fatal("transaction wasn't confirmed")
+	}
+}
+
+// TestWalletSchedules tests adding, sending, updating and removing scheduled
+// payments through the API.
+func TestWalletSchedules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := api.WalletSchedulePOSTParams{
+		Label:      "partner payout",
+		Outputs:    []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{1}}},
+		NextHeight: cg.Height + 1,
+		Interval:   10,
+	}
+	wsp, err := testNode.WalletSchedulesPost(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := wsp.Schedule.ID
+
+	// Mine a block and wait for the payment to be sent.
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		wsg, err := testNode.WalletSchedulesGet()
+		if err != nil {
+			return err
+		}
+		if len(wsg.Schedules) != 1 || wsg.Schedules[0].Payments != 1 {
+			return errors.New("payment wasn't sent")
+		}
+		if wsg.Schedules[0].NextHeight != cg.Height+11 {
+			return fmt.Errorf("next height should be %v, was %v", cg.Height+11, wsg.Schedules[0].NextHeight)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update and remove the schedule.
+	params.NextHeight = cg.Height + 100
+	if err := testNode.WalletSchedulePost(id, params); err != nil {
+		t.Fatal(err)
+	}
+	wsg, err := testNode.WalletSchedulesGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wsg.Schedules[0].NextHeight != cg.Height+100 || wsg.Schedules[0].Payments != 1 {
+		t.Fatal("schedule wasn't updated", wsg.Schedules[0])
+	}
+	if err := testNode.WalletScheduleRemovePost(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.WalletScheduleRemovePost(id); err == nil {
+		t.Fatal("removing a schedule twice should fail")
+	}
+}
+
+// TestWalletTimelock tests sending siacoins to a time-locked output using the
+// /wallet/timelock endpoints.
+func TestWalletTimelock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Lock the output a few blocks into the future.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	height, err := testNode.BlockHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timelock := height + 3
+	uc := modules.TimelockUnlockConditions(spk, timelock)
+
+	// The address returned by the API should match the unlock conditions.
+	wtg, err := testNode.WalletTimelockGet(spk, timelock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wtg.Address != uc.UnlockHash() || wtg.UnlockConditions.Timelock != timelock {
+		t.Fatal("unexpected time-locked address", wtg.Address, wtg.UnlockConditions)
+	}
+
+	// Send coins to the time-locked address.
+	amount := types.SiacoinPrecision.Mul64(10)
+	wtp, err := testNode.WalletTimelockPost(amount, spk, timelock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wtp.Address != uc.UnlockHash() {
+		t.Fatal("unexpected address", wtp.Address)
+	}
+	var outputID types.SiacoinOutputID
+	for _, txn := range wtp.Transactions {
+		for i, sco := range txn.SiacoinOutputs {
+			if sco.UnlockHash == uc.UnlockHash() && sco.Value.Equals(amount) {
+				outputID = txn.SiacoinOutputID(uint64(i))
+			}
+		}
+	}
+	if outputID == (types.SiacoinOutputID{}) {
+		t.Fatal("time-locked output not found in transactions")
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// spend creates a signed transaction spending the time-locked output.
+	spend := func() types.Transaction {
+		cg, err := testNode.ConsensusGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		txn := types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{
+				ParentID:         outputID,
+				UnlockConditions: uc,
+			}},
+			SiacoinOutputs: []types.SiacoinOutput{{
+				Value:      amount,
+				UnlockHash: types.UnlockHash{},
+			}},
+			TransactionSignatures: []types.TransactionSignature{{
+				ParentID:      crypto.Hash(outputID),
+				CoveredFields: types.CoveredFields{WholeTransaction: true},
+			}},
+		}
+		sig := crypto.SignHash(txn.SigHash(0, cg.Height), sk)
+		txn.TransactionSignatures[0].Signature = sig[:]
+		return txn
+	}
+
+	// The output can't be spent before the timelock expires.
+	if err := testNode.TransactionPoolRawPost(spend(), nil); err == nil {
+		t.Fatal("time-locked output was spent before the timelock expired")
+	}
+
+	// Mine until the timelock expires and try again.
+	for {
+		height, err := testNode.BlockHeight()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height >= timelock {
+			break
+		}
+		if err := testNode.MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := testNode.TransactionPoolRawPost(spend(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -92,3 +92,16 @@ func (r *ExchangeRate) ApplyAndFormat(c Currency) string {
 	result = fmt.Sprintf("~ %s %s", result, r.staticSymbol)
 	return result
 }
+
+// ApplyInverse converts an amount in the currency of the exchange rate into
+// hastings, e.g. the number of hastings which are worth 5 USD. Assumes that
+// amount cannot be negative.
+func (r *ExchangeRate) ApplyInverse(amount float64) Currency {
+	asRatio, _ := r.staticValue.Rat(nil)
+	amountRat := new(big.Rat).SetFloat64(amount)
+	precisionRat := new(big.Rat).SetInt(SiacoinPrecision.Big())
+
+	// calculate (amountRat * precisionRat) / asRatio
+	resultRat := new(big.Rat).Quo(new(big.Rat).Mul(amountRat, precisionRat), asRatio)
+	return NewCurrency(new(big.Int).Quo(resultRat.Num(), resultRat.Denom()))
+}
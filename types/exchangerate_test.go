@@ -122,3 +122,35 @@ func TestApplyAndFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestApplyInverse checks that amounts are correctly converted into hastings.
+func TestApplyInverse(t *testing.T) {
+	mustParse := func(s string) *ExchangeRate {
+		rate, err := ParseExchangeRate(s)
+		if err != nil {
+			t.Fatalf("test case uses invalid exchange rate: %v", err)
+		}
+
+		return rate
+	}
+	tests := []struct {
+		rate   *ExchangeRate
+		amount float64
+		c      Currency
+	}{
+		{mustParse("1 USD"), 1, SiacoinPrecision},
+		{mustParse("1 USD"), 5, SiacoinPrecision.Mul64(5)},
+		{mustParse("1 USD"), 0, ZeroCurrency},
+		{mustParse("0.5 USD"), 1, SiacoinPrecision.Mul64(2)},
+		{mustParse("4 EUR"), 1, SiacoinPrecision.Div64(4)},
+		{mustParse("0.25 USD"), 2.5, SiacoinPrecision.Mul64(10)},
+	}
+	for _, test := range tests {
+		c := test.rate.ApplyInverse(test.amount)
+
+		if !c.Equals(test.c) {
+			t.Errorf("TestApplyInverse with %v %v: expected %v, got %v",
+				test.rate.staticValue, test.rate.staticSymbol, test.c, c)
+		}
+	}
+}